@@ -18,6 +18,81 @@ type Background interface {
 	WithShadow(shadow Shadow) Background
 }
 
+// Measurer is implemented by backgrounds that can report the size of their image
+// without rendering it
+type Measurer interface {
+	// Measure returns the size of the image Render returns for content of the given
+	// size. A size of zero measures like Render(nil).
+	Measure(contentWidth, contentHeight int) (width, height int)
+}
+
+// Placer is implemented by backgrounds that can report where they place the content
+// without rendering it
+type Placer interface {
+	// Place returns where Render puts content of the given size in its image
+	Place(contentWidth, contentHeight int) image.Rectangle
+}
+
+// ContentRounder is implemented by backgrounds whose shadow and border can follow the
+// rounded corners of the content they're placed around, like the corners of the
+// window chrome. The canvas passes the corner radius on when it knows it.
+type ContentRounder interface {
+	// WithContentCornerRadius sets the corner radius of the content
+	WithContentCornerRadius(radius float64) Background
+}
+
+// Scaler is implemented by backgrounds that can be drawn larger, for high density
+// displays
+type Scaler interface {
+	// Scaled returns a copy of the background with its padding, corner radius, shadow,
+	// border and every other dimension multiplied by factor
+	Scaled(factor float64) Background
+}
+
+// Padder is implemented by backgrounds whose padding can be grown, for bringing the
+// image to a size
+type Padder interface {
+	// Padded returns a copy of the background with the given amounts added to its
+	// padding on each side
+	Padded(top, right, bottom, left int) Background
+}
+
+// cardRadius returns the corner radius of the content a background is placed around:
+// the one passed on by WithContentCornerRadius, or else the background's own
+func cardRadius(contentRadius *float64, cornerRadius float64) float64 {
+	if contentRadius != nil {
+		return *contentRadius
+	}
+	return cornerRadius
+}
+
+// fitShadow gives the shadow the corner radius of the content, if it's known
+func fitShadow(shadow Shadow, contentRadius *float64) {
+	if s, ok := shadow.(*shadowImpl); ok && contentRadius != nil {
+		s.cornerRadius = *contentRadius
+	}
+}
+
+// measure returns the size of a background that places the content, grown by its
+// shadow, inside the padding, the way every background here does
+func measure(contentWidth, contentHeight int, padding Padding, shadow Shadow) (width, height int) {
+	if contentWidth == 0 && contentHeight == 0 {
+		contentWidth = padding.Left + padding.Right
+		contentHeight = padding.Top + padding.Bottom
+	}
+	if s, ok := shadow.(*shadowImpl); ok {
+		contentWidth += s.expandBy() * 2
+		contentHeight += s.expandBy() * 2
+	}
+	return contentWidth + padding.Left + padding.Right, contentHeight + padding.Top + padding.Bottom
+}
+
+// place returns where a background measured by measure puts the content
+func place(contentWidth, contentHeight int, padding Padding, shadow Shadow) image.Rectangle {
+	width, height := measure(contentWidth, contentHeight, padding, shadow)
+	return cardRect(width, height, padding, shadow)
+}
+
 var (
 	// DarkColor is the default dark mode background color
 	DarkColor = color.RGBA{R: 30, G: 30, B: 30, A: 255}
@@ -0,0 +1,41 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlace(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 60, 30))
+	draw.Draw(content, content.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	shadow := NewShadow().WithOffset(4, 6).WithBlur(5)
+	tests := []struct {
+		name string
+		bg   Background
+	}{
+		{"color", NewColorBackground().WithPaddingDetailed(10, 20, 30, 40)},
+		{"color with shadow", NewColorBackground().WithPadding(12).WithShadow(shadow)},
+		{"pattern with shadow", NewPatternBackground(PatternDots).WithPadding(8).WithShadow(shadow)},
+		{"layered", NewLayeredBackground(NewColorBackground().WithPadding(30), NewColorBackground().WithPaddingDetailed(5, 0, 15, 0)).WithPadding(7).WithShadow(shadow)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := tt.bg.Render(content)
+			require.NoError(t, err)
+			rect := tt.bg.(Placer).Place(60, 30)
+
+			assert.Equal(t, image.Pt(60, 30), rect.Size())
+			assert.Equal(t, red, img.At(rect.Min.X, rect.Min.Y))
+			assert.Equal(t, red, img.At(rect.Max.X-1, rect.Max.Y-1))
+			assert.NotEqual(t, red, img.At(rect.Min.X-1, rect.Min.Y-1))
+			assert.NotEqual(t, red, img.At(rect.Max.X, rect.Max.Y))
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package background
 
+import (
+	"image"
+	"math"
+)
+
 // BlurType represents the type of blur algorithm to use
 type BlurType int
 
@@ -15,3 +20,217 @@ type BlurConfig struct {
 	Type   BlurType
 	Radius float64
 }
+
+const (
+	// blurPasses is how many box blurs approximate a gaussian blur. Three come within
+	// a few percent of a true gaussian.
+	blurPasses = 3
+
+	// minBoxSigma is the smallest standard deviation approximated with box blurs.
+	// Narrower blurs take so few pixels in that the gaussian itself is cheap, while
+	// boxes only a pixel or three wide are a poor match for it.
+	minBoxSigma = 2
+)
+
+// apply returns img blurred as configured. The result may be img itself.
+func (b *BlurConfig) apply(img *image.RGBA) *image.RGBA {
+	if b == nil || b.Radius <= 0 {
+		return img
+	}
+	switch b.Type {
+	case PixelatedBlur:
+		// Larger radii make larger blocks
+		return pixelate(img, int(math.Max(1, b.Radius)))
+	default:
+		return gaussianBlur(img, b.Radius)
+	}
+}
+
+// gaussianBlur returns img blurred by a gaussian of standard deviation sigma. Larger
+// blurs are approximated by successive box blurs, which take the same time whatever
+// the sigma. The rows are blurred, then the columns, by transposing the image so that
+// they're blurred as rows too, which keeps to the memory cache. The pixels past the
+// edges are taken to be copies of the edge pixels. Colors are premultiplied, so
+// transparent pixels don't darken the rest.
+func gaussianBlur(img *image.RGBA, sigma float64) *image.RGBA {
+	bounds := img.Bounds()
+	if sigma <= 0 || bounds.Empty() {
+		return img
+	}
+
+	w, h := bounds.Dx(), bounds.Dy()
+	pix := make([]uint8, w*h*4)
+	for y := 0; y < h; y++ {
+		i := img.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+		copy(pix[y*w*4:(y+1)*w*4], img.Pix[i:i+w*4])
+	}
+	tmp := make([]uint8, len(pix))
+
+	blurRows := func(pix, tmp []uint8, w, h int) {
+		if sigma < minBoxSigma {
+			convolveRows(tmp, pix, w, h, gaussianKernel(sigma))
+			copy(pix, tmp)
+			return
+		}
+		for _, size := range boxSizes(sigma, blurPasses) {
+			boxBlurRows(tmp, pix, w, h, (size-1)/2)
+			pix, tmp = tmp, pix
+		}
+		// An odd number of passes leaves the result in the other buffer
+		if blurPasses%2 == 1 {
+			copy(tmp, pix)
+		}
+	}
+	blurRows(pix, tmp, w, h)
+	transpose(tmp, pix, w, h)
+	blurRows(tmp, pix, h, w)
+	transpose(pix, tmp, h, w)
+
+	return &image.RGBA{Pix: pix, Stride: w * 4, Rect: bounds}
+}
+
+// boxSizes returns the widths of the n box blurs that together come closest to a
+// gaussian blur of standard deviation sigma, each of an odd width
+func boxSizes(sigma float64, n int) []int {
+	ideal := math.Sqrt(12*sigma*sigma/float64(n) + 1)
+	lower := int(math.Floor(ideal))
+	if lower%2 == 0 {
+		lower--
+	}
+	upper := lower + 2
+
+	// The first m boxes are the narrower ones
+	m := int(math.Round((12*sigma*sigma - float64(n*lower*lower) - float64(4*n*lower) - float64(3*n)) / float64(-4*lower-4)))
+	sizes := make([]int, n)
+	for i := range sizes {
+		sizes[i] = upper
+		if i < m {
+			sizes[i] = lower
+		}
+	}
+	return sizes
+}
+
+// boxBlurRows averages each pixel of the rows of src, an image w pixels wide and h
+// high, with the r pixels to either side of it, writing the result to dst
+func boxBlurRows(dst, src []uint8, w, h, r int) {
+	n := uint64(2*r + 1)
+	inv := (1<<32 + n - 1) / n // Dividing by n is multiplying by inv and shifting
+	last := (w - 1) * 4
+	for y := 0; y < h; y++ {
+		row, out := src[y*w*4:(y+1)*w*4], dst[y*w*4:(y+1)*w*4]
+
+		// The window starts centered on the first pixel, with the pixels before it
+		// being copies of it
+		var sum [4]uint64
+		for c := range sum {
+			sum[c] = uint64(r+1) * uint64(row[c])
+		}
+		for i := 1; i <= r; i++ {
+			j := min(i*4, last)
+			for c := range sum {
+				sum[c] += uint64(row[j+c])
+			}
+		}
+
+		for x := 0; x < w; x++ {
+			o := x * 4
+			add, sub := min(o+(r+1)*4, last), max(o-r*4, 0)
+			for c := range sum {
+				out[o+c] = uint8((sum[c]*inv + 1<<31) >> 32)
+				sum[c] += uint64(row[add+c]) - uint64(row[sub+c])
+			}
+		}
+	}
+}
+
+// gaussianKernel returns the weights of a gaussian of standard deviation sigma out to
+// three deviations either side, in 16.16 fixed point, adding up to one
+func gaussianKernel(sigma float64) []uint32 {
+	r := int(math.Ceil(3 * sigma))
+	weights := make([]float64, 2*r+1)
+	sum := 0.0
+	for i := range weights {
+		x := float64(i - r)
+		weights[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += weights[i]
+	}
+
+	kernel := make([]uint32, len(weights))
+	var total uint32
+	for i, w := range weights {
+		kernel[i] = uint32(math.Round(w / sum * (1 << 16)))
+		total += kernel[i]
+	}
+	// Rounding leaves the sum a little off, which the center makes up
+	kernel[r] += 1<<16 - total
+	return kernel
+}
+
+// convolveRows applies the kernel, centered on each pixel, along the rows of src, an
+// image w pixels wide and h high, writing the result to dst
+func convolveRows(dst, src []uint8, w, h int, kernel []uint32) {
+	r := len(kernel) / 2
+	for y := 0; y < h; y++ {
+		row, out := src[y*w*4:(y+1)*w*4], dst[y*w*4:(y+1)*w*4]
+		for x := 0; x < w; x++ {
+			var sum [4]uint32
+			for k, weight := range kernel {
+				j := min(max(x+k-r, 0), w-1) * 4
+				for c := range sum {
+					sum[c] += weight * uint32(row[j+c])
+				}
+			}
+			for c := range sum {
+				out[x*4+c] = uint8((sum[c] + 1<<15) >> 16)
+			}
+		}
+	}
+}
+
+// transpose writes src, an image w pixels wide and h high, to dst with its rows and
+// columns swapped
+func transpose(dst, src []uint8, w, h int) {
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			copy(dst[(x*h+y)*4:(x*h+y)*4+4], src[(y*w+x)*4:(y*w+x)*4+4])
+		}
+	}
+}
+
+// pixelate returns img divided into blocks of size pixels, each filled with the
+// average of its pixels, like scaling the image down and back up again
+func pixelate(img *image.RGBA, size int) *image.RGBA {
+	bounds := img.Bounds()
+	result := image.NewRGBA(bounds)
+	for y0 := bounds.Min.Y; y0 < bounds.Max.Y; y0 += size {
+		for x0 := bounds.Min.X; x0 < bounds.Max.X; x0 += size {
+			block := image.Rect(x0, y0, x0+size, y0+size).Intersect(bounds)
+
+			var sum [4]uint32
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				i := img.PixOffset(block.Min.X, y)
+				for x := block.Min.X; x < block.Max.X; x++ {
+					for c := range sum {
+						sum[c] += uint32(img.Pix[i+c])
+					}
+					i += 4
+				}
+			}
+			count := uint32(block.Dx() * block.Dy())
+			var avg [4]uint8
+			for c := range avg {
+				avg[c] = uint8((sum[c] + count/2) / count)
+			}
+
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				i := result.PixOffset(block.Min.X, y)
+				for x := block.Min.X; x < block.Max.X; x++ {
+					copy(result.Pix[i:i+4], avg[:])
+					i += 4
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// blurTestImage returns an opaque image with hard edges and stripes, the hardest for
+// an approximated blur to match
+func blurTestImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), A: 255}
+			if (x/16+y/16)%2 == 0 {
+				c.B = 255
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestGaussianBlur(t *testing.T) {
+	img := blurTestImage(160, 120)
+	for _, sigma := range []float64{1, 3, 8} {
+		got := gaussianBlur(img, sigma)
+		want := imaging.Blur(img, sigma)
+		assert.Equal(t, img.Bounds(), got.Bounds())
+
+		// Larger blurs are approximated, so they're close but not exact. The edges differ
+		// more, as the pixels past them are taken to be copies of the edge pixels rather
+		// than left out.
+		margin := int(3 * sigma)
+		interior := img.Bounds().Inset(margin)
+		var total, worst float64
+		for i := range got.Pix {
+			diff := math.Abs(float64(got.Pix[i]) - float64(want.Pix[i]))
+			total += diff
+			if image.Pt(i%got.Stride/4, i/got.Stride).In(interior) {
+				worst = math.Max(worst, diff)
+			}
+		}
+		assert.Less(t, total/float64(len(got.Pix)), 1.0, "Mean difference at sigma %v", sigma)
+		assert.LessOrEqual(t, worst, 6.0, "Largest difference at sigma %v", sigma)
+	}
+
+	// Transparent pixels don't darken the opaque ones next to them
+	clear := image.NewRGBA(image.Rect(0, 0, 20, 1))
+	for x := 0; x < 10; x++ {
+		clear.SetRGBA(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	c := gaussianBlur(clear, 2).RGBAAt(10, 0)
+	assert.Equal(t, c.R, c.A, "Colors stay premultiplied")
+
+	// Images not at the origin are blurred in place
+	sub := img.SubImage(image.Rect(40, 30, 100, 90)).(*image.RGBA)
+	assert.Equal(t, sub.Bounds(), gaussianBlur(sub, 2).Bounds())
+}
+
+func TestPixelate(t *testing.T) {
+	img := blurTestImage(50, 30)
+	got := pixelate(img, 8)
+	assert.Equal(t, img.Bounds(), got.Bounds())
+	assert.Equal(t, got.RGBAAt(0, 0), got.RGBAAt(7, 7), "A block has a single color")
+	assert.NotEqual(t, got.RGBAAt(7, 7), got.RGBAAt(8, 7))
+	assert.Equal(t, got.RGBAAt(48, 24), got.RGBAAt(49, 29), "Blocks at the edges are cut short")
+}
+
+func BenchmarkGaussianBlur(b *testing.B) {
+	img := blurTestImage(2560, 1440)
+	b.Run("box", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			gaussianBlur(img, 8)
+		}
+	})
+	// The blur backgrounds used before, for comparison
+	b.Run("imaging", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			imaging.Blur(img, 8)
+		}
+	})
+}
+
+func BenchmarkPixelate(b *testing.B) {
+	img := blurTestImage(2560, 1440)
+	b.Run("blocks", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pixelate(img, 8)
+		}
+	})
+	b.Run("imaging", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			small := imaging.Resize(img, 2560/8, 1440/8, imaging.Box)
+			imaging.Resize(small, 2560, 1440, imaging.NearestNeighbor)
+		}
+	})
+}
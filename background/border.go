@@ -0,0 +1,67 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/watzon/goshot/svg"
+)
+
+// border is a stroke around the content, drawn in the padding of a background
+type border struct {
+	width int
+	color color.Color
+}
+
+// newBorder returns the border of the given width and color, or nil if it wouldn't
+// show
+func newBorder(width int, col color.Color) *border {
+	if width <= 0 || col == nil {
+		return nil
+	}
+	return &border{width: width, color: col}
+}
+
+// cardRect returns where a background of the given size places the content itself,
+// inside the padding and the room taken by its shadow
+func cardRect(width, height int, padding Padding, shadow Shadow) image.Rectangle {
+	rect := image.Rect(padding.Left, padding.Top, width-padding.Right, height-padding.Bottom)
+	if s, ok := shadow.(*shadowImpl); ok {
+		rect = rect.Inset(s.expandBy())
+	}
+	return rect
+}
+
+// draw strokes the border just outside of the card, following its corner radius
+func (b *border) draw(img draw.Image, card image.Rectangle, radius float64) {
+	if b == nil || card.Empty() {
+		return
+	}
+
+	outer := card.Inset(-b.width)
+	outerMask := image.NewAlpha(outer)
+	drawRoundedRect(outerMask, outer, color.Alpha{A: 255}, radius+float64(b.width))
+	innerMask := image.NewAlpha(card)
+	drawRoundedRect(innerMask, card, color.Alpha{A: 255}, radius)
+
+	// The ring is whatever the outer shape covers and the card doesn't
+	for y := card.Min.Y; y < card.Max.Y; y++ {
+		for x := card.Min.X; x < card.Max.X; x++ {
+			if innerMask.AlphaAt(x, y).A > 0 {
+				outerMask.SetAlpha(x, y, color.Alpha{})
+			}
+		}
+	}
+	draw.DrawMask(img, outer, image.NewUniform(b.color), image.Point{}, outerMask, outer.Min, draw.Over)
+}
+
+// svg returns the border around the card as a stroked rectangle
+func (b *border) svg(card image.Rectangle, radius float64) string {
+	if b == nil || card.Empty() {
+		return ""
+	}
+	w := float64(b.width)
+	return svg.RoundedRect(float64(card.Min.X)-w/2, float64(card.Min.Y)-w/2, float64(card.Dx())+w, float64(card.Dy())+w,
+		radius+w/2, ` fill="none"`+svg.Paint("stroke", b.color)+` stroke-width="`+svg.Number(w)+`"`)
+}
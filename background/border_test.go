@@ -0,0 +1,46 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/svg"
+)
+
+func TestBorder(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(content, content.Bounds(), image.NewUniform(green), image.Point{}, draw.Src)
+
+	bg := NewColorBackground().WithColor(color.White).WithPadding(10).WithBorder(2, red)
+	img, err := bg.Render(content)
+	require.NoError(t, err)
+
+	// The border hugs the content from the outside, without covering it
+	assert.Equal(t, red, img.At(8, 15))
+	assert.Equal(t, red, img.At(15, 31))
+	assert.Equal(t, green, img.At(10, 15))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, img.At(7, 15))
+
+	t.Run("with shadow", func(t *testing.T) {
+		shadow := NewShadow().WithOffset(0, 0).WithBlur(4)
+		img, err := bg.WithShadow(shadow).Render(content)
+		require.NoError(t, err)
+		// The shadow grows the content by 4 pixels on each side, and darkens the border
+		r, g, _, _ := img.At(12, 19).RGBA()
+		assert.Greater(t, r>>8, uint32(200))
+		assert.Zero(t, g)
+		assert.Equal(t, green, img.At(14, 19))
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		f, err := bg.RenderSVG(&svg.Fragment{Width: 20, Height: 20})
+		require.NoError(t, err)
+		assert.Contains(t, f.Body, `<rect x="9" y="9" width="22" height="22" rx="1" fill="none" stroke="#ff0000" stroke-width="2"/>`)
+	})
+}
@@ -5,14 +5,21 @@ import (
 	"image/color"
 	"image/draw"
 	"math"
+
+	"github.com/disintegration/imaging"
 )
 
 // ColorBackground represents a solid color background
 type ColorBackground struct {
-	color        color.Color
-	padding      Padding
-	cornerRadius float64
-	shadow       Shadow
+	color         color.Color
+	padding       Padding
+	cornerRadius  float64
+	shadow        Shadow
+	contentRadius *float64 // Corner radius of the content, when the canvas knows it
+	border        *border
+	reflection    *reflection
+	centerImage   image.Image
+	centerScale   float64
 }
 
 // NewColorBackground creates a new ColorBackground with the given color
@@ -31,7 +38,17 @@ func (bg ColorBackground) WithColor(c color.Color) ColorBackground {
 	return bg
 }
 
-// WithPadding sets equal padding for all sides
+// WithCenterImage places an image (like a logo) centered on the background, behind
+// the content. The image is scaled to fit within the given fraction of the background
+// size while keeping its aspect ratio, so a scale of 0.5 covers at most half the width
+// and half the height.
+func (bg ColorBackground) WithCenterImage(img image.Image, scale float64) ColorBackground {
+	bg.centerImage = img
+	bg.centerScale = scale
+	return bg
+}
+
+// WithPadding sets equal padding for all sides, a shortcut for WithPaddingDetailed
 func (bg ColorBackground) WithPadding(value int) ColorBackground {
 	bg.padding = NewPadding(value)
 	return bg
@@ -60,6 +77,37 @@ func (bg ColorBackground) WithShadow(shadow Shadow) Background {
 	return bg
 }
 
+// WithContentCornerRadius implements the ContentRounder interface
+func (bg ColorBackground) WithContentCornerRadius(radius float64) Background {
+	bg.contentRadius = &radius
+	return bg
+}
+
+// WithBorder draws a border of the given width around the content, in the padding
+// and following the corner radius
+func (bg ColorBackground) WithBorder(width int, col color.Color) ColorBackground {
+	bg.border = newBorder(width, col)
+	return bg
+}
+
+// WithReflection draws a mirror image of the content right below it, height pixels
+// tall and fading from opacity (0.0 - 1.0) to nothing. It's only drawn in the room
+// below the content, so it's cut short to fit the bottom padding.
+func (bg ColorBackground) WithReflection(height int, opacity float64) ColorBackground {
+	bg.reflection = newReflection(height, opacity)
+	return bg
+}
+
+// Measure implements the Measurer interface
+func (bg ColorBackground) Measure(contentWidth, contentHeight int) (width, height int) {
+	return measure(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// Place implements the Placer interface
+func (bg ColorBackground) Place(contentWidth, contentHeight int) image.Rectangle {
+	return place(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
 // drawRoundedRect draws a rounded rectangle on the destination image
 func drawRoundedRect(dst draw.Image, r image.Rectangle, col color.Color, radius float64) {
 	// Create a mask image for the rounded corners
@@ -118,10 +166,13 @@ func (bg ColorBackground) Render(content image.Image) (image.Image, error) {
 
 	bounds := content.Bounds()
 
+	// The reflection is of the content without its shadow
+	reflected := content
+
 	// If shadow is configured, apply it to the content first
 	if bg.shadow != nil {
 		// With the shadow's corner radius to match the background
-		bg.shadow.(*shadowImpl).cornerRadius = bg.cornerRadius
+		bg.shadow.(*shadowImpl).cornerRadius = cardRadius(bg.contentRadius, bg.cornerRadius)
 		content = bg.shadow.Apply(content)
 		bounds = content.Bounds() // Update bounds to include shadow
 	}
@@ -140,6 +191,15 @@ func (bg ColorBackground) Render(content image.Image) (image.Image, error) {
 		draw.Draw(img, img.Bounds(), &image.Uniform{bg.color}, image.Point{}, draw.Src)
 	}
 
+	// Draw the center image between the background color and the content
+	if bg.centerImage != nil && bg.centerScale > 0 {
+		bg.drawCenterImage(img)
+	}
+
+	// The border goes between the background and the content
+	bg.border.draw(img, cardRect(width, height, bg.padding, bg.shadow), cardRadius(bg.contentRadius, bg.cornerRadius))
+	bg.reflection.draw(img, cardRect(width, height, bg.padding, bg.shadow), reflected)
+
 	// Draw the content with shadow in the center (accounting for padding)
 	contentRect := image.Rect(
 		bg.padding.Left,
@@ -151,3 +211,33 @@ func (bg ColorBackground) Render(content image.Image) (image.Image, error) {
 
 	return img, nil
 }
+
+// drawCenterImage draws the scaled center image in the middle of the background,
+// clipped to the background's rounded corners
+func (bg ColorBackground) drawCenterImage(img *image.RGBA) {
+	bounds := img.Bounds()
+	maxWidth := int(float64(bounds.Dx()) * bg.centerScale)
+	maxHeight := int(float64(bounds.Dy()) * bg.centerScale)
+	if maxWidth <= 0 || maxHeight <= 0 {
+		return
+	}
+
+	// Scale up or down to fit, keeping the aspect ratio
+	src := bg.centerImage.Bounds()
+	if src.Empty() {
+		return
+	}
+	ratio := math.Min(float64(maxWidth)/float64(src.Dx()), float64(maxHeight)/float64(src.Dy()))
+	scaled := imaging.Resize(bg.centerImage, max(1, int(float64(src.Dx())*ratio)), max(1, int(float64(src.Dy())*ratio)), imaging.Lanczos)
+	size := scaled.Bounds().Size()
+	offset := image.Pt((bounds.Dx()-size.X)/2, (bounds.Dy()-size.Y)/2)
+	rect := image.Rectangle{Min: offset, Max: offset.Add(size)}
+
+	if bg.cornerRadius > 0 {
+		mask := image.NewAlpha(bounds)
+		drawRoundedRect(mask, bounds, color.White, bg.cornerRadius)
+		draw.DrawMask(img, rect, scaled, scaled.Bounds().Min, mask, rect.Min, draw.Over)
+	} else {
+		draw.Draw(img, rect, scaled, scaled.Bounds().Min, draw.Over)
+	}
+}
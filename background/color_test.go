@@ -0,0 +1,66 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorBackgroundCenterImage(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	logo := func(width, height int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+		return img
+	}
+	// The content is see-through, so the image shows in the middle of the 200x200 background
+	content := image.NewRGBA(image.Rect(0, 0, 100, 100))
+
+	tests := []struct {
+		name  string
+		logo  image.Image
+		scale float64
+		want  image.Rectangle // Where the image is drawn, or nothing
+	}{
+		{name: "tall", logo: logo(10, 20), scale: 0.5, want: image.Rect(75, 50, 125, 150)},
+		{name: "wide", logo: logo(40, 10), scale: 0.5, want: image.Rect(50, 87, 150, 112)},
+		{name: "scaled down", logo: logo(1000, 1000), scale: 0.25, want: image.Rect(75, 75, 125, 125)},
+		{name: "zero scale", logo: logo(10, 10), scale: 0},
+		{name: "empty image", logo: image.NewRGBA(image.Rectangle{}), scale: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bg := NewColorBackground().WithColor(white).WithPadding(50).WithCenterImage(tt.logo, tt.scale)
+			img, err := bg.Render(content)
+			require.NoError(t, err)
+			require.Equal(t, image.Rect(0, 0, 200, 200), img.Bounds())
+
+			for y := 0; y < 200; y++ {
+				for x := 0; x < 200; x++ {
+					want := white
+					if image.Pt(x, y).In(tt.want) {
+						want = red
+					}
+					if !assert.Equal(t, want, img.At(x, y), "pixel %d,%d", x, y) {
+						return
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("clipped to the rounded corners", func(t *testing.T) {
+		bg := NewColorBackground().WithColor(white).WithPadding(50).WithCenterImage(logo(10, 10), 1).WithCornerRadius(40)
+		img, err := bg.Render(content)
+		require.NoError(t, err)
+		assert.Equal(t, red, img.At(100, 100))
+		assert.Equal(t, red, img.At(100, 0))
+		_, _, _, a := img.At(0, 0).RGBA()
+		assert.Zero(t, a)
+	})
+}
@@ -5,8 +5,6 @@ import (
 	"image/color"
 	"image/draw"
 	"math"
-
-	"github.com/disintegration/imaging"
 )
 
 // GradientType represents the type of gradient
@@ -37,16 +35,20 @@ type GradientStop struct {
 
 // GradientBackground represents a gradient background
 type GradientBackground struct {
-	gradientType GradientType
-	stops        []GradientStop
-	angle        float64 // Angle in degrees for linear/angular gradients
-	centerX      float64 // Center X position for radial/angular gradients (0-1)
-	centerY      float64 // Center Y position for radial/angular gradients (0-1)
-	intensity    float64 // Intensity modifier for special gradients (spiral tightness, star points)
-	blur         *BlurConfig
-	padding      Padding
-	cornerRadius float64
-	shadow       Shadow
+	gradientType  GradientType
+	stops         []GradientStop
+	angle         float64 // Angle in degrees for linear/angular gradients
+	centerX       float64 // Center X position for radial/angular gradients (0-1)
+	centerY       float64 // Center Y position for radial/angular gradients (0-1)
+	intensity     float64 // Intensity modifier for special gradients (spiral tightness, star points)
+	blur          *BlurConfig
+	vignette      float64 // How much the edges are darkened, from 0 to 1
+	padding       Padding
+	cornerRadius  float64
+	shadow        Shadow
+	contentRadius *float64 // Corner radius of the content, when the canvas knows it
+	border        *border
+	reflection    *reflection
 }
 
 // NewGradientBackground creates a new GradientBackground
@@ -93,7 +95,14 @@ func (bg GradientBackground) WithBlur(blurType BlurType, radius float64) Gradien
 	return bg
 }
 
-// WithPadding sets equal padding for all sides
+// WithVignette darkens the gradient toward its edges, from not at all with an
+// intensity of 0 to black in the corners with 1
+func (bg GradientBackground) WithVignette(intensity float64) GradientBackground {
+	bg.vignette = intensity
+	return bg
+}
+
+// WithPadding sets equal padding for all sides, a shortcut for WithPaddingDetailed
 func (bg GradientBackground) WithPadding(value int) GradientBackground {
 	bg.padding = NewPadding(value)
 	return bg
@@ -122,6 +131,37 @@ func (bg GradientBackground) WithShadow(shadow Shadow) Background {
 	return bg
 }
 
+// WithContentCornerRadius implements the ContentRounder interface
+func (bg GradientBackground) WithContentCornerRadius(radius float64) Background {
+	bg.contentRadius = &radius
+	return bg
+}
+
+// WithBorder draws a border of the given width around the content, in the padding
+// and following the corner radius
+func (bg GradientBackground) WithBorder(width int, col color.Color) GradientBackground {
+	bg.border = newBorder(width, col)
+	return bg
+}
+
+// WithReflection draws a mirror image of the content right below it, height pixels
+// tall and fading from opacity (0.0 - 1.0) to nothing. It's only drawn in the room
+// below the content, so it's cut short to fit the bottom padding.
+func (bg GradientBackground) WithReflection(height int, opacity float64) GradientBackground {
+	bg.reflection = newReflection(height, opacity)
+	return bg
+}
+
+// Measure implements the Measurer interface
+func (bg GradientBackground) Measure(contentWidth, contentHeight int) (width, height int) {
+	return measure(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// Place implements the Placer interface
+func (bg GradientBackground) Place(contentWidth, contentHeight int) image.Rectangle {
+	return place(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
 // interpolateColor interpolates between two colors based on t (0 to 1)
 func interpolateColor(c1, c2 color.Color, t float64) color.Color {
 	r1, g1, b1, a1 := c1.RGBA()
@@ -170,6 +210,7 @@ func (bg GradientBackground) Render(content image.Image) (image.Image, error) {
 	// Create a new image for the content with shadow
 	contentWithShadow := content
 	if bg.shadow != nil {
+		fitShadow(bg.shadow, bg.contentRadius)
 		contentWithShadow = bg.shadow.Apply(content)
 	}
 
@@ -270,38 +311,13 @@ func (bg GradientBackground) Render(content image.Image) (image.Image, error) {
 	}
 
 	// Apply blur if configured
-	if bg.blur != nil {
-		// Convert RGBA to NRGBA for imaging operations
-		nrgba := imaging.Clone(gradientImg)
-
-		switch bg.blur.Type {
-		case GaussianBlur:
-			nrgba = imaging.Blur(nrgba, bg.blur.Radius)
-		case PixelatedBlur:
-			// Create pixelated effect by scaling down and back up
-			w := nrgba.Bounds().Dx()
-			h := nrgba.Bounds().Dy()
-			// Scale factor based on radius (larger radius = more pixelation)
-			factor := math.Max(1, bg.blur.Radius)
-			smallW := int(float64(w) / factor)
-			smallH := int(float64(h) / factor)
-			if smallW < 1 {
-				smallW = 1
-			}
-			if smallH < 1 {
-				smallH = 1
-			}
-			// Scale down
-			small := imaging.Resize(nrgba, smallW, smallH, imaging.Box)
-			// Scale back up
-			nrgba = imaging.Resize(small, w, h, imaging.NearestNeighbor)
-		}
+	gradientImg = bg.blur.apply(gradientImg)
 
-		// Convert back to RGBA
-		result := image.NewRGBA(gradientImg.Bounds())
-		draw.Draw(result, result.Bounds(), nrgba, image.Point{}, draw.Src)
-		gradientImg = result
-	}
+	applyVignette(gradientImg, bg.vignette)
+
+	// The border goes between the background and the content
+	bg.border.draw(gradientImg, cardRect(width, height, bg.padding, bg.shadow), cardRadius(bg.contentRadius, bg.cornerRadius))
+	bg.reflection.draw(gradientImg, cardRect(width, height, bg.padding, bg.shadow), content)
 
 	// Draw the content (with shadow) centered on the background
 	contentPos := image.Point{
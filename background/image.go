@@ -1,10 +1,17 @@
 package background
 
 import (
+	"bufio"
+	"context"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"math"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 )
@@ -27,13 +34,17 @@ const (
 
 // ImageBackground represents an image background
 type ImageBackground struct {
-	image        image.Image
-	scaleMode    ImageScaleMode
-	blur         *BlurConfig
-	opacity      float64
-	padding      Padding
-	cornerRadius float64
-	shadow       Shadow
+	image         image.Image
+	scaleMode     ImageScaleMode
+	blur          *BlurConfig
+	vignette      float64 // How much the edges are darkened, from 0 to 1
+	opacity       float64
+	padding       Padding
+	cornerRadius  float64
+	shadow        Shadow
+	contentRadius *float64 // Corner radius of the content, when the canvas knows it
+	border        *border
+	reflection    *reflection
 }
 
 // NewImageBackground creates a new ImageBackground
@@ -58,6 +69,58 @@ func NewImageBackgroundFromFile(path string) (ImageBackground, error) {
 	return NewImageBackground(img), nil
 }
 
+// URLTimeout is how long NewImageBackgroundFromURL waits for an image to download
+var URLTimeout = 30 * time.Second
+
+// NewImageBackgroundFromURL creates a new ImageBackground from an image fetched over
+// HTTP(S), giving up after URLTimeout
+func NewImageBackgroundFromURL(url string) (ImageBackground, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), URLTimeout)
+	defer cancel()
+	return NewImageBackgroundFromURLContext(ctx, url)
+}
+
+// NewImageBackgroundFromURLContext creates a new ImageBackground from an image fetched
+// over HTTP(S). The download is abandoned if ctx is done first.
+func NewImageBackgroundFromURLContext(ctx context.Context, url string) (ImageBackground, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ImageBackground{}, fmt.Errorf("invalid background image URL: %v", err)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ImageBackground{}, fmt.Errorf("failed to fetch background image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ImageBackground{}, fmt.Errorf("failed to fetch background image: %s", resp.Status)
+	}
+
+	// Servers often label images as generic binary data, so when the content type
+	// isn't an image the body gets a second look before giving up
+	body := bufio.NewReader(resp.Body)
+	if !isImageType(resp.Header.Get("Content-Type")) {
+		head, _ := body.Peek(512)
+		if sniffed := http.DetectContentType(head); !isImageType(sniffed) {
+			return ImageBackground{}, fmt.Errorf("background image URL returned %s, not an image", sniffed)
+		}
+	}
+
+	img, err := imaging.Decode(body)
+	if err != nil {
+		return ImageBackground{}, fmt.Errorf("failed to decode background image: %v", err)
+	}
+	return NewImageBackground(img), nil
+}
+
+// isImageType reports whether a MIME type is that of an image
+func isImageType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.HasPrefix(mediaType, "image/")
+}
+
 // WithScaleMode sets the scaling mode for the image
 func (bg ImageBackground) WithScaleMode(mode ImageScaleMode) ImageBackground {
 	bg.scaleMode = mode
@@ -90,13 +153,20 @@ func (bg ImageBackground) WithBlur(blurType BlurType, radius float64) ImageBackg
 	return bg
 }
 
+// WithVignette darkens the image toward its edges, from not at all with an intensity
+// of 0 to black in the corners with 1
+func (bg ImageBackground) WithVignette(intensity float64) ImageBackground {
+	bg.vignette = intensity
+	return bg
+}
+
 // WithOpacity sets the opacity of the background image (0.0 - 1.0)
 func (bg ImageBackground) WithOpacity(opacity float64) ImageBackground {
 	bg.opacity = math.Max(0, math.Min(1, opacity))
 	return bg
 }
 
-// WithPadding sets equal padding for all sides
+// WithPadding sets equal padding for all sides, a shortcut for WithPaddingDetailed
 func (bg ImageBackground) WithPadding(value int) ImageBackground {
 	bg.padding = NewPadding(value)
 	return bg
@@ -125,6 +195,37 @@ func (bg ImageBackground) WithShadow(shadow Shadow) Background {
 	return bg
 }
 
+// WithContentCornerRadius implements the ContentRounder interface
+func (bg ImageBackground) WithContentCornerRadius(radius float64) Background {
+	bg.contentRadius = &radius
+	return bg
+}
+
+// WithBorder draws a border of the given width around the content, in the padding
+// and following the corner radius
+func (bg ImageBackground) WithBorder(width int, col color.Color) ImageBackground {
+	bg.border = newBorder(width, col)
+	return bg
+}
+
+// WithReflection draws a mirror image of the content right below it, height pixels
+// tall and fading from opacity (0.0 - 1.0) to nothing. It's only drawn in the room
+// below the content, so it's cut short to fit the bottom padding.
+func (bg ImageBackground) WithReflection(height int, opacity float64) ImageBackground {
+	bg.reflection = newReflection(height, opacity)
+	return bg
+}
+
+// Measure implements the Measurer interface
+func (bg ImageBackground) Measure(contentWidth, contentHeight int) (width, height int) {
+	return measure(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// Place implements the Placer interface
+func (bg ImageBackground) Place(contentWidth, contentHeight int) image.Rectangle {
+	return place(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
 // scaleImage scales the image according to the scale mode
 func (bg ImageBackground) scaleImage(width, height int) image.Image {
 	bounds := bg.image.Bounds()
@@ -226,6 +327,7 @@ func (bg ImageBackground) Render(content image.Image) (image.Image, error) {
 	// Create a new image for the content with shadow
 	contentWithShadow := content
 	if bg.shadow != nil {
+		fitShadow(bg.shadow, bg.contentRadius)
 		contentWithShadow = bg.shadow.Apply(content)
 	}
 
@@ -238,36 +340,9 @@ func (bg ImageBackground) Render(content image.Image) (image.Image, error) {
 	scaledImg := bg.scaleImage(width, height)
 
 	if bg.blur != nil {
-		// Convert to NRGBA for imaging operations
-		nrgba := imaging.Clone(scaledImg)
-
-		switch bg.blur.Type {
-		case GaussianBlur:
-			nrgba = imaging.Blur(nrgba, bg.blur.Radius)
-		case PixelatedBlur:
-			// Create pixelated effect by scaling down and back up
-			w := nrgba.Bounds().Dx()
-			h := nrgba.Bounds().Dy()
-			// Scale factor based on radius (larger radius = more pixelation)
-			factor := math.Max(1, bg.blur.Radius)
-			smallW := int(float64(w) / factor)
-			smallH := int(float64(h) / factor)
-			if smallW < 1 {
-				smallW = 1
-			}
-			if smallH < 1 {
-				smallH = 1
-			}
-			// Scale down
-			small := imaging.Resize(nrgba, smallW, smallH, imaging.Box)
-			// Scale back up
-			nrgba = imaging.Resize(small, w, h, imaging.NearestNeighbor)
-		}
-
-		// Convert back to original format
-		result := image.NewRGBA(scaledImg.Bounds())
-		draw.Draw(result, result.Bounds(), nrgba, image.Point{}, draw.Src)
-		scaledImg = result
+		rgba := image.NewRGBA(scaledImg.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), scaledImg, scaledImg.Bounds().Min, draw.Src)
+		scaledImg = bg.blur.apply(rgba)
 	}
 
 	// Create the final image
@@ -276,6 +351,8 @@ func (bg ImageBackground) Render(content image.Image) (image.Image, error) {
 	// Draw the scaled background image
 	draw.Draw(result, result.Bounds(), scaledImg, image.Point{}, draw.Over)
 
+	applyVignette(result, bg.vignette)
+
 	// Apply opacity if needed
 	if bg.opacity < 1.0 {
 		result = applyOpacity(result, bg.opacity)
@@ -291,6 +368,10 @@ func (bg ImageBackground) Render(content image.Image) (image.Image, error) {
 		result = final
 	}
 
+	// The border goes between the background and the content
+	bg.border.draw(result, cardRect(width, height, bg.padding, bg.shadow), cardRadius(bg.contentRadius, bg.cornerRadius))
+	bg.reflection.draw(result, cardRect(width, height, bg.padding, bg.shadow), content)
+
 	// Draw the content (with shadow) centered on the background
 	contentPos := image.Point{
 		X: bg.padding.Left - shadowBounds.Min.X,
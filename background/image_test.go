@@ -0,0 +1,75 @@
+package background
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewImageBackgroundFromURL(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(buf.Bytes())
+		case "/untyped":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(buf.Bytes())
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>not an image</body></html>"))
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("image", func(t *testing.T) {
+		bg, err := NewImageBackgroundFromURL(server.URL + "/image.png")
+		require.NoError(t, err)
+		assert.Equal(t, img.Bounds(), bg.image.Bounds())
+		r, _, _, _ := bg.image.At(1, 1).RGBA()
+		assert.Equal(t, uint32(0xffff), r)
+	})
+
+	t.Run("untyped image", func(t *testing.T) {
+		_, err := NewImageBackgroundFromURL(server.URL + "/untyped")
+		assert.NoError(t, err)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		_, err := NewImageBackgroundFromURL(server.URL + "/page")
+		assert.ErrorContains(t, err, "not an image")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := NewImageBackgroundFromURL(server.URL + "/missing.png")
+		assert.ErrorContains(t, err, "404")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := NewImageBackgroundFromURLContext(ctx, server.URL+"/slow")
+		assert.ErrorContains(t, err, context.DeadlineExceeded.Error())
+	})
+}
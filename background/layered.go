@@ -0,0 +1,183 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// layer is a background of a LayeredBackground and how opaque it is drawn
+type layer struct {
+	background Background
+	opacity    float64
+}
+
+// LayeredBackground stacks backgrounds on top of each other, like a gradient under a
+// translucent pattern. Every layer is rendered around the content with its own
+// padding, blur and other settings, and centered on the layers below it. The
+// result is as large as the largest layer.
+type LayeredBackground struct {
+	layers        []layer
+	padding       Padding // Padding shared by all of the layers, on top of their own
+	cornerRadius  float64
+	shadow        Shadow
+	contentRadius *float64 // Corner radius of the content, when the canvas knows it
+}
+
+// NewLayeredBackground creates a new LayeredBackground from its layers, from the bottom
+// up. Every layer is fully opaque until WithLayerOpacity says otherwise.
+func NewLayeredBackground(layers ...Background) LayeredBackground {
+	bg := LayeredBackground{
+		layers:       make([]layer, len(layers)),
+		cornerRadius: 0,
+		shadow:       nil,
+	}
+	for i, l := range layers {
+		bg.layers[i] = layer{background: l, opacity: 1}
+	}
+	return bg
+}
+
+// WithLayerOpacity sets how opaque the layer at the given index is drawn (0.0 - 1.0)
+func (bg LayeredBackground) WithLayerOpacity(index int, opacity float64) LayeredBackground {
+	if index < 0 || index >= len(bg.layers) {
+		return bg
+	}
+	bg.layers = append([]layer(nil), bg.layers...)
+	bg.layers[index].opacity = math.Max(0, math.Min(1, opacity))
+	return bg
+}
+
+// WithPadding sets equal padding for all sides, a shortcut for WithPaddingDetailed.
+// Every layer adds it to its own padding.
+func (bg LayeredBackground) WithPadding(value int) LayeredBackground {
+	bg.padding = NewPadding(value)
+	return bg
+}
+
+// WithPaddingDetailed sets detailed padding for each side. Every layer adds it to its
+// own padding.
+func (bg LayeredBackground) WithPaddingDetailed(top, right, bottom, left int) LayeredBackground {
+	bg.padding = Padding{
+		Top:    top,
+		Right:  right,
+		Bottom: bottom,
+		Left:   left,
+	}
+	return bg
+}
+
+// WithCornerRadius sets the corner radius that all of the layers are clipped to
+func (bg LayeredBackground) WithCornerRadius(radius float64) Background {
+	bg.cornerRadius = radius
+	return bg
+}
+
+// WithShadow sets the shadow configuration for the background
+func (bg LayeredBackground) WithShadow(shadow Shadow) Background {
+	bg.shadow = shadow
+	return bg
+}
+
+// WithContentCornerRadius implements the ContentRounder interface
+func (bg LayeredBackground) WithContentCornerRadius(radius float64) Background {
+	bg.contentRadius = &radius
+	return bg
+}
+
+// background returns the background of layer i, told the corner radius of the content
+// if it's known
+func (bg LayeredBackground) background(i int) Background {
+	l := bg.layers[i].background
+	if r, ok := l.(ContentRounder); ok && bg.contentRadius != nil {
+		return r.WithContentCornerRadius(*bg.contentRadius)
+	}
+	return l
+}
+
+// Measure implements the Measurer interface. Layers that can't be measured are
+// rendered to find their size.
+func (bg LayeredBackground) Measure(contentWidth, contentHeight int) (width, height int) {
+	if s, ok := bg.shadow.(*shadowImpl); ok {
+		contentWidth += s.expandBy() * 2
+		contentHeight += s.expandBy() * 2
+	}
+	contentWidth += bg.padding.Left + bg.padding.Right
+	contentHeight += bg.padding.Top + bg.padding.Bottom
+	for _, l := range bg.layers {
+		var w, h int
+		if m, ok := l.background.(Measurer); ok {
+			w, h = m.Measure(contentWidth, contentHeight)
+		} else if img, err := l.background.Render(image.NewRGBA(image.Rect(0, 0, contentWidth, contentHeight))); err == nil {
+			w, h = img.Bounds().Dx(), img.Bounds().Dy()
+		}
+		width, height = max(width, w), max(height, h)
+	}
+	return max(width, contentWidth), max(height, contentHeight)
+}
+
+// Place implements the Placer interface
+func (bg LayeredBackground) Place(contentWidth, contentHeight int) image.Rectangle {
+	width, height := bg.Measure(contentWidth, contentHeight)
+	expand := 0
+	if s, ok := bg.shadow.(*shadowImpl); ok {
+		expand = s.expandBy()
+	}
+	standInWidth := contentWidth + 2*expand + bg.padding.Left + bg.padding.Right
+	standInHeight := contentHeight + 2*expand + bg.padding.Top + bg.padding.Bottom
+	origin := image.Pt((width-standInWidth)/2+bg.padding.Left+expand, (height-standInHeight)/2+bg.padding.Top+expand)
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(contentWidth, contentHeight))}
+}
+
+// Render applies the background to the given content image
+// It returns a new image with the layers stacked and the content centered on them
+func (bg LayeredBackground) Render(content image.Image) (image.Image, error) {
+	if content == nil {
+		content = image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	// If shadow is configured, apply it to the content first
+	if bg.shadow != nil {
+		bg.shadow.(*shadowImpl).cornerRadius = cardRadius(bg.contentRadius, bg.cornerRadius)
+		content = bg.shadow.Apply(content)
+	}
+	bounds := content.Bounds()
+
+	// Every layer is rendered around a transparent stand-in for the padded content
+	standIn := image.Rect(0, 0, bounds.Dx()+bg.padding.Left+bg.padding.Right, bounds.Dy()+bg.padding.Top+bg.padding.Bottom)
+	width, height := standIn.Dx(), standIn.Dy()
+	rendered := make([]image.Image, len(bg.layers))
+	for i := range bg.layers {
+		img, err := bg.background(i).Render(image.NewRGBA(standIn))
+		if err != nil {
+			return nil, err
+		}
+		rendered[i] = img
+		width = max(width, img.Bounds().Dx())
+		height = max(height, img.Bounds().Dy())
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, l := range bg.layers {
+		layerBounds := rendered[i].Bounds()
+		offset := image.Pt((width-layerBounds.Dx())/2, (height-layerBounds.Dy())/2)
+		mask := image.NewUniform(color.Alpha{A: uint8(math.Round(l.opacity * 255))})
+		draw.DrawMask(img, layerBounds.Sub(layerBounds.Min).Add(offset), rendered[i], layerBounds.Min, mask, image.Point{}, draw.Over)
+	}
+
+	// Clip all of the layers to the rounded corners at once
+	if bg.cornerRadius > 0 {
+		clipped := image.NewRGBA(img.Bounds())
+		mask := image.NewAlpha(img.Bounds())
+		drawRoundedRect(mask, img.Bounds(), color.Alpha{A: 255}, bg.cornerRadius)
+		draw.DrawMask(clipped, img.Bounds(), img, image.Point{}, mask, image.Point{}, draw.Src)
+		img = clipped
+	}
+
+	// Draw the content (with shadow) centered on the layers, inside the padding
+	offset := image.Pt((width-standIn.Dx())/2+bg.padding.Left, (height-standIn.Dy())/2+bg.padding.Top)
+	draw.Draw(img, bounds.Sub(bounds.Min).Add(offset), content, bounds.Min, draw.Over)
+
+	return img, nil
+}
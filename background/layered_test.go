@@ -0,0 +1,80 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayeredBackground(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 20, 20))
+
+	// A red backdrop with 20 pixels of padding under a smaller, half transparent blue one
+	bg := NewLayeredBackground(
+		NewColorBackground().WithColor(red).WithPadding(20),
+		NewColorBackground().WithColor(blue).WithPadding(10),
+	).WithLayerOpacity(1, 0.5)
+
+	img, err := bg.Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 60, 60), img.Bounds())
+
+	w, h := bg.Measure(20, 20)
+	assert.Equal(t, 60, w)
+	assert.Equal(t, 60, h)
+
+	// The red layer shows around the centered blue one, which is blended with it
+	assert.Equal(t, red, img.At(5, 5))
+	r, _, b, a := img.At(15, 15).RGBA()
+	assert.InDelta(t, 0x8000, r, 0x200)
+	assert.InDelta(t, 0x8000, b, 0x200)
+	assert.Equal(t, uint32(0xffff), a)
+
+	t.Run("shared corner radius", func(t *testing.T) {
+		img, err := bg.WithCornerRadius(15).Render(content)
+		require.NoError(t, err)
+		_, _, _, a := img.At(0, 0).RGBA()
+		assert.Zero(t, a)
+		assert.Equal(t, red, img.At(30, 2))
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		f, err := NewLayeredBackground(
+			NewGradientBackground(LinearGradient, GradientStop{Color: red, Position: 0}, GradientStop{Color: blue, Position: 1}),
+			NewGradientBackground(LinearGradient, GradientStop{Color: blue, Position: 0}, GradientStop{Color: red, Position: 1}).WithPadding(10),
+		).WithLayerOpacity(1, 0.25).RenderSVG(nil)
+		require.NoError(t, err)
+		assert.Equal(t, 40, f.Width)
+		assert.Contains(t, f.Body, `id="goshot-bg-layer0-gradient"`)
+		assert.Contains(t, f.Body, `fill="url(#goshot-bg-layer1-gradient)"`)
+		assert.Contains(t, f.Body, `<g opacity="0.25">`)
+	})
+}
+
+func TestLayeredBackgroundPadding(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(content, content.Bounds(), image.NewUniform(green), image.Point{}, draw.Src)
+
+	// The shared padding comes on top of the padding of each layer
+	bg := NewLayeredBackground(NewColorBackground().WithColor(red).WithPadding(10)).WithPaddingDetailed(20, 0, 0, 5)
+	img, err := bg.Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 45, 60), img.Bounds())
+
+	w, h := bg.Measure(20, 20)
+	assert.Equal(t, 45, w)
+	assert.Equal(t, 60, h)
+
+	assert.Equal(t, red, img.At(14, 29))
+	assert.Equal(t, green, img.At(15, 30))
+	assert.Equal(t, green, img.At(34, 49))
+	assert.Equal(t, red, img.At(35, 50))
+}
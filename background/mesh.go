@@ -0,0 +1,205 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// MeshPoint is a control point of a mesh gradient
+type MeshPoint struct {
+	X     float64 // Horizontal position between 0 and 1
+	Y     float64 // Vertical position between 0 and 1
+	Color color.Color
+}
+
+// MeshGradient is a background that blends colors placed at arbitrary points, for
+// soft multi-color backdrops
+type MeshGradient struct {
+	points        []MeshPoint
+	power         float64 // How quickly the influence of a point falls off with distance
+	padding       Padding
+	cornerRadius  float64
+	shadow        Shadow
+	contentRadius *float64 // Corner radius of the content, when the canvas knows it
+	border        *border
+	reflection    *reflection
+}
+
+// NewMeshBackground creates a new MeshGradient from its control points. Every pixel is
+// a blend of all the colors, weighted by the inverse of its distance to each point.
+func NewMeshBackground(points []MeshPoint) MeshGradient {
+	return MeshGradient{
+		points:       points,
+		power:        2,
+		padding:      NewPadding(20),
+		cornerRadius: 0,
+		shadow:       nil,
+	}
+}
+
+// WithPower sets how quickly the influence of a point falls off with distance. Higher
+// values keep each color closer to its point, while lower values blend them more
+// evenly. The default is 2.
+func (bg MeshGradient) WithPower(power float64) MeshGradient {
+	bg.power = power
+	return bg
+}
+
+// WithPadding sets equal padding for all sides, a shortcut for WithPaddingDetailed
+func (bg MeshGradient) WithPadding(value int) MeshGradient {
+	bg.padding = NewPadding(value)
+	return bg
+}
+
+// WithPaddingDetailed sets detailed padding for each side
+func (bg MeshGradient) WithPaddingDetailed(top, right, bottom, left int) MeshGradient {
+	bg.padding = Padding{
+		Top:    top,
+		Right:  right,
+		Bottom: bottom,
+		Left:   left,
+	}
+	return bg
+}
+
+// WithCornerRadius sets the corner radius for the background
+func (bg MeshGradient) WithCornerRadius(radius float64) Background {
+	bg.cornerRadius = radius
+	return bg
+}
+
+// WithShadow sets the shadow configuration for the background
+func (bg MeshGradient) WithShadow(shadow Shadow) Background {
+	bg.shadow = shadow
+	return bg
+}
+
+// WithContentCornerRadius implements the ContentRounder interface
+func (bg MeshGradient) WithContentCornerRadius(radius float64) Background {
+	bg.contentRadius = &radius
+	return bg
+}
+
+// WithBorder draws a border of the given width around the content, in the padding
+// and following the corner radius
+func (bg MeshGradient) WithBorder(width int, col color.Color) MeshGradient {
+	bg.border = newBorder(width, col)
+	return bg
+}
+
+// WithReflection draws a mirror image of the content right below it, height pixels
+// tall and fading from opacity (0.0 - 1.0) to nothing. It's only drawn in the room
+// below the content, so it's cut short to fit the bottom padding.
+func (bg MeshGradient) WithReflection(height int, opacity float64) MeshGradient {
+	bg.reflection = newReflection(height, opacity)
+	return bg
+}
+
+// Measure implements the Measurer interface
+func (bg MeshGradient) Measure(contentWidth, contentHeight int) (width, height int) {
+	return measure(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// Place implements the Placer interface
+func (bg MeshGradient) Place(contentWidth, contentHeight int) image.Rectangle {
+	return place(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// meshColor holds the premultiplied components of a control point color for blending
+type meshColor struct {
+	r, g, b, a float64
+}
+
+// getColorAt returns the blended color at pixel (x, y) of an image of the given size
+func (bg MeshGradient) getColorAt(colors []meshColor, x, y, width, height float64) color.Color {
+	var sum meshColor
+	var total float64
+	for i, p := range bg.points {
+		dx := x - p.X*width
+		dy := y - p.Y*height
+		dist := math.Sqrt(dx*dx + dy*dy)
+		if dist < 0.5 {
+			// The pixel is on the point itself
+			c := colors[i]
+			return color.RGBA64{R: uint16(c.r), G: uint16(c.g), B: uint16(c.b), A: uint16(c.a)}
+		}
+
+		w := 1 / math.Pow(dist, bg.power)
+		sum.r += colors[i].r * w
+		sum.g += colors[i].g * w
+		sum.b += colors[i].b * w
+		sum.a += colors[i].a * w
+		total += w
+	}
+
+	return color.RGBA64{
+		R: uint16(sum.r / total),
+		G: uint16(sum.g / total),
+		B: uint16(sum.b / total),
+		A: uint16(sum.a / total),
+	}
+}
+
+// Render applies the mesh gradient background to the given content image
+func (bg MeshGradient) Render(content image.Image) (image.Image, error) {
+	if content == nil {
+		width := bg.padding.Left + bg.padding.Right
+		height := bg.padding.Top + bg.padding.Bottom
+		content = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+
+	// Create a new image for the content with shadow
+	contentWithShadow := content
+	if bg.shadow != nil {
+		fitShadow(bg.shadow, bg.contentRadius)
+		contentWithShadow = bg.shadow.Apply(content)
+	}
+
+	// Calculate total size including padding and shadow bounds
+	shadowBounds := contentWithShadow.Bounds()
+	width := shadowBounds.Dx() + bg.padding.Left + bg.padding.Right
+	height := shadowBounds.Dy() + bg.padding.Top + bg.padding.Bottom
+
+	meshImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// Create a mask for rounded corners if needed
+	var mask *image.Alpha
+	if bg.cornerRadius > 0 {
+		mask = image.NewAlpha(meshImg.Bounds())
+		drawRoundedRect(mask, meshImg.Bounds(), color.Alpha{A: 255}, bg.cornerRadius)
+	}
+
+	// Draw the mesh, unless there's nothing to blend
+	if len(bg.points) > 0 {
+		colors := make([]meshColor, len(bg.points))
+		for i, p := range bg.points {
+			r, g, b, a := p.Color.RGBA()
+			colors[i] = meshColor{float64(r), float64(g), float64(b), float64(a)}
+		}
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if mask != nil && mask.AlphaAt(x, y).A == 0 {
+					continue
+				}
+				// Sample at the center of the pixel
+				meshImg.Set(x, y, bg.getColorAt(colors, float64(x)+0.5, float64(y)+0.5, float64(width), float64(height)))
+			}
+		}
+	}
+
+	// The border goes between the background and the content
+	bg.border.draw(meshImg, cardRect(width, height, bg.padding, bg.shadow), cardRadius(bg.contentRadius, bg.cornerRadius))
+	bg.reflection.draw(meshImg, cardRect(width, height, bg.padding, bg.shadow), content)
+
+	// Draw the content (with shadow) centered on the background
+	contentPos := image.Point{
+		X: bg.padding.Left - shadowBounds.Min.X,
+		Y: bg.padding.Top - shadowBounds.Min.Y,
+	}
+	draw.Draw(meshImg, shadowBounds.Add(contentPos), contentWithShadow, shadowBounds.Min, draw.Over)
+
+	return meshImg, nil
+}
@@ -0,0 +1,93 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeshGradient(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	// Without any content, the background is the size of its padding
+	empty := image.NewRGBA(image.Rectangle{})
+
+	tests := []struct {
+		name   string
+		bg     func(points []MeshPoint) Background
+		size   image.Point
+		pixels []image.Point // Pixels of the control points, in the padding
+	}{
+		{
+			name:   "even padding",
+			bg:     func(points []MeshPoint) Background { return NewMeshBackground(points).WithPadding(50) },
+			size:   image.Pt(100, 100),
+			pixels: []image.Point{{10, 10}, {89, 10}, {10, 89}, {89, 89}},
+		},
+		{
+			name: "detailed padding",
+			bg: func(points []MeshPoint) Background {
+				return NewMeshBackground(points).WithPaddingDetailed(10, 40, 30, 20)
+			},
+			size:   image.Pt(60, 40),
+			pixels: []image.Point{{2, 2}, {57, 2}, {2, 37}, {57, 37}},
+		},
+		{
+			name: "rounded corners",
+			bg: func(points []MeshPoint) Background {
+				return NewMeshBackground(points).WithPadding(50).WithCornerRadius(20)
+			},
+			size:   image.Pt(100, 100),
+			pixels: []image.Point{{20, 20}, {79, 20}, {20, 79}, {79, 79}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			colors := []color.Color{red, green, blue, white}
+			points := make([]MeshPoint, len(tt.pixels))
+			for i, p := range tt.pixels {
+				// At the center of the pixel
+				points[i] = MeshPoint{
+					X:     (float64(p.X) + 0.5) / float64(tt.size.X),
+					Y:     (float64(p.Y) + 0.5) / float64(tt.size.Y),
+					Color: colors[i],
+				}
+			}
+
+			img, err := tt.bg(points).Render(empty)
+			require.NoError(t, err)
+			require.Equal(t, image.Rectangle{Max: tt.size}, img.Bounds())
+
+			// Each control point has its own color, and everything in between is a blend
+			for i, p := range tt.pixels {
+				assert.Equal(t, colors[i], color.RGBAModel.Convert(img.At(p.X, p.Y)), "point %d", i)
+			}
+			r, g, b, a := img.At(tt.size.X/2, tt.size.Y/2).RGBA()
+			assert.Equal(t, uint32(0xffff), a)
+			for _, c := range []uint32{r, g, b} {
+				assert.Greater(t, c, uint32(0x1000))
+				assert.Less(t, c, uint32(0xf000))
+			}
+		})
+	}
+
+	t.Run("corners cut off", func(t *testing.T) {
+		img, err := NewMeshBackground([]MeshPoint{{X: 0.5, Y: 0.5, Color: red}}).WithPadding(50).WithCornerRadius(20).Render(empty)
+		require.NoError(t, err)
+		_, _, _, a := img.At(0, 0).RGBA()
+		assert.Zero(t, a)
+		assert.Equal(t, red, img.At(50, 0))
+	})
+
+	t.Run("no points", func(t *testing.T) {
+		img, err := NewMeshBackground(nil).WithPadding(10).Render(empty)
+		require.NoError(t, err)
+		_, _, _, a := img.At(5, 5).RGBA()
+		assert.Zero(t, a)
+	})
+}
@@ -0,0 +1,48 @@
+package background
+
+// grown returns the padding with the given amounts added to each side
+func (p Padding) grown(top, right, bottom, left int) Padding {
+	return Padding{
+		Top:    p.Top + top,
+		Right:  p.Right + right,
+		Bottom: p.Bottom + bottom,
+		Left:   p.Left + left,
+	}
+}
+
+// Padded implements the Padder interface
+func (bg ColorBackground) Padded(top, right, bottom, left int) Background {
+	bg.padding = bg.padding.grown(top, right, bottom, left)
+	return bg
+}
+
+// Padded implements the Padder interface
+func (bg GradientBackground) Padded(top, right, bottom, left int) Background {
+	bg.padding = bg.padding.grown(top, right, bottom, left)
+	return bg
+}
+
+// Padded implements the Padder interface
+func (bg ImageBackground) Padded(top, right, bottom, left int) Background {
+	bg.padding = bg.padding.grown(top, right, bottom, left)
+	return bg
+}
+
+// Padded implements the Padder interface
+func (bg MeshGradient) Padded(top, right, bottom, left int) Background {
+	bg.padding = bg.padding.grown(top, right, bottom, left)
+	return bg
+}
+
+// Padded implements the Padder interface
+func (bg PatternBackground) Padded(top, right, bottom, left int) Background {
+	bg.padding = bg.padding.grown(top, right, bottom, left)
+	return bg
+}
+
+// Padded implements the Padder interface. The layers' own padding is kept, as the
+// padding of the LayeredBackground is added to all of them.
+func (bg LayeredBackground) Padded(top, right, bottom, left int) Background {
+	bg.padding = bg.padding.grown(top, right, bottom, left)
+	return bg
+}
@@ -0,0 +1,182 @@
+package background
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+)
+
+// ParseGradientStops parses CSS-style color stops, such as
+// "#ff0000 0%, rgba(0,0,255,0.5) 50%, blue 100%". Every stop needs a percentage
+// position, and the positions must be in increasing order. An empty string has no
+// stops.
+func ParseGradientStops(css string) ([]GradientStop, error) {
+	if strings.TrimSpace(css) == "" {
+		return nil, nil
+	}
+
+	var stops []GradientStop
+	for _, part := range splitTopLevel(css, ',') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty gradient stop in %q", css)
+		}
+
+		// The position is the last word, after the color
+		i := strings.LastIndexAny(part, " \t")
+		if i < 0 || !strings.HasSuffix(part, "%") {
+			return nil, fmt.Errorf("gradient stop %q is missing a percentage position (e.g., \"%s 50%%\")", part, part)
+		}
+		colorStr, positionStr := strings.TrimSpace(part[:i]), part[i+1:]
+
+		c, err := ParseColor(colorStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color in gradient stop %q: %v", part, err)
+		}
+
+		position, err := strconv.ParseFloat(strings.TrimSuffix(positionStr, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position in gradient stop %q: %v", part, err)
+		}
+		if math.IsNaN(position) || math.IsInf(position, 0) {
+			return nil, fmt.Errorf("gradient stop position must be a number: %q", part)
+		}
+		if position < 0 || position > 100 {
+			return nil, fmt.Errorf("gradient stop position must be between 0%% and 100%%: %q", part)
+		}
+		if len(stops) > 0 && position/100 < stops[len(stops)-1].Position {
+			return nil, fmt.Errorf("gradient stop %q comes before the previous stop", part)
+		}
+
+		stops = append(stops, GradientStop{
+			Color:    c,
+			Position: position / 100, // Convert percentage to decimal
+		})
+	}
+	return stops, nil
+}
+
+// ParseColor parses a CSS color: a hex color (#rgb, #rgba, #rrggbb or #rrggbbaa),
+// rgb(), rgba(), a named color like "blue" or "transparent"
+func ParseColor(s string) (color.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch {
+	case strings.HasPrefix(s, "#"):
+		return parseHexColor(s[1:])
+	case strings.HasPrefix(s, "rgba(") || strings.HasPrefix(s, "rgb("):
+		return parseRGBFunction(s)
+	}
+
+	if s == "transparent" {
+		return color.Transparent, nil
+	}
+	if c, ok := colornames.Map[s]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown color: %q", s)
+}
+
+// parseHexColor parses the digits of a hex color, in any of the CSS lengths
+func parseHexColor(digits string) (color.Color, error) {
+	hex := digits
+
+	// Expand the short forms so every channel has two digits
+	if len(hex) == 3 || len(hex) == 4 {
+		var b strings.Builder
+		for _, ch := range hex {
+			b.WriteRune(ch)
+			b.WriteRune(ch)
+		}
+		hex = b.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("invalid hex color: #%s", digits)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex color: #%s", digits)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// parseRGBFunction parses rgb() and rgba(), with the channels separated by commas or
+// spaces and an optional alpha given as a fraction or a percentage
+func parseRGBFunction(s string) (color.Color, error) {
+	open := strings.IndexByte(s, '(')
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid color %q: missing closing parenthesis", s)
+	}
+	args := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool {
+		return r == ',' || r == '/' || r == ' ' || r == '\t'
+	})
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("invalid color %q: expected 3 or 4 components", s)
+	}
+
+	var channels [3]uint8
+	for i, arg := range args[:3] {
+		v, err := parseComponent(arg, 255)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color %q: %v", s, err)
+		}
+		channels[i] = v
+	}
+
+	alpha := uint8(255)
+	if len(args) == 4 {
+		v, err := parseComponent(args[3], 1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color %q: %v", s, err)
+		}
+		alpha = v
+	}
+
+	return color.NRGBA{R: channels[0], G: channels[1], B: channels[2], A: alpha}, nil
+}
+
+// parseComponent parses a color component that is either a number up to limit or a
+// percentage, and scales it to 0-255
+func parseComponent(s string, limit float64) (uint8, error) {
+	number := s
+	if strings.HasSuffix(s, "%") {
+		number, limit = strings.TrimSuffix(s, "%"), 100
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid component %q", s)
+	}
+	if v < 0 || v > limit {
+		return 0, fmt.Errorf("component %q out of range", s)
+	}
+	return uint8(math.Round(v * 255 / limit)), nil
+}
+
+// splitTopLevel splits s at every sep that isn't inside parentheses
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, ch := range s {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
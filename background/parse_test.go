@@ -0,0 +1,73 @@
+package background
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGradientStops(t *testing.T) {
+	stops, err := ParseGradientStops("#ff0000 0%, rgba(0,0,255,0.5) 50%, blue 100%")
+	require.NoError(t, err)
+	require.Len(t, stops, 3)
+
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, stops[0].Color)
+	assert.Equal(t, 0.0, stops[0].Position)
+	assert.Equal(t, color.NRGBA{B: 255, A: 128}, stops[1].Color)
+	assert.Equal(t, 0.5, stops[1].Position)
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, stops[2].Color)
+	assert.Equal(t, 1.0, stops[2].Position)
+}
+
+func TestParseGradientStopsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want string
+	}{
+		{name: "missing percentage", css: "#ff0000 0%, blue", want: "missing a percentage"},
+		{name: "unitless position", css: "#ff0000 0, blue 100%", want: "missing a percentage"},
+		{name: "out of order", css: "red 60%, blue 40%", want: "comes before the previous stop"},
+		{name: "out of range", css: "red 0%, blue 120%", want: "between 0% and 100%"},
+		{name: "NaN position", css: "red NaN%, blue 100%", want: "must be a number"},
+		{name: "infinite position", css: "red 0%, blue Inf%", want: "must be a number"},
+		{name: "negative infinite position", css: "red -Inf%, blue 100%", want: "must be a number"},
+		{name: "unknown color", css: "notacolor 0%", want: "unknown color"},
+		{name: "bad hex", css: "#12345 0%", want: "invalid hex color"},
+		{name: "bad component", css: "rgb(300, 0, 0) 0%", want: "out of range"},
+		{name: "empty stop", css: "red 0%,, blue 100%", want: "empty gradient stop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGradientStops(tt.css)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  color.Color
+	}{
+		{input: "#abc", want: color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xff}},
+		{input: "#abcd", want: color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xdd}},
+		{input: "#11223344", want: color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x44}},
+		{input: "rgb(10, 20, 30)", want: color.NRGBA{R: 10, G: 20, B: 30, A: 255}},
+		{input: "rgb(100% 0% 0% / 50%)", want: color.NRGBA{R: 255, A: 128}},
+		{input: "SteelBlue", want: color.RGBA{R: 70, G: 130, B: 180, A: 255}},
+		{input: "transparent", want: color.Transparent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseColor(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// PatternType is the geometric pattern a PatternBackground repeats
+type PatternType int
+
+const (
+	// PatternDots places a dot in the middle of every cell
+	PatternDots PatternType = iota
+	// PatternGrid draws horizontal and vertical lines along the cell edges
+	PatternGrid
+	// PatternCheckerboard alternates the two colors from cell to cell
+	PatternCheckerboard
+	// PatternDiagonalLines draws lines running from the bottom left to the top right
+	PatternDiagonalLines
+)
+
+// PatternBackground is a background filled with a procedurally drawn pattern, for
+// subtle backdrops that don't need an image
+type PatternBackground struct {
+	pattern       PatternType
+	spacing       int         // Size of a cell of the pattern
+	thickness     float64     // Width of lines, or diameter of dots
+	foreground    color.Color // Color of the pattern
+	background    color.Color // Color behind the pattern
+	padding       Padding
+	cornerRadius  float64
+	shadow        Shadow
+	contentRadius *float64 // Corner radius of the content, when the canvas knows it
+	border        *border
+	reflection    *reflection
+}
+
+// NewPatternBackground creates a new PatternBackground, drawing the pattern in a
+// slightly darker shade of the default light background
+func NewPatternBackground(pattern PatternType) PatternBackground {
+	return PatternBackground{
+		pattern:      pattern,
+		spacing:      20,
+		thickness:    2,
+		foreground:   color.RGBA{R: 210, G: 210, B: 210, A: 255},
+		background:   LightColor,
+		padding:      NewPadding(20),
+		cornerRadius: 0,
+		shadow:       nil,
+	}
+}
+
+// WithSpacing sets the size of a cell of the pattern, which is the distance between
+// dots and lines, or the size of a checkerboard square
+func (bg PatternBackground) WithSpacing(spacing int) PatternBackground {
+	bg.spacing = spacing
+	return bg
+}
+
+// WithThickness sets the width of lines, or the diameter of dots. Checkerboards
+// ignore it.
+func (bg PatternBackground) WithThickness(thickness float64) PatternBackground {
+	bg.thickness = thickness
+	return bg
+}
+
+// WithColors sets the color of the pattern and the color behind it
+func (bg PatternBackground) WithColors(foreground, background color.Color) PatternBackground {
+	bg.foreground = foreground
+	bg.background = background
+	return bg
+}
+
+// WithPadding sets equal padding for all sides, a shortcut for WithPaddingDetailed
+func (bg PatternBackground) WithPadding(value int) PatternBackground {
+	bg.padding = NewPadding(value)
+	return bg
+}
+
+// WithPaddingDetailed sets detailed padding for each side
+func (bg PatternBackground) WithPaddingDetailed(top, right, bottom, left int) PatternBackground {
+	bg.padding = Padding{
+		Top:    top,
+		Right:  right,
+		Bottom: bottom,
+		Left:   left,
+	}
+	return bg
+}
+
+// WithCornerRadius sets the corner radius for the background
+func (bg PatternBackground) WithCornerRadius(radius float64) Background {
+	bg.cornerRadius = radius
+	return bg
+}
+
+// WithShadow sets the shadow configuration for the background
+func (bg PatternBackground) WithShadow(shadow Shadow) Background {
+	bg.shadow = shadow
+	return bg
+}
+
+// WithContentCornerRadius implements the ContentRounder interface
+func (bg PatternBackground) WithContentCornerRadius(radius float64) Background {
+	bg.contentRadius = &radius
+	return bg
+}
+
+// WithBorder draws a border of the given width around the content, in the padding
+// and following the corner radius
+func (bg PatternBackground) WithBorder(width int, col color.Color) PatternBackground {
+	bg.border = newBorder(width, col)
+	return bg
+}
+
+// WithReflection draws a mirror image of the content right below it, height pixels
+// tall and fading from opacity (0.0 - 1.0) to nothing. It's only drawn in the room
+// below the content, so it's cut short to fit the bottom padding.
+func (bg PatternBackground) WithReflection(height int, opacity float64) PatternBackground {
+	bg.reflection = newReflection(height, opacity)
+	return bg
+}
+
+// Measure implements the Measurer interface
+func (bg PatternBackground) Measure(contentWidth, contentHeight int) (width, height int) {
+	return measure(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// Place implements the Placer interface
+func (bg PatternBackground) Place(contentWidth, contentHeight int) image.Rectangle {
+	return place(contentWidth, contentHeight, bg.padding, bg.shadow)
+}
+
+// coverage returns how much of the pixel at x, y the pattern covers, between 0 and 1.
+// Shapes are measured from the center of the pixel, which gives them smooth edges.
+func (bg PatternBackground) coverage(x, y int) float64 {
+	s := float64(bg.spacing)
+	px, py := float64(x)+0.5, float64(y)+0.5
+
+	// distance returns how far v is from the nearest multiple of s
+	distance := func(v float64) float64 {
+		m := math.Mod(v, s)
+		return math.Min(m, s-m)
+	}
+	// edge turns the distance to the center of a shape into coverage
+	edge := func(d, halfWidth float64) float64 {
+		return math.Max(0, math.Min(1, halfWidth-d+0.5))
+	}
+
+	switch bg.pattern {
+	case PatternDots:
+		dx, dy := distance(px+s/2), distance(py+s/2)
+		return edge(math.Hypot(dx, dy), bg.thickness/2)
+	case PatternGrid:
+		return math.Max(edge(distance(px), bg.thickness/2), edge(distance(py), bg.thickness/2))
+	case PatternCheckerboard:
+		if (x/bg.spacing+y/bg.spacing)%2 == 1 {
+			return 1
+		}
+		return 0
+	case PatternDiagonalLines:
+		return edge(distance(px+py)/math.Sqrt2, bg.thickness/2)
+	default:
+		return 0
+	}
+}
+
+// fill draws the pattern over the whole image
+func (bg PatternBackground) fill(img *image.RGBA) {
+	bounds := img.Bounds()
+	draw.Draw(img, bounds, &image.Uniform{bg.background}, image.Point{}, draw.Src)
+	if bg.spacing <= 0 || bg.foreground == nil {
+		return
+	}
+
+	fr, fg, fb, fa := bg.foreground.RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := bg.coverage(x, y)
+			if c == 0 {
+				continue
+			}
+			// Blend the foreground over the background, scaled by the coverage
+			a := float64(fa) * c / 0xffff
+			dst := img.RGBAAt(x, y)
+			blend := func(src uint32, dst uint8) uint8 {
+				return uint8(math.Round((float64(src)*c/0xffff)*255 + float64(dst)*(1-a)))
+			}
+			img.SetRGBA(x, y, color.RGBA{
+				R: blend(fr, dst.R),
+				G: blend(fg, dst.G),
+				B: blend(fb, dst.B),
+				A: blend(fa, dst.A),
+			})
+		}
+	}
+}
+
+// Render applies the background to the given content image
+// It returns a new image with the background applied and the content centered
+func (bg PatternBackground) Render(content image.Image) (image.Image, error) {
+	if content == nil {
+		width := bg.padding.Left + bg.padding.Right
+		height := bg.padding.Top + bg.padding.Bottom
+		content = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+
+	// If shadow is configured, apply it to the content first
+	contentWithShadow := content
+	if bg.shadow != nil {
+		bg.shadow.(*shadowImpl).cornerRadius = cardRadius(bg.contentRadius, bg.cornerRadius)
+		contentWithShadow = bg.shadow.Apply(content)
+	}
+
+	// Calculate total size including padding and shadow bounds
+	shadowBounds := contentWithShadow.Bounds()
+	width := shadowBounds.Dx() + bg.padding.Left + bg.padding.Right
+	height := shadowBounds.Dy() + bg.padding.Top + bg.padding.Bottom
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if bg.cornerRadius > 0 {
+		pattern := image.NewRGBA(img.Bounds())
+		bg.fill(pattern)
+		mask := image.NewAlpha(img.Bounds())
+		drawRoundedRect(mask, img.Bounds(), color.Alpha{A: 255}, bg.cornerRadius)
+		draw.DrawMask(img, img.Bounds(), pattern, image.Point{}, mask, image.Point{}, draw.Src)
+	} else {
+		bg.fill(img)
+	}
+
+	// The border goes between the background and the content
+	bg.border.draw(img, cardRect(width, height, bg.padding, bg.shadow), cardRadius(bg.contentRadius, bg.cornerRadius))
+	bg.reflection.draw(img, cardRect(width, height, bg.padding, bg.shadow), content)
+
+	// Draw the content (with shadow) centered on the background
+	contentPos := image.Point{
+		X: bg.padding.Left - shadowBounds.Min.X,
+		Y: bg.padding.Top - shadowBounds.Min.Y,
+	}
+	draw.Draw(img, shadowBounds.Add(contentPos), contentWithShadow, shadowBounds.Min, draw.Over)
+
+	return img, nil
+}
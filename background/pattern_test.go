@@ -0,0 +1,54 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternBackground(t *testing.T) {
+	fg := color.RGBA{R: 255, A: 255}
+	bgColor := color.RGBA{B: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 40, 40))
+
+	tests := []struct {
+		pattern PatternType
+		fg, bg  []image.Point // Points drawn in the pattern and background colors
+	}{
+		{pattern: PatternDots, fg: []image.Point{{5, 5}, {15, 25}}, bg: []image.Point{{0, 0}, {10, 5}}},
+		{pattern: PatternGrid, fg: []image.Point{{0, 5}, {10, 7}, {3, 20}}, bg: []image.Point{{5, 5}, {15, 25}}},
+		{pattern: PatternCheckerboard, fg: []image.Point{{15, 5}, {5, 15}}, bg: []image.Point{{5, 5}, {15, 15}}},
+		{pattern: PatternDiagonalLines, fg: []image.Point{{9, 0}, {4, 5}, {19, 0}}, bg: []image.Point{{5, 0}, {0, 15}}},
+	}
+	for _, tt := range tests {
+		bg := NewPatternBackground(tt.pattern).WithSpacing(10).WithThickness(3).WithColors(fg, bgColor).WithPadding(10)
+		img, err := bg.Render(content)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 60, 60), img.Bounds())
+
+		for _, p := range tt.fg {
+			assert.Equal(t, fg, img.At(p.X, p.Y), "pattern %d at %v", tt.pattern, p)
+		}
+		for _, p := range tt.bg {
+			assert.Equal(t, bgColor, img.At(p.X, p.Y), "pattern %d at %v", tt.pattern, p)
+		}
+	}
+
+	t.Run("corner radius", func(t *testing.T) {
+		img, err := NewPatternBackground(PatternCheckerboard).WithCornerRadius(10).Render(content)
+		require.NoError(t, err)
+		_, _, _, a := img.At(0, 0).RGBA()
+		assert.Zero(t, a)
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		f, err := NewPatternBackground(PatternDots).WithSpacing(12).WithPadding(10).RenderSVG(nil)
+		require.NoError(t, err)
+		assert.Equal(t, 20, f.Width)
+		assert.Contains(t, f.Body, `<pattern id="goshot-bg-pattern" patternUnits="userSpaceOnUse" width="12" height="12">`)
+		assert.Contains(t, f.Body, `fill="url(#goshot-bg-pattern)"`)
+	})
+}
@@ -0,0 +1,70 @@
+package background
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/watzon/goshot/svg"
+)
+
+// reflection is a faded mirror image of the content, drawn below it in the padding of
+// a background
+type reflection struct {
+	height  int
+	opacity float64
+}
+
+// newReflection returns the reflection of the given height and opacity, or nil if it
+// wouldn't show
+func newReflection(height int, opacity float64) *reflection {
+	if height <= 0 || opacity <= 0 {
+		return nil
+	}
+	return &reflection{height: height, opacity: math.Min(1, opacity)}
+}
+
+// fit returns how tall the reflection is drawn below the card in a background of the
+// given height: no taller than the content, nor than the room left below the card
+func (r *reflection) fit(card image.Rectangle, height int) int {
+	if r == nil {
+		return 0
+	}
+	return max(0, min(r.height, card.Dy(), height-card.Max.Y))
+}
+
+// draw draws the content upside down right below the card, fading from the
+// reflection's opacity to nothing
+func (r *reflection) draw(img *image.RGBA, card image.Rectangle, content image.Image) {
+	height := r.fit(card, img.Bounds().Max.Y)
+	if height == 0 || content == nil {
+		return
+	}
+
+	src := content.Bounds()
+	for i := 0; i < height; i++ {
+		alpha := r.opacity * (1 - (float64(i)+0.5)/float64(height))
+		row := image.Rect(card.Min.X, card.Max.Y+i, card.Max.X, card.Max.Y+i+1)
+		mask := image.NewUniform(color.Alpha{A: uint8(math.Round(alpha * 255))})
+		draw.DrawMask(img, row, content, image.Pt(src.Min.X, src.Max.Y-1-i), mask, image.Point{}, draw.Over)
+	}
+}
+
+// svg returns the content body flipped below the card, faded by a gradient mask
+func (r *reflection) svg(card image.Rectangle, height int, body string) string {
+	h := r.fit(card, height)
+	if h == 0 || body == "" {
+		return ""
+	}
+
+	x, y, w := float64(card.Min.X), float64(card.Max.Y), float64(card.Dx())
+	return fmt.Sprintf("<defs><linearGradient id=\"goshot-reflection-fade\" x1=\"0\" y1=\"0\" x2=\"0\" y2=\"1\">"+
+		"<stop offset=\"0\" stop-color=\"#fff\" stop-opacity=\"%s\"/><stop offset=\"1\" stop-color=\"#fff\" stop-opacity=\"0\"/></linearGradient>"+
+		"<mask id=\"goshot-reflection\" maskUnits=\"userSpaceOnUse\" x=\"%[2]s\" y=\"%[3]s\" width=\"%[4]s\" height=\"%[5]s\">"+
+		"<rect x=\"%[2]s\" y=\"%[3]s\" width=\"%[4]s\" height=\"%[5]s\" fill=\"url(#goshot-reflection-fade)\"/></mask></defs>\n"+
+		"<g mask=\"url(#goshot-reflection)\"><g transform=\"translate(%[2]s %[6]s) scale(1 -1)\">\n%[7]s</g></g>\n",
+		svg.Number(r.opacity), svg.Number(x), svg.Number(y), svg.Number(w), svg.Number(float64(h)),
+		svg.Number(y+float64(card.Dy())), body)
+}
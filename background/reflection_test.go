@@ -0,0 +1,79 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/svg"
+)
+
+func TestReflection(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// Red on top, blue below, so that the reflection starts with blue
+	content := image.NewRGBA(image.Rect(0, 0, 100, 40))
+	draw.Draw(content, image.Rect(0, 0, 100, 20), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(content, image.Rect(0, 20, 100, 40), image.NewUniform(blue), image.Point{}, draw.Src)
+
+	plain := NewColorBackground().WithColor(white).WithPaddingDetailed(10, 10, 50, 10)
+	card := image.Rect(10, 10, 110, 50)
+	render := func(bg Background) *image.RGBA {
+		img, err := bg.Render(content)
+		require.NoError(t, err)
+		return img.(*image.RGBA)
+	}
+
+	t.Run("fades out below the card", func(t *testing.T) {
+		img := render(plain.WithReflection(30, 0.5))
+		assert.Equal(t, render(plain).Bounds(), img.Bounds())
+
+		top := img.RGBAAt(50, card.Max.Y)
+		assert.InDelta(t, 127, int(top.R), 8, "Blue drawn at half opacity over white")
+		assert.InDelta(t, 255, int(top.B), 1)
+		assert.Less(t, img.RGBAAt(50, card.Max.Y+15).B-img.RGBAAt(50, card.Max.Y+15).R, top.B-top.R, "Fading out")
+		assert.Equal(t, white, img.RGBAAt(50, card.Max.Y+30), "Below the reflection")
+		assert.Equal(t, white, img.RGBAAt(5, card.Max.Y), "Beside the reflection")
+	})
+
+	t.Run("off", func(t *testing.T) {
+		assert.Equal(t, render(plain), render(plain.WithReflection(0, 0.5)))
+		assert.Equal(t, render(plain), render(plain.WithReflection(30, 0)))
+	})
+
+	t.Run("cut short to the padding", func(t *testing.T) {
+		short := plain.WithPaddingDetailed(10, 10, 10, 10)
+		img := render(short.WithReflection(30, 0.5))
+		assert.Equal(t, render(short).Bounds(), img.Bounds())
+		assert.NotEqual(t, white, img.RGBAAt(50, card.Max.Y), "Drawn in the padding there is")
+	})
+
+	t.Run("without room", func(t *testing.T) {
+		tight := plain.WithPaddingDetailed(10, 10, 0, 10)
+		assert.Equal(t, render(tight), render(tight.WithReflection(30, 0.5)))
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		f := &svg.Fragment{Width: 100, Height: 40, Body: "<rect width=\"100\" height=\"40\"/>\n"}
+		reflected, err := plain.WithReflection(30, 0.5).RenderSVG(f)
+		require.NoError(t, err)
+		assert.Contains(t, reflected.Body, `mask="url(#goshot-reflection)"`)
+		assert.Equal(t, 2, strings.Count(reflected.Body, f.Body), "The content and its reflection")
+
+		without, err := plain.RenderSVG(f)
+		require.NoError(t, err)
+		assert.NotContains(t, without.Body, "goshot-reflection")
+	})
+
+	t.Run("scaled", func(t *testing.T) {
+		scaled := plain.WithReflection(30, 0.5).Scaled(2).(ColorBackground)
+		assert.Equal(t, 60, scaled.reflection.height)
+		assert.Equal(t, 0.5, scaled.reflection.opacity)
+	})
+}
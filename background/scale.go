@@ -0,0 +1,147 @@
+package background
+
+import "math"
+
+// scaleInt multiplies a size in pixels by factor
+func scaleInt(v int, factor float64) int {
+	return int(math.Round(float64(v) * factor))
+}
+
+// scaled returns the padding multiplied by factor
+func (p Padding) scaled(factor float64) Padding {
+	return Padding{
+		Top:    scaleInt(p.Top, factor),
+		Right:  scaleInt(p.Right, factor),
+		Bottom: scaleInt(p.Bottom, factor),
+		Left:   scaleInt(p.Left, factor),
+	}
+}
+
+// scaleShadow returns a copy of the shadow with its offset, blur, spread and corner
+// radius multiplied by factor. Shadows implemented elsewhere are kept as they are.
+func scaleShadow(shadow Shadow, factor float64) Shadow {
+	s, ok := shadow.(*shadowImpl)
+	if !ok {
+		return shadow
+	}
+	return &shadowImpl{
+		offsetX:      s.offsetX * factor,
+		offsetY:      s.offsetY * factor,
+		blur:         s.blur * factor,
+		spread:       s.spread * factor,
+		color:        s.color,
+		cornerRadius: s.cornerRadius * factor,
+	}
+}
+
+// scaleRadius returns the corner radius of the content multiplied by factor, if it's
+// known
+func scaleRadius(radius *float64, factor float64) *float64 {
+	if radius == nil {
+		return nil
+	}
+	r := *radius * factor
+	return &r
+}
+
+// scaled returns the border with its width multiplied by factor
+func (b *border) scaled(factor float64) *border {
+	if b == nil {
+		return nil
+	}
+	return newBorder(max(1, scaleInt(b.width, factor)), b.color)
+}
+
+// scaled returns the reflection with its height multiplied by factor
+func (r *reflection) scaled(factor float64) *reflection {
+	if r == nil {
+		return nil
+	}
+	return newReflection(max(1, scaleInt(r.height, factor)), r.opacity)
+}
+
+// scaled returns the blur with its radius multiplied by factor
+func (b *BlurConfig) scaled(factor float64) *BlurConfig {
+	if b == nil {
+		return nil
+	}
+	return &BlurConfig{Type: b.Type, Radius: b.Radius * factor}
+}
+
+// Scaled implements the Scaler interface
+func (bg ColorBackground) Scaled(factor float64) Background {
+	bg.reflection = bg.reflection.scaled(factor)
+	bg.padding = bg.padding.scaled(factor)
+	bg.cornerRadius *= factor
+	bg.shadow = scaleShadow(bg.shadow, factor)
+	bg.contentRadius = scaleRadius(bg.contentRadius, factor)
+	bg.border = bg.border.scaled(factor)
+	return bg
+}
+
+// Scaled implements the Scaler interface
+func (bg GradientBackground) Scaled(factor float64) Background {
+	bg.reflection = bg.reflection.scaled(factor)
+	bg.blur = bg.blur.scaled(factor)
+	bg.padding = bg.padding.scaled(factor)
+	bg.cornerRadius *= factor
+	bg.shadow = scaleShadow(bg.shadow, factor)
+	bg.contentRadius = scaleRadius(bg.contentRadius, factor)
+	bg.border = bg.border.scaled(factor)
+	return bg
+}
+
+// Scaled implements the Scaler interface
+func (bg ImageBackground) Scaled(factor float64) Background {
+	bg.reflection = bg.reflection.scaled(factor)
+	bg.blur = bg.blur.scaled(factor)
+	bg.padding = bg.padding.scaled(factor)
+	bg.cornerRadius *= factor
+	bg.shadow = scaleShadow(bg.shadow, factor)
+	bg.contentRadius = scaleRadius(bg.contentRadius, factor)
+	bg.border = bg.border.scaled(factor)
+	return bg
+}
+
+// Scaled implements the Scaler interface
+func (bg MeshGradient) Scaled(factor float64) Background {
+	bg.reflection = bg.reflection.scaled(factor)
+	bg.padding = bg.padding.scaled(factor)
+	bg.cornerRadius *= factor
+	bg.shadow = scaleShadow(bg.shadow, factor)
+	bg.contentRadius = scaleRadius(bg.contentRadius, factor)
+	bg.border = bg.border.scaled(factor)
+	return bg
+}
+
+// Scaled implements the Scaler interface. The cells of the pattern grow along with
+// everything else.
+func (bg PatternBackground) Scaled(factor float64) Background {
+	bg.reflection = bg.reflection.scaled(factor)
+	bg.spacing = max(1, scaleInt(bg.spacing, factor))
+	bg.thickness *= factor
+	bg.padding = bg.padding.scaled(factor)
+	bg.cornerRadius *= factor
+	bg.shadow = scaleShadow(bg.shadow, factor)
+	bg.contentRadius = scaleRadius(bg.contentRadius, factor)
+	bg.border = bg.border.scaled(factor)
+	return bg
+}
+
+// Scaled implements the Scaler interface. Layers that can't be scaled are kept as they
+// are.
+func (bg LayeredBackground) Scaled(factor float64) Background {
+	layers := make([]layer, len(bg.layers))
+	for i, l := range bg.layers {
+		if s, ok := l.background.(Scaler); ok {
+			l.background = s.Scaled(factor)
+		}
+		layers[i] = l
+	}
+	bg.layers = layers
+	bg.padding = bg.padding.scaled(factor)
+	bg.cornerRadius *= factor
+	bg.shadow = scaleShadow(bg.shadow, factor)
+	bg.contentRadius = scaleRadius(bg.contentRadius, factor)
+	return bg
+}
@@ -76,12 +76,17 @@ func (s *shadowImpl) WithCornerRadius(radius float64) Shadow {
 	return s
 }
 
+// expandBy returns how far the shadow grows the content on each side
+func (s *shadowImpl) expandBy() int {
+	maxOffset := math.Max(math.Abs(s.offsetX), math.Abs(s.offsetY))
+	return int(math.Ceil(s.blur + s.spread + maxOffset))
+}
+
 func (s *shadowImpl) Apply(img image.Image) image.Image {
 	bounds := img.Bounds()
 
 	// Calculate the expanded bounds to accommodate shadow and offset
-	maxOffset := math.Max(math.Abs(s.offsetX), math.Abs(s.offsetY))
-	expandBy := int(math.Ceil(s.blur + s.spread + maxOffset))
+	expandBy := s.expandBy()
 
 	// Create new bounds that can accommodate the shadow in any direction
 	newBounds := image.Rectangle{
@@ -118,8 +123,9 @@ func (s *shadowImpl) Apply(img image.Image) image.Image {
 	}
 	drawRoundedRect(shadowMask, shadowBounds, s.color, cornerRadius)
 
-	// Apply gaussian blur to the shadow mask
-	blurredShadow := applyGaussianBlur(shadowMask, s.blur)
+	// Apply gaussian blur to the shadow mask, the blur radius spanning two standard
+	// deviations as in CSS
+	blurredShadow := gaussianBlur(shadowMask, s.blur/2)
 
 	// Draw the blurred shadow
 	draw.Draw(shadowImg, newBounds, blurredShadow, image.Point{}, draw.Over)
@@ -133,125 +139,3 @@ func (s *shadowImpl) Apply(img image.Image) image.Image {
 
 	return shadowImg
 }
-
-// applyGaussianBlur applies a gaussian blur effect to the input image
-func applyGaussianBlur(img *image.RGBA, radius float64) *image.RGBA {
-	if radius <= 0 {
-		return img
-	}
-
-	// Calculate kernel size (must be odd)
-	size := int(math.Ceil(radius * 6))
-	if size%2 == 0 {
-		size++
-	}
-
-	// Create gaussian kernel
-	kernel := makeGaussianKernel(radius, size)
-
-	// Apply horizontal blur
-	horizontal := horizontalBlur(img, kernel, size)
-
-	// Apply vertical blur
-	return verticalBlur(horizontal, kernel, size)
-}
-
-// makeGaussianKernel creates a 1D gaussian kernel
-func makeGaussianKernel(radius float64, size int) []float64 {
-	kernel := make([]float64, size)
-	sigma := radius / 2
-	twoSigmaSquare := 2 * sigma * sigma
-	sum := 0.0
-
-	mid := size / 2
-	for i := 0; i < size; i++ {
-		x := float64(i - mid)
-		kernel[i] = math.Exp(-(x * x) / (twoSigmaSquare))
-		sum += kernel[i]
-	}
-
-	// Normalize kernel
-	for i := range kernel {
-		kernel[i] /= sum
-	}
-
-	return kernel
-}
-
-// horizontalBlur applies the gaussian kernel horizontally
-func horizontalBlur(img *image.RGBA, kernel []float64, size int) *image.RGBA {
-	bounds := img.Bounds()
-	result := image.NewRGBA(bounds)
-	mid := size / 2
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			var r, g, b, a float64
-
-			for k := 0; k < size; k++ {
-				kx := x + k - mid
-				if kx < bounds.Min.X {
-					kx = bounds.Min.X
-				}
-				if kx >= bounds.Max.X {
-					kx = bounds.Max.X - 1
-				}
-
-				c := img.RGBAAt(kx, y)
-				weight := kernel[k]
-				r += float64(c.R) * weight
-				g += float64(c.G) * weight
-				b += float64(c.B) * weight
-				a += float64(c.A) * weight
-			}
-
-			result.Set(x, y, color.RGBA{
-				R: uint8(math.Min(math.Max(r, 0), 255)),
-				G: uint8(math.Min(math.Max(g, 0), 255)),
-				B: uint8(math.Min(math.Max(b, 0), 255)),
-				A: uint8(math.Min(math.Max(a, 0), 255)),
-			})
-		}
-	}
-
-	return result
-}
-
-// verticalBlur applies the gaussian kernel vertically
-func verticalBlur(img *image.RGBA, kernel []float64, size int) *image.RGBA {
-	bounds := img.Bounds()
-	result := image.NewRGBA(bounds)
-	mid := size / 2
-
-	for x := bounds.Min.X; x < bounds.Max.X; x++ {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			var r, g, b, a float64
-
-			for k := 0; k < size; k++ {
-				ky := y + k - mid
-				if ky < bounds.Min.Y {
-					ky = bounds.Min.Y
-				}
-				if ky >= bounds.Max.Y {
-					ky = bounds.Max.Y - 1
-				}
-
-				c := img.RGBAAt(x, ky)
-				weight := kernel[k]
-				r += float64(c.R) * weight
-				g += float64(c.G) * weight
-				b += float64(c.B) * weight
-				a += float64(c.A) * weight
-			}
-
-			result.Set(x, y, color.RGBA{
-				R: uint8(math.Min(math.Max(r, 0), 255)),
-				G: uint8(math.Min(math.Max(g, 0), 255)),
-				B: uint8(math.Min(math.Max(b, 0), 255)),
-				A: uint8(math.Min(math.Max(a, 0), 255)),
-			})
-		}
-	}
-
-	return result
-}
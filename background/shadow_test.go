@@ -0,0 +1,37 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowFollowsContentCornerRadius(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 40, 40))
+
+	// shadowAt returns how dark the shadow makes the background at x, y
+	shadowAt := func(bg Background, x, y int) uint32 {
+		img, err := bg.Render(content)
+		require.NoError(t, err)
+		r, _, _, _ := img.At(x, y).RGBA()
+		return 0xffff - r
+	}
+
+	gradient := NewGradientBackground(LinearGradient, GradientStop{Color: white, Position: 0}, GradientStop{Color: white, Position: 1}).
+		WithPadding(10)
+
+	// Without knowing the radius of the content, the shadow of the transparent content
+	// is a sharp rectangle
+	square := gradient.WithShadow(NewShadow().WithOffset(0, 0).WithBlur(1))
+	// The corner of the content is 11 pixels in, past the padding and the shadow
+	assert.Greater(t, shadowAt(square, 11, 11), uint32(0x1000))
+
+	// Rounded like the content, the shadow leaves its corners alone
+	rounded := square.(GradientBackground).WithContentCornerRadius(12)
+	assert.Less(t, shadowAt(rounded, 11, 11), uint32(0x100))
+	assert.Greater(t, shadowAt(rounded, 31, 31), uint32(0x1000))
+}
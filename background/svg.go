@@ -0,0 +1,296 @@
+package background
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/watzon/goshot/svg"
+)
+
+// SVGBackground is implemented by backgrounds that can also be rendered as SVG
+type SVGBackground interface {
+	// RenderSVG places the content on the background. A nil content renders just
+	// the padding, like Render does.
+	RenderSVG(content *svg.Fragment) (*svg.Fragment, error)
+}
+
+// renderSVG lays out the content on a background the way the raster renderers do,
+// with its shadow and inset by the padding, strokes the border around it and draws
+// its reflection. fill draws the background itself at the size of the result.
+func renderSVG(content *svg.Fragment, padding Padding, shadow Shadow, border *border, reflection *reflection, radius float64, fill func(width, height int) (string, error)) (*svg.Fragment, error) {
+	if content == nil {
+		content = &svg.Fragment{}
+	}
+
+	// The shadow grows the content on every side, just like Shadow.Apply
+	var shadowBody string
+	expandBy := 0
+	if s, ok := shadow.(*shadowImpl); ok {
+		shadowBody, expandBy = s.svg(content.Width, content.Height)
+	}
+
+	width := content.Width + expandBy*2 + padding.Left + padding.Right
+	height := content.Height + expandBy*2 + padding.Top + padding.Bottom
+
+	body, err := fill(width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString(body)
+	b.WriteString(border.svg(cardRect(width, height, padding, shadow), radius))
+	b.WriteString(reflection.svg(cardRect(width, height, padding, shadow), height, content.Body))
+	if shadowBody != "" {
+		b.WriteString(svg.Translate(shadowBody, float64(padding.Left), float64(padding.Top)))
+	}
+	b.WriteString(svg.Translate(content.Body, float64(padding.Left+expandBy), float64(padding.Top+expandBy)))
+
+	return &svg.Fragment{Width: width, Height: height, Body: b.String()}, nil
+}
+
+// svg returns the shadow of content of the given size, along with how far the shadow
+// grows the content on each side. The shadow is drawn relative to the grown area.
+func (s *shadowImpl) svg(width, height int) (string, int) {
+	expandBy := s.expandBy()
+
+	cornerRadius := s.cornerRadius
+	if s.spread > 0 {
+		cornerRadius += s.spread
+	}
+
+	size := float64(expandBy * 2)
+	var b strings.Builder
+	attrs := svg.Paint("fill", s.color)
+	if s.blur > 0 {
+		// The raster blur uses a sigma of half the radius, and doesn't spill over
+		// the grown area
+		fmt.Fprintf(&b, "<defs><filter id=\"goshot-shadow\" filterUnits=\"userSpaceOnUse\" x=\"0\" y=\"0\" width=\"%s\" height=\"%s\"><feGaussianBlur stdDeviation=\"%s\"/></filter></defs>\n",
+			svg.Number(float64(width)+size), svg.Number(float64(height)+size), svg.Number(s.blur/2))
+		attrs += ` filter="url(#goshot-shadow)"`
+	}
+	b.WriteString(svg.RoundedRect(
+		float64(expandBy+int(s.offsetX)-int(s.spread)),
+		float64(expandBy+int(s.offsetY)-int(s.spread)),
+		float64(width+int(s.spread)*2),
+		float64(height+int(s.spread)*2),
+		cornerRadius, attrs))
+
+	return b.String(), expandBy
+}
+
+// rasterFill renders the fill of a background as an embedded image, for the
+// fills that have no vector equivalent
+func rasterFill(bg Background, width, height int) (string, error) {
+	img, err := bg.Render(image.NewRGBA(image.Rect(0, 0, width, height)))
+	if err != nil {
+		return "", err
+	}
+	f, err := svg.Image(img)
+	if err != nil {
+		return "", err
+	}
+	return f.Body, nil
+}
+
+// RenderSVG implements the SVGBackground interface
+func (bg ColorBackground) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	// Match Render, which gives the shadow the background's corner radius
+	if s, ok := bg.shadow.(*shadowImpl); ok {
+		s.cornerRadius = cardRadius(bg.contentRadius, bg.cornerRadius)
+	}
+
+	return renderSVG(content, bg.padding, bg.shadow, bg.border, bg.reflection, cardRadius(bg.contentRadius, bg.cornerRadius), func(width, height int) (string, error) {
+		fill := svg.RoundedRect(0, 0, float64(width), float64(height), bg.cornerRadius, svg.Paint("fill", bg.color))
+		if bg.centerImage == nil || bg.centerScale <= 0 {
+			return fill, nil
+		}
+
+		// The center image is drawn on its own transparent layer
+		layer := image.NewRGBA(image.Rect(0, 0, width, height))
+		bg.drawCenterImage(layer)
+		f, err := svg.Image(layer)
+		if err != nil {
+			return "", err
+		}
+		return fill + f.Body, nil
+	})
+}
+
+// RenderSVG implements the SVGBackground interface. Linear and radial gradients are
+// drawn as SVG gradients, while the other types and blurred gradients are embedded
+// as images.
+func (bg GradientBackground) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	fitShadow(bg.shadow, bg.contentRadius)
+	return renderSVG(content, bg.padding, bg.shadow, bg.border, bg.reflection, cardRadius(bg.contentRadius, bg.cornerRadius), func(width, height int) (string, error) {
+		if bg.blur != nil || (bg.gradientType != LinearGradient && bg.gradientType != RadialGradient) {
+			plain := bg
+			plain.shadow = nil
+			plain.padding = Padding{}
+			plain.border = nil
+			return rasterFill(plain, width, height)
+		}
+
+		var stops strings.Builder
+		for _, stop := range bg.stops {
+			fmt.Fprintf(&stops, "<stop offset=\"%s\"%s/>", svg.Number(stop.Position), svg.StopColor(stop.Color))
+		}
+
+		var gradient string
+		w, h := float64(width), float64(height)
+		if bg.gradientType == LinearGradient {
+			// Render projects each point onto the angle and divides by the extent of
+			// the image along it, which is exactly an SVG gradient ending at that extent
+			angle := bg.angle * math.Pi / 180
+			extent := w*math.Abs(math.Cos(angle)) + h*math.Abs(math.Sin(angle))
+			gradient = fmt.Sprintf("<linearGradient id=\"goshot-bg-gradient\" gradientUnits=\"userSpaceOnUse\" x1=\"0\" y1=\"0\" x2=\"%s\" y2=\"%s\">%s</linearGradient>",
+				svg.Number(extent*math.Cos(angle)), svg.Number(extent*math.Sin(angle)), stops.String())
+		} else {
+			cx, cy := w*bg.centerX, h*bg.centerY
+			radius := math.Sqrt(math.Max(cx*cx, math.Pow(w-cx, 2)) + math.Max(cy*cy, math.Pow(h-cy, 2)))
+			gradient = fmt.Sprintf("<radialGradient id=\"goshot-bg-gradient\" gradientUnits=\"userSpaceOnUse\" cx=\"%s\" cy=\"%s\" r=\"%s\">%s</radialGradient>",
+				svg.Number(cx), svg.Number(cy), svg.Number(radius), stops.String())
+		}
+
+		return "<defs>" + gradient + "</defs>\n" +
+			svg.RoundedRect(0, 0, w, h, bg.cornerRadius, ` fill="url(#goshot-bg-gradient)"`), nil
+	})
+}
+
+// RenderSVG implements the SVGBackground interface. The image is embedded as is.
+func (bg ImageBackground) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	fitShadow(bg.shadow, bg.contentRadius)
+	return renderSVG(content, bg.padding, bg.shadow, bg.border, bg.reflection, cardRadius(bg.contentRadius, bg.cornerRadius), func(width, height int) (string, error) {
+		plain := bg
+		plain.shadow = nil
+		plain.padding = Padding{}
+		plain.border = nil
+		return rasterFill(plain, width, height)
+	})
+}
+
+// RenderSVG implements the SVGBackground interface. The mesh has no SVG equivalent,
+// so it is embedded as an image.
+func (bg MeshGradient) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	fitShadow(bg.shadow, bg.contentRadius)
+	return renderSVG(content, bg.padding, bg.shadow, bg.border, bg.reflection, cardRadius(bg.contentRadius, bg.cornerRadius), func(width, height int) (string, error) {
+		plain := bg
+		plain.shadow = nil
+		plain.padding = Padding{}
+		plain.border = nil
+		return rasterFill(plain, width, height)
+	})
+}
+
+// RenderSVG implements the SVGBackground interface. The pattern is drawn as an SVG
+// pattern, with the same cells as Render.
+func (bg PatternBackground) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	// Match Render, which gives the shadow the background's corner radius
+	if s, ok := bg.shadow.(*shadowImpl); ok {
+		s.cornerRadius = cardRadius(bg.contentRadius, bg.cornerRadius)
+	}
+
+	return renderSVG(content, bg.padding, bg.shadow, bg.border, bg.reflection, cardRadius(bg.contentRadius, bg.cornerRadius), func(width, height int) (string, error) {
+		w, h := float64(width), float64(height)
+		fill := svg.RoundedRect(0, 0, w, h, bg.cornerRadius, svg.Paint("fill", bg.background))
+		if bg.spacing <= 0 || bg.foreground == nil {
+			return fill, nil
+		}
+
+		s, t := float64(bg.spacing), bg.thickness
+		size := s
+		var shapes string
+		switch bg.pattern {
+		case PatternDots:
+			shapes = fmt.Sprintf("<circle cx=\"%s\" cy=\"%s\" r=\"%s\"/>", svg.Number(s/2), svg.Number(s/2), svg.Number(t/2))
+		case PatternGrid:
+			// Lines run along the edges of the cells, so each edge of the tile has half of one
+			shapes = svg.RoundedRect(0, 0, t/2, s, 0, "") + svg.RoundedRect(s-t/2, 0, t/2, s, 0, "") +
+				svg.RoundedRect(0, 0, s, t/2, 0, "") + svg.RoundedRect(0, s-t/2, s, t/2, 0, "")
+		case PatternCheckerboard:
+			size = 2 * s
+			shapes = svg.RoundedRect(s, 0, s, s, 0, "") + svg.RoundedRect(0, s, s, s, 0, "")
+		case PatternDiagonalLines:
+			// The line through the middle of the tile, and the corners of its neighbors
+			shapes = fmt.Sprintf("<path d=\"M0 %[1]s L%[1]s 0 M-1 1 L1 -1 M%[2]s %[3]s L%[3]s %[2]s\" stroke-width=\"%[4]s\"%[5]s/>",
+				svg.Number(s), svg.Number(s-1), svg.Number(s+1), svg.Number(t), svg.Paint("stroke", bg.foreground))
+		}
+		shapes = strings.ReplaceAll(shapes, "\n", "")
+
+		pattern := fmt.Sprintf("<pattern id=\"goshot-bg-pattern\" patternUnits=\"userSpaceOnUse\" width=\"%s\" height=\"%s\"><g%s>%s</g></pattern>",
+			svg.Number(size), svg.Number(size), svg.Paint("fill", bg.foreground), shapes)
+		return "<defs>" + pattern + "</defs>\n" + fill +
+			svg.RoundedRect(0, 0, w, h, bg.cornerRadius, ` fill="url(#goshot-bg-pattern)"`), nil
+	})
+}
+
+// RenderSVG implements the SVGBackground interface. Layers that can't be rendered as
+// SVG are embedded as images.
+func (bg LayeredBackground) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	if content == nil {
+		content = &svg.Fragment{}
+	}
+
+	// The shadow grows the content on every side, just like in Render
+	var shadowBody string
+	expandBy := 0
+	if s, ok := bg.shadow.(*shadowImpl); ok {
+		s.cornerRadius = cardRadius(bg.contentRadius, bg.cornerRadius)
+		shadowBody, expandBy = s.svg(content.Width, content.Height)
+	}
+	grownWidth, grownHeight := content.Width+expandBy*2, content.Height+expandBy*2
+	paddedWidth := grownWidth + bg.padding.Left + bg.padding.Right
+	paddedHeight := grownHeight + bg.padding.Top + bg.padding.Bottom
+
+	width, height := paddedWidth, paddedHeight
+	layers := make([]*svg.Fragment, len(bg.layers))
+	for i := range bg.layers {
+		var f *svg.Fragment
+		var err error
+		layer := bg.background(i)
+		if sb, ok := layer.(SVGBackground); ok {
+			f, err = sb.RenderSVG(&svg.Fragment{Width: paddedWidth, Height: paddedHeight})
+		} else {
+			var img image.Image
+			img, err = layer.Render(image.NewRGBA(image.Rect(0, 0, paddedWidth, paddedHeight)))
+			if err == nil {
+				f, err = svg.Image(img)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Keep the ids of layers of the same kind apart
+		f.Body = strings.ReplaceAll(f.Body, "goshot-bg-", fmt.Sprintf("goshot-bg-layer%d-", i))
+		layers[i] = f
+		width, height = max(width, f.Width), max(height, f.Height)
+	}
+
+	var b strings.Builder
+	clip := ""
+	if bg.cornerRadius > 0 {
+		fmt.Fprintf(&b, "<defs><clipPath id=\"goshot-bg-layers-clip\">%s</clipPath></defs>\n",
+			strings.TrimSuffix(svg.RoundedRect(0, 0, float64(width), float64(height), bg.cornerRadius, ""), "\n"))
+		clip = ` clip-path="url(#goshot-bg-layers-clip)"`
+	}
+	fmt.Fprintf(&b, "<g%s>\n", clip)
+	for i, l := range bg.layers {
+		f := layers[i]
+		body := svg.Translate(f.Body, float64((width-f.Width)/2), float64((height-f.Height)/2))
+		if l.opacity < 1 {
+			body = fmt.Sprintf("<g opacity=\"%s\">\n%s</g>\n", svg.Number(l.opacity), body)
+		}
+		b.WriteString(body)
+	}
+	b.WriteString("</g>\n")
+
+	x, y := float64((width-paddedWidth)/2+bg.padding.Left), float64((height-paddedHeight)/2+bg.padding.Top)
+	if shadowBody != "" {
+		b.WriteString(svg.Translate(shadowBody, x, y))
+	}
+	b.WriteString(svg.Translate(content.Body, x+float64(expandBy), y+float64(expandBy)))
+
+	return &svg.Fragment{Width: width, Height: height, Body: b.String()}, nil
+}
@@ -0,0 +1,41 @@
+package background
+
+import (
+	"image"
+	"math"
+)
+
+// vignetteStart is how far from the center to the corners, as a fraction, the
+// vignette starts darkening the background
+const vignetteStart = 0.3
+
+// applyVignette darkens img toward its edges, multiplying the brightness of each pixel
+// by a falloff that's 1 around the center and 1 - intensity in the corners. An
+// intensity of 0 leaves the image as it is.
+func applyVignette(img *image.RGBA, intensity float64) {
+	intensity = math.Max(0, math.Min(1, intensity))
+	if intensity == 0 {
+		return
+	}
+
+	bounds := img.Bounds()
+	halfW, halfH := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		dy := (float64(y-bounds.Min.Y) + 0.5 - halfH) / halfH
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dx := (float64(x-bounds.Min.X) + 0.5 - halfW) / halfW
+
+			// The distance from the center is 0 there and 1 in the corners, so that
+			// the falloff follows the shape of the image
+			d := math.Min(1, math.Sqrt(dx*dx+dy*dy)/math.Sqrt2)
+			t := math.Max(0, (d-vignetteStart)/(1-vignetteStart))
+			factor := 1 - intensity*t*t*(3-2*t)
+
+			// Colors are premultiplied, so scaling the channels keeps the alpha valid
+			i := img.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				img.Pix[i+c] = uint8(math.Round(float64(img.Pix[i+c]) * factor))
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package background
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVignette(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	photo := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	draw.Draw(photo, photo.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	gradient := NewGradientBackground(LinearGradient, GradientStop{Color: white, Position: 0}, GradientStop{Color: white, Position: 1})
+
+	tests := []struct {
+		name     string
+		plain    Background
+		vignette func(intensity float64) Background
+	}{
+		{"gradient", gradient, func(intensity float64) Background { return gradient.WithVignette(intensity) }},
+		{"image", NewImageBackground(photo).WithScaleMode(ImageScaleStretch), func(intensity float64) Background {
+			return NewImageBackground(photo).WithScaleMode(ImageScaleStretch).WithVignette(intensity)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := image.NewRGBA(image.Rect(0, 0, 160, 60))
+			render := func(bg Background) *image.RGBA {
+				img, err := bg.Render(content)
+				require.NoError(t, err)
+				return img.(*image.RGBA)
+			}
+
+			plain := render(tt.plain)
+			assert.Equal(t, plain, render(tt.vignette(0)), "An intensity of 0 is off")
+
+			mild, strong := render(tt.vignette(0.4)), render(tt.vignette(1))
+			center := image.Pt(plain.Bounds().Dx()/2, plain.Bounds().Dy()/2)
+			assert.Equal(t, white, strong.RGBAAt(center.X, center.Y), "The center keeps its brightness")
+			corner := mild.RGBAAt(0, 0)
+			assert.Less(t, corner.R, white.R, "The edges are darkened")
+			assert.Less(t, strong.RGBAAt(0, 0).R, corner.R, "More so at a higher intensity")
+			assert.Equal(t, white.A, corner.A, "The background stays opaque")
+		})
+	}
+}
@@ -3,8 +3,6 @@ package chrome
 import (
 	"image"
 	"image/color"
-
-	"github.com/fogleman/gg"
 )
 
 const (
@@ -15,6 +13,7 @@ const (
 type BlankChrome struct {
 	theme        Theme
 	cornerRadius float64
+	scale        float64 // Pixels per unit drawn, set with Scaled; 0 means 1
 }
 
 // NewBlankChrome creates a new blank window chrome
@@ -86,7 +85,7 @@ func (c *BlankChrome) Render(content image.Image) (image.Image, error) {
 	content, width, height := contentOrBlank(c, content)
 
 	// Create context for drawing
-	dc := gg.NewContext(width, height)
+	dc, width, height := newWindowContext(width, height, 0, c.scale)
 
 	// Draw the base window with rounded corners
 	if err := DrawWindowBase(dc, width, height, c.cornerRadius,
@@ -97,13 +96,31 @@ func (c *BlankChrome) Render(content image.Image) (image.Image, error) {
 	}
 
 	// Draw content
-	dc.DrawImage(content, 0, 0)
+	drawContent(dc, content, 0)
 
 	return dc.Image(), nil
 }
 
+// RenderFrame renders an empty window of the given content size, with the content
+// area filled with the theme's content background
+func (c *BlankChrome) RenderFrame(width, height int) (image.Image, error) {
+	return RenderFrameWithFill(c, width, height, c.theme.Properties.ContentBackground)
+}
+
 func (c *BlankChrome) MinimumSize() (width, height int) {
-	return 100, 100 // Minimal reasonable size
+	return scaleSize(100, c.scale), scaleSize(100, c.scale) // Minimal reasonable size
+}
+
+// CornerRadius implements the RoundedChrome interface
+func (c *BlankChrome) CornerRadius() float64 {
+	return c.cornerRadius * scaleFactor(c.scale)
+}
+
+// Scaled implements the Scaler interface
+func (c *BlankChrome) Scaled(factor float64) Chrome {
+	scaled := *c
+	scaled.scale = factor
+	return &scaled
 }
 
 func (c *BlankChrome) ContentInsets() (top, right, bottom, left int) {
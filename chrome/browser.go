@@ -0,0 +1,655 @@
+package chrome
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/watzon/goshot/fonts"
+	"golang.org/x/image/font"
+)
+
+const (
+	browserTabStripHeight    = 38
+	browserToolbarHeight     = 40
+	browserDefaultFontSize   = 12
+	browserDefaultCorner     = 8.0
+	browserMaxTabWidth       = 220
+	browserTabRadius         = 8
+	browserTabPadding        = 12
+	browserNavButtonWidth    = 28
+	browserAddressBarHeight  = 28
+	browserControlWidth      = 36 // Width of each window button of the Chromium style
+	browserTrafficLightSize  = 12
+	browserTrafficLightSpace = 8
+	browserEdgePadding       = 8
+)
+
+// BrowserStyle represents different web browser UI styles
+type BrowserStyle string
+
+const (
+	BrowserStyleChromium BrowserStyle = "chromium" // Chrome, Edge and other Chromium browsers
+	BrowserStyleSafari   BrowserStyle = "safari"   // Safari on macOS
+)
+
+// BrowserChrome implements the Chrome interface with a web browser's tab strip, address
+// bar and navigation buttons
+type BrowserChrome struct {
+	theme        Theme
+	cornerRadius float64
+	title        string
+	themeName    string
+	variant      ThemeVariant
+	titleBar     bool
+	style        BrowserStyle
+	url          string
+	tabs         []string
+	activeTab    int
+	controls     controlColors
+	scale        float64 // Pixels per unit drawn, set with Scaled; 0 means 1
+}
+
+func init() {
+	// Register Chromium themes
+	registerBrowserChromiumThemes()
+	// Register Safari themes
+	registerBrowserSafariThemes()
+}
+
+func registerBrowserChromiumThemes() {
+	lightTheme := Theme{
+		Type:    ThemeTypeBrowser,
+		Variant: ThemeVariantLight,
+		Name:    "chromium",
+		Properties: ThemeProperties{
+			TitleFont:          "Inter",
+			TitleFontSize:      browserDefaultFontSize,
+			TitleBackground:    color.RGBA{R: 222, G: 225, B: 230, A: 255},
+			TitleText:          color.RGBA{R: 32, G: 33, B: 36, A: 255},
+			ControlsColor:      color.RGBA{R: 95, G: 99, B: 104, A: 255},
+			ContentBackground:  color.White,
+			TextColor:          color.RGBA{R: 32, G: 33, B: 36, A: 255},
+			AccentColor:        color.RGBA{R: 26, G: 115, B: 232, A: 255},
+			BorderColor:        color.RGBA{R: 199, G: 202, B: 207, A: 255},
+			InactiveTitleBg:    color.RGBA{R: 232, G: 234, B: 237, A: 255},
+			InactiveTitleText:  color.RGBA{R: 95, G: 99, B: 104, A: 255},
+			ButtonHoverColor:   color.RGBA{R: 232, G: 234, B: 237, A: 255},
+			ButtonPressedColor: color.RGBA{R: 218, G: 220, B: 224, A: 255},
+			CornerRadius:       browserDefaultCorner,
+			BorderWidth:        1.0,
+			CustomProperties: map[string]any{
+				"style":                BrowserStyleChromium,
+				"toolbarBackground":    color.RGBA{R: 255, G: 255, B: 255, A: 255},
+				"addressBarBackground": color.RGBA{R: 241, G: 243, B: 244, A: 255},
+			},
+		},
+	}
+
+	darkTheme := Theme{
+		Type:    ThemeTypeBrowser,
+		Variant: ThemeVariantDark,
+		Name:    "chromium",
+		Properties: ThemeProperties{
+			TitleFont:          "Inter",
+			TitleFontSize:      browserDefaultFontSize,
+			TitleBackground:    color.RGBA{R: 32, G: 33, B: 36, A: 255},
+			TitleText:          color.RGBA{R: 232, G: 234, B: 237, A: 255},
+			ControlsColor:      color.RGBA{R: 189, G: 193, B: 198, A: 255},
+			ContentBackground:  color.RGBA{R: 32, G: 33, B: 36, A: 255},
+			TextColor:          color.RGBA{R: 232, G: 234, B: 237, A: 255},
+			AccentColor:        color.RGBA{R: 138, G: 180, B: 248, A: 255},
+			BorderColor:        color.RGBA{R: 72, G: 73, B: 77, A: 255},
+			InactiveTitleBg:    color.RGBA{R: 41, G: 42, B: 45, A: 255},
+			InactiveTitleText:  color.RGBA{R: 154, G: 160, B: 166, A: 255},
+			ButtonHoverColor:   color.RGBA{R: 65, G: 66, B: 70, A: 255},
+			ButtonPressedColor: color.RGBA{R: 80, G: 81, B: 85, A: 255},
+			CornerRadius:       browserDefaultCorner,
+			BorderWidth:        1.0,
+			CustomProperties: map[string]any{
+				"style":                BrowserStyleChromium,
+				"toolbarBackground":    color.RGBA{R: 53, G: 54, B: 58, A: 255},
+				"addressBarBackground": color.RGBA{R: 32, G: 33, B: 36, A: 255},
+			},
+		},
+	}
+
+	DefaultRegistry.RegisterTheme(ThemeTypeBrowser, "chromium", ThemeVariantLight, lightTheme)
+	DefaultRegistry.RegisterTheme(ThemeTypeBrowser, "chromium", ThemeVariantDark, darkTheme)
+}
+
+func registerBrowserSafariThemes() {
+	lightTheme := Theme{
+		Type:    ThemeTypeBrowser,
+		Variant: ThemeVariantLight,
+		Name:    "safari",
+		Properties: ThemeProperties{
+			TitleFont:          "Inter",
+			TitleFontSize:      browserDefaultFontSize,
+			TitleBackground:    color.RGBA{R: 229, G: 229, B: 229, A: 255},
+			TitleText:          color.RGBA{R: 38, G: 38, B: 38, A: 255},
+			ControlsColor:      color.RGBA{R: 110, G: 110, B: 115, A: 255},
+			ContentBackground:  color.White,
+			TextColor:          color.RGBA{R: 38, G: 38, B: 38, A: 255},
+			AccentColor:        color.RGBA{R: 0, G: 122, B: 255, A: 255},
+			BorderColor:        color.RGBA{R: 210, G: 210, B: 210, A: 255},
+			InactiveTitleBg:    color.RGBA{R: 236, G: 236, B: 236, A: 255},
+			InactiveTitleText:  color.RGBA{R: 128, G: 128, B: 128, A: 255},
+			ButtonHoverColor:   color.RGBA{R: 222, G: 222, B: 222, A: 255},
+			ButtonPressedColor: color.RGBA{R: 204, G: 204, B: 204, A: 255},
+			CornerRadius:       browserDefaultCorner,
+			BorderWidth:        1.0,
+			CustomProperties: map[string]any{
+				"style":                BrowserStyleSafari,
+				"toolbarBackground":    color.RGBA{R: 246, G: 246, B: 246, A: 255},
+				"addressBarBackground": color.RGBA{R: 228, G: 228, B: 230, A: 255},
+			},
+		},
+	}
+
+	darkTheme := Theme{
+		Type:    ThemeTypeBrowser,
+		Variant: ThemeVariantDark,
+		Name:    "safari",
+		Properties: ThemeProperties{
+			TitleFont:          "Inter",
+			TitleFontSize:      browserDefaultFontSize,
+			TitleBackground:    color.RGBA{R: 30, G: 30, B: 32, A: 255},
+			TitleText:          color.RGBA{R: 229, G: 229, B: 231, A: 255},
+			ControlsColor:      color.RGBA{R: 174, G: 174, B: 178, A: 255},
+			ContentBackground:  color.RGBA{R: 30, G: 30, B: 30, A: 255},
+			TextColor:          color.RGBA{R: 229, G: 229, B: 231, A: 255},
+			AccentColor:        color.RGBA{R: 10, G: 132, B: 255, A: 255},
+			BorderColor:        color.RGBA{R: 20, G: 20, B: 20, A: 255},
+			InactiveTitleBg:    color.RGBA{R: 40, G: 40, B: 42, A: 255},
+			InactiveTitleText:  color.RGBA{R: 142, G: 142, B: 147, A: 255},
+			ButtonHoverColor:   color.RGBA{R: 64, G: 64, B: 66, A: 255},
+			ButtonPressedColor: color.RGBA{R: 80, G: 80, B: 82, A: 255},
+			CornerRadius:       browserDefaultCorner,
+			BorderWidth:        1.0,
+			CustomProperties: map[string]any{
+				"style":                BrowserStyleSafari,
+				"toolbarBackground":    color.RGBA{R: 50, G: 50, B: 52, A: 255},
+				"addressBarBackground": color.RGBA{R: 72, G: 72, B: 74, A: 255},
+			},
+		},
+	}
+
+	DefaultRegistry.RegisterTheme(ThemeTypeBrowser, "safari", ThemeVariantLight, lightTheme)
+	DefaultRegistry.RegisterTheme(ThemeTypeBrowser, "safari", ThemeVariantDark, darkTheme)
+}
+
+// WithURL sets the text of the address bar of a browser chrome. Other chromes ignore it.
+func WithURL(url string) ChromeOption {
+	return func(c Chrome) Chrome {
+		if b, ok := c.(*BrowserChrome); ok {
+			return b.WithURL(url)
+		}
+		return c
+	}
+}
+
+// WithTabs sets the titles of the tabs of a browser chrome. Other chromes ignore it.
+func WithTabs(titles ...string) ChromeOption {
+	return func(c Chrome) Chrome {
+		if b, ok := c.(*BrowserChrome); ok {
+			return b.WithTabs(titles...)
+		}
+		return c
+	}
+}
+
+// WithActiveTab sets which tab of a browser chrome is selected. Other chromes ignore it.
+func WithActiveTab(index int) ChromeOption {
+	return func(c Chrome) Chrome {
+		if b, ok := c.(*BrowserChrome); ok {
+			return b.WithActiveTab(index)
+		}
+		return c
+	}
+}
+
+// NewBrowserChrome creates a new web browser window chrome
+func NewBrowserChrome(style BrowserStyle, opts ...ChromeOption) *BrowserChrome {
+	chrome := &BrowserChrome{
+		cornerRadius: browserDefaultCorner,
+		title:        "",
+		titleBar:     true,
+		themeName:    string(style),
+		variant:      ThemeVariantLight,
+		style:        style,
+	}
+
+	// Set initial theme
+	if theme, ok := DefaultRegistry.GetTheme(ThemeTypeBrowser, string(style), ThemeVariantLight); ok {
+		chrome.theme = theme
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		chrome = opt(chrome).(*BrowserChrome)
+	}
+
+	return chrome
+}
+
+// WithURL sets the text of the address bar
+func (c *BrowserChrome) WithURL(url string) *BrowserChrome {
+	c.url = url
+	return c
+}
+
+// WithTabs sets the titles of the tabs. Without tabs, the title is shown as the only
+// tab, and without a title either there is no tab strip.
+func (c *BrowserChrome) WithTabs(titles ...string) *BrowserChrome {
+	c.tabs = append([]string(nil), titles...)
+	return c
+}
+
+// WithActiveTab sets the index of the selected tab
+func (c *BrowserChrome) WithActiveTab(index int) *BrowserChrome {
+	c.activeTab = index
+	return c
+}
+
+func (c *BrowserChrome) WithTheme(theme Theme) Chrome {
+	c.theme = theme
+	c.themeName = theme.Name
+	c.variant = theme.Variant
+	if style, ok := theme.Properties.CustomProperties["style"].(BrowserStyle); ok {
+		c.style = style
+	}
+	return c
+}
+
+func (c *BrowserChrome) WithThemeByName(name string, variant ThemeVariant) Chrome {
+	if theme, ok := DefaultRegistry.GetTheme(ThemeTypeBrowser, name, variant); ok {
+		c.themeName = name
+		c.variant = variant
+		c.theme = theme
+		if style, ok := theme.Properties.CustomProperties["style"].(BrowserStyle); ok {
+			c.style = style
+		}
+	}
+	return c
+}
+
+func (c *BrowserChrome) GetCurrentThemeName() string {
+	return c.themeName
+}
+
+func (c *BrowserChrome) GetCurrentVariant() ThemeVariant {
+	return c.variant
+}
+
+func (c *BrowserChrome) WithVariant(variant ThemeVariant) Chrome {
+	return c.WithThemeByName(c.themeName, variant)
+}
+
+func (c *BrowserChrome) CurrentTheme() Theme {
+	return c.theme
+}
+
+func (c *BrowserChrome) WithTitle(title string) Chrome {
+	c.title = title
+	return c
+}
+
+func (c *BrowserChrome) WithCornerRadius(radius float64) Chrome {
+	c.cornerRadius = radius
+	return c
+}
+
+// WithControlColors implements the ControlColorer interface
+func (c *BrowserChrome) WithControlColors(close, minimize, maximize color.Color) Chrome {
+	c.controls = controlColors{close: close, minimize: minimize, maximize: maximize}
+	return c
+}
+
+// WithMonochromeControls implements the ControlColorer interface
+func (c *BrowserChrome) WithMonochromeControls(enabled bool) Chrome {
+	c.controls.monochrome = enabled
+	return c
+}
+
+// WithTitleBar enables or disables the tab strip and toolbar altogether
+func (c *BrowserChrome) WithTitleBar(enabled bool) Chrome {
+	c.titleBar = enabled
+	return c
+}
+
+// Render implements the Chrome interface
+func (c *BrowserChrome) Render(content image.Image) (image.Image, error) {
+	content, width, height := contentOrBlank(c, content)
+	titleBarHeight := c.titleBarHeight()
+
+	// Create context for drawing
+	dc, width, height := newWindowContext(width, height, titleBarHeight, c.scale)
+
+	// Draw the base window with rounded corners
+	if err := DrawWindowBase(dc, width, height+titleBarHeight, c.cornerRadius,
+		c.theme.Properties.TitleBackground,
+		c.theme.Properties.TitleBackground,
+		titleBarHeight); err != nil {
+		return nil, err
+	}
+
+	if c.titleBar {
+		c.renderControls(dc, width)
+
+		labels, err := c.labels(width)
+		if err != nil {
+			return nil, err
+		}
+		if err := drawLabels(dc, labels, c.theme.Properties.TitleFont); err != nil {
+			return nil, err
+		}
+	}
+
+	// Draw content
+	drawContent(dc, content, titleBarHeight)
+
+	return dc.Image(), nil
+}
+
+// RenderFrame renders an empty window of the given content size, with the content
+// area filled with the theme's content background
+func (c *BrowserChrome) RenderFrame(width, height int) (image.Image, error) {
+	return RenderFrameWithFill(c, width, height, c.theme.Properties.ContentBackground)
+}
+
+// visibleTabs returns the titles of the tabs in the tab strip
+func (c *BrowserChrome) visibleTabs() []string {
+	if len(c.tabs) > 0 {
+		return c.tabs
+	}
+	if c.title != "" {
+		return []string{c.title}
+	}
+	return nil
+}
+
+func (c *BrowserChrome) tabStripHeight() int {
+	if c.titleBar && len(c.visibleTabs()) > 0 {
+		return browserTabStripHeight
+	}
+	return 0
+}
+
+func (c *BrowserChrome) titleBarHeight() int {
+	if c.titleBar {
+		return c.tabStripHeight() + browserToolbarHeight
+	}
+	return 0
+}
+
+// windowControlsWidth returns the room taken by the window buttons, on the left for
+// Safari and on the right for Chromium
+func (c *BrowserChrome) windowControlsWidth() int {
+	if c.style == BrowserStyleSafari {
+		return browserEdgePadding*2 + browserTrafficLightSize*3 + browserTrafficLightSpace*2
+	}
+	return browserControlWidth * 3
+}
+
+// rowBounds returns the left and right ends of the free space of a row, after the
+// window buttons if they're in it
+func (c *BrowserChrome) rowBounds(width int, hasControls bool) (left, right int) {
+	left, right = browserEdgePadding, width-browserEdgePadding
+	if hasControls {
+		if c.style == BrowserStyleSafari {
+			left = c.windowControlsWidth()
+		} else {
+			right = width - c.windowControlsWidth() - browserEdgePadding
+		}
+	}
+	return left, right
+}
+
+// tabRects returns where each tab is drawn in the tab strip
+func (c *BrowserChrome) tabRects(width int) []image.Rectangle {
+	tabs := c.visibleTabs()
+	if len(tabs) == 0 {
+		return nil
+	}
+	left, right := c.rowBounds(width, true)
+	tabWidth := min(browserMaxTabWidth, max(0, right-left)/len(tabs))
+
+	rects := make([]image.Rectangle, len(tabs))
+	for i := range tabs {
+		x := left + i*tabWidth
+		rects[i] = image.Rect(x, 6, x+tabWidth, browserTabStripHeight)
+	}
+	return rects
+}
+
+// addressBar returns where the address bar is drawn
+func (c *BrowserChrome) addressBar(width int) image.Rectangle {
+	left, right := c.rowBounds(width, c.tabStripHeight() == 0)
+	top := c.tabStripHeight() + (browserToolbarHeight-browserAddressBarHeight)/2
+	return image.Rect(left+browserNavButtonWidth*3+browserEdgePadding, top, right, top+browserAddressBarHeight)
+}
+
+func (c *BrowserChrome) addressBarRadius() float64 {
+	if c.style == BrowserStyleSafari {
+		return 7
+	}
+	return browserAddressBarHeight / 2
+}
+
+// themeColor returns a color from the custom properties of the theme, or the fallback
+func (c *BrowserChrome) themeColor(key string, fallback color.Color) color.Color {
+	if col, ok := c.theme.Properties.CustomProperties[key].(color.Color); ok {
+		return col
+	}
+	return fallback
+}
+
+// renderControls draws everything but text: the tabs, the toolbar with its
+// navigation buttons and address bar, and the window buttons
+func (c *BrowserChrome) renderControls(dc painter, width int) {
+	props := c.theme.Properties
+	toolbar := c.themeColor("toolbarBackground", props.TitleBackground)
+	stripHeight := c.tabStripHeight()
+
+	// The selected tab blends into the toolbar, which hides its bottom corners
+	for i, rect := range c.tabRects(width) {
+		if i == c.activeTab {
+			dc.SetColor(toolbar)
+			dc.DrawRoundedRectangle(float64(rect.Min.X), float64(rect.Min.Y), float64(rect.Dx()),
+				float64(rect.Dy()+browserTabRadius), browserTabRadius)
+			dc.Fill()
+		} else if i+1 != c.activeTab {
+			dc.SetColor(props.BorderColor)
+			dc.DrawRectangle(float64(rect.Max.X)-1, float64(rect.Min.Y+6), 1, float64(rect.Dy()-12))
+			dc.Fill()
+		}
+	}
+
+	// Toolbar, with a line separating it from the page
+	dc.SetColor(toolbar)
+	dc.DrawRectangle(0, float64(stripHeight), float64(width), browserToolbarHeight)
+	dc.Fill()
+	dc.SetColor(props.BorderColor)
+	dc.DrawRectangle(0, float64(stripHeight+browserToolbarHeight)-1, float64(width), 1)
+	dc.Fill()
+
+	// Back, forward and reload. There's nothing to go forward to.
+	bar := c.addressBar(width)
+	cy := float64(stripHeight) + browserToolbarHeight/2
+	cx := float64(bar.Min.X-browserEdgePadding-browserNavButtonWidth*3) + browserNavButtonWidth/2
+	dc.SetLineWidth(1.5)
+	dc.SetColor(props.ControlsColor)
+	dc.MoveTo(cx+5, cy)
+	dc.LineTo(cx-5, cy)
+	dc.MoveTo(cx, cy-5)
+	dc.LineTo(cx-5, cy)
+	dc.LineTo(cx, cy+5)
+	dc.Stroke()
+
+	cx += browserNavButtonWidth
+	dc.SetColor(props.InactiveTitleText)
+	dc.MoveTo(cx-5, cy)
+	dc.LineTo(cx+5, cy)
+	dc.MoveTo(cx, cy-5)
+	dc.LineTo(cx+5, cy)
+	dc.LineTo(cx, cy+5)
+	dc.Stroke()
+
+	cx += browserNavButtonWidth
+	dc.SetColor(props.ControlsColor)
+	dc.DrawArc(cx, cy, 5, 0, 3*math.Pi/2)
+	dc.Stroke()
+	dc.MoveTo(cx-3, cy-8)
+	dc.LineTo(cx, cy-5)
+	dc.LineTo(cx-3, cy-2)
+	dc.Stroke()
+
+	// Address bar, with a padlock for secure pages
+	dc.SetColor(c.themeColor("addressBarBackground", props.InactiveTitleBg))
+	dc.DrawRoundedRectangle(float64(bar.Min.X), float64(bar.Min.Y), float64(bar.Dx()), float64(bar.Dy()), c.addressBarRadius())
+	dc.Fill()
+	if strings.HasPrefix(c.url, "https://") {
+		x := float64(bar.Min.X) + browserTabPadding
+		y := float64(bar.Min.Y) + float64(bar.Dy())/2
+		dc.SetColor(props.ControlsColor)
+		dc.DrawRectangle(x, y-1, 9, 7)
+		dc.Fill()
+		dc.SetLineWidth(1.25)
+		dc.DrawArc(x+4.5, y-2, 3, math.Pi, 2*math.Pi)
+		dc.Stroke()
+	}
+
+	c.renderWindowControls(dc, width)
+}
+
+// renderWindowControls draws the window buttons in the first row
+func (c *BrowserChrome) renderWindowControls(dc painter, width int) {
+	rowHeight := browserToolbarHeight
+	if c.tabStripHeight() > 0 {
+		rowHeight = browserTabStripHeight
+	}
+
+	if c.style == BrowserStyleSafari {
+		y := float64(rowHeight-browserTrafficLightSize) / 2
+		closeX := float64(browserEdgePadding)
+		minimizeX := closeX + browserTrafficLightSize + browserTrafficLightSpace
+		maximizeX := minimizeX + browserTrafficLightSize + browserTrafficLightSpace
+		drawTrafficLights(dc, c.controls, c.theme.Properties, closeX, minimizeX, maximizeX, y, browserTrafficLightSize)
+		return
+	}
+
+	// Minimize, maximize and close, from left to right
+	props := c.theme.Properties
+	closeColor, minimizeColor, maximizeColor := c.controls.resolve(props, props.ControlsColor, props.ControlsColor, props.ControlsColor)
+	cy := float64(rowHeight) / 2
+	cx := float64(width-browserControlWidth*3) + browserControlWidth/2
+	dc.SetLineWidth(1)
+	dc.SetColor(minimizeColor)
+	dc.DrawLine(cx-5, cy, cx+5, cy)
+	dc.Stroke()
+
+	cx += browserControlWidth
+	dc.SetColor(maximizeColor)
+	dc.DrawRectangle(cx-5, cy-5, 10, 10)
+	dc.Stroke()
+
+	cx += browserControlWidth
+	dc.SetColor(closeColor)
+	dc.DrawLine(cx-5, cy-5, cx+5, cy+5)
+	dc.DrawLine(cx-5, cy+5, cx+5, cy-5)
+	dc.Stroke()
+}
+
+// labels returns the titles of the tabs and the text of the address bar, shortened to
+// fit their room
+func (c *BrowserChrome) labels(width int) ([]textLabel, error) {
+	props := c.theme.Properties
+	fontSize := props.TitleFontSize
+	if fontSize == 0 {
+		fontSize = browserDefaultFontSize
+	}
+
+	face, err := loadFace(fontSize, props.TitleFont, fonts.WeightRegular)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	// baseline centers the text vertically between top and bottom
+	metrics := face.Face.Metrics()
+	baseline := func(top, bottom int) float64 {
+		return float64(top+bottom)/2 + float64(metrics.Ascent-metrics.Descent)/64/2
+	}
+
+	var labels []textLabel
+	tabs := c.visibleTabs()
+	for i, rect := range c.tabRects(width) {
+		col := props.InactiveTitleText
+		if i == c.activeTab {
+			col = props.TitleText
+		}
+		text := fitText(face.Face, tabs[i], float64(rect.Dx()-browserTabPadding*2))
+		labels = append(labels, textLabel{
+			text:     text,
+			x:        float64(rect.Min.X + browserTabPadding),
+			baseline: baseline(rect.Min.Y, rect.Max.Y),
+			color:    col,
+			fontSize: fontSize,
+		})
+	}
+
+	if c.url != "" {
+		bar := c.addressBar(width)
+		x := bar.Min.X + browserTabPadding
+		if strings.HasPrefix(c.url, "https://") {
+			x += 16
+		}
+		labels = append(labels, textLabel{
+			text:     fitText(face.Face, c.url, float64(bar.Max.X-browserTabPadding-x)),
+			x:        float64(x),
+			baseline: baseline(bar.Min.Y, bar.Max.Y),
+			color:    props.TextColor,
+			fontSize: fontSize,
+		})
+	}
+
+	return labels, nil
+}
+
+// fitText shortens text with an ellipsis until it's no wider than maxWidth
+func fitText(face font.Face, text string, maxWidth float64) string {
+	if float64(font.MeasureString(face, text))/64 <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		shortened := strings.TrimRight(string(runes), " ") + "…"
+		if float64(font.MeasureString(face, shortened))/64 <= maxWidth {
+			return shortened
+		}
+	}
+	return ""
+}
+
+func (c *BrowserChrome) MinimumSize() (width, height int) {
+	return scaleSize(300, c.scale), scaleSize(browserToolbarHeight, c.scale) // Minimum size required for the toolbar
+}
+
+// CornerRadius implements the RoundedChrome interface
+func (c *BrowserChrome) CornerRadius() float64 {
+	return c.cornerRadius * scaleFactor(c.scale)
+}
+
+// Scaled implements the Scaler interface
+func (c *BrowserChrome) Scaled(factor float64) Chrome {
+	scaled := *c
+	scaled.scale = factor
+	return &scaled
+}
+
+func (c *BrowserChrome) ContentInsets() (top, right, bottom, left int) {
+	return scaleSize(c.titleBarHeight(), c.scale), 0, 0, 0
+}
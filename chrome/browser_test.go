@@ -0,0 +1,79 @@
+package chrome
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/svg"
+)
+
+func TestBrowserChromeInsets(t *testing.T) {
+	c := NewBrowserChrome(BrowserStyleChromium)
+	top, right, bottom, left := c.ContentInsets()
+	assert.Equal(t, browserToolbarHeight, top, "Without tabs only the toolbar is drawn")
+	assert.Zero(t, right+bottom+left)
+
+	c = NewBrowserChrome(BrowserStyleChromium, WithTabs("One", "Two"))
+	top, _, _, _ = c.ContentInsets()
+	assert.Equal(t, browserTabStripHeight+browserToolbarHeight, top)
+
+	c = NewBrowserChrome(BrowserStyleSafari, WithTitle("Docs"))
+	top, _, _, _ = c.ContentInsets()
+	assert.Equal(t, browserTabStripHeight+browserToolbarHeight, top, "The title is shown as a tab")
+
+	c.WithTitleBar(false)
+	top, _, _, _ = c.ContentInsets()
+	assert.Zero(t, top)
+}
+
+func TestBrowserChromeThemes(t *testing.T) {
+	for _, name := range []string{"chromium", "safari"} {
+		for _, variant := range []ThemeVariant{ThemeVariantLight, ThemeVariantDark} {
+			_, ok := DefaultRegistry.GetTheme(ThemeTypeBrowser, name, variant)
+			assert.True(t, ok, "%s %s should be registered", name, variant)
+		}
+	}
+
+	c := NewBrowserChrome(BrowserStyleChromium)
+	c.WithThemeByName("safari", ThemeVariantDark)
+	assert.Equal(t, BrowserStyleSafari, c.style)
+	assert.Equal(t, "safari", c.GetCurrentThemeName())
+	assert.Equal(t, ThemeVariantDark, c.GetCurrentVariant())
+}
+
+func TestBrowserChromeRender(t *testing.T) {
+	c := NewBrowserChrome(BrowserStyleChromium, WithURL("https://example.com"), WithCornerRadius(0))
+	content := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	img, err := c.Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 400, 100+browserToolbarHeight), img.Bounds())
+
+	// Right of the text, the address bar is filled with its own color
+	bar := c.addressBar(400)
+	assert.Equal(t, c.themeColor("addressBarBackground", nil), img.At(bar.Max.X-20, bar.Min.Y+bar.Dy()/2))
+
+	fragment, err := c.RenderSVG(&svg.Fragment{Width: 400, Height: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 100+browserToolbarHeight, fragment.Height)
+	assert.Contains(t, fragment.Body, ">https://example.com</text>")
+}
+
+func TestBrowserOptionsIgnoredByOtherChromes(t *testing.T) {
+	c := NewMacChrome(MacStyleSequoia, WithURL("https://example.com"), WithTabs("One"), WithActiveTab(0))
+	top, _, _, _ := c.ContentInsets()
+	assert.Equal(t, macDefaultTitleBarHeight, top)
+}
+
+func TestFitText(t *testing.T) {
+	face, err := loadTitleFace(12, "")
+	require.NoError(t, err)
+	defer face.Close()
+
+	assert.Equal(t, "Short", fitText(face.Face, "Short", 200))
+	fitted := fitText(face.Face, strings.Repeat("Long title ", 10), 100)
+	assert.True(t, strings.HasSuffix(fitted, "…"))
+	assert.Equal(t, "", fitText(face.Face, "Anything", 1))
+}
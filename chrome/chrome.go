@@ -12,6 +12,8 @@ const (
 	ThemeTypeWindows ThemeType = "windows"
 	ThemeTypeMac     ThemeType = "mac"
 	ThemeTypeGNOME   ThemeType = "gnome"
+	ThemeTypeBrowser ThemeType = "browser"
+	ThemeTypeMinimal ThemeType = "minimal"
 )
 
 // ThemeVariant represents a variant of a theme (e.g., light or dark)
@@ -42,6 +44,9 @@ type ThemeProperties struct {
 	ButtonPressedColor color.Color    // Button pressed state color
 	CornerRadius       float64        // Window corner radius
 	BorderWidth        float64        // Window border width
+	CloseColor         color.Color    // Close button color, if not the chrome's usual one
+	MinimizeColor      color.Color    // Minimize button color, if not the chrome's usual one
+	MaximizeColor      color.Color    // Maximize button color, if not the chrome's usual one
 	CustomProperties   map[string]any // Additional theme-specific properties
 }
 
@@ -65,10 +70,107 @@ type Chrome interface {
 	GetCurrentThemeName() string
 	GetCurrentVariant() ThemeVariant
 	CurrentTheme() Theme
+	// MinimumSize returns the size of the content area of the empty window Render
+	// draws for nil content
 	MinimumSize() (width, height int)
+	// ContentInsets returns how far the content is drawn from each edge of the image
+	// Render returns, which covers the title bar and any border. The image is the
+	// content grown by the insets.
 	ContentInsets() (top, right, bottom, left int)
 }
 
+// RoundedChrome is implemented by chromes that can report the corner radius of the
+// window they draw, so the background can round its shadow and border to match
+type RoundedChrome interface {
+	CornerRadius() float64
+}
+
+// Scaler is implemented by chromes that can be drawn larger, for high density displays
+type Scaler interface {
+	// Scaled returns a copy of the chrome that draws everything factor times as large,
+	// around content rendered at the same scale. Its MinimumSize and ContentInsets are
+	// in pixels of the scaled window.
+	Scaled(factor float64) Chrome
+}
+
+// FrameRenderer is implemented by chromes that can render an empty window with the
+// content area filled with the content background of their theme, for frames that
+// get composited over content later on (see RenderFrame)
+type FrameRenderer interface {
+	RenderFrame(width, height int) (image.Image, error)
+}
+
+// Align is the horizontal alignment of a window title
+type Align int
+
+const (
+	AlignAuto   Align = iota // Centered, or against the right when the title bar is right to left
+	AlignCenter              // Centered in the title bar
+	AlignLeft                // Against the left edge, past any window controls there
+	AlignRight               // Against the right edge, short of any window controls there
+)
+
+// TitleAligner is implemented by chromes that can align their title and lay out their
+// title bar right to left
+type TitleAligner interface {
+	WithTitleAlignment(align Align) Chrome
+	// WithRightToLeft mirrors the title bar, moving the window controls to the other
+	// side, and reads the title from right to left
+	WithRightToLeft(enabled bool) Chrome
+}
+
+// IconPosition is where an icon goes in a title bar
+type IconPosition int
+
+const (
+	IconLeading  IconPosition = iota // At the start of the title bar, past any window controls there
+	IconTrailing                     // At the end of the title bar, short of any window controls there
+)
+
+// IconChrome is implemented by chromes that can show an icon, like a product logo, in
+// their title bar. The icon is scaled to fit the title bar and left out of windows too
+// narrow to fit it beside the window controls.
+type IconChrome interface {
+	WithTitleIcon(img image.Image) Chrome
+	WithTitleIconPosition(position IconPosition) Chrome
+}
+
+// ControlColorer is implemented by chromes whose window buttons can be recolored
+type ControlColorer interface {
+	// WithControlColors sets the colors of the close, minimize and maximize buttons.
+	// Nil colors keep those of the theme.
+	WithControlColors(close, minimize, maximize color.Color) Chrome
+	// WithMonochromeControls draws all of the buttons in the theme's ControlsColor
+	WithMonochromeControls(enabled bool) Chrome
+}
+
+// controlColors holds the button colors set with the ControlColorer methods
+type controlColors struct {
+	close      color.Color
+	minimize   color.Color
+	maximize   color.Color
+	monochrome bool
+}
+
+// resolve returns the colors of the close, minimize and maximize buttons. The colors
+// set on the chrome come first, then those of the theme, then the chrome's usual ones.
+func (cc controlColors) resolve(props ThemeProperties, close, minimize, maximize color.Color) (color.Color, color.Color, color.Color) {
+	if cc.monochrome {
+		return props.ControlsColor, props.ControlsColor, props.ControlsColor
+	}
+	pick := func(colors ...color.Color) color.Color {
+		for _, c := range colors {
+			if c != nil {
+				return c
+			}
+		}
+		return nil
+	}
+	return pick(cc.close, props.CloseColor, close),
+		pick(cc.minimize, props.MinimizeColor, minimize),
+		pick(cc.maximize, props.MaximizeColor, maximize)
+}
+
 // ChromeOption is a function that modifies a Chrome instance
 type ChromeOption func(Chrome) Chrome
 
@@ -114,6 +216,71 @@ func WithVariant(variant ThemeVariant) ChromeOption {
 	}
 }
 
+// WithControlColors sets the colors of the close, minimize and maximize buttons, for
+// chromes that implement ControlColorer
+func WithControlColors(close, minimize, maximize color.Color) ChromeOption {
+	return func(c Chrome) Chrome {
+		if cc, ok := c.(ControlColorer); ok {
+			return cc.WithControlColors(close, minimize, maximize)
+		}
+		return c
+	}
+}
+
+// WithMonochromeControls draws all of the window buttons in the theme's ControlsColor,
+// for chromes that implement ControlColorer
+func WithMonochromeControls(enabled bool) ChromeOption {
+	return func(c Chrome) Chrome {
+		if cc, ok := c.(ControlColorer); ok {
+			return cc.WithMonochromeControls(enabled)
+		}
+		return c
+	}
+}
+
+// WithTitleAlignment sets the alignment of the title, for chromes that implement
+// TitleAligner
+func WithTitleAlignment(align Align) ChromeOption {
+	return func(c Chrome) Chrome {
+		if ta, ok := c.(TitleAligner); ok {
+			return ta.WithTitleAlignment(align)
+		}
+		return c
+	}
+}
+
+// WithRightToLeft lays out the title bar right to left, for chromes that implement
+// TitleAligner
+func WithRightToLeft(enabled bool) ChromeOption {
+	return func(c Chrome) Chrome {
+		if ta, ok := c.(TitleAligner); ok {
+			return ta.WithRightToLeft(enabled)
+		}
+		return c
+	}
+}
+
+// WithTitleIcon shows an icon in the title bar, for chromes that implement IconChrome
+func WithTitleIcon(img image.Image) ChromeOption {
+	return func(c Chrome) Chrome {
+		if ic, ok := c.(IconChrome); ok {
+			return ic.WithTitleIcon(img)
+		}
+		return c
+	}
+}
+
+// WithTitleIconPosition sets which end of the title bar the icon goes at, for chromes
+// that implement IconChrome
+func WithTitleIconPosition(position IconPosition) ChromeOption {
+	return func(c Chrome) Chrome {
+		if ic, ok := c.(IconChrome); ok {
+			return ic.WithTitleIconPosition(position)
+		}
+		return c
+	}
+}
+
 // ThemeRegistry maintains a registry of available themes
 type ThemeRegistry struct {
 	themes map[ThemeType]map[string]map[ThemeVariant]Theme
@@ -0,0 +1,178 @@
+package chrome
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlColorsResolve(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	props := ThemeProperties{ControlsColor: gray, MinimizeColor: green}
+
+	closeColor, minimizeColor, maximizeColor := controlColors{close: blue}.resolve(props, red, red, red)
+	assert.Equal(t, blue, closeColor, "Colors set on the chrome come first")
+	assert.Equal(t, green, minimizeColor, "Then those of the theme")
+	assert.Equal(t, red, maximizeColor, "Then the usual ones")
+
+	closeColor, minimizeColor, maximizeColor = controlColors{close: blue, monochrome: true}.resolve(props, red, red, red)
+	assert.Equal(t, []color.Color{gray, gray, gray}, []color.Color{closeColor, minimizeColor, maximizeColor})
+}
+
+func TestMacControlColors(t *testing.T) {
+	brand := color.RGBA{R: 10, G: 20, B: 200, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 200, 100))
+
+	// The center of the close button
+	x := macDefaultControlPadding + macDefaultControlSize/2
+	y := macDefaultTitleBarHeight / 2
+
+	img, err := NewMacChrome(MacStyleSequoia).Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, macCloseColor, img.At(x, y))
+
+	img, err = NewMacChrome(MacStyleSequoia, WithControlColors(brand, nil, nil)).Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, brand, img.At(x, y))
+	assert.Equal(t, macMinimizeColor, img.At(x+macDefaultControlSize+macDefaultControlSpacing, y), "Nil colors keep the usual ones")
+
+	c := NewMacChrome(MacStyleSequoia, WithMonochromeControls(true))
+	img, err = c.Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, c.theme.Properties.ControlsColor, img.At(x, y))
+}
+
+func TestControlColorsIgnoredByBlankChrome(t *testing.T) {
+	c := NewBlankChrome()
+	assert.Same(t, c, WithMonochromeControls(true)(c))
+}
+
+func TestTitleLayoutPosition(t *testing.T) {
+	// Controls on the left, like macOS
+	x, anchor := titleLayout{}.position(titleBar{width: 400, height: 30, controlsStart: 8, controlsEnd: 66})
+	assert.Equal(t, []float64{200, 0.5}, []float64{x, anchor}, "Titles are centered by default")
+	x, anchor = titleLayout{align: AlignLeft}.position(titleBar{width: 400, height: 30, controlsStart: 8, controlsEnd: 66})
+	assert.Equal(t, []float64{66 + titlePadding, 0}, []float64{x, anchor}, "Left aligned titles start after the controls")
+	x, anchor = titleLayout{rtl: true}.position(titleBar{width: 400, height: 30, controlsStart: 8, controlsEnd: 66})
+	assert.Equal(t, []float64{400 - 66 - titlePadding, 1}, []float64{x, anchor}, "Right to left titles end before the mirrored controls")
+
+	// Controls on the right, like Windows
+	x, anchor = titleLayout{align: AlignRight}.position(titleBar{width: 400, height: 30, controlsStart: 256, controlsEnd: 400})
+	assert.Equal(t, []float64{256 - titlePadding, 1}, []float64{x, anchor})
+	x, anchor = titleLayout{align: AlignLeft, rtl: true}.position(titleBar{width: 400, height: 30, controlsStart: 256, controlsEnd: 400})
+	assert.Equal(t, []float64{144 + titlePadding, 0}, []float64{x, anchor})
+}
+
+func TestMacRightToLeftControls(t *testing.T) {
+	content := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	y := macDefaultTitleBarHeight / 2
+
+	img, err := NewMacChrome(MacStyleSequoia, WithRightToLeft(true), WithCornerRadius(0)).Render(content)
+	require.NoError(t, err)
+	x := 200 - macDefaultControlPadding - macDefaultControlSize/2
+	assert.Equal(t, macCloseColor, img.At(x, y), "The close button moves to the right")
+	assert.NotEqual(t, macCloseColor, img.At(200-x, y))
+}
+
+func TestTitleAlignmentIgnoredByBlankChrome(t *testing.T) {
+	c := NewBlankChrome()
+	assert.Same(t, c, WithTitleAlignment(AlignRight)(c))
+}
+
+func TestTitleIcon(t *testing.T) {
+	icon := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	draw.Draw(icon, icon.Bounds(), image.NewUniform(color.RGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+
+	// Scaled to fit three fifths of the title bar, past the controls on the left
+	layout := titleLayout{icon: icon}
+	rect := layout.iconRect(titleBar{width: 400, height: 30, controlsStart: 8, controlsEnd: 66})
+	assert.Equal(t, image.Rect(66+titlePadding, 10, 66+titlePadding+18, 19), rect)
+	x, _ := titleLayout{icon: icon, align: AlignLeft}.position(titleBar{width: 400, height: 30, controlsStart: 8, controlsEnd: 66})
+	assert.Equal(t, float64(rect.Max.X+titlePadding), x, "Left aligned titles follow the icon")
+
+	// Trailing icons go before the controls on the right, and mirror with the title bar
+	layout.iconPosition = IconTrailing
+	assert.Equal(t, 256-titlePadding, layout.iconRect(titleBar{width: 400, height: 30, controlsStart: 256, controlsEnd: 400}).Max.X)
+	layout.rtl = true
+	assert.Equal(t, 144+titlePadding, layout.iconRect(titleBar{width: 400, height: 30, controlsStart: 256, controlsEnd: 400}).Min.X)
+
+	// With no room beside the controls or the title, the icon is left out
+	assert.True(t, layout.iconRect(titleBar{width: 150, height: 30, controlsStart: 8, controlsEnd: 140}).Empty())
+	assert.True(t, titleLayout{icon: icon}.iconRect(titleBar{width: 200, height: 30, controlsStart: 8, controlsEnd: 66, titleWidth: 100}).Empty())
+	assert.True(t, titleLayout{icon: icon, align: AlignLeft}.iconRect(titleBar{width: 200, height: 30, controlsStart: 8, controlsEnd: 66, titleWidth: 100}).Empty())
+	assert.False(t, titleLayout{icon: icon, align: AlignLeft}.iconRect(titleBar{width: 250, height: 30, controlsStart: 8, controlsEnd: 66, titleWidth: 100}).Empty())
+
+	c := NewMacChrome(MacStyleSequoia, WithTitleIcon(icon), WithCornerRadius(0))
+	img, err := c.Render(image.NewRGBA(image.Rect(0, 0, 200, 100)))
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 200, A: 255}, img.At(66+titlePadding+9, 15))
+}
+
+func TestTitleIconIgnoredByBlankChrome(t *testing.T) {
+	c := NewBlankChrome()
+	assert.Same(t, c, WithTitleIcon(image.NewRGBA(image.Rect(0, 0, 1, 1)))(c))
+}
+
+func TestContentInsets(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	content := image.NewRGBA(image.Rect(0, 0, 320, 80))
+	draw.Draw(content, content.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	chromes := map[string]func() Chrome{
+		"mac":      func() Chrome { return NewMacChrome(MacStyleSequoia, WithTitle("Title")) },
+		"windows":  func() Chrome { return NewWindowsChrome(WindowsStyleWin11, WithTitle("Title")) },
+		"adwaita":  func() Chrome { return NewGNOMEChrome(GNOMEStyleAdwaita, WithTitle("Title")) },
+		"breeze":   func() Chrome { return NewGNOMEChrome(GNOMEStyleBreeze, WithTitle("Title")) },
+		"blank":    func() Chrome { return NewBlankChrome() },
+		"browser":  func() Chrome { return NewBrowserChrome(BrowserStyleSafari, WithTabs("One", "Two")) },
+		"minimal":  func() Chrome { return NewMinimalChrome(WithTitle("Title")) },
+		"no title": func() Chrome { return NewMacChrome(MacStyleSequoia, WithTitleBar(false)) },
+	}
+	for name, newChrome := range chromes {
+		t.Run(name, func(t *testing.T) {
+			c := newChrome().WithCornerRadius(0)
+			img, err := c.Render(content)
+			require.NoError(t, err)
+
+			// The image is the content grown by the insets, with the content right inside
+			top, right, bottom, left := c.ContentInsets()
+			assert.Equal(t, image.Rect(0, 0, left+320+right, top+80+bottom), img.Bounds())
+			assert.Equal(t, red, img.At(left, top))
+			assert.Equal(t, red, img.At(left+319, top+79))
+			if top > 0 {
+				assert.NotEqual(t, red, img.At(left, top-1))
+			}
+		})
+	}
+}
+
+func TestScaledChrome(t *testing.T) {
+	for _, c := range []Chrome{
+		NewMacChrome(MacStyleSequoia, WithTitle("main.go")),
+		NewWindowsChrome(WindowsStyleWin11, WithTitle("main.go")),
+		NewGNOMEChrome(GNOMEStyleAdwaita, WithTitle("main.go")),
+		NewBrowserChrome(BrowserStyleChromium),
+		NewMinimalChrome(WithTitle("main.go")),
+		NewBlankChrome(),
+	} {
+		scaled := c.(Scaler).Scaled(2)
+		top, _, _, _ := c.ContentInsets()
+		scaledTop, _, _, _ := scaled.ContentInsets()
+		assert.Equal(t, top*2, scaledTop, "%T", c)
+
+		img, err := scaled.Render(image.NewRGBA(image.Rect(0, 0, 400, 200)))
+		require.NoError(t, err, "%T", c)
+		assert.Equal(t, image.Pt(400, 200+scaledTop), img.Bounds().Size(), "%T draws around the content as it is", c)
+
+		scaledWidth, scaledHeight := scaled.MinimumSize()
+		width, height := c.MinimumSize()
+		assert.Equal(t, []int{width * 2, height * 2}, []int{scaledWidth, scaledHeight}, "%T", c)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"image"
 	"image/color"
 	"math"
-
-	"github.com/fogleman/gg"
 )
 
 const (
@@ -41,6 +39,9 @@ type GNOMEChrome struct {
 	variant      ThemeVariant
 	titleBar     bool
 	style        GNOMEStyle
+	controls     controlColors
+	layout       titleLayout
+	scale        float64 // Pixels per unit drawn, set with Scaled; 0 means 1
 }
 
 func init() {
@@ -236,6 +237,42 @@ func (c *GNOMEChrome) WithCornerRadius(radius float64) Chrome {
 	return c
 }
 
+// WithControlColors implements the ControlColorer interface
+func (c *GNOMEChrome) WithControlColors(close, minimize, maximize color.Color) Chrome {
+	c.controls = controlColors{close: close, minimize: minimize, maximize: maximize}
+	return c
+}
+
+// WithMonochromeControls implements the ControlColorer interface
+func (c *GNOMEChrome) WithMonochromeControls(enabled bool) Chrome {
+	c.controls.monochrome = enabled
+	return c
+}
+
+// WithTitleAlignment implements the TitleAligner interface
+func (c *GNOMEChrome) WithTitleAlignment(align Align) Chrome {
+	c.layout.align = align
+	return c
+}
+
+// WithRightToLeft implements the TitleAligner interface
+func (c *GNOMEChrome) WithRightToLeft(enabled bool) Chrome {
+	c.layout.rtl = enabled
+	return c
+}
+
+// WithTitleIcon implements the IconChrome interface
+func (c *GNOMEChrome) WithTitleIcon(img image.Image) Chrome {
+	c.layout.icon = img
+	return c
+}
+
+// WithTitleIconPosition implements the IconChrome interface
+func (c *GNOMEChrome) WithTitleIconPosition(position IconPosition) Chrome {
+	c.layout.iconPosition = position
+	return c
+}
+
 func (c *GNOMEChrome) WithTitleBar(enabled bool) Chrome {
 	c.titleBar = enabled
 	return c
@@ -248,7 +285,7 @@ func (c *GNOMEChrome) Render(content image.Image) (image.Image, error) {
 	titleBarHeight := c.titleBarHeight()
 
 	// Create context for drawing
-	dc := gg.NewContext(width, height+titleBarHeight)
+	dc, width, height := newWindowContext(width, height, titleBarHeight, c.scale)
 
 	// Draw the base window with rounded corners
 	if err := DrawWindowBase(dc, width, height+titleBarHeight, c.cornerRadius,
@@ -259,22 +296,33 @@ func (c *GNOMEChrome) Render(content image.Image) (image.Image, error) {
 	}
 
 	if c.titleBar {
+		// Draw the icon, if there's room for it
+		bar := c.layoutTitleBar(width)
+		c.layout.drawIcon(dc, bar)
+
 		// Draw title text if enabled
 		if c.title != "" {
-			DrawTitleText(dc, c.title, width, titleBarHeight, c.theme.Properties.TitleText, c.theme.Properties.TitleFontSize, c.theme.Properties.TitleFont)
+			x, anchor := c.layout.position(bar)
+			drawTitleText(dc, c.title, x, anchor, c.layout.rtl, titleBarHeight, c.theme.Properties.TitleText, c.theme.Properties.TitleFontSize, c.theme.Properties.TitleFont)
 		}
 
 		// Draw window controls based on style
-		c.renderWindowControls(dc, width, titleBarHeight)
+		c.renderWindowControls(c.layout.painter(dc, width), width, titleBarHeight)
 	}
 
 	// Draw content
-	dc.DrawImage(content, 0, titleBarHeight)
+	drawContent(dc, content, titleBarHeight)
 
 	return dc.Image(), nil
 }
 
-func (c *GNOMEChrome) renderWindowControls(dc *gg.Context, width, titleBarHeight int) {
+// RenderFrame renders an empty window of the given content size, with the content
+// area filled with the theme's content background
+func (c *GNOMEChrome) RenderFrame(width, height int) (image.Image, error) {
+	return RenderFrameWithFill(c, width, height, c.theme.Properties.ContentBackground)
+}
+
+func (c *GNOMEChrome) renderWindowControls(dc painter, width, titleBarHeight int) {
 	switch c.style {
 	case GNOMEStyleAdwaita:
 		c.renderAdwaitaControls(dc, width, titleBarHeight)
@@ -285,7 +333,7 @@ func (c *GNOMEChrome) renderWindowControls(dc *gg.Context, width, titleBarHeight
 	}
 }
 
-func (c *GNOMEChrome) renderAdwaitaControls(dc *gg.Context, width, titleBarHeight int) {
+func (c *GNOMEChrome) renderAdwaitaControls(dc painter, width, titleBarHeight int) {
 	controlY := float64(titleBarHeight-adwaitaControlSize) / 2
 	closeX := float64(width - adwaitaRightPadding - adwaitaControlSize)
 	maximizeX := closeX - float64(adwaitaControlSize) - float64(adwaitaControlSpacing)
@@ -295,8 +343,11 @@ func (c *GNOMEChrome) renderAdwaitaControls(dc *gg.Context, width, titleBarHeigh
 	iconSize := buttonSize * 0.4
 	strokeWidth := 2.0
 
+	props := c.theme.Properties
+	closeColor, minimizeColor, maximizeColor := c.controls.resolve(props, props.ControlsColor, props.ControlsColor, props.ControlsColor)
+
 	dc.SetLineWidth(strokeWidth)
-	dc.SetColor(c.theme.Properties.ControlsColor)
+	dc.SetColor(closeColor)
 
 	// Close icon (X) - slightly smaller
 	closeIconSize := buttonSize * 0.45 // Reduced from 0.5 (half of button) to 0.45
@@ -308,23 +359,27 @@ func (c *GNOMEChrome) renderAdwaitaControls(dc *gg.Context, width, titleBarHeigh
 	dc.Stroke()
 
 	// Maximize icon (rectangle)
+	dc.SetColor(maximizeColor)
 	dc.DrawRectangle(maximizeX+buttonSize*0.3, controlY+buttonSize*0.3, iconSize, iconSize)
 	dc.Stroke()
 
 	// Minimize icon (line) - aligned with bottom of maximize
+	dc.SetColor(minimizeColor)
 	minimizeY := controlY + buttonSize*0.65 // Aligned with bottom of maximize icon
 	dc.MoveTo(minimizeX+buttonSize*0.25, minimizeY)
 	dc.LineTo(minimizeX+buttonSize*0.75, minimizeY)
 	dc.Stroke()
 }
 
-func (c *GNOMEChrome) renderBreezeControls(dc *gg.Context, width, titleBarHeight int) {
+func (c *GNOMEChrome) renderBreezeControls(dc painter, width, titleBarHeight int) {
 	controlY := float64(titleBarHeight-gnomeDefaultControlSize) / 2
 	closeX := float64(width) - float64(gnomeDefaultControlSize) - float64(gnomeDefaultControlPadding)
 	minimizeX := closeX - float64(gnomeDefaultControlSize) - float64(gnomeDefaultControlSpacing)
 
-	// Draw controls
-	dc.SetColor(c.theme.Properties.ControlsColor)
+	// Draw controls. Breeze has no maximize button.
+	props := c.theme.Properties
+	closeColor, minimizeColor, _ := c.controls.resolve(props, props.ControlsColor, props.ControlsColor, props.ControlsColor)
+	dc.SetColor(closeColor)
 
 	// Close button circle with X
 	dc.DrawCircle(closeX+float64(gnomeDefaultControlSize)/2, controlY+float64(gnomeDefaultControlSize)/2, float64(gnomeDefaultControlSize)/2)
@@ -353,7 +408,7 @@ func (c *GNOMEChrome) renderBreezeControls(dc *gg.Context, width, titleBarHeight
 	dc.Stroke()
 
 	// Reset color for minimize button
-	dc.SetColor(c.theme.Properties.ControlsColor)
+	dc.SetColor(minimizeColor)
 
 	// Minimize button (downward caret)
 	caretSize := float64(gnomeDefaultControlSize) / 2
@@ -368,11 +423,37 @@ func (c *GNOMEChrome) renderBreezeControls(dc *gg.Context, width, titleBarHeight
 }
 
 func (c *GNOMEChrome) MinimumSize() (width, height int) {
-	return 100, gnomeDefaultTitleBarHeight // Minimum size required for controls
+	return scaleSize(100, c.scale), scaleSize(gnomeDefaultTitleBarHeight, c.scale) // Minimum size required for controls
+}
+
+// controlsSpan returns where the window controls are in a title bar of the given width
+func (c *GNOMEChrome) controlsSpan(width int) (start, end int) {
+	if c.style == GNOMEStyleBreeze {
+		return width - gnomeDefaultControlPadding - 2*gnomeDefaultControlSize - gnomeDefaultControlSpacing, width - gnomeDefaultControlPadding
+	}
+	return width - adwaitaRightPadding - 3*adwaitaControlSize - 2*adwaitaControlSpacing, width - adwaitaRightPadding
+}
+
+// layoutTitleBar describes the title bar of a window of the given width
+func (c *GNOMEChrome) layoutTitleBar(width int) titleBar {
+	start, end := c.controlsSpan(width)
+	return measureTitleBar(width, c.titleBarHeight(), start, end, c.title, c.theme.Properties.TitleFontSize, c.theme.Properties.TitleFont)
+}
+
+// CornerRadius implements the RoundedChrome interface
+func (c *GNOMEChrome) CornerRadius() float64 {
+	return c.cornerRadius * scaleFactor(c.scale)
+}
+
+// Scaled implements the Scaler interface
+func (c *GNOMEChrome) Scaled(factor float64) Chrome {
+	scaled := *c
+	scaled.scale = factor
+	return &scaled
 }
 
 func (c *GNOMEChrome) ContentInsets() (top, right, bottom, left int) {
-	return c.titleBarHeight(), 0, 0, 0
+	return scaleSize(c.titleBarHeight(), c.scale), 0, 0, 0
 }
 
 func (c *GNOMEChrome) titleBarHeight() int {
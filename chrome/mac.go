@@ -3,8 +3,6 @@ package chrome
 import (
 	"image"
 	"image/color"
-
-	"github.com/fogleman/gg"
 )
 
 const (
@@ -46,6 +44,9 @@ type MacChrome struct {
 	variant      ThemeVariant
 	titleBar     bool
 	style        MacStyle
+	controls     controlColors
+	layout       titleLayout
+	scale        float64 // Pixels per unit drawn, set with Scaled; 0 means 1
 }
 
 func init() {
@@ -256,6 +257,42 @@ func (c *MacChrome) WithCornerRadius(radius float64) Chrome {
 	return c
 }
 
+// WithControlColors implements the ControlColorer interface
+func (c *MacChrome) WithControlColors(close, minimize, maximize color.Color) Chrome {
+	c.controls = controlColors{close: close, minimize: minimize, maximize: maximize}
+	return c
+}
+
+// WithMonochromeControls implements the ControlColorer interface
+func (c *MacChrome) WithMonochromeControls(enabled bool) Chrome {
+	c.controls.monochrome = enabled
+	return c
+}
+
+// WithTitleAlignment implements the TitleAligner interface
+func (c *MacChrome) WithTitleAlignment(align Align) Chrome {
+	c.layout.align = align
+	return c
+}
+
+// WithRightToLeft implements the TitleAligner interface
+func (c *MacChrome) WithRightToLeft(enabled bool) Chrome {
+	c.layout.rtl = enabled
+	return c
+}
+
+// WithTitleIcon implements the IconChrome interface
+func (c *MacChrome) WithTitleIcon(img image.Image) Chrome {
+	c.layout.icon = img
+	return c
+}
+
+// WithTitleIconPosition implements the IconChrome interface
+func (c *MacChrome) WithTitleIconPosition(position IconPosition) Chrome {
+	c.layout.iconPosition = position
+	return c
+}
+
 func (c *MacChrome) WithTitleBar(enabled bool) Chrome {
 	c.titleBar = enabled
 	return c
@@ -271,7 +308,7 @@ func (c *MacChrome) Render(content image.Image) (image.Image, error) {
 	}
 
 	// Create context for drawing
-	dc := gg.NewContext(width, height+titleBarHeight)
+	dc, width, height := newWindowContext(width, height, titleBarHeight, c.scale)
 
 	// Draw the base window with rounded corners
 	if err := DrawWindowBase(dc, width, height+titleBarHeight, c.cornerRadius,
@@ -283,21 +320,32 @@ func (c *MacChrome) Render(content image.Image) (image.Image, error) {
 
 	if c.titleBar {
 		// Draw window controls
-		c.renderWindowControls(dc, titleBarHeight)
+		c.renderWindowControls(c.layout.painter(dc, width), titleBarHeight)
+
+		// Draw the icon, if there's room for it
+		bar := c.layoutTitleBar(width)
+		c.layout.drawIcon(dc, bar)
 
 		// Draw title text if enabled
 		if c.title != "" {
-			DrawTitleText(dc, c.title, width, titleBarHeight, c.theme.Properties.TitleText, macDefaultTitleFontSize, c.theme.Properties.TitleFont)
+			x, anchor := c.layout.position(bar)
+			drawTitleText(dc, c.title, x, anchor, c.layout.rtl, titleBarHeight, c.theme.Properties.TitleText, macDefaultTitleFontSize, c.theme.Properties.TitleFont)
 		}
 	}
 
 	// Draw content
-	dc.DrawImage(content, 0, titleBarHeight)
+	drawContent(dc, content, titleBarHeight)
 
 	return dc.Image(), nil
 }
 
-func (c *MacChrome) renderWindowControls(dc *gg.Context, titleBarHeight int) {
+// RenderFrame renders an empty window of the given content size, with the content
+// area filled with the theme's content background
+func (c *MacChrome) RenderFrame(width, height int) (image.Image, error) {
+	return RenderFrameWithFill(c, width, height, c.theme.Properties.ContentBackground)
+}
+
+func (c *MacChrome) renderWindowControls(dc painter, titleBarHeight int) {
 	switch c.style {
 	case MacStyleSequoia, MacStyleSonoma, MacStyleVentura, MacStyleMonterey, MacStyleBigSur:
 		c.renderModernControls(dc, titleBarHeight)
@@ -308,43 +356,79 @@ func (c *MacChrome) renderWindowControls(dc *gg.Context, titleBarHeight int) {
 	}
 }
 
-func (c *MacChrome) renderModernControls(dc *gg.Context, titleBarHeight int) {
+func (c *MacChrome) renderModernControls(dc painter, titleBarHeight int) {
 	controlY := float64(titleBarHeight-macDefaultControlSize) / 2
 	closeX := float64(macDefaultControlPadding)
 	minimizeX := closeX + float64(macDefaultControlSize) + float64(macDefaultControlSpacing)
 	maximizeX := minimizeX + float64(macDefaultControlSize) + float64(macDefaultControlSpacing)
-	buttonSize := float64(macDefaultControlSize)
+	drawTrafficLights(dc, c.controls, c.theme.Properties, closeX, minimizeX, maximizeX, controlY, float64(macDefaultControlSize))
+}
+
+// The usual colors of the modern macOS window buttons
+var (
+	macCloseColor    = color.RGBA{R: 255, G: 95, B: 87, A: 255}
+	macMinimizeColor = color.RGBA{R: 255, G: 189, B: 46, A: 255}
+	macMaximizeColor = color.RGBA{R: 39, G: 201, B: 63, A: 255}
+)
+
+// drawTrafficLights draws the round buttons of modern macOS, each given by the left
+// edge of its button. They're red, yellow and green unless the chrome says otherwise.
+func drawTrafficLights(dc painter, controls controlColors, props ThemeProperties, closeX, minimizeX, maximizeX, y, buttonSize float64) {
+	closeColor, minimizeColor, maximizeColor := controls.resolve(props, macCloseColor, macMinimizeColor, macMaximizeColor)
 
-	// Close button (red)
-	dc.SetColor(color.RGBA{R: 255, G: 95, B: 87, A: 255})
-	dc.DrawCircle(closeX+buttonSize/2, controlY+buttonSize/2, buttonSize/2)
+	// Close button
+	dc.SetColor(closeColor)
+	dc.DrawCircle(closeX+buttonSize/2, y+buttonSize/2, buttonSize/2)
 	dc.Fill()
 
-	// Minimize button (yellow)
-	dc.SetColor(color.RGBA{R: 255, G: 189, B: 46, A: 255})
-	dc.DrawCircle(minimizeX+buttonSize/2, controlY+buttonSize/2, buttonSize/2)
+	// Minimize button
+	dc.SetColor(minimizeColor)
+	dc.DrawCircle(minimizeX+buttonSize/2, y+buttonSize/2, buttonSize/2)
 	dc.Fill()
 
-	// Maximize button (green)
-	dc.SetColor(color.RGBA{R: 39, G: 201, B: 63, A: 255})
-	dc.DrawCircle(maximizeX+buttonSize/2, controlY+buttonSize/2, buttonSize/2)
+	// Maximize button
+	dc.SetColor(maximizeColor)
+	dc.DrawCircle(maximizeX+buttonSize/2, y+buttonSize/2, buttonSize/2)
 	dc.Fill()
 }
 
-func (c *MacChrome) renderFlatControls(dc *gg.Context, titleBarHeight int) {
+func (c *MacChrome) renderFlatControls(dc painter, titleBarHeight int) {
 	// TODO: Implement flat style controls (Catalina/Mojave)
 }
 
-func (c *MacChrome) renderLegacyControls(dc *gg.Context, titleBarHeight int) {
+func (c *MacChrome) renderLegacyControls(dc painter, titleBarHeight int) {
 	// TODO: Implement legacy style controls (pre-Mojave)
 }
 
 func (c *MacChrome) MinimumSize() (width, height int) {
-	return 100, macDefaultTitleBarHeight // Minimum size required for controls
+	return scaleSize(100, c.scale), scaleSize(macDefaultTitleBarHeight, c.scale) // Minimum size required for controls
+}
+
+// controlsSpan returns where the window controls are in a title bar of the given width
+func (c *MacChrome) controlsSpan(width int) (start, end int) {
+	return macDefaultControlPadding, macDefaultControlPadding + 3*macDefaultControlSize + 2*macDefaultControlSpacing
+}
+
+// layoutTitleBar describes the title bar of a window of the given width
+func (c *MacChrome) layoutTitleBar(width int) titleBar {
+	start, end := c.controlsSpan(width)
+	return measureTitleBar(width, c.titleBarHeight(), start, end, c.title, macDefaultTitleFontSize, c.theme.Properties.TitleFont)
+}
+
+// CornerRadius implements the RoundedChrome interface
+func (c *MacChrome) CornerRadius() float64 {
+	return c.cornerRadius * scaleFactor(c.scale)
+}
+
+// Scaled implements the Scaler interface
+func (c *MacChrome) Scaled(factor float64) Chrome {
+	scaled := *c
+	scaled.scale = factor
+	return &scaled
 }
 
 func (c *MacChrome) ContentInsets() (top, right, bottom, left int) {
-	return c.titleBarHeight(), 0, 0, 0
+	return scaleSize(c.titleBarHeight(), c.scale), 0, 0, 0
 }
 
 func (c *MacChrome) titleBarHeight() int {
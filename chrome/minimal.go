@@ -0,0 +1,264 @@
+package chrome
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	minimalDefaultAccentHeight   = 4
+	minimalDefaultTitleBarHeight = 28
+	minimalDefaultTitleFontSize  = 12
+	minimalDefaultCornerRadius   = 6.0
+)
+
+// MinimalChrome implements the Chrome interface with a thin accent bar along the top
+// of the window and an optional title below it, without any window controls. It sits
+// between BlankChrome and the operating system chromes, for brand neutral screenshots.
+type MinimalChrome struct {
+	theme        Theme
+	cornerRadius float64
+	title        string
+	themeName    string
+	variant      ThemeVariant
+	titleBar     bool
+	accentHeight int
+	accentColor  color.Color // Color of the accent bar, the theme's AccentColor if nil
+	layout       titleLayout
+	scale        float64 // Pixels per unit drawn, set with Scaled; 0 means 1
+}
+
+func init() {
+	registerMinimalThemes()
+}
+
+func registerMinimalThemes() {
+	lightTheme := Theme{
+		Type:    ThemeTypeMinimal,
+		Variant: ThemeVariantLight,
+		Name:    "minimal",
+		Properties: ThemeProperties{
+			TitleFont:         "Inter",
+			TitleBackground:   color.RGBA{R: 246, G: 246, B: 246, A: 255},
+			TitleText:         color.RGBA{R: 90, G: 90, B: 90, A: 255},
+			ContentBackground: color.White,
+			TextColor:         color.RGBA{R: 90, G: 90, B: 90, A: 255},
+			AccentColor:       color.RGBA{R: 0, G: 122, B: 255, A: 255},
+			CornerRadius:      minimalDefaultCornerRadius,
+		},
+	}
+
+	darkTheme := Theme{
+		Type:    ThemeTypeMinimal,
+		Variant: ThemeVariantDark,
+		Name:    "minimal",
+		Properties: ThemeProperties{
+			TitleFont:         "Inter",
+			TitleBackground:   color.RGBA{R: 38, G: 38, B: 38, A: 255},
+			TitleText:         color.RGBA{R: 200, G: 200, B: 200, A: 255},
+			ContentBackground: color.RGBA{R: 28, G: 28, B: 28, A: 255},
+			TextColor:         color.RGBA{R: 200, G: 200, B: 200, A: 255},
+			AccentColor:       color.RGBA{R: 10, G: 132, B: 255, A: 255},
+			CornerRadius:      minimalDefaultCornerRadius,
+		},
+	}
+
+	DefaultRegistry.RegisterTheme(ThemeTypeMinimal, "minimal", ThemeVariantLight, lightTheme)
+	DefaultRegistry.RegisterTheme(ThemeTypeMinimal, "minimal", ThemeVariantDark, darkTheme)
+}
+
+// NewMinimalChrome creates a new minimal window chrome
+func NewMinimalChrome(opts ...ChromeOption) *MinimalChrome {
+	chrome := &MinimalChrome{
+		cornerRadius: minimalDefaultCornerRadius,
+		title:        "",
+		titleBar:     true,
+		themeName:    "minimal",
+		variant:      ThemeVariantLight,
+		accentHeight: minimalDefaultAccentHeight,
+	}
+
+	// Set initial theme
+	if theme, ok := DefaultRegistry.GetTheme(ThemeTypeMinimal, "minimal", ThemeVariantLight); ok {
+		chrome.theme = theme
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		chrome = opt(chrome).(*MinimalChrome)
+	}
+
+	return chrome
+}
+
+// WithAccentBar sets the height and color of the accent bar of a minimal chrome. Other
+// chromes ignore it.
+func WithAccentBar(height int, col color.Color) ChromeOption {
+	return func(c Chrome) Chrome {
+		if m, ok := c.(*MinimalChrome); ok {
+			return m.WithAccentBar(height, col)
+		}
+		return c
+	}
+}
+
+// WithAccentBar sets the height and color of the accent bar. A height of 0 leaves the
+// bar out, and a nil color keeps the theme's AccentColor.
+func (c *MinimalChrome) WithAccentBar(height int, col color.Color) *MinimalChrome {
+	c.accentHeight = max(0, height)
+	c.accentColor = col
+	return c
+}
+
+func (c *MinimalChrome) WithTheme(theme Theme) Chrome {
+	c.theme = theme
+	c.themeName = theme.Name
+	c.variant = theme.Variant
+	return c
+}
+
+func (c *MinimalChrome) WithThemeByName(name string, variant ThemeVariant) Chrome {
+	if theme, ok := DefaultRegistry.GetTheme(ThemeTypeMinimal, name, variant); ok {
+		c.themeName = name
+		c.variant = variant
+		c.theme = theme
+	}
+	return c
+}
+
+func (c *MinimalChrome) GetCurrentThemeName() string {
+	return c.themeName
+}
+
+func (c *MinimalChrome) GetCurrentVariant() ThemeVariant {
+	return c.variant
+}
+
+func (c *MinimalChrome) WithVariant(variant ThemeVariant) Chrome {
+	return c.WithThemeByName(c.themeName, variant)
+}
+
+func (c *MinimalChrome) CurrentTheme() Theme {
+	return c.theme
+}
+
+func (c *MinimalChrome) WithTitle(title string) Chrome {
+	c.title = title
+	return c
+}
+
+func (c *MinimalChrome) WithCornerRadius(radius float64) Chrome {
+	c.cornerRadius = radius
+	return c
+}
+
+// WithTitleAlignment implements the TitleAligner interface
+func (c *MinimalChrome) WithTitleAlignment(align Align) Chrome {
+	c.layout.align = align
+	return c
+}
+
+// WithRightToLeft implements the TitleAligner interface
+func (c *MinimalChrome) WithRightToLeft(enabled bool) Chrome {
+	c.layout.rtl = enabled
+	return c
+}
+
+func (c *MinimalChrome) WithTitleBar(enabled bool) Chrome {
+	c.titleBar = enabled
+	return c
+}
+
+// accent returns the color of the accent bar
+func (c *MinimalChrome) accent() color.Color {
+	if c.accentColor != nil {
+		return c.accentColor
+	}
+	return c.theme.Properties.AccentColor
+}
+
+// renderAccentBar draws the accent bar across the top of the window
+func (c *MinimalChrome) renderAccentBar(dc painter, width int) {
+	if c.accentHeight == 0 || c.accent() == nil {
+		return
+	}
+	dc.SetColor(c.accent())
+	dc.DrawRectangle(0, 0, float64(width), float64(c.accentHeight))
+	dc.Fill()
+}
+
+// Render implements the Chrome interface
+func (c *MinimalChrome) Render(content image.Image) (image.Image, error) {
+	content, width, height := contentOrBlank(c, content)
+	titleBarHeight := c.titleBarHeight()
+
+	// Create context for drawing
+	dc, width, height := newWindowContext(width, height, titleBarHeight, c.scale)
+
+	// Draw the base window with rounded corners
+	if err := DrawWindowBase(dc, width, height+titleBarHeight, c.cornerRadius,
+		c.theme.Properties.TitleBackground,
+		c.theme.Properties.ContentBackground,
+		titleBarHeight); err != nil {
+		return nil, err
+	}
+
+	if c.titleBar {
+		c.renderAccentBar(dc, width)
+
+		// The title is centered in the part of the title bar below the accent bar
+		if c.title != "" {
+			x, anchor := c.layout.position(titleBar{width: width, height: minimalDefaultTitleBarHeight})
+			dc.Push()
+			dc.Translate(0, float64(c.accentHeight))
+			err := drawTitleText(dc, c.title, x, anchor, c.layout.rtl, minimalDefaultTitleBarHeight, c.theme.Properties.TitleText, minimalDefaultTitleFontSize, c.theme.Properties.TitleFont)
+			dc.Pop()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Draw content
+	drawContent(dc, content, titleBarHeight)
+
+	return dc.Image(), nil
+}
+
+// RenderFrame renders an empty window of the given content size, with the content
+// area filled with the theme's content background
+func (c *MinimalChrome) RenderFrame(width, height int) (image.Image, error) {
+	return RenderFrameWithFill(c, width, height, c.theme.Properties.ContentBackground)
+}
+
+func (c *MinimalChrome) MinimumSize() (width, height int) {
+	return scaleSize(100, c.scale), scaleSize(minimalDefaultAccentHeight+minimalDefaultTitleBarHeight, c.scale)
+}
+
+// CornerRadius implements the RoundedChrome interface
+func (c *MinimalChrome) CornerRadius() float64 {
+	return c.cornerRadius * scaleFactor(c.scale)
+}
+
+// Scaled implements the Scaler interface
+func (c *MinimalChrome) Scaled(factor float64) Chrome {
+	scaled := *c
+	scaled.scale = factor
+	return &scaled
+}
+
+func (c *MinimalChrome) ContentInsets() (top, right, bottom, left int) {
+	return scaleSize(c.titleBarHeight(), c.scale), 0, 0, 0
+}
+
+// titleBarHeight returns the height of the accent bar, and of the title below it if
+// there is one
+func (c *MinimalChrome) titleBarHeight() int {
+	if !c.titleBar {
+		return 0
+	}
+	if c.title == "" {
+		return c.accentHeight
+	}
+	return c.accentHeight + minimalDefaultTitleBarHeight
+}
@@ -0,0 +1,53 @@
+package chrome
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/svg"
+)
+
+func TestMinimalChromeInsets(t *testing.T) {
+	c := NewMinimalChrome()
+	top, right, bottom, left := c.ContentInsets()
+	assert.Equal(t, minimalDefaultAccentHeight, top, "Without a title only the accent bar is drawn")
+	assert.Zero(t, right+bottom+left)
+
+	c = NewMinimalChrome(WithTitle("main.go"), WithAccentBar(6, nil))
+	top, _, _, _ = c.ContentInsets()
+	assert.Equal(t, 6+minimalDefaultTitleBarHeight, top)
+
+	c.WithTitleBar(false)
+	top, _, _, _ = c.ContentInsets()
+	assert.Zero(t, top)
+}
+
+func TestMinimalChromeRender(t *testing.T) {
+	brand := color.RGBA{R: 200, G: 20, B: 90, A: 255}
+	c := NewMinimalChrome(WithAccentBar(5, brand), WithCornerRadius(0))
+	content := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	img, err := c.Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 200, 105), img.Bounds())
+	assert.Equal(t, brand, img.At(100, 4))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, img.At(100, 5), "The content starts right below the bar")
+
+	c.WithVariant(ThemeVariantDark)
+	c.WithAccentBar(3, nil)
+	img, err = c.Render(content)
+	require.NoError(t, err)
+	assert.Equal(t, c.theme.Properties.AccentColor, img.At(100, 2))
+
+	fragment, err := c.WithTitle("main.go").(*MinimalChrome).RenderSVG(&svg.Fragment{Width: 200, Height: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 100+3+minimalDefaultTitleBarHeight, fragment.Height)
+	assert.Contains(t, fragment.Body, ">main.go</text>")
+}
+
+func TestAccentBarIgnoredByOtherChromes(t *testing.T) {
+	c := NewMacChrome(MacStyleSequoia)
+	assert.Same(t, c, WithAccentBar(10, nil)(c))
+}
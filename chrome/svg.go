@@ -0,0 +1,255 @@
+package chrome
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/watzon/goshot/svg"
+)
+
+// SVGChrome is implemented by chromes that can also be rendered as SVG
+type SVGChrome interface {
+	// RenderSVG wraps the content in the window chrome. A nil content renders an
+	// empty window of the chrome's minimum size, like Render does.
+	RenderSVG(content *svg.Fragment) (*svg.Fragment, error)
+}
+
+// painter is the part of gg.Context used to draw window controls. svg.Painter
+// implements it as well, so the same code draws the controls of both renderings.
+type painter interface {
+	SetColor(c color.Color)
+	SetLineWidth(width float64)
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	DrawLine(x1, y1, x2, y2 float64)
+	DrawRectangle(x, y, width, height float64)
+	DrawRoundedRectangle(x, y, width, height, r float64)
+	DrawCircle(x, y, r float64)
+	DrawArc(x, y, r, angle1, angle2 float64)
+	Fill()
+	Stroke()
+}
+
+// svgWindow describes a window drawn by renderWindowSVG
+type svgWindow struct {
+	cornerRadius   float64
+	background     color.Color // Fill of the whole window, including the title bar
+	titleBarHeight int
+	titleTop       int // Top of the part of the title bar the title is centered in
+	title          string
+	titleColor     color.Color
+	titleFontSize  float64
+	titleFont      string
+	controls       func(p painter, width int)                 // Draws the window controls, if any
+	titlePosition  func(width int) (x, anchor float64)        // Where the title goes, centered if nil
+	icon           func(width int) (image.Image, image.Point) // The title bar icon and where it goes, if any
+	rtl            bool                                       // Whether the title reads right to left
+	labels         []textLabel                                // Text other than the title, like browser tabs
+}
+
+// renderWindowSVG draws the window around the content, mirroring DrawWindowBase and
+// DrawTitleText. Everything, the content included, is clipped to the rounded corners.
+func renderWindowSVG(c Chrome, content *svg.Fragment, w svgWindow) (*svg.Fragment, error) {
+	if content == nil {
+		width, height := c.MinimumSize()
+		content = &svg.Fragment{Width: width, Height: height}
+	}
+	width, height := content.Width, content.Height+w.titleBarHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<defs><clipPath id=\"goshot-window-clip\">%s</clipPath></defs>\n",
+		strings.TrimSuffix(svg.RoundedRect(0, 0, float64(width), float64(height), w.cornerRadius, ""), "\n"))
+	b.WriteString("<g clip-path=\"url(#goshot-window-clip)\">\n")
+	b.WriteString(svg.RoundedRect(0, 0, float64(width), float64(height), 0, svg.Paint("fill", w.background)))
+
+	if w.titleBarHeight > 0 {
+		if w.controls != nil {
+			p := svg.NewPainter()
+			w.controls(p, width)
+			b.WriteString(p.String())
+		}
+
+		if w.icon != nil {
+			if img, at := w.icon(width); img != nil {
+				icon, err := svg.Image(img)
+				if err != nil {
+					return nil, err
+				}
+				b.WriteString(svg.Translate(icon.Body, float64(at.X), float64(at.Y)))
+			}
+		}
+
+		family := "sans-serif"
+		if w.titleFont != "" {
+			family = fmt.Sprintf("'%s', sans-serif", svg.Escape(w.titleFont))
+		}
+
+		if w.title != "" {
+			face, err := loadTitleFace(w.titleFontSize, w.titleFont)
+			if err != nil {
+				return nil, err
+			}
+			// Place the baseline where DrawTitleText's anchoring puts it
+			metrics := face.Face.Metrics()
+			baseline := float64(w.titleTop) + float64(w.titleBarHeight-w.titleTop)/2 - float64(metrics.Height.Round())/4 + float64(metrics.Height)/64/2
+			face.Close()
+
+			x, anchor := float64(width)/2, 0.5
+			if w.titlePosition != nil {
+				x, anchor = w.titlePosition(width)
+			}
+			// The viewer puts right to left text in display order itself, and the start
+			// of a right to left title is its right end
+			textAnchor, direction := "middle", ""
+			switch {
+			case anchor == 0.5:
+			case (anchor == 0) != w.rtl:
+				textAnchor = "start"
+			default:
+				textAnchor = "end"
+			}
+			if w.rtl {
+				direction = ` direction="rtl"`
+			}
+			fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\" text-anchor=\"%s\"%s font-family=\"%s\" font-size=\"%s\" font-weight=\"bold\"%s>%s</text>\n",
+				svg.Number(x), svg.Number(baseline), textAnchor, direction, family, svg.Number(w.titleFontSize),
+				svg.Paint("fill", w.titleColor), svg.Escape(w.title))
+		}
+
+		for _, l := range w.labels {
+			fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\" font-family=\"%s\" font-size=\"%s\"%s>%s</text>\n",
+				svg.Number(l.x), svg.Number(l.baseline), family, svg.Number(l.fontSize),
+				svg.Paint("fill", l.color), svg.Escape(l.text))
+		}
+	}
+
+	b.WriteString(svg.Translate(content.Body, 0, float64(w.titleBarHeight)))
+	b.WriteString("</g>\n")
+
+	return &svg.Fragment{Width: width, Height: height, Body: b.String()}, nil
+}
+
+// RenderSVG implements the SVGChrome interface
+func (c *MacChrome) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	return renderWindowSVG(c, content, svgWindow{
+		cornerRadius:   c.cornerRadius,
+		background:     c.theme.Properties.TitleBackground,
+		titleBarHeight: c.titleBarHeight(),
+		title:          c.title,
+		titleColor:     c.theme.Properties.TitleText,
+		titleFontSize:  macDefaultTitleFontSize,
+		titleFont:      c.theme.Properties.TitleFont,
+		controls: func(p painter, width int) {
+			c.renderWindowControls(c.layout.painter(p, width), c.titleBarHeight())
+		},
+		titlePosition: func(width int) (x, anchor float64) {
+			return c.layout.position(c.layoutTitleBar(width))
+		},
+		icon: func(width int) (image.Image, image.Point) {
+			return c.layout.placedIcon(c.layoutTitleBar(width))
+		},
+		rtl: c.layout.rtl,
+	})
+}
+
+// RenderSVG implements the SVGChrome interface
+func (c *WindowsChrome) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	return renderWindowSVG(c, content, svgWindow{
+		cornerRadius:   c.cornerRadius,
+		background:     c.theme.Properties.TitleBackground,
+		titleBarHeight: c.titleBarHeight(),
+		title:          c.title,
+		titleColor:     c.theme.Properties.TitleText,
+		titleFontSize:  winDefaultTitleFontSize,
+		titleFont:      c.theme.Properties.TitleFont,
+		controls: func(p painter, width int) {
+			c.renderWindowControls(c.layout.painter(p, width), width, c.titleBarHeight())
+		},
+		titlePosition: func(width int) (x, anchor float64) {
+			return c.layout.position(c.layoutTitleBar(width))
+		},
+		icon: func(width int) (image.Image, image.Point) {
+			return c.layout.placedIcon(c.layoutTitleBar(width))
+		},
+		rtl: c.layout.rtl,
+	})
+}
+
+// RenderSVG implements the SVGChrome interface
+func (c *GNOMEChrome) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	return renderWindowSVG(c, content, svgWindow{
+		cornerRadius:   c.cornerRadius,
+		background:     c.theme.Properties.TitleBackground,
+		titleBarHeight: c.titleBarHeight(),
+		title:          c.title,
+		titleColor:     c.theme.Properties.TitleText,
+		titleFontSize:  c.theme.Properties.TitleFontSize,
+		titleFont:      c.theme.Properties.TitleFont,
+		controls: func(p painter, width int) {
+			c.renderWindowControls(c.layout.painter(p, width), width, c.titleBarHeight())
+		},
+		titlePosition: func(width int) (x, anchor float64) {
+			return c.layout.position(c.layoutTitleBar(width))
+		},
+		icon: func(width int) (image.Image, image.Point) {
+			return c.layout.placedIcon(c.layoutTitleBar(width))
+		},
+		rtl: c.layout.rtl,
+	})
+}
+
+// RenderSVG implements the SVGChrome interface
+func (c *BlankChrome) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	return renderWindowSVG(c, content, svgWindow{
+		cornerRadius: c.cornerRadius,
+		background:   c.theme.Properties.ContentBackground,
+	})
+}
+
+// RenderSVG implements the SVGChrome interface
+func (c *MinimalChrome) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	return renderWindowSVG(c, content, svgWindow{
+		cornerRadius:   c.cornerRadius,
+		background:     c.theme.Properties.TitleBackground,
+		titleBarHeight: c.titleBarHeight(),
+		titleTop:       c.accentHeight,
+		title:          c.title,
+		titleColor:     c.theme.Properties.TitleText,
+		titleFontSize:  minimalDefaultTitleFontSize,
+		titleFont:      c.theme.Properties.TitleFont,
+		controls:       c.renderAccentBar,
+		titlePosition: func(width int) (x, anchor float64) {
+			return c.layout.position(titleBar{width: width, height: minimalDefaultTitleBarHeight})
+		},
+		rtl: c.layout.rtl,
+	})
+}
+
+// RenderSVG implements the SVGChrome interface
+func (c *BrowserChrome) RenderSVG(content *svg.Fragment) (*svg.Fragment, error) {
+	width, _ := c.MinimumSize()
+	if content != nil {
+		width = content.Width
+	}
+
+	var labels []textLabel
+	if c.titleBar {
+		var err error
+		if labels, err = c.labels(width); err != nil {
+			return nil, err
+		}
+	}
+
+	return renderWindowSVG(c, content, svgWindow{
+		cornerRadius:   c.cornerRadius,
+		background:     c.theme.Properties.TitleBackground,
+		titleBarHeight: c.titleBarHeight(),
+		titleFont:      c.theme.Properties.TitleFont,
+		controls: func(p painter, width int) {
+			c.renderControls(p, width)
+		},
+		labels: labels,
+	})
+}
@@ -0,0 +1,202 @@
+package chrome
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/watzon/goshot/background"
+)
+
+// themeFile is the description of a theme in a JSON or TOML file. Colors are CSS
+// colors, such as "#1e1e1e" or "rgba(0, 0, 0, 0.5)".
+type themeFile struct {
+	Type    string `json:"type" toml:"type"`
+	Name    string `json:"name" toml:"name"`
+	Variant string `json:"variant" toml:"variant"`
+	Style   string `json:"style" toml:"style"` // Style of the chrome to draw, e.g. "sonoma" for a Mac theme
+
+	TitleFont          string  `json:"title_font" toml:"title_font"`
+	TitleFontSize      float64 `json:"title_font_size" toml:"title_font_size"`
+	TitleText          string  `json:"title_text" toml:"title_text"`
+	TitleBackground    string  `json:"title_background" toml:"title_background"`
+	ControlsColor      string  `json:"controls_color" toml:"controls_color"`
+	ContentBackground  string  `json:"content_background" toml:"content_background"`
+	TextColor          string  `json:"text_color" toml:"text_color"`
+	AccentColor        string  `json:"accent_color" toml:"accent_color"`
+	BorderColor        string  `json:"border_color" toml:"border_color"`
+	InactiveTitleBg    string  `json:"inactive_title_background" toml:"inactive_title_background"`
+	InactiveTitleText  string  `json:"inactive_title_text" toml:"inactive_title_text"`
+	ButtonHoverColor   string  `json:"button_hover_color" toml:"button_hover_color"`
+	ButtonPressedColor string  `json:"button_pressed_color" toml:"button_pressed_color"`
+	CornerRadius       float64 `json:"corner_radius" toml:"corner_radius"`
+	BorderWidth        float64 `json:"border_width" toml:"border_width"`
+	CloseColor         string  `json:"close_color" toml:"close_color"`
+	MinimizeColor      string  `json:"minimize_color" toml:"minimize_color"`
+	MaximizeColor      string  `json:"maximize_color" toml:"maximize_color"`
+
+	// Colors the chrome looks up by name, like "toolbarBackground" for browsers
+	Colors map[string]string `json:"colors" toml:"colors"`
+}
+
+// LoadThemeFile reads a theme from a JSON or TOML file, chosen by its extension, and
+// registers it in DefaultRegistry so that it can be selected with WithThemeByName.
+// The file gives the chrome type ("mac", "windows", "gnome", "browser" or
+// "minimal"), the theme's name and variant, and its properties in snake case, such
+// as:
+//
+//	type = "mac"
+//	name = "solarized"
+//	variant = "dark"
+//	style = "sonoma"
+//	title_background = "#073642"
+//	title_text = "#93a1a1"
+//	content_background = "#002b36"
+//	corner_radius = 10
+//
+// The title text, title background and content background are required.
+func LoadThemeFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read theme file: %v", err)
+	}
+
+	var f themeFile
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &f)
+	case ".toml":
+		err = toml.Unmarshal(data, &f)
+	default:
+		return fmt.Errorf("unsupported theme file format %q: expected .json or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse theme file %s: %v", path, err)
+	}
+
+	theme, err := f.theme()
+	if err != nil {
+		return fmt.Errorf("invalid theme file %s: %v", path, err)
+	}
+	DefaultRegistry.RegisterTheme(theme.Type, theme.Name, theme.Variant, theme)
+	return nil
+}
+
+// theme validates the description and turns it into a theme
+func (f themeFile) theme() (Theme, error) {
+	theme := Theme{
+		Type:    ThemeType(f.Type),
+		Name:    f.Name,
+		Variant: ThemeVariant(f.Variant),
+	}
+	switch theme.Type {
+	case ThemeTypeMac, ThemeTypeWindows, ThemeTypeGNOME, ThemeTypeBrowser, ThemeTypeMinimal:
+	case "":
+		return Theme{}, fmt.Errorf("missing type")
+	default:
+		return Theme{}, fmt.Errorf("unknown type %q", f.Type)
+	}
+	if f.Name == "" {
+		return Theme{}, fmt.Errorf("missing name")
+	}
+	switch theme.Variant {
+	case ThemeVariantLight, ThemeVariantDark:
+	case "":
+		return Theme{}, fmt.Errorf("missing variant")
+	default:
+		return Theme{}, fmt.Errorf("unknown variant %q: expected %q or %q", f.Variant, ThemeVariantLight, ThemeVariantDark)
+	}
+
+	// Every color is optional but for the ones drawn by all chromes
+	var err error
+	parse := func(field, value string, required bool) color.Color {
+		if err != nil {
+			return nil
+		}
+		if value == "" {
+			if required {
+				err = fmt.Errorf("missing %s", field)
+			}
+			return nil
+		}
+		var c color.Color
+		if c, err = background.ParseColor(value); err != nil {
+			err = fmt.Errorf("invalid %s: %v", field, err)
+		}
+		return c
+	}
+	theme.Properties = ThemeProperties{
+		TitleFont:          f.TitleFont,
+		TitleFontSize:      f.TitleFontSize,
+		TitleText:          parse("title_text", f.TitleText, true),
+		TitleBackground:    parse("title_background", f.TitleBackground, true),
+		ContentBackground:  parse("content_background", f.ContentBackground, true),
+		ControlsColor:      parse("controls_color", f.ControlsColor, false),
+		TextColor:          parse("text_color", f.TextColor, false),
+		AccentColor:        parse("accent_color", f.AccentColor, false),
+		BorderColor:        parse("border_color", f.BorderColor, false),
+		InactiveTitleBg:    parse("inactive_title_background", f.InactiveTitleBg, false),
+		InactiveTitleText:  parse("inactive_title_text", f.InactiveTitleText, false),
+		ButtonHoverColor:   parse("button_hover_color", f.ButtonHoverColor, false),
+		ButtonPressedColor: parse("button_pressed_color", f.ButtonPressedColor, false),
+		CornerRadius:       f.CornerRadius,
+		BorderWidth:        f.BorderWidth,
+		CloseColor:         parse("close_color", f.CloseColor, false),
+		MinimizeColor:      parse("minimize_color", f.MinimizeColor, false),
+		MaximizeColor:      parse("maximize_color", f.MaximizeColor, false),
+		CustomProperties:   make(map[string]any),
+	}
+	for name, value := range f.Colors {
+		if c := parse("color "+name, value, false); c != nil {
+			theme.Properties.CustomProperties[name] = c
+		}
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+	if f.TitleFontSize < 0 || f.CornerRadius < 0 || f.BorderWidth < 0 {
+		return Theme{}, fmt.Errorf("sizes can't be negative")
+	}
+
+	if f.Style != "" {
+		style, err := themeStyle(theme.Type, f.Style)
+		if err != nil {
+			return Theme{}, err
+		}
+		theme.Properties.CustomProperties["style"] = style
+	}
+	return theme, nil
+}
+
+// themeStyle returns the style of the given chrome type named style
+func themeStyle(themeType ThemeType, style string) (any, error) {
+	switch themeType {
+	case ThemeTypeMac:
+		switch s := MacStyle(style); s {
+		case MacStyleSequoia, MacStyleSonoma, MacStyleVentura, MacStyleMonterey, MacStyleBigSur,
+			MacStyleCatalina, MacStyleMojave, MacStyleHighSierra, MacStyleSierra, MacStyleElCapitan,
+			MacStyleYosemite, MacStyleMavericks, MacStyleMountainLion, MacStyleLion, MacStyleSnowLeopard:
+			return s, nil
+		}
+	case ThemeTypeWindows:
+		switch s := WindowsStyle(style); s {
+		case WindowsStyleWin11, WindowsStyleWin10, WindowsStyleWin8, WindowsStyleWinXP:
+			return s, nil
+		}
+	case ThemeTypeGNOME:
+		switch s := GNOMEStyle(style); s {
+		case GNOMEStyleAdwaita, GNOMEStyleBreeze:
+			return s, nil
+		}
+	case ThemeTypeBrowser:
+		switch s := BrowserStyle(style); s {
+		case BrowserStyleChromium, BrowserStyleSafari:
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown %s style %q", themeType, style)
+}
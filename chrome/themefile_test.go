@@ -0,0 +1,80 @@
+package chrome
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeThemeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	return path
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	t.Run("toml", func(t *testing.T) {
+		path := writeThemeFile(t, "theme.toml", `
+type = "mac"
+name = "test-solarized"
+variant = "dark"
+style = "catalina"
+title_background = "#073642"
+title_text = "#93a1a1"
+content_background = "#002b36"
+close_color = "rgba(220, 50, 47, 0.5)"
+corner_radius = 10
+`)
+		require.NoError(t, LoadThemeFile(path))
+
+		c := NewMacChrome(MacStyleSequoia)
+		c.WithThemeByName("test-solarized", ThemeVariantDark)
+		assert.Equal(t, "test-solarized", c.GetCurrentThemeName())
+		assert.Equal(t, MacStyleCatalina, c.style)
+		props := c.CurrentTheme().Properties
+		assert.Equal(t, color.NRGBA{R: 0x07, G: 0x36, B: 0x42, A: 255}, props.TitleBackground)
+		assert.Equal(t, color.NRGBA{R: 220, G: 50, B: 47, A: 128}, props.CloseColor)
+		assert.Equal(t, 10.0, props.CornerRadius)
+		assert.Nil(t, props.BorderColor, "Colors that aren't given are left unset")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := writeThemeFile(t, "theme.json", `{
+	"type": "browser",
+	"name": "test-browser",
+	"variant": "light",
+	"title_background": "#eeeeee",
+	"title_text": "black",
+	"content_background": "white",
+	"colors": {"addressBarBackground": "#ff0000"}
+}`)
+		require.NoError(t, LoadThemeFile(path))
+
+		theme, ok := DefaultRegistry.GetTheme(ThemeTypeBrowser, "test-browser", ThemeVariantLight)
+		require.True(t, ok)
+		assert.Equal(t, color.NRGBA{R: 255, A: 255}, theme.Properties.CustomProperties["addressBarBackground"])
+	})
+
+	for _, tt := range []struct {
+		name, file, data, err string
+	}{
+		{"format", "theme.yaml", "", "unsupported theme file format"},
+		{"syntax", "theme.json", "{", "failed to parse"},
+		{"type", "theme.toml", `type = "amiga"`, `unknown type "amiga"`},
+		{"name", "theme.toml", `type = "mac"`, "missing name"},
+		{"variant", "theme.toml", "type = \"mac\"\nname = \"x\"", "missing variant"},
+		{"required color", "theme.toml", "type = \"mac\"\nname = \"x\"\nvariant = \"dark\"\ntitle_text = \"#fff\"", "missing title_background"},
+		{"color", "theme.toml", "type = \"mac\"\nname = \"x\"\nvariant = \"dark\"\ntitle_text = \"#ffg\"", "invalid title_text"},
+		{"style", "theme.toml", "type = \"gnome\"\nname = \"x\"\nvariant = \"dark\"\nstyle = \"sonoma\"\ntitle_text = \"#fff\"\ntitle_background = \"#000\"\ncontent_background = \"#000\"", `unknown gnome style "sonoma"`},
+	} {
+		t.Run(tt.name+" error", func(t *testing.T) {
+			err := LoadThemeFile(writeThemeFile(t, tt.file, tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
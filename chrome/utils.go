@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"log"
+	"math"
 
 	"github.com/fogleman/gg"
 	"github.com/watzon/goshot/fonts"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
 )
 
 // WindowStyle represents the style of window controls (macOS, Windows, etc)
@@ -44,10 +48,295 @@ func DrawWindowBase(dc *gg.Context, width int, height int, cornerRadius float64,
 
 // DrawTitleText draws centered title text in the title bar
 func DrawTitleText(dc *gg.Context, title string, width, titleBarHeight int, textColor color.Color, fontSize float64, fontName string) error {
-	// Draw text centered horizontally and vertically in the title bar
-	x := float64(width) / 2
+	return drawTitleText(dc, title, float64(width)/2, 0.5, false, titleBarHeight, textColor, fontSize, fontName)
+}
+
+// drawTitleText draws the title vertically centered in the title bar, anchored at x
+// like gg's DrawStringAnchored. Text in right to left scripts is put in display order,
+// in a title that reads right to left if rtl is set.
+func drawTitleText(dc *gg.Context, title string, x, anchor float64, rtl bool, titleBarHeight int, textColor color.Color, fontSize float64, fontName string) error {
 	y := float64(titleBarHeight) / 2
 
+	// The face is as large as the text is drawn, so it's sharp at any scale
+	scale := contextScale(dc)
+	face, err := loadTitleFace(fontSize*scale, fontName)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	dc.SetFontFace(face.Face)
+	dc.SetColor(textColor)
+
+	// Adjust Y position to account for font metrics and achieve true vertical centering
+	metrics := face.Face.Metrics()
+	height := float64(metrics.Height.Round()) / scale
+	// Move up by a quarter of the total height to achieve true vertical centering
+	y = y - height/4
+
+	// Draw the text anchored at the specified position
+	visual, _ := fonts.BidiReorder([]rune(title), rtl)
+	drawUnscaled(dc, func(x, y float64) {
+		dc.DrawStringAnchored(string(visual), x, y, anchor, 0.5)
+	}, x, y)
+
+	return nil
+}
+
+// contextScale returns how many pixels a unit drawn on the context spans, which is
+// more than one for chromes scaled with Scaled
+func contextScale(dc *gg.Context) float64 {
+	x0, _ := dc.TransformPoint(0, 0)
+	x1, _ := dc.TransformPoint(1, 0)
+	return x1 - x0
+}
+
+// drawUnscaled calls draw with the point x, y in pixels, with the context's transform
+// reset. Text and images are drawn this way at their own resolution, since gg would
+// otherwise stretch their pixels by the transform.
+func drawUnscaled(dc *gg.Context, draw func(x, y float64), x, y float64) {
+	x, y = dc.TransformPoint(x, y)
+	dc.Push()
+	dc.Identity()
+	draw(x, y)
+	dc.Pop()
+}
+
+// titlePadding is the room left between an aligned title and the edge of the window,
+// or the window controls
+const titlePadding = 12
+
+// titleLayout holds how a title bar is laid out, as set with the TitleAligner and
+// IconChrome methods
+type titleLayout struct {
+	align        Align
+	rtl          bool
+	icon         image.Image
+	iconPosition IconPosition
+}
+
+// titleBar describes a title bar to lay out
+type titleBar struct {
+	width, height              int
+	controlsStart, controlsEnd int     // Where the window controls are, before the title bar is mirrored
+	titleWidth                 float64 // Width of the title text, 0 without a title
+}
+
+// measureTitleBar describes a title bar, measuring the title in the bold face
+// drawTitleText draws it with
+func measureTitleBar(width, height, controlsStart, controlsEnd int, title string, fontSize float64, fontName string) titleBar {
+	bar := titleBar{width: width, height: height, controlsStart: controlsStart, controlsEnd: controlsEnd}
+	if title == "" {
+		return bar
+	}
+	if face, err := loadTitleFace(fontSize, fontName); err == nil {
+		bar.titleWidth = float64(font.MeasureString(face.Face, title)) / 64
+		face.Close()
+	}
+	return bar
+}
+
+// edges returns where the room for the title starts and ends in the title bar, between
+// the window controls and the other edge of the window
+func (tl titleLayout) edges(bar titleBar) (left, right int) {
+	controlsStart, controlsEnd := bar.controlsStart, bar.controlsEnd
+	if tl.rtl {
+		controlsStart, controlsEnd = bar.width-controlsEnd, bar.width-controlsStart
+	}
+	if controlsStart+controlsEnd < bar.width {
+		return controlsEnd, bar.width
+	}
+	return 0, controlsStart
+}
+
+// alignment returns how the title is aligned, resolving AlignAuto
+func (tl titleLayout) alignment() Align {
+	if tl.align != AlignAuto {
+		return tl.align
+	}
+	if tl.rtl {
+		return AlignRight
+	}
+	return AlignCenter
+}
+
+// iconRect returns where the icon goes in the title bar, or an empty rectangle if there's
+// no icon or no room for it. The icon is scaled to fit in the middle three fifths of
+// the title bar's height.
+func (tl titleLayout) iconRect(bar titleBar) image.Rectangle {
+	if tl.icon == nil || tl.icon.Bounds().Empty() {
+		return image.Rectangle{}
+	}
+	bounds := tl.icon.Bounds()
+	size := float64(bar.height) * 3 / 5
+	scale := math.Min(size/float64(bounds.Dx()), size/float64(bounds.Dy()))
+	w := max(1, int(math.Round(float64(bounds.Dx())*scale)))
+	h := max(1, int(math.Round(float64(bounds.Dy())*scale)))
+
+	// The window controls and the title come first: the icon is left out if it doesn't
+	// fit beside them
+	left, right := tl.edges(bar)
+	room := w + 2*titlePadding
+	if tl.alignment() != AlignCenter && bar.titleWidth > 0 {
+		room += int(math.Ceil(bar.titleWidth)) + titlePadding
+	}
+	if right-left < room {
+		return image.Rectangle{}
+	}
+
+	x := left + titlePadding
+	if (tl.iconPosition == IconTrailing) != tl.rtl {
+		x = right - titlePadding - w
+	}
+	y := (bar.height - h) / 2
+	rect := image.Rect(x, y, x+w, y+h)
+
+	if tl.alignment() == AlignCenter && bar.titleWidth > 0 {
+		titleStart := float64(bar.width)/2 - bar.titleWidth/2 - titlePadding
+		titleEnd := float64(bar.width)/2 + bar.titleWidth/2 + titlePadding
+		if float64(rect.Max.X) > titleStart && float64(rect.Min.X) < titleEnd {
+			return image.Rectangle{}
+		}
+	}
+	return rect
+}
+
+// placedIcon returns the icon scaled to fit the title bar and where it goes, or nil if
+// there's no icon or no room for it
+func (tl titleLayout) placedIcon(bar titleBar) (image.Image, image.Point) {
+	rect := tl.iconRect(bar)
+	if rect.Empty() {
+		return nil, image.Point{}
+	}
+	return fitIcon(tl.icon, rect.Dx(), rect.Dy()), rect.Min
+}
+
+// fitIcon returns the icon scaled to the given size
+func fitIcon(icon image.Image, width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(img, img.Bounds(), icon, icon.Bounds(), draw.Over, nil)
+	return img
+}
+
+// drawIcon draws the icon, if there's one and room for it, into the title bar. It's
+// scaled straight from the original to the pixels it covers.
+func (tl titleLayout) drawIcon(dc *gg.Context, bar titleBar) {
+	rect := tl.iconRect(bar)
+	if rect.Empty() {
+		return
+	}
+	scale := contextScale(dc)
+	width := max(1, int(math.Round(float64(rect.Dx())*scale)))
+	height := max(1, int(math.Round(float64(rect.Dy())*scale)))
+	img := fitIcon(tl.icon, width, height)
+	drawUnscaled(dc, func(x, y float64) {
+		dc.DrawImage(img, int(math.Round(x)), int(math.Round(y)))
+	}, float64(rect.Min.X), float64(rect.Min.Y))
+}
+
+// position returns where the title goes in the title bar, as the x and anchor of
+// drawTitleText. Aligned titles keep clear of the window controls and the icon.
+func (tl titleLayout) position(bar titleBar) (x, anchor float64) {
+	left, right := tl.edges(bar)
+	if icon := tl.iconRect(bar); !icon.Empty() {
+		if icon.Min.X-left < right-icon.Max.X {
+			left = icon.Max.X
+		} else {
+			right = icon.Min.X
+		}
+	}
+
+	switch tl.alignment() {
+	case AlignLeft:
+		return float64(left + titlePadding), 0
+	case AlignRight:
+		return float64(right - titlePadding), 1
+	default:
+		return float64(bar.width) / 2, 0.5
+	}
+}
+
+// painter returns the painter to draw the window controls with, which mirrors them
+// when the title bar is right to left
+func (tl titleLayout) painter(p painter, width int) painter {
+	if tl.rtl {
+		return mirroredPainter{p: p, width: float64(width)}
+	}
+	return p
+}
+
+// mirroredPainter draws with p flipped horizontally within the given width
+type mirroredPainter struct {
+	p     painter
+	width float64
+}
+
+func (m mirroredPainter) SetColor(c color.Color)     { m.p.SetColor(c) }
+func (m mirroredPainter) SetLineWidth(width float64) { m.p.SetLineWidth(width) }
+func (m mirroredPainter) MoveTo(x, y float64)        { m.p.MoveTo(m.width-x, y) }
+func (m mirroredPainter) LineTo(x, y float64)        { m.p.LineTo(m.width-x, y) }
+func (m mirroredPainter) Fill()                      { m.p.Fill() }
+func (m mirroredPainter) Stroke()                    { m.p.Stroke() }
+
+func (m mirroredPainter) DrawLine(x1, y1, x2, y2 float64) {
+	m.p.DrawLine(m.width-x1, y1, m.width-x2, y2)
+}
+
+func (m mirroredPainter) DrawRectangle(x, y, width, height float64) {
+	m.p.DrawRectangle(m.width-x-width, y, width, height)
+}
+
+func (m mirroredPainter) DrawRoundedRectangle(x, y, width, height, r float64) {
+	m.p.DrawRoundedRectangle(m.width-x-width, y, width, height, r)
+}
+
+func (m mirroredPainter) DrawCircle(x, y, r float64) {
+	m.p.DrawCircle(m.width-x, y, r)
+}
+
+// DrawArc mirrors the angles as well, so the arc runs the other way around
+func (m mirroredPainter) DrawArc(x, y, r, angle1, angle2 float64) {
+	m.p.DrawArc(m.width-x, y, r, math.Pi-angle1, math.Pi-angle2)
+}
+
+// textLabel is a line of regular weight text drawn by a chrome, placed by the left end
+// of its baseline
+type textLabel struct {
+	text     string
+	x        float64
+	baseline float64
+	color    color.Color
+	fontSize float64
+}
+
+// drawLabels draws the labels in the given font
+func drawLabels(dc *gg.Context, labels []textLabel, fontName string) error {
+	scale := contextScale(dc)
+	for _, l := range labels {
+		face, err := loadFace(l.fontSize*scale, fontName, fonts.WeightRegular)
+		if err != nil {
+			return err
+		}
+		dc.SetFontFace(face.Face)
+		dc.SetColor(l.color)
+		visual, _ := fonts.BidiReorder([]rune(l.text), false)
+		drawUnscaled(dc, func(x, y float64) {
+			dc.DrawString(string(visual), x, y)
+		}, l.x, l.baseline)
+		face.Close()
+	}
+	return nil
+}
+
+// loadTitleFace loads the bold face used for title text, falling back to the default
+// sans-serif font if the requested one can't be loaded
+func loadTitleFace(fontSize float64, fontName string) (*fonts.Face, error) {
+	return loadFace(fontSize, fontName, fonts.WeightBold)
+}
+
+// loadFace loads a face of the given weight, falling back to the default sans-serif
+// font if the requested one can't be loaded
+func loadFace(fontSize float64, fontName string, weight fonts.FontWeight) (*fonts.Face, error) {
 	var font *fonts.Font
 	var err error
 
@@ -63,32 +352,18 @@ func DrawTitleText(dc *gg.Context, title string, width, titleBarHeight int, text
 	if font == nil {
 		font, err = fonts.GetFallback(fonts.FallbackSans)
 		if err != nil {
-			return fmt.Errorf("failed to load fallback font: %v", err)
+			return nil, fmt.Errorf("failed to load fallback font: %v", err)
 		}
 	}
 
 	face, err := font.GetFace(fontSize, &fonts.FontStyle{
-		Weight:  fonts.WeightBold,
+		Weight:  weight,
 		Stretch: fonts.StretchNormal,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create font face: %v", err)
+		return nil, fmt.Errorf("failed to create font face: %v", err)
 	}
-	defer face.Close()
-
-	dc.SetFontFace(face.Face)
-	dc.SetColor(textColor)
-
-	// Adjust Y position to account for font metrics and achieve true vertical centering
-	metrics := face.Face.Metrics()
-	height := float64(metrics.Height.Round())
-	// Move up by a quarter of the total height to achieve true vertical centering
-	y = y - height/4
-
-	// Draw the text centered at the specified position
-	dc.DrawStringAnchored(title, x, y, 0.5, 0.5)
-
-	return nil
+	return face, nil
 }
 
 // DrawCross draws an X symbol for the close button
@@ -167,3 +442,75 @@ func contentOrBlank(chrome Chrome, content image.Image) (image.Image, int, int)
 
 	return content, width, height
 }
+
+// scaleFactor returns the scale a chrome draws at, which is 1 unless Scaled set
+// another
+func scaleFactor(scale float64) float64 {
+	if scale <= 0 {
+		return 1
+	}
+	return scale
+}
+
+// scaleSize returns a size of the chrome in pixels, multiplied by its scale
+func scaleSize(v int, scale float64) int {
+	return int(math.Round(float64(v) * scaleFactor(scale)))
+}
+
+// newWindowContext creates the context to draw a window around content of the given
+// size in pixels, with a title bar of the given height. Everything is drawn scaled by
+// scale, the content included, whose size is returned in the units drawn in.
+func newWindowContext(width, height, titleBarHeight int, scale float64) (*gg.Context, int, int) {
+	s := scaleFactor(scale)
+	dc := gg.NewContext(width, height+scaleSize(titleBarHeight, s))
+	dc.Scale(s, s)
+	// Rounding up keeps the window from stopping short of the right and bottom edges
+	return dc, int(math.Ceil(float64(width) / s)), int(math.Ceil(float64(height) / s))
+}
+
+// drawContent draws the content at its own resolution below the title bar
+func drawContent(dc *gg.Context, content image.Image, titleBarHeight int) {
+	drawUnscaled(dc, func(x, y float64) {
+		dc.DrawImage(content, int(math.Round(x)), int(math.Round(y)))
+	}, 0, float64(titleBarHeight))
+}
+
+// RenderFrame renders the chrome around an empty content area of the given size,
+// filled with the content background of the chrome's theme. Chromes implementing
+// FrameRenderer render the frame themselves. Use RenderFrameWithFill for any other
+// fill.
+func RenderFrame(chrome Chrome, width, height int) (image.Image, error) {
+	if r, ok := chrome.(FrameRenderer); ok {
+		return r.RenderFrame(width, height)
+	}
+	return RenderFrameWithFill(chrome, width, height, chrome.CurrentTheme().Properties.ContentBackground)
+}
+
+// RenderFrameWithFill renders the chrome around an empty content area of the given
+// size, filling the content area with fill. A nil or transparent fill leaves the
+// content area see-through, which is useful for window frames that get composited
+// over other images later on.
+func RenderFrameWithFill(chrome Chrome, width, height int, fill color.Color) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid frame size: %dx%d", width, height)
+	}
+	if fill == nil {
+		fill = color.Transparent
+	}
+
+	frame, err := chrome.Render(image.NewRGBA(image.Rect(0, 0, width, height)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := image.NewRGBA(frame.Bounds())
+	draw.Draw(result, result.Bounds(), frame, frame.Bounds().Min, draw.Src)
+
+	// Replace the content area, using the frame's own alpha as the mask so that
+	// rounded corners stay intact
+	top, _, _, left := chrome.ContentInsets()
+	contentRect := image.Rect(left, top, left+width, top+height).Add(frame.Bounds().Min)
+	draw.DrawMask(result, contentRect, image.NewUniform(fill), image.Point{}, frame, contentRect.Min, draw.Src)
+
+	return result, nil
+}
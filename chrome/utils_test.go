@@ -1,11 +1,13 @@
 package chrome
 
 import (
+	"image"
 	"image/color"
 	"testing"
 
 	"github.com/fogleman/gg"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/watzon/goshot/fonts"
 )
 
@@ -139,3 +141,46 @@ func TestDrawTitleText(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderFrame(t *testing.T) {
+	tests := []struct {
+		name          string
+		chrome        Chrome
+		width, height int
+	}{
+		{name: "mac", chrome: NewMacChrome(MacStyleSequoia, WithTitle("Title")), width: 320, height: 80},
+		{name: "windows", chrome: NewWindowsChrome(WindowsStyleWin11, WithTitle("Title")), width: 200, height: 200},
+		{name: "gnome", chrome: NewGNOMEChrome(GNOMEStyleAdwaita, WithTitle("Title")), width: 640, height: 40},
+		{name: "browser", chrome: NewBrowserChrome(BrowserStyleSafari, WithTabs("One")), width: 400, height: 300},
+		{name: "minimal", chrome: NewMinimalChrome(WithTitle("Title")), width: 100, height: 100},
+		{name: "blank", chrome: NewBlankChrome(), width: 50, height: 30},
+		// Chromes that don't render frames themselves get the same frame
+		{name: "not a frame renderer", chrome: struct{ Chrome }{NewMacChrome(MacStyleSequoia)}, width: 320, height: 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := RenderFrame(tt.chrome, tt.width, tt.height)
+			require.NoError(t, err)
+
+			// The frame is the content area grown by the insets, filled with the theme's
+			// content background
+			top, right, bottom, left := tt.chrome.ContentInsets()
+			assert.Equal(t, image.Rect(0, 0, left+tt.width+right, top+tt.height+bottom), img.Bounds())
+			want := color.RGBAModel.Convert(tt.chrome.CurrentTheme().Properties.ContentBackground)
+			assert.Equal(t, want, img.At(left+tt.width/2, top+tt.height/2))
+
+			// A transparent fill leaves the content area see-through
+			img, err = RenderFrameWithFill(tt.chrome, tt.width, tt.height, nil)
+			require.NoError(t, err)
+			_, _, _, a := img.At(left+tt.width/2, top+tt.height/2).RGBA()
+			assert.Zero(t, a)
+		})
+	}
+
+	t.Run("invalid size", func(t *testing.T) {
+		for _, size := range [][2]int{{0, 10}, {10, 0}, {-1, -1}} {
+			_, err := RenderFrame(NewMacChrome(MacStyleSequoia), size[0], size[1])
+			assert.Error(t, err, "%v", size)
+		}
+	})
+}
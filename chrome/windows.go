@@ -3,8 +3,6 @@ package chrome
 import (
 	"image"
 	"image/color"
-
-	"github.com/fogleman/gg"
 )
 
 const (
@@ -37,6 +35,9 @@ type WindowsChrome struct {
 	variant      ThemeVariant
 	titleBar     bool
 	style        WindowsStyle
+	controls     controlColors
+	layout       titleLayout
+	scale        float64 // Pixels per unit drawn, set with Scaled; 0 means 1
 }
 
 func init() {
@@ -189,6 +190,42 @@ func (c *WindowsChrome) WithCornerRadius(radius float64) Chrome {
 	return c
 }
 
+// WithControlColors implements the ControlColorer interface
+func (c *WindowsChrome) WithControlColors(close, minimize, maximize color.Color) Chrome {
+	c.controls = controlColors{close: close, minimize: minimize, maximize: maximize}
+	return c
+}
+
+// WithMonochromeControls implements the ControlColorer interface
+func (c *WindowsChrome) WithMonochromeControls(enabled bool) Chrome {
+	c.controls.monochrome = enabled
+	return c
+}
+
+// WithTitleAlignment implements the TitleAligner interface
+func (c *WindowsChrome) WithTitleAlignment(align Align) Chrome {
+	c.layout.align = align
+	return c
+}
+
+// WithRightToLeft implements the TitleAligner interface
+func (c *WindowsChrome) WithRightToLeft(enabled bool) Chrome {
+	c.layout.rtl = enabled
+	return c
+}
+
+// WithTitleIcon implements the IconChrome interface
+func (c *WindowsChrome) WithTitleIcon(img image.Image) Chrome {
+	c.layout.icon = img
+	return c
+}
+
+// WithTitleIconPosition implements the IconChrome interface
+func (c *WindowsChrome) WithTitleIconPosition(position IconPosition) Chrome {
+	c.layout.iconPosition = position
+	return c
+}
+
 func (c *WindowsChrome) WithTitleBar(enabled bool) Chrome {
 	c.titleBar = enabled
 	return c
@@ -200,7 +237,7 @@ func (c *WindowsChrome) Render(content image.Image) (image.Image, error) {
 	titleBarHeight := c.titleBarHeight()
 
 	// Create context for drawing
-	dc := gg.NewContext(width, height+titleBarHeight)
+	dc, width, height := newWindowContext(width, height, titleBarHeight, c.scale)
 
 	// Draw the base window with rounded corners
 	if err := DrawWindowBase(dc, width, height+titleBarHeight, c.cornerRadius,
@@ -211,22 +248,33 @@ func (c *WindowsChrome) Render(content image.Image) (image.Image, error) {
 	}
 
 	if c.titleBar {
+		// Draw the icon, if there's room for it
+		bar := c.layoutTitleBar(width)
+		c.layout.drawIcon(dc, bar)
+
 		// Draw title text
 		if c.title != "" {
-			DrawTitleText(dc, c.title, width, titleBarHeight, c.theme.Properties.TitleText, winDefaultTitleFontSize, c.theme.Properties.TitleFont)
+			x, anchor := c.layout.position(bar)
+			drawTitleText(dc, c.title, x, anchor, c.layout.rtl, titleBarHeight, c.theme.Properties.TitleText, winDefaultTitleFontSize, c.theme.Properties.TitleFont)
 		}
 
 		// Draw window controls based on style
-		c.renderWindowControls(dc, width, titleBarHeight)
+		c.renderWindowControls(c.layout.painter(dc, width), width, titleBarHeight)
 	}
 
 	// Draw content
-	dc.DrawImage(content, 0, titleBarHeight)
+	drawContent(dc, content, titleBarHeight)
 
 	return dc.Image(), nil
 }
 
-func (c *WindowsChrome) renderWindowControls(dc *gg.Context, width, titleBarHeight int) {
+// RenderFrame renders an empty window of the given content size, with the content
+// area filled with the theme's content background
+func (c *WindowsChrome) RenderFrame(width, height int) (image.Image, error) {
+	return RenderFrameWithFill(c, width, height, c.theme.Properties.ContentBackground)
+}
+
+func (c *WindowsChrome) renderWindowControls(dc painter, width, titleBarHeight int) {
 	switch c.style {
 	case WindowsStyleWin11:
 		c.renderWindows11Controls(dc, width, titleBarHeight)
@@ -239,7 +287,7 @@ func (c *WindowsChrome) renderWindowControls(dc *gg.Context, width, titleBarHeig
 	}
 }
 
-func (c *WindowsChrome) renderWindows11Controls(dc *gg.Context, width, titleBarHeight int) {
+func (c *WindowsChrome) renderWindows11Controls(dc painter, width, titleBarHeight int) {
 	controlY := float64(titleBarHeight-winDefaultControlSize) / 2
 	buttonWidth := float64(winDefaultButtonWidth)
 
@@ -254,12 +302,12 @@ func (c *WindowsChrome) renderWindows11Controls(dc *gg.Context, width, titleBarH
 
 	dc.SetLineWidth(strokeWidth)
 
-	// Close button (red background in hover state)
+	// The icons are white on dark themes
+	iconColor := c.theme.Properties.ControlsColor
 	if c.variant == ThemeVariantDark {
-		dc.SetColor(color.RGBA{R: 255, G: 255, B: 255, A: 255})
-	} else {
-		dc.SetColor(c.theme.Properties.ControlsColor)
+		iconColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
 	}
+	closeColor, minimizeColor, maximizeColor := c.controls.resolve(c.theme.Properties, iconColor, iconColor, iconColor)
 
 	// Center the icons within their button areas
 	closeIconX := closeX + (buttonWidth-buttonSize)/2
@@ -267,6 +315,7 @@ func (c *WindowsChrome) renderWindows11Controls(dc *gg.Context, width, titleBarH
 	minimizeIconX := minimizeX + (buttonWidth-buttonSize)/2
 
 	// Close icon (X)
+	dc.SetColor(closeColor)
 	closeOffset := buttonSize * 0.28
 	dc.MoveTo(closeIconX+closeOffset, controlY+closeOffset)
 	dc.LineTo(closeIconX+buttonSize-closeOffset, controlY+buttonSize-closeOffset)
@@ -275,35 +324,60 @@ func (c *WindowsChrome) renderWindows11Controls(dc *gg.Context, width, titleBarH
 	dc.Stroke()
 
 	// Maximize icon (rectangle)
+	dc.SetColor(maximizeColor)
 	rectOffset := (buttonSize - iconSize) / 2
 	dc.DrawRectangle(maximizeIconX+rectOffset, controlY+rectOffset, iconSize, iconSize)
 	dc.Stroke()
 
 	// Minimize icon (line)
+	dc.SetColor(minimizeColor)
 	lineY := controlY + buttonSize*0.65
 	dc.MoveTo(minimizeIconX+buttonSize*0.25, lineY)
 	dc.LineTo(minimizeIconX+buttonSize*0.75, lineY)
 	dc.Stroke()
 }
 
-func (c *WindowsChrome) renderWindows10Controls(dc *gg.Context, width, titleBarHeight int) {
+func (c *WindowsChrome) renderWindows10Controls(dc painter, width, titleBarHeight int) {
 	// TODO: Implement Windows 10 controls
 }
 
-func (c *WindowsChrome) renderWindows8Controls(dc *gg.Context, width, titleBarHeight int) {
+func (c *WindowsChrome) renderWindows8Controls(dc painter, width, titleBarHeight int) {
 	// TODO: Implement Windows 8 controls
 }
 
-func (c *WindowsChrome) renderWindowsXPControls(dc *gg.Context, width, titleBarHeight int) {
+func (c *WindowsChrome) renderWindowsXPControls(dc painter, width, titleBarHeight int) {
 	// TODO: Implement Windows XP controls
 }
 
 func (c *WindowsChrome) MinimumSize() (width, height int) {
-	return 100, winDefaultTitleBarHeight // Minimum size required for controls
+	return scaleSize(100, c.scale), scaleSize(winDefaultTitleBarHeight, c.scale) // Minimum size required for controls
+}
+
+// controlsSpan returns where the window controls are in a title bar of the given width
+func (c *WindowsChrome) controlsSpan(width int) (start, end int) {
+	return width - 3*winDefaultButtonWidth, width
+}
+
+// layoutTitleBar describes the title bar of a window of the given width
+func (c *WindowsChrome) layoutTitleBar(width int) titleBar {
+	start, end := c.controlsSpan(width)
+	return measureTitleBar(width, c.titleBarHeight(), start, end, c.title, winDefaultTitleFontSize, c.theme.Properties.TitleFont)
+}
+
+// CornerRadius implements the RoundedChrome interface
+func (c *WindowsChrome) CornerRadius() float64 {
+	return c.cornerRadius * scaleFactor(c.scale)
+}
+
+// Scaled implements the Scaler interface
+func (c *WindowsChrome) Scaled(factor float64) Chrome {
+	scaled := *c
+	scaled.scale = factor
+	return &scaled
 }
 
 func (c *WindowsChrome) ContentInsets() (top, right, bottom, left int) {
-	return c.titleBarHeight(), 0, 0, 0
+	return scaleSize(c.titleBarHeight(), c.scale), 0, 0, 0
 }
 
 func (c *WindowsChrome) titleBarHeight() int {
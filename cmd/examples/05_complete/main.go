@@ -154,13 +154,19 @@ func main() {
 		imageBackground,
 	}
 
+	// The code is highlighted and laid out once, and reused by every canvas
+	rendered, err := content.RenderContent()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	os.MkdirAll("example_output", 0755)
 	for i, chrome := range chromes {
 		for j, background := range backgrounds {
 			canvas := render.NewCanvas().
 				WithChrome(chrome).
 				WithBackground(background).
-				WithContent(content)
+				WithContent(rendered)
 			err := canvas.SaveAsPNG(fmt.Sprintf("example_output/output_%d_%d.png", i, j))
 			if err != nil {
 				log.Fatal(err)
@@ -2,9 +2,9 @@ package main
 
 import (
 	"image/color"
+	"image/png"
 	"log"
 	"os"
-	"sort"
 	"strings"
 	"text/template"
 
@@ -98,14 +98,23 @@ func main() {
     }
 }`
 
-	color_schemes := code.GetAvailableStyles()
-	sort.Strings(color_schemes)
+	var color_schemes []string
+	var canvases []*render.Canvas
+	for _, info := range code.GetStyleInfo() {
+		scheme := info.Name
+		color_schemes = append(color_schemes, scheme)
 
-	for _, scheme := range color_schemes {
-		canvas := render.NewCanvas().
+		// Match the window to the scheme
+		variant := chrome.ThemeVariantLight
+		if info.Dark {
+			variant = chrome.ThemeVariantDark
+		}
+
+		canvases = append(canvases, render.NewCanvas().
 			WithChrome(chrome.NewMacChrome(
 				chrome.MacStyleSequoia,
-				chrome.WithTitle(scheme+" example"))).
+				chrome.WithTitle(scheme+" example"),
+				chrome.WithVariant(variant))).
 			WithBackground(
 				background.NewColorBackground().
 					WithColor(color.RGBA{R: 20, G: 30, B: 40, A: 255}).
@@ -115,10 +124,22 @@ func main() {
 				WithLanguage("go").
 				WithTheme(scheme).
 				WithTabWidth(4).
-				WithLineNumbers(true))
+				WithLineNumbers(true)))
+	}
 
-		os.MkdirAll("example_output", 0755)
-		err := canvas.SaveAsPNG("example_output/" + scheme + ".png")
+	// The schemes are rendered in parallel, one worker per CPU
+	images, errs := render.RenderBatch(canvases, 0)
+	os.MkdirAll("example_output", 0755)
+	for i, scheme := range color_schemes {
+		if errs[i] != nil {
+			log.Fatal(errs[i])
+		}
+		f, err := os.Create("example_output/" + scheme + ".png")
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = png.Encode(f, images[i])
+		f.Close()
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/watzon/goshot/cmd/goshot/config"
+	"github.com/watzon/goshot/cmd/goshot/utils"
+)
+
+// isGlob reports whether the input argument is a pattern rather than a file name
+func isGlob(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// renderBatch renders every file matching the glob pattern, each saved to the output
+// filename template as filled in for that file. A file that fails is reported and the
+// rest are still rendered; the error returned then says how many failed.
+func renderBatch(cfg *config.Config, pattern string) error {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files match %q", pattern)
+	}
+	if len(files) > 1 && cfg.OutputFile != "" && !strings.Contains(cfg.OutputFile, "{{") {
+		return fmt.Errorf("%d files match %q, but they would all be written to %s; use a template such as 'out/{{.FileBase}}.png'", len(files), pattern, cfg.OutputFile)
+	}
+
+	failed := 0
+	for _, file := range files {
+		cfg.Input = file
+		content, err := os.ReadFile(file)
+		if err == nil {
+			err = utils.RenderCode(cfg, true, string(content))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, config.Styles.Error.Render(fmt.Sprintf("Failed to render %s: %v", file, err)))
+			failed++
+		}
+	}
+
+	config.LogMessage(config.Styles.SuccessBox, "DONE", fmt.Sprintf("rendered %d of %d files", len(files)-failed, len(files)))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to render", failed, len(files))
+	}
+	return nil
+}
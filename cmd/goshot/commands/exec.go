@@ -78,6 +78,7 @@ func init() {
 	// Appearance flags
 	appearanceFlags := makeAppearanceFlagSet()
 	appearanceFlags.StringVarP(&config.Default.PromptTemplate, "prompt-template", "P", "\x1b[1;35m❯ \x1b[0;32m[command]\x1b[0m\n", "Prompt template")
+	appearanceFlags.StringVar(&config.Default.TermPalette, "palette", "", "ANSI color palette: xterm, vga, terminal-app, campbell or a terminal theme name (default: the theme's)")
 	execCmd.Flags().AddFlagSet(appearanceFlags)
 	rfg[appearanceFlags] = "appearance"
 
@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/watzon/goshot/cmd/goshot/config"
 	"github.com/watzon/goshot/cmd/goshot/utils"
+	"github.com/watzon/goshot/content/code"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -37,6 +38,31 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
+		if config.Default.Watch {
+			if len(args) == 0 || config.Default.FromClipboard || config.Default.ToStdout {
+				fmt.Println(config.Styles.Error.Render("Watch mode needs an input file, and can't write to stdout"))
+				os.Exit(1)
+			}
+			config.Default.Input = args[0]
+			if err := watchFile(&config.Default, args[0]); err != nil {
+				fmt.Println(config.Styles.Error.Render("Failed to watch file: " + err.Error()))
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(args) > 0 && !config.Default.FromClipboard && isGlob(args[0]) {
+			if config.Default.ToStdout {
+				fmt.Println(config.Styles.Error.Render("Can't write several files to stdout"))
+				os.Exit(1)
+			}
+			if err := renderBatch(&config.Default, args[0]); err != nil {
+				fmt.Println(config.Styles.Error.Render(err.Error()))
+				os.Exit(1)
+			}
+			return
+		}
+
 		var code string
 		var err error
 		switch {
@@ -84,6 +110,7 @@ func init() {
 	flags := rootCmd.PersistentFlags()
 
 	flags.StringVar(&config.Default.Language, "language", "", "Language override")
+	flags.String("config", "", "Config file to use instead of ~/.config/goshot/config.yaml")
 
 	// Add flag sets
 	rootCmd.PersistentFlags().AddFlagSet(makeOutputFlagSet())
@@ -114,9 +141,13 @@ func init() {
 func makeOutputFlagSet() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("output", pflag.ContinueOnError)
 	fs.StringVarP(&config.Default.OutputFile, "output", "o", "", "Write output image to specific location instead of cwd")
+	fs.IntVar(&config.Default.Quality, "quality", 0, "WebP quality from 1 to 100 (lossy), or 0 for lossless")
+	fs.IntVar(&config.Default.JPEGQuality, "jpeg-quality", 90, "JPEG quality from 1 to 100 (ignored for other formats)")
+	fs.BoolVar(&config.Default.Metadata, "metadata", false, "Embed the command, theme and creation time in PNG output")
 	fs.BoolVarP(&config.Default.ToClipboard, "to-clipboard", "c", false, "Copy the output image to clipboard")
 	fs.BoolVar(&config.Default.FromClipboard, "from-clipboard", false, "Read input from clipboard")
 	fs.BoolVarP(&config.Default.ToStdout, "to-stdout", "s", false, "Write output to stdout")
+	fs.BoolVar(&config.Default.Watch, "watch", false, "Render again whenever the input file changes")
 	fs.BoolVar(&config.Default.ShowPrompt, "show-prompt", false, "Show the prompt used to generate the screenshot")
 	return fs
 }
@@ -155,38 +186,54 @@ func makeLayoutFlagSet() *pflag.FlagSet {
 	fs.IntVar(&config.Default.MinWidth, "min-width", 0, "Minimum width")
 	fs.IntVar(&config.Default.MaxWidth, "max-width", 0, "Maximum width")
 	fs.IntVar(&config.Default.TabWidth, "tab-width", 4, "Tab width")
+	fs.StringVar(&config.Default.AspectRatio, "aspect-ratio", "", "Pad the background to an aspect ratio, as a size or ratio (e.g., 1200x630 or 16:9)")
+	fs.StringVar(&config.Default.AspectFit, "aspect-fit", "grow", "How to reach the aspect ratio (grow: only pad, scale: pad then scale to the size)")
+	fs.StringVar(&config.Default.OutputSize, "output-size", "", "Exact size of the image (e.g., 800x400), scaling the window down if it doesn't fit")
 	return fs
 }
 
 func makeRedactionFlagSet() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("redaction", pflag.ExitOnError)
 	fs.BoolVar(&config.Default.RedactionEnabled, "redact", false, "Enable redaction of sensitive information")
-	fs.StringVar(&config.Default.RedactionStyle, "redact-style", "block", "Redaction style (block or blur)")
+	fs.StringVar(&config.Default.RedactionStyle, "redact-style", "block", "Redaction style (block, blur or label)")
 	fs.Float64Var(&config.Default.RedactionBlurRadius, "redact-blur", 5.0, "Blur radius for redacted areas")
 	fs.StringSliceVar(&config.Default.RedactionPatterns, "redact-pattern", []string{}, "Additional regex patterns for redaction (can be specified multiple times)")
+	fs.StringSliceVar(&config.Default.RedactionPatternSets, "redact-set", []string{}, "Additional redaction patterns for a provider: "+strings.Join(code.RedactionPatternSetNames(), ", ")+" (can be specified multiple times)")
 	fs.StringSliceVar(&config.Default.RedactionAreas, "redact-area", []string{}, "Manual redaction areas in format 'x,y,width,height' (can be specified multiple times)")
 	return fs
 }
 
 func makeAppearanceFlagSet() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("appearance", pflag.ExitOnError)
-	fs.StringVarP(&config.Default.WindowChrome, "chrome", "C", "mac", "Chrome style (mac, windows, gnome)")
+	fs.StringVarP(&config.Default.WindowChrome, "chrome", "C", "mac", "Chrome style (mac, windows, gnome, browser, minimal)")
 	fs.StringVarP(&config.Default.ChromeThemeName, "chrome-theme", "T", "", "Chrome theme name")
 	fs.BoolVarP(&config.Default.LightMode, "light-mode", "L", false, "Use light mode")
+	fs.BoolVar(&config.Default.AutoChromeVariant, "auto-variant", false, "Use the light or dark chrome to suit the background, instead of --light-mode")
 	fs.StringVarP(&config.Default.Theme, "theme", "t", "ayu-dark", "Syntax highlight theme name")
 	fs.StringVarP(&config.Default.Font, "font", "f", "JetBrainsMonoNerdFont", "Fallback font list (e.g., 'Hack; SimSun=31')")
+	fs.BoolVar(&config.Default.Ligatures, "ligatures", false, "Draw the font's ligatures, like those of -> and !=")
+	fs.BoolVar(&config.Default.NestedCode, "nested-code", false, "Highlight fenced code blocks in Markdown in their own languages")
+	fs.IntVar(&config.Default.LetterSpacing, "letter-spacing", 0, "Pixels added between characters")
 	fs.Float64Var(&config.Default.LineHeight, "line-height", 1.0, "Line height")
 	fs.StringVarP(&config.Default.BackgroundColor, "background", "b", "#ABB8C3", "Background color")
-	fs.StringVar(&config.Default.BackgroundImage, "background-image", "", "Background image path")
+	fs.BoolVar(&config.Default.TransparentCode, "transparent-code", false, "Leave out the theme's background behind the code")
+	fs.Float64Var(&config.Default.FrostedBlur, "frosted", 0.0, "Blur the background under the window by this radius, like frosted glass")
+	fs.StringVar(&config.Default.FrostedTint, "frosted-tint", "#FFFFFF1A", "Color drawn over the frosted background, in any CSS color format")
+	fs.StringVar(&config.Default.BackgroundImage, "background-image", "", "Background image path or HTTP(S) URL")
 	fs.StringVar(&config.Default.BackgroundImageFit, "background-image-fit", "cover", "Background image fit (contain, cover, fill, stretch, tile)")
 	fs.Float64Var(&config.Default.BackgroundBlur, "background-blur", 0.0, "Background blur radius")
 	fs.StringVar(&config.Default.BackgroundBlurType, "background-blur-type", "gaussian", "Background blur type (gaussian, pixelated)")
+	fs.Float64Var(&config.Default.BackgroundVignette, "background-vignette", 0.0, "Darken the edges of a gradient or image background, from 0 (off) to 1")
+	fs.IntVar(&config.Default.Reflection, "reflection", 0, "Height of a faded mirror image of the window drawn below it, in the bottom padding")
+	fs.Float64Var(&config.Default.ReflectionOpacity, "reflection-opacity", 0.3, "Opacity of the reflection at its top, from 0 to 1")
 	fs.Float64Var(&config.Default.CornerRadius, "corner-radius", 10.0, "Corner radius of the image")
 	fs.BoolVar(&config.Default.NoWindowControls, "no-window-controls", false, "Hide window controls")
 	fs.StringVar(&config.Default.WindowTitle, "window-title", "", "Window title")
 	fs.Float64Var(&config.Default.WindowCornerRadius, "window-corner-radius", 10.0, "Corner radius of the window")
 	fs.StringSliceVar(&config.Default.LineRanges, "line-range", []string{}, "Line range (e.g. 1-10)")
+	fs.IntVar(&config.Default.MaxLines, "max-lines", 0, "Maximum number of lines to render, followed by a count of the rest (0 for no limit)")
 	fs.StringSliceVar(&config.Default.HighlightLines, "highlight-lines", []string{}, "Highlight lines")
+	fs.BoolVar(&config.Default.DimNonHighlighted, "dim-others", false, "Dim the lines that aren't highlighted")
 	return fs
 }
 
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/watzon/goshot/cmd/goshot/config"
+	"github.com/watzon/goshot/cmd/goshot/utils"
+)
+
+// watchDebounce is how long the input has to stay unchanged before it's rendered
+// again, so that an editor saving in several writes causes a single render
+const watchDebounce = 100 * time.Millisecond
+
+// watchFile renders the file at path, then renders it again each time it changes,
+// until interrupted. Failed renders are reported and the watch goes on.
+func watchFile(cfg *config.Config, path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// Many editors save by writing a new file and renaming it over the old one, which
+	// ends a watch on the file itself, so its directory is watched instead
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	render := func() {
+		start := time.Now()
+		content, err := os.ReadFile(path)
+		if err == nil {
+			err = utils.RenderCode(cfg, true, string(content))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, config.Styles.Error.Render("Failed to render image: "+err.Error()))
+			return
+		}
+		config.LogMessage(config.Styles.InfoBox, "RENDERED", fmt.Sprintf("%s in %s", cfg.Input, time.Since(start).Round(time.Millisecond)))
+	}
+	render()
+	config.LogMessage(config.Styles.InfoBox, "WATCHING", cfg.Input+" (press Ctrl+C to stop)")
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Name != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			pending = time.After(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintln(os.Stderr, config.Styles.Error.Render("Watch error: "+err.Error()))
+		case <-pending:
+			pending = nil
+			render()
+		case <-interrupt:
+			return nil
+		}
+	}
+}
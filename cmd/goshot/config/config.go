@@ -19,30 +19,46 @@ type Config struct {
 	Input         string
 	Args          []string
 	OutputFile    string
+	Quality       int  // WebP quality from 1 to 100, or 0 for lossless
+	JPEGQuality   int  // JPEG quality from 1 to 100
+	Metadata      bool // Whether to embed the command, theme and time in PNG output
 	ToClipboard   bool
 	FromClipboard bool
 	ToStdout      bool
+	Watch         bool // Whether to render again whenever the input file changes
 
 	// Appearance
 	WindowChrome       string
 	ChromeThemeName    string
 	LightMode          bool
+	AutoChromeVariant  bool
 	Theme              string
 	Language           string
 	Font               string
+	Ligatures          bool
+	NestedCode         bool
+	LetterSpacing      int
 	LineHeight         float64
 	BackgroundColor    string
+	TransparentCode    bool
+	FrostedBlur        float64
+	FrostedTint        string
 	BackgroundImage    string
 	BackgroundImageFit string
 	BackgroundBlur     float64
 	BackgroundBlurType string
+	BackgroundVignette float64
+	Reflection         int
+	ReflectionOpacity  float64
 	NoLineNumbers      bool
 	CornerRadius       float64
 	NoWindowControls   bool
 	WindowTitle        string
 	WindowCornerRadius float64
 	LineRanges         []string
+	MaxLines           int
 	HighlightLines     []string
+	DimNonHighlighted  bool
 
 	// Gradient options
 	GradientType      string
@@ -66,6 +82,9 @@ type Config struct {
 	LineNumberPadding int
 	MinWidth          int
 	MaxWidth          int
+	AspectRatio       string
+	AspectFit         string
+	OutputSize        string
 
 	// Shadow options
 	ShadowBlurRadius float64
@@ -86,24 +105,30 @@ type Config struct {
 	ShowPrompt     bool
 	AutoTitle      bool
 	PromptTemplate string
+	TermPalette    string
 
 	// Redaction settings
-	RedactionEnabled    bool
-	RedactionStyle      string
-	RedactionBlurRadius float64
-	RedactionPatterns   []string
-	RedactionAreas      []string
+	RedactionEnabled     bool
+	RedactionStyle       string
+	RedactionBlurRadius  float64
+	RedactionPatterns    []string
+	RedactionPatternSets []string
+	RedactionAreas       []string
 }
 
 // Default configuration instance
 var Default Config
 
+// ProjectConfigName is the name of a project's config file, looked for in the working
+// directory and then each of its parents
+const ProjectConfigName = ".goshot.yaml"
+
 func getConfigDir() string {
 	configHome := os.Getenv("XDG_CONFIG_HOME")
 	if configHome == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, fmt.Sprintf("Error getting home directory: %v", err), "\n")
+			fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
 			return ""
 		}
 		configHome = filepath.Join(homeDir, ".config")
@@ -149,27 +174,42 @@ func saveDefaultConfig() error {
 // bindConfig binds viper values to the Default config struct
 func bindConfig() {
 	Default.OutputFile = viper.GetString("io.output_file")
+	Default.Quality = viper.GetInt("io.quality")
+	Default.JPEGQuality = viper.GetInt("io.jpeg_quality")
+	Default.Metadata = viper.GetBool("io.metadata")
 	Default.ToClipboard = viper.GetBool("io.copy_to_clipboard")
 
 	// Appearance
 	Default.WindowChrome = viper.GetString("appearance.window_chrome")
 	Default.ChromeThemeName = viper.GetString("appearance.chrome_theme")
 	Default.LightMode = viper.GetBool("appearance.light_mode")
+	Default.AutoChromeVariant = viper.GetBool("appearance.auto_variant")
 	Default.Theme = viper.GetString("appearance.theme")
 	Default.Font = viper.GetString("appearance.font")
+	Default.Ligatures = viper.GetBool("appearance.ligatures")
+	Default.NestedCode = viper.GetBool("appearance.nested_code")
+	Default.LetterSpacing = viper.GetInt("appearance.letter_spacing")
 	Default.LineHeight = viper.GetFloat64("appearance.line_height")
 	Default.BackgroundColor = viper.GetString("appearance.background.color")
+	Default.TransparentCode = viper.GetBool("appearance.background.transparent_code")
+	Default.FrostedBlur = viper.GetFloat64("appearance.background.frosted.blur_radius")
+	Default.FrostedTint = viper.GetString("appearance.background.frosted.tint")
 	Default.BackgroundImage = viper.GetString("appearance.background.image.source")
 	Default.BackgroundImageFit = viper.GetString("appearance.background.image_fit")
 	Default.BackgroundBlur = viper.GetFloat64("appearance.background.blur.radius")
 	Default.BackgroundBlurType = viper.GetString("appearance.background.blur.type")
+	Default.BackgroundVignette = viper.GetFloat64("appearance.background.vignette")
+	Default.Reflection = viper.GetInt("appearance.background.reflection.height")
+	Default.ReflectionOpacity = viper.GetFloat64("appearance.background.reflection.opacity")
 	Default.NoLineNumbers = !viper.GetBool("appearance.line_numbers")
 	Default.CornerRadius = viper.GetFloat64("appearance.corner_radius")
 	Default.NoWindowControls = !viper.GetBool("appearance.window.controls")
 	Default.WindowTitle = viper.GetString("appearance.window.title")
 	Default.WindowCornerRadius = viper.GetFloat64("appearance.window.corner_radius")
 	Default.LineRanges = viper.GetStringSlice("appearance.lines.ranges")
+	Default.MaxLines = viper.GetInt("appearance.lines.max")
 	Default.HighlightLines = viper.GetStringSlice("appearance.lines.highlight")
+	Default.DimNonHighlighted = viper.GetBool("appearance.lines.dim_others")
 
 	// Gradient
 	Default.GradientType = viper.GetString("appearance.background.gradient.type")
@@ -198,6 +238,9 @@ func bindConfig() {
 	Default.MinWidth = viper.GetInt("appearance.layout.width.min")
 	Default.MaxWidth = viper.GetInt("appearance.layout.width.max")
 	Default.TabWidth = viper.GetInt("appearance.layout.tab_width")
+	Default.AspectRatio = viper.GetString("appearance.layout.aspect_ratio.size")
+	Default.AspectFit = viper.GetString("appearance.layout.aspect_ratio.fit")
+	Default.OutputSize = viper.GetString("appearance.layout.output_size")
 
 	// Terminal
 	Default.CellWidth = viper.GetInt("terminal.width")
@@ -210,43 +253,109 @@ func bindConfig() {
 	Default.CellSpacing = viper.GetInt("terminal.cell_spacing")
 	Default.ShowPrompt = viper.GetBool("terminal.show_prompt")
 	Default.PromptTemplate = viper.GetString("terminal.prompt_template")
+	Default.TermPalette = viper.GetString("terminal.palette")
 
 	// Redaction
 	Default.RedactionEnabled = viper.GetBool("redaction.enabled")
 	Default.RedactionStyle = viper.GetString("redaction.style")
 	Default.RedactionBlurRadius = viper.GetFloat64("redaction.blur_radius")
 	Default.RedactionPatterns = viper.GetStringSlice("redaction.patterns")
+	Default.RedactionPatternSets = viper.GetStringSlice("redaction.pattern_sets")
 	Default.RedactionAreas = viper.GetStringSlice("redaction.areas")
 }
 
-// Initialize sets up the configuration with defaults and loads from config file
-func Initialize() {
+// findProjectConfig returns the path of the project config file closest to the working
+// directory, or "" if there's none
+func findProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ConfigFileArg returns the value of the --config flag in the command line arguments,
+// which has to be known before the flags are parsed, or "" if it isn't given
+func ConfigFileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			return value
+		}
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// Initialize sets up the configuration with defaults and loads from config files.
+// Each layer overrides the one before it:
+//
+//  1. the built-in defaults
+//  2. the user config, config.yaml in $XDG_CONFIG_HOME/goshot or ~/.config/goshot,
+//     or configFile instead if it isn't empty
+//  3. the project config, the .goshot.yaml closest to the working directory
+//  4. GOSHOT_ environment variables
+//  5. flags given on the command line, which are parsed afterwards
+//
+// A user config with the defaults is written if there is none.
+func Initialize(configFile string) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 
 	// Set config paths
-	configDir := getConfigDir()
-	if configDir != "" {
-		viper.AddConfigPath(configDir)
+	if configFile != "" {
+		viper.SetConfigFile(configFile)
+	} else {
+		configDir := getConfigDir()
+		if configDir != "" {
+			viper.AddConfigPath(configDir)
+		}
+		viper.AddConfigPath(".")
 	}
-	viper.AddConfigPath(".")
 
 	// Input/Output options
 	viper.SetDefault("io.output_file", "output.png")
+	viper.SetDefault("io.quality", 0)
+	viper.SetDefault("io.jpeg_quality", 90)
+	viper.SetDefault("io.metadata", false)
 	viper.SetDefault("io.copy_to_clipboard", false)
 
 	// Appearance
 	viper.SetDefault("appearance.window_chrome", "mac")
 	viper.SetDefault("appearance.chrome_theme", "")
 	viper.SetDefault("appearance.light_mode", false)
+	viper.SetDefault("appearance.auto_variant", false)
 	viper.SetDefault("appearance.theme", "ayu-dark")
 	viper.SetDefault("appearance.font", "JetBrainsMonoNerdFont")
+	viper.SetDefault("appearance.ligatures", false)
+	viper.SetDefault("appearance.nested_code", false)
+	viper.SetDefault("appearance.letter_spacing", 0)
 	viper.SetDefault("appearance.line_height", 1.0)
 	viper.SetDefault("appearance.background.color", "#ABB8C3")
+	viper.SetDefault("appearance.background.transparent_code", false)
+	viper.SetDefault("appearance.background.frosted.blur_radius", 0.0)
+	viper.SetDefault("appearance.background.frosted.tint", "#FFFFFF1A")
 	viper.SetDefault("appearance.background.image.source", "")
 	viper.SetDefault("appearance.background.image_fit", "cover")
 	viper.SetDefault("appearance.background.blur.radius", 0.0)
 	viper.SetDefault("appearance.background.blur.type", "gaussian")
+	viper.SetDefault("appearance.background.vignette", 0.0)
+	viper.SetDefault("appearance.background.reflection.height", 0)
+	viper.SetDefault("appearance.background.reflection.opacity", 0.3)
 	viper.SetDefault("appearance.background.gradient.type", "")
 	viper.SetDefault("appearance.background.gradient.stops", []string{"#232323;0", "#383838;100"})
 	viper.SetDefault("appearance.background.gradient.angle", 45.0)
@@ -259,7 +368,9 @@ func Initialize() {
 	viper.SetDefault("appearance.window.title", "")
 	viper.SetDefault("appearance.window.corner_radius", 10.0)
 	viper.SetDefault("appearance.lines.ranges", []string{})
+	viper.SetDefault("appearance.lines.max", 0)
 	viper.SetDefault("appearance.lines.highlight", []string{})
+	viper.SetDefault("appearance.lines.dim_others", false)
 	viper.SetDefault("appearance.shadow.blur_radius", 0.0)
 	viper.SetDefault("appearance.shadow.color", "#00000033")
 	viper.SetDefault("appearance.shadow.spread", 0.0)
@@ -276,6 +387,9 @@ func Initialize() {
 	viper.SetDefault("appearance.layout.width.min", 0)
 	viper.SetDefault("appearance.layout.width.max", 0)
 	viper.SetDefault("appearance.layout.tab_width", 4)
+	viper.SetDefault("appearance.layout.aspect_ratio.size", "")
+	viper.SetDefault("appearance.layout.aspect_ratio.fit", "grow")
+	viper.SetDefault("appearance.layout.output_size", "")
 
 	// Terminal options
 	viper.SetDefault("terminal.width", 120)
@@ -288,12 +402,14 @@ func Initialize() {
 	viper.SetDefault("terminal.cell_spacing", 0)
 	viper.SetDefault("terminal.show_prompt", false)
 	viper.SetDefault("terminal.prompt_template", "\x1b[1;35m❯ \x1b[0;32m[command]\x1b[0m\n")
+	viper.SetDefault("terminal.palette", "")
 
 	// Redaction options
 	viper.SetDefault("redaction.enabled", false)
 	viper.SetDefault("redaction.style", "block")
 	viper.SetDefault("redaction.blur_radius", 5.0)
 	viper.SetDefault("redaction.patterns", []string{})
+	viper.SetDefault("redaction.pattern_sets", []string{})
 	viper.SetDefault("redaction.areas", []string{})
 
 	// Read config file
@@ -301,10 +417,20 @@ func Initialize() {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; save default config
 			if err := saveDefaultConfig(); err != nil {
-				fmt.Fprintf(os.Stderr, fmt.Sprintf("Error saving default config: %v", err), "\n")
+				fmt.Fprintf(os.Stderr, "Error saving default config: %v\n", err)
 			}
 		} else {
-			fmt.Fprintf(os.Stderr, fmt.Sprintf("Error reading config file: %v", err), "\n")
+			fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
+		}
+	}
+
+	// Merge the project config over the user config
+	if path := findProjectConfig(); path != "" {
+		if f, err := os.Open(path); err == nil {
+			if err := viper.MergeConfig(f); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading project config file %s: %v\n", path, err)
+			}
+			f.Close()
 		}
 	}
 
@@ -9,8 +9,8 @@ import (
 )
 
 func main() {
-	// Initialize configuration
-	config.Initialize()
+	// Initialize configuration, before the flags that override it are parsed
+	config.Initialize(config.ConfigFileArg(os.Args[1:]))
 
 	// Execute root command
 	if err := commands.Execute(); err != nil {
@@ -3,7 +3,6 @@ package utils
 import (
 	"fmt"
 	"image/color"
-	"strconv"
 	"strings"
 
 	"github.com/watzon/goshot/background"
@@ -34,37 +33,17 @@ func ParseHexColor(hex string) (color.Color, error) {
 	return color.RGBA{R: r, G: g, B: b, A: a}, nil
 }
 
-// ParseGradientStops takes in a string slice of gradient stops and returns
-// a slice of background.GradientStop.
+// ParseGradientStops takes in a string slice of gradient stops in the form
+// "color;percentage" and returns a slice of background.GradientStop. The colors can be
+// anything background.ParseColor accepts.
 func ParseGradientStops(input []string) ([]background.GradientStop, error) {
-	var result []background.GradientStop
-	for _, part := range input {
-		parts := strings.Split(part, ";")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid gradient stop format: %s; expected hex color and percentage (e.g., #ff0000;50)", part)
+	stops := make([]string, len(input))
+	for i, part := range input {
+		color, position, ok := strings.Cut(part, ";")
+		if !ok || strings.Contains(position, ";") {
+			return nil, fmt.Errorf("invalid gradient stop format: %s; expected color and percentage (e.g., #ff0000;50)", part)
 		}
-
-		hexColor := strings.TrimSpace(parts[0])
-		positionStr := strings.TrimSpace(parts[1])
-
-		color, err := ParseHexColor(hexColor)
-		if err != nil {
-			return nil, fmt.Errorf("invalid color in gradient stop: %s", err)
-		}
-
-		position, err := strconv.ParseFloat(positionStr, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid position in gradient stop: %s", err)
-		}
-
-		if position < 0 || position > 100 {
-			return nil, fmt.Errorf("gradient stop position must be between 0 and 100: %f", position)
-		}
-
-		result = append(result, background.GradientStop{
-			Color:    color,
-			Position: position / 100, // Convert percentage to decimal
-		})
+		stops[i] = fmt.Sprintf("%s %s%%", strings.TrimSpace(color), strings.TrimSpace(position))
 	}
-	return result, nil
+	return background.ParseGradientStops(strings.Join(stops, ", "))
 }
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gen2brain/webp"
+	"github.com/watzon/goshot/render"
 	"golang.org/x/image/bmp"
 )
 
@@ -45,8 +47,11 @@ func EscapeCommand(command string) string {
 	).Replace(command)
 }
 
-// SaveImageToFile saves the given image to a file with the specified configuration
-func SaveImageToFile(img image.Image, outputFile string) (string, error) {
+// SaveImageToFile saves the given image to a file in the format matching its
+// extension. The quality only applies to WebP, where 1 to 100 saves a lossy image
+// and 0 a lossless one, and the JPEG quality, from 1 to 100, only to JPEG; PNG and
+// BMP ignore both. The metadata is embedded in PNG images, and ignored otherwise.
+func SaveImageToFile(img image.Image, outputFile string, quality, jpegQuality int, metadata map[string]string) (string, error) {
 	if outputFile == "" {
 		return "", nil
 	}
@@ -61,6 +66,29 @@ func SaveImageToFile(img image.Image, outputFile string) (string, error) {
 		resolvedFilename += ext
 	}
 
+	// Pick the encoder before creating anything on disk
+	var encode func(f *os.File) error
+	switch ext {
+	case ".png":
+		encode = func(f *os.File) error { return render.EncodePNG(f, img, metadata) }
+	case ".jpg", ".jpeg":
+		if jpegQuality < 1 || jpegQuality > 100 {
+			return "", fmt.Errorf("invalid JPEG quality %d: must be between 1 and 100", jpegQuality)
+		}
+		encode = func(f *os.File) error { return jpeg.Encode(f, img, &jpeg.Options{Quality: jpegQuality}) }
+	case ".bmp":
+		encode = func(f *os.File) error { return bmp.Encode(f, img) }
+	case ".webp":
+		if quality < 0 || quality > 100 {
+			return "", fmt.Errorf("invalid WebP quality %d: must be between 0 and 100", quality)
+		}
+		encode = func(f *os.File) error {
+			return webp.Encode(f, img, webp.Options{Quality: quality, Lossless: quality == 0, Method: webp.DefaultMethod})
+		}
+	default:
+		return "", fmt.Errorf("unsupported file format: %s", ext)
+	}
+
 	// Ensure the directory exists
 	if dir := filepath.Dir(resolvedFilename); dir != "" {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -75,17 +103,7 @@ func SaveImageToFile(img image.Image, outputFile string) (string, error) {
 	}
 	defer f.Close()
 
-	// Save in the format matching the extension
-	switch ext {
-	case ".png":
-		return resolvedFilename, png.Encode(f, img)
-	case ".jpg", ".jpeg":
-		return resolvedFilename, jpeg.Encode(f, img, nil)
-	case ".bmp":
-		return resolvedFilename, bmp.Encode(f, img)
-	default:
-		return "", fmt.Errorf("unsupported file format: %s", ext)
-	}
+	return resolvedFilename, encode(f)
 }
 
 // GetDefaultFilename generates a default filename if none is provided
@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"bufio"
 	"fmt"
 	"image"
 	"image/color"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/watzon/goshot/background"
 	"github.com/watzon/goshot/chrome"
 	"github.com/watzon/goshot/cmd/goshot/config"
@@ -46,10 +50,13 @@ func RenderCode(cfg *config.Config, echo bool, input string) error {
 
 	// Configure content
 	content := code.DefaultRenderer(input).
-		WithLanguage(DetectLanguage(cfg.Input, cfg.Language)).
+		WithLanguage(DetectLanguage(cfg.Input, input, cfg.Language)).
 		WithTheme(cfg.Theme).
 		WithFontSize(fontSize).
 		WithLineHeight(cfg.LineHeight).
+		WithLigatures(cfg.Ligatures).
+		WithNestedCodeHighlighting(cfg.NestedCode).
+		WithLetterSpacing(cfg.LetterSpacing).
 		WithPadding(cfg.CodePadLeft, cfg.CodePadRight, cfg.CodePadTop, cfg.CodePadBottom).
 		WithLineNumberPadding(cfg.LineNumberPadding).
 		WithTabWidth(cfg.TabWidth).
@@ -70,8 +77,10 @@ func RenderCode(cfg *config.Config, echo bool, input string) error {
 			style = code.RedactionStyleBlur
 		case "block":
 			style = code.RedactionStyleBlock
+		case "label":
+			style = code.RedactionStyleLabel
 		default:
-			return fmt.Errorf("invalid redaction style: %s (must be 'block' or 'blur')", cfg.RedactionStyle)
+			return fmt.Errorf("invalid redaction style: %s (must be 'block', 'blur' or 'label')", cfg.RedactionStyle)
 		}
 		content.WithRedactionStyle(style)
 
@@ -82,6 +91,13 @@ func RenderCode(cfg *config.Config, echo bool, input string) error {
 			}
 		}
 
+		// Add redaction pattern sets, failing on unknown names rather than ignoring them
+		for _, name := range cfg.RedactionPatternSets {
+			if err := content.Style.RedactionConfig.AddPatternSet(name); err != nil {
+				return err
+			}
+		}
+
 		// Add manual redaction areas
 		if len(cfg.RedactionAreas) > 0 {
 			areas, err := ParseRedactionAreas(cfg.RedactionAreas)
@@ -111,10 +127,43 @@ func RenderCode(cfg *config.Config, echo bool, input string) error {
 	for _, lr := range lineRanges {
 		content.WithLineRange(lr.Start, lr.End)
 	}
+	content.WithMaxLines(cfg.MaxLines)
+	content.WithDimNonHighlighted(cfg.DimNonHighlighted)
+	if cfg.TransparentCode {
+		content.WithTransparentBackground()
+	}
 
 	canvas.WithContent(content)
 
-	return renderAndSave(canvas, cfg, echo)
+	if err := renderAndSave(canvas, cfg, echo); err != nil {
+		return err
+	}
+	if echo && cfg.RedactionEnabled {
+		logRedactions(input, content.Style.RedactionConfig)
+	}
+	return nil
+}
+
+// logRedactions reports how many secrets each redaction pattern found in the input
+func logRedactions(input string, redaction *code.RedactionConfig) {
+	counts := make(map[string]int)
+	var names []string
+	for _, match := range code.ScanForSecrets(input, redaction) {
+		if counts[match.Pattern] == 0 {
+			names = append(names, match.Pattern)
+		}
+		counts[match.Pattern]++
+	}
+	if len(names) == 0 {
+		config.LogMessage(config.Styles.InfoBox, "REDACTED", "nothing")
+		return
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	config.LogMessage(config.Styles.InfoBox, "REDACTED", strings.Join(parts, ", "))
 }
 
 // RenderTerm renders terminal content to an image with the given configuration
@@ -162,6 +211,13 @@ func RenderTerm(cfg *config.Config, echo bool, args []string, input []byte) erro
 		ShowPrompt:    cfg.ShowPrompt,
 		PromptFunc:    NewPromptFunc(cfg.PromptTemplate, cfg),
 	})
+	if cfg.TermPalette != "" {
+		palette, ok := content_term.GetPalette(cfg.TermPalette)
+		if !ok {
+			return fmt.Errorf("unknown palette: %s", cfg.TermPalette)
+		}
+		renderer.WithPalette(palette)
+	}
 
 	canvas.WithContent(renderer)
 
@@ -184,7 +240,7 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 	if cfg.NoWindowControls {
 		window := chrome.NewBlankChrome().
 			WithCornerRadius(cfg.WindowCornerRadius)
-		canvas.WithChrome(window)
+		canvas.WithChrome(transparentChrome(cfg, window))
 	} else {
 		var window chrome.Chrome
 		switch cfg.WindowChrome {
@@ -194,6 +250,10 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 			window = chrome.NewWindowsChrome(chrome.WindowsStyleWin11)
 		case "gnome":
 			window = chrome.NewGNOMEChrome(chrome.GNOMEStyleAdwaita)
+		case "browser":
+			window = chrome.NewBrowserChrome(chrome.BrowserStyleChromium)
+		case "minimal":
+			window = chrome.NewMinimalChrome()
 		default:
 			return nil, fmt.Errorf("invalid chrome style: %s", cfg.WindowChrome)
 		}
@@ -213,20 +273,33 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 		}
 
 		window = window.WithCornerRadius(cfg.WindowCornerRadius)
-		canvas.WithChrome(window)
+		canvas.WithChrome(transparentChrome(cfg, window))
+		if cfg.AutoChromeVariant {
+			canvas.WithAutoChromeVariant()
+		}
 	}
 
 	// Set background
 	var bg background.Background
 	if cfg.BackgroundImage != "" {
-		file, err := os.Open(cfg.BackgroundImage)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open background image: %v", err)
-		}
-		defer file.Close()
-		backgroundImage, _, err := image.Decode(file)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode background image: %v", err)
+		var imageBg background.ImageBackground
+		if strings.HasPrefix(cfg.BackgroundImage, "http://") || strings.HasPrefix(cfg.BackgroundImage, "https://") {
+			var err error
+			imageBg, err = background.NewImageBackgroundFromURL(cfg.BackgroundImage)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			file, err := os.Open(cfg.BackgroundImage)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open background image: %v", err)
+			}
+			defer file.Close()
+			backgroundImage, _, err := image.Decode(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode background image: %v", err)
+			}
+			imageBg = background.NewImageBackground(backgroundImage)
 		}
 		var fit background.ImageScaleMode
 		switch cfg.BackgroundImageFit {
@@ -243,9 +316,7 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 		default:
 			return nil, fmt.Errorf("invalid background image fit mode: %s", cfg.BackgroundImageFit)
 		}
-		bg = background.
-			NewImageBackground(backgroundImage).
-			WithScaleMode(fit)
+		bg = imageBg.WithScaleMode(fit)
 
 		// Apply blur if configured
 		if cfg.BackgroundBlur > 0 {
@@ -262,6 +333,8 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 		}
 
 		bg = bg.(background.ImageBackground).
+			WithVignette(cfg.BackgroundVignette).
+			WithReflection(cfg.Reflection, cfg.ReflectionOpacity).
 			WithPaddingDetailed(cfg.PadVert, cfg.PadHoriz, cfg.PadVert, cfg.PadHoriz)
 	} else if cfg.GradientType != "" {
 		stops, err := ParseGradientStops(cfg.GradientStops)
@@ -310,6 +383,8 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 		}
 
 		bg = bg.(background.GradientBackground).
+			WithVignette(cfg.BackgroundVignette).
+			WithReflection(cfg.Reflection, cfg.ReflectionOpacity).
 			WithPaddingDetailed(cfg.PadVert, cfg.PadHoriz, cfg.PadVert, cfg.PadHoriz)
 	} else if cfg.BackgroundColor != "" {
 		// Parse background color
@@ -326,6 +401,7 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 
 		bg = background.NewColorBackground().
 			WithColor(bgColor).
+			WithReflection(cfg.Reflection, cfg.ReflectionOpacity).
 			WithPaddingDetailed(cfg.PadVert, cfg.PadHoriz, cfg.PadVert, cfg.PadHoriz)
 	}
 
@@ -350,11 +426,74 @@ func makeCanvas(cfg *config.Config, args []string) (*render.Canvas, error) {
 
 		// Set background
 		canvas.WithBackground(bg)
+
+		if cfg.FrostedBlur > 0 {
+			tint, err := background.ParseColor(cfg.FrostedTint)
+			if err != nil {
+				return nil, fmt.Errorf("invalid frosted tint: %v", err)
+			}
+			canvas.WithFrostedContent(cfg.FrostedBlur, tint)
+		}
+	}
+
+	if cfg.AspectRatio != "" {
+		width, height, err := ParseAspectRatio(cfg.AspectRatio)
+		if err != nil {
+			return nil, err
+		}
+		var fit render.FitMode
+		switch cfg.AspectFit {
+		case "grow", "":
+			fit = render.FitGrow
+		case "scale":
+			fit = render.FitScale
+		default:
+			return nil, fmt.Errorf("invalid aspect fit: %s; expected grow or scale", cfg.AspectFit)
+		}
+		canvas.WithAspectRatio(width, height, fit)
+	}
+
+	// An exact size takes the place of the aspect ratio
+	if cfg.OutputSize != "" {
+		width, height, err := ParseSize(cfg.OutputSize)
+		if err != nil {
+			return nil, err
+		}
+		canvas.WithOutputSize(width, height)
 	}
 
 	return canvas, nil
 }
 
+// transparentChrome returns the chrome with the background it draws under the content
+// made transparent when the code's is, so that it doesn't show through
+func transparentChrome(cfg *config.Config, window chrome.Chrome) chrome.Chrome {
+	if !cfg.TransparentCode {
+		return window
+	}
+	theme := window.CurrentTheme()
+	theme.Properties.ContentBackground = color.Transparent
+	return window.WithTheme(theme)
+}
+
+// pngMetadata returns the metadata to embed in PNG output, or nil unless it's enabled
+func pngMetadata(cfg *config.Config) map[string]string {
+	if !cfg.Metadata {
+		return nil
+	}
+	metadata := map[string]string{
+		"Source":        strings.Join(os.Args, " "),
+		"Creation Time": time.Now().Format(time.RFC1123Z),
+	}
+	if cfg.Input != "" {
+		metadata["Title"] = filepath.Base(cfg.Input)
+	}
+	if cfg.Theme != "" {
+		metadata["Theme"] = cfg.Theme
+	}
+	return metadata
+}
+
 // renderAndSave renders the canvas to an image and saves it according to the configuration
 func renderAndSave(canvas *render.Canvas, cfg *config.Config, echo bool) error {
 	// Render to image
@@ -365,28 +504,22 @@ func renderAndSave(canvas *render.Canvas, cfg *config.Config, echo bool) error {
 
 	// Handle clipboard output
 	if cfg.ToClipboard {
-		imgBytes, err := ImageToBytes(img)
-		if err != nil {
+		if err := render.CopyToClipboard(img); err != nil {
 			return err
 		}
 
-		if err := clipboard.WriteAll(string(imgBytes)); err != nil {
-			return fmt.Errorf("failed to copy image to clipboard: %v", err)
-		}
-
 		if echo {
 			config.LogMessage(config.Styles.SuccessBox, "COPIED", "to clipboard")
 		}
 	}
 
-	// Handle stdout output
+	// Handle stdout output, encoding straight to it rather than into memory first
 	if cfg.ToStdout {
-		imgBytes, err := ImageToBytes(img)
-		if err != nil {
-			return err
+		out := bufio.NewWriter(os.Stdout)
+		if err := render.EncodePNG(out, img, pngMetadata(cfg)); err != nil {
+			return fmt.Errorf("failed to write image to stdout: %v", err)
 		}
-
-		if _, err := os.Stdout.Write(imgBytes); err != nil {
+		if err := out.Flush(); err != nil {
 			return fmt.Errorf("failed to write image to stdout: %v", err)
 		}
 
@@ -398,7 +531,7 @@ func renderAndSave(canvas *render.Canvas, cfg *config.Config, echo bool) error {
 	// Handle file output
 	if cfg.OutputFile != "" {
 		outputFile := NewFilenameFunc(cfg.OutputFile, cfg)()
-		resolvedFilename, err := SaveImageToFile(img, outputFile)
+		resolvedFilename, err := SaveImageToFile(img, outputFile, cfg.Quality, cfg.JPEGQuality, pngMetadata(cfg))
 		if err != nil {
 			return fmt.Errorf("failed to save image: %v", err)
 		}
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -140,21 +139,44 @@ func ParseRedactionAreas(areas []string) ([]code.RedactionArea, error) {
 	return result, nil
 }
 
-// DetectLanguage attempts to detect the language from file extension or explicit setting
-func DetectLanguage(filename, explicitLanguage string) string {
-	// If language is explicitly set, use it
+// DetectLanguage returns the language set explicitly, or else the one detected from
+// the filename and content
+func DetectLanguage(filename, content, explicitLanguage string) string {
 	if explicitLanguage != "" {
 		return explicitLanguage
 	}
+	return code.DetectLanguage(filename, content)
+}
 
-	// If we have a filename, try to detect from extension
-	if filename != "" {
-		ext := strings.TrimPrefix(filepath.Ext(filename), ".")
-		if ext != "" {
-			return ext
-		}
+// ParseAspectRatio parses an aspect ratio given as a size like 1200x630 or a ratio
+// like 16:9
+func ParseAspectRatio(s string) (width, height int, err error) {
+	parts := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == 'x' || r == ':' })
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid aspect ratio: %s; expected a size or ratio (e.g., 1200x630 or 16:9)", s)
+	}
+	width, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err == nil {
+		height, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	}
+	if err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid aspect ratio: %s; expected positive whole numbers", s)
 	}
+	return width, height, nil
+}
 
-	// Default to no language
-	return ""
+// ParseSize parses a size in pixels like 800x400
+func ParseSize(s string) (width, height int, err error) {
+	parts := strings.Split(strings.ToLower(s), "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid size: %s; expected a width and height (e.g., 800x400)", s)
+	}
+	width, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err == nil {
+		height, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	}
+	if err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size: %s; expected positive whole numbers", s)
+	}
+	return width, height, nil
 }
@@ -3,22 +3,23 @@ package utils
 import (
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/watzon/goshot/cmd/goshot/config"
+	content_term "github.com/watzon/goshot/content/term"
 )
 
 // TemplateData holds data that can be used in templates
 type TemplateData struct {
 	// System information
-	User    string
-	Host    string
-	Path    string
-	Command string
+	User      string
+	Host      string
+	Path      string
+	Command   string
+	GitBranch string // Branch of the Git repository Path is in, if any
 
 	// File information (from input file)
 	Filename string // Full filename with extension (or "stdin" for clipboard/stdin input)
@@ -41,15 +42,13 @@ func NewTemplateData(command string, cfg *config.Config) (*TemplateData, error)
 	}
 
 	// Get system information
-	if usr, err := user.Current(); err == nil {
-		data.User = usr.Username
-	}
-	if host, err := os.Hostname(); err == nil {
-		data.Host = host
-	}
-	if cwd, err := os.Getwd(); err == nil {
-		data.Path = cwd
-		data.FileDir = cwd // Default FileDir to cwd
+	ctx := content_term.NewPromptContext(command)
+	data.User = ctx.User
+	data.Host = ctx.Host
+	data.GitBranch = ctx.GitBranch
+	if ctx.Cwd != "" {
+		data.Path = ctx.Cwd
+		data.FileDir = ctx.Cwd // Default FileDir to cwd
 	}
 
 	// Only process file information if we have a config
@@ -0,0 +1,108 @@
+package code
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/fonts"
+	"golang.org/x/image/font"
+)
+
+// Corner identifies a corner of the code area. The zero value is BottomRight.
+type Corner int
+
+const (
+	BottomRight Corner = iota
+	BottomLeft
+	TopRight
+	TopLeft
+)
+
+const (
+	badgeFontScale = 0.8 // Size of the badge text relative to the code
+	badgeMargin    = 8   // Space between the badge and the edges of the code area
+	badgePaddingX  = 8   // Space between the text and the ends of the badge
+	badgePaddingY  = 3   // Space between the text and the top and bottom of the badge
+)
+
+// languageBadge is the placement and colors of a language badge
+type languageBadge struct {
+	x, y, width, height float64
+	fill, text          color.Color
+	baseline            float64 // Baseline of the text, centered horizontally in the badge
+}
+
+// layoutLanguageBadge places the language badge in the configured corner of an image
+// of the given size, colored after the theme's highlight and line numbers. The margin
+// and padding are multiplied by the style's scale, the font size being scaled already.
+func layoutLanguageBadge(h *HighlightedCode, config *CodeStyle, face font.Face, imageWidth, imageHeight int) languageBadge {
+	textWidth := float64(font.MeasureString(face, h.Language)) / 64
+	textHeight := float64(face.Metrics().Height) / 64
+	scale := config.scaleFactor()
+	margin := badgeMargin * scale
+
+	b := languageBadge{
+		x:      margin,
+		y:      margin,
+		width:  textWidth + badgePaddingX*2*scale,
+		height: textHeight + badgePaddingY*2*scale,
+		fill:   h.HighlightColor,
+		text:   h.LineNumberColor,
+	}
+	if config.LanguageBadgeCorner == BottomRight || config.LanguageBadgeCorner == TopRight {
+		b.x = float64(imageWidth) - margin - b.width
+	}
+	if config.LanguageBadgeCorner == BottomRight || config.LanguageBadgeCorner == BottomLeft {
+		b.y = float64(imageHeight) - margin - b.height
+	}
+	b.baseline = b.y + b.height/2 + textHeight*0.35
+
+	if b.fill == nil {
+		b.fill = color.NRGBA{R: 128, G: 128, B: 128, A: 96}
+	}
+	if b.text == nil {
+		b.text = color.Gray{Y: 128}
+	}
+	return b
+}
+
+// loadBadgeFace loads the face the language badge is written in
+func loadBadgeFace(config *CodeStyle) (*fonts.Face, error) {
+	face, err := config.getFace(config.FontSize*badgeFontScale, &fonts.FontStyle{
+		Weight:  fonts.WeightRegular,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create badge font face: %v", err)
+	}
+	return face, nil
+}
+
+// drawLanguageBadge draws a pill with the name of the highlighted language in the
+// configured corner of img
+func drawLanguageBadge(img *image.RGBA, h *HighlightedCode, config *CodeStyle) error {
+	if h.Language == "" {
+		return nil
+	}
+
+	face, err := loadBadgeFace(config)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	b := layoutLanguageBadge(h, config, face.Face, img.Bounds().Dx(), img.Bounds().Dy())
+
+	dc := gg.NewContextForRGBA(img)
+	dc.SetFontFace(face.Face)
+
+	dc.SetColor(b.fill)
+	dc.DrawRoundedRectangle(b.x, b.y, b.width, b.height, b.height/2)
+	dc.Fill()
+
+	dc.SetColor(b.text)
+	dc.DrawStringAnchored(h.Language, b.x+b.width/2, b.baseline, 0.5, 0)
+	return nil
+}
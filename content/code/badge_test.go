@@ -0,0 +1,75 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageBadge(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+	plain, err := DefaultRenderer(src).WithLanguage("go").Render()
+	require.NoError(t, err)
+	width, height := plain.Bounds().Dx(), plain.Bounds().Dy()
+
+	tests := []struct {
+		name      string
+		corner    Corner
+		left, top bool
+	}{
+		{name: "bottom right", corner: BottomRight},
+		{name: "bottom left", corner: BottomLeft, left: true},
+		{name: "top right", corner: TopRight, top: true},
+		{name: "top left", corner: TopLeft, left: true, top: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRenderer(src).WithLanguage("go").WithLanguageBadge(true).WithLanguageBadgeCorner(tt.corner)
+			h, err := Highlight(src, r.Style)
+			require.NoError(t, err)
+			face, err := loadBadgeFace(r.Style)
+			require.NoError(t, err)
+			defer face.Close()
+			b := layoutLanguageBadge(h, r.Style, face.Face, width, height)
+
+			// The badge is inset from the edges of its corner
+			assert.Equal(t, "Go", h.Language)
+			if tt.left {
+				assert.Equal(t, float64(badgeMargin), b.x)
+			} else {
+				assert.InDelta(t, float64(width-badgeMargin), b.x+b.width, 0.01)
+			}
+			if tt.top {
+				assert.Equal(t, float64(badgeMargin), b.y)
+			} else {
+				assert.InDelta(t, float64(height-badgeMargin), b.y+b.height, 0.01)
+			}
+
+			// The margin and padding grow with the scale, like the text
+			scaled := r.Scaled(2).(*CodeRenderer)
+			scaledFace, err := loadBadgeFace(scaled.Style)
+			require.NoError(t, err)
+			defer scaledFace.Close()
+			s := layoutLanguageBadge(h, scaled.Style, scaledFace.Face, 2*width, 2*height)
+			assert.InDelta(t, 2*b.x, s.x, 2)
+			assert.InDelta(t, 2*b.y, s.y, 2)
+			assert.InDelta(t, 2*b.height, s.height, 2)
+
+			// It's drawn there and nowhere else
+			img, err := r.Render()
+			require.NoError(t, err)
+			require.Equal(t, plain.Bounds(), img.Bounds())
+			x, y := int(b.x)+3, int(b.y+b.height/2)
+			assert.NotEqual(t, plain.At(x, y), img.At(x, y))
+			x, y = 2, 2
+			if tt.left {
+				x = width - 3
+			}
+			if tt.top {
+				y = height - 3
+			}
+			assert.Equal(t, plain.At(x, y), img.At(x, y))
+		})
+	}
+}
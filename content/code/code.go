@@ -1,14 +1,20 @@
 package code
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"log"
+	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/alecthomas/chroma/v2"
+	"github.com/fogleman/gg"
 	"github.com/watzon/goshot/content"
 	"github.com/watzon/goshot/fonts"
 	"golang.org/x/image/font"
@@ -16,23 +22,85 @@ import (
 )
 
 type CodeStyle struct {
-	Theme               string              // The chroma syntax theme to use
-	Language            string              // The language to highlight
-	Font                *fonts.Font         // The font to use
-	FontSize            float64             // The font size in points
-	LineHeight          float64             // The line height multiplier
-	PaddingLeft         int                 // Padding between the code and the left edge
-	PaddingRight        int                 // Padding between the code and the right edge
-	PaddingTop          int                 // Padding between the code and the top edge
-	PaddingBottom       int                 // Padding between the code and the bottom edge
-	LineNumberPadding   int                 // Padding between line numbers and code
-	TabWidth            int                 // Width of tab characters in spaces
-	MinWidth            int                 // Minimum width in pixels (0 means no minimum)
-	MaxWidth            int                 // Maximum width in pixels (0 means no limit)
-	ShowLineNumbers     bool                // Whether to show line numbers
-	LineRanges          []content.LineRange // Ranges of lines to render
-	LineHighlightRanges []content.LineRange // Ranges of lines to highlight
-	RedactionConfig     *RedactionConfig    // Redaction configuration
+	Theme                 string               // The chroma syntax theme to use
+	Language              string               // The language to highlight
+	Font                  *fonts.Font          // The font to use
+	FontFallbacks         []*fonts.Font        // Fonts consulted in order for characters the font doesn't have
+	FontVariations        map[string]float32   // Axis values variable fonts are drawn at, by tag like fonts.AxisWeight
+	Ligatures             bool                 // Whether to draw the font's ligatures, like those of -> and !=
+	LetterSpacing         int                  // Pixels added between characters, or taken away if negative
+	FontSize              float64              // The font size in points
+	LineHeight            float64              // The line height, a multiple of the font's or pixels depending on LineHeightMode
+	LineHeightMode        LineHeightMode       // How LineHeight is interpreted
+	PaddingLeft           int                  // Padding between the code and the left edge
+	PaddingRight          int                  // Padding between the code and the right edge
+	PaddingTop            int                  // Padding between the code and the top edge
+	PaddingBottom         int                  // Padding between the code and the bottom edge
+	LineNumberPadding     int                  // Padding between line numbers and code
+	TabWidth              int                  // Width of tab characters in spaces
+	MinWidth              int                  // Minimum width in pixels (0 means no minimum)
+	MaxWidth              int                  // Maximum width in pixels (0 means no limit)
+	Columns               int                  // Fixed number of visible columns (0 means size to content)
+	WrapMode              WrapMode             // How lines wider than the available width are wrapped
+	TruncationIndicator   TruncationIndicator  // How lines cut off at the right edge are marked, with wrapping off
+	WrapIndicator         bool                 // Whether continuation lines show an indicator instead of the line number
+	ShowLineNumbers       bool                 // Whether to show line numbers
+	LineNumberZeroPad     bool                 // Whether to pad line numbers with leading zeros
+	LineNumberOffset      int                  // Added to the line numbers shown in the gutter
+	LineNumberFormat      func(n int) string   // Formats the line numbers shown in the gutter (nil for decimal)
+	LinePrefix            LinePrefixFunc       // Text shown in a column before the line numbers (nil for none)
+	LineRanges            []content.LineRange  // Ranges of lines to render
+	MaxLines              int                  // Maximum number of lines to render, followed by a count of the rest (0 means no limit)
+	LineHighlightRanges   []content.LineRange  // Ranges of lines to highlight
+	DimNonHighlighted     bool                 // Whether to dim the lines that aren't highlighted, if any are
+	ColumnHighlights      []ColumnHighlight    // Spans of characters to highlight within lines
+	GutterMarkers         map[int]GutterMarker // Markers drawn in the gutter beside individual lines (1-based)
+	RedactionConfig       *RedactionConfig     // Redaction configuration
+	FocusRange            *FocusRange          // Range of lines kept sharp while the rest is blurred
+	LineBackgrounds       map[int]color.Color  // Background colors of individual lines (1-based)
+	ZebraEven             color.Color          // Background of even numbered lines (nil for none)
+	ZebraOdd              color.Color          // Background of odd numbered lines (nil for none)
+	Lint                  *LintOptions         // Visual linter drawn over the code (nil disables)
+	MaxTokens             int                  // Maximum number of tokens to highlight (0 means no limit)
+	GlyphSubstitutions    map[string]string    // Operator sequences to replace with other glyphs
+	DiffHighlighting      bool                 // Whether to tint the added and removed lines of a unified diff
+	NestedHighlighting    bool                 // Whether fenced code blocks in Markdown are highlighted in their own languages
+	DiffColors            *DiffColors          // Backgrounds overriding the theme's diff colors (nil for the theme's)
+	TransparentBackground bool                 // Whether to leave out the theme's background, so the code is drawn on nothing
+	CornerRadius          int                  // Radius of the corners the image is rounded to (0 for square corners)
+	ShowLanguageBadge     bool                 // Whether to label the code with its language
+	LanguageBadgeCorner   Corner               // Corner of the code area the language badge is drawn in
+
+	scale float64 // Factor the fixed sizes of the badge and lint marks are multiplied by, set by Scaled (0 means 1)
+}
+
+// ColumnHighlight is a span of characters highlighted within a line. Columns count
+// the characters of the line from 1, a tab being a single one.
+type ColumnHighlight struct {
+	Line        int         // Line of the span (1-based)
+	StartColumn int         // First column of the span (inclusive)
+	EndColumn   int         // Last column of the span (inclusive)
+	Color       color.Color // Background drawn behind the characters
+}
+
+// GutterMarker is a symbol drawn in the gutter beside a line, between its number and
+// the code, such as a breakpoint or the status of the line in a change
+type GutterMarker struct {
+	Glyph string      // The symbol, such as ●, +, - or ▶
+	Color color.Color // Color of the symbol (nil for the line numbers' color)
+}
+
+// LinePrefixFunc returns the text shown before a line of the code, given its line
+// number in the source (1-based), such as a commit hash or a timestamp, and the color
+// it's drawn in (nil for the line numbers' color)
+type LinePrefixFunc func(line int) (text string, col color.Color)
+
+// FocusRange describes a range of lines that stays sharp while every other
+// line is blurred
+type FocusRange struct {
+	Start      int     // First line to keep sharp (1-based, inclusive)
+	End        int     // Last line to keep sharp (1-based, inclusive)
+	BlurRadius float64 // Radius of the gaussian blur applied outside the range
 }
 
 type CodeRenderer struct {
@@ -82,6 +150,14 @@ func (r *CodeRenderer) WithLanguage(language string) *CodeRenderer {
 	return r
 }
 
+// WithLexerByContent picks the lexer from the code itself with chroma's analysers,
+// rather than the language DefaultRenderer starts out with, falling back to plain text
+// if none of them recognize it. See DetectLanguage to also take a filename into account.
+func (r *CodeRenderer) WithLexerByContent() *CodeRenderer {
+	r.Style.Language = ""
+	return r
+}
+
 func (r *CodeRenderer) WithFontSize(size float64) *CodeRenderer {
 	r.Style.FontSize = size
 	return r
@@ -92,6 +168,13 @@ func (r *CodeRenderer) WithLineHeight(height float64) *CodeRenderer {
 	return r
 }
 
+// WithLineHeightMode sets whether the line height is a multiple of the font's
+// natural line height, the default, or a height in pixels
+func (r *CodeRenderer) WithLineHeightMode(mode LineHeightMode) *CodeRenderer {
+	r.Style.LineHeightMode = mode
+	return r
+}
+
 func (r *CodeRenderer) WithPadding(left, right, top, bottom int) *CodeRenderer {
 	r.Style.PaddingLeft = left
 	r.Style.PaddingRight = right
@@ -120,11 +203,97 @@ func (r *CodeRenderer) WithMaxWidth(width int) *CodeRenderer {
 	return r
 }
 
+func (r *CodeRenderer) WithColumns(columns int) *CodeRenderer {
+	r.Style.Columns = columns
+	return r
+}
+
+// WithWordWrap sets how lines wider than the available width are wrapped. With
+// WrapNone lines are never wrapped, and the image grows as wide as the longest
+// line regardless of the maximum width, unless a truncation indicator is set.
+func (r *CodeRenderer) WithWordWrap(mode WrapMode) *CodeRenderer {
+	r.Style.WrapMode = mode
+	return r
+}
+
+// WithTruncationIndicator marks the lines cut off at the right edge of the code with a
+// fade into the background or an ellipsis, so it's clear they go on. Lines are only
+// cut off with wrapping off (WrapNone), by a number of columns set with WithColumns or
+// by a maximum width set with WithMaxWidth, which with an indicator set cuts the lines
+// off rather than letting the image grow as wide as the longest of them.
+func (r *CodeRenderer) WithTruncationIndicator(style TruncationIndicator) *CodeRenderer {
+	r.Style.TruncationIndicator = style
+	return r
+}
+
+// WithWrapIndicator marks the continuation lines of wrapped lines with a ↪ in the
+// line number gutter, instead of repeating the line number. It has no effect without
+// line numbers.
+func (r *CodeRenderer) WithWrapIndicator(show bool) *CodeRenderer {
+	r.Style.WrapIndicator = show
+	return r
+}
+
+// WithDiffHighlighting treats the code as a unified diff: added and removed lines get
+// a tinted background, with their '+' or '-' moved into the gutter, and hunk headers
+// are shaded. The colors come from the theme unless set with WithDiffColors.
+func (r *CodeRenderer) WithDiffHighlighting(enabled bool) *CodeRenderer {
+	r.Style.DiffHighlighting = enabled
+	return r
+}
+
+// WithNestedCodeHighlighting highlights the fenced code blocks of Markdown in the
+// languages named after their opening fence, as in ```go or ~~~ python, rather than
+// leaving that to the Markdown lexer, which only knows the simplest of fences. Fences
+// may be indented, as they are in lists, and a block left open runs to the end of the
+// code. Blocks without a language, or with one chroma doesn't know, are plain text.
+// Code in any other language is highlighted as usual.
+func (r *CodeRenderer) WithNestedCodeHighlighting(enabled bool) *CodeRenderer {
+	r.Style.NestedHighlighting = enabled
+	return r
+}
+
+// WithDiffColors overrides the backgrounds of added lines, removed lines and headers
+// in diffs. Nil colors keep the theme's.
+func (r *CodeRenderer) WithDiffColors(add, remove, header color.Color) *CodeRenderer {
+	r.Style.DiffColors = &DiffColors{Add: add, Remove: remove, Header: header}
+	return r
+}
+
 func (r *CodeRenderer) WithLineNumbers(show bool) *CodeRenderer {
 	r.Style.ShowLineNumbers = show
 	return r
 }
 
+func (r *CodeRenderer) WithLineNumberZeroPad(zeroPad bool) *CodeRenderer {
+	r.Style.LineNumberZeroPad = zeroPad
+	return r
+}
+
+// WithLineNumberStart sets the number shown next to the first line, e.g. to show the
+// real line numbers of a fragment taken from the middle of a file. Line ranges,
+// highlights and line backgrounds still count the lines of the code from 1.
+func (r *CodeRenderer) WithLineNumberStart(n int) *CodeRenderer {
+	r.Style.LineNumberOffset = n - 1
+	return r
+}
+
+// WithLineNumberFormat sets how line numbers are written, e.g. in hex. It takes
+// precedence over WithLineNumberZeroPad, so padding is up to the function.
+func (r *CodeRenderer) WithLineNumberFormat(format func(n int) string) *CodeRenderer {
+	r.Style.LineNumberFormat = format
+	return r
+}
+
+// WithLinePrefix shows the text returned by prefix for each line in a column before
+// the code, right aligned and as wide as the widest. The column goes before the line
+// numbers, or takes their place when they're turned off. Ellipses and the footer left
+// by WithMaxLines, and the continuations of wrapped lines, have no prefix.
+func (r *CodeRenderer) WithLinePrefix(prefix LinePrefixFunc) *CodeRenderer {
+	r.Style.LinePrefix = prefix
+	return r
+}
+
 func (r *CodeRenderer) WithFont(font *fonts.Font) *CodeRenderer {
 	r.Style.Font = font
 	return r
@@ -138,6 +307,45 @@ func (r *CodeRenderer) WithFontName(name string, style *fonts.FontStyle) *CodeRe
 	return r.WithFont(font)
 }
 
+// WithFontFallbacks sets the fonts that characters the font doesn't have, like those
+// of CJK comments, are drawn with. Each is consulted in order until one has the
+// character. Fonts that can't be found are skipped.
+func (r *CodeRenderer) WithFontFallbacks(names ...string) *CodeRenderer {
+	r.Style.FontFallbacks = nil
+	for _, name := range names {
+		font, err := fonts.GetFont(name, nil)
+		if err != nil {
+			log.Printf("Failed to load fallback font %q: %v", name, err)
+			continue
+		}
+		r.Style.FontFallbacks = append(r.Style.FontFallbacks, font)
+	}
+	return r
+}
+
+// WithLetterSpacing sets the pixels added after every character, spreading the text
+// out, or taken away if negative. The code is measured and wrapped with the spacing.
+func (r *CodeRenderer) WithLetterSpacing(px int) *CodeRenderer {
+	r.Style.LetterSpacing = px
+	return r
+}
+
+// WithLigatures sets whether the font's ligatures are drawn, such as the arrows and
+// comparison operators of coding fonts like Fira Code and JetBrains Mono. Ligatures
+// are only formed within a token, and redacted text is drawn without them.
+func (r *CodeRenderer) WithLigatures(enabled bool) *CodeRenderer {
+	r.Style.Ligatures = enabled
+	return r
+}
+
+// WithFontVariations sets the values of the axes variable fonts are drawn at, by tag,
+// such as {"wght": 500} for a medium weight. Bold text is drawn heavier than the
+// weight given. Fonts that aren't variable are drawn as usual.
+func (r *CodeRenderer) WithFontVariations(variations map[string]float32) *CodeRenderer {
+	r.Style.FontVariations = variations
+	return r
+}
+
 func (r *CodeRenderer) WithStyle(style *CodeStyle) *CodeRenderer {
 	r.Style = style
 	return r
@@ -148,11 +356,47 @@ func (r *CodeRenderer) WithLineRange(start, end int) *CodeRenderer {
 	return r
 }
 
+// WithMaxLines renders only the first n lines of the code, followed by a footer such
+// as "… 142 more lines" in the comment color when there are more. Unlike line ranges
+// it doesn't need to know how long the code is, which keeps the image to a bounded
+// height whatever the input. The footer has no line number and isn't highlighted.
+// Lines kept by line ranges are counted, but not the ellipses between them. 0 means
+// no limit.
+func (r *CodeRenderer) WithMaxLines(n int) *CodeRenderer {
+	r.Style.MaxLines = n
+	return r
+}
+
 func (r *CodeRenderer) WithLineHighlightRange(start, end int) *CodeRenderer {
 	r.Style.LineHighlightRanges = append(r.Style.LineHighlightRanges, content.LineRange{Start: start, End: end})
 	return r
 }
 
+// WithHighlightLineSet highlights each of the given lines, which needn't be in order
+// or next to each other, such as the lines a change touches
+func (r *CodeRenderer) WithHighlightLineSet(lines []int) *CodeRenderer {
+	for _, line := range lines {
+		r.WithLineHighlightRange(line, line)
+	}
+	return r
+}
+
+// WithDimNonHighlighted sets whether the lines that aren't highlighted are dimmed, by
+// blending the colors of their text toward the background, so that the highlighted
+// ones stand out. Without highlighted lines nothing is dimmed.
+func (r *CodeRenderer) WithDimNonHighlighted(dim bool) *CodeRenderer {
+	r.Style.DimNonHighlighted = dim
+	return r
+}
+
+// WithLineHighlightRangeColor highlights a range of lines in its own color instead of
+// the theme's, such as green for good code and red for problems. Where ranges overlap,
+// the one added last wins.
+func (r *CodeRenderer) WithLineHighlightRangeColor(start, end int, col color.Color) *CodeRenderer {
+	r.Style.LineHighlightRanges = append(r.Style.LineHighlightRanges, content.LineRange{Start: start, End: end, Color: col})
+	return r
+}
+
 func (r *CodeRenderer) WithRedactionEnabled(enabled bool) *CodeRenderer {
 	if r.Style.RedactionConfig == nil {
 		r.Style.RedactionConfig = NewRedactionConfig()
@@ -185,6 +429,19 @@ func (r *CodeRenderer) WithRedactionPattern(pattern string, name string) *CodeRe
 	return r
 }
 
+// WithRedactionPatternSet adds a bundle of patterns for a provider's keys to the ones
+// redacted: "aws", "github", "google", "jwt", "openai", "private-key", "slack" or
+// "stripe". See RedactionPatternSetNames.
+func (r *CodeRenderer) WithRedactionPatternSet(name string) *CodeRenderer {
+	if r.Style.RedactionConfig == nil {
+		r.Style.RedactionConfig = NewRedactionConfig()
+	}
+	if err := r.Style.RedactionConfig.AddPatternSet(name); err != nil {
+		log.Printf("Failed to add redaction patterns: %v", err)
+	}
+	return r
+}
+
 func (r *CodeRenderer) WithManualRedaction(x, y, width, height int) *CodeRenderer {
 	if r.Style.RedactionConfig == nil {
 		r.Style.RedactionConfig = NewRedactionConfig()
@@ -201,6 +458,176 @@ func (r *CodeRenderer) WithRedactionStyle(style RedactionStyle) *CodeRenderer {
 	return r
 }
 
+func (r *CodeRenderer) WithBlurOutsideRange(start, end int, radius float64) *CodeRenderer {
+	r.Style.FocusRange = &FocusRange{
+		Start:      start,
+		End:        end,
+		BlurRadius: radius,
+	}
+	return r
+}
+
+// WithLineBackground sets the background color of a single line (1-based)
+func (r *CodeRenderer) WithLineBackground(line int, col color.Color) *CodeRenderer {
+	if r.Style.LineBackgrounds == nil {
+		r.Style.LineBackgrounds = make(map[int]color.Color)
+	}
+	r.Style.LineBackgrounds[line] = col
+	return r
+}
+
+// WithColumnHighlight paints a background behind the characters from startCol to
+// endCol of a line, inclusive, to point out a token. Columns count characters from
+// 1 and tabs are highlighted across the width they expand to.
+func (r *CodeRenderer) WithColumnHighlight(line, startCol, endCol int, col color.Color) *CodeRenderer {
+	r.Style.ColumnHighlights = append(r.Style.ColumnHighlights, ColumnHighlight{
+		Line:        line,
+		StartColumn: startCol,
+		EndColumn:   endCol,
+		Color:       col,
+	})
+	return r
+}
+
+// WithGutterMarkers draws a marker in the gutter beside each of the given lines
+// (1-based), in a column of its own between the line numbers and the code. Markers
+// replace any set before for the same lines.
+func (r *CodeRenderer) WithGutterMarkers(markers map[int]GutterMarker) *CodeRenderer {
+	if r.Style.GutterMarkers == nil {
+		r.Style.GutterMarkers = make(map[int]GutterMarker)
+	}
+	for line, marker := range markers {
+		r.Style.GutterMarkers[line] = marker
+	}
+	return r
+}
+
+// WithTransparentBackground leaves out the theme's background, drawing the code on
+// a see-through image so it can be composited over other graphics. Line highlights,
+// backgrounds and the gutter are still drawn. Chromes draw a background of their own
+// under the content, which shows through unless theirs is made transparent too.
+func (r *CodeRenderer) WithTransparentBackground() *CodeRenderer {
+	r.Style.TransparentBackground = true
+	return r
+}
+
+// WithCornerRadius rounds the corners of the rendered image, leaving it transparent
+// outside of them, for code embedded in a layout of its own without a chrome or
+// background to round it
+func (r *CodeRenderer) WithCornerRadius(radius int) *CodeRenderer {
+	r.Style.CornerRadius = radius
+	return r
+}
+
+// WithZebraStripes shades even and odd numbered lines with alternating colors
+func (r *CodeRenderer) WithZebraStripes(even, odd color.Color) *CodeRenderer {
+	r.Style.ZebraEven = even
+	r.Style.ZebraOdd = odd
+	return r
+}
+
+// WithMaxTokens limits the number of tokens the code may be highlighted into,
+// making Render fail with ErrTooManyTokens on inputs that exceed it
+func (r *CodeRenderer) WithMaxTokens(limit int) *CodeRenderer {
+	r.Style.MaxTokens = limit
+	return r
+}
+
+// WithGlyphSubstitution replaces operator sequences with other glyphs when rendering,
+// e.g. "->" with "→" (see DefaultGlyphSubstitutions). Only operators and plain text
+// are affected, so string literals and comments are left untouched.
+func (r *CodeRenderer) WithGlyphSubstitution(substitutions map[string]string) *CodeRenderer {
+	r.Style.GlyphSubstitutions = substitutions
+	return r
+}
+
+// WithLanguageBadge labels the code with the name of its language in a small pill,
+// drawn in the bottom right corner unless set otherwise with WithLanguageBadgeCorner
+func (r *CodeRenderer) WithLanguageBadge(show bool) *CodeRenderer {
+	r.Style.ShowLanguageBadge = show
+	return r
+}
+
+// WithLanguageBadgeCorner sets the corner of the code area the language badge is drawn in
+func (r *CodeRenderer) WithLanguageBadgeCorner(corner Corner) *CodeRenderer {
+	r.Style.LanguageBadgeCorner = corner
+	return r
+}
+
+// WithLintOverlay marks trailing whitespace, mixed indentation and overly long lines
+func (r *CodeRenderer) WithLintOverlay(opts LintOptions) *CodeRenderer {
+	r.Style.Lint = &opts
+	return r
+}
+
+// lineBackground returns the background color of a line, or nil if it has none.
+// Colors set for a single line take precedence over zebra stripes.
+func (s *CodeStyle) lineBackground(lineNumber int) color.Color {
+	if col, ok := s.LineBackgrounds[lineNumber]; ok {
+		return col
+	}
+	if lineNumber%2 == 0 {
+		return s.ZebraEven
+	}
+	return s.ZebraOdd
+}
+
+// LineHeightMode says how CodeStyle.LineHeight is interpreted
+type LineHeightMode int
+
+const (
+	LineHeightMultiplier LineHeightMode = iota // A multiple of the font's natural line height
+	LineHeightAbsolute                         // A height in pixels
+)
+
+// lineHeightPixels returns the height of a line of code drawn with a font of the given
+// metrics. The font's natural line height is its ascent, descent and line gap, or at
+// least the ascent and descent for fonts whose line gap is negative. Whatever the
+// line height asked for, lines are never shorter than the ascent and descent, so that
+// descenders aren't clipped by the next line's background.
+func (s *CodeStyle) lineHeightPixels(metrics font.Metrics) int {
+	if s.LineHeightMode == LineHeightAbsolute {
+		return max(int(math.Round(s.LineHeight)), fonts.LineHeight(metrics, 0))
+	}
+	return fonts.LineHeight(metrics, s.LineHeight)
+}
+
+// fontChain returns the font followed by its fallbacks
+func (s *CodeStyle) fontChain() fonts.FontChain {
+	return fonts.NewFontChain(append([]*fonts.Font{s.Font}, s.FontFallbacks...)...)
+}
+
+// getFace returns a face of the font in the given style and at the font variations,
+// which falls back to the fallback fonts for the characters the font doesn't have
+func (s *CodeStyle) getFace(size float64, style *fonts.FontStyle) (*fonts.Face, error) {
+	if len(s.FontVariations) > 0 && style != nil {
+		varied := style.WithVariations(s.FontVariations)
+		style = &varied
+	}
+	return s.fontChain().GetFace(size, style)
+}
+
+// lineNumberLabel returns the label shown for line n of the code, padded to digits
+// when zero padding
+func (s *CodeStyle) lineNumberLabel(n, digits int) string {
+	n += s.LineNumberOffset
+	if s.LineNumberFormat != nil {
+		return s.LineNumberFormat(n)
+	}
+	if s.LineNumberZeroPad {
+		return fmt.Sprintf("%0*d", digits, n)
+	}
+	return strconv.Itoa(n)
+}
+
+// lineNumberDigits returns the number of digits of the largest line number shown
+func (s *CodeStyle) lineNumberDigits(lineNumberMap []int) int {
+	if len(lineNumberMap) == 0 {
+		return 1
+	}
+	return len(strconv.Itoa(slices.Max(lineNumberMap) + s.LineNumberOffset))
+}
+
 // getLineText concatenates all tokens in a line into a single string
 func getLineText(line Line) string {
 	var text strings.Builder
@@ -218,50 +645,101 @@ func drawText(img *image.RGBA, face font.Face, text string, x, y int, col color.
 		Face: face,
 		Dot:  point,
 	}
-	d.DrawString(text)
+	fonts.DrawString(d, text)
 
 	// Draw underline if needed
 	if token.Underline {
-		metrics := face.Metrics()
-		underlineY := y + metrics.Descent.Round()/2
-		width := font.MeasureString(face, text).Round()
-
-		// Draw a line 1px thick
-		for dy := 0; dy < 1; dy++ {
-			for dx := 0; dx < width; dx++ {
-				img.Set(x+dx, underlineY+dy, col)
-			}
-		}
+		drawUnderline(img, face, x, y, font.MeasureString(face, text).Round(), col)
 	}
 }
 
-func (r *CodeRenderer) Render() (image.Image, error) {
+// drawUnderline draws a line 1px thick and width long under text drawn at x on the
+// baseline y
+func drawUnderline(img *image.RGBA, face font.Face, x, y, width int, col color.Color) {
+	underlineY := y + face.Metrics().Descent.Round()/2
+	for dx := 0; dx < width; dx++ {
+		img.Set(x+dx, underlineY, col)
+	}
+}
+
+// codeLayout is the highlighted code along with its measurements, shared by the
+// raster and SVG renderers
+type codeLayout struct {
+	h                  *HighlightedCode
+	source             []string // Lines of the code as given, before highlighting expands tabs
+	regularFace        *fonts.Face
+	boldFace           *fonts.Face
+	italicFace         *fonts.Face
+	boldItalicFace     *fonts.Face
+	metrics            font.Metrics
+	lines              []Line            // The lines left after filtering ranges
+	lineNumberMap      []int             // Line number of each of the lines
+	ellipsisLines      map[int]bool      // Lines standing in for omitted ranges
+	wrappedLines       [][]Token         // The lines after wrapping
+	lineToWrappedMap   []int             // Index in lines of each wrapped line
+	wrappedLineOffsets []wrappedLineInfo // Where each wrapped line starts in its line
+	lineNumberOffset   int               // Width of the gutter: the line prefixes, line numbers, their padding and any markers
+	prefixWidth        int               // Width of the column of line prefixes before the line numbers
+	gutterMarkerWidth  int               // Width of the column of gutter markers after the line numbers
+	markerWidth        int               // Width of the diff markers at the end of the gutter
+	diff               []diffLine        // Kind of each of the lines, when highlighting a diff
+	footer             bool              // Whether the last line counts the lines left out by MaxLines
+	maxDigits          int               // Number of digits of the largest line number
+	lineHeight         int
+	codeWidth          int
+	totalWidth         int
+	totalHeight        int
+}
+
+// wrappedLineInfo locates a wrapped line within the line it belongs to
+type wrappedLineInfo struct {
+	originalLineIdx int
+	startOffset     int // Rune offset where this wrapped line starts in the original line
+	startColumn     int // Column it starts at in the original line, which tab stops count from
+}
+
+// layout highlights and measures the code without drawing it. The returned layout
+// must be closed to release its font faces.
+func (r *CodeRenderer) layout() (*codeLayout, error) {
+	return r.layoutWithProgress(nil)
+}
+
+// layoutWithProgress lays out the code like layout, reporting when it's done
+// highlighting and moves on to measuring
+func (r *CodeRenderer) layoutWithProgress(progress content.ProgressFunc) (_ *codeLayout, err error) {
 	config := r.Style
+	progress.Report(content.StageHighlighting, 0)
 	h, err := Highlight(r.Code, r.Style)
 	if err != nil {
 		return nil, err
 	}
+	progress.Report(content.StageMeasuring, highlightingProgress)
+
+	l := &codeLayout{h: h, source: strings.Split(r.Code, "\n")}
+	defer func() {
+		if err != nil {
+			l.close()
+		}
+	}()
 
 	// Get the font face for each style combination we need
-	regularFace, err := config.Font.GetFace(config.FontSize, &fonts.FontStyle{
+	l.regularFace, err = config.getFace(config.FontSize, &fonts.FontStyle{
 		Weight:  fonts.WeightRegular,
 		Stretch: fonts.StretchNormal,
 	})
 	if err != nil {
 		return nil, err
 	}
-	defer regularFace.Close()
 
-	boldFace, err := config.Font.GetFace(config.FontSize, &fonts.FontStyle{
+	l.boldFace, err = config.getFace(config.FontSize, &fonts.FontStyle{
 		Weight:  fonts.WeightBold,
 		Stretch: fonts.StretchNormal,
 	})
 	if err != nil {
 		return nil, err
 	}
-	defer boldFace.Close()
 
-	italicFace, err := config.Font.GetFace(config.FontSize, &fonts.FontStyle{
+	l.italicFace, err = config.getFace(config.FontSize, &fonts.FontStyle{
 		Weight:  fonts.WeightRegular,
 		Stretch: fonts.StretchNormal,
 		Italic:  true,
@@ -269,9 +747,8 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer italicFace.Close()
 
-	boldItalicFace, err := config.Font.GetFace(config.FontSize, &fonts.FontStyle{
+	l.boldItalicFace, err = config.getFace(config.FontSize, &fonts.FontStyle{
 		Weight:  fonts.WeightBold,
 		Stretch: fonts.StretchNormal,
 		Italic:  true,
@@ -279,18 +756,11 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer boldItalicFace.Close()
 
-	// Function to get the appropriate face based on token style
-	getFaceForToken := func(token Token) font.Face {
-		if token.Bold && token.Italic && !token.NoItalic {
-			return boldItalicFace.Face
-		} else if token.Bold {
-			return boldFace.Face
-		} else if token.Italic && !token.NoItalic {
-			return italicFace.Face
+	if config.LetterSpacing != 0 {
+		for _, face := range []*fonts.Face{l.regularFace, l.boldFace, l.italicFace, l.boldItalicFace} {
+			face.Face = &trackedFace{Face: face.Face, spacing: fixed.I(config.LetterSpacing)}
 		}
-		return regularFace.Face
 	}
 
 	// Get lines
@@ -308,108 +778,97 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 		return nil, err
 	}
 
-	// Create ellipsis token with comment color
-	ellipsisToken := Token{
-		Text:   "...",
-		Color:  h.CommentColor,
-		Italic: true, // Comments are typically italic
+	// Validate the focus range, if any
+	if config.FocusRange != nil && (config.FocusRange.Start < 1 || config.FocusRange.End < config.FocusRange.Start) {
+		return nil, fmt.Errorf("invalid focus range: %d-%d", config.FocusRange.Start, config.FocusRange.End)
 	}
 
 	// Filter lines based on ranges and add ellipses
-	var filteredLines []Line
-	var lineNumberMap []int // Map filtered line indices to original line numbers
-	if len(config.LineRanges) > 0 {
-		// Add ellipsis at start if first range doesn't start at 1
-		if config.LineRanges[0].Start > 1 {
-			filteredLines = append(filteredLines, Line{
-				Tokens: []Token{ellipsisToken},
-			})
-			// For the first ellipsis, show the line number that comes before the first range
-			lineNumberMap = append(lineNumberMap, config.LineRanges[0].Start-1)
-		}
-
-		// Process each range
-		for i, lr := range config.LineRanges {
-			// Convert to 0-based indices
-			start := lr.Start - 1
-			end := lr.End - 1
-
-			// Add lines in this range
-			for j := start; j <= end; j++ {
-				filteredLines = append(filteredLines, lines[j])
-				lineNumberMap = append(lineNumberMap, j+1) // Store 1-based line number
-			}
-
-			// Add ellipsis between ranges
-			if i < len(config.LineRanges)-1 && lr.End+1 < config.LineRanges[i+1].Start {
-				filteredLines = append(filteredLines, Line{
-					Tokens: []Token{ellipsisToken},
-				})
-				// For ellipsis between ranges, show the line number that comes after the previous range
-				lineNumberMap = append(lineNumberMap, lr.End+1)
-			}
-		}
-
-		// Add ellipsis at end if last range doesn't end at the last line
-		if config.LineRanges[len(config.LineRanges)-1].End < len(lines) {
-			filteredLines = append(filteredLines, Line{
-				Tokens: []Token{ellipsisToken},
-			})
-			// For the last ellipsis, show the line number that comes after the last range
-			lineNumberMap = append(lineNumberMap, config.LineRanges[len(config.LineRanges)-1].End+1)
-		}
+	lines, lineNumberMap, ellipsisLines := filterLines(lines, config.LineRanges, h.CommentColor)
+	lines, lineNumberMap, l.footer = truncateLines(lines, lineNumberMap, ellipsisLines, config.MaxLines, h.CommentColor)
+	if rc := config.RedactionConfig; rc != nil && rc.Enabled && rc.Style == RedactionStyleLabel {
+		lines = labelRedactions(rc, lines, h.CommentColor)
+	}
+	if config.DimNonHighlighted {
+		lines = dimLines(lines, ellipsisLines, h.BackgroundColor)
+	}
+	lines = substituteGlyphs(lines, config.GlyphSubstitutions)
 
-		lines = filteredLines
-	} else {
-		// If no ranges specified, create 1:1 mapping
-		lineNumberMap = make([]int, len(lines))
-		for i := range lines {
-			lineNumberMap[i] = i + 1
-		}
+	// Diff markers move into the gutter, so they don't take up room in the code
+	if config.DiffHighlighting {
+		lines, l.diff = splitDiffMarkers(lines, ellipsisLines)
 	}
 
 	// Calculate line number width if needed
 	lineNumberOffset := 0
+	maxDigits := 0
 	if config.ShowLineNumbers {
-		// Calculate width needed for the largest line number
-		var maxLineNumber int
-		if len(config.LineRanges) > 0 {
-			// Use the last line number from the last range
-			maxLineNumber = config.LineRanges[len(config.LineRanges)-1].End
-		} else {
-			maxLineNumber = len(lines)
+		maxDigits = config.lineNumberDigits(lineNumberMap)
+
+		// Decimal numbers are as wide as the nines with as many digits as the largest,
+		// while formatted ones are as wide as the widest of them
+		lineNumberWidth := font.MeasureString(l.regularFace.Face, strings.Repeat("9", maxDigits)).Round()
+		if config.LineNumberFormat != nil {
+			lineNumberWidth = 0
+			for _, n := range lineNumberMap {
+				lineNumberWidth = max(lineNumberWidth, font.MeasureString(l.regularFace.Face, config.lineNumberLabel(n, maxDigits)).Round())
+			}
 		}
-		lineCountStr := strconv.Itoa(maxLineNumber)
-		maxDigits := len(lineCountStr)
 
-		// Calculate base width for digits
-		lnw, err := config.Font.MeasureString(strings.Repeat("9", maxDigits), config.FontSize, &fonts.FontStyle{
-			Weight:  fonts.WeightRegular,
-			Stretch: fonts.StretchNormal,
-		})
-		if err != nil {
-			return nil, err
+		// Only add padding to the right side of line numbers
+		lineNumberOffset = lineNumberWidth + config.LineNumberPadding
+	}
+
+	// Line prefixes get a column before the line numbers, as wide as the widest of them
+	if config.LinePrefix != nil {
+		for i, n := range lineNumberMap {
+			if ellipsisLines[i] || (l.footer && i == len(lines)-1) {
+				continue
+			}
+			text, _ := config.LinePrefix(n)
+			l.prefixWidth = max(l.prefixWidth, font.MeasureString(l.regularFace.Face, text).Round())
 		}
+		if l.prefixWidth > 0 {
+			lineNumberOffset += l.prefixWidth + config.LineNumberPadding
+		}
+	}
 
-		// Round to nearest pixel
-		lineNumberWidth := lnw.Round()
+	// Gutter markers get a column as wide as the widest of them and a space
+	for _, marker := range config.GutterMarkers {
+		l.gutterMarkerWidth = max(l.gutterMarkerWidth, font.MeasureString(l.regularFace.Face, marker.Glyph+" ").Round())
+	}
+	lineNumberOffset += l.gutterMarkerWidth
 
-		// Only add padding to the right side of line numbers
-		lineNumberOffset = lineNumberWidth + config.LineNumberPadding
+	if config.DiffHighlighting {
+		l.markerWidth = font.MeasureString(l.regularFace.Face, "+ ").Round()
+		lineNumberOffset += l.markerWidth
 	}
 
 	// Calculate max text width (total width minus padding and line numbers)
 	maxTextWidth := config.MaxWidth - config.PaddingLeft - config.PaddingRight - lineNumberOffset
 
+	// A fixed column count overrides the pixel based width, using the width of a
+	// single cell of the (monospace) font
+	columnsWidth := 0
+	if config.Columns > 0 {
+		columnsWidth = config.Columns * font.MeasureString(l.regularFace.Face, "0").Round()
+		maxTextWidth = columnsWidth
+	}
+
+	// Without wrapping, lines are as wide as they need to be
+	if config.WrapMode == WrapNone {
+		maxTextWidth = 0
+	}
+
 	// Calculate initial dimensions
-	metrics := regularFace.Face.Metrics()
-	lineHeight := int(float64(metrics.Height.Round()) * config.LineHeight)
+	metrics := l.regularFace.Face.Metrics()
+	lineHeight := config.lineHeightPixels(metrics)
 	maxLineWidth := 0
 
 	// First measure ellipsis width if we have ranges
 	ellipsisWidth := 0
 	if len(config.LineRanges) > 0 {
-		ellipsisWidth = font.MeasureString(regularFace.Face, "...").Round()
+		ellipsisWidth = font.MeasureString(l.regularFace.Face, "...").Round()
 		if ellipsisWidth > maxLineWidth {
 			maxLineWidth = ellipsisWidth
 		}
@@ -421,7 +880,7 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 	for i, line := range lines {
 		var wrapped [][]Token
 		if len(line.Tokens) > 0 {
-			wrapped = wrapTokens(line.Tokens, regularFace.Face, maxTextWidth, 0)
+			wrapped = wrapLine(line.Tokens, l.regularFace.Face, maxTextWidth, config.WrapMode)
 		} else {
 			// For empty lines, add an empty token list
 			wrapped = [][]Token{{}}
@@ -431,43 +890,39 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 			lineToWrappedMap = append(lineToWrappedMap, i)
 		}
 		wrappedLines = append(wrappedLines, wrapped...)
+	}
+	l.wrappedLines = wrappedLines
 
-		// Calculate max line width
-		for _, wline := range wrapped {
-			lineWidth := 0
-			currentColumn := 0
-			for _, token := range wline {
-				// Handle tab expansion for width calculation
-				if strings.Contains(token.Text, "\t") {
-					expandedText, newColumn := expandTabs(token.Text, currentColumn, config.TabWidth)
-					lineWidth += font.MeasureString(regularFace.Face, expandedText).Round()
-					currentColumn = newColumn
-				} else {
-					lineWidth += font.MeasureString(regularFace.Face, token.Text).Round()
-					currentColumn += len(token.Text)
-				}
-			}
-			if lineWidth > maxLineWidth {
-				maxLineWidth = lineWidth
-			}
+	// Track character offsets for wrapped lines
+	l.locateWrappedLines(config, lineToWrappedMap)
+
+	// Lines are measured the way they're drawn, a character at a time in the face of
+	// its token, so that backgrounds reach exactly as far as the text
+	for i := range wrappedLines {
+		lineWidth := 0
+		for _, c := range l.placeChars(config, i) {
+			lineWidth += c.width
 		}
+		maxLineWidth = max(maxLineWidth, lineWidth)
 	}
 
 	// Calculate final image dimensions
 	codeWidth := maxLineWidth + (config.PaddingLeft + config.PaddingRight)
 
-	// Apply min/max width constraints
-	if config.MinWidth > 0 && codeWidth < config.MinWidth {
-		codeWidth = config.MinWidth
-	}
-	if config.MaxWidth > 0 && codeWidth > config.MaxWidth {
-		codeWidth = config.MaxWidth
+	// Apply min/max width constraints, or size to exactly the requested columns
+	if config.Columns > 0 {
+		codeWidth = columnsWidth + (config.PaddingLeft + config.PaddingRight)
+	} else {
+		if config.MinWidth > 0 && codeWidth < config.MinWidth {
+			codeWidth = config.MinWidth
+		}
+		truncates := config.WrapMode != WrapNone || config.TruncationIndicator != TruncationNone
+		if config.MaxWidth > 0 && codeWidth > config.MaxWidth && truncates {
+			codeWidth = config.MaxWidth
+		}
 	}
 
-	totalWidth := codeWidth
-	if config.ShowLineNumbers {
-		totalWidth += lineNumberOffset
-	}
+	totalWidth := codeWidth + lineNumberOffset
 
 	// Calculate total height
 	totalHeight := (lineHeight * len(wrappedLines)) + (config.PaddingTop + config.PaddingBottom)
@@ -477,95 +932,415 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 		totalHeight += (len(config.LineRanges) - 1)
 	}
 
+	l.metrics = metrics
+	l.lines = lines
+	l.lineNumberMap = lineNumberMap
+	l.ellipsisLines = ellipsisLines
+	l.lineToWrappedMap = lineToWrappedMap
+	l.lineNumberOffset = lineNumberOffset
+	l.maxDigits = maxDigits
+	l.lineHeight = lineHeight
+	l.codeWidth = codeWidth
+	l.totalWidth = totalWidth
+	l.totalHeight = totalHeight
+	return l, nil
+}
+
+// close releases the font faces of the layout
+func (l *codeLayout) close() {
+	for _, face := range []*fonts.Face{l.regularFace, l.boldFace, l.italicFace, l.boldItalicFace} {
+		if face != nil {
+			face.Close()
+		}
+	}
+}
+
+// face returns the font face matching the style of a token
+func (l *codeLayout) face(token Token) font.Face {
+	return l.fontFace(token).Face
+}
+
+// fontFace returns the face of the font matching the style of a token
+func (l *codeLayout) fontFace(token Token) *fonts.Face {
+	if token.Bold && token.Italic && !token.NoItalic {
+		return l.boldItalicFace
+	} else if token.Bold {
+		return l.boldFace
+	} else if token.Italic && !token.NoItalic {
+		return l.italicFace
+	}
+	return l.regularFace
+}
+
+// gutterLabel returns the line number shown next to wrapped line i, or the wrap
+// indicator if it continues the line before it
+func (l *codeLayout) gutterLabel(config *CodeStyle, i int) string {
+	originalLineIdx := l.lineToWrappedMap[i]
+	if l.footer && originalLineIdx == len(l.lines)-1 {
+		return ""
+	}
+	if config.WrapIndicator && i > 0 && l.lineToWrappedMap[i-1] == originalLineIdx {
+		return wrapIndicator
+	}
+
+	return config.lineNumberLabel(l.lineNumberMap[originalLineIdx], l.maxDigits)
+}
+
+// linePrefix returns the line prefix drawn before wrapped line i and its color, if
+// any. Like gutter markers, only the first of the wrapped lines of a line gets one.
+func (l *codeLayout) linePrefix(config *CodeStyle, i int) (string, color.Color, bool) {
+	originalLineIdx := l.lineToWrappedMap[i]
+	if l.prefixWidth == 0 || (i > 0 && l.lineToWrappedMap[i-1] == originalLineIdx) ||
+		l.ellipsisLines[originalLineIdx] || (l.footer && originalLineIdx == len(l.lines)-1) {
+		return "", nil, false
+	}
+	text, col := config.LinePrefix(l.lineNumberMap[originalLineIdx])
+	if col == nil {
+		col = l.h.LineNumberColor
+	}
+	return text, col, text != ""
+}
+
+// gutterMarker returns the gutter marker drawn beside wrapped line i, if any. Only
+// the first of the wrapped lines of a line gets one, and ellipses and the footer
+// never do.
+func (l *codeLayout) gutterMarker(config *CodeStyle, i int) (GutterMarker, bool) {
+	originalLineIdx := l.lineToWrappedMap[i]
+	if (i > 0 && l.lineToWrappedMap[i-1] == originalLineIdx) || l.ellipsisLines[originalLineIdx] {
+		return GutterMarker{}, false
+	}
+	marker, ok := config.GutterMarkers[l.lineNumberMap[originalLineIdx]]
+	return marker, ok && marker.Glyph != ""
+}
+
+// lineRect returns the area covered by the background of a wrapped line starting at y
+func (l *codeLayout) lineRect(config *CodeStyle, y int) image.Rectangle {
+	if config.ShowLineNumbers {
+		// With line numbers, start after the line number area
+		return image.Rect(
+			config.PaddingLeft+l.lineNumberOffset,
+			y,
+			l.codeWidth+config.PaddingLeft+l.lineNumberOffset,
+			y+l.lineHeight,
+		)
+	}
+	// Without line numbers, extend to both edges
+	return image.Rect(0, y, l.totalWidth, y+l.lineHeight)
+}
+
+// tokenBackground is the box painted behind a token with a background of its own
+type tokenBackground struct {
+	rect  image.Rectangle
+	color color.Color
+}
+
+// placedChar is a character of a wrapped line along with where it's drawn
+type placedChar struct {
+	ch    rune // The character to draw, mirrored if it's a bracket in right to left text
+	x     int
+	width int
+}
+
+// locateWrappedLines sets where each of the wrapped lines starts within its original
+// line, given the original line of each
+func (l *codeLayout) locateWrappedLines(config *CodeStyle, lineToWrappedMap []int) {
+	l.wrappedLineOffsets = make([]wrappedLineInfo, len(l.wrappedLines))
+	currentOffset, currentColumn := 0, 0
+	for i, originalLineIdx := range lineToWrappedMap {
+		// If this is the first wrapped line for this original line, reset the offset
+		if i == 0 || lineToWrappedMap[i-1] != originalLineIdx {
+			currentOffset, currentColumn = 0, 0
+		}
+
+		l.wrappedLineOffsets[i] = wrappedLineInfo{
+			originalLineIdx: originalLineIdx,
+			startOffset:     currentOffset,
+			startColumn:     currentColumn,
+		}
+
+		// The next wrapped line starts where this one ends
+		for _, text := range l.expandedTokens(config, i) {
+			currentOffset += utf8.RuneCountInString(text)
+			currentColumn += columnWidth.StringWidth(text)
+		}
+	}
+}
+
+// expandedTokens returns the text of each token of wrapped line i with its tabs
+// expanded. Tab stops are counted from the start of the original line, so that a tab
+// after a wrap expands the same as it would without it.
+func (l *codeLayout) expandedTokens(config *CodeStyle, i int) []string {
+	tokens := l.wrappedLines[i]
+	texts := make([]string, len(tokens))
+	column := l.wrappedLineOffsets[i].startColumn
+	for j, token := range tokens {
+		texts[j], column = expandTabs(token.Text, column, config.TabWidth)
+	}
+	return texts
+}
+
+// placeChars returns the characters of wrapped line i after expanding tabs, in the
+// order of the line, each placed where it's displayed. Runs of text in right to left
+// scripts are laid out right to left.
+func (l *codeLayout) placeChars(config *CodeStyle, i int) []placedChar {
+	var runes []rune
+	var widths []int
+	texts := l.expandedTokens(config, i)
+	for j, token := range l.wrappedLines[i] {
+		for _, ch := range texts[j] {
+			runes = append(runes, ch)
+			widths = append(widths, font.MeasureString(l.face(token), string(ch)).Round())
+		}
+	}
+
+	visual, order := fonts.BidiReorder(runes, false)
+	chars := make([]placedChar, len(runes))
+	x := config.PaddingLeft + l.lineNumberOffset
+	for v, idx := range order {
+		chars[idx] = placedChar{ch: visual[v], x: x, width: widths[idx]}
+		x += widths[idx]
+	}
+	return chars
+}
+
+// tokenBackgrounds returns the boxes behind the tokens of wrapped line i, drawn at y.
+// Tokens containing tabs get the box across the whole width the tabs expand to.
+func (l *codeLayout) tokenBackgrounds(config *CodeStyle, i, y int) []tokenBackground {
+	var boxes []tokenBackground
+	chars := l.placeChars(config, i)
+	lineChar := 0
+	texts := l.expandedTokens(config, i)
+	for j, token := range l.wrappedLines[i] {
+		n := utf8.RuneCountInString(texts[j])
+
+		// The box spans every character of the token, wherever right to left text
+		// puts them
+		if token.Background != nil && n > 0 {
+			rect := image.Rect(chars[lineChar].x, y, chars[lineChar].x, y+l.lineHeight)
+			for _, c := range chars[lineChar : lineChar+n] {
+				rect.Min.X = min(rect.Min.X, c.x)
+				rect.Max.X = max(rect.Max.X, c.x+c.width)
+			}
+			if rect.Dx() > 0 {
+				boxes = append(boxes, tokenBackground{rect: rect, color: token.Background})
+			}
+		}
+		lineChar += n
+	}
+	return boxes
+}
+
+// expandedColumn returns the offset of a column of a source line among the
+// characters laid out for the line. Highlighting expands tabs, so columns are counted
+// in the source. The column just past the end of the line is valid.
+func (l *codeLayout) expandedColumn(config *CodeStyle, line, column int) (int, bool) {
+	if line < 1 || line > len(l.source) {
+		return 0, false
+	}
+	runes := []rune(strings.TrimSuffix(l.source[line-1], "\r"))
+	if column < 1 || column > len(runes)+1 {
+		return 0, false
+	}
+	expanded, _ := expandTabs(string(runes[:column-1]), 0, config.TabWidth)
+	return utf8.RuneCountInString(expanded), true
+}
+
+// columnHighlights returns the boxes behind the highlighted columns of wrapped line i,
+// drawn at y
+func (l *codeLayout) columnHighlights(config *CodeStyle, i, y int) []tokenBackground {
+	info := l.wrappedLineOffsets[i]
+	if len(config.ColumnHighlights) == 0 || l.ellipsisLines[info.originalLineIdx] {
+		return nil
+	}
+	line := l.lineNumberMap[info.originalLineIdx]
+
+	var boxes []tokenBackground
+	var chars []placedChar
+	for _, span := range config.ColumnHighlights {
+		if span.Line != line || span.Color == nil || span.EndColumn < span.StartColumn {
+			continue
+		}
+		start, ok := l.expandedColumn(config, line, max(1, span.StartColumn))
+		if !ok {
+			continue
+		}
+		length := len([]rune(strings.TrimSuffix(l.source[line-1], "\r")))
+		end, _ := l.expandedColumn(config, line, min(length, span.EndColumn)+1)
+
+		if chars == nil {
+			chars = l.placeChars(config, i)
+		}
+		start = max(0, start-info.startOffset)
+		end = min(len(chars), end-info.startOffset)
+		if start >= end {
+			continue
+		}
+
+		// The box spans every character, wherever right to left text puts them
+		rect := image.Rect(chars[start].x, y, chars[start].x, y+l.lineHeight)
+		for _, c := range chars[start:end] {
+			rect.Min.X = min(rect.Min.X, c.x)
+			rect.Max.X = max(rect.Max.X, c.x+c.width)
+		}
+		boxes = append(boxes, tokenBackground{rect: rect, color: span.Color})
+	}
+	return boxes
+}
+
+// Measure implements the content.Measurer interface
+func (r *CodeRenderer) Measure() (width, height int, err error) {
+	l, err := r.layout()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer l.close()
+	return l.totalWidth, l.totalHeight, nil
+}
+
+// LocateChar implements the content.CharLocator interface. Lines are numbered like the
+// source, before WithLineNumberStart, and columns count characters, a tab being a
+// single one. The column just past the end of a line locates the empty box there.
+func (r *CodeRenderer) LocateChar(line, column int) (image.Rectangle, error) {
+	config := r.Style
+	l, err := r.layout()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	defer l.close()
+
+	idx := -1
+	for i, n := range l.lineNumberMap {
+		if n == line && !l.ellipsisLines[i] {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return image.Rectangle{}, fmt.Errorf("line %d isn't shown", line)
+	}
+
+	// Find the character among the wrapped lines
+	offset, ok := l.expandedColumn(config, line, column)
+	if !ok {
+		return image.Rectangle{}, fmt.Errorf("column %d is out of range for line %d", column, line)
+	}
+	for i, info := range l.wrappedLineOffsets {
+		if info.originalLineIdx != idx || offset < info.startOffset {
+			continue
+		}
+		chars := l.placeChars(config, i)
+		k := offset - info.startOffset
+		lastPart := i+1 == len(l.wrappedLineOffsets) || l.wrappedLineOffsets[i+1].originalLineIdx != idx
+		if k > len(chars) || (k == len(chars) && !lastPart) {
+			continue
+		}
+
+		y := config.PaddingTop + i*l.lineHeight
+		if k < len(chars) {
+			return image.Rect(chars[k].x, y, chars[k].x+chars[k].width, y+l.lineHeight), nil
+		}
+		x := config.PaddingLeft + l.lineNumberOffset
+		for _, c := range chars {
+			x = max(x, c.x+c.width)
+		}
+		return image.Rect(x, y, x, y+l.lineHeight), nil
+	}
+	return image.Rectangle{}, fmt.Errorf("column %d is out of range for line %d", column, line)
+}
+
+func (r *CodeRenderer) Render() (image.Image, error) {
+	return r.RenderWithProgress(nil)
+}
+
+// How far along a render of code is once it's highlighted, and once it's measured and
+// the lines are drawn
+const (
+	highlightingProgress = 0.2
+	measuringProgress    = 0.3
+)
+
+// RenderWithProgress renders the code like Render, reporting its progress as it's
+// highlighted, measured, and drawn line by line
+func (r *CodeRenderer) RenderWithProgress(progress content.ProgressFunc) (image.Image, error) {
+	config := r.Style
+	l, err := r.layoutWithProgress(progress)
+	if err != nil {
+		return nil, err
+	}
+	defer l.close()
+	progress.Report(content.StageDrawing, measuringProgress)
+
+	h, lines, lineNumberMap, ellipsisLines := l.h, l.lines, l.lineNumberMap, l.ellipsisLines
+	wrappedLines, lineToWrappedMap, wrappedLineOffsets := l.wrappedLines, l.lineToWrappedMap, l.wrappedLineOffsets
+	lineNumberOffset, metrics, lineHeight := l.lineNumberOffset, l.metrics, l.lineHeight
+	totalWidth, totalHeight := l.totalWidth, l.totalHeight
+	regularFace, getFaceForToken := l.regularFace, l.face
+
 	// Create the image
 	img := image.NewRGBA(image.Rect(0, 0, totalWidth, totalHeight))
 
-	// Fill background with theme color
+	// Fill background with theme color, unless the code is drawn on nothing
 	bgColor := h.BackgroundColor
 	if bgColor == nil {
 		bgColor = color.White
 	}
-	for y := 0; y < totalHeight; y++ {
-		for x := 0; x < totalWidth; x++ {
-			img.Set(x, y, bgColor)
+	if !config.TransparentBackground {
+		for y := 0; y < totalHeight; y++ {
+			for x := 0; x < totalWidth; x++ {
+				img.Set(x, y, bgColor)
+			}
 		}
 	}
 
-	// Draw line highlights
+	// Draw line backgrounds and highlights
 	currentY := config.PaddingTop
 	for i := range wrappedLines {
 		originalLineIdx := lineToWrappedMap[i]
-		if lines[originalLineIdx].Highlight {
-
-			// Calculate highlight rectangle based on whether line numbers are shown
-			var highlightRect image.Rectangle
-			if config.ShowLineNumbers {
-				// With line numbers, start after the line number area
-				highlightRect = image.Rect(
-					config.PaddingLeft+lineNumberOffset,
-					currentY,
-					codeWidth+config.PaddingLeft+lineNumberOffset,
-					currentY+lineHeight,
-				)
-			} else {
-				// Without line numbers, extend to both edges
-				highlightRect = image.Rect(
-					0,
-					currentY,
-					totalWidth,
-					currentY+lineHeight,
-				)
+
+		highlightRect := l.lineRect(config, currentY)
+
+		// Wrapped lines share the background of the line they belong to
+		if !ellipsisLines[originalLineIdx] {
+			if lineBg := config.lineBackground(lineNumberMap[originalLineIdx]); lineBg != nil {
+				draw.Draw(img, highlightRect, image.NewUniform(lineBg), image.Point{}, draw.Over)
 			}
+		}
+
+		if diffBg := l.diffBackground(config, originalLineIdx); diffBg != nil {
+			draw.Draw(img, highlightRect, image.NewUniform(diffBg), image.Point{}, draw.Over)
+		}
+
+		if highlight := h.lineHighlight(lines[originalLineIdx]); highlight != nil {
+			draw.Draw(img, highlightRect, image.NewUniform(highlight), image.Point{}, draw.Over)
+		}
 
-			uniform := image.NewUniform(h.HighlightColor)
-			draw.Draw(img, highlightRect, uniform, image.Point{}, draw.Over)
+		for _, box := range l.tokenBackgrounds(config, i, currentY) {
+			draw.Draw(img, box.rect, image.NewUniform(box.color), image.Point{}, draw.Over)
+		}
+		for _, box := range l.columnHighlights(config, i, currentY) {
+			draw.Draw(img, box.rect, image.NewUniform(box.color), image.Point{}, draw.Over)
 		}
 		currentY += lineHeight
 	}
 
+	// Truncated lines are marked against the backgrounds as they are before the text
+	truncations := map[int]truncation{}
+	for i := range wrappedLines {
+		if t, ok := l.truncation(config, l.placeChars(config, i)); ok {
+			truncations[i] = t
+		}
+	}
+	var textBackground *image.RGBA
+	if len(truncations) > 0 {
+		textBackground = image.NewRGBA(img.Bounds())
+		draw.Draw(textBackground, textBackground.Bounds(), img, image.Point{}, draw.Src)
+	}
+
 	// Find redaction ranges if redaction is enabled
+	// Labels have already replaced the redacted text, leaving nothing to draw over
 	var lineRedactionRanges map[int][]RedactionRange
-	if r.Style.RedactionConfig != nil && r.Style.RedactionConfig.Enabled {
-		lineRedactionRanges = make(map[int][]RedactionRange)
-
-		// First find redaction ranges in the entire text
-		var fullText strings.Builder
-		lineStarts := make([]int, len(lines))
-		currentPos := 0
-
-		for i, line := range lines {
-			lineStarts[i] = currentPos
-			text := getLineText(line)
-			fullText.WriteString(text)
-			fullText.WriteString("\n")
-			currentPos = fullText.Len()
-		}
-
-		// Find ranges in the full text
-		ranges := FindRedactionRanges(r.Style.RedactionConfig, fullText.String())
-
-		// Map the ranges back to individual lines
-		for _, r := range ranges {
-			// Find which line(s) this range belongs to
-			for i := 0; i < len(lines); i++ {
-				lineStart := lineStarts[i]
-				lineEnd := lineStarts[i] + len(getLineText(lines[i]))
-
-				// Check if this range overlaps with the current line
-				if r.StartIndex <= lineEnd && r.EndIndex > lineStart {
-					// Calculate the portion of the range that falls within this line
-					startInLine := max(0, r.StartIndex-lineStart)
-					endInLine := min(lineEnd-lineStart, r.EndIndex-lineStart)
-
-					lineRange := RedactionRange{
-						StartIndex: startInLine,
-						EndIndex:   endInLine,
-						Pattern:    r.Pattern,
-					}
-					lineRedactionRanges[i] = append(lineRedactionRanges[i], lineRange)
-				}
-			}
-		}
+	if r.Style.RedactionConfig != nil && r.Style.RedactionConfig.Enabled && r.Style.RedactionConfig.Style != RedactionStyleLabel {
+		lineRedactionRanges = findLineRedactionRanges(r.Style.RedactionConfig, lines)
 	}
 
 	// Create a separate image for blur-style redactions
@@ -583,52 +1358,22 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 	var currentBlurArea *blurArea
 	var blurAreas []blurArea
 
-	// Track character offsets for wrapped lines
-	type wrappedLineInfo struct {
-		originalLineIdx int
-		startOffset     int // Character offset where this wrapped line starts in the original line
-	}
-	wrappedLineOffsets := make([]wrappedLineInfo, len(wrappedLines))
-	currentOffset := 0
-	for i, tokens := range wrappedLines {
-		originalLineIdx := lineToWrappedMap[i]
-
-		// If this is the first wrapped line for this original line, reset the offset
-		if i == 0 || lineToWrappedMap[i-1] != originalLineIdx {
-			currentOffset = 0
-		}
-
-		wrappedLineOffsets[i] = wrappedLineInfo{
-			originalLineIdx: originalLineIdx,
-			startOffset:     currentOffset,
-		}
-
-		// Calculate the length of this wrapped line for the next offset
-		lineLength := 0
-		for _, token := range tokens {
-			if strings.Contains(token.Text, "\t") {
-				expandedText, _ := expandTabs(token.Text, lineLength, config.TabWidth)
-				lineLength += len(expandedText)
-			} else {
-				lineLength += len(token.Text)
-			}
-		}
-		currentOffset += lineLength
-	}
-
 	// Draw line numbers and text
 	currentY = config.PaddingTop
 
 	for i, tokens := range wrappedLines {
+		// Reported every percent or so, for code of many lines
+		if step := max(1, len(wrappedLines)/100); i > 0 && i%step == 0 {
+			progress.Report(content.StageDrawing, measuringProgress+(1-measuringProgress)*float64(i)/float64(len(wrappedLines)))
+		}
+
 		// Draw line numbers if enabled
 		if config.ShowLineNumbers {
-			originalLineIdx := lineToWrappedMap[i]
-			lineNumber := lineNumberMap[originalLineIdx]
-			lineNumberStr := strconv.Itoa(lineNumber)
+			lineNumberStr := l.gutterLabel(config, i)
 			lineNumberWidth := font.MeasureString(regularFace.Face, lineNumberStr)
 
 			// Get the font face for line numbers
-			face, err := config.Font.GetFace(config.FontSize, &fonts.FontStyle{
+			face, err := config.getFace(config.FontSize, &fonts.FontStyle{
 				Weight:  fonts.WeightRegular,
 				Stretch: fonts.StretchNormal,
 			})
@@ -638,7 +1383,27 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 			defer face.Close()
 
 			// Draw the line number
-			drawText(img, regularFace.Face, lineNumberStr, config.PaddingLeft+lineNumberOffset-lineNumberWidth.Round()-config.LineNumberPadding, currentY+metrics.Ascent.Round(), h.LineNumberColor, Token{Text: lineNumberStr})
+			drawText(img, regularFace.Face, lineNumberStr, config.PaddingLeft+lineNumberOffset-l.markerWidth-l.gutterMarkerWidth-lineNumberWidth.Round()-config.LineNumberPadding, currentY+metrics.Ascent.Round(), h.LineNumberColor, Token{Text: lineNumberStr})
+		}
+
+		// Draw the line prefix, right aligned in its column
+		if text, col, ok := l.linePrefix(config, i); ok {
+			x := config.PaddingLeft + l.prefixWidth - font.MeasureString(regularFace.Face, text).Round()
+			drawText(img, regularFace.Face, text, x, currentY+metrics.Ascent.Round(), col, Token{Text: text})
+		}
+
+		// Draw the gutter marker between the line number and the diff marker
+		if marker, ok := l.gutterMarker(config, i); ok {
+			col := marker.Color
+			if col == nil {
+				col = h.LineNumberColor
+			}
+			drawText(img, regularFace.Face, marker.Glyph, config.PaddingLeft+lineNumberOffset-l.markerWidth-l.gutterMarkerWidth, currentY+metrics.Ascent.Round(), col, Token{Text: marker.Glyph})
+		}
+
+		// Draw the diff marker at the end of the gutter
+		if marker := l.diffMarker(i); marker != "" {
+			drawText(img, regularFace.Face, marker, config.PaddingLeft+lineNumberOffset-l.markerWidth, currentY+metrics.Ascent.Round(), h.LineNumberColor, Token{Text: marker})
 		}
 
 		// Draw tokens
@@ -646,105 +1411,96 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 		currentColumn := wrappedLineOffsets[i].startOffset
 		originalLineIdx := wrappedLineOffsets[i].originalLineIdx
 		redactionRanges := lineRedactionRanges[originalLineIdx]
-
-		for _, token := range tokens {
-			// Handle tab expansion for drawing
-			if strings.Contains(token.Text, "\t") {
-				expandedText, newColumn := expandTabs(token.Text, currentColumn, config.TabWidth)
-				// Draw expanded text character by character
-				charX := x
-				for j, ch := range expandedText {
-					shouldRedact := false
-					if len(redactionRanges) > 0 {
-						shouldRedact = ShouldRedact(currentColumn+j, redactionRanges)
+		chars := l.placeChars(config, i)
+		lineChar := 0
+
+		texts := l.expandedTokens(config, i)
+		for k := 0; k < len(tokens); k++ {
+			token, text := tokens[k], texts[k]
+
+			// With ligatures, runs of tokens drawn alike are shaped as a whole, unless
+			// part of them has to be drawn character by character
+			if config.Ligatures {
+				end := l.ligatureRun(tokens, k)
+				run := strings.Join(texts[k:end], "")
+				n := utf8.RuneCountInString(run)
+				tokenChars := chars[lineChar : lineChar+n]
+				redacted := func(j int) bool {
+					return len(redactionRanges) > 0 && ShouldRedact(currentColumn+j, redactionRanges)
+				}
+				if glyphs, ok := shapeToken(l.fontFace(token), run, tokenChars, redacted); ok {
+					if currentBlurArea != nil {
+						blurAreas = append(blurAreas, *currentBlurArea)
+						currentBlurArea = nil
 					}
-
-					if shouldRedact {
-						if r.Style.RedactionConfig.Style == RedactionStyleBlock {
-							// Draw a block character
-							drawText(img, getFaceForToken(token), "█", charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						} else {
-							// For blur style, track the area to blur
-							if currentBlurArea == nil {
-								currentBlurArea = &blurArea{
-									startX: charX,
-									startY: currentY,
-									width:  0,
-								}
-							}
-							// Still draw the actual character but we'll blur it later
-							drawText(blurImg, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						}
-					} else {
-						// If we were tracking a blur area, finish it
-						if currentBlurArea != nil {
-							blurAreas = append(blurAreas, *currentBlurArea)
-							currentBlurArea = nil
-						}
-						// Draw the character normally
-						if r.Style.RedactionConfig.Style == RedactionStyleBlur {
-							drawText(blurImg, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						} else {
-							drawText(img, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						}
+					dst := img
+					if r.Style.RedactionConfig != nil && r.Style.RedactionConfig.Style == RedactionStyleBlur {
+						dst = blurImg
 					}
-
-					charWidth := font.MeasureString(getFaceForToken(token), string(ch)).Round()
-					if currentBlurArea != nil {
-						currentBlurArea.width += charWidth
+					if err := drawGlyphs(dst, l.fontFace(token), glyphs, tokenChars, currentY+metrics.Ascent.Round(), token); err != nil {
+						return nil, err
 					}
-					charX += charWidth
+					last := tokenChars[n-1]
+					x = last.x + last.width
+					lineChar += n
+					currentColumn += n
+					k = end - 1
+					continue
 				}
-				x = charX
-				currentColumn = newColumn
-			} else {
-				// Draw regular text character by character
-				charX := x
-				for j, ch := range token.Text {
-					shouldRedact := false
-					if len(redactionRanges) > 0 {
-						shouldRedact = ShouldRedact(currentColumn+j, redactionRanges)
-					}
+			}
 
-					if shouldRedact {
-						if r.Style.RedactionConfig.Style == RedactionStyleBlock {
-							// Draw a block character
-							drawText(img, getFaceForToken(token), "█", charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						} else {
-							// For blur style, track the area to blur
-							if currentBlurArea == nil {
-								currentBlurArea = &blurArea{
-									startX: charX,
-									startY: currentY,
-									width:  0,
-								}
-							}
-							// Still draw the actual character but we'll blur it later
-							drawText(blurImg, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						}
+			// Draw the text with its tabs expanded, character by character
+			charX := x
+			for j, ch := range []rune(text) {
+				// Right to left text moves the character, and may mirror it
+				charX, ch = chars[lineChar].x, chars[lineChar].ch
+				lineChar++
+
+				shouldRedact := false
+				if len(redactionRanges) > 0 {
+					shouldRedact = ShouldRedact(currentColumn+j, redactionRanges)
+				}
+
+				if shouldRedact {
+					if r.Style.RedactionConfig.Style == RedactionStyleBlock {
+						// Draw a block character
+						drawText(img, getFaceForToken(token), "█", charX, currentY+metrics.Ascent.Round(), token.Color, token)
 					} else {
-						// If we were tracking a blur area, finish it
-						if currentBlurArea != nil {
-							blurAreas = append(blurAreas, *currentBlurArea)
-							currentBlurArea = nil
-						}
-						// Draw the character normally
-						if r.Style.RedactionConfig.Style == RedactionStyleBlur {
-							drawText(blurImg, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
-						} else {
-							drawText(img, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
+						// For blur style, track the area to blur
+						if currentBlurArea == nil {
+							currentBlurArea = &blurArea{
+								startX: charX,
+								startY: currentY,
+								width:  0,
+							}
 						}
+						// Still draw the actual character but we'll blur it later
+						drawText(blurImg, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
 					}
-
-					charWidth := font.MeasureString(getFaceForToken(token), string(ch)).Round()
+				} else {
+					// If we were tracking a blur area, finish it
 					if currentBlurArea != nil {
-						currentBlurArea.width += charWidth
+						blurAreas = append(blurAreas, *currentBlurArea)
+						currentBlurArea = nil
 					}
-					charX += charWidth
+					// Draw the character normally
+					if r.Style.RedactionConfig.Style == RedactionStyleBlur {
+						drawText(blurImg, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
+					} else {
+						drawText(img, getFaceForToken(token), string(ch), charX, currentY+metrics.Ascent.Round(), token.Color, token)
+					}
+				}
+
+				charWidth := chars[lineChar-1].width
+				if currentBlurArea != nil {
+					end := max(currentBlurArea.startX+currentBlurArea.width, charX+charWidth)
+					currentBlurArea.startX = min(currentBlurArea.startX, charX)
+					currentBlurArea.width = end - currentBlurArea.startX
 				}
-				x = charX
-				currentColumn += len(token.Text)
+				charX += charWidth
 			}
+			x = charX
+			currentColumn += utf8.RuneCountInString(text)
 		}
 
 		// If we have an unfinished blur area at the end of the line, add it
@@ -787,5 +1543,239 @@ func (r *CodeRenderer) Render() (image.Image, error) {
 		}
 	}
 
+	// Draw lint marks over the code
+	if config.Lint != nil {
+		drawLintOverlay(img, config.Lint, r.Code, lines, ellipsisLines, wrappedLines, lineToWrappedMap, lineNumberMap, regularFace.Face, config.PaddingLeft+lineNumberOffset, config.PaddingTop, lineHeight, config.TabWidth, config.scaleFactor())
+	}
+
+	// Mark the lines cut off at the right edge
+	for i, t := range truncations {
+		l.drawTruncation(img, textBackground, config, t, config.PaddingTop+i*lineHeight)
+	}
+
+	// Blur everything outside of the focus range
+	if config.FocusRange != nil {
+		blurOutsideFocus(img, config.FocusRange, wrappedLines, lineToWrappedMap, lineNumberMap, config.PaddingTop, lineHeight)
+	}
+
+	// Label the code with its language
+	if config.ShowLanguageBadge {
+		if err := drawLanguageBadge(img, h, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Round the corners once everything is drawn, so nothing pokes out of them
+	if config.CornerRadius > 0 {
+		img = roundCorners(img, float64(config.CornerRadius))
+	}
+
+	progress.Report(content.StageDrawing, 1)
 	return img, nil
 }
+
+// roundCorners returns a copy of img clipped to a rectangle with rounded corners,
+// antialiased and transparent outside of them
+func roundCorners(img *image.RGBA, radius float64) *image.RGBA {
+	bounds := img.Bounds()
+	mask := gg.NewContext(bounds.Dx(), bounds.Dy())
+	mask.DrawRoundedRectangle(0, 0, float64(bounds.Dx()), float64(bounds.Dy()), radius)
+	mask.Fill()
+
+	rounded := image.NewRGBA(bounds)
+	draw.DrawMask(rounded, bounds, img, bounds.Min, mask.Image(), image.Point{}, draw.Src)
+	return rounded
+}
+
+// findLineRedactionRanges finds the redaction ranges in the lines, returning them per
+// line index with rune (rather than byte) offsets into each line's text
+func findLineRedactionRanges(config *RedactionConfig, lines []Line) map[int][]RedactionRange {
+	lineRedactionRanges := make(map[int][]RedactionRange)
+
+	// First find redaction ranges in the entire text
+	var fullText strings.Builder
+	lineTexts := make([]string, len(lines))
+	lineStarts := make([]int, len(lines))
+	for i, line := range lines {
+		lineStarts[i] = fullText.Len()
+		lineTexts[i] = getLineText(line)
+		fullText.WriteString(lineTexts[i])
+		fullText.WriteString("\n")
+	}
+
+	// Find ranges in the full text
+	ranges := FindRedactionRanges(config, fullText.String())
+
+	// Map the ranges back to individual lines
+	for _, r := range ranges {
+		// Find which line(s) this range belongs to
+		for i, text := range lineTexts {
+			lineStart := lineStarts[i]
+			lineEnd := lineStart + len(text)
+
+			// Check if this range overlaps with the current line
+			if r.StartIndex <= lineEnd && r.EndIndex > lineStart {
+				// Calculate the portion of the range that falls within this line
+				startInLine := max(0, r.StartIndex-lineStart)
+				endInLine := min(len(text), r.EndIndex-lineStart)
+
+				lineRedactionRanges[i] = append(lineRedactionRanges[i], RedactionRange{
+					StartIndex: utf8.RuneCountInString(text[:startInLine]),
+					EndIndex:   utf8.RuneCountInString(text[:endInLine]),
+					Pattern:    r.Pattern,
+				})
+			}
+		}
+	}
+	return lineRedactionRanges
+}
+
+// filterLines keeps only the lines within the given ranges, replacing the lines left out
+// with ellipses. It returns the remaining lines along with the original (1-based) line
+// number of each one and the indices of the ones that are ellipses.
+func filterLines(lines []Line, ranges []content.LineRange, commentColor color.Color) ([]Line, []int, map[int]bool) {
+	ellipsisLines := map[int]bool{}
+
+	// If no ranges specified, create 1:1 mapping
+	if len(ranges) == 0 {
+		lineNumberMap := make([]int, len(lines))
+		for i := range lines {
+			lineNumberMap[i] = i + 1
+		}
+		return lines, lineNumberMap, ellipsisLines
+	}
+
+	// Create ellipsis token with comment color
+	ellipsisToken := Token{
+		Text:   "...",
+		Type:   chroma.Comment,
+		Color:  commentColor,
+		Italic: true, // Comments are typically italic
+	}
+
+	var filteredLines []Line
+	var lineNumberMap []int // Map filtered line indices to original line numbers
+
+	// Add ellipsis at start if first range doesn't start at 1
+	if ranges[0].Start > 1 {
+		ellipsisLines[len(filteredLines)] = true
+		filteredLines = append(filteredLines, Line{
+			Tokens: []Token{ellipsisToken},
+		})
+		// For the first ellipsis, show the line number that comes before the first range
+		lineNumberMap = append(lineNumberMap, ranges[0].Start-1)
+	}
+
+	// Process each range
+	for i, lr := range ranges {
+		// Convert to 0-based indices
+		start := lr.Start - 1
+		end := lr.End - 1
+
+		// Add lines in this range
+		for j := start; j <= end; j++ {
+			filteredLines = append(filteredLines, lines[j])
+			lineNumberMap = append(lineNumberMap, j+1) // Store 1-based line number
+		}
+
+		// Add ellipsis between ranges
+		if i < len(ranges)-1 && lr.End+1 < ranges[i+1].Start {
+			ellipsisLines[len(filteredLines)] = true
+			filteredLines = append(filteredLines, Line{
+				Tokens: []Token{ellipsisToken},
+			})
+			// For ellipsis between ranges, show the line number that comes after the previous range
+			lineNumberMap = append(lineNumberMap, lr.End+1)
+		}
+	}
+
+	// Add ellipsis at end if last range doesn't end at the last line
+	if ranges[len(ranges)-1].End < len(lines) {
+		ellipsisLines[len(filteredLines)] = true
+		filteredLines = append(filteredLines, Line{
+			Tokens: []Token{ellipsisToken},
+		})
+		// For the last ellipsis, show the line number that comes after the last range
+		lineNumberMap = append(lineNumberMap, ranges[len(ranges)-1].End+1)
+	}
+
+	return filteredLines, lineNumberMap, ellipsisLines
+}
+
+// truncateLines keeps the first maxLines of the lines that aren't ellipses, and if any
+// are left out adds a footer saying how many, marked as an ellipsis so that it's
+// neither numbered nor highlighted. It returns the lines and their line numbers, the
+// footer's being 0, and whether there's a footer.
+func truncateLines(lines []Line, lineNumberMap []int, ellipsisLines map[int]bool, maxLines int, commentColor color.Color) ([]Line, []int, bool) {
+	if maxLines <= 0 {
+		return lines, lineNumberMap, false
+	}
+
+	kept, cut := 0, len(lines)
+	for i := range lines {
+		if ellipsisLines[i] {
+			continue
+		}
+		if kept == maxLines {
+			cut = i
+			break
+		}
+		kept++
+	}
+	if cut == len(lines) {
+		return lines, lineNumberMap, false
+	}
+
+	remaining := 0
+	for i := cut; i < len(lines); i++ {
+		if !ellipsisLines[i] {
+			remaining++
+		}
+		delete(ellipsisLines, i)
+	}
+	label := "lines"
+	if remaining == 1 {
+		label = "line"
+	}
+
+	ellipsisLines[cut] = true
+	footer := Line{Tokens: []Token{{
+		Text:   fmt.Sprintf("… %d more %s", remaining, label),
+		Type:   chroma.Comment,
+		Color:  commentColor,
+		Italic: true,
+	}}}
+	return append(lines[:cut:cut], footer), append(lineNumberMap[:cut:cut], 0), true
+}
+
+// blurOutsideFocus blurs the rows of every wrapped line whose original line number falls
+// outside of the focus range. Contiguous rows are blurred as a single area so that no seams
+// appear between lines, and the areas touching the top and bottom edges extend into the padding.
+func blurOutsideFocus(img *image.RGBA, focus *FocusRange, wrappedLines [][]Token, lineToWrappedMap, lineNumberMap []int, paddingTop, lineHeight int) {
+	bounds := img.Bounds()
+	spanStart := -1
+
+	flush := func(end int) {
+		if spanStart < 0 {
+			return
+		}
+		redactArea(img, 0, spanStart, bounds.Dx(), end-spanStart, focus.BlurRadius)
+		spanStart = -1
+	}
+
+	for i := range wrappedLines {
+		lineNumber := lineNumberMap[lineToWrappedMap[i]]
+		y := paddingTop + i*lineHeight
+		if lineNumber < focus.Start || lineNumber > focus.End {
+			if spanStart < 0 {
+				spanStart = y
+				if i == 0 {
+					spanStart = 0
+				}
+			}
+		} else {
+			flush(y)
+		}
+	}
+	flush(bounds.Max.Y)
+}
@@ -0,0 +1,811 @@
+package code
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font"
+)
+
+func TestLineNumberStartAndFormat(t *testing.T) {
+	src := strings.Repeat("x := 1\n", 3)
+
+	t.Run("start", func(t *testing.T) {
+		r := DefaultRenderer(src).WithLineNumberStart(98).WithLineNumberZeroPad(true)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+
+		assert.Equal(t, "098", l.gutterLabel(r.Style, 0))
+		assert.Equal(t, "100", l.gutterLabel(r.Style, 2))
+		// The gutter fits the largest number shown, not the number of lines
+		digitsWidth := font.MeasureString(l.regularFace.Face, "999").Round()
+		assert.Equal(t, digitsWidth+r.Style.LineNumberPadding, l.lineNumberOffset)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		r := DefaultRenderer(src).WithLineNumberStart(0x1fe).WithLineNumberFormat(func(n int) string {
+			return fmt.Sprintf("0x%x", n)
+		})
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+
+		assert.Equal(t, "0x1fe", l.gutterLabel(r.Style, 0))
+		assert.Equal(t, "0x200", l.gutterLabel(r.Style, 2))
+		labelWidth := font.MeasureString(l.regularFace.Face, "0x200").Round()
+		assert.Equal(t, labelWidth+r.Style.LineNumberPadding, l.lineNumberOffset)
+
+		out, err := RenderHTML(src, r.Style)
+		require.NoError(t, err)
+		assert.Contains(t, out, `<span class="ln">0x1ff</span>`)
+		assert.Contains(t, out, "min-width: 5ch")
+	})
+}
+
+func TestLineHeight(t *testing.T) {
+	for _, height := range []float64{0.5, 1.0, 1.5} {
+		t.Run(fmt.Sprint(height), func(t *testing.T) {
+			r := DefaultRenderer("gypqj\nÅÉ|()[]_\n").WithFontSize(14).WithLineHeight(height)
+			l, err := r.layout()
+			require.NoError(t, err)
+			defer l.close()
+
+			// The glyphs, drawn with their baseline at the ascent, fit within the line
+			ascent := l.metrics.Ascent.Round()
+			for _, text := range []string{"gypqj", "ÅÉ|()[]_"} {
+				bounds, _ := font.BoundString(l.regularFace.Face, text)
+				assert.GreaterOrEqual(t, ascent+bounds.Min.Y.Floor(), 0, text)
+				assert.LessOrEqual(t, ascent+bounds.Max.Y.Ceil(), l.lineHeight, text)
+			}
+		})
+	}
+
+	t.Run("absolute", func(t *testing.T) {
+		r := DefaultRenderer("x\n").WithLineHeightMode(LineHeightAbsolute).WithLineHeight(30)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		assert.Equal(t, 30, l.lineHeight)
+
+		// Too short to fit the text, so the line is as tall as the glyphs need
+		r.WithLineHeight(4)
+		short, err := r.layout()
+		require.NoError(t, err)
+		defer short.close()
+		assert.Equal(t, short.metrics.Ascent.Round()+short.metrics.Descent.Ceil(), short.lineHeight)
+	})
+}
+
+func TestMaxLines(t *testing.T) {
+	var src strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&src, "x%d := %d\n", i, i)
+	}
+
+	r := DefaultRenderer(src.String()).WithLanguage("go").WithMaxLines(5)
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	require.Len(t, l.lines, 6)
+	assert.Equal(t, "x5 := 5", getLineText(l.lines[4]))
+	assert.Equal(t, "… 15 more lines", getLineText(l.lines[5]))
+	assert.True(t, l.footer)
+
+	// The footer isn't numbered, and the numbers are as wide as the ones shown
+	assert.Equal(t, "5", l.gutterLabel(r.Style, 4))
+	assert.Equal(t, "", l.gutterLabel(r.Style, 5))
+	assert.Equal(t, 1, l.maxDigits)
+
+	t.Run("short enough", func(t *testing.T) {
+		r := DefaultRenderer("a\nb\n").WithMaxLines(2)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		assert.False(t, l.footer)
+		assert.Len(t, l.lines, 2)
+	})
+
+	t.Run("with line ranges", func(t *testing.T) {
+		// The ellipsis between the ranges doesn't count, nor does the one at the end
+		r := DefaultRenderer(src.String()).WithLineRange(1, 2).WithLineRange(10, 14).WithMaxLines(3)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		require.Len(t, l.lines, 5)
+		assert.Equal(t, "...", getLineText(l.lines[2]))
+		assert.Equal(t, "x10 := 10", getLineText(l.lines[3]))
+		assert.Equal(t, "… 4 more lines", getLineText(l.lines[4]))
+	})
+}
+
+func TestDimNonHighlighted(t *testing.T) {
+	src := "a := 1\nb := 2\nc := 3\n"
+	plain, err := DefaultRenderer(src).WithLanguage("go").layout()
+	require.NoError(t, err)
+	defer plain.close()
+
+	r := DefaultRenderer(src).WithLanguage("go").WithHighlightLineSet([]int{3, 1}).WithDimNonHighlighted(true)
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	require.Len(t, l.lines, 3)
+	assert.True(t, l.lines[0].Highlight)
+	assert.False(t, l.lines[1].Highlight)
+	assert.True(t, l.lines[2].Highlight)
+	for i, line := range l.lines {
+		for j, token := range line.Tokens {
+			want := plain.lines[i].Tokens[j].Color
+			if !line.Highlight && want != nil {
+				want = mixColors(want, l.h.BackgroundColor, dimAmount)
+			}
+			assert.Equal(t, want, token.Color, "line %d token %d", i+1, j)
+		}
+	}
+
+	t.Run("nothing highlighted", func(t *testing.T) {
+		l, err := DefaultRenderer(src).WithLanguage("go").WithDimNonHighlighted(true).layout()
+		require.NoError(t, err)
+		defer l.close()
+		assert.Equal(t, plain.lines, l.lines)
+	})
+}
+
+func TestGutterMarkers(t *testing.T) {
+	var src strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&src, "x%d := %d\n", i, i)
+	}
+	plain, err := DefaultRenderer(src.String()).WithLineRange(1, 3).WithLineRange(8, 10).layout()
+	require.NoError(t, err)
+	defer plain.close()
+
+	red := color.RGBA{255, 0, 0, 255}
+	r := DefaultRenderer(src.String()).WithLineRange(1, 3).WithLineRange(8, 10).
+		WithGutterMarkers(map[int]GutterMarker{2: {Glyph: "●", Color: red}, 8: {Glyph: "+"}, 5: {Glyph: "-"}})
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	// The code moves right to make room for the markers
+	assert.Positive(t, l.gutterMarkerWidth)
+	assert.Equal(t, plain.lineNumberOffset+l.gutterMarkerWidth, l.lineNumberOffset)
+
+	var glyphs []string
+	for i := range l.wrappedLines {
+		marker, ok := l.gutterMarker(r.Style, i)
+		if !ok {
+			glyphs = append(glyphs, "")
+			continue
+		}
+		glyphs = append(glyphs, marker.Glyph)
+		if marker.Glyph == "●" {
+			assert.Equal(t, red, marker.Color)
+		}
+	}
+	// Line 5 isn't shown, and the ellipsis standing in for it gets no marker
+	assert.Equal(t, []string{"", "●", "", "", "+", "", ""}, glyphs)
+
+	t.Run("wrapped", func(t *testing.T) {
+		r := DefaultRenderer("short\n" + strings.Repeat("word ", 30) + "\n").WithMaxWidth(200).WithMinWidth(0).
+			WithGutterMarkers(map[int]GutterMarker{2: {Glyph: "▶"}})
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		require.Greater(t, len(l.wrappedLines), 3)
+		for i := range l.wrappedLines {
+			_, ok := l.gutterMarker(r.Style, i)
+			assert.Equal(t, i == 1, ok, "wrapped line %d", i)
+		}
+	})
+}
+
+func TestLinePrefix(t *testing.T) {
+	var src strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&src, "x%d := %d\n", i, i)
+	}
+	hashes := map[int]string{1: "a1b2c3d", 2: "9f8e", 5: "e5e5e5e5", 8: "42"}
+	red := color.RGBA{255, 0, 0, 255}
+	prefix := func(line int) (string, color.Color) {
+		if line == 2 {
+			return hashes[line], red
+		}
+		return hashes[line], nil
+	}
+
+	plain, err := DefaultRenderer(src.String()).WithLineRange(1, 3).WithLineRange(8, 10).layout()
+	require.NoError(t, err)
+	defer plain.close()
+	r := DefaultRenderer(src.String()).WithLineRange(1, 3).WithLineRange(8, 10).WithLinePrefix(prefix)
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	// The column is as wide as the widest prefix shown; line 5 is left out
+	width := font.MeasureString(l.regularFace.Face, "a1b2c3d").Round()
+	assert.Equal(t, width, l.prefixWidth)
+	assert.Equal(t, plain.lineNumberOffset+width+r.Style.LineNumberPadding, l.lineNumberOffset)
+
+	var texts []string
+	for i := range l.wrappedLines {
+		text, col, _ := l.linePrefix(r.Style, i)
+		texts = append(texts, text)
+		if text == "9f8e" {
+			assert.Equal(t, red, col)
+		} else if text != "" {
+			assert.Equal(t, l.h.LineNumberColor, col, "Defaults to the line numbers' color")
+		}
+	}
+	// The ellipsis standing in for lines 4 to 7 gets no prefix
+	assert.Equal(t, []string{"a1b2c3d", "9f8e", "", "", "42", "", ""}, texts)
+
+	t.Run("without line numbers", func(t *testing.T) {
+		r := DefaultRenderer(src.String()).WithLineNumbers(false).WithLinePrefix(prefix)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		assert.Equal(t, font.MeasureString(l.regularFace.Face, "e5e5e5e5").Round()+r.Style.LineNumberPadding, l.lineNumberOffset)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		r := DefaultRenderer("a\nb\n").WithLinePrefix(prefix)
+		out, err := RenderHTML(r.Code, r.Style)
+		require.NoError(t, err)
+		assert.Contains(t, out, `<span class="lp">a1b2c3d</span>`)
+		assert.Contains(t, out, `<span class="lp" style="color:#ff0000">9f8e</span>`)
+		assert.Contains(t, out, ".goshot .lp { min-width: 7ch;")
+	})
+}
+
+func TestTransparentBackground(t *testing.T) {
+	r := DefaultRenderer("x := 1\ny := 2\n").WithLanguage("go").WithLineHighlightRange(2, 2).WithTransparentBackground()
+	img, err := r.Render()
+	require.NoError(t, err)
+
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a, "the theme's background is left out")
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+	_, _, _, a = img.At(img.Bounds().Max.X-2, r.Style.PaddingTop+l.lineHeight+l.lineHeight/2).RGBA()
+	assert.NotZero(t, a, "highlights are still drawn")
+
+	// The SVG leaves out the rectangle covering the opaque one
+	f, err := r.RenderSVG()
+	require.NoError(t, err)
+	opaque, err := DefaultRenderer("x := 1\ny := 2\n").WithLanguage("go").WithLineHighlightRange(2, 2).RenderSVG()
+	require.NoError(t, err)
+	assert.Contains(t, opaque.Body, `<rect x="0" y="0"`)
+	assert.NotContains(t, f.Body, `<rect x="0" y="0"`)
+}
+
+func TestCornerRadius(t *testing.T) {
+	r := DefaultRenderer("x := 1\n").WithLanguage("go").WithLineHighlightRange(1, 1).WithCornerRadius(12)
+	img, err := r.Render()
+	require.NoError(t, err)
+	bounds := img.Bounds()
+
+	alpha := func(x, y int) uint32 {
+		_, _, _, a := img.At(x, y).RGBA()
+		return a >> 8
+	}
+	for _, corner := range []image.Point{{0, 0}, {bounds.Max.X - 1, 0}, {0, bounds.Max.Y - 1}, {bounds.Max.X - 1, bounds.Max.Y - 1}} {
+		assert.Zero(t, alpha(corner.X, corner.Y), "corner %v", corner)
+	}
+	assert.Equal(t, uint32(255), alpha(bounds.Dx()/2, bounds.Dy()/2))
+	assert.Equal(t, uint32(255), alpha(0, bounds.Dy()/2), "edges between the corners are kept")
+
+	// The curve is antialiased rather than stepped
+	partial := false
+	for x := 0; x < 12; x++ {
+		partial = partial || (alpha(x, 1) > 0 && alpha(x, 1) < 255)
+	}
+	assert.True(t, partial)
+
+	f, err := r.RenderSVG()
+	require.NoError(t, err)
+	assert.Contains(t, f.Body, `clip-path="url(#goshot-code-clip)"`)
+}
+
+func TestFontFallbacks(t *testing.T) {
+	r := DefaultRenderer("// → ok\n").WithFontName("Cantarell", nil).WithFontFallbacks("NoSuchFont", "JetBrainsMonoNerdFont")
+	require.Len(t, r.Style.FontFallbacks, 1, "fonts that can't be found are skipped")
+
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	// The arrow comes from the fallback, so it's as wide as it is there
+	mono, err := r.Style.FontFallbacks[0].GetFace(r.Style.FontSize, nil)
+	require.NoError(t, err)
+	defer mono.Close()
+	assert.Equal(t, font.MeasureString(mono.Face, "→"), font.MeasureString(l.regularFace.Face, "→"))
+
+	frag, err := r.RenderSVG()
+	require.NoError(t, err)
+	assert.Contains(t, frag.Body, `font-family="'Cantarell', 'JetBrainsMonoNerdFont', monospace"`)
+}
+
+func TestRightToLeftText(t *testing.T) {
+	r := DefaultRenderer(`s := "אב"` + "\n")
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	chars := l.placeChars(r.Style, 0)
+	require.Len(t, chars, 9)
+	alef, bet := chars[6], chars[7]
+	assert.Equal(t, 'א', alef.ch)
+	assert.Greater(t, alef.x, bet.x, "Hebrew reads right to left")
+	assert.Equal(t, bet.x+bet.width, alef.x)
+	assert.Less(t, chars[5].x, bet.x, "The quotes stay around the string")
+	assert.Greater(t, chars[8].x, alef.x)
+	for i := 1; i < 6; i++ {
+		assert.Greater(t, chars[i].x, chars[i-1].x, "Everything else reads left to right")
+	}
+}
+
+func TestLocateChar(t *testing.T) {
+	r := DefaultRenderer("package main\n\n\tx := 1\n")
+	l, err := r.layout()
+	require.NoError(t, err)
+	lineHeight := l.lineHeight
+	l.close()
+
+	first, err := r.LocateChar(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, r.Style.PaddingTop, first.Min.Y)
+	assert.Equal(t, lineHeight, first.Dy())
+	assert.Positive(t, first.Dx())
+
+	// The tab counts as a single column, but is as wide as its expansion
+	x, err := r.LocateChar(3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, r.Style.PaddingTop+2*lineHeight, x.Min.Y)
+	assert.Equal(t, first.Min.X+r.Style.TabWidth*first.Dx(), x.Min.X)
+
+	end, err := r.LocateChar(3, 8)
+	require.NoError(t, err)
+	assert.Zero(t, end.Dx(), "Past the end of the line the box is empty")
+	assert.Equal(t, x.Min.X+6*first.Dx(), end.Min.X)
+
+	_, err = r.LocateChar(3, 9)
+	assert.Error(t, err)
+	_, err = r.LocateChar(4, 1)
+	assert.Error(t, err)
+	_, err = r.WithLineRange(2, 3).LocateChar(1, 1)
+	assert.Error(t, err, "Lines outside the range aren't shown")
+}
+
+func TestLineHighlightRangeColor(t *testing.T) {
+	green := color.NRGBA{G: 200, A: 255}
+	red := color.NRGBA{R: 200, A: 255}
+	r := DefaultRenderer(strings.Repeat("x := 1\n", 4)).
+		WithLineHighlightRange(1, 1).
+		WithLineHighlightRangeColor(2, 3, green).
+		WithLineHighlightRangeColor(3, 4, red)
+	l, err := r.layout()
+	require.NoError(t, err)
+	lineHeight := l.lineHeight
+	l.close()
+
+	img, err := r.Render()
+	require.NoError(t, err)
+	h, err := Highlight(r.Code, r.Style)
+	require.NoError(t, err)
+	at := func(line int) color.Color {
+		return color.NRGBAModel.Convert(img.At(img.Bounds().Max.X-5, r.Style.PaddingTop+(line-1)*lineHeight+lineHeight/2))
+	}
+	assert.NotEqual(t, color.NRGBAModel.Convert(h.BackgroundColor), at(1), "Ranges without a color use the theme's")
+	assert.NotEqual(t, green, at(1))
+	assert.Equal(t, green, at(2))
+	assert.Equal(t, red, at(3), "The last range wins")
+	assert.Equal(t, red, at(4))
+
+	out, err := RenderHTML(r.Code, r.Style)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(out, `class="line hl"`))
+	assert.Contains(t, out, "background-image:linear-gradient(#c80000,#c80000)")
+}
+
+func TestColumnHighlight(t *testing.T) {
+	yellow := color.NRGBA{R: 255, G: 255, A: 255}
+	r := DefaultRenderer("package main\n\n\tx := 1\n").WithColumnHighlight(3, 1, 2, yellow).WithColumnHighlight(1, 9, 99, yellow)
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	// The tab is highlighted across its whole width
+	boxes := l.columnHighlights(r.Style, 2, 0)
+	require.Len(t, boxes, 1)
+	tab, err := r.LocateChar(3, 1)
+	require.NoError(t, err)
+	x, err := r.LocateChar(3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, tab.Min.X, boxes[0].rect.Min.X)
+	assert.Equal(t, x.Max.X, boxes[0].rect.Max.X)
+	assert.Equal(t, yellow, boxes[0].color)
+
+	// Spans past the end of the line stop there
+	boxes = l.columnHighlights(r.Style, 0, 0)
+	require.Len(t, boxes, 1)
+	end, err := r.LocateChar(1, 13)
+	require.NoError(t, err)
+	assert.Equal(t, end.Min.X, boxes[0].rect.Max.X)
+	assert.Empty(t, l.columnHighlights(r.Style, 1, 0))
+
+	img, err := r.Render()
+	require.NoError(t, err)
+	assert.Equal(t, yellow, color.NRGBAModel.Convert(img.At(tab.Min.X+1, tab.Min.Y+1)))
+}
+
+func TestRenderContent(t *testing.T) {
+	r := DefaultRenderer("package main\n\nfunc main() {}\n")
+	want, err := r.Render()
+	require.NoError(t, err)
+
+	rendered, err := r.RenderContent()
+	require.NoError(t, err)
+	r.WithTheme("github").WithFontSize(20)
+
+	// Changing the renderer afterwards doesn't change the content
+	img, err := rendered.Render()
+	require.NoError(t, err)
+	assert.Equal(t, want, img)
+	width, height, err := rendered.Measure()
+	require.NoError(t, err)
+	assert.Equal(t, want.Bounds().Size(), image.Pt(width, height))
+
+	frag, err := rendered.RenderSVG()
+	require.NoError(t, err)
+	assert.Equal(t, width, frag.Width)
+	frag.Body = ""
+	again, err := rendered.RenderSVG()
+	require.NoError(t, err)
+	assert.NotEmpty(t, again.Body, "Each caller gets its own fragment")
+
+	box, err := rendered.LocateChar(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRenderer("").Style.PaddingTop, box.Min.Y)
+
+	t.Run("scaled", func(t *testing.T) {
+		want, err := DefaultRenderer("package main\n\nfunc main() {}\n").Scaled(2).Render()
+		require.NoError(t, err)
+		scaled := rendered.Scaled(2)
+		img, err := scaled.Render()
+		require.NoError(t, err)
+		assert.Equal(t, want, img)
+		assert.Same(t, scaled, rendered.Scaled(2), "Rendered once for each scale")
+	})
+}
+
+func TestLigatures(t *testing.T) {
+	src := "if a != b {\n\treturn c -> d\n}\n"
+	plain, err := DefaultRenderer(src).WithLanguage("rust").Render()
+	require.NoError(t, err)
+
+	r := DefaultRenderer(src).WithLanguage("rust").WithLigatures(true)
+	img, err := r.Render()
+	require.NoError(t, err)
+	assert.Equal(t, plain.Bounds(), img.Bounds(), "Ligatures keep the text on the same grid")
+
+	// The bundled JetBrains Mono draws != and -> as ligatures
+	for _, at := range []struct{ line, column int }{{1, 6}, {2, 11}} {
+		first, err := r.LocateChar(at.line, at.column)
+		require.NoError(t, err)
+		second, err := r.LocateChar(at.line, at.column+1)
+		require.NoError(t, err)
+		box := first.Union(second)
+
+		differs := false
+		for y := box.Min.Y; y < box.Max.Y && !differs; y++ {
+			for x := box.Min.X; x < box.Max.X; x++ {
+				if plain.At(x, y) != img.At(x, y) {
+					differs = true
+					break
+				}
+			}
+		}
+		assert.True(t, differs, "Line %d, column %d is drawn as a ligature", at.line, at.column)
+	}
+}
+
+func TestLetterSpacing(t *testing.T) {
+	src := "abcd\n"
+	plain := DefaultRenderer(src).WithMinWidth(0)
+	spaced := DefaultRenderer(src).WithMinWidth(0).WithLetterSpacing(3)
+
+	charX := func(r *CodeRenderer, column int) int {
+		box, err := r.LocateChar(1, column)
+		require.NoError(t, err)
+		return box.Min.X
+	}
+	advance := charX(plain, 2) - charX(plain, 1)
+	assert.Equal(t, advance+3, charX(spaced, 2)-charX(spaced, 1))
+	assert.Equal(t, 3*(advance+3), charX(spaced, 4)-charX(spaced, 1))
+
+	// The code is measured with the spacing
+	plainWidth, _, err := plain.Measure()
+	require.NoError(t, err)
+	spacedWidth, _, err := spaced.Measure()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, spacedWidth-plainWidth, 4*3)
+
+	// And wrapped with it, so the line that fit no longer does
+	for spacing, lines := range map[int]int{0: 1, 3: 2} {
+		l, err := DefaultRenderer(src).WithMinWidth(0).WithMaxWidth(plainWidth).WithWordWrap(WrapChar).WithLetterSpacing(spacing).layout()
+		require.NoError(t, err)
+		assert.Len(t, l.wrappedLines, lines, "Spacing of %d", spacing)
+		l.close()
+	}
+
+	// Even when the indentation doesn't fit on a line
+	indented := DefaultRenderer("func f() {\n    return\n}\n").WithLetterSpacing(200)
+	l, err := indented.layout()
+	require.NoError(t, err)
+	defer l.close()
+	assert.Greater(t, len(l.wrappedLines), 3)
+	for i := range l.wrappedLines {
+		chars := l.placeChars(indented.Style, i)
+		require.NotEmpty(t, chars)
+		last := chars[len(chars)-1]
+		assert.LessOrEqual(t, last.x+last.width, indented.Style.PaddingLeft+l.lineNumberOffset+l.codeWidth, "Line %d", i)
+	}
+	_, err = indented.Render()
+	require.NoError(t, err)
+}
+
+func TestTruncationIndicator(t *testing.T) {
+	src := "short\n" + strings.Repeat("long ", 40) + "\n"
+	renderer := func(indicator TruncationIndicator) *CodeRenderer {
+		return DefaultRenderer(src).WithWordWrap(WrapNone).WithMaxWidth(300).WithTruncationIndicator(indicator)
+	}
+
+	// Without an indicator, the image grows to fit the lines as before
+	plain, err := renderer(TruncationNone).Render()
+	require.NoError(t, err)
+	assert.Greater(t, plain.Bounds().Dx(), 300+100)
+
+	for _, indicator := range []TruncationIndicator{TruncationFade, TruncationEllipsis} {
+		r := renderer(indicator)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		assert.Equal(t, 300, l.codeWidth, "Cut off at the maximum width")
+
+		_, short := l.truncation(r.Style, l.placeChars(r.Style, 0))
+		assert.False(t, short)
+		tr, long := l.truncation(r.Style, l.placeChars(r.Style, 1))
+		require.True(t, long)
+		assert.Equal(t, l.totalWidth-r.Style.PaddingRight, tr.right)
+		assert.Less(t, tr.cut, tr.right)
+
+		img, err := r.Render()
+		require.NoError(t, err)
+		rgba := img.(*image.RGBA)
+		y := r.Style.PaddingTop + l.lineHeight + l.lineHeight/2
+		background := color.RGBAModel.Convert(l.h.BackgroundColor)
+		for x := tr.right; x < l.totalWidth; x++ {
+			require.Equal(t, background, rgba.At(x, y), "Nothing drawn past the edge of the code")
+		}
+
+		f, err := r.RenderSVG()
+		require.NoError(t, err)
+		assert.Contains(t, f.Body, "goshot-truncated-1")
+		assert.NotContains(t, f.Body, "goshot-truncated-0")
+	}
+
+	// Wrapped lines are never cut off
+	wrapped := renderer(TruncationEllipsis).WithWordWrap(WrapWord)
+	l, err := wrapped.layout()
+	require.NoError(t, err)
+	defer l.close()
+	for i := range l.wrappedLines {
+		_, truncated := l.truncation(wrapped.Style, l.placeChars(wrapped.Style, i))
+		assert.False(t, truncated)
+	}
+
+	// Even when the columns or width leave no room for the indentation
+	const indented = "func f() {\n        y()\n}\n"
+	for _, narrow := range []*CodeRenderer{
+		DefaultRenderer(indented).WithColumns(2).WithTruncationIndicator(TruncationEllipsis),
+		DefaultRenderer(indented).WithMaxWidth(40).WithTruncationIndicator(TruncationFade),
+	} {
+		_, err := narrow.Render()
+		require.NoError(t, err)
+		_, err = narrow.RenderSVG()
+		require.NoError(t, err)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        string
+		columns     int
+		lineNumbers bool
+		minWidth    int
+		maxWidth    int
+		wantLines   int
+	}{
+		{name: "short line", code: "x\n", columns: 10, wantLines: 1},
+		{name: "long line wrapped", code: strings.Repeat("a", 25) + "\n", columns: 10, wantLines: 3},
+		{name: "line numbers outside the columns", code: "x := 1\n", columns: 40, lineNumbers: true, wantLines: 1},
+		{name: "min and max width ignored", code: "x\n", columns: 20, minWidth: 1000, maxWidth: 50, wantLines: 1},
+		{name: "indented at one column", code: "    x\n", columns: 1, wantLines: 5},
+		{name: "indented at two columns", code: "    xy\n", columns: 2, wantLines: 5},
+		{name: "indented at three columns", code: "    xyz\n", columns: 3, wantLines: 5},
+		{name: "tab indented at one column", code: "\tx\n", columns: 1, wantLines: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRenderer(tt.code).WithColumns(tt.columns).WithLineNumbers(tt.lineNumbers).
+				WithMinWidth(tt.minWidth).WithMaxWidth(tt.maxWidth)
+			l, err := r.layout()
+			require.NoError(t, err)
+			defer l.close()
+
+			cell := font.MeasureString(l.regularFace.Face, "0").Round()
+			want := tt.columns*cell + r.Style.PaddingLeft + r.Style.PaddingRight + l.lineNumberOffset
+			assert.Equal(t, want, l.totalWidth)
+			assert.Len(t, l.wrappedLines, tt.wantLines)
+
+			img, err := r.Render()
+			require.NoError(t, err)
+			assert.Equal(t, want, img.Bounds().Dx())
+		})
+	}
+}
+
+func TestLineNumberZeroPad(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   int
+		start   int
+		zeroPad bool
+		want    []string // Labels of the first and last lines
+	}{
+		{name: "off", lines: 12, zeroPad: false, want: []string{"1", "12"}},
+		{name: "two digits", lines: 12, zeroPad: true, want: []string{"01", "12"}},
+		{name: "three digits", lines: 120, zeroPad: true, want: []string{"001", "120"}},
+		{name: "single digit", lines: 9, zeroPad: true, want: []string{"1", "9"}},
+		{name: "padded to the last number", lines: 3, start: 99, zeroPad: true, want: []string{"099", "101"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRenderer(strings.Repeat("x\n", tt.lines)).WithLineNumberZeroPad(tt.zeroPad)
+			if tt.start > 0 {
+				r.WithLineNumberStart(tt.start)
+			}
+			l, err := r.layout()
+			require.NoError(t, err)
+			defer l.close()
+
+			last := len(l.wrappedLines) - 1
+			assert.Equal(t, tt.want, []string{l.gutterLabel(r.Style, 0), l.gutterLabel(r.Style, last)})
+		})
+	}
+}
+
+func TestBlurOutsideRange(t *testing.T) {
+	src := "alpha := 1\nbeta := 2\ngamma := 3\ndelta := 4\nepsilon := 5\n"
+	plain, err := DefaultRenderer(src).WithLanguage("go").Render()
+	require.NoError(t, err)
+	r := DefaultRenderer(src).WithLanguage("go")
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	// rowsDiffer says whether any pixel of the rows of a line differs between the images
+	rowsDiffer := func(a, b image.Image, line int) bool {
+		top := r.Style.PaddingTop + (line-1)*l.lineHeight
+		for y := top; y < top+l.lineHeight; y++ {
+			for x := 0; x < a.Bounds().Dx(); x++ {
+				if a.At(x, y) != b.At(x, y) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+	}{
+		{name: "middle", start: 2, end: 3},
+		{name: "first line", start: 1, end: 1},
+		{name: "last lines", start: 4, end: 5},
+		{name: "past the end", start: 3, end: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := DefaultRenderer(src).WithLanguage("go").WithBlurOutsideRange(tt.start, tt.end, 4).Render()
+			require.NoError(t, err)
+			require.Equal(t, plain.Bounds(), img.Bounds())
+			for line := 1; line <= 5; line++ {
+				focused := line >= tt.start && line <= tt.end
+				assert.Equal(t, !focused, rowsDiffer(plain, img, line), "line %d", line)
+			}
+		})
+	}
+
+	t.Run("invalid range", func(t *testing.T) {
+		for _, r := range [][2]int{{0, 2}, {3, 2}} {
+			_, err := DefaultRenderer(src).WithBlurOutsideRange(r[0], r[1], 4).Render()
+			assert.Error(t, err, "%d-%d", r[0], r[1])
+		}
+	})
+}
+
+func TestLineBackgrounds(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	src := strings.Repeat("x\n", 5)
+
+	tests := []struct {
+		name     string
+		renderer func() *CodeRenderer
+		want     []color.Color // Background of each line, nil for the theme's
+	}{
+		{
+			name:     "zebra",
+			renderer: func() *CodeRenderer { return DefaultRenderer(src).WithZebraStripes(red, green) },
+			want:     []color.Color{green, red, green, red, green},
+		},
+		{
+			name:     "even only",
+			renderer: func() *CodeRenderer { return DefaultRenderer(src).WithZebraStripes(red, nil) },
+			want:     []color.Color{nil, red, nil, red, nil},
+		},
+		{
+			name: "single lines",
+			renderer: func() *CodeRenderer {
+				return DefaultRenderer(src).WithLineBackground(2, blue).WithLineBackground(5, red)
+			},
+			want: []color.Color{nil, blue, nil, nil, red},
+		},
+		{
+			name: "line over zebra",
+			renderer: func() *CodeRenderer {
+				return DefaultRenderer(src).WithZebraStripes(red, green).WithLineBackground(3, blue)
+			},
+			want: []color.Color{green, red, blue, red, green},
+		},
+		{
+			name: "by source line whatever the numbers shown",
+			renderer: func() *CodeRenderer {
+				return DefaultRenderer(src).WithLineNumberStart(10).WithZebraStripes(red, green).WithLineBackground(2, blue)
+			},
+			want: []color.Color{green, blue, green, red, green},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.renderer()
+			l, err := r.layout()
+			require.NoError(t, err)
+			defer l.close()
+			img, err := r.Render()
+			require.NoError(t, err)
+
+			for i, want := range tt.want {
+				if want == nil {
+					want = l.h.BackgroundColor
+				}
+				// Past the end of the text, within the line
+				y := r.Style.PaddingTop + i*l.lineHeight + l.lineHeight/2
+				assert.Equal(t, color.RGBAModel.Convert(want), color.RGBAModel.Convert(img.At(img.Bounds().Dx()-2, y)), "line %d", i+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package code
+
+import (
+	"image/color"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// diffTintAlpha is the opacity of diff backgrounds derived from a theme's text colors
+const diffTintAlpha = 56
+
+// DiffColors overrides the backgrounds of diff lines. Nil colors keep the theme's.
+type DiffColors struct {
+	Add    color.Color // Background of added lines
+	Remove color.Color // Background of removed lines
+	Header color.Color // Background of hunk and file headers
+}
+
+// diffLine is the kind of a line in a unified diff
+type diffLine int
+
+const (
+	diffContext diffLine = iota // Unchanged, or not part of a diff at all
+	diffAdd
+	diffRemove
+	diffHeader
+)
+
+// diffHeaderPrefixes start the lines of a unified diff that describe the change
+// rather than being part of it
+var diffHeaderPrefixes = []string{"@@", "+++ ", "--- ", "diff ", "index "}
+
+// splitDiffMarkers classifies the lines of a unified diff and strips the leading
+// '+', '-' or ' ' from its lines, so the markers can be drawn in the gutter instead
+func splitDiffMarkers(lines []Line, ellipsisLines map[int]bool) ([]Line, []diffLine) {
+	kinds := make([]diffLine, len(lines))
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = line
+		if ellipsisLines[i] {
+			continue
+		}
+
+		text := getLineText(line)
+		if slices.ContainsFunc(diffHeaderPrefixes, func(prefix string) bool { return strings.HasPrefix(text, prefix) }) {
+			kinds[i] = diffHeader
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+"):
+			kinds[i] = diffAdd
+		case strings.HasPrefix(text, "-"):
+			kinds[i] = diffRemove
+		case !strings.HasPrefix(text, " "):
+			continue
+		}
+		result[i].Tokens = trimFirstRune(line.Tokens)
+	}
+	return result, kinds
+}
+
+// trimFirstRune returns the tokens without the first character of their text
+func trimFirstRune(tokens []Token) []Token {
+	tokens = slices.Clone(tokens)
+	for i, token := range tokens {
+		if token.Text == "" {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(token.Text)
+		if len(token.Text) == size {
+			return slices.Delete(tokens, i, i+1)
+		}
+		tokens[i].Text = token.Text[size:]
+		return tokens
+	}
+	return tokens
+}
+
+// marker returns the character drawn in the gutter of a diff line
+func (d diffLine) marker() string {
+	switch d {
+	case diffAdd:
+		return "+"
+	case diffRemove:
+		return "-"
+	}
+	return ""
+}
+
+// getDiffColor returns the background for diff lines of the given token type. Themes
+// that give the type a background of its own use it as is, while those that only
+// color its text get a translucent tint of that color.
+func getDiffColor(style *chroma.Style, tokenType chroma.TokenType, fallback color.Color) color.Color {
+	if !style.Has(tokenType) {
+		return fallback
+	}
+
+	entry := style.Get(tokenType)
+	if bg := entry.Background; bg != 0 && bg != style.Get(chroma.Background).Background {
+		return color.RGBA{R: bg.Red(), G: bg.Green(), B: bg.Blue(), A: 255}
+	}
+	if c := entry.Colour; c != 0 {
+		return color.NRGBA{R: c.Red(), G: c.Green(), B: c.Blue(), A: diffTintAlpha}
+	}
+	return fallback
+}
+
+// diffBackground returns the background of the original line i, or nil if it has none
+func (l *codeLayout) diffBackground(config *CodeStyle, i int) color.Color {
+	if l.diff == nil {
+		return nil
+	}
+
+	colors := config.DiffColors
+	if colors == nil {
+		colors = &DiffColors{}
+	}
+
+	var themed, override color.Color
+	switch l.diff[i] {
+	case diffAdd:
+		themed, override = l.h.DiffAddColor, colors.Add
+	case diffRemove:
+		themed, override = l.h.DiffRemoveColor, colors.Remove
+	case diffHeader:
+		themed, override = l.h.DiffHeaderColor, colors.Header
+	default:
+		return nil
+	}
+
+	if override != nil {
+		return override
+	}
+	return themed
+}
+
+// diffMarker returns the diff marker drawn in the gutter of wrapped line i, if any.
+// Only the first of the wrapped lines of a line gets one.
+func (l *codeLayout) diffMarker(i int) string {
+	if l.diff == nil || (i > 0 && l.lineToWrappedMap[i-1] == l.lineToWrappedMap[i]) {
+		return ""
+	}
+	return l.diff[l.lineToWrappedMap[i]].marker()
+}
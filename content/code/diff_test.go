@@ -0,0 +1,28 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitDiffMarkers(t *testing.T) {
+	lines := []Line{
+		{Tokens: []Token{{Text: "@@ -1,2 +1,2 @@"}}},
+		{Tokens: []Token{{Text: " "}, {Text: "package"}}},
+		{Tokens: []Token{{Text: "-"}, {Text: "old"}}},
+		{Tokens: []Token{{Text: "+new"}}},
+		{Tokens: []Token{{Text: "--- a/main.go"}}},
+		{Tokens: []Token{{Text: "plain"}}},
+	}
+
+	result, kinds := splitDiffMarkers(lines, nil)
+	assert.Equal(t, []diffLine{diffHeader, diffContext, diffRemove, diffAdd, diffHeader, diffContext}, kinds)
+
+	var texts []string
+	for _, line := range result {
+		texts = append(texts, getLineText(line))
+	}
+	assert.Equal(t, []string{"@@ -1,2 +1,2 @@", "package", "old", "new", "--- a/main.go", "plain"}, texts)
+	assert.Equal(t, "-old", getLineText(lines[2]), "the input lines are left untouched")
+}
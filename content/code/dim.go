@@ -0,0 +1,51 @@
+package code
+
+import (
+	"image/color"
+	"math"
+)
+
+// dimAmount is how far the colors of dimmed lines are blended toward the background
+const dimAmount = 0.6
+
+// dimLines blends the tokens of every line that isn't highlighted toward the
+// background, leaving the ellipses alone. Nothing is dimmed unless some line is
+// highlighted.
+func dimLines(lines []Line, ellipsisLines map[int]bool, background color.Color) []Line {
+	highlighted := false
+	for _, line := range lines {
+		highlighted = highlighted || line.Highlight
+	}
+	if !highlighted || background == nil {
+		return lines
+	}
+
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = line
+		if line.Highlight || ellipsisLines[i] {
+			continue
+		}
+		result[i].Tokens = make([]Token, len(line.Tokens))
+		for j, token := range line.Tokens {
+			if token.Color != nil {
+				token.Color = mixColors(token.Color, background, dimAmount)
+			}
+			if token.Background != nil {
+				token.Background = mixColors(token.Background, background, dimAmount)
+			}
+			result[i].Tokens[j] = token
+		}
+	}
+	return result
+}
+
+// mixColors returns the color t of the way from a to b
+func mixColors(a, b color.Color, t float64) color.Color {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	mix := func(x, y uint32) uint8 {
+		return uint8(math.Round((float64(x)*(1-t) + float64(y)*t) / 257))
+	}
+	return color.RGBA{mix(ar, br), mix(ag, bg), mix(ab, bb), mix(aa, ba)}
+}
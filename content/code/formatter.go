@@ -1,10 +1,15 @@
 package code
 
 import (
+	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"image/color"
+	"io"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/alecthomas/chroma/v2"
@@ -15,20 +20,27 @@ import (
 //go:embed themes/*.xml
 var themesFS embed.FS
 
+// ErrTooManyTokens is returned when highlighting produces more tokens than
+// CodeStyle.MaxTokens allows
+var ErrTooManyTokens = errors.New("code is too complex to highlight")
+
 // Token represents a syntax highlighted token
 type Token struct {
-	Text      string
-	Color     color.Color
-	Bold      bool
-	Italic    bool
-	Underline bool
-	NoItalic  bool
+	Text       string
+	Type       chroma.TokenType // The type of token assigned by the lexer
+	Color      color.Color
+	Background color.Color // Box behind the text, if the theme gives the token one
+	Bold       bool
+	Italic     bool
+	Underline  bool
+	NoItalic   bool
 }
 
 // Line represents a single line of highlighted code
 type Line struct {
-	Tokens    []Token // The tokens in this line
-	Highlight bool    // Whether this line should be highlighted
+	Tokens         []Token     // The tokens in this line
+	Highlight      bool        // Whether this line should be highlighted
+	HighlightColor color.Color // Color of the highlight, nil for the code's HighlightColor
 }
 
 // HighlightedCode represents syntax highlighted code ready for rendering
@@ -39,7 +51,22 @@ type HighlightedCode struct {
 	LineNumberColor  color.Color // Color for line numbers
 	HighlightColor   color.Color // Color for highlighted lines
 	CommentColor     color.Color // Color for comments
+	DiffAddColor     color.Color // Background of added lines in diffs
+	DiffRemoveColor  color.Color // Background of removed lines in diffs
+	DiffHeaderColor  color.Color // Background of hunk and file headers in diffs
 	HighlightedLines []int       // Lines that should be highlighted
+	Language         string      // Name of the language the code was highlighted as
+}
+
+// lineHighlight returns the color line is highlighted in, or nil if it isn't
+func (h *HighlightedCode) lineHighlight(line Line) color.Color {
+	if !line.Highlight {
+		return nil
+	}
+	if line.HighlightColor != nil {
+		return line.HighlightColor
+	}
+	return h.HighlightColor
 }
 
 // GetAvailableStyles returns a list of all available syntax highlighting styles
@@ -47,6 +74,32 @@ func GetAvailableStyles() []string {
 	return styles.Names()
 }
 
+// StyleInfo describes a syntax highlighting style
+type StyleInfo struct {
+	Name       string      // Name of the style, as passed to WithTheme
+	Dark       bool        // Whether the style has a dark background
+	Background color.Color // Background color of the code
+	Foreground color.Color // Color of plain text
+}
+
+// GetStyleInfo returns the name, background and text colors of every available syntax
+// highlighting style, and whether it's light or dark, sorted by name
+func GetStyleInfo() []StyleInfo {
+	var infos []StyleInfo
+	for _, name := range styles.Names() {
+		style := styles.Get(name)
+		background := getBackgroundColor(style)
+		r, g, b, _ := background.RGBA()
+		infos = append(infos, StyleInfo{
+			Name:       name,
+			Dark:       !isLight(chroma.NewColour(uint8(r>>8), uint8(g>>8), uint8(b>>8))),
+			Background: background,
+			Foreground: getColorFromChroma(style, style.Get(chroma.Text).Colour),
+		})
+	}
+	return infos
+}
+
 // GetAvailableLanguages returns a list of all supported languages
 func GetAvailableLanguages(aliases bool) []string {
 	var langs []string
@@ -81,6 +134,77 @@ func GetLanguageByAlias(alias string) string {
 	return ""
 }
 
+// DetectLanguage returns the name of the language to highlight a file as, from its
+// filename and content, or "" if neither gives it away. A filename claimed by a single
+// lexer decides it; when several claim it, as C and Objective-C do headers, chroma's
+// analysers pick one from the content, which may also be a lexer that doesn't claim it
+// at all, like C++ for a header that uses namespaces. With no filename, or one no lexer
+// claims, the content alone decides.
+func DetectLanguage(filename, content string) string {
+	candidates := lexersForFilename(filename)
+	if len(candidates) == 1 {
+		return candidates[0].Config().Name
+	}
+	if lexer := analyseContent(content, candidates); lexer != nil {
+		return lexer.Config().Name
+	}
+	if len(candidates) > 0 {
+		return candidates[0].Config().Name
+	}
+	return ""
+}
+
+// lexersForFilename returns the lexers claiming the filename, most likely first, as
+// lexers.Match picks between them
+func lexersForFilename(filename string) chroma.PrioritisedLexers {
+	if filename == "" {
+		return nil
+	}
+	base := filepath.Base(filename)
+	var matched chroma.PrioritisedLexers
+	for _, globs := range []func(*chroma.Config) []string{
+		func(c *chroma.Config) []string { return c.Filenames },
+		func(c *chroma.Config) []string { return c.AliasFilenames },
+	} {
+		for _, lexer := range lexers.GlobalLexerRegistry.Lexers {
+			for _, glob := range globs(lexer.Config()) {
+				if ok, _ := filepath.Match(glob, base); ok {
+					matched = append(matched, lexer)
+					break
+				}
+			}
+		}
+		if len(matched) > 0 {
+			sort.Sort(matched)
+			return matched
+		}
+	}
+
+	// Backups like main.go.bak are only matched by chroma itself
+	if lexer := lexers.Match(filename); lexer != nil {
+		return chroma.PrioritisedLexers{lexer}
+	}
+	return nil
+}
+
+// analyseContent returns the lexer chroma's analysers rate highest for the content,
+// the candidates winning any ties, or nil if none recognizes it
+func analyseContent(content string, candidates chroma.PrioritisedLexers) chroma.Lexer {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	var picked chroma.Lexer
+	highest := float32(0)
+	for _, lexer := range append(candidates, lexers.GlobalLexerRegistry.Lexers...) {
+		if analyser, ok := lexer.(chroma.Analyser); ok {
+			if score := analyser.AnalyseText(content); score > highest {
+				picked, highest = lexer, score
+			}
+		}
+	}
+	return picked
+}
+
 // Highlight performs syntax highlighting on the given code
 func Highlight(code string, opts *CodeStyle) (*HighlightedCode, error) {
 	if opts == nil {
@@ -116,6 +240,7 @@ func Highlight(code string, opts *CodeStyle) (*HighlightedCode, error) {
 
 	formatter := &customFormatter{
 		highlightedLines: make(map[int]bool),
+		highlightColors:  make(map[int]color.Color),
 		tabWidth:         opts.TabWidth,
 		Result: &HighlightedCode{
 			BackgroundColor: backgroundColor,
@@ -123,10 +248,14 @@ func Highlight(code string, opts *CodeStyle) (*HighlightedCode, error) {
 			LineNumberColor: lineNumberColor,
 			HighlightColor:  highlightColor,
 			CommentColor:    commentColor,
+			DiffAddColor:    getDiffColor(style, chroma.GenericInserted, color.NRGBA{R: 46, G: 160, B: 67, A: diffTintAlpha}),
+			DiffRemoveColor: getDiffColor(style, chroma.GenericDeleted, color.NRGBA{R: 248, G: 81, B: 73, A: diffTintAlpha}),
+			DiffHeaderColor: getDiffColor(style, chroma.GenericSubheading, color.NRGBA{R: 56, G: 139, B: 253, A: diffTintAlpha}),
+			Language:        lexer.Config().Name,
 		},
 	}
 
-	// Set up highlighted lines
+	// Set up highlighted lines. Where ranges overlap, the color of the last one wins.
 	if len(opts.LineHighlightRanges) > 0 {
 		ranges := opts.LineHighlightRanges
 		for _, rangePair := range ranges {
@@ -136,18 +265,32 @@ func Highlight(code string, opts *CodeStyle) (*HighlightedCode, error) {
 			for i := start; i <= end; i++ {
 				formatter.highlightedLines[i] = true
 				formatter.Result.HighlightedLines = append(formatter.Result.HighlightedLines, i+1)
+				formatter.highlightColors[i+1] = rangePair.Color
 			}
 		}
 	}
 
 	// Tokenize the code
-	iterator, err := lexer.Tokenise(nil, code)
+	tokenise := lexer.Tokenise
+	if opts.NestedHighlighting && isMarkdown(lexer) {
+		tokenise = tokeniseFencedBlocks(lexer)
+	}
+	iterator, err := tokenise(nil, code)
 	if err != nil {
 		return nil, fmt.Errorf("error tokenizing code: %v", err)
 	}
 
+	// Collect the tokens, stopping as soon as there are too many
+	var tokens []chroma.Token
+	for token := iterator(); token != chroma.EOF; token = iterator() {
+		if opts.MaxTokens > 0 && len(tokens) >= opts.MaxTokens {
+			return nil, fmt.Errorf("%w: more than %d tokens", ErrTooManyTokens, opts.MaxTokens)
+		}
+		tokens = append(tokens, token)
+	}
+
 	// Format the tokens
-	err = formatter.Format(iterator.Tokens(), style)
+	err = formatter.Format(tokens, style)
 	if err != nil {
 		return nil, fmt.Errorf("error formatting tokens: %v", err)
 	}
@@ -178,6 +321,7 @@ func PrintTokens(code string) error {
 // customFormatter implements the chroma.Formatter interface
 type customFormatter struct {
 	highlightedLines map[int]bool
+	highlightColors  map[int]color.Color // Colors of highlighted lines that have their own
 	lineNumber       int
 	tabWidth         int
 	Result           *HighlightedCode
@@ -185,19 +329,23 @@ type customFormatter struct {
 	currentColumn    int // Track current column position for tab expansion
 }
 
-func (f *customFormatter) createToken(text string, entry chroma.StyleEntry, style *chroma.Style) Token {
+func (f *customFormatter) createToken(text string, tokenType chroma.TokenType, style *chroma.Style) Token {
+	entry := style.Get(tokenType)
 	return Token{
-		Text:      text,
-		Color:     getColorFromChroma(style, entry.Colour),
-		Bold:      entry.Bold == chroma.Yes,
-		Italic:    entry.Italic == chroma.Yes && !entry.NoInherit,
-		Underline: entry.Underline == chroma.Yes,
-		NoItalic:  entry.NoInherit,
+		Text:       text,
+		Type:       tokenType,
+		Color:      getColorFromChroma(style, entry.Colour),
+		Background: getTokenBackground(style, entry.Background),
+		Bold:       entry.Bold == chroma.Yes,
+		Italic:     entry.Italic == chroma.Yes && !entry.NoInherit,
+		Underline:  entry.Underline == chroma.Yes,
+		NoItalic:   entry.NoInherit,
 	}
 }
 
 func (f *customFormatter) addLine(line Line) {
 	line.Highlight = f.highlightedLines[f.lineNumber]
+	line.HighlightColor = f.highlightColors[f.lineNumber]
 	f.Result.Lines = append(f.Result.Lines, line)
 	f.lineNumber++
 	f.currentColumn = 0 // Reset column position for new line
@@ -216,7 +364,7 @@ func (f *customFormatter) addToken(text string, tokenType chroma.TokenType, styl
 
 	// Add the token with expanded text
 	if expandedText != "" {
-		f.currentLine.Tokens = append(f.currentLine.Tokens, f.createToken(expandedText, style.Get(tokenType), style))
+		f.currentLine.Tokens = append(f.currentLine.Tokens, f.createToken(expandedText, tokenType, style))
 	}
 }
 
@@ -242,7 +390,7 @@ func (f *customFormatter) processNewlines(text string, tokenType chroma.TokenTyp
 	if parts[lastIndex] != "" {
 		expandedText, col := expandTabs(parts[lastIndex], 0, f.tabWidth)
 		if expandedText != "" {
-			nextLine.Tokens = append(nextLine.Tokens, f.createToken(expandedText, style.Get(tokenType), style))
+			nextLine.Tokens = append(nextLine.Tokens, f.createToken(expandedText, tokenType, style))
 			f.currentColumn = col
 		}
 	} else {
@@ -299,18 +447,43 @@ func LoadCustomThemes() error {
 			return fmt.Errorf("failed to read theme file %s: %w", entry.Name(), err)
 		}
 
-		style, err := chroma.NewXMLStyle(strings.NewReader(string(data)))
-		if err != nil {
+		if _, err := RegisterStyleFromXML(bytes.NewReader(data)); err != nil {
 			return fmt.Errorf("failed to parse theme %s: %w", entry.Name(), err)
 		}
-
-		// Register the theme with Chroma
-		styles.Register(style)
 	}
 
 	return nil
 }
 
+// RegisterStyleFromXML parses a syntax highlighting style in Chroma's XML format and
+// registers it alongside the built-in ones, so that it can be selected with
+// WithTheme and is listed by GetAvailableStyles. It returns the name of the style,
+// which replaces any style of the same name.
+func RegisterStyleFromXML(r io.Reader) (string, error) {
+	style, err := chroma.NewXMLStyle(r)
+	if err != nil {
+		return "", fmt.Errorf("invalid style: %w", err)
+	}
+	if style.Name == "" {
+		return "", fmt.Errorf("invalid style: missing name")
+	}
+
+	// Register the theme with Chroma
+	styles.Register(style)
+	return style.Name, nil
+}
+
+// RegisterStyleFromFile registers the syntax highlighting style in a Chroma XML file,
+// like RegisterStyleFromXML
+func RegisterStyleFromFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open style file: %w", err)
+	}
+	defer f.Close()
+	return RegisterStyleFromXML(f)
+}
+
 // init loads any custom themes when the package is initialized
 func init() {
 	if err := LoadCustomThemes(); err != nil {
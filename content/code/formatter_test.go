@@ -0,0 +1,113 @@
+package code
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStyleInfo(t *testing.T) {
+	infos := GetStyleInfo()
+	require.Len(t, infos, len(GetAvailableStyles()))
+
+	byName := map[string]StyleInfo{}
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+
+	require.Contains(t, byName, "monokai")
+	assert.True(t, byName["monokai"].Dark)
+	assert.Equal(t, color.RGBA{R: 39, G: 40, B: 34, A: 255}, byName["monokai"].Background)
+	assert.Equal(t, color.RGBA{R: 248, G: 248, B: 242, A: 255}, byName["monokai"].Foreground)
+
+	require.Contains(t, byName, "github")
+	assert.False(t, byName["github"].Dark)
+}
+
+func TestRegisterStyleFromXML(t *testing.T) {
+	const xml = `<style name="test-brand">
+  <entry type="Background" style="bg:#102030"/>
+  <entry type="Text" style="#e0e0e0"/>
+  <entry type="Keyword" style="bold #ff8800"/>
+</style>`
+	path := filepath.Join(t.TempDir(), "brand.xml")
+	require.NoError(t, os.WriteFile(path, []byte(xml), 0644))
+
+	name, err := RegisterStyleFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "test-brand", name)
+	assert.Contains(t, GetAvailableStyles(), "test-brand")
+
+	h, err := Highlight("package main\n", &CodeStyle{Theme: name, Language: "go", TabWidth: 4})
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 255}, h.BackgroundColor)
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0x88, A: 255}, h.Lines[0].Tokens[0].Color)
+
+	_, err = RegisterStyleFromXML(strings.NewReader(`<style><entry type="Text" style="#fff"/></style>`))
+	assert.Error(t, err, "Styles need a name")
+	_, err = RegisterStyleFromXML(strings.NewReader(`<style name="broken">`))
+	assert.Error(t, err)
+	_, err = RegisterStyleFromFile(filepath.Join(t.TempDir(), "missing.xml"))
+	assert.Error(t, err)
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cpp := "#include <iostream>\n\nusing namespace std;\n\nint main() { cout << 1; }\n"
+	c := "#include <stdio.h>\n#ifndef FOO\n#define FOO\n#endif\n"
+
+	assert.Equal(t, "Go", DetectLanguage("main.go", ""))
+	assert.Equal(t, "Go", DetectLanguage("/src/cmd/main.go", cpp), "A filename claimed by one lexer decides it")
+	assert.Equal(t, "markdown", DetectLanguage("README.md", "# Title\n\n```go\nfunc main() {}\n```\n"))
+	assert.Equal(t, "C++", DetectLanguage("vector.h", cpp), "Ambiguous headers are told apart by their content")
+	assert.Equal(t, "C", DetectLanguage("stdio.h", c))
+	assert.Equal(t, "C", DetectLanguage("empty.h", ""), "The most likely lexer without content to go by")
+	assert.Equal(t, "Go", DetectLanguage("", "package main\n\nfunc main() { fmt.Println() }\n"), "Content alone without a filename")
+	assert.Equal(t, "Go", DetectLanguage("main.go.bak", ""))
+	assert.Equal(t, "", DetectLanguage("notes.unknownext", "just some words"))
+
+	// The detected names are ones the highlighter accepts
+	for _, name := range []string{"Go", "C++", "markdown"} {
+		_, err := Highlight(cpp, &CodeStyle{Language: name})
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestMaxTokens(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tprintln(1 + 2)\n}\n"
+	iterator, err := lexers.Get("go").Tokenise(nil, src)
+	require.NoError(t, err)
+	count := len(iterator.Tokens())
+	require.Greater(t, count, 10)
+
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr bool
+	}{
+		{name: "no limit", limit: 0},
+		{name: "exactly enough", limit: count},
+		{name: "more than enough", limit: count + 1},
+		{name: "one short", limit: count - 1, wantErr: true},
+		{name: "one token", limit: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRenderer(src).WithLanguage("go").WithMaxTokens(tt.limit)
+			_, err := Highlight(src, r.Style)
+			_, renderErr := r.Render()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrTooManyTokens)
+				assert.ErrorIs(t, renderErr, ErrTooManyTokens)
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, renderErr)
+			}
+		})
+	}
+}
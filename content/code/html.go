@@ -0,0 +1,217 @@
+package code
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+)
+
+// RenderHTML highlights the input and returns it as a <pre> block whose tokens are
+// colored with inline styles, preceded by a <style> element with the CSS for the
+// block, its gutter and highlighted lines. The colors match those of rendered images.
+// Line ranges, the maximum line count, line prefixes and numbers, highlighted and
+// dimmed lines, line backgrounds and glyph substitutions from the style are honored;
+// options that only apply to images (fonts, padding, wrapping, redaction and so on)
+// are ignored.
+func RenderHTML(input string, style *CodeStyle) (string, error) {
+	result, err := highlightLines(input, style, false)
+	if err != nil {
+		return "", err
+	}
+	h, lines, lineNumberMap, ellipsisLines, footer := &result.HighlightedCode, result.Lines, result.LineNumbers, result.Ellipses, result.Footer
+
+	// Line numbers are padded to the width of the largest one, or of the longest when
+	// formatted
+	maxDigits := 0
+	if style.ShowLineNumbers && len(lineNumberMap) > 0 {
+		maxDigits = style.lineNumberDigits(lineNumberMap)
+		if style.LineNumberFormat != nil {
+			maxDigits = 0
+			for _, n := range lineNumberMap {
+				maxDigits = max(maxDigits, utf8.RuneCountInString(style.lineNumberLabel(n, 0)))
+			}
+		}
+	}
+
+	// Line prefixes are padded to the longest of them
+	prefixWidth := 0
+	if style.LinePrefix != nil {
+		for i, n := range lineNumberMap {
+			if !ellipsisLines[i] && !(footer && i == len(lines)-1) {
+				text, _ := style.LinePrefix(n)
+				prefixWidth = max(prefixWidth, utf8.RuneCountInString(text))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	writeHTMLStyles(&sb, h, style, maxDigits, prefixWidth)
+
+	sb.WriteString(`<pre class="goshot"><code>`)
+	for i, line := range lines {
+		lineNumber := lineNumberMap[i]
+
+		classes := "line"
+		if line.Highlight && line.HighlightColor == nil {
+			classes += " hl"
+		}
+		sb.WriteString(`<span class="` + classes + `"`)
+		var css []string
+		if !ellipsisLines[i] {
+			if lineBg := style.lineBackground(lineNumber); lineBg != nil {
+				css = append(css, "background-color:"+cssColor(lineBg))
+			}
+		}
+		// A highlight in its own color is layered over the line's background
+		if line.Highlight && line.HighlightColor != nil {
+			c := cssColor(line.HighlightColor)
+			css = append(css, "background-image:linear-gradient("+c+","+c+")")
+		}
+		if len(css) > 0 {
+			sb.WriteString(` style="` + strings.Join(css, ";") + `"`)
+		}
+		sb.WriteString(">")
+
+		if prefixWidth > 0 {
+			var text string
+			var col color.Color
+			if !ellipsisLines[i] && !(footer && i == len(lines)-1) {
+				text, col = style.LinePrefix(lineNumber)
+			}
+			sb.WriteString(`<span class="lp"`)
+			if col != nil {
+				sb.WriteString(` style="color:` + cssColor(col) + `"`)
+			}
+			sb.WriteString(`>` + html.EscapeString(text) + `</span>`)
+		}
+		if style.ShowLineNumbers {
+			number := html.EscapeString(style.lineNumberLabel(lineNumber, maxDigits))
+			if footer && i == len(lines)-1 {
+				number = ""
+			}
+			sb.WriteString(`<span class="ln">` + number + `</span>`)
+		}
+
+		// Adjacent tokens that look the same are merged into a single span
+		var run []Token
+		for _, token := range line.Tokens {
+			if len(run) > 0 && tokenCSS(token) != tokenCSS(run[0]) {
+				writeHTMLRun(&sb, run)
+				run = run[:0]
+			}
+			run = append(run, token)
+		}
+		writeHTMLRun(&sb, run)
+
+		// The newline is kept inside the line so copied text keeps its line breaks
+		sb.WriteString("\n</span>")
+	}
+	sb.WriteString("</code></pre>\n")
+
+	return sb.String(), nil
+}
+
+// writeHTMLStyles writes the <style> element used by RenderHTML
+func writeHTMLStyles(sb *strings.Builder, h *HighlightedCode, style *CodeStyle, maxDigits, prefixWidth int) {
+	background := h.BackgroundColor
+	if background == nil {
+		background = color.White
+	}
+	if style.TransparentBackground {
+		background = color.Transparent
+	}
+
+	var families []string
+	for _, f := range style.fontChain() {
+		if f.Name == "" {
+			continue
+		}
+		// Drop anything that could end the quoted name or the style element
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`'"\<>;{}`, r) {
+				return -1
+			}
+			return r
+		}, f.Name)
+		families = append(families, "'"+name+"'")
+	}
+	fontFamily := strings.Join(append(families, "monospace"), ", ")
+
+	sb.WriteString("<style>\n")
+	fmt.Fprintf(sb, ".goshot { background-color: %s; font-family: %s; tab-size: %d; padding: 1em 0; overflow-x: auto; }\n",
+		cssColor(background), fontFamily, max(1, style.TabWidth))
+	if style.CornerRadius > 0 {
+		fmt.Fprintf(sb, ".goshot { border-radius: %dpx; }\n", style.CornerRadius)
+	}
+	if style.LetterSpacing != 0 {
+		fmt.Fprintf(sb, ".goshot { letter-spacing: %dpx; }\n", style.LetterSpacing)
+	}
+	sb.WriteString(".goshot .line { display: flex; padding: 0 1em; }\n")
+	if h.HighlightColor != nil {
+		fmt.Fprintf(sb, ".goshot .line.hl { background-color: %s; }\n", cssColor(h.HighlightColor))
+	}
+	if maxDigits > 0 {
+		gutter := "transparent"
+		if h.GutterColor != nil {
+			gutter = cssColor(h.GutterColor)
+		}
+		fmt.Fprintf(sb, ".goshot .ln { min-width: %dch; margin-right: 1em; text-align: right; color: %s; background-color: %s; user-select: none; }\n",
+			maxDigits, cssColor(h.LineNumberColor), gutter)
+	}
+	if prefixWidth > 0 {
+		fmt.Fprintf(sb, ".goshot .lp { min-width: %dch; margin-right: 1em; text-align: right; color: %s; white-space: pre; user-select: none; }\n",
+			prefixWidth, cssColor(h.LineNumberColor))
+	}
+	sb.WriteString("</style>\n")
+}
+
+// tokenCSS returns the inline style of a token
+func tokenCSS(token Token) string {
+	var css []string
+	if token.Color != nil {
+		css = append(css, "color:"+cssColor(token.Color))
+	}
+	if token.Bold {
+		css = append(css, "font-weight:bold")
+	}
+	if token.Italic && !token.NoItalic {
+		css = append(css, "font-style:italic")
+	}
+	if token.Underline {
+		css = append(css, "text-decoration:underline")
+	}
+	return strings.Join(css, ";")
+}
+
+// writeHTMLRun writes tokens sharing the same style as a single span
+func writeHTMLRun(sb *strings.Builder, run []Token) {
+	if len(run) == 0 {
+		return
+	}
+
+	var text strings.Builder
+	for _, token := range run {
+		text.WriteString(html.EscapeString(token.Text))
+	}
+
+	css := tokenCSS(run[0])
+	if css == "" {
+		sb.WriteString(text.String())
+		return
+	}
+	sb.WriteString(`<span style="` + css + `">` + text.String() + `</span>`)
+}
+
+// cssColor formats a color as a CSS hex color, or rgba() if it is translucent
+func cssColor(c color.Color) string {
+	if c == nil {
+		return "inherit"
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("rgba(%d, %d, %d, %.3g)", n.R, n.G, n.B, float64(n.A)/255)
+}
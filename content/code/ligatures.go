@@ -0,0 +1,83 @@
+package code
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/watzon/goshot/fonts"
+	"golang.org/x/image/math/fixed"
+)
+
+// ligatureRun returns the end of the run of tokens starting at k
+// that are drawn alike, which are shaped together so that ligatures can form across
+// tokens, such as the - and > of an arrow in languages without the operator
+func (l *codeLayout) ligatureRun(tokens []Token, k int) int {
+	face := l.fontFace(tokens[k])
+	end := k + 1
+	for end < len(tokens) && l.fontFace(tokens[end]) == face && tokens[end].Underline == tokens[k].Underline &&
+		sameColor(tokens[end].Color, tokens[k].Color) {
+		end++
+	}
+	return end
+}
+
+// sameColor reports whether two colors are the same, either of which may be nil
+func sameColor(a, b color.Color) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
+
+// shapeToken returns the glyphs of a run of tokens' text shaped with the font's ligatures,
+// given its characters as placed on the line, or false if the text has to be drawn a
+// character at a time: when it's a single character, part of it is redacted, it's
+// laid out right to left, or the font leaves some of it to a fallback font.
+func shapeToken(face *fonts.Face, text string, chars []placedChar, redacted func(j int) bool) ([]fonts.Glyph, bool) {
+	runes := []rune(text)
+	if len(runes) < 2 {
+		return nil, false
+	}
+	for j, ch := range runes {
+		if redacted(j) || chars[j].ch != ch || !face.Font.HasGlyph(ch) {
+			return nil, false
+		}
+		if j > 0 && chars[j].x != chars[j-1].x+chars[j-1].width {
+			return nil, false
+		}
+	}
+
+	glyphs, err := face.Shape(text, true)
+	if err != nil {
+		return nil, false
+	}
+	return glyphs, true
+}
+
+// drawGlyphs draws the glyphs of a shaped run of tokens on the baseline y, each cluster of
+// glyphs starting where its first character is placed so that the text keeps to the
+// same grid as without ligatures
+func drawGlyphs(img *image.RGBA, face *fonts.Face, glyphs []fonts.Glyph, chars []placedChar, y int, token Token) error {
+	src := image.NewUniform(token.Color)
+	cluster := -1
+	var pen fixed.Int26_6 // Offset of the pen within the current cluster
+	for _, glyph := range glyphs {
+		if glyph.Cluster != cluster {
+			cluster = glyph.Cluster
+			pen = 0
+		}
+		dot := fixed.Point26_6{X: fixed.I(chars[cluster].x) + pen, Y: fixed.I(y)}
+		if err := face.DrawGlyph(img, src, glyph, dot); err != nil {
+			return err
+		}
+		pen += glyph.Advance
+	}
+
+	if token.Underline {
+		last := chars[len(chars)-1]
+		drawUnderline(img, face.Face, chars[0].x, y, last.x+last.width-chars[0].x, token.Color)
+	}
+	return nil
+}
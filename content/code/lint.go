@@ -0,0 +1,144 @@
+package code
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+)
+
+// DefaultLintColor is the color of lint marks when none is set
+var DefaultLintColor = color.RGBA{R: 200, G: 30, B: 30, A: 200}
+
+const (
+	lintUnderlineOffset    = 2 // Space between the baseline and the underline of long lines
+	lintUnderlineThickness = 2
+)
+
+// LintOptions configures the visual linter drawn over the rendered code
+type LintOptions struct {
+	TrailingWhitespace bool        // Mark whitespace at the end of lines
+	MixedIndentation   bool        // Mark leading indentation that mixes tabs and spaces
+	MaxColumn          int         // Mark text past this column and draw a ruler at it (0 disables)
+	Color              color.Color // Color of the marks (nil uses DefaultLintColor)
+}
+
+// lintSpan is a range of columns of an (expanded) line marked by the linter
+type lintSpan struct {
+	start, end int  // Rune columns, end exclusive
+	underline  bool // Whether to underline the text rather than fill behind it
+}
+
+// lintLine finds the issues in a single line. raw is the line as it appears in the
+// source and expanded is the same line with its tabs expanded, as it is drawn.
+func lintLine(opts *LintOptions, raw, expanded string, tabWidth int) []lintSpan {
+	var spans []lintSpan
+	length := utf8.RuneCountInString(expanded)
+
+	// Mixed indentation, marked across the whole indentation
+	indentEnd := 0
+	if opts.MixedIndentation {
+		indent := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+		if strings.Contains(indent, " ") && strings.Contains(indent, "\t") {
+			expandedIndent, _ := expandTabs(indent, 0, tabWidth)
+			indentEnd = min(length, utf8.RuneCountInString(expandedIndent))
+			spans = append(spans, lintSpan{start: 0, end: indentEnd})
+		}
+	}
+
+	// Trailing whitespace, not overlapping the indentation of blank lines
+	if opts.TrailingWhitespace {
+		start := max(indentEnd, utf8.RuneCountInString(strings.TrimRight(expanded, " \t")))
+		if start < length {
+			spans = append(spans, lintSpan{start: start, end: length})
+		}
+	}
+
+	// Text past the maximum column
+	if opts.MaxColumn > 0 && length > opts.MaxColumn {
+		spans = append(spans, lintSpan{start: opts.MaxColumn, end: length, underline: true})
+	}
+
+	return spans
+}
+
+// drawLintOverlay marks the lint issues of every rendered line. Columns are mapped onto
+// the wrapped rows they ended up on, so marks follow the text when lines wrap.
+// Underlines and the ruler are drawn scale times as thick.
+func drawLintOverlay(img *image.RGBA, opts *LintOptions, source string, lines []Line, ellipsisLines map[int]bool, wrappedLines [][]Token, lineToWrappedMap, lineNumberMap []int, face font.Face, textX, paddingTop, lineHeight, tabWidth int, scale float64) {
+	col := opts.Color
+	if col == nil {
+		col = DefaultLintColor
+	}
+	mark := image.NewUniform(col)
+	ascent := face.Metrics().Ascent.Round()
+	scaled := func(v int) int {
+		return max(1, int(math.Round(float64(v)*scale)))
+	}
+	underlineTop, underlineBottom := ascent+scaled(lintUnderlineOffset), ascent+scaled(lintUnderlineOffset+lintUnderlineThickness)
+	rawLines := strings.Split(source, "\n")
+
+	// measure returns the width of the first n runes of text
+	measure := func(text string, n int) int {
+		for i := range text {
+			if n == 0 {
+				return font.MeasureString(face, text[:i]).Round()
+			}
+			n--
+		}
+		return font.MeasureString(face, text).Round()
+	}
+
+	var spans []lintSpan
+	rowStart := 0 // Column at which the current wrapped row starts
+	for i, tokens := range wrappedLines {
+		originalLineIdx := lineToWrappedMap[i]
+
+		// Lint each line once, when its first row is reached
+		if i == 0 || lineToWrappedMap[i-1] != originalLineIdx {
+			spans = nil
+			rowStart = 0
+			lineNumber := lineNumberMap[originalLineIdx]
+			if !ellipsisLines[originalLineIdx] && lineNumber >= 1 && lineNumber <= len(rawLines) {
+				raw := strings.TrimSuffix(rawLines[lineNumber-1], "\r")
+				spans = lintLine(opts, raw, getLineText(lines[originalLineIdx]), tabWidth)
+			}
+		}
+
+		var row strings.Builder
+		for _, token := range tokens {
+			row.WriteString(token.Text)
+		}
+		text := row.String()
+		rowEnd := rowStart + utf8.RuneCountInString(text)
+		top := paddingTop + i*lineHeight
+
+		for _, span := range spans {
+			start, end := max(span.start, rowStart), min(span.end, rowEnd)
+			if start >= end {
+				continue
+			}
+			x0 := textX + measure(text, start-rowStart)
+			x1 := textX + measure(text, end-rowStart)
+			rect := image.Rect(x0, top, x1, top+lineHeight)
+			if span.underline {
+				rect = image.Rect(x0, top+underlineTop, x1, top+underlineBottom)
+			}
+			draw.Draw(img, rect, mark, image.Point{}, draw.Over)
+		}
+		rowStart = rowEnd
+	}
+
+	// Column ruler, drawn where the column falls on an unwrapped line
+	if opts.MaxColumn > 0 {
+		x := textX + font.MeasureString(face, strings.Repeat(" ", opts.MaxColumn)).Round()
+		if x < img.Bounds().Max.X {
+			ruler := image.Rect(x, paddingTop, x+scaled(1), paddingTop+len(wrappedLines)*lineHeight)
+			draw.Draw(img, ruler, mark, image.Point{}, draw.Over)
+		}
+	}
+}
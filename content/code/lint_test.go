@@ -0,0 +1,87 @@
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font"
+)
+
+func TestLintLine(t *testing.T) {
+	all := LintOptions{TrailingWhitespace: true, MixedIndentation: true, MaxColumn: 10}
+	tests := []struct {
+		name string
+		opts LintOptions
+		raw  string
+		want []lintSpan
+	}{
+		{name: "clean", opts: all, raw: "\tx := 1"},
+		{name: "trailing spaces", opts: all, raw: "x := 1  ", want: []lintSpan{{start: 6, end: 8}}},
+		{name: "trailing tab", opts: all, raw: "x\t", want: []lintSpan{{start: 1, end: 4}}},
+		{name: "trailing whitespace off", opts: LintOptions{MixedIndentation: true}, raw: "x  "},
+		{name: "spaces then tab", opts: all, raw: "  \tx", want: []lintSpan{{start: 0, end: 4}}},
+		{name: "tab then spaces", opts: all, raw: "\t  x", want: []lintSpan{{start: 0, end: 6}}},
+		{name: "indentation of one kind", opts: all, raw: "    x"},
+		{name: "mixed indentation off", opts: LintOptions{TrailingWhitespace: true}, raw: " \tx"},
+		{
+			name: "blank line of mixed indentation",
+			opts: all,
+			raw:  " \t ",
+			want: []lintSpan{{start: 0, end: 5}},
+		},
+		{name: "long line", opts: all, raw: "0123456789abc", want: []lintSpan{{start: 10, end: 13, underline: true}}},
+		{name: "exactly the maximum", opts: all, raw: "0123456789"},
+		{name: "long with tabs", opts: all, raw: "\t\t\tx", want: []lintSpan{{start: 10, end: 13, underline: true}}},
+		{name: "max column off", opts: LintOptions{TrailingWhitespace: true}, raw: "0123456789abc"},
+		{
+			name: "every rule",
+			opts: all,
+			raw:  " \tlong enough line ",
+			want: []lintSpan{{start: 0, end: 4}, {start: 20, end: 21}, {start: 10, end: 21, underline: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, _ := expandTabs(tt.raw, 0, 4)
+			assert.Equal(t, tt.want, lintLine(&tt.opts, tt.raw, expanded, 4))
+		})
+	}
+}
+
+func TestLintOverlay(t *testing.T) {
+	src := "x := 1   \n \ty := 2\n"
+	plain, err := DefaultRenderer(src).WithTabWidth(4).Render()
+	require.NoError(t, err)
+	img, err := DefaultRenderer(src).WithTabWidth(4).WithLintOverlay(LintOptions{TrailingWhitespace: true, MixedIndentation: true}).Render()
+	require.NoError(t, err)
+	require.Equal(t, plain.Bounds(), img.Bounds())
+	assert.NotEqual(t, plain, img)
+
+	t.Run("scaled underline", func(t *testing.T) {
+		// The underline past the maximum column runs below spaces, where nothing else
+		// is drawn, and is as thick as the scale
+		src := "ab" + strings.Repeat(" ", 10) + "c\n"
+		for _, scale := range []float64{1, 2, 3} {
+			plain := DefaultRenderer(src).Scaled(scale).(*CodeRenderer)
+			linted := DefaultRenderer(src).WithLintOverlay(LintOptions{MaxColumn: 2}).Scaled(scale).(*CodeRenderer)
+			want, err := plain.Render()
+			require.NoError(t, err)
+			img, err := linted.Render()
+			require.NoError(t, err)
+
+			l, err := plain.layout()
+			require.NoError(t, err)
+			x := plain.Style.PaddingLeft + l.lineNumberOffset + font.MeasureString(l.regularFace.Face, "ab   ").Round()
+			l.close()
+			rows := 0
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				if img.At(x, y) != want.At(x, y) {
+					rows++
+				}
+			}
+			assert.Equal(t, int(lintUnderlineThickness*scale), rows, "scale %v", scale)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package code
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// isMarkdown returns whether the lexer is chroma's Markdown lexer
+func isMarkdown(lexer chroma.Lexer) bool {
+	return strings.EqualFold(lexer.Config().Name, "markdown")
+}
+
+// fence is the line opening a fenced code block: the run of backticks or tildes it
+// starts with, and the language named after it
+type fence struct {
+	marker   string
+	language string
+}
+
+// openingFence returns the fence the line opens, if it opens one
+func openingFence(line string) (fence, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+		return fence{}, false
+	}
+	marker := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+	info := strings.TrimSpace(trimmed[len(marker):])
+	if marker[0] == '`' && strings.Contains(info, "`") {
+		// Inline code, like ```this```
+		return fence{}, false
+	}
+
+	// The language is the first word of the info string, which may also be written
+	// in braces, as in {.python}
+	f := fence{marker: marker}
+	if fields := strings.Fields(info); len(fields) > 0 {
+		f.language = strings.TrimLeft(strings.TrimRight(fields[0], "}"), "{.")
+	}
+	return f, true
+}
+
+// closes returns whether the line closes the fenced code block: a run of the same
+// character at least as long as the one opening it, and nothing else
+func (f fence) closes(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(f.marker) && strings.Trim(trimmed, f.marker[:1]) == ""
+}
+
+// lexer returns the lexer of the fence's language, or nil for plain text
+func (f fence) lexer() chroma.Lexer {
+	if f.language == "" {
+		return nil
+	}
+	return lexers.Get(f.language)
+}
+
+// tokeniseFencedBlocks returns a tokenising function that leaves the Markdown to lexer
+// and highlights each of its fenced code blocks with the lexer of its language, the
+// fences themselves being strings as the Markdown lexer has them
+func tokeniseFencedBlocks(lexer chroma.Lexer) func(*chroma.TokeniseOptions, string) (chroma.Iterator, error) {
+	return func(options *chroma.TokeniseOptions, code string) (chroma.Iterator, error) {
+		var iterators []chroma.Iterator
+		var text strings.Builder
+		tokenise := func(l chroma.Lexer, s string) error {
+			if s == "" {
+				return nil
+			}
+			if l == nil {
+				iterators = append(iterators, chroma.Literator(chroma.Token{Type: chroma.Text, Value: s}))
+				return nil
+			}
+			it, err := l.Tokenise(options, s)
+			if err != nil {
+				return err
+			}
+			iterators = append(iterators, it)
+			return nil
+		}
+		literal := func(s string) {
+			iterators = append(iterators, chroma.Literator(chroma.Token{Type: chroma.LiteralString, Value: s}))
+		}
+
+		lines := strings.SplitAfter(code, "\n")
+		for i := 0; i < len(lines); i++ {
+			f, ok := openingFence(lines[i])
+			if !ok {
+				text.WriteString(lines[i])
+				continue
+			}
+			end := i + 1
+			for end < len(lines) && !f.closes(lines[end]) {
+				end++
+			}
+
+			if err := tokenise(lexer, text.String()); err != nil {
+				return nil, err
+			}
+			text.Reset()
+			literal(lines[i])
+			if err := tokenise(f.lexer(), strings.Join(lines[i+1:end], "")); err != nil {
+				return nil, err
+			}
+			if end < len(lines) {
+				literal(lines[end])
+			}
+			i = end
+		}
+		if err := tokenise(lexer, text.String()); err != nil {
+			return nil, err
+		}
+		return chroma.Concaterator(iterators...), nil
+	}
+}
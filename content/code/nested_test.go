@@ -0,0 +1,78 @@
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNestedHighlighting(t *testing.T) {
+	input := strings.Join([]string{
+		"# Install",
+		"",
+		"1. Run it:",
+		"   ~~~ python title=\"run.py\"",
+		"   def run(): pass",
+		"   ~~~",
+		"",
+		"```c++",
+		"class Foo {};",
+		"```",
+		"",
+		"```",
+		"def plain",
+		"```",
+		"",
+		"```go",
+		"func open() {}",
+	}, "\n")
+
+	highlight := func(nested bool) *HighlightedCode {
+		h, err := Highlight(input, &CodeStyle{Language: "markdown", Theme: "monokai", NestedHighlighting: nested})
+		require.NoError(t, err)
+		return h
+	}
+	hasToken := func(h *HighlightedCode, line int, text string, tokenType chroma.TokenType) bool {
+		for _, token := range h.Lines[line].Tokens {
+			if strings.TrimSpace(token.Text) == text && token.Type == tokenType {
+				return true
+			}
+		}
+		return false
+	}
+
+	h := highlight(true)
+	assert.Equal(t, "markdown", h.Language)
+	assert.Equal(t, strings.Split(input, "\n"), lineTexts(h), "The text is left as it is")
+
+	assert.True(t, hasToken(h, 0, "# Install", chroma.GenericHeading), "Markdown is left to its lexer")
+	assert.True(t, hasToken(h, 3, `~~~ python title="run.py"`, chroma.LiteralString))
+	assert.True(t, hasToken(h, 4, "def", chroma.Keyword), "Indented tilde fences with attributes")
+	assert.True(t, hasToken(h, 8, "class", chroma.Keyword), "Languages that aren't a single word")
+	assert.True(t, hasToken(h, 12, "def plain", chroma.Text), "Blocks without a language are plain text")
+	assert.True(t, hasToken(h, 16, "func", chroma.KeywordDeclaration), "A block left open runs to the end")
+
+	plain := highlight(false)
+	assert.Equal(t, strings.Split(input, "\n"), lineTexts(plain))
+	assert.False(t, hasToken(plain, 4, "def", chroma.Keyword), "Only with the option")
+
+	// Other languages are left alone
+	goCode := "package main\n\nconst s = `\n```python\ndef f(): pass\n```\n`\n"
+	nested, err := Highlight(goCode, &CodeStyle{Language: "go", NestedHighlighting: true})
+	require.NoError(t, err)
+	without, err := Highlight(goCode, &CodeStyle{Language: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, without.Lines, nested.Lines)
+}
+
+// lineTexts returns the text of each of the highlighted lines
+func lineTexts(h *HighlightedCode) []string {
+	var texts []string
+	for _, line := range h.Lines {
+		texts = append(texts, getLineText(line))
+	}
+	return texts
+}
@@ -0,0 +1,85 @@
+package code
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redactionPatternSets are the bundles of patterns WithRedactionPatternSet adds, by
+// name. Unlike the default patterns, which only look at quoted strings and
+// assignments, they match the shape of a provider's keys wherever they appear, such
+// as in logs or configuration files.
+var redactionPatternSets = map[string][]RedactionPattern{
+	"aws": {
+		// Access key IDs, long-lived (AKIA) or temporary (ASIA)
+		{regexp.MustCompile(`\b(?P<value>(?:AKIA|ASIA)[0-9A-Z]{16})\b`), "AWS Access Key"},
+		// Secret access keys, which are only recognizable by the name they're given
+		{regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<value>[A-Za-z0-9/+=]{40})`), "AWS Secret Key"},
+	},
+	"github": {
+		// Personal access, OAuth, user-to-server, server-to-server and refresh tokens
+		{regexp.MustCompile(`\b(?P<value>gh[pousr]_[A-Za-z0-9]{36,})\b`), "GitHub Token"},
+		// Fine-grained personal access tokens
+		{regexp.MustCompile(`\b(?P<value>github_pat_[A-Za-z0-9_]{22,})`), "GitHub Token"},
+	},
+	"google": {
+		// API keys for Maps, Firebase and the other Google Cloud APIs
+		{regexp.MustCompile(`\b(?P<value>AIza[0-9A-Za-z_-]{35})`), "Google API Key"},
+		// OAuth client secrets
+		{regexp.MustCompile(`\b(?P<value>GOCSPX-[0-9A-Za-z_-]{28})`), "Google OAuth Secret"},
+	},
+	"jwt": {
+		// A header and payload, both base64url encoded JSON objects, and a signature
+		{regexp.MustCompile(`\b(?P<value>eyJ[\w-]+\.eyJ[\w-]+\.[\w-]+)`), "JWT"},
+	},
+	"openai": {
+		// Legacy, project and service account keys
+		{regexp.MustCompile(`\b(?P<value>sk-(?:proj-|svcacct-)?[\w-]{32,})`), "OpenAI Key"},
+	},
+	"private-key": {
+		// The body of PEM encoded RSA, EC, DSA, OpenSSH and PKCS #8 private keys
+		{regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----(?P<value>[\s\S]+?)-----END (?:[A-Z]+ )*PRIVATE KEY-----`), "Private Key"},
+	},
+	"slack": {
+		// Bot, app, user, refresh and workspace tokens
+		{regexp.MustCompile(`\b(?P<value>xox[baprs]-[0-9A-Za-z-]{10,})`), "Slack Token"},
+		// The secret part of incoming webhook URLs
+		{regexp.MustCompile(`hooks\.slack\.com/services/T\w+/B\w+/(?P<value>\w+)`), "Slack Webhook"},
+	},
+	"stripe": {
+		// Secret and restricted keys, live or test
+		{regexp.MustCompile(`\b(?P<value>[sr]k_(?:live|test)_[0-9A-Za-z]{24,})`), "Stripe Key"},
+		// Webhook signing secrets
+		{regexp.MustCompile(`\b(?P<value>whsec_[0-9A-Za-z]{32,})`), "Stripe Webhook Secret"},
+	},
+}
+
+// RedactionPatternSetNames returns the names of the pattern sets that can be passed to
+// WithRedactionPatternSet, sorted
+func RedactionPatternSetNames() []string {
+	names := make([]string, 0, len(redactionPatternSets))
+	for name := range redactionPatternSets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// RedactionPatternSet returns a copy of the patterns of the named set, and whether
+// there is a set by that name. Names are case-insensitive.
+func RedactionPatternSet(name string) ([]RedactionPattern, bool) {
+	patterns, ok := redactionPatternSets[strings.ToLower(name)]
+	return slices.Clone(patterns), ok
+}
+
+// AddPatternSet adds the patterns of the named set to the ones redacted
+func (rc *RedactionConfig) AddPatternSet(name string) error {
+	patterns, ok := RedactionPatternSet(name)
+	if !ok {
+		return fmt.Errorf("unknown redaction pattern set %q (must be one of %s)", name, strings.Join(RedactionPatternSetNames(), ", "))
+	}
+	rc.Patterns = append(rc.Patterns, patterns...)
+	return nil
+}
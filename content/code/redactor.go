@@ -2,10 +2,15 @@ package code
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
 	"regexp"
+	"slices"
 	"sort"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/alecthomas/chroma/v2"
 	"github.com/disintegration/imaging"
 )
 
@@ -15,16 +20,18 @@ type RedactionPattern struct {
 	Name    string
 }
 
-// DefaultRedactionPatterns contains common patterns for sensitive information
-var DefaultRedactionPatterns = []RedactionPattern{
+// defaultRedactionPatterns contains common patterns for sensitive information
+var defaultRedactionPatterns = []RedactionPattern{
 	// Known secret formats (API keys, tokens, etc)
 	{regexp.MustCompile(`` +
 		`(?i)` + // Case-insensitive
 		`"` + // Opening quote
 		`(?P<value>` + // Start value capture
 		`(?:` +
-		`sk_(?:live|test)_[\w]{24,}|` + // Stripe
+		`[sr]k_(?:live|test)_[\w]{24,}|` + // Stripe secret and restricted keys
 		`sk-[\w]{32,}|` + // OpenAI
+		`xox[baprs]-[\w-]{10,}|` + // Slack
+		`AIza[\w-]{35}|` + // Google API keys
 		`gh[porsu]_[\w]{36,}|` + // GitHub tokens
 		`AKIA[\w]{16}|` + // AWS
 		`eyJ[\w-_=]+\.eyJ[\w-_=]+\.[\w-_.+/=]+` + // JWT
@@ -59,6 +66,27 @@ var DefaultRedactionPatterns = []RedactionPattern{
 		`)` + // End value capture
 		`(?:"|\x60)`, // Closing quote or backtick
 	), "Sensitive Variable"},
+
+	// Unquoted assignments to sensitive names, as in .env, INI and properties files
+	// or shell scripts, such as DB_PASSWORD=hunter2. The value has to be a single
+	// word, so calls like secret = load() are left alone.
+	{regexp.MustCompile(`` +
+		`(?im)` + // Case-insensitive and multiline mode
+		`^\s*(?:export\s+)?` + // Start of line, with an optional shell export
+		`[\w.-]*` + // Optional prefix
+		`(?:password|passwd|pwd|secret|token|api_?key)` + // Sensitive words
+		`[\w.-]*` + // Optional suffix
+		`\s*[=:]\s*` + // Assignment
+		`(?P<value>[^\s"'\x60#=(][^\s"'\x60#(]*)` + // Unquoted value
+		`(?:\s|$)`), "Generic Assignment"},
+}
+
+// DefaultRedactionPatterns returns the patterns redaction uses unless given others:
+// known secret formats such as Stripe, OpenAI, GitHub and AWS keys and JWTs, passwords
+// in URLs, and the values of variables with names like key, token or password. The
+// slice is a copy, so it can be appended to or changed freely.
+func DefaultRedactionPatterns() []RedactionPattern {
+	return slices.Clone(defaultRedactionPatterns)
 }
 
 // RedactionStyle represents the style of redaction to apply
@@ -69,6 +97,10 @@ const (
 	RedactionStyleBlock RedactionStyle = "block"
 	// RedactionStyleBlur applies a blur effect to the text
 	RedactionStyleBlur RedactionStyle = "blur"
+	// RedactionStyleLabel replaces text with the name of the pattern that matched it,
+	// such as <REDACTED:known_secret_format>, in the theme's comment color. The line
+	// grows or shrinks to fit the label. Manual redactions are drawn as blocks.
+	RedactionStyleLabel RedactionStyle = "label"
 )
 
 // RedactionConfig holds configuration for the redaction feature
@@ -100,7 +132,7 @@ func NewRedactionConfig() *RedactionConfig {
 	return &RedactionConfig{
 		Enabled:    false,
 		Style:      RedactionStyleBlock,
-		Patterns:   DefaultRedactionPatterns,
+		Patterns:   DefaultRedactionPatterns(),
 		BlurRadius: 5.0,
 	}
 }
@@ -115,21 +147,18 @@ func (rc *RedactionConfig) AddManualRedaction(x, y, width, height int) {
 	})
 }
 
-// FindRedactionRanges analyzes the text and returns ranges that should be redacted
-func FindRedactionRanges(config *RedactionConfig, text string) []RedactionRange {
-	if !config.Enabled {
-		return nil
-	}
-
+// findPatternRanges returns the value matched by each of the patterns in text, sorted
+// by where they start. Matches of different patterns may overlap.
+func findPatternRanges(patterns []RedactionPattern, text string) []RedactionRange {
 	var ranges []RedactionRange
 	contentBytes := []byte(text)
 
 	// Apply patterns to find matches
-	for _, pattern := range config.Patterns {
+	for _, pattern := range patterns {
 		matches := pattern.Pattern.FindAllSubmatchIndex(contentBytes, -1)
 		for _, match := range matches {
 			// The first subgroup is our "value" group that we want to redact
-			if len(match) >= 4 { // Make sure we have a subgroup
+			if len(match) >= 4 && match[2] >= 0 { // Make sure we have a subgroup
 				ranges = append(ranges, RedactionRange{
 					StartIndex: match[2], // Start of first subgroup
 					EndIndex:   match[3], // End of first subgroup
@@ -140,9 +169,63 @@ func FindRedactionRanges(config *RedactionConfig, text string) []RedactionRange
 	}
 
 	// Sort ranges by start index
-	sort.Slice(ranges, func(i, j int) bool {
+	sort.SliceStable(ranges, func(i, j int) bool {
 		return ranges[i].StartIndex < ranges[j].StartIndex
 	})
+	return ranges
+}
+
+// SecretMatch is a secret found by ScanForSecrets
+type SecretMatch struct {
+	Pattern    string // Name of the pattern that matched
+	Text       string // The secret itself
+	StartIndex int    // Offset in bytes of the start of the secret
+	EndIndex   int    // Offset in bytes just past the end of the secret
+	Line       int    // Line the secret starts on, from 1
+	Column     int    // Column the secret starts at, from 1, counted in runes
+}
+
+// ScanForSecrets finds the text that redaction would hide, without rendering
+// anything, for example to warn about secrets in a file before sharing it. The
+// patterns of cfg are used, or DefaultRedactionPatterns if cfg is nil; cfg.Enabled
+// doesn't matter. Unlike FindRedactionRanges, overlapping matches of different
+// patterns aren't merged, so a secret can be reported once for each pattern it
+// matches. The matches are sorted by where they start.
+func ScanForSecrets(text string, cfg *RedactionConfig) []SecretMatch {
+	patterns := defaultRedactionPatterns
+	if cfg != nil {
+		patterns = cfg.Patterns
+	}
+
+	var matches []SecretMatch
+	line, lineStart := 1, 0
+	for _, r := range findPatternRanges(patterns, text) {
+		// Ranges are sorted, so lines are counted from the last match onwards
+		for i := lineStart; i < r.StartIndex; i++ {
+			if text[i] == '\n' {
+				line++
+				lineStart = i + 1
+			}
+		}
+		matches = append(matches, SecretMatch{
+			Pattern:    r.Pattern,
+			Text:       text[r.StartIndex:r.EndIndex],
+			StartIndex: r.StartIndex,
+			EndIndex:   r.EndIndex,
+			Line:       line,
+			Column:     utf8.RuneCountInString(text[lineStart:r.StartIndex]) + 1,
+		})
+	}
+	return matches
+}
+
+// FindRedactionRanges analyzes the text and returns ranges that should be redacted
+func FindRedactionRanges(config *RedactionConfig, text string) []RedactionRange {
+	if !config.Enabled {
+		return nil
+	}
+
+	ranges := findPatternRanges(config.Patterns, text)
 
 	// Merge overlapping ranges
 	if len(ranges) > 1 {
@@ -169,6 +252,134 @@ func FindRedactionRanges(config *RedactionConfig, text string) []RedactionRange
 	return ranges
 }
 
+// RedactionLabel returns the placeholder RedactionStyleLabel shows in place of text
+// matched by the named pattern: the name in lower case, with underscores for spaces
+func RedactionLabel(pattern string) string {
+	name := strings.ToLower(strings.Join(strings.Fields(pattern), "_"))
+	if name == "" {
+		name = "secret"
+	}
+	return "<REDACTED:" + name + ">"
+}
+
+// labelRedactions replaces the redacted text in the lines with labels drawn in
+// labelColor. A range spanning several lines is labeled on the first of them and
+// removed from the rest.
+func labelRedactions(config *RedactionConfig, lines []Line, labelColor color.Color) []Line {
+	var fullText strings.Builder
+	lineStarts := make([]int, len(lines))
+	for i, line := range lines {
+		lineStarts[i] = fullText.Len()
+		fullText.WriteString(getLineText(line))
+		fullText.WriteString("\n")
+	}
+	ranges := FindRedactionRanges(config, fullText.String())
+	if len(ranges) == 0 {
+		return lines
+	}
+
+	// cut is a part of a line to remove, in bytes from the start of the line, with the
+	// label to put in its place if any
+	type cut struct {
+		start, end int
+		label      string
+	}
+
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = line
+		lineStart := lineStarts[i]
+		lineEnd := lineStart + len(getLineText(line))
+
+		var cuts []cut
+		for _, r := range ranges {
+			if r.EndIndex <= lineStart || r.StartIndex > lineEnd {
+				continue
+			}
+			c := cut{start: max(0, r.StartIndex-lineStart), end: min(lineEnd, r.EndIndex) - lineStart}
+			if r.StartIndex >= lineStart {
+				c.label = RedactionLabel(r.Pattern)
+			}
+			cuts = append(cuts, c)
+		}
+		if len(cuts) == 0 {
+			continue
+		}
+
+		result[i].Tokens = nil
+		labeled := -1 // Index of the last cut whose label was added
+		addLabel := func(j int) {
+			if labeled < j && cuts[j].label != "" {
+				result[i].Tokens = append(result[i].Tokens, Token{Text: cuts[j].label, Type: chroma.Comment, Color: labelColor})
+			}
+			labeled = j
+		}
+
+		offset, next := 0, 0
+		for _, token := range line.Tokens {
+			text := token.Text
+			for len(text) > 0 {
+				if next < len(cuts) && offset >= cuts[next].start {
+					addLabel(next)
+					n := min(len(text), cuts[next].end-offset)
+					text, offset = text[n:], offset+n
+					if offset >= cuts[next].end {
+						next++
+					}
+					continue
+				}
+
+				n := len(text)
+				if next < len(cuts) {
+					n = min(n, cuts[next].start-offset)
+				}
+				part := token
+				part.Text = text[:n]
+				result[i].Tokens = append(result[i].Tokens, part)
+				text, offset = text[n:], offset+n
+			}
+		}
+		// Ranges starting at the end of the line still get their label
+		for ; next < len(cuts); next++ {
+			addLabel(next)
+		}
+	}
+	return result
+}
+
+// blockRedactions replaces each character of the redacted text in the lines with a
+// block, as RedactionStyleBlock draws it
+func blockRedactions(config *RedactionConfig, lines []Line) []Line {
+	lineRanges := findLineRedactionRanges(config, lines)
+	if len(lineRanges) == 0 {
+		return lines
+	}
+
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = line
+		ranges := lineRanges[i]
+		if len(ranges) == 0 {
+			continue
+		}
+
+		result[i].Tokens = make([]Token, len(line.Tokens))
+		column := 0
+		for j, token := range line.Tokens {
+			runes := []rune(token.Text)
+			for k := range runes {
+				if ShouldRedact(column+k, ranges) {
+					runes[k] = '█'
+				}
+			}
+			column += len(runes)
+			result[i].Tokens[j] = token
+			result[i].Tokens[j].Text = string(runes)
+		}
+	}
+	return result
+}
+
 // ShouldRedact returns true if the given position in the text should be redacted
 func ShouldRedact(pos int, ranges []RedactionRange) bool {
 	for _, r := range ranges {
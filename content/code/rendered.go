@@ -0,0 +1,91 @@
+package code
+
+import (
+	"image"
+	"sync"
+
+	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/svg"
+)
+
+// RenderedContent is code rendered once, to be composited by any number of canvases
+// without highlighting and laying it out again, such as when the same code is shown
+// on a matrix of chromes and backgrounds. It implements the same interfaces as
+// CodeRenderer, so it can be passed to Canvas.WithContent in its place.
+type RenderedContent struct {
+	image    image.Image
+	renderer *CodeRenderer // Snapshot of the renderer, for SVG and locating characters
+
+	svgOnce sync.Once
+	svg     *svg.Fragment
+	svgErr  error
+
+	scaledMu sync.Mutex
+	scaled   map[float64]*RenderedContent // The code rendered at each scale asked for
+}
+
+// RenderContent renders the code and returns it for reuse. Later changes to the
+// renderer don't affect the returned content.
+func (r *CodeRenderer) RenderContent() (*RenderedContent, error) {
+	img, err := r.Render()
+	if err != nil {
+		return nil, err
+	}
+	style := *r.Style
+	return &RenderedContent{
+		image:    img,
+		renderer: &CodeRenderer{Code: r.Code, Style: &style},
+	}, nil
+}
+
+// Render implements the content.Content interface, returning the rendered image
+func (c *RenderedContent) Render() (image.Image, error) {
+	return c.image, nil
+}
+
+// Measure implements the content.Measurer interface
+func (c *RenderedContent) Measure() (width, height int, err error) {
+	return c.image.Bounds().Dx(), c.image.Bounds().Dy(), nil
+}
+
+// RenderSVG implements the content.SVGContent interface. The SVG is only rendered the
+// first time it's asked for.
+func (c *RenderedContent) RenderSVG() (*svg.Fragment, error) {
+	c.svgOnce.Do(func() {
+		c.svg, c.svgErr = c.renderer.RenderSVG()
+	})
+	if c.svgErr != nil {
+		return nil, c.svgErr
+	}
+	// Canvases add to the fragment's body, so each gets its own copy
+	f := *c.svg
+	return &f, nil
+}
+
+// LocateChar implements the content.CharLocator interface
+func (c *RenderedContent) LocateChar(line, column int) (image.Rectangle, error) {
+	return c.renderer.LocateChar(line, column)
+}
+
+// Scaled implements the content.Scaler interface. The code is rendered again at the
+// scale, through the renderer it was first rendered with, once for each factor asked
+// for.
+func (c *RenderedContent) Scaled(factor float64) content.Content {
+	c.scaledMu.Lock()
+	defer c.scaledMu.Unlock()
+	if scaled, ok := c.scaled[factor]; ok {
+		return scaled
+	}
+
+	renderer := c.renderer.Scaled(factor).(*CodeRenderer)
+	scaled, err := renderer.RenderContent()
+	if err != nil {
+		// The renderer fails again with the error when the canvas renders it
+		return renderer
+	}
+	if c.scaled == nil {
+		c.scaled = make(map[float64]*RenderedContent)
+	}
+	c.scaled[factor] = scaled
+	return scaled
+}
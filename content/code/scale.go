@@ -0,0 +1,71 @@
+package code
+
+import (
+	"math"
+
+	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/fonts"
+)
+
+// scaleFactor returns the factor the fixed sizes of decorations, like the language
+// badge, are multiplied by
+func (s *CodeStyle) scaleFactor() float64 {
+	if s.scale <= 0 {
+		return 1
+	}
+	return s.scale
+}
+
+// Scaled implements the content.Scaler interface. The copy has its own style, with
+// the font size, padding, widths and blur radii multiplied by factor, so the code is
+// laid out the same but drawn with more pixels.
+func (r *CodeRenderer) Scaled(factor float64) content.Content {
+	style := *r.Style
+	scale := func(v int) int {
+		return int(math.Round(float64(v) * factor))
+	}
+
+	style.FontSize *= factor
+	style.PaddingLeft = scale(style.PaddingLeft)
+	style.PaddingRight = scale(style.PaddingRight)
+	style.PaddingTop = scale(style.PaddingTop)
+	style.PaddingBottom = scale(style.PaddingBottom)
+	style.LineNumberPadding = scale(style.LineNumberPadding)
+	style.MinWidth = scale(style.MinWidth)
+	style.MaxWidth = scale(style.MaxWidth)
+	style.CornerRadius = scale(style.CornerRadius)
+	style.LetterSpacing = scale(style.LetterSpacing)
+	style.scale = r.Style.scaleFactor() * factor
+
+	// Line heights are rounded to whole pixels, so the larger font's would be off by
+	// a pixel or so. Scaling the unscaled height keeps the lines exactly to scale.
+	if face, err := r.Style.getFace(r.Style.FontSize, &fonts.FontStyle{Weight: fonts.WeightRegular, Stretch: fonts.StretchNormal}); err == nil {
+		style.LineHeight = float64(r.Style.lineHeightPixels(face.Face.Metrics())) * factor
+		style.LineHeightMode = LineHeightAbsolute
+		face.Close()
+	} else if style.LineHeightMode == LineHeightAbsolute {
+		style.LineHeight *= factor
+	}
+
+	if style.RedactionConfig != nil {
+		redaction := *style.RedactionConfig
+		redaction.BlurRadius *= factor
+		redaction.ManualRedactions = make([]RedactionArea, len(style.RedactionConfig.ManualRedactions))
+		for i, area := range style.RedactionConfig.ManualRedactions {
+			redaction.ManualRedactions[i] = RedactionArea{
+				X:      scale(area.X),
+				Y:      scale(area.Y),
+				Width:  scale(area.Width),
+				Height: scale(area.Height),
+			}
+		}
+		style.RedactionConfig = &redaction
+	}
+	if style.FocusRange != nil {
+		focus := *style.FocusRange
+		focus.BlurRadius *= factor
+		style.FocusRange = &focus
+	}
+
+	return &CodeRenderer{Code: r.Code, Style: &style}
+}
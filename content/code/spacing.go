@@ -0,0 +1,33 @@
+package code
+
+import (
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// trackedFace adds letter spacing to the advance of every glyph of a face, so that
+// text is measured, wrapped and drawn with the spacing without any of that code
+// having to know about it
+type trackedFace struct {
+	font.Face
+	spacing fixed.Int26_6 // Advance added after each glyph
+}
+
+func (f *trackedFace) Glyph(dot fixed.Point26_6, r rune) (
+	dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+
+	dr, mask, maskp, advance, ok = f.Face.Glyph(dot, r)
+	return dr, mask, maskp, advance + f.spacing, ok
+}
+
+func (f *trackedFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	bounds, advance, ok = f.Face.GlyphBounds(r)
+	return bounds, advance + f.spacing, ok
+}
+
+func (f *trackedFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	advance, ok = f.Face.GlyphAdvance(r)
+	return advance + f.spacing, ok
+}
@@ -0,0 +1,149 @@
+package code
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// DefaultGlyphSubstitutions replaces common operators with their Unicode symbols
+var DefaultGlyphSubstitutions = map[string]string{
+	"->": "→",
+	"=>": "⇒",
+	"!=": "≠",
+	">=": "≥",
+	"<=": "≤",
+}
+
+// substitutable reports whether glyphs may be substituted in a token of the given
+// type. Besides operators this includes punctuation and plain text, which some
+// lexers use for operators such as "->", but never literals or comments.
+func substitutable(tokenType chroma.TokenType) bool {
+	return tokenType.InCategory(chroma.Operator) ||
+		tokenType.InCategory(chroma.Punctuation) ||
+		tokenType.InCategory(chroma.Text)
+}
+
+// substituteGlyphs replaces the operator sequences in the lines with their glyphs.
+// Lexers often split operators into a token per character, so each run of adjacent
+// substitutable tokens is matched as a whole. A replacement takes the style of the
+// token its sequence starts in.
+func substituteGlyphs(lines []Line, substitutions map[string]string) []Line {
+	if len(substitutions) == 0 {
+		return lines
+	}
+
+	// Longer sequences are matched first so that e.g. "==>" wins over "=>"
+	keys := make([]string, 0, len(substitutions))
+	for key := range substitutions {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = line
+		result[i].Tokens = nil
+
+		for start := 0; start < len(line.Tokens); {
+			if !substitutable(line.Tokens[start].Type) {
+				result[i].Tokens = append(result[i].Tokens, line.Tokens[start])
+				start++
+				continue
+			}
+			end := start + 1
+			for end < len(line.Tokens) && substitutable(line.Tokens[end].Type) {
+				end++
+			}
+			result[i].Tokens = append(result[i].Tokens, substituteRun(line.Tokens[start:end], keys, substitutions)...)
+			start = end
+		}
+	}
+	return result
+}
+
+// substituteRun applies the substitutions to a run of adjacent tokens
+func substituteRun(run []Token, keys []string, substitutions map[string]string) []Token {
+	// Record which token each byte of the run's text comes from
+	var text strings.Builder
+	var owners []int
+	for i, token := range run {
+		text.WriteString(token.Text)
+		for range len(token.Text) {
+			owners = append(owners, i)
+		}
+	}
+	s := text.String()
+
+	var result []Token
+	emit := func(owner int, part string) {
+		if n := len(result); n > 0 && result[n-1].Type == run[owner].Type && sameStyle(result[n-1], run[owner]) {
+			result[n-1].Text += part
+			return
+		}
+		token := run[owner]
+		token.Text = part
+		result = append(result, token)
+	}
+
+	for pos := 0; pos < len(s); {
+		matched := false
+		for _, key := range keys {
+			if matchesOperator(s, pos, key) {
+				emit(owners[pos], substitutions[key])
+				pos += len(key)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			// Copy up to the start of the next token unchanged
+			next := pos + 1
+			for next < len(s) && owners[next] == owners[pos] && !matchesAnyOperator(s, next, keys) {
+				next++
+			}
+			emit(owners[pos], s[pos:next])
+			pos = next
+		}
+	}
+	return result
+}
+
+// sameStyle reports whether two tokens are drawn the same way
+func sameStyle(a, b Token) bool {
+	return a.Color == b.Color && a.Background == b.Background && a.Bold == b.Bold && a.Italic == b.Italic && a.Underline == b.Underline && a.NoItalic == b.NoItalic
+}
+
+// operatorChars are the characters operators are made of
+const operatorChars = "!#$%&*+-./:<=>?@^|~"
+
+// matchesOperator reports whether key appears in s at pos as a complete operator,
+// rather than as part of a longer one such as "!=" in "!=="
+func matchesOperator(s string, pos int, key string) bool {
+	if !strings.HasPrefix(s[pos:], key) {
+		return false
+	}
+	if pos > 0 && strings.IndexByte(operatorChars, s[pos-1]) >= 0 {
+		return false
+	}
+	end := pos + len(key)
+	return end == len(s) || strings.IndexByte(operatorChars, s[end]) < 0
+}
+
+// matchesAnyOperator reports whether any of the keys matches in s at pos
+func matchesAnyOperator(s string, pos int, keys []string) bool {
+	for _, key := range keys {
+		if matchesOperator(s, pos, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlyphSubstitution(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		code     string
+		want     string
+	}{
+		{name: "operators", language: "go", code: "ok := a != b && c >= d", want: "ok := a ≠ b && c ≥ d"},
+		{name: "string untouched", language: "go", code: `s := "a != b"`, want: `s := "a != b"`},
+		{name: "comment untouched", language: "go", code: "x <= y // x <= y", want: "x ≤ y // x <= y"},
+		{name: "arrow split across tokens", language: "rust", code: "fn f() -> i32 { 1 }", want: "fn f() → i32 { 1 }"},
+		{name: "part of a longer operator", language: "javascript", code: "a !== b", want: "a !== b"},
+		{name: "fat arrow", language: "javascript", code: "const f = (x) => x", want: "const f = (x) ⇒ x"},
+		{name: "nothing to replace", language: "go", code: "x := 1", want: "x := 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRenderer(tt.code).WithLanguage(tt.language).WithGlyphSubstitution(DefaultGlyphSubstitutions)
+			l, err := r.layout()
+			require.NoError(t, err)
+			defer l.close()
+			assert.Equal(t, tt.want, getLineText(l.lines[0]))
+		})
+	}
+
+	t.Run("styles kept", func(t *testing.T) {
+		r := DefaultRenderer(`x != "!="`).WithLanguage("go").WithGlyphSubstitution(DefaultGlyphSubstitutions)
+		l, err := r.layout()
+		require.NoError(t, err)
+		defer l.close()
+		plain, err := DefaultRenderer(`x != "!="`).WithLanguage("go").layout()
+		require.NoError(t, err)
+		defer plain.close()
+
+		styles := func(line Line) map[string]Token {
+			byText := map[string]Token{}
+			for _, token := range line.Tokens {
+				text := token.Text
+				token.Text = ""
+				byText[text] = token
+			}
+			return byText
+		}
+		got, want := styles(l.lines[0]), styles(plain.lines[0])
+		assert.Equal(t, want["!="], got["≠"])
+		assert.Equal(t, want[`"!="`], got[`"!="`])
+	})
+}
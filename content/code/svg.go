@@ -0,0 +1,295 @@
+package code
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/watzon/goshot/svg"
+	"golang.org/x/image/font"
+)
+
+// svgRun is a run of characters drawn as a single <tspan>
+type svgRun struct {
+	token Token
+	text  strings.Builder
+	xs    []string // X position of each character
+}
+
+// RenderSVG renders the code as SVG text, laid out exactly like Render. Each character
+// is positioned explicitly so the grid holds even if the viewer substitutes the font.
+// Line numbers, highlighted lines, line and token backgrounds, redactions, the focus
+// range and the language badge are drawn; the lint overlay is not.
+func (r *CodeRenderer) RenderSVG() (*svg.Fragment, error) {
+	config := r.Style
+	l, err := r.layout()
+	if err != nil {
+		return nil, err
+	}
+	defer l.close()
+
+	h := l.h
+	ascent := l.metrics.Ascent.Round()
+
+	bgColor := h.BackgroundColor
+	if bgColor == nil {
+		bgColor = color.White
+	}
+
+	var b strings.Builder
+	var families []string
+	for _, f := range config.fontChain() {
+		if f.Name != "" {
+			families = append(families, fmt.Sprintf("'%s'", svg.Escape(f.Name)))
+		}
+	}
+	family := strings.Join(append(families, "monospace"), ", ")
+	clip := ""
+	if config.CornerRadius > 0 {
+		fmt.Fprintf(&b, "<defs><clipPath id=\"goshot-code-clip\">%s</clipPath></defs>\n",
+			strings.TrimSuffix(svg.RoundedRect(0, 0, float64(l.totalWidth), float64(l.totalHeight), float64(config.CornerRadius), ""), "\n"))
+		clip = ` clip-path="url(#goshot-code-clip)"`
+	}
+	fmt.Fprintf(&b, "<g font-family=\"%s\" font-size=\"%s\" xml:space=\"preserve\" style=\"white-space:pre\"%s>\n",
+		family, svg.Number(config.FontSize), clip)
+
+	// Everything that the focus blur applies to
+	b.WriteString("<g id=\"goshot-code-layers\">\n")
+	b.WriteString("<g id=\"goshot-code\">\n")
+	if !config.TransparentBackground {
+		b.WriteString(svg.RoundedRect(0, 0, float64(l.totalWidth), float64(l.totalHeight), 0, svg.Paint("fill", bgColor)))
+	}
+
+	// Line backgrounds and highlights
+	for i := range l.wrappedLines {
+		originalLineIdx := l.lineToWrappedMap[i]
+		// Unlike an image, the SVG doesn't clip the rectangles to its bounds
+		rect := l.lineRect(config, config.PaddingTop+i*l.lineHeight).Intersect(image.Rect(0, 0, l.totalWidth, l.totalHeight))
+		if !l.ellipsisLines[originalLineIdx] {
+			if lineBg := config.lineBackground(l.lineNumberMap[originalLineIdx]); lineBg != nil {
+				b.WriteString(svgRect(rect, svg.Paint("fill", lineBg)))
+			}
+		}
+		if diffBg := l.diffBackground(config, originalLineIdx); diffBg != nil {
+			b.WriteString(svgRect(rect, svg.Paint("fill", diffBg)))
+		}
+		if highlight := h.lineHighlight(l.lines[originalLineIdx]); highlight != nil {
+			b.WriteString(svgRect(rect, svg.Paint("fill", highlight)))
+		}
+		for _, box := range l.tokenBackgrounds(config, i, config.PaddingTop+i*l.lineHeight) {
+			b.WriteString(svgRect(box.rect, svg.Paint("fill", box.color)))
+		}
+		for _, box := range l.columnHighlights(config, i, config.PaddingTop+i*l.lineHeight) {
+			b.WriteString(svgRect(box.rect, svg.Paint("fill", box.color)))
+		}
+	}
+
+	var lineRedactionRanges map[int][]RedactionRange
+	redaction := config.RedactionConfig
+	if redaction != nil && redaction.Enabled && redaction.Style != RedactionStyleLabel {
+		lineRedactionRanges = findLineRedactionRanges(redaction, l.lines)
+	}
+	blurRedactions := redaction != nil && redaction.Style == RedactionStyleBlur
+	var blurRects []image.Rectangle
+
+	for i, tokens := range l.wrappedLines {
+		y := config.PaddingTop + i*l.lineHeight
+		baseline := strconv.Itoa(y + ascent)
+
+		// Line numbers, right aligned like in Render
+		if config.ShowLineNumbers {
+			lineNumberStr := l.gutterLabel(config, i)
+			x := config.PaddingLeft + l.lineNumberOffset - l.markerWidth - l.gutterMarkerWidth - font.MeasureString(l.regularFace.Face, lineNumberStr).Round() - config.LineNumberPadding
+			fmt.Fprintf(&b, "<text x=\"%d\" y=\"%s\"%s>%s</text>\n", x, baseline, svg.Paint("fill", h.LineNumberColor), svg.Escape(lineNumberStr))
+		}
+		if text, col, ok := l.linePrefix(config, i); ok {
+			x := config.PaddingLeft + l.prefixWidth - font.MeasureString(l.regularFace.Face, text).Round()
+			fmt.Fprintf(&b, "<text x=\"%d\" y=\"%s\"%s>%s</text>\n", x, baseline, svg.Paint("fill", col), svg.Escape(text))
+		}
+		if marker, ok := l.gutterMarker(config, i); ok {
+			col := marker.Color
+			if col == nil {
+				col = h.LineNumberColor
+			}
+			fmt.Fprintf(&b, "<text x=\"%d\" y=\"%s\"%s>%s</text>\n", config.PaddingLeft+l.lineNumberOffset-l.markerWidth-l.gutterMarkerWidth, baseline, svg.Paint("fill", col), svg.Escape(marker.Glyph))
+		}
+		if marker := l.diffMarker(i); marker != "" {
+			fmt.Fprintf(&b, "<text x=\"%d\" y=\"%s\"%s>%s</text>\n", config.PaddingLeft+l.lineNumberOffset-l.markerWidth, baseline, svg.Paint("fill", h.LineNumberColor), marker)
+		}
+
+		// Split the line into runs of characters that share a style
+		var runs []*svgRun
+		var current *svgRun
+		var blurStart, blurEnd int
+		inBlur := false
+
+		chars := l.placeChars(config, i)
+		lineChar := 0
+		currentColumn := l.wrappedLineOffsets[i].startOffset
+		redactionRanges := lineRedactionRanges[l.wrappedLineOffsets[i].originalLineIdx]
+		texts := l.expandedTokens(config, i)
+		for k, token := range tokens {
+			text := texts[k]
+
+			for j := range []rune(text) {
+				placed := chars[lineChar]
+				lineChar++
+				x := placed.x
+
+				redacted := len(redactionRanges) > 0 && ShouldRedact(currentColumn+j, redactionRanges)
+				drawn := placed.ch
+				if redacted && !blurRedactions {
+					drawn = '█'
+				}
+
+				if current == nil || !sameStyle(current.token, token) {
+					current = &svgRun{token: token}
+					runs = append(runs, current)
+				}
+				current.text.WriteRune(drawn)
+				current.xs = append(current.xs, strconv.Itoa(x))
+
+				if redacted && blurRedactions {
+					if !inBlur {
+						blurStart, blurEnd, inBlur = x, x, true
+					}
+					blurStart = min(blurStart, x)
+					blurEnd = max(blurEnd, x+placed.width)
+				} else if inBlur {
+					blurRects = append(blurRects, image.Rect(blurStart, y, blurEnd, y+l.metrics.Height.Round()))
+					inBlur = false
+				}
+			}
+			currentColumn += utf8.RuneCountInString(text)
+		}
+		if inBlur {
+			blurRects = append(blurRects, image.Rect(blurStart, y, blurEnd, y+l.metrics.Height.Round()))
+		}
+
+		if len(runs) > 0 {
+			var text strings.Builder
+			fmt.Fprintf(&text, "<text y=\"%s\">", baseline)
+			for _, run := range runs {
+				fmt.Fprintf(&text, "<tspan x=\"%s\"%s>%s</tspan>", strings.Join(run.xs, " "), svgTokenAttrs(run.token), svg.Escape(run.text.String()))
+			}
+			text.WriteString("</text>\n")
+			if t, ok := l.truncation(config, chars); ok {
+				b.WriteString(l.svgTruncation(config, t, i, y, text.String()))
+			} else {
+				b.WriteString(text.String())
+			}
+		}
+	}
+	b.WriteString("</g>\n")
+
+	// Redactions blur a copy of the code clipped to the redacted areas, or cover
+	// manually redacted areas with blocks
+	if redaction != nil {
+		if blurRedactions {
+			for _, area := range redaction.ManualRedactions {
+				blurRects = append(blurRects, image.Rect(area.X, area.Y, area.X+area.Width, area.Y+area.Height))
+			}
+			b.WriteString(svgBlurredCopy("goshot-code", "goshot-code-redactions", blurRects, redaction.BlurRadius))
+		} else {
+			blockChar := "█"
+			blockWidth := font.MeasureString(l.regularFace.Face, blockChar).Round()
+			for _, area := range redaction.ManualRedactions {
+				numBlocks := area.Width / blockWidth
+				if numBlocks <= 0 {
+					continue
+				}
+				for y := area.Y; y < area.Y+area.Height; y += l.lineHeight {
+					fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" fill=\"#000000\">%s</text>\n", area.X, y+ascent, strings.Repeat(blockChar, numBlocks))
+				}
+			}
+		}
+	}
+	b.WriteString("</g>\n")
+
+	// Blur everything outside of the focus range
+	if config.FocusRange != nil {
+		var rects []image.Rectangle
+		spanStart := -1
+		for i := range l.wrappedLines {
+			lineNumber := l.lineNumberMap[l.lineToWrappedMap[i]]
+			y := config.PaddingTop + i*l.lineHeight
+			if lineNumber < config.FocusRange.Start || lineNumber > config.FocusRange.End {
+				if spanStart < 0 {
+					spanStart = y
+					if i == 0 {
+						spanStart = 0
+					}
+				}
+			} else if spanStart >= 0 {
+				rects = append(rects, image.Rect(0, spanStart, l.totalWidth, y))
+				spanStart = -1
+			}
+		}
+		if spanStart >= 0 {
+			rects = append(rects, image.Rect(0, spanStart, l.totalWidth, l.totalHeight))
+		}
+		b.WriteString(svgBlurredCopy("goshot-code-layers", "goshot-code-focus", rects, config.FocusRange.BlurRadius))
+	}
+
+	// Label the code with its language
+	if config.ShowLanguageBadge && h.Language != "" {
+		face, err := loadBadgeFace(config)
+		if err != nil {
+			return nil, err
+		}
+		badge := layoutLanguageBadge(h, config, face.Face, l.totalWidth, l.totalHeight)
+		face.Close()
+
+		b.WriteString(svg.RoundedRect(badge.x, badge.y, badge.width, badge.height, badge.height/2, svg.Paint("fill", badge.fill)))
+		fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\" text-anchor=\"middle\" font-size=\"%s\"%s>%s</text>\n",
+			svg.Number(badge.x+badge.width/2), svg.Number(badge.baseline), svg.Number(config.FontSize*badgeFontScale),
+			svg.Paint("fill", badge.text), svg.Escape(h.Language))
+	}
+
+	b.WriteString("</g>\n")
+
+	return &svg.Fragment{Width: l.totalWidth, Height: l.totalHeight, Body: b.String()}, nil
+}
+
+// svgTokenAttrs returns the presentation attributes of a token
+func svgTokenAttrs(token Token) string {
+	attrs := ""
+	if token.Color != nil {
+		attrs += svg.Paint("fill", token.Color)
+	}
+	if token.Bold {
+		attrs += ` font-weight="bold"`
+	}
+	if token.Italic && !token.NoItalic {
+		attrs += ` font-style="italic"`
+	}
+	if token.Underline {
+		attrs += ` text-decoration="underline"`
+	}
+	return attrs
+}
+
+// svgRect returns a rectangle element covering rect
+func svgRect(rect image.Rectangle, attrs string) string {
+	return svg.RoundedRect(float64(rect.Min.X), float64(rect.Min.Y), float64(rect.Dx()), float64(rect.Dy()), 0, attrs)
+}
+
+// svgBlurredCopy draws a blurred copy of the element with the given id, clipped to
+// the rectangles
+func svgBlurredCopy(id, clipID string, rects []image.Rectangle, radius float64) string {
+	if len(rects) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<defs><clipPath id=\"%s\">", clipID)
+	for _, rect := range rects {
+		b.WriteString(strings.TrimSuffix(svgRect(rect, ""), "\n"))
+	}
+	fmt.Fprintf(&b, "</clipPath><filter id=\"%s-blur\"><feGaussianBlur stdDeviation=\"%s\"/></filter></defs>\n", clipID, svg.Number(radius))
+	fmt.Fprintf(&b, "<g clip-path=\"url(#%s)\"><use xlink:href=\"#%s\" filter=\"url(#%s-blur)\"/></g>\n", clipID, id, clipID)
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package code
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/svg"
+)
+
+func TestRenderSVG(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tprintln(\"<hi>\")\n}\n"
+	r := DefaultRenderer(src).WithLineHighlightRange(3, 3)
+
+	f, err := r.RenderSVG()
+	require.NoError(t, err)
+
+	// The SVG has the size of the image
+	img, err := r.Render()
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds().Dx(), f.Width)
+	assert.Equal(t, img.Bounds().Dy(), f.Height)
+
+	// The document is well formed, and the text is escaped rather than dropped
+	text := svgText(t, f)
+	assert.Contains(t, text, `println`)
+	assert.Contains(t, text, `"<hi>"`)
+
+	// Line numbers are written as text
+	for _, number := range []string{">1</text>", ">5</text>"} {
+		assert.Contains(t, f.Body, number)
+	}
+
+	// A single line is highlighted
+	h, err := Highlight(src, r.Style)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(f.Body, svg.Paint("fill", h.HighlightColor)+"/>"))
+}
+
+func TestRenderSVGLineNumberFormat(t *testing.T) {
+	r := DefaultRenderer("a\nb\n").WithLineNumberFormat(func(n int) string {
+		return fmt.Sprintf("<%d>&", n)
+	})
+
+	f, err := r.RenderSVG()
+	require.NoError(t, err)
+	text := svgText(t, f)
+	assert.Contains(t, text, "<1>&")
+	assert.Contains(t, text, "<2>&")
+}
+
+// svgText returns the text of an SVG document, failing the test if it isn't well
+// formed
+func svgText(t *testing.T, f *svg.Fragment) string {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(string(svg.Document(f))))
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if data, ok := token.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+	return text.String()
+}
+
+func TestRenderSVGControlCharacters(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{name: "form feed", code: "a\fb", want: "a�b"},
+		{name: "escape", code: "a\x1b[0mb", want: "a�[0mb"},
+		{name: "null", code: "a\x00b", want: "a�b"},
+		{name: "invalid UTF-8", code: "a\xffb", want: "a�b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := DefaultRenderer(tt.code + "\n").RenderSVG()
+			require.NoError(t, err)
+			assert.Contains(t, svgText(t, f), tt.want)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package code
+
+// HighlightedResult is highlighted code with its lines made ready to be drawn, as
+// goshot's own images and HTML are drawn from them, for writing it out in formats of
+// one's own
+type HighlightedResult struct {
+	HighlightedCode              // The lines ready to be drawn, with the theme's colors
+	LineNumbers     []int        // The number of each line in the input, from 1; the footer's is 0
+	Ellipses        map[int]bool // Indices of the lines standing in for lines left out, or cut by MaxLines
+	Footer          bool         // Whether the last line says how many lines MaxLines cut
+}
+
+// HighlightTokens highlights the code like Highlight, then applies the options of the
+// style that change the text of the lines or their colors: line ranges, replacing the
+// lines left out with ellipses, and the maximum line count; redaction, with labels in
+// RedactionStyleLabel and block characters in either of the other styles; dimming of
+// lines that aren't highlighted; and glyph substitutions. The lines of a diff keep
+// their markers, and options that are only a matter of drawing, like fonts and
+// wrapping, are left to the caller.
+func HighlightTokens(code string, style *CodeStyle) (*HighlightedResult, error) {
+	return highlightLines(code, style, true)
+}
+
+// highlightLines highlights the code and applies the options of the style that change
+// the text or colors of its lines, redacting them if redact is set
+func highlightLines(code string, style *CodeStyle, redact bool) (*HighlightedResult, error) {
+	h, err := Highlight(code, style)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := h.Lines
+	if err := validateLineRanges(lines, style.LineRanges); err != nil {
+		return nil, err
+	}
+	if err := validateLineHighlightRanges(lines, style.LineRanges, style.LineHighlightRanges); err != nil {
+		return nil, err
+	}
+	lines, lineNumbers, ellipses := filterLines(lines, style.LineRanges, h.CommentColor)
+	lines, lineNumbers, footer := truncateLines(lines, lineNumbers, ellipses, style.MaxLines, h.CommentColor)
+	if rc := style.RedactionConfig; redact && rc != nil && rc.Enabled {
+		if rc.Style == RedactionStyleLabel {
+			lines = labelRedactions(rc, lines, h.CommentColor)
+		} else {
+			lines = blockRedactions(rc, lines)
+		}
+	}
+	if style.DimNonHighlighted {
+		lines = dimLines(lines, ellipses, h.BackgroundColor)
+	}
+	lines = substituteGlyphs(lines, style.GlyphSubstitutions)
+
+	result := &HighlightedResult{HighlightedCode: *h, LineNumbers: lineNumbers, Ellipses: ellipses, Footer: footer}
+	result.Lines = lines
+	return result, nil
+}
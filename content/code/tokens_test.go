@@ -0,0 +1,77 @@
+package code
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/content"
+)
+
+func TestHighlightTokens(t *testing.T) {
+	input := "package main\n\nconst id = \"id=42\"\n\nfunc main() {}\n\n// end\n"
+	style := DefaultRenderer(input).Style
+	style.RedactionConfig = &RedactionConfig{
+		Enabled:  true,
+		Style:    RedactionStyleBlock,
+		Patterns: []RedactionPattern{{regexp.MustCompile(`id=(\d+)`), "ID"}},
+	}
+
+	t.Run("as drawn", func(t *testing.T) {
+		result, err := HighlightTokens(input, style)
+		require.NoError(t, err)
+		h, err := Highlight(input, style)
+		require.NoError(t, err)
+
+		assert.Equal(t, h.BackgroundColor, result.BackgroundColor)
+		assert.Equal(t, h.LineNumberColor, result.LineNumberColor)
+		assert.Equal(t, h.HighlightColor, result.HighlightColor)
+		assert.Equal(t, "Go", result.Language)
+		assert.Equal(t, `const id = "id=██"`, getLineText(result.Lines[2]), "Redacted")
+		assert.Equal(t, `const id = "id=42"`, getLineText(h.Lines[2]), "Highlight itself is left alone")
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, result.LineNumbers)
+		assert.Empty(t, result.Ellipses)
+		assert.False(t, result.Footer)
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		labeled := *style
+		labeled.RedactionConfig = &RedactionConfig{Enabled: true, Style: RedactionStyleLabel, Patterns: style.RedactionConfig.Patterns}
+		result, err := HighlightTokens(input, &labeled)
+		require.NoError(t, err)
+		assert.Equal(t, `const id = "id=<REDACTED:id>"`, getLineText(result.Lines[2]))
+	})
+
+	t.Run("line ranges", func(t *testing.T) {
+		ranged := *style
+		ranged.LineRanges = []content.LineRange{{Start: 3, End: 3}, {Start: 5, End: 5}}
+		result, err := HighlightTokens(input, &ranged)
+		require.NoError(t, err)
+
+		var texts []string
+		for _, line := range result.Lines {
+			texts = append(texts, getLineText(line))
+		}
+		assert.Equal(t, []string{"...", `const id = "id=██"`, "...", "func main() {}", "..."}, texts)
+		assert.Equal(t, []int{2, 3, 4, 5, 6}, result.LineNumbers)
+		assert.Equal(t, map[int]bool{0: true, 2: true, 4: true}, result.Ellipses)
+		assert.Equal(t, chroma.Comment, result.Lines[0].Tokens[0].Type)
+	})
+
+	t.Run("max lines", func(t *testing.T) {
+		short := *style
+		short.MaxLines = 3
+		result, err := HighlightTokens(input, &short)
+		require.NoError(t, err)
+		require.Len(t, result.Lines, 4)
+		assert.True(t, result.Footer)
+		assert.True(t, strings.HasPrefix(getLineText(result.Lines[3]), "… 4 more"))
+		assert.Equal(t, 0, result.LineNumbers[3])
+	})
+
+	_, err := HighlightTokens(input, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package code
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/watzon/goshot/svg"
+	"golang.org/x/image/font"
+)
+
+// TruncationIndicator is how lines cut off at the right edge of the code are marked,
+// to make it clear they go on
+type TruncationIndicator int
+
+const (
+	TruncationNone     TruncationIndicator = iota // Lines are cut off without a mark
+	TruncationFade                                // The end of the line fades into the background
+	TruncationEllipsis                            // The line ends in an ellipsis
+)
+
+// truncationFadeColumns is how many characters wide the fade at the end of a
+// truncated line is
+const truncationFadeColumns = 4
+
+// truncation is where a line too wide for the code area is cut off
+type truncation struct {
+	cut   int // Where the text stops being drawn in full: the start of the fade or ellipsis
+	right int // Right edge of the code area, which the fade reaches the background at
+}
+
+// truncation returns where the wrapped line with the given characters is cut off, or
+// false if it fits or lines aren't marked when they're cut off
+func (l *codeLayout) truncation(config *CodeStyle, chars []placedChar) (truncation, bool) {
+	if config.WrapMode != WrapNone || config.TruncationIndicator == TruncationNone {
+		return truncation{}, false
+	}
+	left, right := config.PaddingLeft+l.lineNumberOffset, l.totalWidth-config.PaddingRight
+	overflows := false
+	for _, c := range chars {
+		overflows = overflows || c.x+c.width > right
+	}
+	if !overflows || right <= left {
+		return truncation{}, false
+	}
+
+	t := truncation{right: right}
+	if config.TruncationIndicator == TruncationFade {
+		t.cut = max(left, right-truncationFadeColumns*font.MeasureString(l.regularFace.Face, "0").Round())
+		return t, true
+	}
+
+	// The ellipsis takes the place of every character reaching into it
+	start := right - font.MeasureString(l.regularFace.Face, "…").Round()
+	t.cut = right
+	for _, c := range chars {
+		if c.x+c.width > start {
+			t.cut = min(t.cut, c.x)
+		}
+	}
+	t.cut = max(left, t.cut)
+	return t, true
+}
+
+// drawTruncation marks the truncated line drawn at y, where background is the image as
+// it was before any text was drawn on it
+func (l *codeLayout) drawTruncation(img, background *image.RGBA, config *CodeStyle, t truncation, y int) {
+	row := image.Rect(t.cut, y, l.totalWidth, y+l.lineHeight).Intersect(img.Bounds())
+	for py := row.Min.Y; py < row.Max.Y; py++ {
+		for px := row.Min.X; px < row.Max.X; px++ {
+			if config.TruncationIndicator == TruncationFade && px < t.right {
+				// Blended from the text to the background, in premultiplied colors
+				f := (float64(px-t.cut) + 0.5) / float64(t.right-t.cut)
+				from, to := img.RGBAAt(px, py), background.RGBAAt(px, py)
+				lerp := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*f + 0.5) }
+				img.SetRGBA(px, py, color.RGBA{lerp(from.R, to.R), lerp(from.G, to.G), lerp(from.B, to.B), lerp(from.A, to.A)})
+			} else {
+				img.SetRGBA(px, py, background.RGBAAt(px, py))
+			}
+		}
+	}
+	if config.TruncationIndicator == TruncationEllipsis {
+		drawText(img, l.regularFace.Face, "…", t.cut, y+l.metrics.Ascent.Round(), l.h.CommentColor, Token{Text: "…"})
+	}
+}
+
+// svgTruncation wraps the text of the truncated wrapped line i, drawn at y, in a mask
+// fading it out or a clip ending it in an ellipsis
+func (l *codeLayout) svgTruncation(config *CodeStyle, t truncation, i, y int, text string) string {
+	var b strings.Builder
+	id := fmt.Sprintf("goshot-truncated-%d", i)
+	if config.TruncationIndicator == TruncationFade {
+		fmt.Fprintf(&b, "<defs><linearGradient id=\"%[1]s-fade\" gradientUnits=\"userSpaceOnUse\" x1=\"%[2]d\" y1=\"0\" x2=\"%[3]d\" y2=\"0\">"+
+			"<stop offset=\"0\" stop-color=\"#fff\"/><stop offset=\"1\" stop-color=\"#fff\" stop-opacity=\"0\"/></linearGradient>"+
+			"<mask id=\"%[1]s\" maskUnits=\"userSpaceOnUse\" x=\"0\" y=\"%[4]d\" width=\"%[3]d\" height=\"%[5]d\">"+
+			"<rect x=\"0\" y=\"%[4]d\" width=\"%[3]d\" height=\"%[5]d\" fill=\"url(#%[1]s-fade)\"/></mask></defs>\n"+
+			"<g mask=\"url(#%[1]s)\">%[6]s</g>\n", id, t.cut, t.right, y, l.lineHeight, strings.TrimSuffix(text, "\n"))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "<defs><clipPath id=\"%[1]s\"><rect x=\"0\" y=\"%[2]d\" width=\"%[3]d\" height=\"%[4]d\"/></clipPath></defs>\n"+
+		"<g clip-path=\"url(#%[1]s)\">%[5]s</g>\n", id, y, t.cut, l.lineHeight, strings.TrimSuffix(text, "\n"))
+	fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\"%s>…</text>\n", t.cut, y+l.metrics.Ascent.Round(), svg.Paint("fill", l.h.CommentColor))
+	return b.String()
+}
@@ -3,10 +3,14 @@ package code
 import (
 	"fmt"
 	"image/color"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/alecthomas/chroma/v2"
+	"github.com/mattn/go-runewidth"
 	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/utils"
 	"golang.org/x/image/font"
 )
 
@@ -128,17 +132,18 @@ func getHighlightColor(style *chroma.Style) color.Color {
 	}
 }
 
+// isLight reports whether a chroma color looks light
 func isLight(c chroma.Colour) bool {
-	// Convert RGB values to 0-255 range
-	r := float64(c.Red())
-	g := float64(c.Green())
-	b := float64(c.Blue())
-
-	// Calculate perceived brightness (ITU-R BT.709)
-	brightness := (r*0.299 + g*0.587 + b*0.114)
+	return utils.IsLight(color.RGBA{R: c.Red(), G: c.Green(), B: c.Blue(), A: 255})
+}
 
-	// Consider the color light if brightness is greater than 128 (half of 255)
-	return brightness > 128
+// getTokenBackground returns the background a style gives a token, or nil if it only
+// inherits the background of the code block
+func getTokenBackground(style *chroma.Style, c chroma.Colour) color.Color {
+	if c == 0 || c == style.Get(chroma.Background).Background {
+		return nil
+	}
+	return color.RGBA{R: c.Red(), G: c.Green(), B: c.Blue(), A: 255}
 }
 
 func getColorFromChroma(style *chroma.Style, c chroma.Colour) color.Color {
@@ -199,6 +204,9 @@ func wrapTokens(tokens []Token, face font.Face, maxWidth, startX int) [][]Token
 	var currentLine []Token
 	currentWidth := startX
 
+	// Tokens broken at a space are replaced by what's left of them
+	tokens = slices.Clone(tokens)
+
 	for i := 0; i < len(tokens); i++ {
 		token := tokens[i]
 		tokenWidth := font.MeasureString(face, token.Text).Round()
@@ -206,6 +214,17 @@ func wrapTokens(tokens []Token, face font.Face, maxWidth, startX int) [][]Token
 		// Check if this token would exceed max width
 		if currentWidth+tokenWidth > maxWidth {
 			if len(currentLine) > 0 {
+				// Fill the line with as much of the token as fits before a space, and
+				// continue with the rest of it on the next line
+				if head, tail, ok := splitAtSpace(token, face, maxWidth-currentWidth); ok {
+					result = append(result, append(currentLine, head))
+					currentLine = nil
+					currentWidth = startX
+					tokens[i] = tail
+					i--
+					continue
+				}
+
 				// Before starting a new line, check if splitting the current token would
 				// allow the next token to fit on the same line
 				if i+1 < len(tokens) {
@@ -290,12 +309,7 @@ func splitToken(token Token, face font.Face, maxWidth int) []Token {
 
 		if numWords > 0 {
 			// We can fit at least one word
-			result = append(result, Token{
-				Text:   strings.TrimRight(text[:endPos], " "),
-				Color:  token.Color,
-				Bold:   token.Bold,
-				Italic: token.Italic,
-			})
+			result = append(result, tokenPart(token, strings.TrimRight(text[:endPos], " ")))
 			text = strings.TrimLeft(text[endPos:], " ")
 			continue
 		}
@@ -306,6 +320,13 @@ func splitToken(token Token, face font.Face, maxWidth int) []Token {
 			firstSpace = len(text)
 		}
 		word := text[:firstSpace]
+		if word == "" {
+			// The text starts with whitespace too wide to fit, which goes on its own
+			_, size := utf8.DecodeRuneInString(text)
+			result = append(result, tokenPart(token, text[:size]))
+			text = text[size:]
+			continue
+		}
 
 		// Binary search for the maximum characters that fit
 		low, high := 1, len(word)
@@ -321,21 +342,11 @@ func splitToken(token Token, face font.Face, maxWidth int) []Token {
 
 		if low > 0 {
 			// Only split if we can fit at least one character
-			result = append(result, Token{
-				Text:   word[:low],
-				Color:  token.Color,
-				Bold:   token.Bold,
-				Italic: token.Italic,
-			})
+			result = append(result, tokenPart(token, word[:low]))
 			text = word[low:] + text[firstSpace:]
 		} else {
 			// Emergency fallback: take at least one character
-			result = append(result, Token{
-				Text:   text[:1],
-				Color:  token.Color,
-				Bold:   token.Bold,
-				Italic: token.Italic,
-			})
+			result = append(result, tokenPart(token, text[:1]))
 			text = text[1:]
 		}
 	}
@@ -395,9 +406,23 @@ func validateLineHighlightRanges(lines []Line, lineRanges []content.LineRange, h
 	return nil
 }
 
+// columnWidth measures how many columns characters take up. East Asian wide characters
+// take up two, like in editors and terminals. It doesn't depend on the locale, so output
+// is the same everywhere.
+var columnWidth = &runewidth.Condition{StrictEmojiNeutral: true}
+
+// ExpandTabs replaces the tabs in text with spaces up to the next tab stop, like the
+// code renderer does, given the column the text starts at. It returns the expanded
+// text and the column it ends at.
+func ExpandTabs(text string, currentColumn, tabWidth int) (string, int) {
+	return expandTabs(text, currentColumn, tabWidth)
+}
+
+// expandTabs replaces the tabs in text with spaces up to the next tab stop, given the
+// column the text starts at. It returns the expanded text and the column it ends at.
 func expandTabs(text string, currentColumn, tabWidth int) (string, int) {
 	if !strings.Contains(text, "\t") {
-		return text, currentColumn + len(text)
+		return text, currentColumn + columnWidth.StringWidth(text)
 	}
 
 	var result strings.Builder
@@ -415,7 +440,7 @@ func expandTabs(text string, currentColumn, tabWidth int) (string, int) {
 			col += spaces
 		} else {
 			result.WriteRune(ch)
-			col++
+			col += columnWidth.RuneWidth(ch)
 		}
 	}
 
@@ -0,0 +1,92 @@
+package code
+
+import (
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+// WrapMode controls how lines wider than the available width are wrapped. The zero
+// value is WrapWord.
+type WrapMode int
+
+const (
+	WrapWord WrapMode = iota // Break at token boundaries or whitespace where possible
+	WrapChar                 // Break at whichever character reaches the edge
+	WrapNone                 // Never wrap
+)
+
+// wrapIndicator is shown in the gutter of continuation lines
+const wrapIndicator = "↪"
+
+// WrapLine splits the tokens of a line into lines no wider than maxWidth pixels, the
+// same way the code renderer wraps. A maxWidth of 0 or less never wraps.
+func WrapLine(tokens []Token, face font.Face, maxWidth int, mode WrapMode) [][]Token {
+	return wrapLine(tokens, face, maxWidth, mode)
+}
+
+// wrapLine splits the tokens of a line into lines no wider than maxWidth
+func wrapLine(tokens []Token, face font.Face, maxWidth int, mode WrapMode) [][]Token {
+	switch mode {
+	case WrapNone:
+		return [][]Token{tokens}
+	case WrapChar:
+		return wrapChars(tokens, face, maxWidth)
+	default:
+		return wrapTokens(tokens, face, maxWidth, 0)
+	}
+}
+
+// wrapChars splits tokens into lines at the character that would exceed maxWidth,
+// breaking tokens wherever needed
+func wrapChars(tokens []Token, face font.Face, maxWidth int) [][]Token {
+	if maxWidth <= 0 {
+		return [][]Token{tokens}
+	}
+
+	var result [][]Token
+	var line []Token
+	width := 0
+	for _, token := range tokens {
+		start := 0
+		for i, ch := range token.Text {
+			charWidth := font.MeasureString(face, string(ch)).Round()
+			// A character wider than a whole line still gets a line of its own
+			if width+charWidth > maxWidth && width > 0 {
+				if i > start {
+					line = append(line, tokenPart(token, token.Text[start:i]))
+				}
+				result = append(result, line)
+				line, width, start = nil, 0, i
+			}
+			width += charWidth
+		}
+		if start < len(token.Text) {
+			line = append(line, tokenPart(token, token.Text[start:]))
+		}
+	}
+
+	if len(line) > 0 || len(result) == 0 {
+		result = append(result, line)
+	}
+	return result
+}
+
+// splitAtSpace splits a token after the last space that leaves a part no wider than
+// maxWidth. The space stays at the end of the first part, so no characters are lost.
+func splitAtSpace(token Token, face font.Face, maxWidth int) (Token, Token, bool) {
+	text := token.Text
+	for i := strings.LastIndexByte(text, ' '); i >= 0; i = strings.LastIndexByte(text[:i], ' ') {
+		head := text[:i+1]
+		if i+1 < len(text) && strings.TrimSpace(head) != "" && font.MeasureString(face, head).Round() <= maxWidth {
+			return tokenPart(token, head), tokenPart(token, text[i+1:]), true
+		}
+	}
+	return Token{}, Token{}, false
+}
+
+// tokenPart returns a token with the style of token and the given text
+func tokenPart(token Token, text string) Token {
+	token.Text = text
+	return token
+}
@@ -0,0 +1,117 @@
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/fonts"
+	"golang.org/x/image/font"
+)
+
+func TestWrapLine(t *testing.T) {
+	style := DefaultRenderer("").Style
+	face, err := style.Font.GetFace(style.FontSize, &fonts.FontStyle{Weight: fonts.WeightRegular, Stretch: fonts.StretchNormal})
+	require.NoError(t, err)
+	defer face.Close()
+
+	charWidth := font.MeasureString(face.Face, "0").Round()
+	tokens := []Token{{Text: "call("}, {Text: "\"one two three\"", Bold: true}, {Text: ")"}}
+	maxWidth := 12 * charWidth
+
+	lineText := func(line []Token) string {
+		return getLineText(Line{Tokens: line})
+	}
+
+	t.Run("word", func(t *testing.T) {
+		lines := wrapLine(tokens, face.Face, maxWidth, WrapWord)
+		require.Len(t, lines, 2)
+		// The string token is broken after the space that still fits
+		assert.Equal(t, "call(\"one ", lineText(lines[0]))
+		assert.Equal(t, "two three\")", lineText(lines[1]))
+		assert.True(t, lines[1][0].Bold, "split tokens keep their style")
+	})
+
+	t.Run("char", func(t *testing.T) {
+		lines := wrapLine(tokens, face.Face, maxWidth, WrapChar)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "call(\"one tw", lineText(lines[0]))
+		assert.Equal(t, "o three\")", lineText(lines[1]))
+	})
+
+	t.Run("none", func(t *testing.T) {
+		lines := wrapLine(tokens, face.Face, maxWidth, WrapNone)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "call(\"one two three\")", lineText(lines[0]))
+	})
+
+	t.Run("leading whitespace wider than the line", func(t *testing.T) {
+		parts := splitToken(Token{Text: "   "}, face.Face, charWidth/2)
+		var texts []string
+		for _, part := range parts {
+			texts = append(texts, part.Text)
+		}
+		// The whitespace is split off one character at a time
+		assert.Equal(t, []string{" ", " ", " "}, texts)
+	})
+}
+
+func TestWrapIndicator(t *testing.T) {
+	src := "//" + strings.Repeat(" word", 40) + "\n"
+	r := DefaultRenderer(src).WithMaxWidth(300).WithWrapIndicator(true)
+
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+
+	require.Greater(t, len(l.wrappedLines), 1)
+	assert.Equal(t, "1", l.gutterLabel(r.Style, 0))
+	for i := 1; i < len(l.wrappedLines); i++ {
+		assert.Equal(t, wrapIndicator, l.gutterLabel(r.Style, i))
+	}
+}
+
+func TestWrapTabStops(t *testing.T) {
+	src := "\tif x {\t\treturn\t\"a\"\t+\t\"b\"\t+\t\"c\"\t+\t\"d\"\t}\n"
+	r := DefaultRenderer(src).WithLanguage("go").WithTabWidth(4).WithMaxWidth(260).WithMinWidth(0)
+
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.close()
+	require.Greater(t, len(l.wrappedLines), 1)
+
+	// The wrapped parts put together expand the same as the whole line
+	var joined strings.Builder
+	for i := range l.wrappedLines {
+		for _, text := range l.expandedTokens(r.Style, i) {
+			joined.WriteString(text)
+		}
+	}
+	want, _ := ExpandTabs(strings.TrimSuffix(src, "\n"), 0, 4)
+	assert.Equal(t, want, joined.String())
+
+	// Every part's text ends within the width measured for the code
+	right := r.Style.PaddingLeft + l.lineNumberOffset + l.codeWidth - r.Style.PaddingLeft - r.Style.PaddingRight
+	for i := range l.wrappedLines {
+		for _, c := range l.placeChars(r.Style, i) {
+			assert.LessOrEqual(t, c.x+c.width, right, "line %d", i)
+		}
+	}
+
+	t.Run("tabs after a wrap", func(t *testing.T) {
+		// Tokens that still have their tabs count tab stops from the start of the
+		// original line, not of the part
+		l.wrappedLines = [][]Token{{{Text: "abcde"}}, {{Text: "\tf"}}, {{Text: "\tg"}}}
+		l.locateWrappedLines(r.Style, []int{0, 0, 1})
+		assert.Equal(t, []string{"   f"}, l.expandedTokens(r.Style, 1))
+		assert.Equal(t, []string{"    g"}, l.expandedTokens(r.Style, 2))
+		assert.Equal(t, wrappedLineInfo{originalLineIdx: 0, startOffset: 5, startColumn: 5}, l.wrappedLineOffsets[1])
+
+		// Wide characters take up two columns but a single offset
+		l.wrappedLines = [][]Token{{{Text: "漢字"}}, {{Text: "\tx"}}}
+		l.locateWrappedLines(r.Style, []int{0, 0})
+		assert.Equal(t, wrappedLineInfo{originalLineIdx: 0, startOffset: 2, startColumn: 4}, l.wrappedLineOffsets[1])
+		assert.Equal(t, []string{"    x"}, l.expandedTokens(r.Style, 1))
+	})
+}
@@ -2,13 +2,70 @@ package content
 
 import (
 	"image"
+	"image/color"
+
+	"github.com/watzon/goshot/svg"
 )
 
 type Content interface {
 	Render() (image.Image, error)
 }
 
+// SVGContent is implemented by content that can also be rendered as SVG
+type SVGContent interface {
+	RenderSVG() (*svg.Fragment, error)
+}
+
+// Measurer is implemented by content that can report the size of its image without
+// drawing it
+type Measurer interface {
+	Measure() (width, height int, err error)
+}
+
+// CharLocator is implemented by content that can tell where a character of its text is
+// drawn, so that annotations can point at it
+type CharLocator interface {
+	// LocateChar returns the box of the character at the given line and column, both
+	// counted from 1, in the content's image
+	LocateChar(line, column int) (image.Rectangle, error)
+}
+
 type LineRange struct {
 	Start int
 	End   int
+	Color color.Color // Color of a highlighted range, nil for the theme's; other ranges ignore it
+}
+
+// Scaler is implemented by content that can be drawn larger, for high density displays
+type Scaler interface {
+	// Scaled returns a copy of the content drawn factor times as large, with its font
+	// size, padding and every other dimension multiplied by factor
+	Scaled(factor float64) Content
+}
+
+// The stages of a render reported to a ProgressFunc
+const (
+	StageHighlighting = "highlighting"
+	StageMeasuring    = "measuring"
+	StageDrawing      = "drawing"
+	StageCompositing  = "compositing"
+)
+
+// ProgressFunc is told the stage a render is at, and how far along the render is as a
+// whole, from 0 to 1
+type ProgressFunc func(stage string, fraction float64)
+
+// Report calls the function, unless it's nil
+func (p ProgressFunc) Report(stage string, fraction float64) {
+	if p != nil {
+		p(stage, fraction)
+	}
+}
+
+// ProgressReporter is implemented by content that can report its progress as it's
+// drawn, for long renders
+type ProgressReporter interface {
+	// RenderWithProgress renders the content like Render, reporting each of its stages
+	// to progress as it goes
+	RenderWithProgress(progress ProgressFunc) (image.Image, error)
 }
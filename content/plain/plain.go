@@ -0,0 +1,301 @@
+// Package plain renders text as is, without any syntax highlighting, for logs,
+// prose and anything else that doesn't need lexing.
+package plain
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/content/code"
+	"github.com/watzon/goshot/fonts"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Ensure PlainRenderer implements content.Content
+var _ content.Content = (*PlainRenderer)(nil)
+var _ content.Scaler = (*PlainRenderer)(nil)
+var _ content.Measurer = (*PlainRenderer)(nil)
+
+type PlainStyle struct {
+	Font              *fonts.Font   // The font to use
+	FontSize          float64       // The font size in points
+	LineHeight        float64       // The line height multiplier
+	TextColor         color.Color   // Color of the text
+	BackgroundColor   color.Color   // Color behind the text
+	LineNumberColor   color.Color   // Color of the line numbers
+	PaddingLeft       int           // Padding between the text and the left edge
+	PaddingRight      int           // Padding between the text and the right edge
+	PaddingTop        int           // Padding between the text and the top edge
+	PaddingBottom     int           // Padding between the text and the bottom edge
+	LineNumberPadding int           // Padding between line numbers and text
+	TabWidth          int           // Width of tab characters in spaces
+	MinWidth          int           // Minimum width in pixels (0 means no minimum)
+	MaxWidth          int           // Maximum width in pixels (0 means no limit)
+	WrapMode          code.WrapMode // How lines wider than the available width are wrapped
+	ShowLineNumbers   bool          // Whether to show line numbers
+}
+
+type PlainRenderer struct {
+	Text  string
+	Style *PlainStyle
+}
+
+func NewRenderer(text string, style *PlainStyle) *PlainRenderer {
+	return &PlainRenderer{
+		Text:  text,
+		Style: style,
+	}
+}
+
+func DefaultRenderer(text string) *PlainRenderer {
+	font, err := fonts.GetFallback(fonts.FallbackMono)
+	if err != nil {
+		panic(err)
+	}
+
+	return NewRenderer(text, &PlainStyle{
+		Font:              font,
+		FontSize:          12,
+		LineHeight:        1.0,
+		TextColor:         color.RGBA{R: 248, G: 248, B: 242, A: 255},
+		BackgroundColor:   color.RGBA{R: 39, G: 40, B: 34, A: 255},
+		LineNumberColor:   color.RGBA{R: 127, G: 127, B: 127, A: 255},
+		PaddingLeft:       10,
+		PaddingRight:      10,
+		PaddingTop:        10,
+		PaddingBottom:     10,
+		LineNumberPadding: 10,
+		TabWidth:          4,
+		MinWidth:          300,
+		MaxWidth:          900,
+		ShowLineNumbers:   false,
+	})
+}
+
+func (r *PlainRenderer) WithFont(font *fonts.Font) *PlainRenderer {
+	r.Style.Font = font
+	return r
+}
+
+func (r *PlainRenderer) WithFontName(name string, style *fonts.FontStyle) *PlainRenderer {
+	font, err := fonts.GetFont(name, style)
+	if err != nil {
+		panic(err)
+	}
+	return r.WithFont(font)
+}
+
+func (r *PlainRenderer) WithFontSize(size float64) *PlainRenderer {
+	r.Style.FontSize = size
+	return r
+}
+
+func (r *PlainRenderer) WithLineHeight(height float64) *PlainRenderer {
+	r.Style.LineHeight = height
+	return r
+}
+
+// WithColors sets the colors of the text, the background and the line numbers
+func (r *PlainRenderer) WithColors(text, background, lineNumbers color.Color) *PlainRenderer {
+	r.Style.TextColor = text
+	r.Style.BackgroundColor = background
+	r.Style.LineNumberColor = lineNumbers
+	return r
+}
+
+func (r *PlainRenderer) WithPadding(left, right, top, bottom int) *PlainRenderer {
+	r.Style.PaddingLeft = left
+	r.Style.PaddingRight = right
+	r.Style.PaddingTop = top
+	r.Style.PaddingBottom = bottom
+	return r
+}
+
+func (r *PlainRenderer) WithLineNumberPadding(padding int) *PlainRenderer {
+	r.Style.LineNumberPadding = padding
+	return r
+}
+
+func (r *PlainRenderer) WithTabWidth(width int) *PlainRenderer {
+	r.Style.TabWidth = width
+	return r
+}
+
+func (r *PlainRenderer) WithMinWidth(width int) *PlainRenderer {
+	r.Style.MinWidth = width
+	return r
+}
+
+func (r *PlainRenderer) WithMaxWidth(width int) *PlainRenderer {
+	r.Style.MaxWidth = width
+	return r
+}
+
+// WithWordWrap sets how lines wider than the maximum width are wrapped, like the
+// code renderer's option of the same name
+func (r *PlainRenderer) WithWordWrap(mode code.WrapMode) *PlainRenderer {
+	r.Style.WrapMode = mode
+	return r
+}
+
+func (r *PlainRenderer) WithLineNumbers(show bool) *PlainRenderer {
+	r.Style.ShowLineNumbers = show
+	return r
+}
+
+// Scaled implements the content.Scaler interface, returning a copy with its own style
+// whose font size, padding and widths are multiplied by factor
+func (r *PlainRenderer) Scaled(factor float64) content.Content {
+	style := *r.Style
+	scale := func(v int) int {
+		return int(math.Round(float64(v) * factor))
+	}
+	style.FontSize *= factor
+	style.PaddingLeft = scale(style.PaddingLeft)
+	style.PaddingRight = scale(style.PaddingRight)
+	style.PaddingTop = scale(style.PaddingTop)
+	style.PaddingBottom = scale(style.PaddingBottom)
+	style.LineNumberPadding = scale(style.LineNumberPadding)
+	style.MinWidth = scale(style.MinWidth)
+	style.MaxWidth = scale(style.MaxWidth)
+	return &PlainRenderer{Text: r.Text, Style: &style}
+}
+
+// plainLayout is the wrapped text along with its measurements
+type plainLayout struct {
+	face             *fonts.Face
+	lines            []string // The lines after wrapping
+	lineNumbers      []int    // Line number of each line, or 0 for continuations of wrapped lines
+	lineNumberOffset int      // Width of the line numbers and their padding
+	lineHeight       int
+	width            int
+	height           int
+}
+
+// layout wraps and measures the text without drawing it. The returned layout must be
+// closed to release its font face.
+func (r *PlainRenderer) layout() (*plainLayout, error) {
+	config := r.Style
+	face, err := config.Font.GetFace(config.FontSize, &fonts.FontStyle{
+		Weight:  fonts.WeightRegular,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l := &plainLayout{face: face}
+	text := strings.TrimSuffix(strings.ReplaceAll(r.Text, "\r\n", "\n"), "\n")
+	sourceLines := strings.Split(text, "\n")
+
+	if config.ShowLineNumbers {
+		digits := strings.Repeat("9", len(strconv.Itoa(len(sourceLines))))
+		l.lineNumberOffset = font.MeasureString(face.Face, digits).Round() + config.LineNumberPadding
+	}
+
+	// Wrap to whatever the maximum width leaves for the text
+	chrome := config.PaddingLeft + config.PaddingRight + l.lineNumberOffset
+	maxTextWidth := 0
+	if config.MaxWidth > 0 && config.WrapMode != code.WrapNone {
+		maxTextWidth = max(config.MaxWidth-chrome, 1)
+	}
+
+	textWidth := 0
+	for i, line := range sourceLines {
+		expanded, _ := code.ExpandTabs(line, 0, config.TabWidth)
+		wrapped := [][]code.Token{{}}
+		if expanded != "" {
+			wrapped = code.WrapLine([]code.Token{{Text: expanded}}, face.Face, maxTextWidth, config.WrapMode)
+		}
+
+		for j, tokens := range wrapped {
+			var b strings.Builder
+			for _, token := range tokens {
+				b.WriteString(token.Text)
+			}
+			l.lines = append(l.lines, b.String())
+			textWidth = max(textWidth, font.MeasureString(face.Face, b.String()).Round())
+
+			lineNumber := 0
+			if j == 0 {
+				lineNumber = i + 1
+			}
+			l.lineNumbers = append(l.lineNumbers, lineNumber)
+		}
+	}
+
+	metrics := face.Face.Metrics()
+	l.lineHeight = int(float64(metrics.Height.Round()) * config.LineHeight)
+	l.width = textWidth + chrome
+	if config.MinWidth > 0 && l.width < config.MinWidth {
+		l.width = config.MinWidth
+	}
+	if config.MaxWidth > 0 && l.width > config.MaxWidth && config.WrapMode != code.WrapNone {
+		l.width = config.MaxWidth
+	}
+	l.height = l.lineHeight*len(l.lines) + config.PaddingTop + config.PaddingBottom
+	return l, nil
+}
+
+// Measure implements the content.Measurer interface
+func (r *PlainRenderer) Measure() (width, height int, err error) {
+	l, err := r.layout()
+	if err != nil {
+		return 0, 0, err
+	}
+	l.face.Close()
+	return l.width, l.height, nil
+}
+
+// Render draws the text, wrapped to the maximum width
+func (r *PlainRenderer) Render() (image.Image, error) {
+	config := r.Style
+	l, err := r.layout()
+	if err != nil {
+		return nil, err
+	}
+	defer l.face.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	bgColor := config.BackgroundColor
+	if bgColor == nil {
+		bgColor = color.White
+	}
+	draw.Draw(img, img.Bounds(), image.NewUniform(bgColor), image.Point{}, draw.Src)
+
+	textColor := config.TextColor
+	if textColor == nil {
+		textColor = color.Black
+	}
+	lineNumberColor := config.LineNumberColor
+	if lineNumberColor == nil {
+		lineNumberColor = textColor
+	}
+
+	ascent := l.face.Face.Metrics().Ascent.Round()
+	d := &font.Drawer{Dst: img, Face: l.face.Face}
+	for i, line := range l.lines {
+		baseline := config.PaddingTop + i*l.lineHeight + ascent
+
+		// Line numbers are right aligned against their padding
+		if config.ShowLineNumbers && l.lineNumbers[i] > 0 {
+			label := strconv.Itoa(l.lineNumbers[i])
+			x := config.PaddingLeft + l.lineNumberOffset - config.LineNumberPadding - font.MeasureString(l.face.Face, label).Round()
+			d.Src = image.NewUniform(lineNumberColor)
+			d.Dot = fixed.P(x, baseline)
+			d.DrawString(label)
+		}
+
+		d.Src = image.NewUniform(textColor)
+		d.Dot = fixed.P(config.PaddingLeft+l.lineNumberOffset, baseline)
+		d.DrawString(line)
+	}
+
+	return img, nil
+}
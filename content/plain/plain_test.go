@@ -0,0 +1,48 @@
+package plain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/content/code"
+)
+
+func TestRender(t *testing.T) {
+	r := DefaultRenderer("first line\n\tindented\n\nlast\n")
+	img, err := r.Render()
+	require.NoError(t, err)
+
+	width, height, err := r.Measure()
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds().Dx(), width)
+	assert.Equal(t, img.Bounds().Dy(), height)
+
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.face.Close()
+	assert.Equal(t, []string{"first line", "    indented", "", "last"}, l.lines)
+	assert.Equal(t, 4*l.lineHeight+r.Style.PaddingTop+r.Style.PaddingBottom, height)
+}
+
+func TestWrapping(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 30)
+	r := DefaultRenderer(text).WithMaxWidth(300).WithLineNumbers(true)
+
+	l, err := r.layout()
+	require.NoError(t, err)
+	defer l.face.Close()
+
+	require.Greater(t, len(l.lines), 1)
+	assert.Equal(t, 300, l.width)
+	assert.Equal(t, 1, l.lineNumbers[0])
+	for _, n := range l.lineNumbers[1:] {
+		assert.Zero(t, n, "continuation lines have no line number")
+	}
+
+	r.WithWordWrap(code.WrapNone)
+	width, _, err := r.Measure()
+	require.NoError(t, err)
+	assert.Greater(t, width, 300, "lines aren't wrapped without a wrap mode")
+}
@@ -1,6 +1,7 @@
 package term
 
 import (
+	"image"
 	"image/color"
 	"log"
 	"strconv"
@@ -9,10 +10,27 @@ import (
 	"github.com/charmbracelet/x/ansi"
 )
 
+const (
+	// maxSequenceLength is the longest escape sequence the parser will decode.
+	// Longer ones are treated as unterminated.
+	maxSequenceLength = 64 * 1024
+
+	// tabWidth is the distance between tab stops
+	tabWidth = 8
+
+	// csiIgnoredBytes are the private marker and intermediate bytes that make a CSI
+	// sequence one the parser doesn't handle
+	csiIgnoredBytes = "<=>? !\"#$%&'()*+,-./"
+)
+
 type ANSIParser struct {
 	terminal *Terminal
 	parser   *ansi.Parser
 	state    byte
+	done     bool // Set once the rest of the input should be ignored
+
+	kitty       *kittyTransfer      // Kitty graphics image being sent in chunks
+	kittyImages map[int]image.Image // Kitty graphics images transmitted by id
 }
 
 func NewANSIParser(t *Terminal) *ANSIParser {
@@ -22,30 +40,78 @@ func NewANSIParser(t *Terminal) *ANSIParser {
 	}
 }
 
+// Parse feeds input to the terminal. It can be called repeatedly to write more
+// output to the same terminal.
 func (ap *ANSIParser) Parse(input []byte) {
-	defer ansi.PutParser(ap.parser)
+	if ap.parser == nil {
+		ap.parser = ansi.GetParser()
+	}
+	defer func() {
+		ansi.PutParser(ap.parser)
+		ap.parser = nil
+	}()
 
 	// Calculate the last usable line (accounting for bottom padding)
 	lastUsableLine := ap.terminal.Height - ap.terminal.PaddingBottom - 1
 
 	for len(input) > 0 {
 		// If we're beyond the last usable line, stop parsing
-		if ap.terminal.CursorY > lastUsableLine {
+		if ap.terminal.CursorY > lastUsableLine || ap.done {
 			break
 		}
 
-		seq, width, n, newState := ansi.DecodeSequence(input, ap.state, ap.parser)
+		// Images are decoded here rather than by the decoder, which would give up on
+		// sequences this long
+		if ap.state == ansi.NormalState {
+			if n := graphicsSequence(input); n > 0 {
+				ap.handleGraphics(input[:n])
+				input = input[n:]
+				continue
+			}
+		}
+
+		// Bound how far the decoder looks ahead so an unterminated string sequence
+		// doesn't make every decode scan the rest of the input
+		window := input
+		if len(window) > maxSequenceLength {
+			window = window[:maxSequenceLength]
+		}
+
+		seq, width, n, newState := ansi.DecodeSequence(window, ap.state, ap.parser)
 		if n == 0 {
 			input = input[1:]
 			continue
 		}
 
+		// The decoder ran out of input in the middle of a sequence
+		if newState != ansi.NormalState {
+			if isStringSequence(seq) {
+				// An OSC, DCS, APC, PM or SOS without a BEL or ST terminator. Its payload
+				// can't contain control characters, so it ends at the next one (usually
+				// the end of the line) and everything after that is still printed.
+				n = stringSequenceEnd(input)
+			}
+			// Anything else left unfinished at the end of the input is discarded
+			input = input[n:]
+			ap.state = ansi.NormalState
+			continue
+		}
+
 		if width > 0 {
 			r := []rune(string(seq))[0]
 			// Only set the cell if we're within bounds
+			if width > 1 && ap.terminal.Width > 0 && ap.terminal.CursorX+1 >= ap.terminal.Width {
+				// A wide character that doesn't fit at the end of the line wraps to the next one
+				ap.terminal.NewLine()
+			}
 			if ap.terminal.CursorY <= lastUsableLine {
-				ap.terminal.SetCell(ap.terminal.CursorX, ap.terminal.CursorY, r)
-				ap.terminal.CursorX++
+				if width > 1 {
+					ap.terminal.SetWideCell(ap.terminal.CursorX, ap.terminal.CursorY, r)
+					ap.terminal.CursorX += 2
+				} else {
+					ap.terminal.SetCell(ap.terminal.CursorX, ap.terminal.CursorY, r)
+					ap.terminal.CursorX++
+				}
 				if ap.terminal.Width > 0 && ap.terminal.CursorX >= ap.terminal.Width {
 					ap.terminal.NewLine()
 				}
@@ -66,14 +132,17 @@ func (ap *ANSIParser) handleControlSequence(seq []byte) {
 	if s == "\n" {
 		ap.terminal.NewLine()
 	} else if s == "\r" {
-		ap.terminal.CursorX = 0
+		ap.terminal.CursorX = ap.terminal.PaddingLeft
+	} else if s == "\t" {
+		ap.handleTab()
+	} else if s == "\b" {
+		ap.terminal.CursorX = max(ap.terminal.PaddingLeft, ap.terminal.CursorX-1)
 	} else {
 		switch prefix {
 		case "CSI":
 			ap.handleCSISequence(s)
 		case "OSC":
-			// Log or handle OSC sequences if needed
-			log.Println("Ignoring OSC sequence:", s)
+			ap.handleOSC(s)
 		case "DCS":
 			// Log or handle DCS sequences if needed
 			log.Println("Ignoring DCS sequence:", s)
@@ -81,7 +150,55 @@ func (ap *ANSIParser) handleControlSequence(seq []byte) {
 	}
 }
 
+// handleOSC handles an operating system command. Only OSC 8 hyperlinks change the
+// output; titles and the like are ignored.
+func (ap *ANSIParser) handleOSC(s string) {
+	// Strip the introducer and the BEL or ST terminator, in their 7 or 8-bit forms
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "\x1b]"), "\x9d")
+	for _, terminator := range []string{"\x07", "\x1b\\", "\x9c"} {
+		s = strings.TrimSuffix(s, terminator)
+	}
+
+	// OSC 8 ; params ; URI opens a link, and an empty URI closes it
+	parts := strings.SplitN(s, ";", 3)
+	if parts[0] != "8" {
+		return
+	}
+	if len(parts) < 3 {
+		log.Println("Invalid hyperlink sequence:", s)
+		return
+	}
+	ap.terminal.CurrLink = parts[2]
+}
+
+// handleTab moves the cursor to the next tab stop
+func (ap *ANSIParser) handleTab() {
+	column := ap.terminal.CursorX - ap.terminal.PaddingLeft
+	ap.terminal.CursorX = ap.terminal.PaddingLeft + (column/tabWidth+1)*tabWidth
+	if ap.terminal.Width > 0 {
+		ap.terminal.CursorX = min(ap.terminal.Width-ap.terminal.PaddingRight-1, ap.terminal.CursorX)
+	}
+}
+
 func (ap *ANSIParser) handleCSISequence(s string) {
+	// Handle the 8-bit CSI introducer the same way as ESC [
+	if strings.HasPrefix(s, "\x9b") {
+		s = "\x1b[" + s[1:]
+	}
+
+	// DEC private modes
+	if strings.HasPrefix(s, "\x1b[?") && (strings.HasSuffix(s, "h") || strings.HasSuffix(s, "l")) {
+		ap.handleDECMode(s)
+		return
+	}
+
+	// Sequences with a private marker or intermediate bytes (e.g. "\x1b[>4;1m" or
+	// "\x1b[1 A") are different controls that share a final byte with the ones
+	// handled below, so they're ignored rather than misinterpreted
+	if len(s) < 3 || strings.ContainsAny(s[2:len(s)-1], csiIgnoredBytes) {
+		return
+	}
+
 	if strings.HasSuffix(s, "m") {
 		ap.handleSGR(s)
 	} else if strings.HasSuffix(s, "G") {
@@ -96,20 +213,51 @@ func (ap *ANSIParser) handleCSISequence(s string) {
 		ap.handleCUF(s)
 	} else if strings.HasSuffix(s, "D") {
 		ap.handleCUB(s)
+	} else if strings.HasSuffix(s, "J") {
+		ap.handleED(s)
 	} else if strings.HasSuffix(s, "K") {
 		ap.handleEL(s)
 	}
 }
 
+// handleSGR sets the colors and attributes of the text that follows. Parameters may
+// have subparameters after colons, like "4:3" for a curly underline or "58:2::255:0:0"
+// for a red underline; extended colors can be given either way.
 func (ap *ANSIParser) handleSGR(s string) {
 	params := strings.TrimSuffix(strings.TrimPrefix(s, "\x1b["), "m")
 	paramSlice := strings.Split(params, ";")
 	for i := 0; i < len(paramSlice); i++ {
-		code, err := strconv.Atoi(paramSlice[i])
-		if err != nil {
-			log.Println("Invalid SGR parameter:", paramSlice[i])
-			continue
+		sub := strings.Split(paramSlice[i], ":")
+		code := 0 // An empty parameter, as in "\x1b[m", is 0
+		if sub[0] != "" {
+			var err error
+			code, err = strconv.Atoi(sub[0])
+			if err != nil {
+				log.Println("Invalid SGR parameter:", paramSlice[i])
+				continue
+			}
 		}
+
+		// Extended colors are followed by their own parameters, either as
+		// subparameters or as the next parameters
+		var extended color.Color
+		if code == 38 || code == 48 || code == 58 {
+			if len(sub) > 1 {
+				args := sub[1:]
+				if len(args) >= 5 && args[0] == "2" {
+					args = append(args[:1], args[2:]...) // Drop the color space
+				}
+				extended, _ = ap.extendedColor(args)
+			} else {
+				var n int
+				extended, n = ap.extendedColor(paramSlice[i+1:])
+				i += n
+			}
+			if extended == nil {
+				continue
+			}
+		}
+
 		switch {
 		case code == 0:
 			ap.terminal.CurrAttrs = Attributes{}
@@ -120,19 +268,41 @@ func (ap *ANSIParser) handleSGR(s string) {
 		case code == 3:
 			ap.terminal.CurrAttrs.Italic = true
 		case code == 4:
+			style := UnderlineSingle
+			if len(sub) > 1 {
+				switch sub[1] {
+				case "0":
+					ap.terminal.CurrAttrs.Underline = false
+					ap.terminal.CurrAttrs.UnderlineStyle = UnderlineSingle
+					continue
+				case "2":
+					style = UnderlineDouble
+				case "3":
+					style = UnderlineCurly
+				case "4":
+					style = UnderlineDotted
+				case "5":
+					style = UnderlineDashed
+				}
+			}
 			ap.terminal.CurrAttrs.Underline = true
+			ap.terminal.CurrAttrs.UnderlineStyle = style
 		case code == 5:
 			ap.terminal.CurrAttrs.Blink = true
 		case code == 7:
 			ap.terminal.CurrFg, ap.terminal.CurrBg = ap.terminal.CurrBg, ap.terminal.CurrFg
 		case code == 9:
 			ap.terminal.CurrAttrs.Strikethrough = true
+		case code == 21:
+			ap.terminal.CurrAttrs.Underline = true
+			ap.terminal.CurrAttrs.UnderlineStyle = UnderlineDouble
 		case code == 22:
 			ap.terminal.CurrAttrs.Bold = false
 		case code == 23:
 			ap.terminal.CurrAttrs.Italic = false
 		case code == 24:
 			ap.terminal.CurrAttrs.Underline = false
+			ap.terminal.CurrAttrs.UnderlineStyle = UnderlineSingle
 		case code == 25:
 			ap.terminal.CurrAttrs.Blink = false
 		case code == 27:
@@ -140,45 +310,55 @@ func (ap *ANSIParser) handleSGR(s string) {
 		case code == 29:
 			ap.terminal.CurrAttrs.Strikethrough = false
 		case code >= 30 && code <= 37:
-			ap.terminal.CurrFg = ansiColor(code-30, ap.terminal.Style)
+			ap.terminal.CurrFg = ansiColor(code-30, ap.terminal)
 		case code >= 40 && code <= 47:
-			ap.terminal.CurrBg = ansiColor(code-40, ap.terminal.Style)
+			ap.terminal.CurrBg = ansiColor(code-40, ap.terminal)
 		case code >= 90 && code <= 97:
-			ap.terminal.CurrFg = ansiBrightColor(code-90, ap.terminal.Style)
+			ap.terminal.CurrFg = ansiBrightColor(code-90, ap.terminal)
 		case code >= 100 && code <= 107:
-			ap.terminal.CurrBg = ansiBrightColor(code-100, ap.terminal.Style)
+			ap.terminal.CurrBg = ansiBrightColor(code-100, ap.terminal)
 		case code == 38:
-			if i+4 < len(paramSlice) && paramSlice[i+1] == "2" {
-				r, _ := strconv.Atoi(paramSlice[i+2])
-				g, _ := strconv.Atoi(paramSlice[i+3])
-				b, _ := strconv.Atoi(paramSlice[i+4])
-				ap.terminal.CurrFg = color.RGBA{uint8(r), uint8(g), uint8(b), 255}
-				i += 4
-			} else if i+2 < len(paramSlice) && paramSlice[i+1] == "5" {
-				colorNum, _ := strconv.Atoi(paramSlice[i+2])
-				ap.terminal.CurrFg = ap.get256Color(colorNum)
-				i += 2
-			}
+			ap.terminal.CurrFg = extended
 		case code == 48:
-			if i+4 < len(paramSlice) && paramSlice[i+1] == "2" {
-				r, _ := strconv.Atoi(paramSlice[i+2])
-				g, _ := strconv.Atoi(paramSlice[i+3])
-				b, _ := strconv.Atoi(paramSlice[i+4])
-				ap.terminal.CurrBg = color.RGBA{uint8(r), uint8(g), uint8(b), 255}
-				i += 4
-			} else if i+2 < len(paramSlice) && paramSlice[i+1] == "5" {
-				colorNum, _ := strconv.Atoi(paramSlice[i+2])
-				ap.terminal.CurrBg = ap.get256Color(colorNum)
-				i += 2
-			}
+			ap.terminal.CurrBg = extended
+		case code == 58:
+			ap.terminal.CurrAttrs.UnderlineColor = extended
 		case code == 39:
 			ap.terminal.CurrFg = ap.terminal.DefaultFg
 		case code == 49:
 			ap.terminal.CurrBg = ap.terminal.DefaultBg
+		case code == 59:
+			ap.terminal.CurrAttrs.UnderlineColor = nil
 		}
 	}
 }
 
+// extendedColor reads the color of an SGR 38, 48 or 58 from its arguments, "5;n" for
+// a color of the 256 color palette or "2;r;g;b" for a true color, and returns it with
+// the number of arguments used. The color is nil if the arguments are incomplete.
+func (ap *ANSIParser) extendedColor(args []string) (color.Color, int) {
+	if len(args) == 0 {
+		return nil, 0
+	}
+	switch args[0] {
+	case "2":
+		if len(args) < 4 {
+			return nil, len(args)
+		}
+		r, _ := strconv.Atoi(args[1])
+		g, _ := strconv.Atoi(args[2])
+		b, _ := strconv.Atoi(args[3])
+		return color.RGBA{uint8(r), uint8(g), uint8(b), 255}, 4
+	case "5":
+		if len(args) < 2 {
+			return nil, len(args)
+		}
+		colorNum, _ := strconv.Atoi(args[1])
+		return ap.get256Color(min(max(colorNum, 0), 255)), 2
+	}
+	return nil, 1
+}
+
 func (ap *ANSIParser) handleCHA(s string) {
 	n := 1
 	params := strings.TrimSuffix(strings.TrimPrefix(s, "\x1b["), "G")
@@ -216,7 +396,7 @@ func (ap *ANSIParser) handleCUP(s string) {
 			}
 		}
 	}
-	ap.terminal.CursorY = min(ap.terminal.Height-1, max(1, row))
+	ap.terminal.CursorY = min(ap.terminal.Height-ap.terminal.PaddingBottom-1, max(ap.terminal.PaddingTop, row-1+ap.terminal.PaddingTop))
 	ap.terminal.CursorX = min(ap.terminal.Width-ap.terminal.PaddingRight-1, max(ap.terminal.PaddingLeft, col-1+ap.terminal.PaddingLeft))
 }
 
@@ -231,7 +411,7 @@ func (ap *ANSIParser) handleCUU(s string) {
 			n = 1
 		}
 	}
-	ap.terminal.CursorY = max(1, ap.terminal.CursorY-n)
+	ap.terminal.CursorY = max(ap.terminal.PaddingTop, ap.terminal.CursorY-n)
 }
 
 func (ap *ANSIParser) handleCUD(s string) {
@@ -245,7 +425,7 @@ func (ap *ANSIParser) handleCUD(s string) {
 			n = 1
 		}
 	}
-	ap.terminal.CursorY = min(ap.terminal.Height-1, ap.terminal.CursorY+n)
+	ap.terminal.CursorY = min(ap.terminal.Height-ap.terminal.PaddingBottom-1, ap.terminal.CursorY+n)
 }
 
 func (ap *ANSIParser) handleCUF(s string) {
@@ -287,29 +467,67 @@ func (ap *ANSIParser) handleEL(s string) {
 			n = 0
 		}
 	}
+	t := ap.terminal
 	switch n {
 	case 0:
-		for x := ap.terminal.CursorX; x < len(ap.terminal.Cells[ap.terminal.CursorY]); x++ {
-			ap.terminal.Cells[ap.terminal.CursorY][x] = Cell{
-				Char:    ' ',
-				FgColor: ap.terminal.DefaultFg,
-				BgColor: ap.terminal.DefaultBg,
-			}
+		t.ClearCells(t.CursorY, t.CursorX, t.Width)
+	case 1:
+		t.ClearCells(t.CursorY, 0, t.CursorX+1)
+	case 2:
+		t.ClearCells(t.CursorY, 0, t.Width)
+	}
+}
+
+func (ap *ANSIParser) handleED(s string) {
+	params := strings.TrimSuffix(strings.TrimPrefix(s, "\x1b["), "J")
+	n := 0
+	if params != "" {
+		var err error
+		n, err = strconv.Atoi(params)
+		if err != nil {
+			log.Println("Invalid ED parameter:", params)
+			n = 0
+		}
+	}
+	t := ap.terminal
+	switch n {
+	case 0:
+		// From the cursor to the end of the screen
+		t.ClearCells(t.CursorY, t.CursorX, t.Width)
+		for y := t.CursorY + 1; y < len(t.Cells); y++ {
+			t.ClearCells(y, 0, t.Width)
 		}
 	case 1:
-		for x := 0; x <= ap.terminal.CursorX; x++ {
-			ap.terminal.Cells[ap.terminal.CursorY][x] = Cell{
-				Char:    ' ',
-				FgColor: ap.terminal.DefaultFg,
-				BgColor: ap.terminal.DefaultBg,
-			}
+		// From the start of the screen to the cursor
+		for y := 0; y < t.CursorY; y++ {
+			t.ClearCells(y, 0, t.Width)
 		}
-	case 2:
-		for x := range ap.terminal.Cells[ap.terminal.CursorY] {
-			ap.terminal.Cells[ap.terminal.CursorY][x] = Cell{
-				Char:    ' ',
-				FgColor: ap.terminal.DefaultFg,
-				BgColor: ap.terminal.DefaultBg,
+		t.ClearCells(t.CursorY, 0, t.CursorX+1)
+	case 2, 3:
+		// The whole screen. There's no scrollback, so clearing it (3) is the same.
+		t.Clear()
+	}
+}
+
+// handleDECMode handles setting (h) and resetting (l) DEC private modes
+func (ap *ANSIParser) handleDECMode(s string) {
+	set := strings.HasSuffix(s, "h")
+	params := s[len("\x1b[?") : len(s)-1]
+	for _, param := range strings.Split(params, ";") {
+		switch param {
+		case "25":
+			// Text cursor enable
+			ap.terminal.CursorHidden = !set
+		case "47", "1047", "1049":
+			// Alternate screen buffer
+			if set {
+				ap.terminal.EnterAltScreen()
+			} else if ap.terminal.KeepAltScreen && ap.terminal.InAltScreen() {
+				// Keep the program's last frame and ignore whatever follows it
+				ap.done = true
+				return
+			} else {
+				ap.terminal.ExitAltScreen()
 			}
 		}
 	}
@@ -317,9 +535,9 @@ func (ap *ANSIParser) handleEL(s string) {
 
 func (ap *ANSIParser) get256Color(colorNum int) color.Color {
 	if colorNum < 8 {
-		return ansiColor(colorNum, ap.terminal.Style)
+		return ansiColor(colorNum, ap.terminal)
 	} else if colorNum < 16 {
-		return ansiBrightColor(colorNum-8, ap.terminal.Style)
+		return ansiBrightColor(colorNum-8, ap.terminal)
 	} else if colorNum < 232 {
 		colorNum -= 16
 		b := colorNum % 6
@@ -0,0 +1,306 @@
+package term
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parse runs input through a parser on a 40x10 terminal with a one cell padding
+func parse(input string) *Terminal {
+	t := NewTerminal(&TermStyle{
+		Width:         40,
+		Height:        10,
+		PaddingLeft:   1,
+		PaddingRight:  1,
+		PaddingTop:    1,
+		PaddingBottom: 1,
+	}, GetTheme("Dracula"))
+	NewANSIParser(t).Parse([]byte(input))
+	return t
+}
+
+// rowText returns the text of the given content row, without padding and trailing spaces
+func rowText(t *Terminal, row int) string {
+	var sb strings.Builder
+	for _, cell := range t.Cells[t.PaddingTop+row][t.PaddingLeft : t.Width-t.PaddingRight] {
+		sb.WriteRune(cell.Char)
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+func TestParseMalformedSequences(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Expected text of the first rows
+	}{
+		{name: "terminated OSC", input: "\x1b]0;title\x07hello", want: []string{"hello"}},
+		{name: "OSC terminated by ST", input: "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", want: []string{"link"}},
+		{name: "unterminated OSC", input: "\x1b]0;title\nhello\nworld", want: []string{"", "hello", "world"}},
+		{name: "unterminated OSC at end of input", input: "hello\x1b]0;title", want: []string{"hello"}},
+		{name: "OSC interrupted by CSI", input: "\x1b]0;title\x1b[31mred", want: []string{"red"}},
+		{name: "unterminated 8-bit OSC", input: "\x9d0;title\nhello", want: []string{"", "hello"}},
+		{name: "unterminated DCS", input: "\x1bPq#0;2;0;0;0\nhello", want: []string{"", "hello"}},
+		{name: "unterminated APC", input: "\x1b_Gf=100\nhello", want: []string{"", "hello"}},
+		{name: "incomplete CSI at end of input", input: "hello\x1b[3", want: []string{"hello"}},
+		{name: "lone escape at end of input", input: "hello\x1b", want: []string{"hello"}},
+		{name: "CSI without final byte", input: "\x1b[\x1b[1mhello", want: []string{"hello"}},
+		{name: "huge parameter", input: "ab\x1b[99999999999999999999Dc", want: []string{"ac"}},
+		{name: "invalid UTF-8", input: "a\xffb", want: []string{"a�b"}},
+		{name: "carriage return", input: "hello\rJ", want: []string{"Jello"}},
+		{name: "backspace", input: "ab\bc", want: []string{"ac"}},
+		{name: "tab", input: "a\tb", want: []string{"a       b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := parse(tt.input)
+			for i, want := range tt.want {
+				assert.Equal(t, want, rowText(term, i), "row %d", i)
+			}
+		})
+	}
+}
+
+func TestParseIgnoresUnsupportedCSI(t *testing.T) {
+	// "\x1b[>4;1m" sets a keyboard mode; it must not be read as SGR 1 (bold)
+	term := parse("\x1b[>4;1mA\x1b[1 AB")
+	assert.Equal(t, "AB", rowText(term, 0))
+	assert.False(t, term.Cells[term.PaddingTop][term.PaddingLeft].Attrs.Bold)
+}
+
+func TestParse8BitCSI(t *testing.T) {
+	term := parse("\x9b31mred")
+	assert.Equal(t, "red", rowText(term, 0))
+	assert.Equal(t, term.Style.GetColor(1), term.Cells[term.PaddingTop][term.PaddingLeft].FgColor)
+}
+
+func TestParseLongUnterminatedOSC(t *testing.T) {
+	// An OSC longer than the decoder's look ahead is skipped up to its terminator
+	input := "\x1b]1337;File=inline=1:" + strings.Repeat("A", maxSequenceLength*2) + "\x07hello"
+	term := parse(input)
+	assert.Equal(t, "hello", rowText(term, 0))
+}
+
+func TestParseClearScreen(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "clear and redraw", input: "old line 1\nold line 2\x1b[2J\x1b[Hnew", want: []string{"new", ""}},
+		{name: "clear scrollback", input: "old\x1b[3J\x1b[1;1Hnew", want: []string{"new"}},
+		{name: "clear to end of screen", input: "line 1\nline 2\nline 3\x1b[2;3H\x1b[J", want: []string{"line 1", "li", ""}},
+		{name: "clear to start of screen", input: "line 1\nline 2\nline 3\x1b[2;3H\x1b[1J", want: []string{"", "   e 2", "line 3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := parse(tt.input)
+			for i, want := range tt.want {
+				assert.Equal(t, want, rowText(term, i), "row %d", i)
+			}
+		})
+	}
+}
+
+func TestParseClearScreenAutoSize(t *testing.T) {
+	term := NewTerminal(&TermStyle{Width: 40, Height: 10, AutoSize: true}, GetTheme("Dracula"))
+	NewANSIParser(term).Parse([]byte("a much longer line\nand another\x1b[2J\x1b[Hnew"))
+
+	// Only the content drawn after the clear determines the size
+	assert.Equal(t, 3, term.MaxX)
+	assert.Equal(t, 1, term.MaxY)
+}
+
+func TestParseAltScreen(t *testing.T) {
+	input := "$ top\n\x1b[?1049h\x1b[Hframe 1\x1b[2J\x1b[Hframe 2\x1b[?1049l$ done"
+
+	t.Run("returns to the main screen", func(t *testing.T) {
+		term := parse(input)
+		assert.Equal(t, "$ top", rowText(term, 0))
+		assert.Equal(t, "$ done", rowText(term, 1))
+	})
+
+	t.Run("keeps the last frame", func(t *testing.T) {
+		term := NewTerminal(&TermStyle{
+			Width:         40,
+			Height:        10,
+			PaddingLeft:   1,
+			PaddingRight:  1,
+			PaddingTop:    1,
+			PaddingBottom: 1,
+			KeepAltScreen: true,
+		}, GetTheme("Dracula"))
+		NewANSIParser(term).Parse([]byte(input))
+		assert.Equal(t, "frame 2", rowText(term, 0))
+		assert.Equal(t, "", rowText(term, 1))
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"hello world",
+		"\x1b[31mred\x1b[0m plain",
+		"\x1b[38;2;255;0;0mtrue\x1b[48;5;123mcolor",
+		"\x1b]0;title\x07text",
+		"\x1b]0;title\ntext",
+		"\x1b]8;;https://example.com\x1b\\link",
+		"\x1bPq#0;2;0;0;0\x1b\\",
+		"\x1bP0;1;0q\"1;1;4;12#1;2;100;0;0#1!4~-!4~$#2;1;120;50;100?@\x1b\\",
+		"\x1b_Ga=T,f=24,s=1,v=1;/wAA\x1b\\\x1b_Ga=T,m=1;AAAA\x1b\\\x1b_Gm=0;AAAA\x1b\\",
+		"\x1b[2J\x1b[H\x1b[10;20Hxy",
+		"main\x1b[?1049halt\x1b[1J\x1b[?1049lmain",
+		"\x1b[5A\x1b[3B\x1b[99C\x1b[99D\x1b[2K\x1b[1K\x1b[K",
+		"a\tb\bc\rd\n",
+		"\x9b1m\x9d0;x\x9c",
+		"日本語 😀 é",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		for _, autoSize := range []bool{false, true} {
+			term := NewTerminal(&TermStyle{
+				Width:         20,
+				Height:        5,
+				AutoSize:      autoSize,
+				PaddingLeft:   1,
+				PaddingRight:  1,
+				PaddingTop:    1,
+				PaddingBottom: 1,
+			}, GetTheme("Dracula"))
+			NewANSIParser(term).Parse(input)
+
+			require.GreaterOrEqual(t, term.CursorX, 0)
+			require.GreaterOrEqual(t, term.CursorY, 0)
+			for _, row := range term.Cells {
+				require.Len(t, row, term.Width)
+			}
+		}
+	})
+}
+
+func TestParseHyperlinks(t *testing.T) {
+	for name, input := range map[string]string{
+		"ST terminated":    "go \x1b]8;;https://example.com\x1b\\here\x1b]8;;\x1b\\ now",
+		"BEL terminated":   "go \x1b]8;id=1;https://example.com\x07here\x1b]8;;\x07 now",
+		"8-bit terminated": "go \x1b]8;;https://example.com\x9chere\x1b]8;;\x9c now",
+	} {
+		t.Run(name, func(t *testing.T) {
+			term := parse(input)
+			require.Equal(t, "go here now", rowText(term, 0))
+
+			row := term.Cells[term.PaddingTop][term.PaddingLeft:]
+			for i, cell := range row[:11] {
+				if i >= 3 && i < 7 {
+					assert.Equal(t, "https://example.com", cell.Link, "cell %d", i)
+				} else {
+					assert.Empty(t, cell.Link, "cell %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWideCharacters(t *testing.T) {
+	term := parse("日本|\n😀x")
+	row := term.Cells[term.PaddingTop][term.PaddingLeft:]
+
+	// Each wide character takes up its cell and an empty one after it
+	assert.Equal(t, '日', row[0].Char)
+	assert.True(t, row[0].IsWide)
+	assert.Equal(t, rune(0), row[1].Char)
+	assert.Equal(t, '本', row[2].Char)
+	assert.True(t, row[2].IsWide)
+	assert.Equal(t, '|', row[4].Char)
+	assert.False(t, row[4].IsWide)
+
+	row = term.Cells[term.PaddingTop+1][term.PaddingLeft:]
+	assert.Equal(t, '😀', row[0].Char)
+	assert.True(t, row[0].IsWide)
+	assert.Equal(t, 'x', row[2].Char)
+
+	t.Run("wraps when it doesn't fit", func(t *testing.T) {
+		// Only one cell is left on the row, so it's left empty
+		term := parse(strings.Repeat("a", 40) + "日")
+		assert.Equal(t, strings.Repeat("a", 40), rowText(term, 0))
+		assert.Equal(t, ' ', term.Cells[term.PaddingTop][term.Width-1].Char)
+		assert.Equal(t, '日', term.Cells[term.PaddingTop+1][term.PaddingLeft].Char)
+	})
+
+	t.Run("overwriting half erases the other", func(t *testing.T) {
+		term := parse("日本\r\x1b[1Cx")
+		row := term.Cells[term.PaddingTop][term.PaddingLeft:]
+		assert.Equal(t, ' ', row[0].Char)
+		assert.False(t, row[0].IsWide)
+		assert.Equal(t, 'x', row[1].Char)
+		assert.Equal(t, '本', row[2].Char)
+	})
+}
+
+func TestParseSGRColors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fg, bg color.Color
+	}{
+		{name: "256 colors", input: "\x1b[38;5;196;48;5;21mx", fg: color.RGBA{210, 0, 0, 255}, bg: color.RGBA{0, 0, 210, 255}},
+		{name: "256 colors with colons", input: "\x1b[38:5:196;48:5:21mx", fg: color.RGBA{210, 0, 0, 255}, bg: color.RGBA{0, 0, 210, 255}},
+		{name: "true color", input: "\x1b[38;2;1;2;3;48;2;4;5;6mx", fg: color.RGBA{1, 2, 3, 255}, bg: color.RGBA{4, 5, 6, 255}},
+		{name: "true color with a color space", input: "\x1b[38:2::1:2:3;48:2:0:4:5:6mx", fg: color.RGBA{1, 2, 3, 255}, bg: color.RGBA{4, 5, 6, 255}},
+		{name: "true color without a color space", input: "\x1b[38:2:1:2:3mx", fg: color.RGBA{1, 2, 3, 255}},
+		{name: "gray", input: "\x1b[38;5;232mx", fg: color.RGBA{8, 8, 8, 255}},
+		{name: "reset", input: "\x1b[38;5;196m\x1b[mx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := parse(tt.input)
+			cell := term.Cells[term.PaddingTop][term.PaddingLeft]
+			fg, bg := tt.fg, tt.bg
+			if fg == nil {
+				fg = term.DefaultFg
+			}
+			if bg == nil {
+				bg = term.DefaultBg
+			}
+			assert.Equal(t, fg, cell.FgColor)
+			assert.Equal(t, bg, cell.BgColor)
+		})
+	}
+}
+
+func TestParseUnderlines(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		underline bool
+		style     UnderlineStyle
+		color     color.Color
+	}{
+		{name: "single", input: "\x1b[4mx", underline: true, style: UnderlineSingle},
+		{name: "double", input: "\x1b[21mx", underline: true, style: UnderlineDouble},
+		{name: "curly", input: "\x1b[4:3mx", underline: true, style: UnderlineCurly},
+		{name: "dotted", input: "\x1b[4:4mx", underline: true, style: UnderlineDotted},
+		{name: "dashed", input: "\x1b[4:5mx", underline: true, style: UnderlineDashed},
+		{name: "off with a subparameter", input: "\x1b[4:3m\x1b[4:0mx"},
+		{name: "off", input: "\x1b[4:3m\x1b[24mx"},
+		{name: "256 color", input: "\x1b[4;58;5;196mx", underline: true, color: color.RGBA{210, 0, 0, 255}},
+		{name: "true color", input: "\x1b[4:3;58:2::255:0:0mx", underline: true, style: UnderlineCurly, color: color.RGBA{255, 0, 0, 255}},
+		{name: "default color", input: "\x1b[4;58;2;255;0;0m\x1b[59mx", underline: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := parse(tt.input)
+			attrs := term.Cells[term.PaddingTop][term.PaddingLeft].Attrs
+			assert.Equal(t, tt.underline, attrs.Underline)
+			assert.Equal(t, tt.style, attrs.UnderlineStyle)
+			assert.Equal(t, tt.color, attrs.UnderlineColor)
+		})
+	}
+}
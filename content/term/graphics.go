@@ -0,0 +1,458 @@
+package term
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// maxGraphicsLength is the longest Sixel or Kitty graphics sequence the parser
+	// will decode, as images are much longer than other escape sequences
+	maxGraphicsLength = 64 * 1024 * 1024
+
+	// maxImageSize is the largest width or height of an inline image, in pixels
+	maxImageSize = 4096
+
+	// defaultCellWidth and defaultCellHeight are the size of a cell in pixels that
+	// images are laid out with when the terminal doesn't set one
+	defaultCellWidth  = 8
+	defaultCellHeight = 16
+)
+
+// sixelPalette is the VT340's default color palette, in percent of red, green and
+// blue, which Sixel images start with
+var sixelPalette = [16][3]int{
+	{0, 0, 0}, {20, 20, 80}, {80, 13, 13}, {20, 80, 20},
+	{80, 20, 80}, {20, 80, 80}, {80, 80, 20}, {53, 53, 53},
+	{26, 26, 26}, {33, 33, 60}, {60, 26, 26}, {33, 60, 33},
+	{60, 33, 60}, {33, 60, 60}, {60, 60, 33}, {80, 80, 80},
+}
+
+// kittyTransfer is an image sent with the Kitty graphics protocol in several chunks,
+// collected until the last one arrives
+type kittyTransfer struct {
+	control map[string]string // Keys of the first chunk
+	payload []byte            // Base64 data of the chunks so far
+}
+
+// graphicsSequence returns the length of the Sixel or Kitty graphics sequence at the
+// start of input, or 0 if there's none or it isn't terminated. Only the 7-bit
+// introducers and ST terminator are recognized, as the 8-bit ones would be read as
+// UTF-8.
+func graphicsSequence(input []byte) int {
+	if len(input) < 3 || input[0] != ansi.ESC {
+		return 0
+	}
+	switch input[1] {
+	case 'P':
+		// A DCS whose final byte is q, after numeric parameters, is a Sixel image
+		i := 2
+		for i < len(input) && (input[i] >= '0' && input[i] <= '9' || input[i] == ';') {
+			i++
+		}
+		if i == len(input) || input[i] != 'q' {
+			return 0
+		}
+	case '_':
+		if input[2] != 'G' {
+			return 0
+		}
+	default:
+		return 0
+	}
+
+	window := input[:min(len(input), maxGraphicsLength)]
+	end := bytes.IndexByte(window[2:], ansi.ESC)
+	if end < 0 || 2+end+1 >= len(window) || window[2+end+1] != '\\' {
+		return 0 // Interrupted or unterminated, which the usual handling takes care of
+	}
+	return 2 + end + 2
+}
+
+// handleGraphics draws the Sixel image or handles the Kitty graphics command seq,
+// a whole sequence found by graphicsSequence
+func (ap *ANSIParser) handleGraphics(seq []byte) {
+	body := seq[2 : len(seq)-2]
+	if seq[1] == 'P' {
+		img, err := decodeSixel(body)
+		if err != nil {
+			log.Println("Invalid Sixel image:", err)
+			return
+		}
+		t := ap.terminal
+		x := t.CursorX
+		_, rows := t.PlaceImage(img, img.Bounds().Dx(), img.Bounds().Dy())
+		// Like on a VT340, the cursor ends up on the last row of the image, where the
+		// image started
+		t.CursorX = x
+		t.CursorY += rows - 1
+		return
+	}
+	ap.handleKitty(body[1:])
+}
+
+// handleKitty handles a command of the Kitty graphics protocol, without the
+// introducer. Images sent directly in PNG, RGB or RGBA format can be displayed as
+// they're transmitted (a=T), or transmitted (a=t) and then displayed by id (a=p);
+// deleting (a=d) removes every image. Images in files or shared memory are ignored.
+func (ap *ANSIParser) handleKitty(s []byte) {
+	rawControl, payload, _ := bytes.Cut(s, []byte(";"))
+	control := make(map[string]string)
+	for _, kv := range strings.Split(string(rawControl), ",") {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			control[key] = value
+		}
+	}
+
+	// Chunks after the first only say whether more follow
+	if ap.kitty != nil {
+		ap.kitty.payload = append(ap.kitty.payload, payload...)
+		if control["m"] == "1" {
+			return
+		}
+		control, payload = ap.kitty.control, ap.kitty.payload
+		ap.kitty = nil
+	} else if control["m"] == "1" {
+		ap.kitty = &kittyTransfer{control: control, payload: append([]byte(nil), payload...)}
+		return
+	}
+
+	switch action := control["a"]; action {
+	case "", "t", "T":
+		img, err := decodeKittyImage(control, payload)
+		if err != nil {
+			log.Println("Invalid Kitty graphics image:", err)
+			return
+		}
+		if id := kittyInt(control, "i"); id != 0 {
+			if ap.kittyImages == nil {
+				ap.kittyImages = make(map[int]image.Image)
+			}
+			ap.kittyImages[id] = img
+		}
+		if action == "T" {
+			ap.placeKittyImage(img, control)
+		}
+	case "p":
+		img, ok := ap.kittyImages[kittyInt(control, "i")]
+		if !ok {
+			log.Println("Kitty graphics image not found:", control["i"])
+			return
+		}
+		ap.placeKittyImage(img, control)
+	case "d":
+		if d := control["d"]; d == "" || d == "a" || d == "A" {
+			ap.terminal.Images = nil
+		}
+	}
+}
+
+// placeKittyImage displays an image at the cursor, cropped to the x, y, w and h keys
+// of the command and stretched over c columns and r rows if they're given, up to
+// maxImageSize pixels
+func (ap *ANSIParser) placeKittyImage(img image.Image, control map[string]string) {
+	bounds := img.Bounds()
+	crop := image.Rect(kittyInt(control, "x"), kittyInt(control, "y"), bounds.Dx(), bounds.Dy())
+	if w := kittyInt(control, "w"); w > 0 {
+		crop.Max.X = crop.Min.X + w
+	}
+	if h := kittyInt(control, "h"); h > 0 {
+		crop.Max.Y = crop.Min.Y + h
+	}
+	crop = crop.Add(bounds.Min).Intersect(bounds)
+	if crop.Empty() {
+		return
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		img = sub.SubImage(crop)
+	}
+
+	t := ap.terminal
+	cellWidth, cellHeight := t.cellSize()
+	width, height := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	columns, rows := kittyInt(control, "c"), kittyInt(control, "r")
+	switch {
+	case columns > 0 && rows > 0:
+		width, height = float64(columns)*cellWidth, float64(rows)*cellHeight
+	case columns > 0:
+		width, height = float64(columns)*cellWidth, height*float64(columns)*cellWidth/width
+	case rows > 0:
+		width, height = width*float64(rows)*cellHeight/height, float64(rows)*cellHeight
+	}
+
+	// Images stretched over more cells than that are shrunk to fit, keeping their
+	// aspect ratio, so that they can't make the renderer scale them to any size
+	if width > maxImageSize || height > maxImageSize {
+		scale := math.Min(maxImageSize/width, maxImageSize/height)
+		width, height = width*scale, height*scale
+	}
+
+	columns, rows = t.PlaceImage(img, max(1, int(math.Round(width))), max(1, int(math.Round(height))))
+	if control["C"] == "1" {
+		return
+	}
+	// The cursor moves past the last column of the image, on its last row
+	t.CursorX += columns
+	t.CursorY += rows - 1
+	if t.Width > 0 && t.CursorX >= t.Width {
+		t.NewLine()
+	}
+}
+
+// kittyInt returns the number of a key of a Kitty graphics command, or 0
+func kittyInt(control map[string]string, key string) int {
+	n, _ := strconv.Atoi(control[key])
+	return n
+}
+
+// decodeKittyImage decodes the image of a Kitty graphics transmission
+func decodeKittyImage(control map[string]string, payload []byte) (image.Image, error) {
+	if medium := control["t"]; medium != "" && medium != "d" {
+		return nil, fmt.Errorf("images sent by file or shared memory (t=%s) aren't supported", medium)
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(string(payload), "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data: %v", err)
+	}
+	if control["o"] == "z" {
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid compressed data: %v", err)
+		}
+		if data, err = io.ReadAll(io.LimitReader(zr, 4*maxImageSize*maxImageSize+1)); err != nil {
+			return nil, fmt.Errorf("invalid compressed data: %v", err)
+		}
+	}
+
+	format := control["f"]
+	if format == "100" {
+		// The decoder allocates the image from the size in the header, so the size is
+		// checked before any pixels are decoded
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PNG data: %v", err)
+		}
+		if config.Width > maxImageSize || config.Height > maxImageSize {
+			return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PNG data: %v", err)
+		}
+		return img, nil
+	}
+
+	depth := 4
+	switch format {
+	case "", "32":
+	case "24":
+		depth = 3
+	default:
+		return nil, fmt.Errorf("unknown format f=%s", format)
+	}
+	width, height := kittyInt(control, "s"), kittyInt(control, "v")
+	if width <= 0 || height <= 0 || width > maxImageSize || height > maxImageSize {
+		return nil, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+	if len(data) != width*height*depth {
+		return nil, fmt.Errorf("expected %d bytes of pixels, got %d", width*height*depth, len(data))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		copy(img.Pix[i*4:i*4+3], data[i*depth:i*depth+3])
+		img.Pix[i*4+3] = 255
+		if depth == 4 {
+			img.Pix[i*4+3] = data[i*4+3]
+		}
+	}
+	return img, nil
+}
+
+// decodeSixel decodes a Sixel image, given the body of its DCS sequence: the
+// parameters, the q and the Sixel data. Pixels that aren't drawn are transparent, and
+// every pixel is drawn square, whatever aspect ratio the image asks for.
+func decodeSixel(data []byte) (image.Image, error) {
+	start := bytes.IndexByte(data, 'q') + 1
+
+	var palette [256]color.RGBA
+	for i, c := range sixelPalette {
+		palette[i] = sixelColor(c[0], c[1], c[2])
+	}
+	for i := len(sixelPalette); i < len(palette); i++ {
+		palette[i] = color.RGBA{A: 255}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 0, 0))
+	width, height := 0, 0 // Size of the image, as drawn or declared
+	current := palette[0]
+	x, y := 0, 0
+
+	// grow makes room for a sixel of n pixels wide at x, y
+	grow := func(n int) error {
+		w, h := x+n, y+6
+		if w > maxImageSize || h > maxImageSize {
+			return fmt.Errorf("image is larger than %dx%d pixels", maxImageSize, maxImageSize)
+		}
+		if b := img.Bounds(); w > b.Dx() || h > b.Dy() {
+			bigger := image.NewRGBA(image.Rect(0, 0, max(w, min(2*b.Dx(), maxImageSize)), max(h, min(2*b.Dy(), maxImageSize))))
+			draw.Draw(bigger, b, img, image.Point{}, draw.Src)
+			img = bigger
+		}
+		return nil
+	}
+
+	for i := start; i < len(data); {
+		c := data[i]
+		i++
+		switch {
+		case c == '"':
+			// Raster attributes: aspect ratio numerator and denominator, width, height
+			var params []int
+			params, i = sixelParams(data, i)
+			if len(params) >= 4 {
+				width = max(width, min(params[2], maxImageSize))
+				height = max(height, min(params[3], maxImageSize))
+			}
+		case c == '#':
+			// Select a color, defining it first if there are more parameters
+			var params []int
+			params, i = sixelParams(data, i)
+			if len(params) == 0 || params[0] < 0 || params[0] >= len(palette) {
+				continue
+			}
+			if len(params) >= 5 {
+				switch params[1] {
+				case 1:
+					palette[params[0]] = sixelHLS(params[2], params[3], params[4])
+				case 2:
+					palette[params[0]] = sixelColor(params[2], params[3], params[4])
+				}
+			}
+			current = palette[params[0]]
+		case c == '!':
+			// Repeat the next sixel
+			var params []int
+			params, i = sixelParams(data, i)
+			if i == len(data) || data[i] < '?' || data[i] > '~' || len(params) == 0 {
+				continue
+			}
+			n := max(1, params[0])
+			if err := grow(n); err != nil {
+				return nil, err
+			}
+			height = max(height, drawSixel(img, x, y, n, data[i]-'?', current))
+			x += n
+			width = max(width, x)
+			i++
+		case c >= '?' && c <= '~':
+			if err := grow(1); err != nil {
+				return nil, err
+			}
+			height = max(height, drawSixel(img, x, y, 1, c-'?', current))
+			x++
+			width = max(width, x)
+		case c == '$':
+			x = 0
+		case c == '-':
+			x = 0
+			y += 6
+		}
+	}
+
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(out, out.Bounds(), img, image.Point{}, draw.Src)
+	return out, nil
+}
+
+// drawSixel draws the pixels set in the six bits of a sixel, n times from x, y, and
+// returns the bottom of the lowest one
+func drawSixel(img *image.RGBA, x, y, n int, bits byte, c color.RGBA) (bottom int) {
+	for bit := 0; bit < 6; bit++ {
+		if bits&(1<<bit) == 0 {
+			continue
+		}
+		for dx := 0; dx < n; dx++ {
+			img.SetRGBA(x+dx, y+bit, c)
+		}
+		bottom = y + bit + 1
+	}
+	return bottom
+}
+
+// sixelParams reads the numeric parameters starting at data[i], separated by
+// semicolons, and returns them with the index of the byte after them
+func sixelParams(data []byte, i int) ([]int, int) {
+	params := []int{0}
+	for ; i < len(data); i++ {
+		c := data[i]
+		if c == ';' {
+			params = append(params, 0)
+		} else if c >= '0' && c <= '9' {
+			last := &params[len(params)-1]
+			*last = min(*last*10+int(c-'0'), math.MaxInt32)
+		} else {
+			break
+		}
+	}
+	return params, i
+}
+
+// sixelColor returns the color of a Sixel RGB definition, in percent
+func sixelColor(r, g, b int) color.RGBA {
+	percent := func(v int) uint8 {
+		return uint8(math.Round(float64(min(max(v, 0), 100)) * 255 / 100))
+	}
+	return color.RGBA{R: percent(r), G: percent(g), B: percent(b), A: 255}
+}
+
+// sixelHLS returns the color of a Sixel HLS definition, with the hue in degrees and
+// the lightness and saturation in percent. Sixel hues start at blue rather than red.
+func sixelHLS(hue, lightness, saturation int) color.RGBA {
+	h := math.Mod(float64(hue+240), 360) / 360
+	l := float64(min(max(lightness, 0), 100)) / 100
+	s := float64(min(max(saturation, 0), 100)) / 100
+	if s == 0 {
+		v := int(math.Round(l * 100))
+		return sixelColor(v, v, v)
+	}
+
+	q := l * (1 + s)
+	if l >= 0.5 {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	channel := func(t float64) int {
+		t = math.Mod(t+1, 1)
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 1.0/2:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return int(math.Round(v * 100))
+	}
+	return sixelColor(channel(h+1.0/3), channel(h), channel(h-1.0/3))
+}
@@ -0,0 +1,249 @@
+package term
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redSixel is a 4x12 pixel Sixel image in red, with raster attributes
+const redSixel = "\x1bP0;1;0q\"1;1;4;12#1;2;100;0;0#1!4~-!4~\x1b\\"
+
+// kittyRGBA returns a Kitty graphics command showing a width by height image filled
+// with c, sent in chunks of at most chunk bytes of base64
+func kittyRGBA(width, height int, c color.RGBA, chunk int) string {
+	pixels := bytes.Repeat([]byte{c.R, c.G, c.B, c.A}, width*height)
+	data := base64.StdEncoding.EncodeToString(pixels)
+
+	var sb strings.Builder
+	first := true
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		more := "0"
+		if n < len(data) {
+			more = "1"
+		}
+		if first {
+			sb.WriteString("\x1b_Ga=T,f=32,s=" + strconv.Itoa(width) + ",v=" + strconv.Itoa(height) + ",m=" + more + ";")
+			first = false
+		} else {
+			sb.WriteString("\x1b_Gm=" + more + ";")
+		}
+		sb.WriteString(data[:n] + "\x1b\\")
+		data = data[n:]
+	}
+	return sb.String()
+}
+
+func TestDecodeSixel(t *testing.T) {
+	img, err := decodeSixel([]byte(redSixel[2 : len(redSixel)-2]))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 12), img.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, img.At(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, img.At(3, 11))
+
+	t.Run("palette and transparency", func(t *testing.T) {
+		// Color 2 of the default palette, with only the top pixel of the sixel set
+		img, err := decodeSixel([]byte("q#2@"))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 1, 1), img.Bounds())
+		assert.Equal(t, sixelColor(80, 13, 13), img.At(0, 0))
+
+		img, err = decodeSixel([]byte("q#2A"))
+		require.NoError(t, err)
+		_, _, _, a := img.At(0, 0).RGBA()
+		assert.Zero(t, a)
+		assert.Equal(t, sixelColor(80, 13, 13), img.At(0, 1))
+	})
+
+	t.Run("HLS colors", func(t *testing.T) {
+		// Sixel hues start at blue, so red is at 120 degrees
+		assert.Equal(t, color.RGBA{R: 255, A: 255}, sixelHLS(120, 50, 100))
+		assert.Equal(t, color.RGBA{B: 255, A: 255}, sixelHLS(0, 50, 100))
+	})
+
+	t.Run("empty image", func(t *testing.T) {
+		_, err := decodeSixel([]byte("q"))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSixel(t *testing.T) {
+	term := parse("ab" + redSixel + "c\nd")
+	require.Len(t, term.Images, 1)
+	p := term.Images[0]
+
+	// Cells are 8x16 pixels by default, so the image covers one of them
+	assert.Equal(t, term.PaddingLeft+2, p.X)
+	assert.Equal(t, term.PaddingTop, p.Y)
+	assert.Equal(t, 4, p.Width)
+	assert.Equal(t, 12, p.Height)
+
+	// The cursor is left where the image started, on its last row
+	assert.Equal(t, "abc", rowText(term, 0))
+	assert.Equal(t, "d", rowText(term, 1))
+
+	t.Run("taller than a row", func(t *testing.T) {
+		term := parse("\x1bPq#1;2;100;0;0!4~-!4~-!4~-!4~\x1b\\x")
+		require.Len(t, term.Images, 1)
+		assert.Equal(t, 24, term.Images[0].Height)
+		assert.Equal(t, "", rowText(term, 0))
+		assert.Equal(t, "x", rowText(term, 1))
+	})
+
+	t.Run("cleared with the screen", func(t *testing.T) {
+		term := parse(redSixel + "\x1b[2J")
+		assert.Empty(t, term.Images)
+	})
+}
+
+func TestParseKittyGraphics(t *testing.T) {
+	green := color.RGBA{G: 255, A: 255}
+
+	t.Run("RGBA", func(t *testing.T) {
+		term := parse(kittyRGBA(16, 16, green, 4096) + "x")
+		require.Len(t, term.Images, 1)
+		p := term.Images[0]
+		assert.Equal(t, 16, p.Width)
+		assert.Equal(t, 16, p.Height)
+		assert.Equal(t, green, color.RGBAModel.Convert(p.Image.At(15, 15)))
+
+		// The cursor moves past the image's two columns
+		assert.Equal(t, "  x", rowText(term, 0))
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		term := parse(kittyRGBA(16, 16, green, 64))
+		require.Len(t, term.Images, 1)
+		assert.Equal(t, green, color.RGBAModel.Convert(term.Images[0].Image.At(8, 8)))
+	})
+
+	t.Run("PNG by id", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		img.Set(1, 2, green)
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, img))
+		data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+		term := parse("\x1b_Ga=t,f=100,i=7;" + data + "\x1b\\\x1b_Ga=p,i=7,c=3,r=2\x1b\\")
+		require.Len(t, term.Images, 1)
+		p := term.Images[0]
+		assert.Equal(t, 24, p.Width)
+		assert.Equal(t, 32, p.Height)
+		assert.Equal(t, green, color.RGBAModel.Convert(p.Image.At(1, 2)))
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		term := parse(kittyRGBA(8, 8, green, 4096) + "\x1b_Ga=d\x1b\\")
+		assert.Empty(t, term.Images)
+	})
+
+	t.Run("PNG claiming to be too large", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+		data := buf.Bytes()
+
+		// The IHDR chunk follows the signature, with the size and then its CRC
+		ihdr := data[8 : 8+8+13]
+		binary.BigEndian.PutUint32(ihdr[8:], 20000)
+		binary.BigEndian.PutUint32(ihdr[12:], 20000)
+		binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(ihdr[4:]))
+
+		control := map[string]string{"f": "100"}
+		payload := []byte(base64.StdEncoding.EncodeToString(data))
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := decodeKittyImage(control, payload)
+		runtime.ReadMemStats(&after)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "20000x20000")
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20), "Rejected before the pixels are allocated")
+
+		term := parse("\x1b_Ga=T,f=100;" + string(payload) + "\x1b\\ok")
+		assert.Empty(t, term.Images)
+		assert.Equal(t, "ok", rowText(term, 0))
+	})
+
+	t.Run("stretched over too many cells", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			keys          string
+			width, height int
+		}{
+			{name: "rows", keys: "r=100000000", width: maxImageSize, height: maxImageSize},
+			{name: "columns", keys: "c=200000", width: maxImageSize, height: maxImageSize},
+			{name: "both", keys: "c=200000,r=2", width: maxImageSize, height: 1},
+			{name: "tall", keys: "c=1,r=100000000", width: 1, height: maxImageSize},
+			{name: "within the limit", keys: "c=4,r=2", width: 32, height: 32},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// A single RGB pixel
+				term := parse("\x1b_Ga=T,f=24,s=1,v=1," + tt.keys + ";AAAA\x1b\\")
+				require.Len(t, term.Images, 1)
+				assert.Equal(t, tt.width, term.Images[0].Width)
+				assert.Equal(t, tt.height, term.Images[0].Height)
+			})
+		}
+
+		// The terminal only grows to fit the shrunk image
+		r := DefaultRenderer([]byte("\x1b_Ga=T,f=24,s=1,v=1,c=200000;AAAA\x1b\\")).WithAutoSize()
+		img, err := r.Render()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, img.Bounds().Dx(), 2*maxImageSize)
+		assert.LessOrEqual(t, img.Bounds().Dy(), 2*maxImageSize)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		term := parse("\x1b_Ga=T,f=32,s=4,v=4;AAAA\x1b\\ok")
+		assert.Empty(t, term.Images)
+		assert.Equal(t, "ok", rowText(term, 0))
+	})
+}
+
+func TestRenderInlineImage(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	r := DefaultRenderer([]byte("\x1b[2C" + kittyRGBA(20, 20, red, 4096)))
+	img, err := r.Render()
+	require.NoError(t, err)
+
+	face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+	require.NoError(t, err)
+	defer face.Close()
+	charWidth := r.charWidth(face)
+
+	// The image starts at the third column of the content
+	left := (r.Style.PaddingLeft+2)*charWidth + r.Style.PaddingLeft
+	top := r.Style.PaddingTop*r.rowHeight() + r.Style.PaddingTop
+	assert.Equal(t, red, img.At(left, top))
+	assert.Equal(t, red, img.At(left+19, top+19))
+	assert.NotEqual(t, red, img.At(left+20, top))
+	assert.NotEqual(t, red, img.At(left-1, top))
+
+	t.Run("scaled", func(t *testing.T) {
+		s := r.Scaled(2).(*TermRenderer)
+		img, err := s.Render()
+		require.NoError(t, err)
+		face, err := s.Style.Font.GetFace(s.Style.FontSize, &s.Style.Font.Style)
+		require.NoError(t, err)
+		defer face.Close()
+
+		// The image is enlarged along with the text
+		left := (s.Style.PaddingLeft+2)*s.charWidth(face) + s.Style.PaddingLeft
+		top := s.Style.PaddingTop*s.rowHeight() + s.Style.PaddingTop
+		assert.Equal(t, red, img.At(left+1, top+1))
+		assert.Equal(t, red, img.At(left+38, top+38))
+		assert.NotEqual(t, red, img.At(left+41, top+10))
+	})
+}
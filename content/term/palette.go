@@ -0,0 +1,84 @@
+package term
+
+import "image/color"
+
+// Palette is the 16 ANSI colors of a terminal: black, red, green, yellow, blue,
+// magenta, cyan and white, then their bright versions. Output colored with SGR 30-37,
+// 40-47, 90-97 and 100-107, or with the first 16 colors of the 256 color palette, is
+// drawn in them. A nil color is left to the theme.
+type Palette [16]color.Color
+
+// The default palettes of some terminals, for captures that look like them
+var (
+	// PaletteXterm is the palette of xterm
+	PaletteXterm = hexPalette(
+		"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+		"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+	)
+	// PaletteVGA is the palette of the VGA text mode, as on the Linux console
+	PaletteVGA = hexPalette(
+		"#000000", "#aa0000", "#00aa00", "#aa5500", "#0000aa", "#aa00aa", "#00aaaa", "#aaaaaa",
+		"#555555", "#ff5555", "#55ff55", "#ffff55", "#5555ff", "#ff55ff", "#55ffff", "#ffffff",
+	)
+	// PaletteTerminalApp is the palette of macOS' Terminal
+	PaletteTerminalApp = hexPalette(
+		"#000000", "#990000", "#00a600", "#999900", "#0000b2", "#b200b2", "#00a6b2", "#bfbfbf",
+		"#666666", "#e50000", "#00d900", "#e5e500", "#0000ff", "#e500e5", "#00e5e5", "#e5e5e5",
+	)
+	// PaletteCampbell is the palette of Windows Terminal and the Windows console
+	PaletteCampbell = hexPalette(
+		"#0c0c0c", "#c50f1f", "#13a10e", "#c19c00", "#0037da", "#881798", "#3a96dd", "#cccccc",
+		"#767676", "#e74856", "#16c60c", "#f9f1a5", "#3b78ff", "#b4009e", "#61d6d6", "#f2f2f2",
+	)
+)
+
+// builtinPalettes are the palettes found by GetPalette ahead of the themes
+var builtinPalettes = map[string]Palette{
+	"xterm":        PaletteXterm,
+	"vga":          PaletteVGA,
+	"terminal-app": PaletteTerminalApp,
+	"campbell":     PaletteCampbell,
+}
+
+// hexPalette returns the palette of the 16 colors, written like #rrggbb
+func hexPalette(colors ...string) Palette {
+	var p Palette
+	for i, hex := range colors {
+		p[i] = parseHexColor(hex)
+	}
+	return p
+}
+
+// Palette returns the 16 ANSI colors of the theme
+func (t *Theme) Palette() Palette {
+	var p Palette
+	for i := range p {
+		p[i] = t.GetColor(i)
+	}
+	return p
+}
+
+// GetPalette returns a palette by name: one of "xterm", "vga", "terminal-app" and
+// "campbell", or the colors of a theme, like "dracula" or "solarized-dark". It returns
+// false if there's no palette or theme of that name.
+func GetPalette(name string) (Palette, bool) {
+	if p, ok := builtinPalettes[normalizeThemeName(name)]; ok {
+		return p, true
+	}
+	if theme := GetTheme(name); theme != nil {
+		return theme.Palette(), true
+	}
+	return Palette{}, false
+}
+
+// paletteColor returns the ANSI color of the given index (0-15), from the palette set
+// with WithPalette or else the theme
+func (t *Terminal) paletteColor(index int) color.Color {
+	if t.Palette != nil && t.Palette[index] != nil {
+		return t.Palette[index]
+	}
+	if t.Style == nil {
+		return nil
+	}
+	return t.Style.GetColor(index)
+}
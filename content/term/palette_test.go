@@ -0,0 +1,49 @@
+package term
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPalette(t *testing.T) {
+	theme := GetTheme("Dracula")
+	palette := PaletteXterm
+	palette[2] = nil // Left to the theme
+
+	parse := func(input string) Cell {
+		term := NewTerminal(&TermStyle{Width: 10, Height: 2, Palette: &palette}, theme)
+		NewANSIParser(term).Parse([]byte(input))
+		return term.Cells[0][0]
+	}
+
+	assert.Equal(t, color.RGBA{0xcd, 0, 0, 0xff}, parse("\x1b[31mx").FgColor)
+	assert.Equal(t, color.RGBA{0, 0, 0xee, 0xff}, parse("\x1b[44mx").BgColor)
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, parse("\x1b[91mx").FgColor)
+	assert.Equal(t, color.RGBA{0x5c, 0x5c, 0xff, 0xff}, parse("\x1b[104mx").BgColor)
+	assert.Equal(t, color.RGBA{0xcd, 0, 0, 0xff}, parse("\x1b[38;5;1mx").FgColor, "The first 16 of the 256 colors")
+	assert.Equal(t, theme.GetColor(2), parse("\x1b[32mx").FgColor)
+	assert.Equal(t, theme.GetForeground(), parse("x").FgColor, "The theme keeps the default colors")
+
+	t.Run("by name", func(t *testing.T) {
+		p, ok := GetPalette("Terminal App")
+		require.True(t, ok)
+		assert.Equal(t, PaletteTerminalApp, p)
+
+		p, ok = GetPalette("solarized-dark")
+		require.True(t, ok)
+		assert.Equal(t, GetTheme("Solarized Dark").GetColor(9), p[9])
+
+		_, ok = GetPalette("no-such-palette")
+		assert.False(t, ok)
+	})
+
+	t.Run("renderer", func(t *testing.T) {
+		r := DefaultRenderer([]byte("\x1b[31mx")).WithPalette(PaletteVGA)
+		require.NotNil(t, r.Style.Palette)
+		assert.Equal(t, PaletteVGA, *r.Style.Palette)
+		assert.Equal(t, r.Style.Palette, r.Scaled(2).(*TermRenderer).Style.Palette)
+	})
+}
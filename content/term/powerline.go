@@ -0,0 +1,82 @@
+package term
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/vector"
+)
+
+// Powerline separator code points
+const (
+	powerlineRightSolid = '\ue0b0' // Solid right-pointing triangle
+	powerlineRightThin  = '\ue0b1' // Thin right-pointing chevron
+	powerlineLeftSolid  = '\ue0b2' // Solid left-pointing triangle
+	powerlineLeftThin   = '\ue0b3' // Thin left-pointing chevron
+	powerlineRightRound = '\ue0b4' // Solid right half circle
+	powerlineLeftRound  = '\ue0b6' // Solid left half circle
+)
+
+// circleRatio is the control point ratio for approximating a quarter circle with a cubic curve
+const circleRatio = 0.5523
+
+// isPowerlineGlyph reports whether the rune is a Powerline separator drawn as a shape
+func isPowerlineGlyph(r rune) bool {
+	switch r {
+	case powerlineRightSolid, powerlineRightThin, powerlineLeftSolid, powerlineLeftThin, powerlineRightRound, powerlineLeftRound:
+		return true
+	}
+	return false
+}
+
+// drawPowerlineGlyph draws Powerline separators as shapes filling the whole cell. Font
+// glyphs for these rarely match the cell height exactly, which leaves visible gaps or
+// overlaps between prompt segments. It returns false if the rune isn't a separator.
+func drawPowerlineGlyph(img *image.RGBA, r rune, rect image.Rectangle, col color.Color) bool {
+	if !isPowerlineGlyph(r) {
+		return false
+	}
+
+	w, h := float32(rect.Dx()), float32(rect.Dy())
+	stroke := max(1, rect.Dx()/8)
+	t := float32(stroke)
+	z := vector.NewRasterizer(rect.Dx(), rect.Dy())
+
+	switch r {
+	case powerlineRightSolid:
+		z.MoveTo(0, 0)
+		z.LineTo(w, h/2)
+		z.LineTo(0, h)
+	case powerlineLeftSolid:
+		z.MoveTo(w, 0)
+		z.LineTo(0, h/2)
+		z.LineTo(w, h)
+	case powerlineRightThin:
+		z.MoveTo(0, 0)
+		z.LineTo(t, 0)
+		z.LineTo(w, h/2)
+		z.LineTo(t, h)
+		z.LineTo(0, h)
+		z.LineTo(w-t, h/2)
+	case powerlineLeftThin:
+		z.MoveTo(w, 0)
+		z.LineTo(w-t, 0)
+		z.LineTo(0, h/2)
+		z.LineTo(w-t, h)
+		z.LineTo(w, h)
+		z.LineTo(t, h/2)
+	case powerlineRightRound:
+		k := float32(circleRatio)
+		z.MoveTo(0, 0)
+		z.CubeTo(k*w, 0, w, h/2-k*h/2, w, h/2)
+		z.CubeTo(w, h/2+k*h/2, k*w, h, 0, h)
+	case powerlineLeftRound:
+		k := float32(circleRatio)
+		z.MoveTo(w, 0)
+		z.CubeTo(w-k*w, 0, 0, h/2-k*h/2, 0, h/2)
+		z.CubeTo(0, h/2+k*h/2, w-k*w, h, w, h)
+	}
+	z.ClosePath()
+	z.Draw(img, rect, image.NewUniform(col), image.Point{})
+	return true
+}
@@ -0,0 +1,128 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// powerlineBranch is the Powerline symbol for a version control branch
+const powerlineBranch = '\ue0a0'
+
+// PromptContext is what a prompt can show about the command that was run and where
+type PromptContext struct {
+	User      string // Name of the user running the command
+	Host      string // Name of the machine
+	Cwd       string // Directory the command was run in
+	Command   string // The command and its arguments
+	GitBranch string // Branch checked out in the Git repository containing Cwd, if any
+}
+
+// NewPromptContext returns the context of a command run by the current user in the
+// working directory. Whatever can't be looked up is left empty.
+func NewPromptContext(command string) PromptContext {
+	ctx := PromptContext{Command: command}
+	if u, err := user.Current(); err == nil {
+		ctx.User = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		ctx.Host = host
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		ctx.Cwd = cwd
+		ctx.GitBranch = GitBranch(cwd)
+	}
+	return ctx
+}
+
+// GitBranch returns the branch checked out in the Git repository containing dir, the
+// start of the commit's hash if the HEAD is detached, or "" outside of a repository.
+// Git itself isn't run; the repository's HEAD is read directly.
+func GitBranch(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitDir); err == nil {
+			if !info.IsDir() {
+				// Worktrees and submodules have a file pointing at the real directory
+				data, err := os.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+				if !ok {
+					return ""
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(dir, target)
+				}
+				gitDir = target
+			}
+			head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			ref := strings.TrimSpace(string(head))
+			if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+				return branch
+			}
+			return ref[:min(len(ref), 7)]
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// PowerlinePrompt is a two line prompt in the style of Powerline shell themes: the
+// user and host, the directory and the Git branch as colored segments joined by Nerd
+// Font separators, then the command after a chevron. Segments with nothing to show
+// are left out. The segments use the theme's blue, cyan and green, and the home
+// directory is shortened to ~.
+func PowerlinePrompt(ctx PromptContext) string {
+	type segment struct {
+		text  string
+		color int // ANSI color of the background
+	}
+	var segments []segment
+	if ctx.User != "" || ctx.Host != "" {
+		text := ctx.User
+		if ctx.Host != "" {
+			text = strings.TrimPrefix(text+"@"+ctx.Host, "@")
+		}
+		segments = append(segments, segment{text, 4})
+	}
+	if ctx.Cwd != "" {
+		cwd := ctx.Cwd
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			if rel, err := filepath.Rel(home, cwd); err == nil && !strings.HasPrefix(rel, "..") {
+				cwd = filepath.Join("~", rel)
+				if rel == "." {
+					cwd = "~"
+				}
+			}
+		}
+		segments = append(segments, segment{cwd, 6})
+	}
+	if ctx.GitBranch != "" {
+		segments = append(segments, segment{string(powerlineBranch) + " " + ctx.GitBranch, 2})
+	}
+
+	var sb strings.Builder
+	for i, seg := range segments {
+		if i > 0 {
+			// The separator points from the last segment's color into this one's
+			fmt.Fprintf(&sb, "\x1b[3%d;4%dm%c", segments[i-1].color, seg.color, powerlineRightSolid)
+		}
+		fmt.Fprintf(&sb, "\x1b[30;4%dm %s ", seg.color, seg.text)
+	}
+	if len(segments) > 0 {
+		fmt.Fprintf(&sb, "\x1b[0;3%dm%c\x1b[0m\n", segments[len(segments)-1].color, powerlineRightSolid)
+	}
+	sb.WriteString("\x1b[1;35m❯\x1b[0m " + ctx.Command)
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package term
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitBranch(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "repo", ".git"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "repo", "src", "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "repo", ".git", "HEAD"), []byte("ref: refs/heads/feature/prompt\n"), 0644))
+
+	assert.Equal(t, "feature/prompt", GitBranch(filepath.Join(root, "repo")))
+	assert.Equal(t, "feature/prompt", GitBranch(filepath.Join(root, "repo", "src", "pkg")))
+
+	t.Run("detached", func(t *testing.T) {
+		dir := filepath.Join(root, "detached")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("0123456789abcdef0123456789abcdef01234567\n"), 0644))
+		assert.Equal(t, "0123456", GitBranch(dir))
+	})
+
+	t.Run("worktree", func(t *testing.T) {
+		dir := filepath.Join(root, "worktree")
+		gitDir := filepath.Join(root, "repo", ".git", "worktrees", "wt")
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.MkdirAll(gitDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/wt\n"), 0644))
+		assert.Equal(t, "wt", GitBranch(dir))
+	})
+
+	t.Run("outside of a repository", func(t *testing.T) {
+		assert.Empty(t, GitBranch(filepath.Join(root)))
+	})
+}
+
+func TestPowerlinePrompt(t *testing.T) {
+	prompt := PowerlinePrompt(PromptContext{
+		User:      "ada",
+		Host:      "engine",
+		Cwd:       "/srv/app",
+		Command:   "make test",
+		GitBranch: "main",
+	})
+	term := parse(prompt)
+	assert.Equal(t, " ada@engine  /srv/app   main ", rowText(term, 0))
+	assert.Equal(t, "❯ make test", rowText(term, 1))
+
+	// Each segment has its own background, and the separators blend them together
+	row := term.Cells[term.PaddingTop][term.PaddingLeft:]
+	assert.Equal(t, term.Style.GetColor(4), row[0].BgColor)
+	sep := strings.Index(rowText(term, 0), "")
+	sep = len([]rune(rowText(term, 0)[:sep]))
+	assert.Equal(t, term.Style.GetColor(4), row[sep].FgColor)
+	assert.Equal(t, term.Style.GetColor(6), row[sep].BgColor)
+
+	t.Run("empty segments", func(t *testing.T) {
+		term := parse(PowerlinePrompt(PromptContext{Command: "ls"}))
+		assert.Equal(t, "❯ ls", rowText(term, 0))
+	})
+}
+
+func TestRenderWithPrompt(t *testing.T) {
+	ctx := PromptContext{User: "ada", Host: "engine", Cwd: "/srv/app"}
+	r := DefaultRenderer([]byte("ok\n")).
+		WithArgs([]string{"make", "test"}).
+		WithShowPrompt().
+		WithPrompt(PowerlinePrompt).
+		WithPromptContext(ctx)
+
+	term := r.parse()
+	assert.Equal(t, " ada@engine  /srv/app ", rowText(term, 0))
+	assert.Equal(t, "❯ make test", rowText(term, 1))
+	assert.Equal(t, "ok", rowText(term, 2))
+
+	// The prompt takes over from the prompt function
+	r.WithPrompt(func(ctx PromptContext) string { return ctx.User + ": " + ctx.Command })
+	term = r.parse()
+	assert.Equal(t, "ada: make test", rowText(term, 0))
+}
@@ -3,18 +3,23 @@ package term
 import (
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
+	"math"
 	"strings"
 
 	"github.com/charmbracelet/x/term"
 	"github.com/watzon/goshot/content"
 	"github.com/watzon/goshot/fonts"
+	xdraw "golang.org/x/image/draw"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 )
 
 // Ensure TermRenderer implements content.Content
 var _ content.Content = (*TermRenderer)(nil)
+var _ content.Measurer = (*TermRenderer)(nil)
+var _ content.Scaler = (*TermRenderer)(nil)
 
 func NewRenderer(input []byte, style *TermStyle) *TermRenderer {
 	// Get the theme once during renderer creation
@@ -82,6 +87,14 @@ func (r *TermRenderer) WithFontSize(size float64) *TermRenderer {
 	return r
 }
 
+// WithFontVariations sets the values of the axes variable fonts are drawn at, by tag,
+// such as {"wght": 500} for a medium weight. Bold text is drawn heavier than the
+// weight given. Fonts that aren't variable are drawn as usual.
+func (r *TermRenderer) WithFontVariations(variations map[string]float32) *TermRenderer {
+	r.Style.FontVariations = variations
+	return r
+}
+
 func (r *TermRenderer) WithLineHeight(height float64) *TermRenderer {
 	r.Style.LineHeight = height
 	return r
@@ -136,6 +149,80 @@ func (r *TermRenderer) WithPromptFunc(promptFunc func(command string) string) *T
 	return r
 }
 
+// WithPrompt sets the function that writes the prompt from its context, such as
+// PowerlinePrompt. It's used instead of the function set with WithPromptFunc. The
+// context is gathered from the environment unless it's set with WithPromptContext.
+func (r *TermRenderer) WithPrompt(prompt func(ctx PromptContext) string) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.Prompt = prompt
+	return r
+}
+
+// WithPromptContext sets the user, host, directory and Git branch shown by the prompt
+// set with WithPrompt, rather than looking them up. The command is always Args.
+func (r *TermRenderer) WithPromptContext(ctx PromptContext) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.PromptContext = &ctx
+	return r
+}
+
+func (r *TermRenderer) WithLigatures(enabled bool) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.Ligatures = enabled
+	return r
+}
+
+// WithDimBlink draws blinking text halfway between its color and its background, so
+// it stands out from the text around it in a still image. Otherwise it's drawn like
+// any other text.
+func (r *TermRenderer) WithDimBlink(enabled bool) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.DimBlink = enabled
+	return r
+}
+
+// WithCursor draws a cursor where the output leaves it, like at the end of a prompt,
+// in the given color or the theme's cursor color if col is nil. A block cursor shows
+// the character under it in inverted colors. The cursor isn't drawn if the output
+// hides it, and CursorNone, the default, draws none.
+func (r *TermRenderer) WithCursor(style CursorStyle, col color.Color) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.Cursor = style
+	r.Style.CursorColor = col
+	return r
+}
+
+// WithPalette draws the 16 ANSI colors in the given palette rather than the theme's,
+// to match a real terminal's colors, like PaletteXterm or a palette from GetPalette.
+// The theme still gives the default foreground, background and cursor colors.
+func (r *TermRenderer) WithPalette(palette Palette) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.Palette = &palette
+	return r
+}
+
+// WithKeepAltScreen renders the last frame drawn on the alternate screen by full screen
+// programs, instead of switching back to the main screen when they exit
+func (r *TermRenderer) WithKeepAltScreen(enabled bool) *TermRenderer {
+	if r.Style == nil {
+		r.Style = &TermStyle{}
+	}
+	r.Style.KeepAltScreen = enabled
+	return r
+}
+
 func (r *TermRenderer) WithArgs(args []string) *TermRenderer {
 	if r.Style == nil {
 		r.Style = &TermStyle{}
@@ -144,44 +231,191 @@ func (r *TermRenderer) WithArgs(args []string) *TermRenderer {
 	return r
 }
 
+// Scaled implements the content.Scaler interface, returning a copy with its own style
+// whose font size, padding and cell spacing are multiplied by factor. The terminal
+// keeps its size in cells, and inline images are enlarged to cover the same cells.
+func (r *TermRenderer) Scaled(factor float64) content.Content {
+	style := *r.Style
+	scale := func(v int) int {
+		return int(math.Round(float64(v) * factor))
+	}
+	style.FontSize *= factor
+	style.PaddingLeft = scale(style.PaddingLeft)
+	style.PaddingRight = scale(style.PaddingRight)
+	style.PaddingTop = scale(style.PaddingTop)
+	style.PaddingBottom = scale(style.PaddingBottom)
+	style.CellSpacing = scale(style.CellSpacing)
+	return &TermRenderer{Output: r.Output, Style: &style, theme: r.theme, imageScale: r.scaleOfImages() * factor}
+}
+
+// getFace returns a face of the font in the given style and at the font variations
+func (r *TermRenderer) getFace(style *fonts.FontStyle) (*fonts.Face, error) {
+	if len(r.Style.FontVariations) > 0 {
+		varied := style.WithVariations(r.Style.FontVariations)
+		style = &varied
+	}
+	return r.Style.Font.GetFace(r.Style.FontSize, style)
+}
+
 // Render implements the content.Content interface
 func (r *TermRenderer) Render() (image.Image, error) {
-	// Create a new terminal with the current style
-	t := NewTerminal(r.Style, r.theme)
-	in := r.Output
+	t := r.parse()
+	width, height := r.size(t)
+	return r.drawTerminal(t, width, height)
+}
 
-	// Add prompt if needed
-	if r.Style.ShowPrompt && r.Style.PromptFunc != nil && len(r.Style.Args) > 0 {
-		// Join args into a command string
-		cmd := strings.Join(r.Style.Args, " ")
-		// Generate prompt text
-		promptText := r.Style.PromptFunc(cmd)
-		// Convert to bytes and prepend to input
-		promptBytes := []byte(promptText + "\n")
-		in = append(promptBytes, in...)
+// Measure implements the content.Measurer interface
+func (r *TermRenderer) Measure() (width, height int, err error) {
+	face, err := r.getFace(&r.Style.Font.Style)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create font face: %v", err)
 	}
+	defer face.Close()
+
+	t := r.parse()
+	columns, rows := r.size(t)
+	bounds := r.bounds(columns, rows, r.charWidth(face))
+	return bounds.Dx(), bounds.Dy(), nil
+}
+
+// parse writes the output, after the prompt if there is one, to a new terminal
+func (r *TermRenderer) parse() *Terminal {
+	// Create a new terminal with the current style
+	t := r.newTerminal()
 
 	// Use ANSIParser to handle ANSI sequences
 	parser := NewANSIParser(t)
-	parser.Parse(in)
+	parser.Parse(r.withPrompt(r.Output))
+	return t
+}
+
+// RenderFrames renders a recording of the terminal, one image per frame. Each frame
+// is the output written since the previous one, so the cursor, colors and attributes
+// carry over and carriage returns or cursor movement redraw earlier output in place.
+// All images share the size of the largest frame. The prompt, if any, is shown from
+// the first frame on.
+func (r *TermRenderer) RenderFrames(frames [][]byte) ([]image.Image, error) {
+	t := r.newTerminal()
+	parser := NewANSIParser(t)
 
-	// Calculate final dimensions
-	width := t.Width
-	height := t.Height
+	snapshots := make([]*Terminal, len(frames))
+	width, height := 0, 0
+	for i, frame := range frames {
+		if i == 0 {
+			frame = r.withPrompt(frame)
+		}
+		parser.Parse(frame)
+		snapshots[i] = t.snapshot()
+
+		w, h := r.size(t)
+		width, height = max(width, w), max(height, h)
+	}
+
+	images := make([]image.Image, len(snapshots))
+	for i, snapshot := range snapshots {
+		img, err := r.drawTerminal(snapshot, width, height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render frame %d: %v", i, err)
+		}
+		images[i] = img
+	}
+	return images, nil
+}
+
+// newTerminal creates a terminal with the renderer's style, which lays out inline
+// images in cells the size they're drawn at
+func (r *TermRenderer) newTerminal() *Terminal {
+	t := NewTerminal(r.Style, r.theme)
+	if face, err := r.getFace(&r.Style.Font.Style); err == nil {
+		// Images are laid out at their own size, and enlarged when they're drawn
+		scale := r.scaleOfImages()
+		t.CellWidth = float64(r.charWidth(face)+r.Style.CellSpacing) / scale
+		t.CellHeight = float64(r.rowHeight()) / scale
+		face.Close()
+	}
+	return t
+}
+
+// scaleOfImages returns the factor inline images are enlarged by
+func (r *TermRenderer) scaleOfImages() float64 {
+	if r.imageScale <= 0 {
+		return 1
+	}
+	return r.imageScale
+}
+
+// withPrompt prepends the prompt to the output, if one should be shown
+func (r *TermRenderer) withPrompt(in []byte) []byte {
+	if !r.Style.ShowPrompt || (r.Style.Prompt == nil && r.Style.PromptFunc == nil) || len(r.Style.Args) == 0 {
+		return in
+	}
+
+	// Join args into a command string
+	cmd := strings.Join(r.Style.Args, " ")
+	// Generate prompt text
+	var promptText string
+	if r.Style.Prompt != nil {
+		ctx := NewPromptContext(cmd)
+		if r.Style.PromptContext != nil {
+			ctx = *r.Style.PromptContext
+			ctx.Command = cmd
+		}
+		promptText = r.Style.Prompt(ctx)
+	} else {
+		promptText = r.Style.PromptFunc(cmd)
+	}
+	// Convert to bytes and prepend to input
+	promptBytes := []byte(promptText + "\n")
+	return append(promptBytes, in...)
+}
+
+// size returns the size of the terminal grid to draw, in cells
+func (r *TermRenderer) size(t *Terminal) (width, height int) {
 	if r.Style.AutoSize {
-		width = t.MaxX + t.PaddingRight   // Add right padding
-		height = t.MaxY + t.PaddingBottom // Add bottom padding
+		width, height = t.MaxX, t.MaxY
+		if r.showCursor(t) {
+			// The cursor is usually just past the output, which mustn't cut it off
+			width, height = max(width, t.CursorX+1), max(height, t.CursorY+1)
+		}
+		return width + t.PaddingRight, height + t.PaddingBottom // Add right and bottom padding
 	}
+	return t.Width, t.Height
+}
+
+// showCursor reports whether a cursor should be drawn on the terminal
+func (r *TermRenderer) showCursor(t *Terminal) bool {
+	return r.Style.Cursor != CursorNone && !t.CursorHidden
+}
+
+// charWidth measures the width of a cell using the font metrics
+func (r *TermRenderer) charWidth(face *fonts.Face) int {
+	advance, _ := face.Face.GlyphAdvance('M')
+	return advance.Round()
+}
+
+// rowHeight returns the height of a row of cells
+func (r *TermRenderer) rowHeight() int {
+	return int(float64(r.Style.FontSize) * r.Style.LineHeight)
+}
+
+// bounds returns the bounds of the image of a grid of width by height cells
+func (r *TermRenderer) bounds(width, height, charWidth int) image.Rectangle {
+	return image.Rect(0, 0,
+		width*charWidth+r.Style.PaddingLeft+r.Style.PaddingRight+width*r.Style.CellSpacing,
+		height*r.rowHeight()+r.Style.PaddingTop+r.Style.PaddingBottom)
+}
 
+// drawTerminal draws the cells of the terminal as an image of width by height cells
+func (r *TermRenderer) drawTerminal(t *Terminal, width, height int) (image.Image, error) {
 	// Create font face using the base font's style
-	face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+	face, err := r.getFace(&r.Style.Font.Style)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create font face: %v", err)
 	}
 	defer face.Close()
 
-	// Create a map to cache font faces for different styles
-	fontFaces := make(map[Attributes]*fonts.Face)
+	// Create a map to cache font faces for different styles, by bold and italic
+	fontFaces := make(map[[2]bool]*fonts.Face)
 	defer func() {
 		// Close all font faces when done
 		for _, f := range fontFaces {
@@ -191,7 +425,8 @@ func (r *TermRenderer) Render() (image.Image, error) {
 
 	// Helper function to get or create a font face for a style
 	getFontFace := func(attrs Attributes) (*fonts.Face, error) {
-		if face, ok := fontFaces[attrs]; ok {
+		key := [2]bool{attrs.Bold, attrs.Italic}
+		if face, ok := fontFaces[key]; ok {
 			return face, nil
 		}
 
@@ -211,68 +446,139 @@ func (r *TermRenderer) Render() (image.Image, error) {
 			style.Italic = true
 		}
 
-		face, err := r.Style.Font.GetFace(r.Style.FontSize, style)
+		face, err := r.getFace(style)
 		if err != nil {
 			// Try fallback if the exact style is not available
 			style.Weight = fonts.WeightRegular
 			style.Italic = false
-			face, err = r.Style.Font.GetFace(r.Style.FontSize, style)
+			face, err = r.getFace(style)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create font face: %v", err)
 			}
 		}
-		fontFaces[attrs] = face
+		fontFaces[key] = face
 		return face, nil
 	}
 
-	// Measure the character width using the font metrics
-	charWidthI26, _ := face.Face.GlyphAdvance('M')
-	charWidth := charWidthI26.Round() // Convert to int
+	charWidth := r.charWidth(face)
+	rowHeight := r.rowHeight()
 
 	// Create the image with correct dimensions based on character width
-	bounds := image.Rect(0, 0,
-		width*charWidth+r.Style.PaddingLeft+r.Style.PaddingRight+width*r.Style.CellSpacing,
-		height*int(float64(r.Style.FontSize)*r.Style.LineHeight)+r.Style.PaddingTop+r.Style.PaddingBottom)
-	img := image.NewRGBA(bounds)
+	img := image.NewRGBA(r.bounds(width, height, charWidth))
 
 	// Fill background
 	draw.Draw(img, img.Bounds(), &image.Uniform{t.DefaultBg}, image.Point{}, draw.Src)
 
+	// cellRect returns the pixel bounds of the cell at the given grid position
+	cellRect := func(x, y int) image.Rectangle {
+		return image.Rect(
+			x*charWidth+r.Style.PaddingLeft+r.Style.CellSpacing*x,
+			y*rowHeight+r.Style.PaddingTop,
+			(x+1)*charWidth+r.Style.PaddingLeft+r.Style.CellSpacing*x,
+			(y+1)*rowHeight+r.Style.PaddingTop,
+		)
+	}
+
+	// baseline returns the pen position for drawing a glyph in the given cell
+	baseline := func(x, y int) fixed.Point26_6 {
+		rect := cellRect(x, y)
+		return fixed.Point26_6{
+			X: fixed.I(rect.Min.X),
+			Y: fixed.I(rect.Min.Y + int(r.Style.FontSize)),
+		}
+	}
+
+	// Hyperlinks in the default foreground are drawn in the theme's blue, so they
+	// stand out from the text around them like in a terminal. Blinking text is drawn
+	// halfway to its background if DimBlink is set.
+	fgColor := func(cell Cell) color.Color {
+		fg := cell.FgColor
+		if cell.Link != "" && cell.FgColor == t.DefaultFg && t.Style != nil {
+			fg = ansiColor(4, t)
+		}
+		if cell.Attrs.Blink && r.Style.DimBlink {
+			fg = mixColors(fg, cell.BgColor, 0.5)
+		}
+		return fg
+	}
+
 	// Calculate the last usable line (accounting for bottom padding)
-	lastUsableLine := height - t.PaddingBottom - 1
+	lastUsableLine := min(height-t.PaddingBottom-1, len(t.Cells)-1)
 
-	// Draw cells from top to bottom, stopping at the last usable line
+	// Rows are drawn in display order, with right to left text reversed
+	rows := make([][]Cell, len(t.Cells))
 	for y := t.PaddingTop; y <= lastUsableLine; y++ {
-		if y >= len(t.Cells) {
-			break
-		}
+		rows[y] = visualRow(t.Cells[y])
+	}
 
-		for x := 0; x < width; x++ {
-			if x >= len(t.Cells[y]) {
-				continue
+	// The cursor is drawn if it's in the area that can be drawn in. A block cursor
+	// swaps the colors of its cell, which may be past the rows written to so far.
+	cursorX, cursorY := t.CursorX, t.CursorY
+	showCursor := r.showCursor(t) && cursorX >= t.PaddingLeft && cursorX < width-t.PaddingRight &&
+		cursorY >= t.PaddingTop && cursorY < height-t.PaddingBottom
+	cursorColor := r.Style.CursorColor
+	if cursorColor == nil {
+		cursorColor = t.DefaultFg
+		if t.Style != nil {
+			cursorColor = t.Style.GetCursor()
+		}
+	}
+	if showCursor && r.Style.Cursor == CursorBlock {
+		if cursorY <= lastUsableLine && cursorX < len(rows[cursorY]) {
+			row := append([]Cell(nil), rows[cursorY]...)
+			cells := 1
+			if row[cursorX].IsWide && cursorX+1 < len(row) {
+				cells = 2
 			}
+			for x := cursorX; x < cursorX+cells; x++ {
+				row[x].FgColor, row[x].BgColor = row[x].BgColor, cursorColor
+			}
+			rows[cursorY] = row
+		} else {
+			draw.Draw(img, cellRect(cursorX, cursorY), &image.Uniform{cursorColor}, image.Point{}, draw.Src)
+		}
+	}
 
-			cell := t.Cells[y][x]
-
-			// Draw background if different from default
+	// Draw all cell backgrounds first, so that glyphs which extend past their cell
+	// (like Powerline separators) aren't clipped by the background of the next cell
+	for y := t.PaddingTop; y <= lastUsableLine; y++ {
+		for x := 0; x < width && x < len(rows[y]); x++ {
+			cell := rows[y][x]
 			if cell.BgColor != t.DefaultBg {
-				cellRect := image.Rect(
-					x*charWidth+r.Style.PaddingLeft+r.Style.CellSpacing*x,
-					y*int(float64(r.Style.FontSize)*r.Style.LineHeight)+r.Style.PaddingTop,
-					(x+1)*charWidth+r.Style.PaddingLeft+r.Style.CellSpacing*x,
-					(y+1)*int(float64(r.Style.FontSize)*r.Style.LineHeight)+r.Style.PaddingTop,
-				)
-				draw.Draw(img, cellRect, &image.Uniform{cell.BgColor}, image.Point{}, draw.Src)
+				draw.Draw(img, cellRect(x, y), &image.Uniform{cell.BgColor}, image.Point{}, draw.Src)
 			}
+		}
+	}
 
+	// Inline images go over the backgrounds and under the text, clipped to the cells
+	// that can be drawn in
+	scale := r.scaleOfImages()
+	area := image.Rectangle{
+		Min: cellRect(t.PaddingLeft, t.PaddingTop).Min,
+		Max: cellRect(width-t.PaddingRight-1, lastUsableLine).Max,
+	}
+	for _, p := range t.Images {
+		origin := cellRect(p.X, p.Y).Min
+		rect := image.Rect(0, 0, int(math.Round(float64(p.Width)*scale)), int(math.Round(float64(p.Height)*scale))).Add(origin)
+		if p.Image.Bounds().Size() == rect.Size() {
+			draw.Draw(img.SubImage(area).(*image.RGBA), rect, p.Image, p.Image.Bounds().Min, draw.Over)
+		} else {
+			xdraw.CatmullRom.Scale(img.SubImage(area).(*image.RGBA), rect, p.Image, p.Image.Bounds(), draw.Over, nil)
+		}
+	}
+
+	// Then draw the characters, stopping at the last usable line
+	for y := t.PaddingTop; y <= lastUsableLine; y++ {
+		row := rows[y]
+		for x := 0; x < width && x < len(row); x++ {
+			cell := row[x]
 			if cell.Char == 0 || cell.Char == ' ' {
 				continue
 			}
 
-			// Draw the character
-			point := fixed.Point26_6{
-				X: fixed.Int26_6(x*charWidth+r.Style.PaddingLeft+r.Style.CellSpacing*x) << 6,
-				Y: fixed.Int26_6(y*int(float64(r.Style.FontSize)*r.Style.LineHeight)+r.Style.PaddingTop+int(r.Style.FontSize)) << 6,
+			// Powerline separators are drawn as shapes so they fill the cell exactly
+			if drawPowerlineGlyph(img, cell.Char, cellRect(x, y), fgColor(cell)) {
+				continue
 			}
 
 			// Get the appropriate font face for this cell's attributes
@@ -281,15 +587,224 @@ func (r *TermRenderer) Render() (image.Image, error) {
 				return nil, fmt.Errorf("failed to get font face for cell at (%d,%d): %v", x, y, err)
 			}
 
+			// With ligatures enabled, shape the whole run of cells sharing this style.
+			// Characters the font doesn't have are left out so they can fall back to emoji.
+			if r.Style.Ligatures && !cell.IsWide && cellFace.Font.HasGlyph(cell.Char) {
+				end := x + 1
+				for end < width && end < len(row) && row[end].Char != 0 && row[end].Char != ' ' && !row[end].IsWide &&
+					row[end].Attrs == cell.Attrs && row[end].FgColor == cell.FgColor && row[end].Link == cell.Link && !isPowerlineGlyph(row[end].Char) &&
+					cellFace.Font.HasGlyph(row[end].Char) {
+					end++
+				}
+				if end-x > 1 {
+					if err := r.drawShapedRun(img, cellFace, row[x:end], fgColor(cell), x, func(col int) fixed.Point26_6 {
+						return baseline(col, y)
+					}); err != nil {
+						return nil, err
+					}
+					x = end - 1
+					continue
+				}
+			}
+
 			d := &font.Drawer{
 				Dst:  img,
-				Src:  &image.Uniform{cell.FgColor},
+				Src:  &image.Uniform{fgColor(cell)},
 				Face: cellFace.Face,
-				Dot:  point,
+				Dot:  baseline(x, y),
+			}
+			if cell.IsWide {
+				// Center wide characters in their two cells, in case the glyph is narrower
+				spare := fixed.I(2*charWidth+r.Style.CellSpacing) - font.MeasureString(cellFace.Face, string(cell.Char))
+				if spare > 0 {
+					d.Dot.X += spare / 2
+				}
+			}
+			fonts.DrawString(d, string(cell.Char))
+		}
+	}
+
+	// Underline text and hyperlinks just below the baseline, spaces included, so each
+	// link reads as a single span. Hyperlinks are underlined in their text's color.
+	thickness := max(1, int(math.Round(r.Style.FontSize/14)))
+	offset := max(1, face.Face.Metrics().Descent.Round()/3)
+	for y := t.PaddingTop; y <= lastUsableLine; y++ {
+		row := rows[y]
+		for x := 0; x < width && x < len(row); x++ {
+			cell := row[x]
+			if cell.Link == "" && !cell.Attrs.Underline {
+				continue
+			}
+			col, style := fgColor(cell), UnderlineSingle
+			if cell.Attrs.Underline {
+				style = cell.Attrs.UnderlineStyle
+				if cell.Attrs.UnderlineColor != nil {
+					col = cell.Attrs.UnderlineColor
+				}
+			}
+
+			rect := cellRect(x, y)
+			span := image.Rect(rect.Min.X, min(baseline(x, y).Y.Round()+offset, rect.Max.Y-thickness), rect.Max.X, rect.Max.Y)
+			if x+1 < len(row) && (row[x+1].Link != "" && row[x+1].Link == cell.Link || row[x+1].Attrs.Underline && cell.Attrs.Underline) {
+				span.Max.X += r.Style.CellSpacing // Bridge the gap to the next underlined cell
+			}
+			drawUnderline(img, span, thickness, style, col)
+		}
+	}
+
+	// Strike text through the middle of its lowercase letters, spaces included so
+	// struck out words are joined
+	strike := face.Face.Metrics().XHeight.Round() / 2
+	if strike <= 0 {
+		strike = face.Face.Metrics().Ascent.Round() / 4
+		if bounds, _, ok := face.Face.GlyphBounds('x'); ok && bounds.Min.Y < 0 {
+			strike = (-bounds.Min.Y).Round() / 2
+		}
+	}
+	for y := t.PaddingTop; y <= lastUsableLine; y++ {
+		row := rows[y]
+		for x := 0; x < width && x < len(row); x++ {
+			if !row[x].Attrs.Strikethrough {
+				continue
 			}
-			d.DrawString(string(cell.Char))
+			rect := cellRect(x, y)
+			top := baseline(x, y).Y.Round() - strike - thickness/2
+			line := image.Rect(rect.Min.X, top, rect.Max.X, top+thickness)
+			if x+1 < len(row) && row[x+1].Attrs.Strikethrough {
+				line.Max.X += r.Style.CellSpacing // Bridge the gap to the next struck out cell
+			}
+			draw.Draw(img, line, &image.Uniform{fgColor(row[x])}, image.Point{}, draw.Over)
 		}
 	}
 
+	// Underline and bar cursors go over everything, two line widths thick
+	if showCursor && r.Style.Cursor != CursorBlock {
+		rect := cellRect(cursorX, cursorY)
+		if r.Style.Cursor == CursorUnderline {
+			rect.Min.Y = rect.Max.Y - 2*thickness
+		} else {
+			rect.Max.X = rect.Min.X + 2*thickness
+		}
+		draw.Draw(img, rect, &image.Uniform{cursorColor}, image.Point{}, draw.Src)
+	}
+
 	return img, nil
 }
+
+// mixColors returns the color t of the way from a to b
+func mixColors(a, b color.Color, t float64) color.Color {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	mix := func(x, y uint32) uint8 {
+		return uint8(math.Round((float64(x)*(1-t) + float64(y)*t) / 257))
+	}
+	return color.RGBA{mix(ar, br), mix(ag, bg), mix(ab, bb), mix(aa, ba)}
+}
+
+// drawUnderline draws an underline of the given style across span, from its top
+// down to at most its bottom. Patterns follow the x coordinates of the image, so
+// they line up from one cell to the next.
+func drawUnderline(img *image.RGBA, span image.Rectangle, thickness int, style UnderlineStyle, col color.Color) {
+	src := &image.Uniform{col}
+	line := func(x0, x1, top int) {
+		top = min(top, span.Max.Y-thickness)
+		draw.Draw(img, image.Rect(x0, top, x1, top+thickness), src, image.Point{}, draw.Over)
+	}
+
+	switch style {
+	case UnderlineDouble:
+		line(span.Min.X, span.Max.X, span.Min.Y)
+		line(span.Min.X, span.Max.X, span.Min.Y+2*thickness)
+	case UnderlineCurly:
+		// A wave two line widths high, with a period of eight of them
+		amplitude := float64(thickness)
+		period := 8 * float64(thickness)
+		for x := span.Min.X; x < span.Max.X; x++ {
+			dy := int(math.Round(amplitude * (1 - math.Sin(2*math.Pi*float64(x)/period))))
+			line(x, x+1, span.Min.Y+dy)
+		}
+	case UnderlineDotted:
+		for x := span.Min.X; x < span.Max.X; x++ {
+			if x/thickness%2 == 0 {
+				line(x, x+1, span.Min.Y)
+			}
+		}
+	case UnderlineDashed:
+		for x := span.Min.X; x < span.Max.X; x++ {
+			if x/thickness%5 < 3 {
+				line(x, x+1, span.Min.Y)
+			}
+		}
+	default:
+		line(span.Min.X, span.Max.X, span.Min.Y)
+	}
+}
+
+// visualRow returns the cells of a row in the order they're displayed, following the
+// Unicode bidirectional algorithm: runs of right to left text are reversed, with the
+// brackets in them mirrored. Wide characters keep their two cells together.
+func visualRow(row []Cell) []Cell {
+	// Every unit is a character and the cells it covers
+	var units [][]Cell
+	var runes []rune
+	for x := 0; x < len(row); x++ {
+		n := 1
+		if row[x].IsWide && x+1 < len(row) {
+			n = 2
+		}
+		units = append(units, row[x:x+n])
+		ch := row[x].Char
+		if ch == 0 {
+			ch = ' '
+		}
+		runes = append(runes, ch)
+		x += n - 1
+	}
+
+	visual, order := fonts.BidiReorder(runes, false)
+	reordered := make([]Cell, 0, len(row))
+	changed := false
+	for v, idx := range order {
+		start := len(reordered)
+		reordered = append(reordered, units[idx]...)
+		if units[idx][0].Char != 0 {
+			reordered[start].Char = visual[v]
+		}
+		changed = changed || idx != v
+	}
+	if !changed {
+		return row
+	}
+	return reordered
+}
+
+// drawShapedRun shapes a run of cells with a single style and draws the resulting glyphs,
+// keeping every glyph cluster aligned to the cell grid
+func (r *TermRenderer) drawShapedRun(img *image.RGBA, f *fonts.Face, cells []Cell, fg color.Color, startX int, dot func(x int) fixed.Point26_6) error {
+	runes := make([]rune, len(cells))
+	for i, cell := range cells {
+		runes[i] = cell.Char
+	}
+
+	glyphs, err := f.Shape(string(runes), true)
+	if err != nil {
+		return fmt.Errorf("failed to shape text: %v", err)
+	}
+
+	src := &image.Uniform{fg}
+	cluster := -1
+	var pen fixed.Int26_6 // Offset of the pen within the current cluster
+	for _, glyph := range glyphs {
+		if glyph.Cluster != cluster {
+			cluster = glyph.Cluster
+			pen = 0
+		}
+		origin := dot(startX + glyph.Cluster)
+		origin.X += pen
+		if err := f.DrawGlyph(img, src, glyph, origin); err != nil {
+			return fmt.Errorf("failed to draw glyph: %v", err)
+		}
+		pen += glyph.Advance
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package term
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// colorDistance returns the sum of the absolute RGB channel differences of two colors
+func colorDistance(a, b color.Color) int {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	diff := func(x, y uint32) int {
+		d := int(x>>8) - int(y>>8)
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	return diff(ar, br) + diff(ag, bg) + diff(ab, bb)
+}
+
+// closerTo reports whether c is closer to want than to other
+func closerTo(c, want, other color.Color) bool {
+	return colorDistance(c, want) < colorDistance(c, other)
+}
+
+func TestRenderPowerlinePrompt(t *testing.T) {
+	// A two segment prompt: " user " on blue, a blue-on-green separator, " ~/code " on green
+	// and a green separator on the default background
+	prompt := "\x1b[30;44m user \x1b[34;42m\ue0b0\x1b[30;42m ~/code \x1b[0m\x1b[32m\ue0b0\x1b[0m\n"
+
+	r := DefaultRenderer([]byte(prompt)).WithAutoSize()
+	img, err := r.Render()
+	require.NoError(t, err)
+	rgba, ok := img.(*image.RGBA)
+	require.True(t, ok)
+
+	face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+	require.NoError(t, err)
+	defer face.Close()
+	advance, _ := face.Face.GlyphAdvance('M')
+	charWidth := advance.Round()
+	rowHeight := int(r.Style.FontSize * r.Style.LineHeight)
+
+	cellRect := func(x, y int) image.Rectangle {
+		left := x*charWidth + r.Style.PaddingLeft
+		top := y*rowHeight + r.Style.PaddingTop
+		return image.Rect(left, top, left+charWidth, top+rowHeight)
+	}
+
+	blue := r.theme.GetColor(4)
+	green := r.theme.GetColor(2)
+	row := r.Style.PaddingTop
+
+	tests := []struct {
+		name string
+		x    int // Cell column, including the left padding
+		fg   color.Color
+		bg   color.Color
+	}{
+		{name: "separator between segments", x: r.Style.PaddingLeft + len(" user "), fg: blue, bg: green},
+		{name: "trailing separator", x: r.Style.PaddingLeft + len(" user ") + 1 + len(" ~/code "), fg: green, bg: r.theme.GetBackground()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rect := cellRect(tt.x, row)
+			mid := rect.Min.Y + rect.Dy()/2
+
+			// The triangle spans the full height of the cell at its left edge, leaving no gaps. The
+			// corner pixels themselves are only partially covered by the anti-aliased diagonal edges.
+			assert.True(t, closerTo(rgba.At(rect.Min.X, rect.Min.Y+2), tt.fg, tt.bg), "top left corner should be filled")
+			assert.True(t, closerTo(rgba.At(rect.Min.X, rect.Max.Y-3), tt.fg, tt.bg), "bottom left corner should be filled")
+
+			// Its tip reaches the right edge of the cell without being clipped
+			assert.True(t, closerTo(rgba.At(rect.Max.X-2, mid), tt.fg, tt.bg), "tip should reach the right edge")
+
+			// The corners on the right remain background
+			assert.True(t, closerTo(rgba.At(rect.Max.X-1, rect.Min.Y), tt.bg, tt.fg), "top right corner should be background")
+			assert.True(t, closerTo(rgba.At(rect.Max.X-1, rect.Max.Y-1), tt.bg, tt.fg), "bottom right corner should be background")
+
+			// Nothing spills into the next cell
+			next := cellRect(tt.x+1, row)
+			assert.True(t, closerTo(rgba.At(next.Min.X, mid), tt.bg, tt.fg), "separator should not overlap the next cell")
+		})
+	}
+}
+
+func TestRenderLigatures(t *testing.T) {
+	input := []byte("a -> b != c\n")
+
+	plain, err := DefaultRenderer(input).WithAutoSize().Render()
+	require.NoError(t, err)
+
+	ligatures, err := DefaultRenderer(input).WithAutoSize().WithLigatures(true).Render()
+	require.NoError(t, err)
+
+	// Ligatures keep the cell grid intact, so both renders have the same size
+	assert.Equal(t, plain.Bounds(), ligatures.Bounds())
+
+	// The bundled font has ligatures for these operators, so the output differs
+	differs := false
+	bounds := plain.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y && !differs; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if colorDistance(plain.At(x, y), ligatures.At(x, y)) > 0 {
+				differs = true
+				break
+			}
+		}
+	}
+	assert.True(t, differs, "expected ligatures to change the rendered operators")
+}
+
+func TestRenderFrames(t *testing.T) {
+	// A progress bar redrawn with carriage returns, then a status line above it
+	// rewritten with a cursor-up sequence
+	frames := [][]byte{
+		[]byte("working\n[#   ]"),
+		[]byte("\r[##  ]"),
+		[]byte("\r[####]\x1b[1A\rdone   \n"),
+	}
+
+	images, err := DefaultRenderer(nil).WithAutoSize().RenderFrames(frames)
+	require.NoError(t, err)
+	require.Len(t, images, len(frames))
+
+	// Every frame is drawn at the same size
+	for _, img := range images[1:] {
+		assert.Equal(t, images[0].Bounds(), img.Bounds())
+	}
+
+	// Each frame shows the screen as if all the output so far had been written at once
+	for i, want := range []string{"working\n[#   ]", "working\n[##  ]", "done   \n[####]\n"} {
+		expected, err := DefaultRenderer([]byte(want)).WithAutoSize().Render()
+		require.NoError(t, err)
+		assert.Equal(t, expected, images[i], "frame %d", i)
+	}
+}
+
+func TestRenderHyperlinks(t *testing.T) {
+	r := DefaultRenderer([]byte("\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\\n")).WithAutoSize()
+	img, err := r.Render()
+	require.NoError(t, err)
+
+	theme := GetTheme(r.Style.Theme)
+	blue := theme.GetColor(4)
+
+	// The link is drawn in the theme's blue rather than the default foreground
+	bounds := img.Bounds()
+	blueish := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if colorDistance(img.At(x, y), blue) == 0 {
+				blueish++
+			}
+		}
+	}
+	assert.Greater(t, blueish, 0, "expected the link to be drawn in the theme's blue")
+
+	// and underlined across the whole link, including the gaps between letters
+	face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+	require.NoError(t, err)
+	defer face.Close()
+	charWidth := r.charWidth(face)
+	longest := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		run := 0
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if colorDistance(img.At(x, y), blue) == 0 {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	assert.GreaterOrEqual(t, longest, 4*charWidth, "expected a solid underline below the link")
+}
+
+func TestVisualRow(t *testing.T) {
+	cells := func(s string) []Cell {
+		var row []Cell
+		for _, ch := range s {
+			row = append(row, Cell{Char: ch})
+		}
+		return row
+	}
+	chars := func(row []Cell) string {
+		var b []rune
+		for _, cell := range row {
+			b = append(b, cell.Char)
+		}
+		return string(b)
+	}
+
+	row := cells("echo hi")
+	assert.Equal(t, row, visualRow(row))
+	assert.Equal(t, "ab (בא)", chars(visualRow(cells("ab (אב)"))))
+
+	// Wide characters keep their continuation cell after them
+	row = append(cells("שלום "), Cell{Char: '世', IsWide: true}, Cell{})
+	assert.Equal(t, "םולש 世\x00", chars(visualRow(row)))
+}
+
+func TestRenderUnderlines(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	// countColor counts the pixels of the first row of cells in c
+	countColor := func(img image.Image, r *TermRenderer, c color.Color) int {
+		top := r.Style.PaddingTop*r.rowHeight() + r.Style.PaddingTop
+		n := 0
+		for y := top; y < top+r.rowHeight(); y++ {
+			for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+				if colorDistance(img.At(x, y), c) < 30 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	for _, style := range []string{"4", "21", "4:3", "4:4", "4:5"} {
+		t.Run(style, func(t *testing.T) {
+			r := DefaultRenderer([]byte("\x1b[" + style + ";58;2;255;0;0m    \x1b[0m")).WithAutoSize()
+			img, err := r.Render()
+			require.NoError(t, err)
+			assert.Positive(t, countColor(img, r, red), "expected a red underline under the spaces")
+		})
+	}
+
+	t.Run("in the text's color", func(t *testing.T) {
+		r := DefaultRenderer([]byte("\x1b[4;31m    ")).WithAutoSize()
+		img, err := r.Render()
+		require.NoError(t, err)
+		assert.Positive(t, countColor(img, r, r.theme.GetColor(1)))
+	})
+
+	t.Run("curly spans more rows than single", func(t *testing.T) {
+		rows := func(style string) int {
+			r := DefaultRenderer([]byte("\x1b[" + style + ";58;2;255;0;0m        ")).WithAutoSize()
+			img, err := r.Render()
+			require.NoError(t, err)
+			n := 0
+			for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+				for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+					if colorDistance(img.At(x, y), red) < 30 {
+						n++
+						break
+					}
+				}
+			}
+			return n
+		}
+		assert.Greater(t, rows("4:3"), rows("4"))
+	})
+}
+
+func TestRenderStrikethrough(t *testing.T) {
+	// Spaces have no glyphs, so anything drawn in red is the line
+	red := color.RGBA{R: 255, A: 255}
+	r := DefaultRenderer([]byte("\x1b[9;38;2;255;0;0m    \x1b[0m    ")).WithAutoSize()
+	img, err := r.Render()
+	require.NoError(t, err)
+
+	face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+	require.NoError(t, err)
+	defer face.Close()
+	charWidth := r.charWidth(face)
+
+	left := r.Style.PaddingLeft*charWidth + r.Style.PaddingLeft
+	top := r.Style.PaddingTop*r.rowHeight() + r.Style.PaddingTop
+	baseline := top + int(r.Style.FontSize)
+	found := -1
+	for y := top; y < baseline; y++ {
+		if colorDistance(img.At(left+1, y), red) < 30 {
+			found = y
+			break
+		}
+	}
+	require.NotEqual(t, -1, found, "expected a line through the struck out cells")
+
+	// The line sits at half the x-height, runs through all four cells and stops there
+	xHeight := face.Face.Metrics().XHeight.Round()
+	assert.InDelta(t, baseline-xHeight/2, found, 2)
+	assert.Less(t, colorDistance(img.At(left+4*charWidth-1, found), red), 30)
+	assert.Greater(t, colorDistance(img.At(left+4*charWidth+1, found), red), 30)
+}
+
+func TestRenderDimBlink(t *testing.T) {
+	input := []byte("\x1b[5;38;2;255;255;255m██\x1b[0m")
+	plain, err := DefaultRenderer(input).WithAutoSize().Render()
+	require.NoError(t, err)
+	r := DefaultRenderer(input).WithAutoSize().WithDimBlink(true)
+	dimmed, err := r.Render()
+	require.NoError(t, err)
+
+	// The middle of the full block is halfway between white and the background
+	face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+	require.NoError(t, err)
+	defer face.Close()
+	x := r.Style.PaddingLeft*r.charWidth(face) + r.Style.PaddingLeft + r.charWidth(face)/2
+	y := r.Style.PaddingTop*r.rowHeight() + r.Style.PaddingTop + r.rowHeight()/2
+
+	white := color.RGBA{255, 255, 255, 255}
+	assert.Less(t, colorDistance(plain.At(x, y), white), 10)
+	assert.Less(t, colorDistance(dimmed.At(x, y), mixColors(white, r.theme.GetBackground(), 0.5)), 10)
+}
+
+func TestRenderCursor(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	// cell returns the bounds of a content cell of the renderer's output
+	cell := func(r *TermRenderer, x, y int) image.Rectangle {
+		face, err := r.Style.Font.GetFace(r.Style.FontSize, &r.Style.Font.Style)
+		require.NoError(t, err)
+		defer face.Close()
+		charWidth := r.charWidth(face)
+		left := (r.Style.PaddingLeft+x)*charWidth + r.Style.PaddingLeft
+		top := (r.Style.PaddingTop+y)*r.rowHeight() + r.Style.PaddingTop
+		return image.Rect(left, top, left+charWidth, top+r.rowHeight())
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		plain, err := DefaultRenderer([]byte("$ ")).WithAutoSize().Render()
+		require.NoError(t, err)
+		r := DefaultRenderer([]byte("$ ")).WithAutoSize().WithCursor(CursorNone, red)
+		img, err := r.Render()
+		require.NoError(t, err)
+		assert.Equal(t, plain, img)
+	})
+
+	t.Run("block past the output", func(t *testing.T) {
+		r := DefaultRenderer([]byte("$ ")).WithAutoSize().WithCursor(CursorBlock, red)
+		img, err := r.Render()
+		require.NoError(t, err)
+
+		// The output is widened to fit the cursor, after the two cells written
+		rect := cell(r, 2, 0)
+		assert.GreaterOrEqual(t, img.Bounds().Max.X, rect.Max.X)
+		assert.Equal(t, red, img.At(rect.Min.X, rect.Min.Y))
+		assert.Equal(t, red, img.At(rect.Max.X-1, rect.Max.Y-1))
+	})
+
+	t.Run("block inverts the character", func(t *testing.T) {
+		r := DefaultRenderer([]byte("█\x1b[1D")).WithAutoSize().WithCursor(CursorBlock, red)
+		img, err := r.Render()
+		require.NoError(t, err)
+		rect := cell(r, 0, 0)
+		center := image.Pt((rect.Min.X+rect.Max.X)/2, (rect.Min.Y+rect.Max.Y)/2)
+		assert.Less(t, colorDistance(img.At(center.X, center.Y), r.theme.GetBackground()), 10)
+	})
+
+	t.Run("underline and bar", func(t *testing.T) {
+		for _, style := range []CursorStyle{CursorUnderline, CursorBar} {
+			r := DefaultRenderer([]byte("$ ")).WithAutoSize().WithCursor(style, red)
+			img, err := r.Render()
+			require.NoError(t, err)
+			rect := cell(r, 2, 0)
+			assert.Equal(t, red, img.At(rect.Min.X, rect.Max.Y-1), "style %d", style)
+			assert.NotEqual(t, red, img.At(rect.Max.X-1, rect.Min.Y), "style %d", style)
+		}
+	})
+
+	t.Run("hidden by the output", func(t *testing.T) {
+		r := DefaultRenderer([]byte("$ \x1b[?25l")).WithCursor(CursorBlock, red)
+		img, err := r.Render()
+		require.NoError(t, err)
+		rect := cell(r, 2, 0)
+		require.True(t, rect.In(img.Bounds()))
+		assert.NotEqual(t, red, img.At(rect.Min.X, rect.Min.Y))
+	})
+}
@@ -0,0 +1,190 @@
+package term
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// windowsTerminalSlots lists the Windows Terminal scheme keys in ANSI color order
+var windowsTerminalSlots = [16]string{
+	"black", "red", "green", "yellow", "blue", "purple", "cyan", "white",
+	"brightBlack", "brightRed", "brightGreen", "brightYellow", "brightBlue", "brightPurple", "brightCyan", "brightWhite",
+}
+
+// itermColor is a single color entry in an iTerm2 color preset converted to JSON
+type itermColor struct {
+	Red   float64 `json:"Red Component"`
+	Green float64 `json:"Green Component"`
+	Blue  float64 `json:"Blue Component"`
+}
+
+// hex returns the color as a #rrggbb string
+func (c itermColor) hex() string {
+	component := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", component(c.Red), component(c.Green), component(c.Blue))
+}
+
+// LoadSchemeFromJSON parses a terminal color scheme and registers it so it can be
+// used with WithTheme. Both Windows Terminal schemes (a single scheme object or a
+// settings fragment with a "schemes" array, in which case the first scheme is used)
+// and iTerm2 color presets converted from plist to JSON are supported. iTerm2 presets
+// don't carry a name, so a top-level "name" key must be added to them.
+func LoadSchemeFromJSON(r io.Reader) (Theme, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return Theme{}, fmt.Errorf("failed to parse color scheme: %w", err)
+	}
+
+	// Windows Terminal fragments wrap their schemes in an array
+	if schemes, ok := raw["schemes"]; ok {
+		var list []map[string]json.RawMessage
+		if err := json.Unmarshal(schemes, &list); err != nil {
+			return Theme{}, fmt.Errorf("failed to parse color schemes: %w", err)
+		}
+		if len(list) == 0 {
+			return Theme{}, errors.New("fragment does not contain any color schemes")
+		}
+		raw = list[0]
+	}
+
+	var theme Theme
+	var err error
+	if _, ok := raw["Ansi 0 Color"]; ok {
+		theme, err = parseITermScheme(raw)
+	} else {
+		theme, err = parseWindowsTerminalScheme(raw)
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+
+	if err := RegisterTheme(&theme); err != nil {
+		return Theme{}, err
+	}
+	return theme, nil
+}
+
+// parseWindowsTerminalScheme converts a Windows Terminal scheme object into a Theme
+func parseWindowsTerminalScheme(raw map[string]json.RawMessage) (Theme, error) {
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			values[key] = s
+		}
+	}
+
+	var colors [16]string
+	for i, slot := range windowsTerminalSlots {
+		value, ok := values[slot]
+		if !ok {
+			return Theme{}, fmt.Errorf("color scheme is missing %q", slot)
+		}
+		if !isHexColor(value) {
+			return Theme{}, fmt.Errorf("invalid color %q for %q", value, slot)
+		}
+		colors[i] = value
+	}
+
+	theme := themeFromColors(values["name"], colors)
+	theme.Background = values["background"]
+	theme.Foreground = values["foreground"]
+	theme.Cursor = values["cursorColor"]
+	for key, value := range map[string]string{"background": theme.Background, "foreground": theme.Foreground, "cursorColor": theme.Cursor} {
+		if value != "" && !isHexColor(value) {
+			return Theme{}, fmt.Errorf("invalid color %q for %q", value, key)
+		}
+	}
+	return theme, nil
+}
+
+// parseITermScheme converts an iTerm2 color preset into a Theme
+func parseITermScheme(raw map[string]json.RawMessage) (Theme, error) {
+	lookup := func(key string) (string, bool, error) {
+		value, ok := raw[key]
+		if !ok {
+			return "", false, nil
+		}
+		var c itermColor
+		if err := json.Unmarshal(value, &c); err != nil {
+			return "", false, fmt.Errorf("invalid color for %q: %w", key, err)
+		}
+		return c.hex(), true, nil
+	}
+
+	var colors [16]string
+	for i := range colors {
+		key := fmt.Sprintf("Ansi %d Color", i)
+		value, ok, err := lookup(key)
+		if err != nil {
+			return Theme{}, err
+		}
+		if !ok {
+			return Theme{}, fmt.Errorf("color scheme is missing %q", key)
+		}
+		colors[i] = value
+	}
+
+	var name string
+	if value, ok := raw["name"]; ok {
+		if err := json.Unmarshal(value, &name); err != nil {
+			return Theme{}, fmt.Errorf("invalid scheme name: %w", err)
+		}
+	}
+
+	theme := themeFromColors(name, colors)
+	var err error
+	if theme.Background, _, err = lookup("Background Color"); err != nil {
+		return Theme{}, err
+	}
+	if theme.Foreground, _, err = lookup("Foreground Color"); err != nil {
+		return Theme{}, err
+	}
+	if theme.Cursor, _, err = lookup("Cursor Color"); err != nil {
+		return Theme{}, err
+	}
+	return theme, nil
+}
+
+// themeFromColors creates a theme from the 16 ANSI colors in order
+func themeFromColors(name string, colors [16]string) Theme {
+	return Theme{
+		Name:    name,
+		Color01: colors[0],
+		Color02: colors[1],
+		Color03: colors[2],
+		Color04: colors[3],
+		Color05: colors[4],
+		Color06: colors[5],
+		Color07: colors[6],
+		Color08: colors[7],
+		Color09: colors[8],
+		Color10: colors[9],
+		Color11: colors[10],
+		Color12: colors[11],
+		Color13: colors[12],
+		Color14: colors[13],
+		Color15: colors[14],
+		Color16: colors[15],
+	}
+}
+
+// isHexColor reports whether s is a #rrggbb color
+func isHexColor(s string) bool {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package term
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// windowsTerminalScheme returns a Windows Terminal scheme whose colors are #0000NN
+// for ANSI color NN
+func windowsTerminalScheme(name string) map[string]any {
+	scheme := map[string]any{
+		"name":        name,
+		"background":  "#101010",
+		"foreground":  "#f0f0f0",
+		"cursorColor": "#ABCDEF",
+	}
+	for i, slot := range windowsTerminalSlots {
+		scheme[slot] = fmt.Sprintf("#0000%02x", i)
+	}
+	return scheme
+}
+
+// itermScheme returns an iTerm2 preset whose colors are #0000NN for ANSI color NN
+func itermScheme(name string) map[string]any {
+	scheme := map[string]any{
+		"name":             name,
+		"Background Color": map[string]float64{"Red Component": 0, "Green Component": 0.5, "Blue Component": 1},
+		"Foreground Color": map[string]float64{"Red Component": 1.5, "Green Component": -1, "Blue Component": 0},
+	}
+	for i := 0; i < 16; i++ {
+		scheme[fmt.Sprintf("Ansi %d Color", i)] = map[string]float64{"Blue Component": float64(i) / 255}
+	}
+	return scheme
+}
+
+func TestLoadSchemeFromJSON(t *testing.T) {
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	tests := []struct {
+		name       string
+		json       string
+		wantName   string
+		background string
+		foreground string
+		cursor     string
+	}{
+		{
+			name:       "windows terminal",
+			json:       encode(windowsTerminalScheme("Scheme Test WT")),
+			wantName:   "Scheme Test WT",
+			background: "#101010",
+			foreground: "#f0f0f0",
+			cursor:     "#ABCDEF",
+		},
+		{
+			name: "windows terminal fragment",
+			json: encode(map[string]any{"schemes": []any{
+				windowsTerminalScheme("Scheme Test Fragment"),
+				windowsTerminalScheme("Scheme Test Second"),
+			}}),
+			wantName:   "Scheme Test Fragment",
+			background: "#101010",
+			foreground: "#f0f0f0",
+			cursor:     "#ABCDEF",
+		},
+		{
+			name:       "iterm2",
+			json:       encode(itermScheme("Scheme Test iTerm")),
+			wantName:   "Scheme Test iTerm",
+			background: "#0080ff",
+			foreground: "#ff0000", // Components are clamped to 0-1
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, err := LoadSchemeFromJSON(strings.NewReader(tt.json))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, theme.Name)
+			assert.Equal(t, tt.background, theme.Background)
+			assert.Equal(t, tt.foreground, theme.Foreground)
+			assert.Equal(t, tt.cursor, theme.Cursor)
+
+			colors := []string{
+				theme.Color01, theme.Color02, theme.Color03, theme.Color04,
+				theme.Color05, theme.Color06, theme.Color07, theme.Color08,
+				theme.Color09, theme.Color10, theme.Color11, theme.Color12,
+				theme.Color13, theme.Color14, theme.Color15, theme.Color16,
+			}
+			for i, c := range colors {
+				assert.Equal(t, fmt.Sprintf("#0000%02x", i), c, "color %d", i)
+			}
+
+			// The scheme is registered under its name
+			registered := GetTheme(tt.wantName)
+			require.NotNil(t, registered)
+			assert.Equal(t, theme, *registered)
+		})
+	}
+}
+
+func TestLoadSchemeFromJSONMalformed(t *testing.T) {
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(data)
+	}
+	without := func(scheme map[string]any, key string) map[string]any {
+		delete(scheme, key)
+		return scheme
+	}
+	with := func(scheme map[string]any, key string, value any) map[string]any {
+		scheme[key] = value
+		return scheme
+	}
+
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{name: "not json", json: "{", wantErr: "failed to parse color scheme"},
+		{name: "not an object", json: "[]", wantErr: "failed to parse color scheme"},
+		{name: "schemes not an array", json: `{"schemes": {}}`, wantErr: "failed to parse color schemes"},
+		{name: "no schemes", json: `{"schemes": []}`, wantErr: "does not contain any color schemes"},
+		{
+			name:    "windows terminal missing color",
+			json:    encode(without(windowsTerminalScheme("Scheme Test Missing"), "brightCyan")),
+			wantErr: `missing "brightCyan"`,
+		},
+		{
+			name:    "windows terminal invalid color",
+			json:    encode(with(windowsTerminalScheme("Scheme Test Invalid"), "red", "red")),
+			wantErr: `invalid color "red" for "red"`,
+		},
+		{
+			name:    "windows terminal short color",
+			json:    encode(with(windowsTerminalScheme("Scheme Test Short"), "blue", "#fff")),
+			wantErr: `invalid color "#fff" for "blue"`,
+		},
+		{
+			name:    "windows terminal invalid background",
+			json:    encode(with(windowsTerminalScheme("Scheme Test Background"), "background", "#zzzzzz")),
+			wantErr: `invalid color "#zzzzzz" for "background"`,
+		},
+		{
+			name:    "windows terminal without name",
+			json:    encode(without(windowsTerminalScheme(""), "name")),
+			wantErr: "theme must have a name",
+		},
+		{
+			name:    "iterm2 missing color",
+			json:    encode(without(itermScheme("Scheme Test iTerm Missing"), "Ansi 15 Color")),
+			wantErr: `missing "Ansi 15 Color"`,
+		},
+		{
+			name:    "iterm2 invalid color",
+			json:    encode(with(itermScheme("Scheme Test iTerm Invalid"), "Ansi 3 Color", "#ff0000")),
+			wantErr: `invalid color for "Ansi 3 Color"`,
+		},
+		{
+			name:    "iterm2 invalid name",
+			json:    encode(with(itermScheme(""), "name", 7)),
+			wantErr: "invalid scheme name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSchemeFromJSON(strings.NewReader(tt.json))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package term
 
+import (
+	"image"
+	"math"
+)
+
 func NewTerminal(style *TermStyle, theme *Theme) *Terminal {
 	t := &Terminal{
 		Width:         style.Width,
@@ -14,6 +19,8 @@ func NewTerminal(style *TermStyle, theme *Theme) *Terminal {
 		CurrFg:        theme.GetForeground(),
 		CurrBg:        theme.GetBackground(),
 		Style:         theme,
+		Palette:       style.Palette,
+		KeepAltScreen: style.KeepAltScreen,
 		// Initialize cursor position at the start of the content area
 		CursorX: style.PaddingLeft,
 		CursorY: style.PaddingTop,
@@ -30,6 +37,8 @@ func (t *Terminal) Reset() {
 	t.CurrAttrs = Attributes{}
 	t.CurrFg = t.DefaultFg
 	t.CurrBg = t.DefaultBg
+	t.CurrLink = ""
+	t.CursorHidden = false
 }
 
 func (t *Terminal) Resize(width, height int) {
@@ -37,18 +46,7 @@ func (t *Terminal) Resize(width, height int) {
 	totalWidth := width + t.PaddingLeft + t.PaddingRight
 	totalHeight := height + t.PaddingTop + t.PaddingBottom
 
-	newCells := make([][]Cell, totalHeight)
-	for i := range newCells {
-		newCells[i] = make([]Cell, totalWidth)
-		// Initialize with default colors and empty runes
-		for j := range newCells[i] {
-			newCells[i][j] = Cell{
-				Char:    ' ',
-				FgColor: t.DefaultFg,
-				BgColor: t.DefaultBg,
-			}
-		}
-	}
+	newCells := t.blankCells(totalWidth, totalHeight)
 
 	// Copy existing content, accounting for padding
 	for y := 0; y < min(len(t.Cells), totalHeight); y++ {
@@ -96,15 +94,170 @@ func (t *Terminal) SetCell(x, y int, ch rune) {
 		t.Cells[y] = newRow
 	}
 
+	// Overwriting either half of a wide character erases the other half
+	row := t.Cells[y]
+	if row[x].IsWide && x+1 < len(row) {
+		row[x+1] = t.blankCell()
+	}
+	if x > 0 && row[x-1].IsWide {
+		row[x-1] = t.blankCell()
+	}
+
 	t.Cells[y][x] = Cell{
 		Char:    ch,
 		FgColor: t.CurrFg,
 		BgColor: t.CurrBg,
 		Attrs:   t.CurrAttrs,
+		Link:    t.CurrLink,
 	}
 }
 
+// SetWideCell sets a character that takes up two cells, like most CJK characters and
+// emoji. The second cell is left empty, for the glyph of the first to cover.
+func (t *Terminal) SetWideCell(x, y int, ch rune) {
+	t.SetCell(x, y, ch)
+	t.SetCell(x+1, y, 0)
+	if x >= t.PaddingLeft && y >= t.PaddingTop && y < len(t.Cells) && x+1 < len(t.Cells[y]) {
+		t.Cells[y][x].IsWide = true
+	}
+}
+
+// PlaceImage shows an image at the cursor, drawn at width by height pixels, and
+// returns how many columns and rows of cells it covers. The cursor doesn't move.
+func (t *Terminal) PlaceImage(img image.Image, width, height int) (columns, rows int) {
+	cellWidth, cellHeight := t.cellSize()
+	columns = max(1, int(math.Ceil(float64(width)/cellWidth)))
+	rows = max(1, int(math.Ceil(float64(height)/cellHeight)))
+	t.Images = append(t.Images, Placement{
+		Image:  img,
+		X:      t.CursorX,
+		Y:      t.CursorY,
+		Width:  width,
+		Height: height,
+	})
+
+	if t.AutoSize {
+		t.MaxX = max(t.MaxX, t.CursorX+columns)
+		t.MaxY = max(t.MaxY, t.CursorY+rows)
+		if t.MaxY > len(t.Cells) || t.MaxX > t.Width {
+			t.Resize(max(t.Width, t.MaxX), max(t.Height, t.MaxY))
+		}
+	}
+	return columns, rows
+}
+
+// cellSize returns the size of a cell in pixels, or a typical one if it isn't set
+func (t *Terminal) cellSize() (width, height float64) {
+	width, height = t.CellWidth, t.CellHeight
+	if width <= 0 {
+		width = defaultCellWidth
+	}
+	if height <= 0 {
+		height = defaultCellHeight
+	}
+	return width, height
+}
+
 func (t *Terminal) NewLine() {
 	t.CursorX = t.PaddingLeft
 	t.CursorY++
 }
+
+// snapshot returns a copy of the terminal whose cells won't change as more output
+// is written to t
+func (t *Terminal) snapshot() *Terminal {
+	s := *t
+	s.Cells = make([][]Cell, len(t.Cells))
+	for i, row := range t.Cells {
+		s.Cells[i] = append([]Cell(nil), row...)
+	}
+	s.Images = append([]Placement(nil), t.Images...)
+	return &s
+}
+
+// blankCells creates a grid of empty cells in the default colors
+func (t *Terminal) blankCells(width, height int) [][]Cell {
+	cells := make([][]Cell, height)
+	for i := range cells {
+		cells[i] = make([]Cell, width)
+		// Initialize with default colors and empty runes
+		for j := range cells[i] {
+			cells[i][j] = t.blankCell()
+		}
+	}
+	return cells
+}
+
+// blankCell returns an empty cell in the default colors
+func (t *Terminal) blankCell() Cell {
+	return Cell{
+		Char:    ' ',
+		FgColor: t.DefaultFg,
+		BgColor: t.DefaultBg,
+	}
+}
+
+// ClearCells blanks the cells of row y from column start up to, but not including, end
+func (t *Terminal) ClearCells(y, start, end int) {
+	if y < 0 || y >= len(t.Cells) {
+		return
+	}
+	row := t.Cells[y]
+	for x := max(0, start); x < min(end, len(row)); x++ {
+		row[x] = t.blankCell()
+	}
+}
+
+// Clear blanks the whole screen, images included. In auto-size mode the tracked
+// content size is reset as well, so only what is drawn afterwards determines the size
+// of the output.
+func (t *Terminal) Clear() {
+	for y := range t.Cells {
+		t.ClearCells(y, 0, len(t.Cells[y]))
+	}
+	t.Images = nil
+	t.MaxX, t.MaxY = 0, 0
+}
+
+// EnterAltScreen switches to a blank alternate screen, saving the main screen and
+// the cursor position so they can be restored by ExitAltScreen
+func (t *Terminal) EnterAltScreen() {
+	if t.mainScreen != nil {
+		return
+	}
+	t.mainScreen = &screen{
+		cells:   t.Cells,
+		images:  t.Images,
+		cursorX: t.CursorX,
+		cursorY: t.CursorY,
+		maxX:    t.MaxX,
+		maxY:    t.MaxY,
+	}
+	t.Cells = t.blankCells(t.Width, t.Height)
+	t.Images = nil
+	t.MaxX, t.MaxY = 0, 0
+}
+
+// ExitAltScreen switches back to the main screen saved by EnterAltScreen
+func (t *Terminal) ExitAltScreen() {
+	if t.mainScreen == nil {
+		return
+	}
+	main := t.mainScreen
+	t.mainScreen = nil
+
+	// The terminal may have grown while the alternate screen was active
+	t.Cells = t.blankCells(t.Width, t.Height)
+	for y := 0; y < min(len(main.cells), len(t.Cells)); y++ {
+		copy(t.Cells[y], main.cells[y])
+	}
+	t.Images = main.images
+
+	t.CursorX, t.CursorY = main.cursorX, main.cursorY
+	t.MaxX, t.MaxY = main.maxX, main.maxY
+}
+
+// InAltScreen reports whether the alternate screen is active
+func (t *Terminal) InAltScreen() bool {
+	return t.mainScreen != nil
+}
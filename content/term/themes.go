@@ -3,6 +3,7 @@ package term
 
 import (
 	"embed"
+	"errors"
 	"image/color"
 	"path/filepath"
 	"sort"
@@ -49,8 +50,10 @@ type Theme struct {
 }
 
 var (
-	themes    = make(map[string]*Theme)
-	themeOnce sync.Once
+	themes           = make(map[string]*Theme)
+	registeredThemes = make(map[string]bool) // Names of themes added with RegisterTheme
+	themesMu         sync.RWMutex
+	themeOnce        sync.Once
 )
 
 // loadThemes loads all themes from the embedded filesystem
@@ -86,7 +89,10 @@ func GetTheme(name string) *Theme {
 	name = normalizeThemeName(name)
 
 	// Check if theme is already loaded
-	if theme := themes[name]; theme != nil {
+	themesMu.RLock()
+	theme := themes[name]
+	themesMu.RUnlock()
+	if theme != nil {
 		return theme
 	}
 
@@ -104,19 +110,48 @@ func GetTheme(name string) *Theme {
 		}
 
 		// Cache the theme for future use
+		themesMu.Lock()
 		themes[name] = &theme
+		themesMu.Unlock()
 		return &theme
 	}
 
 	return nil
 }
 
+// RegisterTheme makes a custom theme available by name, replacing any
+// built-in theme with the same normalized name
+func RegisterTheme(theme *Theme) error {
+	if theme == nil {
+		return errors.New("theme is nil")
+	}
+	name := normalizeThemeName(theme.Name)
+	if name == "" {
+		return errors.New("theme must have a name")
+	}
+
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	themes[name] = theme
+	registeredThemes[name] = true
+	return nil
+}
+
 // ListThemes returns a list of all available theme names
 func ListThemes() []string {
 	names := make([]string, 0, len(themeFileMap))
 	for name := range themeFileMap {
 		names = append(names, name)
 	}
+
+	themesMu.RLock()
+	for name := range registeredThemes {
+		if _, ok := themeFileMap[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	themesMu.RUnlock()
+
 	sort.Strings(names)
 	return names
 }
@@ -1,49 +1,85 @@
 package term
 
 import (
+	"image"
 	"image/color"
 
 	"github.com/watzon/goshot/fonts"
 )
 
 type TermStyle struct {
-	Args          []string                    // Command and arguments
-	Theme         string                      // The terminal theme to use
-	Font          *fonts.Font                 // The font to use
-	FontSize      float64                     // The font size in points
-	LineHeight    float64                     // The line height multiplier
-	PaddingLeft   int                         // Padding between the code and the left edge
-	PaddingRight  int                         // Padding between the code and the right edge
-	PaddingTop    int                         // Padding between the code and the top edge
-	PaddingBottom int                         // Padding between the code and the bottom edge
-	Width         int                         // Terminal width in cells
-	Height        int                         // Terminal height in cells
-	AutoSize      bool                        // Whether to automatically size the output to the content
-	CellSpacing   int                         // Additional horizontal spacing between cells
-	ShowPrompt    bool                        // Whether to show a prompt
-	PromptFunc    func(command string) string // Template function that returns the prompt text
+	Args           []string                       // Command and arguments
+	Theme          string                         // The terminal theme to use
+	Font           *fonts.Font                    // The font to use
+	FontSize       float64                        // The font size in points
+	FontVariations map[string]float32             // Axis values variable fonts are drawn at, by tag like fonts.AxisWeight
+	LineHeight     float64                        // The line height multiplier
+	PaddingLeft    int                            // Padding between the code and the left edge
+	PaddingRight   int                            // Padding between the code and the right edge
+	PaddingTop     int                            // Padding between the code and the top edge
+	PaddingBottom  int                            // Padding between the code and the bottom edge
+	Width          int                            // Terminal width in cells
+	Height         int                            // Terminal height in cells
+	AutoSize       bool                           // Whether to automatically size the output to the content
+	CellSpacing    int                            // Additional horizontal spacing between cells
+	ShowPrompt     bool                           // Whether to show a prompt
+	PromptFunc     func(command string) string    // Template function that returns the prompt text
+	Prompt         func(ctx PromptContext) string // Function that returns the prompt text from its context, used instead of PromptFunc if set
+	PromptContext  *PromptContext                 // Context passed to Prompt, gathered from the environment if nil
+	Ligatures      bool                           // Whether to render font ligatures
+	KeepAltScreen  bool                           // Whether to render the last alternate screen frame instead of returning to the main screen
+	DimBlink       bool                           // Whether to draw blinking text dimmed
+	Cursor         CursorStyle                    // Shape of the cursor drawn at its final position, none by default
+	CursorColor    color.Color                    // Color of the cursor, the theme's if nil
+	Palette        *Palette                       // ANSI colors used instead of the theme's, if set
 }
 
+// CursorStyle is the shape of the cursor in a terminal capture
+type CursorStyle int
+
+const (
+	CursorNone      CursorStyle = iota // No cursor is drawn
+	CursorBlock                        // A block over the cell, with its character in inverted colors
+	CursorUnderline                    // A line along the bottom of the cell
+	CursorBar                          // A line along the left edge of the cell
+)
+
 type TermRenderer struct {
-	Output []byte
-	Style  *TermStyle
-	theme  *Theme // Store theme here
+	Output     []byte
+	Style      *TermStyle
+	theme      *Theme  // Store theme here
+	imageScale float64 // Scale of inline images, set by Scaled; 1 if zero
 }
 
 type Attributes struct {
-	Bold          bool
-	Italic        bool
-	Underline     bool
-	Strikethrough bool
-	Blink         bool
+	Bold           bool
+	Italic         bool
+	Underline      bool
+	UnderlineStyle UnderlineStyle // Shape of the underline, if Underline is set
+	UnderlineColor color.Color    // Color of the underline, the text's if nil
+	Strikethrough  bool
+	Blink          bool
 }
 
+// UnderlineStyle is the shape of an underline, chosen with SGR 4 and a subparameter
+// such as "4:3"
+type UnderlineStyle int
+
+const (
+	UnderlineSingle UnderlineStyle = iota
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
 type Cell struct {
 	Char    rune
 	FgColor color.Color
 	BgColor color.Color
 	Attrs   Attributes
-	IsWide  bool // For handling wide characters
+	IsWide  bool   // For handling wide characters
+	Link    string // Target of the OSC 8 hyperlink the cell is part of, if any
 }
 
 type Terminal struct {
@@ -55,9 +91,12 @@ type Terminal struct {
 	CurrAttrs     Attributes
 	CurrFg        color.Color
 	CurrBg        color.Color
-	Style         *Theme // Theme colors from theme
-	MaxX          int    // For dynamic sizing
-	MaxY          int    // For dynamic sizing
+	CurrLink      string   // Target of the open OSC 8 hyperlink, if any
+	CursorHidden  bool     // Whether the cursor was hidden with DECTCEM
+	Style         *Theme   // Theme colors from theme
+	Palette       *Palette // ANSI colors used instead of the theme's, if set
+	MaxX          int      // For dynamic sizing
+	MaxY          int      // For dynamic sizing
 	DefaultFg     color.Color
 	DefaultBg     color.Color
 	AutoSize      bool // Whether to automatically size the terminal
@@ -65,4 +104,24 @@ type Terminal struct {
 	PaddingRight  int
 	PaddingTop    int
 	PaddingBottom int
+	KeepAltScreen bool        // Whether leaving the alternate screen keeps its contents
+	Images        []Placement // Inline images, drawn over the cells in order
+	CellWidth     float64     // Width of a cell in pixels, to lay out images; 8 if zero
+	CellHeight    float64     // Height of a cell in pixels, to lay out images; 16 if zero
+	mainScreen    *screen     // Saved main screen while the alternate screen is active
+}
+
+// Placement is an inline image, shown with Sixel or the Kitty graphics protocol
+type Placement struct {
+	Image         image.Image
+	X, Y          int // Cell of the image's top left corner
+	Width, Height int // Size to draw the image at, in pixels
+}
+
+// screen is a saved copy of the terminal's cells, images and cursor
+type screen struct {
+	cells            [][]Cell
+	images           []Placement
+	cursorX, cursorY int
+	maxX, maxY       int
 }
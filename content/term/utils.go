@@ -17,19 +17,49 @@ func getPrefix(seq []byte) string {
 		return "DCS"
 	case ansi.HasApcPrefix(seq):
 		return "APC"
+	case ansi.HasPmPrefix(seq):
+		return "PM"
+	case ansi.HasSosPrefix(seq):
+		return "SOS"
 	default:
 		return ""
 	}
 }
 
+// isStringSequence reports whether seq is a control string (OSC, DCS, APC, PM or
+// SOS), which runs until a BEL or ST terminator
+func isStringSequence(seq []byte) bool {
+	switch getPrefix(seq) {
+	case "OSC", "DCS", "APC", "PM", "SOS":
+		return true
+	default:
+		return false
+	}
+}
+
+// stringSequenceEnd returns the length of the unterminated control string at the
+// start of input, which ends at the first control character after its introducer
+func stringSequenceEnd(input []byte) int {
+	start := 2
+	if input[0] != ansi.ESC {
+		start = 1 // 8-bit introducer
+	}
+	for i := start; i < len(input); i++ {
+		if input[i] < 0x20 || input[i] == ansi.DEL {
+			return i
+		}
+	}
+	return len(input)
+}
+
 // ansiColor returns the color for a standard ANSI color code (0-7)
-func ansiColor(code int, theme *Theme) color.Color {
-	return theme.GetColor(code)
+func ansiColor(code int, t *Terminal) color.Color {
+	return t.paletteColor(code)
 }
 
 // ansiBrightColor returns the color for a bright ANSI color code (8-15)
-func ansiBrightColor(code int, theme *Theme) color.Color {
-	return theme.GetColor(code + 8) // Bright colors start at index 8
+func ansiBrightColor(code int, t *Terminal) color.Color {
+	return t.paletteColor(code + 8) // Bright colors start at index 8
 }
 
 // min returns the smaller of two integers
@@ -0,0 +1,148 @@
+package fonts
+
+import (
+	"github.com/go-text/typesetting/bidi"
+	xbidi "golang.org/x/text/unicode/bidi"
+)
+
+// BidiReorder returns the runes of a line of text in the order they're displayed from
+// left to right, following the Unicode bidirectional algorithm, along with the index
+// in runes of each of them. The line reads left to right unless rtl is set; either way
+// the runs of text in the other direction are reversed, and brackets within right to
+// left runs are mirrored. Only the order is changed: Arabic letters aren't joined.
+func BidiReorder(runes []rune, rtl bool) (visual []rune, order []int) {
+	order = make([]int, len(runes))
+	for i := range order {
+		order[i] = i
+	}
+	if len(runes) == 0 {
+		return runes, order
+	}
+
+	direction := bidi.LeftToRight
+	if rtl {
+		direction = bidi.RightToLeft
+	}
+	var p bidi.Paragraph
+	runs := p.Segment(runes, direction)
+	if runs.NumRuns() == 1 && runs.Run(0).IsLeftToRight() && runs.Run(0).Level == 0 {
+		return runes, order
+	}
+
+	// The level of every rune, and the range of levels to reverse
+	levels := make([]bidi.Level, len(runes))
+	for i := 0; i < runs.NumRuns(); i++ {
+		run := runs.Run(i)
+		level := run.Level
+		if !rtl && level%2 == 0 {
+			level = 0 // Numbers are raised below
+		}
+		for j := run.Start; j < run.End; j++ {
+			levels[j] = level
+		}
+	}
+	if !rtl {
+		raiseNumbers(runes, levels)
+	}
+	highest, lowestOdd := bidi.Level(0), bidi.Level(127)
+	for _, level := range levels {
+		highest = max(highest, level)
+		if level%2 == 1 {
+			lowestOdd = min(lowestOdd, level)
+		}
+	}
+
+	// From the highest level down to the lowest odd one, reverse every sequence of
+	// runes at that level or higher
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for start := 0; start < len(order); {
+			if levels[order[start]] < level {
+				start++
+				continue
+			}
+			end := start
+			for end < len(order) && levels[order[end]] >= level {
+				end++
+			}
+			for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+				order[i], order[j] = order[j], order[i]
+			}
+			start = end
+		}
+	}
+
+	visual = make([]rune, len(runes))
+	for i, idx := range order {
+		visual[i] = runes[idx]
+		if levels[idx]%2 == 1 {
+			visual[i] = mirrorRune(runes[idx])
+		}
+	}
+	return visual, order
+}
+
+// raiseNumbers puts the numbers of right to left text in a left to right line at level
+// 2, so they keep reading left to right (rule I1). Runs only tell odd levels from
+// even ones, and take the level of their first rune, which lumps these numbers
+// together with the left to right text after them.
+func raiseNumbers(runes []rune, levels []bidi.Level) {
+	class := func(i int) xbidi.Class {
+		props, _ := xbidi.LookupRune(runes[i])
+		return props.Class()
+	}
+	numeric := func(i int) bool {
+		switch class(i) {
+		case xbidi.EN, xbidi.AN, xbidi.ET, xbidi.ES, xbidi.CS:
+			return true
+		}
+		return false
+	}
+	separator := func(i int) bool {
+		return class(i) == xbidi.ES || class(i) == xbidi.CS
+	}
+
+	afterRTL := false // Whether the last strong character reads right to left
+	for i := 0; i < len(runes); {
+		switch class(i) {
+		case xbidi.L:
+			afterRTL = false
+		case xbidi.R, xbidi.AL:
+			afterRTL = true
+		}
+		if levels[i] != 0 || !numeric(i) {
+			i++
+			continue
+		}
+
+		// Separators only belong to a number between its digits
+		start, end := i, i
+		for end < len(runes) && levels[end] == 0 && numeric(end) {
+			end++
+		}
+		i = end
+		for start < end && separator(start) {
+			start++
+		}
+		for end > start && separator(end-1) {
+			end--
+		}
+
+		arabic := false
+		for j := start; j < end; j++ {
+			arabic = arabic || class(j) == xbidi.AN
+		}
+		if afterRTL || arabic {
+			for j := start; j < end; j++ {
+				levels[j] = 2
+			}
+		}
+	}
+}
+
+// mirrorRune returns the counterpart of a bracket, like ')' for '(', or r itself
+func mirrorRune(r rune) rune {
+	if props, _ := xbidi.LookupRune(r); props.IsBracket() {
+		return []rune(xbidi.ReverseString(string(r)))[0]
+	}
+	return r
+}
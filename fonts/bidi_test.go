@@ -0,0 +1,41 @@
+package fonts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBidiReorder(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		rtl   bool
+		want  string
+		order []int
+	}{
+		{name: "left to right", text: "abc", want: "abc", order: []int{0, 1, 2}},
+		{name: "hebrew", text: "אבג", want: "גבא", order: []int{2, 1, 0}},
+		{name: "hebrew in code", text: `x = "שלום"`, want: `x = "םולש"`},
+		{name: "numbers stay left to right", text: "abc אב 12 גד", want: "abc דג 12 בא"},
+		{name: "text after numbers", text: `s := "אב 12" + t`, want: `s := "12 בא" + t`},
+		{name: "separators", text: "אב 1.5, גד", want: "דג ,1.5 בא"},
+		{name: "brackets are mirrored", text: "א(ב)", want: "(ב)א"},
+		{name: "right to left line", text: "abc אב", rtl: true, want: "בא abc"},
+		{name: "empty", text: "", want: "", order: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visual, order := BidiReorder([]rune(tt.text), tt.rtl)
+			if string(visual) != tt.want {
+				t.Errorf("BidiReorder(%q) = %q, want %q", tt.text, string(visual), tt.want)
+			}
+			if tt.order != nil && !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("BidiReorder(%q) order = %v, want %v", tt.text, order, tt.order)
+			}
+			if len(order) != len([]rune(tt.text)) {
+				t.Errorf("BidiReorder(%q) returned %d indices for %d runes", tt.text, len(order), len([]rune(tt.text)))
+			}
+		})
+	}
+}
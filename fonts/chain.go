@@ -0,0 +1,215 @@
+package fonts
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontChain is a list of fonts consulted in order for each character, so that text
+// mixing scripts can be drawn even when no single font has all of its characters.
+// Characters none of them have fall back to the bundled emoji font.
+type FontChain []*Font
+
+// NewFontChain creates a FontChain from the fonts, in the order they're consulted.
+// Nil fonts are skipped.
+func NewFontChain(fonts ...*Font) FontChain {
+	chain := make(FontChain, 0, len(fonts))
+	for _, f := range fonts {
+		if f != nil {
+			chain = append(chain, f)
+		}
+	}
+	return chain
+}
+
+// GetFace returns a face that draws each character with the first font of the chain
+// that has it, in the variant that best matches the style. The Font and Style of the
+// face are those of the first font.
+func (c FontChain) GetFace(size float64, style *FontStyle) (*Face, error) {
+	if len(c) == 0 {
+		return nil, fmt.Errorf("font chain is empty")
+	}
+
+	emoji, err := newEmojiFace(size)
+	if err != nil {
+		return nil, err
+	}
+
+	face := &chainFace{emoji: emoji, resolved: make(map[rune]int)}
+	var primary *Font
+	for _, f := range c {
+		variantFace, variant, err := f.variantFace(size, style)
+		if err != nil {
+			face.Close()
+			return nil, err
+		}
+		if primary == nil {
+			primary = variant
+		}
+		face.faces = append(face.faces, variantFace)
+		face.fonts = append(face.fonts, variant.Font)
+	}
+
+	// The face is of the primary font's style, at the variations asked for
+	faceStyle := primary.Style
+	if style != nil {
+		faceStyle.Variations = style.Variations
+	}
+	return &Face{
+		Font:  primary,
+		Style: faceStyle,
+		Size:  size,
+		Face:  face,
+	}, nil
+}
+
+// FontFor returns the first font of the chain with a glyph for r, or nil if none has one
+func (c FontChain) FontFor(r rune) *Font {
+	for _, f := range c {
+		if f.HasGlyph(r) {
+			return f
+		}
+	}
+	return nil
+}
+
+// HasGlyph reports whether the font itself has a glyph for r, rather than one of the
+// fonts its faces fall back to
+func (f *Font) HasGlyph(r rune) bool {
+	if f == nil || f.Font == nil {
+		return false
+	}
+	var buf sfnt.Buffer
+	i, err := f.Font.GlyphIndex(&buf, r)
+	return err == nil && i != 0
+}
+
+// What chainFace.resolve returns for characters that aren't drawn by one of the faces
+const (
+	resolvedEmoji     = -1 // Drawn with the emoji font
+	resolvedInvisible = -2 // Not drawn at all, and takes up no room
+)
+
+// chainFace draws each character with the first of its faces that has it, falling
+// back to emoji
+type chainFace struct {
+	faces    []font.Face  // Faces of the fonts of the chain, in order
+	fonts    []*sfnt.Font // Fonts of the faces
+	buf      sfnt.Buffer
+	emoji    *emojiFace
+	resolved map[rune]int // Index of the face drawing each character seen so far
+}
+
+// resolve returns the index of the face that draws r, resolvedEmoji or
+// resolvedInvisible. Characters nobody has are drawn by the first face, as its
+// missing glyph.
+func (f *chainFace) resolve(r rune) int {
+	if i, ok := f.resolved[r]; ok {
+		return i
+	}
+
+	i := f.find(r)
+	if i < 0 {
+		switch {
+		case isInvisible(r):
+			i = resolvedInvisible
+		case f.emoji.glyph(r) != nil:
+			i = resolvedEmoji
+		default:
+			i = 0
+		}
+	}
+	f.resolved[r] = i
+	return i
+}
+
+// find returns the index of the first font with a glyph for r, or -1
+func (f *chainFace) find(r rune) int {
+	for i, sf := range f.fonts {
+		if gi, err := sf.GlyphIndex(&f.buf, r); err == nil && gi != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *chainFace) Close() error {
+	for _, face := range f.faces {
+		if closer, ok := face.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return nil
+}
+
+func (f *chainFace) Glyph(dot fixed.Point26_6, r rune) (
+	dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+
+	switch i := f.resolve(r); i {
+	case resolvedEmoji:
+		// Drawn through a mask the glyph keeps its shape, if not its colors
+		g := f.emoji.glyph(r)
+		dr = g.img.Bounds().Add(image.Point{X: dot.X.Round(), Y: dot.Y.Round()}).Add(g.offset)
+		return dr, g.img, image.Point{}, g.advance, true
+	case resolvedInvisible:
+		return image.Rectangle{}, nil, image.Point{}, 0, true
+	default:
+		return f.faces[i].Glyph(dot, r)
+	}
+}
+
+func (f *chainFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	switch i := f.resolve(r); i {
+	case resolvedEmoji:
+		g := f.emoji.glyph(r)
+		rect := g.img.Bounds().Add(g.offset)
+		return fixed.R(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y), g.advance, true
+	case resolvedInvisible:
+		return fixed.Rectangle26_6{}, 0, true
+	default:
+		return f.faces[i].GlyphBounds(r)
+	}
+}
+
+func (f *chainFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	switch i := f.resolve(r); i {
+	case resolvedEmoji:
+		return f.emoji.glyph(r).advance, true
+	case resolvedInvisible:
+		return 0, true
+	default:
+		return f.faces[i].GlyphAdvance(r)
+	}
+}
+
+// Kern only applies between characters drawn by the same face
+func (f *chainFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	i := f.resolve(r0)
+	if i < 0 || i != f.resolve(r1) {
+		return 0
+	}
+	return f.faces[i].Kern(r0, r1)
+}
+
+// Metrics are those of the first face, so lines keep the same height whatever
+// characters they have
+func (f *chainFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
+
+// DrawColorGlyph implements the ColorFace interface
+func (f *chainFace) DrawColorGlyph(dst draw.Image, dot fixed.Point26_6, r rune) (advance fixed.Int26_6, ok bool) {
+	if f.resolve(r) != resolvedEmoji {
+		return 0, false
+	}
+	g := f.emoji.glyph(r)
+	dr := g.img.Bounds().Add(image.Point{X: dot.X.Round(), Y: dot.Y.Round()}).Add(g.offset)
+	draw.Draw(dst, dr, g.img, image.Point{}, draw.Over)
+	return g.advance, true
+}
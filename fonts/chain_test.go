@@ -0,0 +1,61 @@
+package fonts
+
+import (
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+func TestFontChain(t *testing.T) {
+	cantarell, err := GetFont("Cantarell", nil)
+	if err != nil {
+		t.Fatalf("Failed to get font Cantarell: %v", err)
+	}
+	mono, err := GetFallback(FallbackMono)
+	if err != nil {
+		t.Fatalf("Failed to get fallback font: %v", err)
+	}
+
+	chain := NewFontChain(cantarell, nil, mono)
+	if len(chain) != 2 {
+		t.Fatalf("NewFontChain() kept %d fonts, want 2", len(chain))
+	}
+	if got := chain.FontFor('a'); got != cantarell {
+		t.Errorf("FontFor('a') = %v, want Cantarell", got.Name)
+	}
+	if got := chain.FontFor('→'); got != mono {
+		t.Errorf("FontFor('→') = %v, want %v", got, mono.Name)
+	}
+	if got := chain.FontFor('日'); got != nil {
+		t.Errorf("FontFor('日') = %v, want nil", got.Name)
+	}
+
+	face, err := chain.GetFace(16, nil)
+	if err != nil {
+		t.Fatalf("GetFace() error = %v", err)
+	}
+	defer face.Close()
+	if face.Font.Name != cantarell.Name {
+		t.Errorf("Face font = %s, want %s", face.Font.Name, cantarell.Name)
+	}
+
+	// Each character is measured with the font that draws it
+	for _, tt := range []struct {
+		r    rune
+		font *Font
+	}{{'a', cantarell}, {'→', mono}} {
+		single, err := tt.font.GetFace(16, nil)
+		if err != nil {
+			t.Fatalf("GetFace() error = %v", err)
+		}
+		want, _ := single.Face.GlyphAdvance(tt.r)
+		single.Close()
+
+		if got, ok := face.Face.GlyphAdvance(tt.r); !ok || got != want {
+			t.Errorf("GlyphAdvance(%q) = %v, want %v", tt.r, got, want)
+		}
+	}
+	if font.MeasureString(face.Face, "a→") == 0 {
+		t.Error("MeasureString() of mixed text is empty")
+	}
+}
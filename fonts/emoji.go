@@ -0,0 +1,160 @@
+package fonts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"sync"
+
+	tsfont "github.com/go-text/typesetting/font"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// The emoji font is parsed once, the first time a face needs it
+var (
+	emojiFont     *tsfont.Font
+	emojiFontErr  error
+	emojiFontOnce sync.Once
+)
+
+// getEmojiFont returns the parsed bundled emoji font, or nil if it was left out of the
+// build
+func getEmojiFont() (*tsfont.Font, error) {
+	emojiFontOnce.Do(func() {
+		if emojiFontData == nil {
+			return
+		}
+		face, err := tsfont.ParseTTF(bytes.NewReader(emojiFontData))
+		if err != nil {
+			emojiFontErr = fmt.Errorf("failed to parse emoji font: %v", err)
+			return
+		}
+		emojiFont = face.Font
+	})
+	return emojiFont, emojiFontErr
+}
+
+// ColorFace is implemented by faces with color glyphs, such as the faces returned by
+// GetFace, which fall back to emoji. A font.Drawer can only fill glyphs with a single
+// color, so those are drawn with DrawColorGlyph instead; DrawString takes care of that.
+type ColorFace interface {
+	font.Face
+
+	// DrawColorGlyph draws the color glyph of r with its pen position at dot. It reports
+	// false, without drawing anything, if r doesn't have a color glyph.
+	DrawColorGlyph(dst draw.Image, dot fixed.Point26_6, r rune) (advance fixed.Int26_6, ok bool)
+}
+
+// DrawString draws s like d.DrawString, except that the color glyphs of faces
+// implementing ColorFace are drawn in their own colors
+func DrawString(d *font.Drawer, s string) {
+	cf, ok := d.Face.(ColorFace)
+	if !ok {
+		d.DrawString(s)
+		return
+	}
+
+	prev := rune(-1)
+	for _, r := range s {
+		if prev >= 0 {
+			d.Dot.X += d.Face.Kern(prev, r)
+		}
+		if advance, ok := cf.DrawColorGlyph(d.Dst, d.Dot, r); ok {
+			d.Dot.X += advance
+		} else {
+			d.DrawString(string(r))
+		}
+		prev = r
+	}
+}
+
+// isInvisible reports whether r only changes how the characters around it are shown,
+// like the variation selector that asks for the emoji presentation of a character
+func isInvisible(r rune) bool {
+	return r == '\u200d' || (r >= '\ufe00' && r <= '\ufe0f')
+}
+
+// emojiGlyph is a color glyph scaled to the size of a face
+type emojiGlyph struct {
+	img     *image.RGBA
+	offset  image.Point // Position of the top left corner of the image relative to the pen
+	advance fixed.Int26_6
+}
+
+// emojiFace draws the bitmaps of the bundled emoji font at a given size
+type emojiFace struct {
+	face   *tsfont.Face
+	scale  float64 // Pixels per font unit
+	glyphs map[rune]*emojiGlyph
+}
+
+// newEmojiFace returns a face of the emoji font at size. The face is nil if the font was
+// left out of the build, which a nil face handles as a font without glyphs.
+func newEmojiFace(size float64) (*emojiFace, error) {
+	f, err := getEmojiFont()
+	if err != nil || f == nil {
+		return nil, err
+	}
+	return &emojiFace{
+		face:   tsfont.NewFace(f),
+		scale:  size / float64(f.Upem()), // At 72 DPI a point is a pixel
+		glyphs: make(map[rune]*emojiGlyph),
+	}, nil
+}
+
+// glyph returns the glyph of r scaled to the face, or nil if the font doesn't have one
+func (e *emojiFace) glyph(r rune) *emojiGlyph {
+	if e == nil {
+		return nil
+	}
+	if g, ok := e.glyphs[r]; ok {
+		return g
+	}
+
+	g := e.loadGlyph(r)
+	e.glyphs[r] = g
+	return g
+}
+
+func (e *emojiFace) loadGlyph(r rune) *emojiGlyph {
+	gid, ok := e.face.NominalGlyph(r)
+	if !ok {
+		return nil
+	}
+	bitmap, ok := e.face.GlyphDataBitmap(gid)
+	if !ok || bitmap.Format != tsfont.PNG {
+		return nil
+	}
+	src, err := png.Decode(bytes.NewReader(bitmap.Data))
+	if err != nil {
+		return nil
+	}
+	extents, ok := e.face.GlyphExtents(gid)
+	if !ok {
+		return nil
+	}
+
+	// The extents place the bitmap around the pen, with y growing upwards
+	rect := image.Rect(
+		int(math.Round(float64(extents.XBearing)*e.scale)),
+		int(math.Round(float64(-extents.YBearing)*e.scale)),
+		int(math.Round(float64(extents.XBearing+extents.Width)*e.scale)),
+		int(math.Round(float64(-extents.YBearing-extents.Height)*e.scale)),
+	)
+	if rect.Empty() {
+		return nil
+	}
+	img := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	xdraw.CatmullRom.Scale(img, img.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	return &emojiGlyph{
+		img:     img,
+		offset:  rect.Min,
+		advance: fixed.Int26_6(math.Round(float64(e.face.HorizontalAdvance(gid)) * e.scale * 64)),
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !noemoji
+
+package fonts
+
+import _ "embed"
+
+// emojiFontData is Noto Color Emoji, whose glyphs are PNG bitmaps (CBDT). Building with
+// the noemoji tag leaves it out, which makes binaries about 11MB smaller.
+//
+//go:embed emoji/NotoColorEmoji.ttf
+var emojiFontData []byte
@@ -0,0 +1,7 @@
+//go:build noemoji
+
+package fonts
+
+// emojiFontData is left empty by the noemoji tag, so characters none of the fonts of a
+// chain have are drawn as missing glyphs
+var emojiFontData []byte
@@ -0,0 +1,65 @@
+package fonts
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestEmojiFallback(t *testing.T) {
+	if emojiFontData == nil {
+		t.Skip("Built without the emoji font")
+	}
+	f, err := GetFallback(FallbackMono)
+	if err != nil {
+		t.Fatalf("Failed to get fallback font: %v", err)
+	}
+	face, err := f.GetFace(16, nil)
+	if err != nil {
+		t.Fatalf("GetFace() error = %v", err)
+	}
+	defer face.Close()
+
+	if f.HasGlyph('🚀') || !f.HasGlyph('a') {
+		t.Errorf("HasGlyph() should only report the glyphs of the font itself")
+	}
+
+	// Emoji are about twice as wide as the characters of a monospace font
+	rocket, ok := face.Face.GlyphAdvance('🚀')
+	if !ok {
+		t.Fatal("Failed to get advance width for emoji")
+	}
+	letter, _ := face.Face.GlyphAdvance('a')
+	if diff := rocket.Round() - 2*letter.Round(); diff < -2 || diff > 2 {
+		t.Errorf("Emoji advance width = %d, want about %d", rocket.Round(), 2*letter.Round())
+	}
+
+	// The variation selector asking for the emoji presentation takes up no room
+	if got, want := font.MeasureString(face.Face, "🚀️"), font.MeasureString(face.Face, "🚀"); got != want {
+		t.Errorf("Width with variation selector = %v, want %v", got, want)
+	}
+
+	// The emoji keeps its colors instead of being filled with the text color
+	img := image.NewRGBA(image.Rect(0, 0, 40, 24))
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(color.White), Face: face.Face, Dot: fixed.P(2, 18)}
+	DrawString(d, "🚀")
+	if want := fixed.I(2) + rocket; d.Dot.X != want {
+		t.Errorf("DrawString() advanced to %v, want %v", d.Dot.X, want)
+	}
+
+	colored := 0
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 40; x++ {
+			c := img.RGBAAt(x, y)
+			if c.A > 0 && (c.R != c.G || c.G != c.B) {
+				colored++
+			}
+		}
+	}
+	if colored == 0 {
+		t.Error("DrawString() didn't draw the emoji in color")
+	}
+}
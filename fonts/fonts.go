@@ -1,12 +1,13 @@
 package fonts
 
 import (
+	"crypto/sha256"
 	"embed"
 	"fmt"
 	"image"
 	"io"
 	"io/fs"
-	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -14,6 +15,7 @@ import (
 	"strings"
 	"sync"
 
+	tsfont "github.com/go-text/typesetting/font"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
@@ -51,6 +53,11 @@ type FontStyle struct {
 	Italic    bool        // Whether the font is italic
 	Underline bool        // Whether the font should be underlined
 	Mono      bool        // Whether the font is monospaced
+
+	// Values of the axes of variable fonts by tag, like AxisWeight, in the units of
+	// the axes. Fonts without an axis ignore its value, and other axes keep their
+	// defaults.
+	Variations map[string]float32
 }
 
 // FontWeight represents the weight of a font
@@ -89,8 +96,12 @@ type Font struct {
 	Filename    string         // Name of the font file
 	IsMonospace bool           // Whether the font is monospaced
 	Style       FontStyle      // Font style
+	data        []byte         // Contents of the font file, for fonts loaded with LoadFont
 	maxWidth    fixed.Int26_6  // Maximum glyph width (lazy loaded)
 	maxWidthMu  sync.Once      // Ensures maxWidth is computed only once
+	shaping     *tsfont.Font   // Font parsed for the text shaper (lazy loaded)
+	shapingErr  error          // Error encountered while parsing the font for the shaper
+	shapingOnce sync.Once      // Ensures the shaping font is parsed only once
 }
 
 // Face represents a font face with specific style and size
@@ -101,8 +112,15 @@ type Face struct {
 	Face  font.Face
 }
 
-// GetFace returns a new Face with the specified style and size
+// GetFace returns a new Face with the specified style and size. Characters the font
+// doesn't have, like emoji, are drawn with the bundled emoji font.
 func (f *Font) GetFace(size float64, style *FontStyle) (*Face, error) {
+	return NewFontChain(f).GetFace(size, style)
+}
+
+// variantFace returns a face of the variant of the font that best matches the style,
+// along with the variant
+func (f *Font) variantFace(size float64, style *FontStyle) (font.Face, *Font, error) {
 	if style == nil {
 		style = &FontStyle{
 			Weight:  WeightRegular,
@@ -113,7 +131,7 @@ func (f *Font) GetFace(size float64, style *FontStyle) (*Face, error) {
 	// Try to find a font variant that matches our style
 	variants, err := GetFontVariants(f.Name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get font variants: %v", err)
+		return nil, nil, fmt.Errorf("failed to get font variants: %v", err)
 	}
 
 	// Find the best matching variant
@@ -133,15 +151,14 @@ func (f *Font) GetFace(size float64, style *FontStyle) (*Face, error) {
 		Hinting: font.HintingFull,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create face: %v", err)
+		return nil, nil, fmt.Errorf("failed to create face: %v", err)
 	}
-
-	return &Face{
-		Font:  bestVariant,
-		Style: bestVariant.Style,
-		Size:  size,
-		Face:  face,
-	}, nil
+	if len(style.Variations) > 0 {
+		if varied, ok := bestVariant.variableFace(face, size, style.Variations); ok {
+			return varied, bestVariant, nil
+		}
+	}
+	return face, bestVariant, nil
 }
 
 // Close releases the resources used by the face
@@ -158,19 +175,9 @@ func (f *Font) ToTrueType() (*truetype.Font, error) {
 	}
 
 	// Get the raw font data
-	var data []byte
-	var err error
-
-	if f.FilePath != "" {
-		data, err = os.ReadFile(f.FilePath)
-	} else if f.Filename != "" {
-		data, err = embeddedFonts.ReadFile("embedded/" + f.Filename)
-	} else {
-		return nil, fmt.Errorf("no font data available")
-	}
-
+	data, err := f.rawData()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read font data: %v", err)
+		return nil, err
 	}
 
 	// Parse as truetype font
@@ -264,6 +271,39 @@ func (f *Font) MeasureString(s string, size float64, style *FontStyle) (fixed.In
 	return font.MeasureString(face.Face, s), nil
 }
 
+// MeasureStringPixels returns the width of a string in whole pixels, rounded the way
+// the renderers round the widths they lay text out with
+func (f *Font) MeasureStringPixels(s string, size float64, style *FontStyle) (int, error) {
+	width, err := f.MeasureString(s, size, style)
+	if err != nil {
+		return 0, err
+	}
+	return width.Round(), nil
+}
+
+// LineHeightPixels returns the height in whole pixels of lines of text in the regular
+// style of the font at the given size, multiplier times the font's own line height,
+// like the lines of rendered code
+func (f *Font) LineHeightPixels(size, multiplier float64) (int, error) {
+	face, err := f.GetFace(size, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer face.Close()
+
+	return LineHeight(face.Face.Metrics(), multiplier), nil
+}
+
+// LineHeight returns the height in whole pixels of lines multiplier times as tall as
+// the line height in the metrics. Lines are never shorter than the glyphs reach above
+// and below a baseline drawn at the rounded ascent, which some fonts' line heights
+// are at small sizes, so a multiplier of 0 gives the shortest lines that fit.
+func LineHeight(metrics font.Metrics, multiplier float64) int {
+	minimum := metrics.Ascent.Round() + metrics.Descent.Ceil()
+	natural := max(metrics.Height.Round(), minimum)
+	return max(int(math.Round(float64(natural)*multiplier)), minimum)
+}
+
 // GetMonoFace returns a font face that will render with fixed-width characters
 // size is the desired font size in points
 // cellWidth is the desired cell width in pixels (if 0, uses the font's natural maximum width)
@@ -470,8 +510,36 @@ const (
 	FallbackMono FallbackVariant = "mono"
 )
 
-// GetFallback returns either JetBrainsMono or Inter as the fallback font
+// pinnedFallbacks holds the fallback fonts set with PinFallback
+var (
+	pinnedFallbacks   = make(map[FallbackVariant]*Font)
+	pinnedFallbacksMu sync.RWMutex
+)
+
+// PinFallback makes GetFallback return font for the variant, instead of looking up
+// the bundled JetBrainsMono or Inter. Everything drawn in the default fonts, like
+// code without a font of its own and window titles, then uses it. A nil font goes
+// back to the default.
+func PinFallback(variant FallbackVariant, font *Font) {
+	pinnedFallbacksMu.Lock()
+	defer pinnedFallbacksMu.Unlock()
+	if font == nil {
+		delete(pinnedFallbacks, variant)
+	} else {
+		pinnedFallbacks[variant] = font
+	}
+}
+
+// GetFallback returns either JetBrainsMono or Inter as the fallback font, or the font
+// pinned with PinFallback
 func GetFallback(variant FallbackVariant) (font *Font, err error) {
+	pinnedFallbacksMu.RLock()
+	pinned := pinnedFallbacks[variant]
+	pinnedFallbacksMu.RUnlock()
+	if pinned != nil {
+		return pinned, nil
+	}
+
 	switch variant {
 	case FallbackMono:
 		font, err = GetFont("JetBrainsMonoNerdFont", nil) // Let GetFont handle style selection
@@ -538,111 +606,45 @@ func GetFontVariants(name string) ([]*Font, error) {
 	}
 	fontCacheMu.RUnlock()
 
-	var variants []*Font
-
-	// First check embedded fonts
-	entries, err := embeddedFonts.ReadDir("embedded")
-	if err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
-
-			cleanName := cleanFontName(entry.Name())
-			if cleanName == name {
-				data, err := embeddedFonts.ReadFile("embedded/" + entry.Name())
-				if err != nil {
-					continue
-				}
-
-				font, err := opentype.Parse(data)
-				if err != nil {
-					continue
-				}
-
-				// Convert to truetype to check if monospace
-				ttf, err := truetype.Parse(data)
-				if err != nil {
-					continue
-				}
-
-				variant := &Font{
-					Name:        cleanName,
-					Font:        font,
-					Filename:    entry.Name(),
-					IsMonospace: detectMonospace(ttf),
-					Style:       extractFontStyle(entry.Name()),
-				}
-				variants = append(variants, variant)
-			}
-		}
-	}
+	// The font directories are only walked once, and looked up afterwards
+	variants := registeredVariants(name)
+	index := fontFiles()
 
 	// Search system fonts
 	osType := runtime.GOOS
-	paths, ok := systemFontPaths[osType]
-	if !ok {
+	if _, ok := systemFontPaths[osType]; !ok && len(index.embedded[name]) == 0 && len(variants) == 0 {
 		return nil, fmt.Errorf("unsupported OS: %s", osType)
 	}
 
-	for _, basePath := range paths {
-		// Expand home directory if needed
-		if strings.HasPrefix(basePath, "~") {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				continue
-			}
-			basePath = filepath.Join(home, basePath[2:])
+	// First check embedded fonts
+	for _, filename := range index.embedded[name] {
+		parsed, err := loadFontFile("embedded/"+filename, embeddedFonts.ReadFile)
+		if err != nil {
+			continue
 		}
 
-		err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip this file/directory
-			}
-
-			if info.IsDir() {
-				return nil
-			}
-
-			ext := strings.ToLower(filepath.Ext(path))
-			if ext != ".ttf" && ext != ".otf" {
-				return nil
-			}
-
-			cleanName := cleanFontName(info.Name())
-			if cleanName == name {
-				data, err := os.ReadFile(path)
-				if err != nil {
-					return nil
-				}
-
-				font, err := opentype.Parse(data)
-				if err != nil {
-					return nil
-				}
-
-				// Convert to truetype to check if monospace
-				ttf, err := truetype.Parse(data)
-				if err != nil {
-					return nil
-				}
-
-				variant := &Font{
-					Name:        cleanName,
-					Font:        font,
-					FilePath:    path,
-					IsMonospace: detectMonospace(ttf),
-					Style:       extractFontStyle(filepath.Base(path)),
-				}
-				variants = append(variants, variant)
-			}
-
-			return nil
+		variants = append(variants, &Font{
+			Name:        name,
+			Font:        parsed.font,
+			Filename:    filename,
+			IsMonospace: parsed.mono,
+			Style:       extractFontStyle(filename),
 		})
+	}
 
+	for _, path := range index.paths[name] {
+		parsed, err := loadFontFile(path, os.ReadFile)
 		if err != nil {
-			log.Printf("Error walking font directory %s: %v", basePath, err)
+			continue
 		}
+
+		variants = append(variants, &Font{
+			Name:        name,
+			Font:        parsed.font,
+			FilePath:    path,
+			IsMonospace: parsed.mono,
+			Style:       extractFontStyle(filepath.Base(path)),
+		})
 	}
 
 	if len(variants) == 0 {
@@ -718,85 +720,21 @@ func extractFontStyle(filename string) FontStyle {
 }
 
 func ListFonts() []string {
-	var fonts []string
-	seen := make(map[string]bool)
-
-	// List embedded fonts first
-	if entries, err := embeddedFonts.ReadDir("embedded"); err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
-
-			name := cleanFontName(entry.Name())
-			if !seen[name] {
-				seen[name] = true
-				fonts = append(fonts, name)
-			}
-		}
-	}
-
-	paths := systemFontPaths[runtime.GOOS]
-	if len(paths) == 0 {
+	if len(systemFontPaths[runtime.GOOS]) == 0 {
 		fmt.Printf("No font paths found for OS %s\n", runtime.GOOS)
-		return fonts
-	}
-
-	// Expand home directory if necessary
-	if home, err := os.UserHomeDir(); err == nil {
-		for i, path := range paths {
-			if strings.HasPrefix(path, "~") {
-				paths[i] = filepath.Join(home, path[2:])
-			}
-		}
 	}
 
-	// Walk through each font directory
-	for _, dir := range paths {
-		// Skip directories that don't exist
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			continue
-		}
-
-		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				fmt.Printf("Error accessing path %s: %v\n", path, err)
-				return nil // Skip files we can't access
-			}
-
-			// Skip directories
-			if d.IsDir() {
-				return nil
-			}
-
-			// Check if it's a font file
-			ext := strings.ToLower(filepath.Ext(path))
-			if ext != ".ttf" && ext != ".otf" {
-				return nil
-			}
-
-			// Get the base name without extension
-			name := filepath.Base(path)
-			name = name[:len(name)-len(ext)]
-
-			// Clean up common suffixes
-			name = cleanFontName(name)
-
-			// Add to list if we haven't seen it
-			if !seen[name] {
-				seen[name] = true
-				fonts = append(fonts, name)
+	// Embedded fonts come first, and registered ones last
+	index := fontFiles()
+	names := append([]string(nil), index.names...)
+	for _, name := range registeredNames() {
+		if _, ok := index.embedded[name]; !ok {
+			if _, ok := index.paths[name]; !ok {
+				names = append(names, name)
 			}
-
-			return nil
-		})
-		if err != nil {
-			fmt.Printf("Error walking directory %s: %v\n", dir, err)
-			continue // Skip directories we can't access
 		}
 	}
-
-	return fonts
+	return names
 }
 
 // cleanFontName removes common suffixes and normalizes the font name
@@ -965,9 +903,19 @@ func (m *multiFS) Open(name string) (fs.File, error) {
 	return nil, os.ErrNotExist
 }
 
-// ClearCache clears the font cache
+// ClearCache clears the font cache, along with the parsed font files and the index of
+// the system font directories, so that fonts installed since are found
 func ClearCache() {
 	fontCacheMu.Lock()
 	fontCache = make(map[string][]*Font)
 	fontCacheMu.Unlock()
+
+	parseCacheMu.Lock()
+	parseCache = make(map[[sha256.Size]byte]*parsedFont)
+	fileCache = make(map[string]*parsedFont)
+	parseCacheMu.Unlock()
+
+	fontIndexMu.Lock()
+	fontIndex = nil
+	fontIndexMu.Unlock()
 }
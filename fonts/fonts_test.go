@@ -1,8 +1,11 @@
 package fonts
 
 import (
+	"bytes"
 	"testing"
 
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -244,6 +247,51 @@ func TestGetMaxWidth(t *testing.T) {
 	}
 }
 
+func TestMeasurePixels(t *testing.T) {
+	f, err := GetFont("JetBrainsMonoNerdFont", nil)
+	if err != nil {
+		t.Fatalf("Failed to get font: %v", err)
+	}
+
+	width, err := f.MeasureString("hello", 14, nil)
+	if err != nil {
+		t.Fatalf("MeasureString() error = %v", err)
+	}
+	pixels, err := f.MeasureStringPixels("hello", 14, nil)
+	if err != nil {
+		t.Fatalf("MeasureStringPixels() error = %v", err)
+	}
+	if pixels != width.Round() || pixels <= 0 {
+		t.Errorf("MeasureStringPixels() = %d, want %d", pixels, width.Round())
+	}
+
+	face, err := f.GetFace(14, nil)
+	if err != nil {
+		t.Fatalf("GetFace() error = %v", err)
+	}
+	metrics := face.Face.Metrics()
+	face.Close()
+	minimum := metrics.Ascent.Round() + metrics.Descent.Ceil()
+
+	single, err := f.LineHeightPixels(14, 1)
+	if err != nil {
+		t.Fatalf("LineHeightPixels() error = %v", err)
+	}
+	double, err := f.LineHeightPixels(14, 2)
+	if err != nil {
+		t.Fatalf("LineHeightPixels() error = %v", err)
+	}
+	if single < minimum {
+		t.Errorf("LineHeightPixels(14, 1) = %d, shorter than the glyphs' %d", single, minimum)
+	}
+	if double != 2*single {
+		t.Errorf("LineHeightPixels(14, 2) = %d, want %d", double, 2*single)
+	}
+	if got := LineHeight(metrics, 0); got != minimum {
+		t.Errorf("LineHeight(metrics, 0) = %d, want %d", got, minimum)
+	}
+}
+
 func TestGetMonoFace(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -319,3 +367,231 @@ func TestGetMonoFace(t *testing.T) {
 		})
 	}
 }
+
+// clearFamilyCache forgets the families looked up so far, but not the parsed files
+func clearFamilyCache() {
+	fontCacheMu.Lock()
+	fontCache = make(map[string][]*Font)
+	fontCacheMu.Unlock()
+}
+
+func TestWarmCache(t *testing.T) {
+	ClearCache()
+	WarmCache()
+
+	fontCacheMu.RLock()
+	cached := fontCache["Cantarell"]
+	fontCacheMu.RUnlock()
+	if len(cached) == 0 {
+		t.Fatal("WarmCache() didn't load the bundled fonts")
+	}
+
+	// Looking the family up again reuses the parsed files
+	clearFamilyCache()
+	variants, err := GetFontVariants("Cantarell")
+	if err != nil {
+		t.Fatalf("GetFontVariants() error = %v", err)
+	}
+	for i, variant := range variants {
+		if variant.Font != cached[i].Font {
+			t.Errorf("GetFontVariants() parsed %s again", variant.Filename)
+		}
+	}
+}
+
+func TestUseEmbeddedOnly(t *testing.T) {
+	UseEmbeddedOnly(true)
+	defer UseEmbeddedOnly(false)
+
+	bundled := map[string]bool{"Cantarell": true, "Inter": true, "JetBrainsMonoNerdFont": true}
+	for _, name := range ListFonts() {
+		if !bundled[name] {
+			t.Errorf("ListFonts() returned %s, which isn't bundled", name)
+		}
+	}
+	if _, err := GetFont("Inter", nil); err != nil {
+		t.Errorf("GetFont() error = %v", err)
+	}
+
+	// Looking the family up again picks the same files in the same order
+	first, err := GetFontVariants("JetBrainsMonoNerdFont")
+	if err != nil {
+		t.Fatalf("GetFontVariants() error = %v", err)
+	}
+	UseEmbeddedOnly(true)
+	second, err := GetFontVariants("JetBrainsMonoNerdFont")
+	if err != nil {
+		t.Fatalf("GetFontVariants() error = %v", err)
+	}
+	if len(first) != len(second) {
+		t.Fatalf("GetFontVariants() returned %d variants, then %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].Filename != second[i].Filename {
+			t.Errorf("variant %d is %s, then %s", i, first[i].Filename, second[i].Filename)
+		}
+	}
+}
+
+func TestPinFallback(t *testing.T) {
+	pinned, err := GetFont("Cantarell", nil)
+	if err != nil {
+		t.Fatalf("GetFont() error = %v", err)
+	}
+	PinFallback(FallbackSans, pinned)
+	defer PinFallback(FallbackSans, nil)
+
+	if font, err := GetFallback(FallbackSans); err != nil || font != pinned {
+		t.Errorf("GetFallback(FallbackSans) = %v, %v, want the pinned font", font, err)
+	}
+	if font, err := GetFallback(FallbackMono); err != nil || font == pinned {
+		t.Errorf("GetFallback(FallbackMono) = %v, %v, want JetBrainsMono", font, err)
+	}
+
+	PinFallback(FallbackSans, nil)
+	if font, err := GetFallback(FallbackSans); err != nil || font.Name != "Inter" {
+		t.Errorf("GetFallback(FallbackSans) after unpinning = %v, %v, want Inter", font, err)
+	}
+}
+
+func TestRegisterFont(t *testing.T) {
+	defer func() {
+		registeredFontsMu.Lock()
+		delete(registeredFonts, "Acme")
+		registeredFontsMu.Unlock()
+		clearFamilyCache()
+	}()
+
+	if _, err := LoadFont("Acme", []byte("not a font")); err == nil {
+		t.Error("LoadFont() of data that isn't a font should fail")
+	}
+	if _, err := GetFont("Acme", nil); err == nil {
+		t.Fatal("GetFont() found Acme before it was registered")
+	}
+
+	regular, err := embeddedFonts.ReadFile("embedded/Cantarell-Regular.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bold, err := embeddedFonts.ReadFile("embedded/Cantarell-Bold.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := LoadFont("Acme-Regular.ttf", regular)
+	if err != nil {
+		t.Fatalf("LoadFont() error = %v", err)
+	}
+	if plain.Name != "Acme" || plain.Style.Weight != WeightRegular {
+		t.Errorf("LoadFont() = %s weighing %d, want Acme weighing %d", plain.Name, plain.Style.Weight, WeightRegular)
+	}
+	// Without a style in its name, the font's own is used
+	heavy, err := LoadFontFrom("Acme", bytes.NewReader(bold))
+	if err != nil {
+		t.Fatalf("LoadFont() error = %v", err)
+	}
+	if heavy.Style.Weight != WeightBold {
+		t.Errorf("LoadFont() of a bold font without a style weighs %d, want %d", heavy.Style.Weight, WeightBold)
+	}
+
+	RegisterFont(plain)
+	if font, err := GetFont("Acme", nil); err != nil || font != plain {
+		t.Errorf("GetFont() = %v, %v, want the registered font", font, err)
+	}
+	RegisterFont(heavy)
+	if font, err := GetFont("Acme", &FontStyle{Weight: WeightBold}); err != nil || font != heavy {
+		t.Errorf("GetFont() of the bold variant = %v, %v, want the font registered last", font, err)
+	}
+
+	// Registering the same style again replaces it
+	again, err := LoadFont("Acme-Regular", regular)
+	if err != nil {
+		t.Fatalf("LoadFont() error = %v", err)
+	}
+	RegisterFont(again)
+	if variants, err := GetFontVariants("Acme"); err != nil || len(variants) != 2 {
+		t.Errorf("GetFontVariants() = %d variants, %v, want 2", len(variants), err)
+	}
+
+	// Registrations outlive the caches
+	ClearCache()
+	if font, err := GetFont("Acme", nil); err != nil || font != again {
+		t.Errorf("GetFont() after ClearCache() = %v, %v, want the registered font", font, err)
+	}
+	found := false
+	for _, name := range ListFonts() {
+		found = found || name == "Acme"
+	}
+	if !found {
+		t.Error("ListFonts() doesn't list the registered family")
+	}
+
+	// Fonts loaded from bytes can be shaped without a file to read
+	if glyphs, err := again.Shape("Acme", 16, true); err != nil || len(glyphs) == 0 {
+		t.Errorf("Shape() = %d glyphs, %v", len(glyphs), err)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	font, err := GetFont("JetBrainsMonoNerdFont", nil)
+	if err != nil {
+		t.Fatalf("GetFont() error = %v", err)
+	}
+	full, err := font.rawData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := font.Subset("Hé")
+	if err != nil {
+		t.Fatalf("Subset() error = %v", err)
+	}
+	if len(data) >= len(full) {
+		t.Errorf("Subset() is %d bytes, no smaller than the %d of the font", len(data), len(full))
+	}
+	subset, err := opentype.Parse(data)
+	if err != nil {
+		t.Fatalf("parsing the subset: %v", err)
+	}
+	if subset.NumGlyphs() != font.Font.NumGlyphs() {
+		t.Errorf("Subset() has %d glyphs, want the font's %d", subset.NumGlyphs(), font.Font.NumGlyphs())
+	}
+
+	var buf sfnt.Buffer
+	for r, want := range map[rune]bool{'H': true, 'é': true, 'e': true, 'z': false} {
+		index, err := subset.GlyphIndex(&buf, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments, err := subset.LoadGlyph(&buf, index, fixed.I(12), nil)
+		if err != nil {
+			t.Fatalf("LoadGlyph(%q) error = %v", r, err)
+		}
+		// The e is kept as a part of the é, a composite glyph
+		if got := len(segments) > 0; got != want {
+			t.Errorf("glyph of %q drawn = %v, want %v", r, got, want)
+		}
+	}
+}
+
+func BenchmarkGetFontVariants(b *testing.B) {
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ClearCache()
+			if _, err := GetFontVariants("Inter"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		WarmCache()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			clearFamilyCache()
+			if _, err := GetFontVariants("Inter"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package fonts
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// registeredFonts holds the fonts added with RegisterFont by family
+var (
+	registeredFonts   = make(map[string][]*Font)
+	registeredFontsMu sync.RWMutex
+)
+
+// LoadFont parses a font from the contents of a TrueType or OpenType file, for fonts
+// that aren't on disk, like ones embedded in a program of one's own. The name is read
+// like a font file's: "Acme-BoldItalic" is the bold italic variant of the Acme family.
+// A name without a style, like "Acme", takes the style the font itself names. The font
+// keeps data, which mustn't be changed afterwards, and is only found by GetFont once
+// it's registered with RegisterFont.
+func LoadFont(name string, data []byte) (*Font, error) {
+	if name == "" {
+		return nil, fmt.Errorf("font name cannot be empty")
+	}
+	parsed, err := parseFontData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %s: %v", name, err)
+	}
+
+	family := cleanFontName(name)
+	style := extractFontStyle(name)
+	if family == strings.TrimSuffix(name, filepath.Ext(name)) {
+		// Typographic subfamilies name weights like SemiBold that the legacy ones group
+		// under Regular or Bold
+		for _, id := range []sfnt.NameID{sfnt.NameIDTypographicSubfamily, sfnt.NameIDSubfamily} {
+			if subfamily, err := parsed.font.Name(nil, id); err == nil && subfamily != "" {
+				mono := style.Mono
+				style = extractFontStyle(subfamily)
+				style.Mono = mono
+				break
+			}
+		}
+	}
+
+	return &Font{
+		Name:        family,
+		Font:        parsed.font,
+		IsMonospace: parsed.mono,
+		Style:       style,
+		data:        data,
+	}, nil
+}
+
+// LoadFontFrom is LoadFont for a font read from r
+func LoadFontFrom(name string, r io.Reader) (*Font, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %v", name, err)
+	}
+	return LoadFont(name, data)
+}
+
+// RegisterFont adds a font, usually one loaded with LoadFont, to the variants of its
+// family, so that GetFont and GetFontVariants find it ahead of the bundled and system
+// fonts of the same name. A font registered with the same style as one before replaces
+// it. Registered fonts are kept by ClearCache and UseEmbeddedOnly. It's safe to call
+// while fonts are being looked up.
+func RegisterFont(font *Font) {
+	if font == nil || font.Font == nil || font.Name == "" {
+		return
+	}
+
+	registeredFontsMu.Lock()
+	variants := slices.DeleteFunc(registeredFonts[font.Name], func(f *Font) bool {
+		return sameStyle(f.Style, font.Style)
+	})
+	registeredFonts[font.Name] = append(variants, font)
+	registeredFontsMu.Unlock()
+
+	// The family is looked up again with the font among its variants
+	fontCacheMu.Lock()
+	delete(fontCache, font.Name)
+	fontCacheMu.Unlock()
+}
+
+// registeredVariants returns a copy of the fonts registered for a family
+func registeredVariants(name string) []*Font {
+	registeredFontsMu.RLock()
+	defer registeredFontsMu.RUnlock()
+	return slices.Clone(registeredFonts[name])
+}
+
+// registeredNames returns the families with registered fonts, sorted
+func registeredNames() []string {
+	registeredFontsMu.RLock()
+	names := make([]string, 0, len(registeredFonts))
+	for name := range registeredFonts {
+		names = append(names, name)
+	}
+	registeredFontsMu.RUnlock()
+	slices.Sort(names)
+	return names
+}
+
+// sameStyle reports whether two variants of a family have the same style, ignoring
+// variations
+func sameStyle(a, b FontStyle) bool {
+	return a.Weight == b.Weight && a.Stretch == b.Stretch && a.Italic == b.Italic &&
+		a.Underline == b.Underline && a.Mono == b.Mono
+}
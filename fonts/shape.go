@@ -0,0 +1,236 @@
+package fonts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+
+	"github.com/go-text/typesetting/di"
+	tsfont "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// ligatureFeatures are the OpenType features responsible for ligatures
+var ligatureFeatures = []string{"liga", "clig", "calt", "dlig"}
+
+// Glyph is a single glyph produced by shaping a run of text
+type Glyph struct {
+	ID      uint32        // Glyph index in the font
+	Cluster int           // Index of the first rune of the text this glyph belongs to
+	Runes   int           // Number of runes in the glyph's cluster
+	XOffset fixed.Int26_6 // Horizontal offset from the pen position
+	YOffset fixed.Int26_6 // Vertical offset from the pen position (positive is up)
+	Advance fixed.Int26_6 // Distance to move the pen after drawing the glyph
+}
+
+// rawData returns the raw bytes of the font file
+func (f *Font) rawData() ([]byte, error) {
+	var data []byte
+	var err error
+
+	if f.data != nil {
+		return f.data, nil
+	} else if f.FilePath != "" {
+		data, err = os.ReadFile(f.FilePath)
+	} else if f.Filename != "" {
+		data, err = embeddedFonts.ReadFile("embedded/" + f.Filename)
+	} else {
+		return nil, fmt.Errorf("no font data available")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font data: %v", err)
+	}
+	return data, nil
+}
+
+// shapingFont returns the font parsed for use by the text shaper (lazy loaded)
+func (f *Font) shapingFont() (*tsfont.Font, error) {
+	f.shapingOnce.Do(func() {
+		data, err := f.rawData()
+		if err != nil {
+			f.shapingErr = err
+			return
+		}
+		face, err := tsfont.ParseTTF(bytes.NewReader(data))
+		if err != nil {
+			f.shapingErr = fmt.Errorf("failed to parse font for shaping: %v", err)
+			return
+		}
+		f.shaping = face.Font
+	})
+	return f.shaping, f.shapingErr
+}
+
+// shapingFace returns a face of the font for the text shaper with the variations
+// applied to the axes the font has. Tags that aren't four characters long are ignored.
+func (f *Font) shapingFace(variations map[string]float32) (*tsfont.Face, error) {
+	sf, err := f.shapingFont()
+	if err != nil {
+		return nil, err
+	}
+	face := tsfont.NewFace(sf)
+	if len(variations) > 0 {
+		var settings []tsfont.Variation
+		for tag, value := range variations {
+			if len(tag) != 4 {
+				continue
+			}
+			settings = append(settings, tsfont.Variation{Tag: ot.MustNewTag(tag), Value: value})
+		}
+		face.SetVariations(settings)
+	}
+	return face, nil
+}
+
+// Shape runs the text through an OpenType shaper, returning the glyphs to draw in
+// visual order. When ligatures is false the ligature features are disabled so that
+// every character keeps its own glyph.
+func (f *Font) Shape(text string, size float64, ligatures bool) ([]Glyph, error) {
+	return f.shape(text, size, ligatures, nil)
+}
+
+// Shape runs the text through an OpenType shaper like Font.Shape, with the face's
+// font, size and variations
+func (f *Face) Shape(text string, ligatures bool) ([]Glyph, error) {
+	return f.Font.shape(text, f.Size, ligatures, f.Style.Variations)
+}
+
+func (f *Font) shape(text string, size float64, ligatures bool, variations map[string]float32) ([]Glyph, error) {
+	face, err := f.shapingFace(variations)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []shaping.FontFeature
+	if !ligatures {
+		for _, tag := range ligatureFeatures {
+			features = append(features, shaping.FontFeature{Tag: ot.MustNewTag(tag), Value: 0})
+		}
+	}
+
+	runes := []rune(text)
+	var shaper shaping.HarfbuzzShaper
+	output := shaper.Shape(shaping.Input{
+		Text:         runes,
+		RunStart:     0,
+		RunEnd:       len(runes),
+		Direction:    di.DirectionLTR,
+		Face:         face,
+		FontFeatures: features,
+		Size:         fixed.Int26_6(math.Round(size * 64)),
+		Script:       language.Latin,
+		Language:     language.NewLanguage("en"),
+	})
+
+	glyphs := make([]Glyph, len(output.Glyphs))
+	for i, g := range output.Glyphs {
+		glyphs[i] = Glyph{
+			ID:      uint32(g.GlyphID),
+			Cluster: g.TextIndex(),
+			Runes:   g.RunesCount(),
+			XOffset: g.XOffset,
+			YOffset: g.YOffset,
+			Advance: g.Advance,
+		}
+	}
+	return glyphs, nil
+}
+
+// DrawGlyph rasterizes a single shaped glyph with its pen position at dot,
+// filling it with src
+func (f *Font) DrawGlyph(dst draw.Image, src image.Image, glyph Glyph, size float64, dot fixed.Point26_6) error {
+	return f.drawGlyph(dst, src, glyph, size, dot, nil)
+}
+
+// DrawGlyph rasterizes a glyph shaped with Face.Shape like Font.DrawGlyph, with the
+// face's font, size and variations
+func (f *Face) DrawGlyph(dst draw.Image, src image.Image, glyph Glyph, dot fixed.Point26_6) error {
+	return f.Font.drawGlyph(dst, src, glyph, f.Size, dot, f.Style.Variations)
+}
+
+func (f *Font) drawGlyph(dst draw.Image, src image.Image, glyph Glyph, size float64, dot fixed.Point26_6, variations map[string]float32) error {
+	face, err := f.shapingFace(variations)
+	if err != nil {
+		return err
+	}
+
+	outline, ok := face.GlyphDataOutline(ot.GID(glyph.ID))
+	if !ok {
+		return nil
+	}
+
+	scale := float32(size) / float32(face.Upem())
+	originX := float32(dot.X+glyph.XOffset) / 64
+	originY := float32(dot.Y-glyph.YOffset) / 64
+	if mask, bounds := rasterizeOutline(outline, scale, originX, originY); mask != nil {
+		draw.DrawMask(dst, bounds, src, bounds.Min, mask, image.Point{}, draw.Over)
+	}
+	return nil
+}
+
+// rasterizeOutline returns the coverage of a glyph's outline, scaled from font units
+// to pixels by scale with its origin at (originX, originY), along with the pixels it
+// covers. The mask is nil for glyphs with nothing to draw, like spaces.
+func rasterizeOutline(outline tsfont.GlyphOutline, scale, originX, originY float32) (*image.Alpha, image.Rectangle) {
+	if len(outline.Segments) == 0 {
+		return nil, image.Rectangle{}
+	}
+
+	// Find the pixel bounds of the outline so the rasterizer only covers the glyph
+	minX, minY := float32(math.MaxFloat32), float32(math.MaxFloat32)
+	maxX, maxY := float32(-math.MaxFloat32), float32(-math.MaxFloat32)
+	for _, segment := range outline.Segments {
+		for _, p := range segment.ArgsSlice() {
+			x, y := originX+p.X*scale, originY-p.Y*scale
+			minX, maxX = min(minX, x), max(maxX, x)
+			minY, maxY = min(minY, y), max(maxY, y)
+		}
+	}
+	bounds := image.Rect(
+		int(math.Floor(float64(minX))), int(math.Floor(float64(minY))),
+		int(math.Ceil(float64(maxX))), int(math.Ceil(float64(maxY))),
+	)
+	if bounds.Empty() {
+		return nil, image.Rectangle{}
+	}
+
+	// Rasterize relative to the top left corner of the bounds
+	offX, offY := float32(bounds.Min.X), float32(bounds.Min.Y)
+	point := func(p ot.SegmentPoint) (float32, float32) {
+		return originX + p.X*scale - offX, originY - p.Y*scale - offY
+	}
+	r := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	for i, segment := range outline.Segments {
+		switch segment.Op {
+		case ot.SegmentOpMoveTo:
+			// Each contour is closed before the next one starts
+			if i > 0 {
+				r.ClosePath()
+			}
+			r.MoveTo(point(segment.Args[0]))
+		case ot.SegmentOpLineTo:
+			r.LineTo(point(segment.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			r.QuadTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			x3, y3 := point(segment.Args[2])
+			r.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	r.ClosePath()
+	mask := image.NewAlpha(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	r.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return mask, bounds
+}
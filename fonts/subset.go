@@ -0,0 +1,259 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// subsetDroppedTables are the tables left out of subsets. Substitutions could swap
+// the characters kept for glyphs that aren't, positioning is left to the documents,
+// which place each character, and variations and signatures no longer match the
+// outlines once they're cut down.
+var subsetDroppedTables = map[string]bool{
+	"GSUB": true, "morx": true, "GPOS": true, "GDEF": true, "kern": true, "DSIG": true,
+	"fvar": true, "gvar": true, "avar": true, "cvar": true,
+	"HVAR": true, "VVAR": true, "MVAR": true, "STAT": true,
+}
+
+// Subset returns the font file cut down to the outlines of the glyphs of the
+// characters in text, which is usually a fraction of its size, for embedding the font
+// in documents like SVG. Every glyph keeps its index, so the other glyphs are left
+// empty rather than removed. Substitutions like ligatures, kerning, glyph names and the
+// axes of variable fonts are left out, the default instance of a variable font being
+// kept. Fonts without TrueType outlines, such as CFF based OpenType fonts, are
+// returned whole.
+func (f *Font) Subset(text string) ([]byte, error) {
+	data, err := f.rawData()
+	if err != nil {
+		return nil, err
+	}
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+	glyf, loca, head, maxp := tables["glyf"], tables["loca"], tables["head"], tables["maxp"]
+	if glyf == nil || loca == nil || head == nil || maxp == nil {
+		return data, nil
+	}
+	if len(head) < 54 || len(maxp) < 6 {
+		return nil, fmt.Errorf("malformed font tables")
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	offsets, err := glyphOffsets(loca, numGlyphs, binary.BigEndian.Uint16(head[50:]) == 1, len(glyf))
+	if err != nil {
+		return nil, err
+	}
+
+	// The glyphs of the characters, and the ones composite glyphs are made of
+	keep := map[int]bool{0: true} // .notdef is drawn for missing characters
+	var pending []int
+	for _, r := range text {
+		if index, err := f.Font.GlyphIndex(nil, r); err == nil && index != 0 && !keep[int(index)] {
+			keep[int(index)] = true
+			pending = append(pending, int(index))
+		}
+	}
+	for len(pending) > 0 {
+		index := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if index >= numGlyphs {
+			continue
+		}
+		for _, component := range glyphComponents(glyf[offsets[index]:offsets[index+1]]) {
+			if !keep[component] {
+				keep[component] = true
+				pending = append(pending, component)
+			}
+		}
+	}
+
+	// Each outline is padded to four bytes, which suits both offset formats
+	var newGlyf []byte
+	newOffsets := make([]int, numGlyphs+1)
+	for i := 0; i < numGlyphs; i++ {
+		if keep[i] {
+			newGlyf = append(newGlyf, glyf[offsets[i]:offsets[i+1]]...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+		newOffsets[i+1] = len(newGlyf)
+	}
+
+	// Short offsets, stored halved, do for all but the largest subsets
+	long := len(newGlyf) > 2*0xFFFF
+	var newLoca []byte
+	for _, offset := range newOffsets {
+		if long {
+			newLoca = binary.BigEndian.AppendUint32(newLoca, uint32(offset))
+		} else {
+			newLoca = binary.BigEndian.AppendUint16(newLoca, uint16(offset/2))
+		}
+	}
+	newHead := slices.Clone(head)
+	binary.BigEndian.PutUint16(newHead[50:], 0)
+	if long {
+		binary.BigEndian.PutUint16(newHead[50:], 1)
+	}
+	if len(newGlyf) == 0 {
+		// Some parsers refuse an empty table
+		newGlyf = make([]byte, 4)
+	}
+	tables["glyf"], tables["loca"], tables["head"] = newGlyf, newLoca, newHead
+
+	// Version 3 of the post table has no glyph names
+	if post := tables["post"]; len(post) >= 32 {
+		newPost := slices.Clone(post[:32])
+		binary.BigEndian.PutUint32(newPost, 0x00030000)
+		tables["post"] = newPost
+	}
+	for tag := range subsetDroppedTables {
+		delete(tables, tag)
+	}
+	return writeTables(binary.BigEndian.Uint32(data), tables), nil
+}
+
+// readTables returns the tables of a font file by tag
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("font file too short")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, fmt.Errorf("font file too short")
+	}
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("font table %s out of bounds", record[:4])
+		}
+		tables[string(record[:4])] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// glyphOffsets returns where each glyph starts in the glyf table, followed by where
+// the last one ends
+func glyphOffsets(loca []byte, numGlyphs int, long bool, glyfLength int) ([]int, error) {
+	offsets := make([]int, numGlyphs+1)
+	for i := range offsets {
+		if long {
+			if len(loca) < 4*(i+1) {
+				return nil, fmt.Errorf("malformed loca table")
+			}
+			offsets[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		} else {
+			if len(loca) < 2*(i+1) {
+				return nil, fmt.Errorf("malformed loca table")
+			}
+			offsets[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+		if offsets[i] > glyfLength || (i > 0 && offsets[i] < offsets[i-1]) {
+			return nil, fmt.Errorf("malformed loca table")
+		}
+	}
+	return offsets, nil
+}
+
+// Flags of the components of composite glyphs
+const (
+	componentArgsAreWords = 0x0001
+	componentHasScale     = 0x0008
+	componentMore         = 0x0020
+	componentHasXYScale   = 0x0040
+	componentHasTwoByTwo  = 0x0080
+)
+
+// glyphComponents returns the indexes of the glyphs a composite glyph is made of, or
+// nothing for a simple glyph
+func glyphComponents(glyph []byte) []int {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	var components []int
+	for pos := 10; pos+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[pos:])
+		components = append(components, int(binary.BigEndian.Uint16(glyph[pos+2:])))
+		pos += 4
+		if flags&componentArgsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&componentHasScale != 0:
+			pos += 2
+		case flags&componentHasXYScale != 0:
+			pos += 4
+		case flags&componentHasTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&componentMore == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// writeTables writes a font file made of the tables, with their checksums and the
+// head table's checksum adjustment worked out again
+func writeTables(version uint32, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	out := make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(out, version)
+	binary.BigEndian.PutUint16(out[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(out[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(16*numTables-searchRange))
+
+	headOffset := -1
+	for i, tag := range tags {
+		table := tables[tag]
+		if tag == "head" {
+			table = slices.Clone(table)
+			binary.BigEndian.PutUint32(table[8:], 0)
+			headOffset = len(out)
+		}
+		record := out[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], tableChecksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		out = append(out, table...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	if headOffset >= 0 {
+		binary.BigEndian.PutUint32(out[headOffset+8:], 0xB1B0AFBA-tableChecksum(out))
+	}
+	return out
+}
+
+// tableChecksum returns the sum of a table's data as big endian 32 bit words
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
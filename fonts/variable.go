@@ -0,0 +1,113 @@
+package fonts
+
+import (
+	"image"
+	"math"
+
+	tsfont "github.com/go-text/typesetting/font"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// bolderWeight is how much heavier on the weight axis bold text is drawn than the
+// weight chosen with WithVariations
+const bolderWeight = 300
+
+// WithVariations returns a copy of the style that draws variable fonts at the given
+// axis values. Bold styles are drawn heavier than the weight given, so that bold text
+// still stands out in a variable font whose weight is chosen.
+func (s FontStyle) WithVariations(variations map[string]float32) FontStyle {
+	if len(variations) == 0 {
+		return s
+	}
+	s.Variations = variations
+	if weight, ok := variations[AxisWeight]; ok && s.Weight >= WeightBold {
+		s.Variations = make(map[string]float32, len(variations))
+		for tag, value := range variations {
+			s.Variations[tag] = value
+		}
+		s.Variations[AxisWeight] = weight + bolderWeight
+	}
+	return s
+}
+
+// variableFace draws the glyphs of a variable font at a point on its axes. The
+// opentype package only draws the default instance of a variable font, so the glyphs
+// are rasterized from outlines with the variations applied instead, while the metrics
+// and kerning are those of the default instance, which keeps lines the same height
+// whatever the axis values.
+type variableFace struct {
+	font.Face              // Face of the default instance
+	face      *tsfont.Face // Font with the axis values applied
+	scale     float32      // Pixels per font unit
+}
+
+// variableFace returns a face drawing the font at the given axis values around base,
+// a face of its default instance, or false if the font has none of the axes
+func (f *Font) variableFace(base font.Face, size float64, variations map[string]float32) (font.Face, bool) {
+	face, err := f.shapingFace(variations)
+	if err != nil {
+		return nil, false
+	}
+	if len(face.Coords()) == 0 {
+		// Not a variable font
+		return nil, false
+	}
+
+	return &variableFace{Face: base, face: face, scale: float32(size) / float32(face.Upem())}, true
+}
+
+// advance returns the advance of a glyph, rounded to whole pixels like the hinted
+// advances of the default instance
+func (f *variableFace) advance(gid tsfont.GID) fixed.Int26_6 {
+	return fixed.I(int(math.Round(float64(f.face.HorizontalAdvance(gid) * f.scale))))
+}
+
+func (f *variableFace) Glyph(dot fixed.Point26_6, r rune) (
+	dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+
+	gid, ok := f.face.NominalGlyph(r)
+	if !ok {
+		return f.Face.Glyph(dot, r)
+	}
+	advance = f.advance(gid)
+	outline, ok := f.face.GlyphDataOutline(gid)
+	if !ok {
+		return f.Face.Glyph(dot, r)
+	}
+	alpha, bounds := rasterizeOutline(outline, f.scale, float32(dot.X)/64, float32(dot.Y)/64)
+	if alpha == nil {
+		return image.Rectangle{}, image.NewAlpha(image.Rectangle{}), image.Point{}, advance, true
+	}
+	return bounds, alpha, image.Point{}, advance, true
+}
+
+func (f *variableFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	gid, ok := f.face.NominalGlyph(r)
+	if !ok {
+		return f.Face.GlyphBounds(r)
+	}
+	advance = f.advance(gid)
+	extents, ok := f.face.GlyphExtents(gid)
+	if !ok {
+		return fixed.Rectangle26_6{}, advance, true
+	}
+
+	// Extents are in font units with y pointing up, bounds in pixels with y down
+	toFixed := func(v float32) fixed.Int26_6 {
+		return fixed.Int26_6(math.Round(float64(v * f.scale * 64)))
+	}
+	bounds = fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: toFixed(extents.XBearing), Y: -toFixed(extents.YBearing)},
+		Max: fixed.Point26_6{X: toFixed(extents.XBearing + extents.Width), Y: -toFixed(extents.YBearing + extents.Height)},
+	}
+	return bounds, advance, true
+}
+
+func (f *variableFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	gid, ok := f.face.NominalGlyph(r)
+	if !ok {
+		return f.Face.GlyphAdvance(r)
+	}
+	return f.advance(gid), true
+}
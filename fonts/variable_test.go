@@ -0,0 +1,91 @@
+package fonts
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// ink returns the total coverage of the text drawn with a face of the font in the style
+func ink(t *testing.T, f *Font, style *FontStyle, text string) int {
+	t.Helper()
+	face, err := f.GetFace(32, style)
+	if err != nil {
+		t.Fatalf("GetFace() error = %v", err)
+	}
+	defer face.Close()
+
+	dst := image.NewAlpha(image.Rect(0, 0, 600, 60))
+	d := &font.Drawer{Dst: dst, Src: image.Opaque, Face: face.Face, Dot: fixed.P(10, 40)}
+	d.DrawString(text)
+	total := 0
+	for _, a := range dst.Pix {
+		total += int(a)
+	}
+	return total
+}
+
+func TestVariations(t *testing.T) {
+	const text = "Hamburgefonstiv"
+	regular := &FontStyle{Weight: WeightRegular, Stretch: StretchNormal}
+
+	t.Run("variable font", func(t *testing.T) {
+		// The bundled Inter is a variable font
+		f, err := GetFont("Inter", regular)
+		if err != nil {
+			t.Fatalf("GetFont() error = %v", err)
+		}
+
+		light := regular.WithVariations(map[string]float32{AxisWeight: 300})
+		heavy := regular.WithVariations(map[string]float32{AxisWeight: 700})
+		lightInk, heavyInk := ink(t, f, &light, text), ink(t, f, &heavy, text)
+		if lightInk == 0 || heavyInk <= lightInk {
+			t.Errorf("ink at weight 700 = %d, want more than at weight 300 (%d)", heavyInk, lightInk)
+		}
+
+		// The metrics stay those of the default instance, whatever the weight
+		face, err := f.GetFace(32, &heavy)
+		if err != nil {
+			t.Fatalf("GetFace() error = %v", err)
+		}
+		defer face.Close()
+		base, err := f.GetFace(32, regular)
+		if err != nil {
+			t.Fatalf("GetFace() error = %v", err)
+		}
+		defer base.Close()
+		if face.Face.Metrics().Height != base.Face.Metrics().Height {
+			t.Errorf("line height at weight 700 = %v, want %v", face.Face.Metrics().Height, base.Face.Metrics().Height)
+		}
+	})
+
+	t.Run("static font", func(t *testing.T) {
+		f, err := GetFont("JetBrainsMonoNerdFont", regular)
+		if err != nil {
+			t.Fatalf("GetFont() error = %v", err)
+		}
+		varied := regular.WithVariations(map[string]float32{AxisWeight: 700})
+		if got, want := ink(t, f, &varied, text), ink(t, f, regular, text); got != want {
+			t.Errorf("ink with variations = %d, want %d as without", got, want)
+		}
+	})
+
+	t.Run("bold", func(t *testing.T) {
+		variations := map[string]float32{AxisWeight: 350, AxisWidth: 90}
+		bold := FontStyle{Weight: WeightBold}.WithVariations(variations)
+		if got := bold.Variations[AxisWeight]; got != 350+bolderWeight {
+			t.Errorf("bold weight = %v, want %v", got, 350+bolderWeight)
+		}
+		if got := bold.Variations[AxisWidth]; got != 90 {
+			t.Errorf("bold width = %v, want 90", got)
+		}
+		if variations[AxisWeight] != 350 {
+			t.Errorf("WithVariations() changed the variations passed to it")
+		}
+		if got := (FontStyle{Weight: WeightRegular}).WithVariations(variations).Variations[AxisWeight]; got != 350 {
+			t.Errorf("regular weight = %v, want 350", got)
+		}
+	})
+}
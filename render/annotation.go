@@ -0,0 +1,300 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/fonts"
+)
+
+const (
+	annotationDefaultWidth    = 3
+	annotationDefaultFontSize = 14
+	annotationArrowHead       = 4 // Length of the arrow head, in line widths
+	annotationCalloutPadding  = 8 // Space between the text and the edges of a callout
+	annotationCalloutGap      = 6 // Space between a callout and what it points at
+)
+
+// annotationDefaultColor is the color annotations are drawn in when theirs isn't set
+var annotationDefaultColor = color.RGBA{R: 255, G: 59, B: 48, A: 255}
+
+// Position is a point an annotation refers to, either in pixels of the final image or
+// at a character of the content
+type Position struct {
+	line, column int // Counted from 1; zero for pixel positions
+	x, y         int // Pixels, or an offset from the character
+}
+
+// At returns the position of a pixel of the final image, or of the unscaled image on a
+// canvas drawn with WithScale
+func At(x, y int) Position {
+	return Position{x: x, y: y}
+}
+
+// AtChar returns the position of the character at the given line and column of the
+// content, both counted from 1, such as line 12, column 5 of a code snippet. The
+// content has to implement content.CharLocator.
+func AtChar(line, column int) Position {
+	return Position{line: line, column: column}
+}
+
+// Offset returns the position moved by dx, dy pixels
+func (p Position) Offset(dx, dy int) Position {
+	p.x += dx
+	p.y += dy
+	return p
+}
+
+// locator resolves positions to boxes in the final image: characters to the box they
+// are drawn in, and pixels to an empty box
+type locator func(p Position) (image.Rectangle, error)
+
+// Annotation is a shape drawn over the final image to point at part of it
+type Annotation interface {
+	// draw draws the annotation with its sizes multiplied by scale, the scale of the
+	// canvas
+	draw(dc *gg.Context, locate locator, scale float64) error
+}
+
+// ArrowAnnotation is an arrow from one position to another. Characters are pointed
+// at from the middle of From to the edge of To.
+type ArrowAnnotation struct {
+	From, To Position
+	Color    color.Color // Red if nil
+	Width    float64     // Width of the line, 3 if zero
+}
+
+// BoxAnnotation is a rectangle around everything from one position to another, such
+// as a few characters or whole lines of code
+type BoxAnnotation struct {
+	From, To Position
+	Color    color.Color // Color of the outline, red if nil
+	Width    float64     // Width of the outline, 3 if zero
+	Radius   float64     // Corner radius
+	Fill     color.Color // Fill inside the outline, none if nil
+}
+
+// TextAnnotation is a rounded callout with a caption, drawn to the right of a
+// position
+type TextAnnotation struct {
+	At        Position
+	Text      string
+	Color     color.Color // Background of the callout, red if nil
+	TextColor color.Color // Black or white, whichever contrasts best with Color, if nil
+	FontSize  float64     // 14 if zero
+}
+
+// WithAnnotation draws an annotation over the final image, after the watermark and
+// sticker. Annotations are drawn in the order they're added.
+func (c *Canvas) WithAnnotation(ann Annotation) *Canvas {
+	c.annotations = append(c.annotations, ann)
+	return c
+}
+
+// annotationLocator returns the locator for the canvas' annotations
+func (c *Canvas) annotationLocator() (locator, error) {
+	// Annotations are drawn before the image is brought to its size, but after the
+	// window is scaled down to it
+	rect, windowScale, err := c.unfittedContentRect()
+	if err != nil {
+		return nil, err
+	}
+	scale := c.scaleFactor()
+	return func(p Position) (image.Rectangle, error) {
+		// Pixel positions and offsets are in units of the unscaled image
+		offset := image.Pt(int(math.Round(float64(p.x)*scale)), int(math.Round(float64(p.y)*scale)))
+		if p.line == 0 && p.column == 0 {
+			return image.Rectangle{Min: offset, Max: offset}, nil
+		}
+		l, ok := c.content.(content.CharLocator)
+		if !ok {
+			return image.Rectangle{}, fmt.Errorf("the content can't locate line %d, column %d", p.line, p.column)
+		}
+		char, err := l.LocateChar(p.line, p.column)
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		if windowScale != 1 {
+			char = image.Rect(
+				int(math.Round(float64(char.Min.X)*windowScale)), int(math.Round(float64(char.Min.Y)*windowScale)),
+				int(math.Round(float64(char.Max.X)*windowScale)), int(math.Round(float64(char.Max.Y)*windowScale)))
+		}
+		return char.Add(rect.Min).Add(offset), nil
+	}, nil
+}
+
+// drawAnnotations draws the canvas' annotations on a context the size of the final
+// image
+func (c *Canvas) drawAnnotations(dc *gg.Context) error {
+	locate, err := c.annotationLocator()
+	if err != nil {
+		return err
+	}
+	for i, ann := range c.annotations {
+		if err := ann.draw(dc, locate, c.scaleFactor()); err != nil {
+			return fmt.Errorf("failed to draw annotation %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// annotationStyle returns col and width, or their defaults
+func annotationStyle(col color.Color, width float64) (color.Color, float64) {
+	if col == nil {
+		col = annotationDefaultColor
+	}
+	if width <= 0 {
+		width = annotationDefaultWidth
+	}
+	return col, width
+}
+
+// center returns the middle of a rectangle
+func center(r image.Rectangle) (x, y float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
+
+func (a ArrowAnnotation) draw(dc *gg.Context, locate locator, scale float64) error {
+	from, err := locate(a.From)
+	if err != nil {
+		return err
+	}
+	to, err := locate(a.To)
+	if err != nil {
+		return err
+	}
+	col, width := annotationStyle(a.Color, a.Width)
+	width *= scale
+
+	// The tip stops where the line enters the target's box
+	x0, y0 := center(from)
+	x1, y1 := center(to)
+	dx, dy := x1-x0, y1-y0
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return nil
+	}
+	halfWidth, halfHeight := float64(to.Dx())/2, float64(to.Dy())/2
+	inside := math.Inf(1)
+	if dx != 0 {
+		inside = math.Min(inside, halfWidth/math.Abs(dx))
+	}
+	if dy != 0 {
+		inside = math.Min(inside, halfHeight/math.Abs(dy))
+	}
+	inside = math.Min(inside, 1)
+	x1, y1 = x1-dx*inside, y1-dy*inside
+	length *= 1 - inside
+	if length == 0 {
+		return nil
+	}
+
+	// The line ends at the base of the head, so its square end doesn't poke out
+	ux, uy := dx/math.Hypot(dx, dy), dy/math.Hypot(dx, dy)
+	head := math.Min(width*annotationArrowHead, length)
+	baseX, baseY := x1-ux*head, y1-uy*head
+
+	dc.Push()
+	defer dc.Pop()
+	dc.SetColor(col)
+	dc.SetLineWidth(width)
+	dc.SetLineCap(gg.LineCapRound)
+	dc.DrawLine(x0, y0, baseX, baseY)
+	dc.Stroke()
+
+	spread := head * 0.6
+	dc.MoveTo(x1, y1)
+	dc.LineTo(baseX-uy*spread, baseY+ux*spread)
+	dc.LineTo(baseX+uy*spread, baseY-ux*spread)
+	dc.ClosePath()
+	dc.Fill()
+	return nil
+}
+
+func (a BoxAnnotation) draw(dc *gg.Context, locate locator, scale float64) error {
+	from, err := locate(a.From)
+	if err != nil {
+		return err
+	}
+	to, err := locate(a.To)
+	if err != nil {
+		return err
+	}
+	col, width := annotationStyle(a.Color, a.Width)
+	width *= scale
+
+	// The outline goes around the boxes, so it doesn't cover the characters
+	r := from.Union(to)
+	x, y := float64(r.Min.X)-width/2, float64(r.Min.Y)-width/2
+	w, h := float64(r.Dx())+width, float64(r.Dy())+width
+
+	dc.Push()
+	defer dc.Pop()
+	if a.Fill != nil {
+		dc.SetColor(a.Fill)
+		dc.DrawRoundedRectangle(x, y, w, h, a.Radius*scale)
+		dc.Fill()
+	}
+	dc.SetColor(col)
+	dc.SetLineWidth(width)
+	dc.DrawRoundedRectangle(x, y, w, h, a.Radius*scale)
+	dc.Stroke()
+	return nil
+}
+
+func (a TextAnnotation) draw(dc *gg.Context, locate locator, scale float64) error {
+	if a.Text == "" {
+		return nil
+	}
+	at, err := locate(a.At)
+	if err != nil {
+		return err
+	}
+	col, _ := annotationStyle(a.Color, 0)
+	textColor := a.TextColor
+	if textColor == nil {
+		textColor = contrastingTextColor(col)
+	}
+	fontSize := a.FontSize
+	if fontSize <= 0 {
+		fontSize = annotationDefaultFontSize
+	}
+	fontSize *= scale
+
+	font, err := fonts.GetFallback(fonts.FallbackSans)
+	if err != nil {
+		return fmt.Errorf("failed to load fallback font: %v", err)
+	}
+	face, err := font.GetFace(fontSize, &fonts.FontStyle{
+		Weight:  fonts.WeightBold,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create font face: %v", err)
+	}
+	defer face.Close()
+
+	dc.Push()
+	defer dc.Pop()
+	dc.SetFontFace(face.Face)
+	textWidth, _ := dc.MeasureString(a.Text)
+
+	// The callout's left edge sits just right of the position, centered on it
+	width, height := textWidth+annotationCalloutPadding*2*scale, fontSize*2
+	x := float64(at.Max.X)
+	if at.Dx() > 0 {
+		x += annotationCalloutGap * scale
+	}
+	_, cy := center(at)
+
+	dc.SetColor(col)
+	dc.DrawRoundedRectangle(x, cy-height/2, width, height, height/4)
+	dc.Fill()
+	dc.SetColor(textColor)
+	dc.DrawStringAnchored(a.Text, x+width/2, cy, 0.5, 0.35)
+	return nil
+}
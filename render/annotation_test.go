@@ -0,0 +1,47 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content/code"
+)
+
+func TestAnnotations(t *testing.T) {
+	green := color.RGBA{G: 255, A: 255}
+	renderer := code.DefaultRenderer("package main\n\nfunc main() {}\n")
+	canvas := NewCanvas().WithContent(renderer).
+		WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia)).
+		WithBackground(background.NewColorBackground().WithPadding(40))
+
+	rect, err := canvas.ContentRect()
+	require.NoError(t, err)
+	char, err := renderer.LocateChar(3, 6)
+	require.NoError(t, err)
+	char = char.Add(rect.Min)
+
+	img, err := canvas.WithAnnotation(BoxAnnotation{From: AtChar(3, 6), To: AtChar(3, 9), Color: green, Width: 2}).
+		WithAnnotation(ArrowAnnotation{From: At(5, 5), To: At(25, 5), Color: green}).
+		RenderToImage()
+	require.NoError(t, err)
+
+	// The box is drawn just outside the characters
+	assert.Equal(t, green, img.At(char.Min.X-1, char.Min.Y+char.Dy()/2))
+	assert.NotEqual(t, green, img.At(char.Min.X+1, char.Min.Y+char.Dy()/2))
+	assert.Equal(t, green, img.At(15, 5))
+
+	fragment, err := canvas.RenderToSVG()
+	require.NoError(t, err)
+	assert.Contains(t, string(fragment), "data:image/png;base64")
+
+	_, err = NewCanvas().WithContent(solidContent{width: 10, height: 10, color: green}).
+		WithAnnotation(TextAnnotation{At: AtChar(1, 1), Text: "Here"}).RenderToImage()
+	assert.Error(t, err, "Characters can only be located in content that knows where they are")
+
+	_, err = canvas.WithAnnotation(TextAnnotation{At: AtChar(10, 1), Text: "Here"}).RenderToImage()
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"image"
+	"runtime"
+	"sync"
+)
+
+// RenderBatch renders independent canvases across a pool of concurrency workers, or
+// one per CPU if concurrency isn't positive, and returns their images and errors in
+// the order of canvases. Every render loads its own font faces, which aren't safe to
+// share between goroutines, so canvases may share fonts and renderers as long as
+// nothing changes them during the batch.
+func RenderBatch(canvases []*Canvas, concurrency int) ([]image.Image, []error) {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	concurrency = min(concurrency, len(canvases))
+
+	images := make([]image.Image, len(canvases))
+	errs := make([]error, len(canvases))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				images[i], errs[i] = canvases[i].RenderToImage()
+			}
+		}()
+	}
+	for i := range canvases {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return images, errs
+}
@@ -0,0 +1,40 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content/code"
+)
+
+func TestRenderBatch(t *testing.T) {
+	themes := []string{"monokai", "github", "dracula", "nord", "ayu-dark", "solarized-dark"}
+	var canvases []*Canvas
+	for _, theme := range themes {
+		canvases = append(canvases, NewCanvas().
+			WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia, chrome.WithTitle(theme))).
+			WithBackground(background.NewColorBackground().WithPadding(20)).
+			WithContent(code.DefaultRenderer("package main\n\nfunc main() {}\n").WithTheme(theme)))
+	}
+	canvases = append(canvases, NewCanvas())
+
+	images, errs := RenderBatch(canvases, 3)
+	require.Len(t, images, len(canvases))
+	require.Len(t, errs, len(canvases))
+	for i, canvas := range canvases[:len(themes)] {
+		require.NoError(t, errs[i])
+		want, err := canvas.RenderToImage()
+		require.NoError(t, err)
+		assert.Equal(t, want, images[i], "The images come back in order")
+	}
+	assert.Error(t, errs[len(themes)], "Errors are returned for the canvases they belong to")
+	assert.Nil(t, images[len(themes)])
+
+	images, errs = RenderBatch([]*Canvas{NewCanvas().WithBackground(background.NewColorBackground().WithColor(color.Black))}, 0)
+	assert.NoError(t, errs[0])
+	assert.NotNil(t, images[0])
+}
@@ -3,7 +3,9 @@ package render
 import (
 	"fmt"
 	"image"
+	"image/color"
 
+	"github.com/fogleman/gg"
 	"github.com/watzon/goshot/background"
 	"github.com/watzon/goshot/chrome"
 	"github.com/watzon/goshot/content"
@@ -11,9 +13,21 @@ import (
 
 // Canvas represents a rendering canvas with all necessary configuration
 type Canvas struct {
-	chrome     chrome.Chrome
-	background background.Background
-	content    content.Content
+	chrome      chrome.Chrome
+	background  background.Background
+	content     content.Content
+	sticker     *sticker
+	watermark   *tiledWatermark
+	annotations []Annotation
+	scale       float64              // Set with WithScale; 0 means 1
+	metadata    map[string]string    // Text embedded in PNG images
+	partsScaled bool                 // Whether the content, chrome and background are already scaled
+	transparent bool                 // Set with WithTransparentBackground
+	frosted     *frostedGlass        // Set with WithFrostedContent
+	size        *imageSize           // Set with WithAspectRatio or WithOutputSize
+	autoVariant bool                 // Set with WithAutoChromeVariant
+	progress    content.ProgressFunc // Set with WithProgress
+	embedFont   bool                 // Set with WithEmbeddedFont
 }
 
 // NewCanvas creates a new Canvas instance with default options
@@ -34,6 +48,20 @@ func (c *Canvas) WithChrome(chrome chrome.Chrome) *Canvas {
 // WithBackground sets the background renderer
 func (c *Canvas) WithBackground(bg background.Background) *Canvas {
 	c.background = bg
+	if c.transparent {
+		c.background = transparentBackground(bg)
+	}
+	return c
+}
+
+// WithTransparentBackground leaves the image see-through around the content, for
+// compositing over other graphics. A ColorBackground keeps its padding, corners,
+// shadow and border, only its color becoming transparent, while any other background
+// is replaced by a transparent one of the same size. The content's own background,
+// like the theme's behind code, isn't affected; see CodeRenderer.WithTransparentBackground.
+func (c *Canvas) WithTransparentBackground() *Canvas {
+	c.transparent = true
+	c.background = transparentBackground(c.background)
 	return c
 }
 
@@ -50,29 +78,82 @@ func (c *Canvas) RenderToImage() (image.Image, error) {
 	if c.chrome == nil && c.background == nil && c.content == nil {
 		return nil, fmt.Errorf("at least one renderer must be set")
 	}
+	c = c.scaled()
 
 	var img image.Image
 	var err error
 
 	// First, render the content
 	if c.content != nil {
-		img, err = c.content.Render()
+		img, err = c.renderContent()
 		if err != nil {
 			return nil, err
 		}
+		c.progress.Report(content.StageCompositing, contentProgress)
+	} else {
+		c.progress.Report(content.StageCompositing, 0)
+	}
+
+	img, err = c.decorate(img)
+	if err != nil {
+		return nil, err
+	}
+
+	// Annotations point into the content, so they go on top of everything else
+	if len(c.annotations) > 0 && img != nil {
+		dc := gg.NewContext(img.Bounds().Dx(), img.Bounds().Dy())
+		dc.DrawImage(img, 0, 0)
+		if err := c.drawAnnotations(dc); err != nil {
+			return nil, err
+		}
+		img = dc.Image()
 	}
 
-	// Then, apply the chrome
-	if c.chrome != nil {
-		img, err = c.chrome.Render(img)
+	img = c.fitted(img)
+	c.progress.Report(content.StageCompositing, 1)
+	return img, nil
+}
+
+// decorate applies the chrome, background and overlays to a rendered content image,
+// which may be nil
+func (c *Canvas) decorate(img image.Image) (image.Image, error) {
+	var err error
+
+	// Apply the chrome, in the variant suiting the background
+	if ch := c.variantChrome(); ch != nil {
+		img, err = ch.Render(img)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// Finally apply the background
-	if c.background != nil {
-		img, err = c.background.Render(img)
+	// Then apply the background, around the window scaled down to the output size and
+	// padded to the image's size or aspect ratio
+	if img != nil {
+		img = c.scaledWindow(img)
+		c = c.padded(img.Bounds().Dx(), img.Bounds().Dy())
+	}
+	if c.background != nil && c.frosted != nil && img != nil {
+		img, err = c.renderFrosted(img)
+		if err != nil {
+			return nil, err
+		}
+	} else if c.background != nil {
+		img, err = c.fittedBackground().Render(img)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Finally draw any overlays on top of everything
+	if c.watermark != nil && img != nil {
+		img, err = drawTiledWatermark(img, c.watermark, c.scaleFactor())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.sticker != nil && img != nil {
+		img, err = drawSticker(img, c.sticker, c.scaleFactor())
 		if err != nil {
 			return nil, err
 		}
@@ -80,3 +161,37 @@ func (c *Canvas) RenderToImage() (image.Image, error) {
 
 	return img, nil
 }
+
+// fittedBackground returns the background, told the corner radius of the chrome so
+// that its shadow and border follow the corners of the window
+func (c *Canvas) fittedBackground() background.Background {
+	if rc, ok := c.chrome.(chrome.RoundedChrome); ok {
+		if br, ok := c.background.(background.ContentRounder); ok {
+			return br.WithContentCornerRadius(rc.CornerRadius())
+		}
+	}
+	return c.background
+}
+
+// transparentBackground returns bg with nothing drawn but its shadow and border, and
+// only those when it's a ColorBackground. Other backgrounds become a transparent
+// ColorBackground placing the content where they would.
+func transparentBackground(bg background.Background) background.Background {
+	switch bg := bg.(type) {
+	case nil:
+		return nil
+	case background.ColorBackground:
+		return bg.WithColor(color.Transparent)
+	}
+
+	clear := background.NewColorBackground().WithColor(color.Transparent).WithPadding(0)
+	m, measures := bg.(background.Measurer)
+	p, places := bg.(background.Placer)
+	if measures && places {
+		// Measured around a single pixel, as no content at all is measured differently
+		width, height := m.Measure(1, 1)
+		rect := p.Place(1, 1)
+		clear = clear.WithPaddingDetailed(rect.Min.Y, width-rect.Max.X, height-rect.Max.Y, rect.Min.X)
+	}
+	return clear
+}
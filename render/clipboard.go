@@ -0,0 +1,46 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// CopyToClipboard encodes img in PNG format and copies it to the system clipboard,
+// like the CLI's --to-clipboard. The clipboard is accessed through
+// github.com/atotto/clipboard: on Linux that takes xclip or xsel under X11, or
+// wl-clipboard (wl-copy and wl-paste) under Wayland, to be installed. The PNG is
+// copied as the clipboard's text, which applications that paste images from the
+// clipboard may not accept.
+func CopyToClipboard(img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode image to png: %v", err)
+	}
+	if err := clipboard.WriteAll(buf.String()); err != nil {
+		return fmt.Errorf("failed to copy image to clipboard: %v", err)
+	}
+	return nil
+}
+
+// ImageFromClipboard decodes the image on the system clipboard, such as one copied
+// with CopyToClipboard. PNG, JPEG, GIF, BMP and WebP images are supported; the
+// clipboard is accessed as in CopyToClipboard.
+func ImageFromClipboard() (image.Image, error) {
+	data, err := clipboard.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from clipboard: %v", err)
+	}
+	if data == "" {
+		return nil, fmt.Errorf("the clipboard is empty")
+	}
+	img, _, err := image.Decode(strings.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("the clipboard doesn't hold an image: %v", err)
+	}
+	return img, nil
+}
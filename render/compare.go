@@ -0,0 +1,138 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/content/code"
+	"github.com/watzon/goshot/fonts"
+)
+
+const (
+	comparisonLabelHeight   = 32
+	comparisonLabelFontSize = 13
+)
+
+// SideBySide places the images next to each other from left to right, aligned to
+// the top, with gap pixels between them. Areas not covered by an image are transparent.
+func SideBySide(gap int, images ...image.Image) image.Image {
+	width, height := 0, 0
+	for i, img := range images {
+		if i > 0 {
+			width += gap
+		}
+		width += img.Bounds().Dx()
+		height = max(height, img.Bounds().Dy())
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	x := 0
+	for _, img := range images {
+		bounds := img.Bounds()
+		draw.Draw(result, image.Rect(x, 0, x+bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Src)
+		x += bounds.Dx() + gap
+	}
+	return result
+}
+
+// ThemeComparison renders the same code under two syntax themes side by side, each
+// half labeled with the name of its theme. Both halves share the same layout, so only
+// the colors differ between them.
+func ThemeComparison(input, language, themeA, themeB string) (image.Image, error) {
+	themes := []string{themeA, themeB}
+	renderers := make([]*code.CodeRenderer, len(themes))
+	for i, theme := range themes {
+		renderers[i] = code.DefaultRenderer(input).WithLanguage(language).WithTheme(theme)
+	}
+	images, err := renderSameWidth(renderers, themes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, theme := range themes {
+		labeled, err := labelCodeImage(images[i], input, renderers[i].Style, theme)
+		if err != nil {
+			return nil, err
+		}
+		images[i] = labeled
+	}
+
+	return SideBySide(0, images...), nil
+}
+
+// renderSameWidth renders the code of each renderer at the width of the widest, the
+// themes naming them in errors. Bold or italic tokens can make one theme's code
+// slightly wider, so the narrower ones are rendered again at that width.
+func renderSameWidth(renderers []*code.CodeRenderer, themes []string) ([]image.Image, error) {
+	images := make([]image.Image, len(renderers))
+	width := 0
+	for i, r := range renderers {
+		img, err := r.Render()
+		if err != nil {
+			return nil, fmt.Errorf("failed to render theme %s: %v", themes[i], err)
+		}
+		images[i] = img
+		width = max(width, img.Bounds().Dx())
+	}
+
+	for i, img := range images {
+		if img.Bounds().Dx() == width {
+			continue
+		}
+		// The minimum width leaves out the line number gutter, which is measured by
+		// setting it to the width of the image
+		withGutter, _, err := renderers[i].WithMinWidth(width).Measure()
+		if err != nil {
+			return nil, fmt.Errorf("failed to render theme %s: %v", themes[i], err)
+		}
+		rendered, err := renderers[i].WithMinWidth(width - (withGutter - width)).Render()
+		if err != nil {
+			return nil, fmt.Errorf("failed to render theme %s: %v", themes[i], err)
+		}
+		images[i] = rendered
+	}
+	return images, nil
+}
+
+// labelCodeImage adds a strip above the code with the given label, using the colors
+// of the code's theme
+func labelCodeImage(img image.Image, input string, style *code.CodeStyle, label string) (image.Image, error) {
+	h, err := code.Highlight(input, style)
+	if err != nil {
+		return nil, err
+	}
+	bg, fg := h.BackgroundColor, h.LineNumberColor
+	if bg == nil {
+		bg = color.White
+	}
+	if fg == nil {
+		fg = color.Black
+	}
+
+	bounds := img.Bounds()
+	dc := gg.NewContext(bounds.Dx(), bounds.Dy()+comparisonLabelHeight)
+	dc.SetColor(bg)
+	dc.Clear()
+	dc.DrawImage(img, 0, comparisonLabelHeight)
+
+	font, err := fonts.GetFallback(fonts.FallbackSans)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fallback font: %v", err)
+	}
+	face, err := font.GetFace(comparisonLabelFontSize, &fonts.FontStyle{
+		Weight:  fonts.WeightBold,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %v", err)
+	}
+	defer face.Close()
+
+	dc.SetFontFace(face.Face)
+	dc.SetColor(fg)
+	dc.DrawStringAnchored(label, float64(bounds.Dx())/2, comparisonLabelHeight/2, 0.5, 0.35)
+	return dc.Image(), nil
+}
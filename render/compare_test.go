@@ -0,0 +1,51 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/content/code"
+)
+
+func TestSideBySide(t *testing.T) {
+	a := solidContent{width: 30, height: 20, color: color.White}
+	b := solidContent{width: 10, height: 40, color: color.Black}
+	imgA, err := a.Render()
+	require.NoError(t, err)
+	imgB, err := b.Render()
+	require.NoError(t, err)
+
+	img := SideBySide(5, imgA, imgB)
+	assert.Equal(t, 45, img.Bounds().Dx())
+	assert.Equal(t, 40, img.Bounds().Dy())
+}
+
+func TestThemeComparison(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tprintln(\"a line long enough to be wider than the minimum width\")\n}\n"
+
+	t.Run("halves of the same width", func(t *testing.T) {
+		img, err := ThemeComparison(src, "go", "monokai", "github")
+		require.NoError(t, err)
+		single, err := code.DefaultRenderer(src).WithLanguage("go").WithTheme("monokai").Render()
+		require.NoError(t, err)
+		assert.Equal(t, 2*single.Bounds().Dx(), img.Bounds().Dx())
+		assert.Equal(t, single.Bounds().Dy()+comparisonLabelHeight, img.Bounds().Dy())
+	})
+
+	t.Run("narrower code rendered at the wider width", func(t *testing.T) {
+		for _, lineNumbers := range []bool{true, false} {
+			renderers := []*code.CodeRenderer{
+				code.DefaultRenderer(src).WithLineNumbers(lineNumbers),
+				code.DefaultRenderer(src).WithLineNumbers(lineNumbers).WithFontSize(16),
+			}
+			images, err := renderSameWidth(renderers, []string{"a", "b"})
+			require.NoError(t, err)
+			wide, err := code.DefaultRenderer(src).WithLineNumbers(lineNumbers).WithFontSize(16).Render()
+			require.NoError(t, err)
+			assert.Equal(t, wide.Bounds().Dx(), images[0].Bounds().Dx(), "Line numbers %v", lineNumbers)
+			assert.Equal(t, wide.Bounds().Dx(), images[1].Bounds().Dx(), "Line numbers %v", lineNumbers)
+		}
+	})
+}
@@ -1,47 +1,129 @@
 package render
 
 import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
 	"image/jpeg"
-	"image/png"
+	"io"
 	"os"
+	"time"
 
+	"github.com/gen2brain/webp"
+	"github.com/watzon/goshot/content"
 	"golang.org/x/image/bmp"
 )
 
 // SaveAsPNG saves an image to a file in PNG format
 func (c *Canvas) SaveAsPNG(filename string) error {
-	img, err := c.RenderToImage()
+	return c.save(filename, c.encodePNG)
+}
+
+// DefaultJPEGQuality is a JPEG quality that keeps text sharp at a reasonable size
+const DefaultJPEGQuality = 90
+
+// SaveAsJPEG saves an image to a file in JPEG format, with a quality from 1 to 100
+// such as DefaultJPEGQuality. Lower qualities make smaller files with more artifacts.
+func (c *Canvas) SaveAsJPEG(filename string, quality int) error {
+	encode, err := jpegEncoder(quality)
 	if err != nil {
 		return err
 	}
+	return c.save(filename, encode)
+}
 
-	f, err := os.Create(filename)
+// SaveAsBMP saves an image to a file in BMP format
+func (c *Canvas) SaveAsBMP(filename string) error {
+	return c.save(filename, bmp.Encode)
+}
+
+// SaveAsWebP saves an image to a file in WebP format. A quality from 1 to 100 saves
+// a lossy image, while 0 saves a lossless one.
+func (c *Canvas) SaveAsWebP(filename string, quality int) error {
+	encode, err := webpEncoder(quality)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	return c.save(filename, encode)
+}
 
-	return png.Encode(f, img)
+// WritePNG renders the canvas and encodes it in PNG format straight to w, such as an
+// HTTP response or stdout, without buffering the encoded image
+func (c *Canvas) WritePNG(w io.Writer) error {
+	return c.write(w, c.encodePNG)
 }
 
-// SaveAsJPEG saves an image to a file in JPEG format
-func (c *Canvas) SaveAsJPEG(filename string) error {
-	img, err := c.RenderToImage()
+// WriteJPEG renders the canvas and encodes it in JPEG format straight to w, with the
+// quality of SaveAsJPEG
+func (c *Canvas) WriteJPEG(w io.Writer, quality int) error {
+	encode, err := jpegEncoder(quality)
 	if err != nil {
 		return err
 	}
+	return c.write(w, encode)
+}
 
-	f, err := os.Create(filename)
+// WriteBMP renders the canvas and encodes it in BMP format straight to w
+func (c *Canvas) WriteBMP(w io.Writer) error {
+	return c.write(w, bmp.Encode)
+}
+
+// WriteWebP renders the canvas and encodes it in WebP format straight to w, with the
+// quality of SaveAsWebP
+func (c *Canvas) WriteWebP(w io.Writer, quality int) error {
+	encode, err := webpEncoder(quality)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	return c.write(w, encode)
+}
+
+// encoder encodes an image to a writer in some format
+type encoder func(w io.Writer, img image.Image) error
 
-	return jpeg.Encode(f, img, nil)
+// encodePNG encodes a PNG image with the canvas' metadata
+func (c *Canvas) encodePNG(w io.Writer, img image.Image) error {
+	return EncodePNG(w, img, c.metadata)
 }
 
-// SaveAsBMP saves an image to a file in BMP format
-func (c *Canvas) SaveAsBMP(filename string) error {
+// jpegEncoder returns the encoder for JPEG images of the given quality
+func jpegEncoder(quality int) (encoder, error) {
+	if quality < 1 || quality > 100 {
+		return nil, fmt.Errorf("invalid JPEG quality %d: must be between 1 and 100", quality)
+	}
+	return func(w io.Writer, img image.Image) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}, nil
+}
+
+// webpEncoder returns the encoder for WebP images of the given quality
+func webpEncoder(quality int) (encoder, error) {
+	if quality < 0 || quality > 100 {
+		return nil, fmt.Errorf("invalid WebP quality %d: must be between 0 and 100", quality)
+	}
+	return func(w io.Writer, img image.Image) error {
+		return webp.Encode(w, img, webp.Options{
+			Quality:  quality,
+			Lossless: quality == 0,
+			Method:   webp.DefaultMethod,
+		})
+	}, nil
+}
+
+// write renders the canvas and encodes it to w
+func (c *Canvas) write(w io.Writer, encode encoder) error {
+	img, err := c.RenderToImage()
+	if err != nil {
+		return err
+	}
+	return encode(w, img)
+}
+
+// save renders the canvas and encodes it to a file, which is only created once the
+// rendering succeeded
+func (c *Canvas) save(filename string, encode encoder) error {
 	img, err := c.RenderToImage()
 	if err != nil {
 		return err
@@ -53,11 +135,52 @@ func (c *Canvas) SaveAsBMP(filename string) error {
 	}
 	defer f.Close()
 
-	return bmp.Encode(f, img)
+	return encode(f, img)
 }
 
 // SaveAsSVG saves an image to a file in SVG format
 func (c *Canvas) SaveAsSVG(filename string) error {
-	// TODO
-	return nil
+	data, err := c.RenderToSVG()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, 0644)
+}
+
+// SaveAsGIF saves an animated GIF with one frame per content image, such as those
+// returned by TermRenderer.RenderFrames, each shown for delay. Every frame gets the
+// canvas' chrome, background and overlays; the canvas' own content is not used, nor
+// are annotations, which point into it. The frames are used as they are, so on a
+// scaled canvas they should be rendered at the same scale.
+func (c *Canvas) SaveAsGIF(filename string, frames []image.Image, delay time.Duration) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("at least one frame is required")
+	}
+	c = c.scaled()
+
+	anim := &gif.GIF{}
+	for i, frame := range frames {
+		c.progress.Report(content.StageCompositing, float64(i)/float64(len(frames)))
+		img, err := c.decorate(frame)
+		if err != nil {
+			return fmt.Errorf("failed to render frame %d: %v", i, err)
+		}
+		img = c.fitted(img)
+
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, img.Bounds().Min)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond))) // In hundredths of a second
+	}
+
+	c.progress.Report(content.StageCompositing, 1)
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gif.EncodeAll(f, anim)
 }
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/webp"
+)
+
+func TestWriteFormats(t *testing.T) {
+	c := NewCanvas().WithContent(solidContent{width: 30, height: 20, color: color.RGBA{B: 255, A: 255}})
+
+	for name, tc := range map[string]struct {
+		write  func(buf *bytes.Buffer) error
+		decode func(buf *bytes.Buffer) (image.Image, error)
+	}{
+		"png": {
+			write:  func(buf *bytes.Buffer) error { return c.WritePNG(buf) },
+			decode: func(buf *bytes.Buffer) (image.Image, error) { return png.Decode(buf) },
+		},
+		"jpeg": {
+			write:  func(buf *bytes.Buffer) error { return c.WriteJPEG(buf, DefaultJPEGQuality) },
+			decode: func(buf *bytes.Buffer) (image.Image, error) { return jpeg.Decode(buf) },
+		},
+		"bmp": {
+			write:  func(buf *bytes.Buffer) error { return c.WriteBMP(buf) },
+			decode: func(buf *bytes.Buffer) (image.Image, error) { return bmp.Decode(buf) },
+		},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, tc.write(&buf), name)
+		img, err := tc.decode(&buf)
+		require.NoError(t, err, name)
+		assert.Equal(t, image.Pt(30, 20), img.Bounds().Size(), name)
+	}
+
+	assert.Error(t, c.WriteWebP(&bytes.Buffer{}, 101))
+	assert.Error(t, c.WriteJPEG(&bytes.Buffer{}, 0))
+	assert.Error(t, c.WriteJPEG(&bytes.Buffer{}, 101))
+	assert.Error(t, NewCanvas().WritePNG(&bytes.Buffer{}), "Nothing to render")
+}
+
+// gradientContent is content with a different color in every pixel
+type gradientContent struct{}
+
+func (gradientContent) Render() (image.Image, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: uint8(x * y), A: 255})
+		}
+	}
+	return img, nil
+}
+
+func TestWriteWebP(t *testing.T) {
+	c := NewCanvas().WithContent(gradientContent{})
+	want, err := c.RenderToImage()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		quality  int
+		lossless bool
+		wantErr  bool
+	}{
+		{name: "lossless", quality: 0, lossless: true},
+		{name: "lowest quality", quality: 1},
+		{name: "default quality", quality: DefaultJPEGQuality},
+		{name: "highest quality", quality: 100},
+		{name: "negative quality", quality: -1, wantErr: true},
+		{name: "quality too high", quality: 101, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := c.WriteWebP(&buf, tt.quality)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "invalid WebP quality")
+				assert.Zero(t, buf.Len())
+
+				filename := filepath.Join(t.TempDir(), "out.webp")
+				assert.Error(t, c.SaveAsWebP(filename, tt.quality))
+				assert.NoFileExists(t, filename)
+				return
+			}
+			require.NoError(t, err)
+
+			// Lossless images are stored as VP8L and lossy ones as VP8
+			chunk := "VP8 "
+			if tt.lossless {
+				chunk = "VP8L"
+			}
+			assert.Equal(t, chunk, string(buf.Bytes()[12:16]))
+
+			// Unlike the encoder's own decoder, x/image decodes lossless images without
+			// converting them to YCbCr
+			img, err := webp.Decode(&buf)
+			require.NoError(t, err)
+			require.Equal(t, want.Bounds(), img.Bounds())
+			if tt.lossless {
+				// Every pixel survives the round trip
+				for y := 0; y < want.Bounds().Dy(); y++ {
+					for x := 0; x < want.Bounds().Dx(); x++ {
+						require.Equal(t, color.NRGBAModel.Convert(want.At(x, y)), color.NRGBAModel.Convert(img.At(x, y)), "pixel %d,%d", x, y)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+)
+
+// frostedGlass is the look of a window set with WithFrostedContent
+type frostedGlass struct {
+	radius float64     // Blur radius of the background under the window
+	tint   color.Color // Color drawn over the blurred background, usually translucent
+}
+
+// WithFrostedContent draws the window as frosted glass: the background under it is
+// blurred by blurRadius and tinted, then the window is drawn on top. The effect shows
+// through wherever the window is see-through, so it's meant for code drawn without
+// its background (see CodeRenderer.WithTransparentBackground) or in a translucent
+// chrome. A nil tint leaves the blurred background as it is. Backgrounds that can't
+// report where they place the content are drawn as usual.
+func (c *Canvas) WithFrostedContent(blurRadius float64, tint color.Color) *Canvas {
+	c.frosted = &frostedGlass{radius: blurRadius, tint: tint}
+	return c
+}
+
+// renderFrosted draws the background around img like Render would, with the part of
+// it under img blurred and tinted
+func (c *Canvas) renderFrosted(img image.Image) (image.Image, error) {
+	bg := c.fittedBackground()
+	placer, ok := bg.(background.Placer)
+	if !ok {
+		return bg.Render(img)
+	}
+
+	// The background is drawn around nothing but a see-through window, leaving what's
+	// under the window to be frosted
+	size := img.Bounds().Size()
+	clear := image.NewRGBA(image.Rectangle{Max: size})
+	behind, err := bg.Render(clear)
+	if err != nil {
+		return nil, err
+	}
+	out := image.NewRGBA(behind.Bounds())
+	draw.Draw(out, out.Bounds(), behind, behind.Bounds().Min, draw.Src)
+	window := placer.Place(size.X, size.Y).Add(out.Bounds().Min)
+
+	// A shadow would show through the glass, so what's frosted is the background
+	// drawn without one, when there is one
+	under, underWindow := image.Image(out), window
+	unshadowed := bg.WithShadow(nil)
+	if p, ok := unshadowed.(background.Placer); ok {
+		if rect := p.Place(size.X, size.Y); rect.Min != window.Min {
+			if under, err = unshadowed.Render(clear); err != nil {
+				return nil, err
+			}
+			underWindow = rect.Add(under.Bounds().Min)
+		}
+	}
+
+	radius := 0.0
+	if rc, ok := c.chrome.(chrome.RoundedChrome); ok {
+		radius = rc.CornerRadius()
+	}
+	frost(out, window, under, underWindow, c.frosted.radius*c.scaleFactor(), c.frosted.tint, radius)
+
+	draw.Draw(out, window, img, img.Bounds().Min, draw.Over)
+	return out, nil
+}
+
+// frost draws the part of src under srcWindow blurred and tinted into the window of
+// dst, keeping to its rounded corners. The blur takes in the pixels around the window
+// too, so that its edges don't fade.
+func frost(dst *image.RGBA, window image.Rectangle, src image.Image, srcWindow image.Rectangle, blurRadius float64, tint color.Color, cornerRadius float64) {
+	window = window.Intersect(dst.Bounds())
+	if window.Empty() {
+		return
+	}
+
+	margin := int(math.Ceil(blurRadius * 3))
+	area := srcWindow.Inset(-margin).Intersect(src.Bounds())
+	frosted := image.NewNRGBA(image.Rectangle{Max: area.Size()})
+	draw.Draw(frosted, frosted.Bounds(), src, area.Min, draw.Src)
+	if blurRadius > 0 {
+		frosted = imaging.Blur(frosted, blurRadius)
+	}
+	inner := image.Rectangle{Min: srcWindow.Min.Sub(area.Min), Max: srcWindow.Min.Sub(area.Min).Add(window.Size())}
+	if tint != nil {
+		draw.Draw(frosted, inner, image.NewUniform(tint), image.Point{}, draw.Over)
+	}
+
+	// Only the window's rounded rectangle is frosted
+	dc := gg.NewContext(window.Dx(), window.Dy())
+	dc.DrawRoundedRectangle(0, 0, float64(window.Dx()), float64(window.Dy()), cornerRadius)
+	dc.Fill()
+	draw.DrawMask(dst, window, frosted, inner.Min, dc.Image(), image.Point{}, draw.Over)
+}
@@ -0,0 +1,35 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+)
+
+func TestFrostedContent(t *testing.T) {
+	blue := color.RGBA{B: 255, A: 255}
+	glass := solidContent{width: 100, height: 40, color: color.Transparent}
+	bg := background.NewColorBackground().WithColor(blue).WithPadding(20).
+		WithShadow(background.NewShadow().WithBlur(6).WithColor(color.RGBA{A: 200}))
+
+	render := func(c *Canvas) color.RGBA {
+		t.Helper()
+		img, err := c.WithContent(glass).WithBackground(bg).RenderToImage()
+		require.NoError(t, err)
+		rect, err := c.ContentRect()
+		require.NoError(t, err)
+		center := rect.Min.Add(rect.Size().Div(2))
+		return color.RGBAModel.Convert(img.At(center.X, center.Y)).(color.RGBA)
+	}
+
+	// Drawn as usual the shadow shows through the window, but not through frosted glass
+	assert.NotEqual(t, blue, render(NewCanvas()))
+	assert.Equal(t, blue, render(NewCanvas().WithFrostedContent(4, nil)))
+
+	tinted := render(NewCanvas().WithFrostedContent(4, color.NRGBA{R: 255, G: 255, B: 255, A: 128}))
+	assert.InDelta(t, 128, int(tinted.R), 2)
+	assert.Equal(t, uint8(255), tinted.B)
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/content"
+)
+
+// Measure returns the size of the image RenderToImage would produce. Content and
+// backgrounds that can measure themselves are sized without drawing anything, and the
+// chrome only adds its insets; anything else is rendered to find out its size. It
+// doesn't change the canvas, so it's safe to call as often as needed.
+func (c *Canvas) Measure() (width, height int, err error) {
+	// Validate that at least one renderer is set
+	if c.chrome == nil && c.background == nil && c.content == nil {
+		return 0, 0, fmt.Errorf("at least one renderer must be set")
+	}
+	c = c.scaled()
+
+	// First, size the content
+	if width, height, err = c.contentSize(); err != nil {
+		return 0, 0, err
+	}
+
+	// Then add the chrome around it
+	if c.chrome != nil {
+		if c.content == nil {
+			width, height = c.chrome.MinimumSize()
+		}
+		top, right, bottom, left := c.chrome.ContentInsets()
+		width += left + right
+		height += top + bottom
+	}
+
+	// The window is scaled down to the output size
+	if c.content != nil || c.chrome != nil {
+		width, height = scaledSize(width, height, c.windowScale(width, height))
+	}
+
+	// Then the background, padded to the image's size or aspect ratio, which may need
+	// to render everything to know its size
+	if c.background != nil {
+		if c.content != nil || c.chrome != nil {
+			c = c.padded(width, height)
+		}
+		m, ok := c.background.(background.Measurer)
+		if !ok {
+			return c.fullRenderSize()
+		}
+		width, height = m.Measure(width, height)
+	}
+
+	if size, _, ok := c.fittedBounds(width, height); ok {
+		return size.X, size.Y, nil
+	}
+	return width, height, nil
+}
+
+// ContentRect returns where RenderToImage places the content in its image, inside the
+// chrome and the background, for drawing over the content afterwards. Like Measure, it
+// only renders what can't be measured.
+func (c *Canvas) ContentRect() (image.Rectangle, error) {
+	rect, _, err := c.unfittedContentRect()
+	if err != nil || c.size == nil {
+		return rect, err
+	}
+
+	// The image may be scaled or centered to bring it to its size
+	width, height, err := c.unfitted().Measure()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return c.fittedRect(rect, width, height), nil
+}
+
+// unfittedContentRect returns where the content is placed in the image before it's
+// scaled or centered to bring it to the size set with WithAspectRatio or
+// WithOutputSize, and how much the window was scaled down to fit the output size
+func (c *Canvas) unfittedContentRect() (rect image.Rectangle, windowScale float64, err error) {
+	// Validate that at least one renderer is set
+	if c.chrome == nil && c.background == nil && c.content == nil {
+		return image.Rectangle{}, 0, fmt.Errorf("at least one renderer must be set")
+	}
+	c = c.unfitted().scaled()
+
+	width, height, err := c.contentSize()
+	if err != nil {
+		return image.Rectangle{}, 0, err
+	}
+
+	// The chrome draws the content past its insets
+	rect = image.Rect(0, 0, width, height)
+	if c.chrome != nil {
+		if c.content == nil {
+			width, height = c.chrome.MinimumSize()
+			rect = image.Rect(0, 0, width, height)
+		}
+		top, right, bottom, left := c.chrome.ContentInsets()
+		rect = rect.Add(image.Pt(left, top))
+		width += left + right
+		height += top + bottom
+	}
+
+	// The window is scaled down to the output size
+	windowScale = c.windowScale(width, height)
+	if windowScale != 1 {
+		scaledWidth, scaledHeight := scaledSize(width, height, windowScale)
+		rect = mapRect(rect, width, height, image.Rect(0, 0, scaledWidth, scaledHeight))
+		width, height = scaledWidth, scaledHeight
+	}
+
+	// Then the background, padded to the image's size or aspect ratio, places the window
+	if c.background != nil {
+		c = c.padded(width, height)
+		if p, ok := c.fittedBackground().(background.Placer); ok {
+			rect = rect.Add(p.Place(width, height).Min)
+		} else {
+			// Backgrounds that can't tell are assumed to center the window
+			fullWidth, fullHeight, err := c.Measure()
+			if err != nil {
+				return image.Rectangle{}, 0, err
+			}
+			rect = rect.Add(image.Pt((fullWidth-width)/2, (fullHeight-height)/2))
+		}
+	}
+
+	return rect, windowScale, nil
+}
+
+// contentSize returns the size of the content, measuring it if it can, or zero
+// without content
+func (c *Canvas) contentSize() (width, height int, err error) {
+	if c.content == nil {
+		return 0, 0, nil
+	}
+	if m, ok := c.content.(content.Measurer); ok {
+		return m.Measure()
+	}
+	return c.renderedSize()
+}
+
+// renderedSize renders the content to find its size
+func (c *Canvas) renderedSize() (width, height int, err error) {
+	img, err := c.content.Render()
+	if err != nil {
+		return 0, 0, err
+	}
+	return img.Bounds().Dx(), img.Bounds().Dy(), nil
+}
+
+// fullRenderSize renders the whole canvas to find its size
+func (c *Canvas) fullRenderSize() (width, height int, err error) {
+	img, err := c.RenderToImage()
+	if err != nil {
+		return 0, 0, err
+	}
+	return img.Bounds().Dx(), img.Bounds().Dy(), nil
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+)
+
+// solidContent is content of a single color, which can't measure itself
+type solidContent struct {
+	width, height int
+	color         color.Color
+}
+
+func (s solidContent) Render() (image.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(s.color), image.Point{}, draw.Src)
+	return img, nil
+}
+
+func TestContentRect(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	content := solidContent{width: 200, height: 50, color: red}
+	shadow := background.NewShadow().WithOffset(0, 4).WithBlur(6)
+
+	tests := []struct {
+		name   string
+		canvas *Canvas
+	}{
+		{"content only", NewCanvas().WithContent(content)},
+		{"chrome", NewCanvas().WithContent(content).WithChrome(chrome.NewWindowsChrome(chrome.WindowsStyleWin11))},
+		{"background", NewCanvas().WithContent(content).WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia)).
+			WithBackground(background.NewColorBackground().WithPaddingDetailed(10, 20, 30, 40).WithShadow(shadow))},
+		{"layered background", NewCanvas().WithContent(content).WithChrome(chrome.NewGNOMEChrome(chrome.GNOMEStyleBreeze)).
+			WithBackground(background.NewLayeredBackground(background.NewColorBackground().WithPadding(25)).WithPadding(5))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := tt.canvas.RenderToImage()
+			require.NoError(t, err)
+			rect, err := tt.canvas.ContentRect()
+			require.NoError(t, err)
+
+			assert.Equal(t, image.Pt(200, 50), rect.Size())
+			assert.True(t, rect.In(img.Bounds()))
+			assert.Equal(t, red, img.At(rect.Min.X, rect.Min.Y+10), "Left edge of the content")
+			assert.Equal(t, red, img.At(rect.Max.X-1, rect.Max.Y-10), "Right edge of the content, above the rounded corner")
+			if rect.Max.X < img.Bounds().Max.X {
+				assert.NotEqual(t, red, img.At(rect.Max.X, rect.Max.Y-10))
+			}
+			if rect.Min.X > 0 {
+				assert.NotEqual(t, red, img.At(rect.Min.X-1, rect.Min.Y+10))
+			}
+			if rect.Min.Y > 0 {
+				assert.NotEqual(t, red, img.At(rect.Min.X+10, rect.Min.Y-1))
+			}
+		})
+	}
+
+	_, err := NewCanvas().ContentRect()
+	assert.Error(t, err)
+}
+
+func TestTransparentBackground(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	content := solidContent{width: 100, height: 40, color: red}
+
+	tests := []struct {
+		name string
+		bg   background.Background
+	}{
+		{"color", background.NewColorBackground().WithColor(color.White).WithPaddingDetailed(10, 20, 30, 40).
+			WithShadow(background.NewShadow().WithBlur(6).WithOffset(0, 4))},
+		{"gradient", background.NewGradientBackground(background.LinearGradient,
+			background.GradientStop{Color: color.White, Position: 0},
+			background.GradientStop{Color: color.Black, Position: 1}).WithPaddingDetailed(10, 20, 30, 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opaque := NewCanvas().WithContent(content).WithBackground(tt.bg)
+			want, err := opaque.ContentRect()
+			require.NoError(t, err)
+
+			// Set either before or after the background
+			for _, canvas := range []*Canvas{
+				NewCanvas().WithContent(content).WithBackground(tt.bg).WithTransparentBackground(),
+				NewCanvas().WithContent(content).WithTransparentBackground().WithBackground(tt.bg),
+			} {
+				img, err := canvas.RenderToImage()
+				require.NoError(t, err)
+				rect, err := canvas.ContentRect()
+				require.NoError(t, err)
+
+				assert.Equal(t, want, rect, "the content stays where the background puts it")
+				assert.Equal(t, red, img.At(rect.Min.X, rect.Min.Y))
+				_, _, _, a := img.At(0, 0).RGBA()
+				assert.Zero(t, a)
+				_, _, _, a = img.At(img.Bounds().Max.X-1, rect.Min.Y).RGBA()
+				assert.Zero(t, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// pngSoftware is the Software entry written along with any metadata
+const pngSoftware = "goshot"
+
+// pngHeaderSize is the size of the PNG signature and the IHDR chunk, after which the
+// text chunks are written
+const pngHeaderSize = 8 + 4 + 4 + 13 + 4
+
+// WithMetadata embeds text in PNG images saved or written by the canvas, such as the
+// command that produced them or the theme used. The keys are PNG keywords, for
+// example "Title", "Description", "Source" or "Creation Time", of 1 to 79 printable
+// ASCII characters; the values may be any text. A "Software: goshot" entry is added
+// unless the metadata has its own. Without metadata the images carry none, so the
+// same canvas always gives the same bytes. Other formats ignore it.
+func (c *Canvas) WithMetadata(metadata map[string]string) *Canvas {
+	c.metadata = metadata
+	return c
+}
+
+// EncodePNG writes img to w in PNG format with the given metadata in text chunks,
+// like a canvas given WithMetadata does. A nil or empty map writes no text chunks at
+// all.
+func EncodePNG(w io.Writer, img image.Image, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return png.Encode(w, img)
+	}
+	chunks, err := pngTextChunks(metadata)
+	if err != nil {
+		return err
+	}
+	tw := &pngTextWriter{w: w, chunks: chunks}
+	if err := png.Encode(tw, img); err != nil {
+		return err
+	}
+	if tw.written < pngHeaderSize {
+		return fmt.Errorf("png: encoder stopped before the image header")
+	}
+	return nil
+}
+
+// pngTextChunks encodes the metadata as text chunks, sorted by keyword so the output
+// doesn't depend on the order of the map. ASCII values are written as tEXt chunks and
+// anything else as UTF-8 iTXt chunks.
+func pngTextChunks(metadata map[string]string) ([]byte, error) {
+	entries := make(map[string]string, len(metadata)+1)
+	entries["Software"] = pngSoftware
+	for key, value := range metadata {
+		entries[key] = value
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		if err := validPNGKeyword(key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	for _, key := range keys {
+		value := entries[key]
+		if !utf8.ValidString(value) {
+			return nil, fmt.Errorf("invalid metadata %q: the value isn't valid UTF-8", key)
+		}
+		if isASCII(value) {
+			writePNGChunk(&buf, "tEXt", []byte(key+"\x00"+value))
+		} else {
+			// No compression, and no language or translated keyword
+			writePNGChunk(&buf, "iTXt", []byte(key+"\x00\x00\x00\x00\x00"+value))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// validPNGKeyword checks that key can be used as the keyword of a text chunk
+func validPNGKeyword(key string) error {
+	if len(key) == 0 || len(key) > 79 {
+		return fmt.Errorf("invalid metadata key %q: must be 1 to 79 characters", key)
+	}
+	if strings.HasPrefix(key, " ") || strings.HasSuffix(key, " ") || strings.Contains(key, "  ") {
+		return fmt.Errorf("invalid metadata key %q: can't start or end with a space, or have two in a row", key)
+	}
+	for _, r := range key {
+		if r < 32 || r > 126 {
+			return fmt.Errorf("invalid metadata key %q: only printable ASCII characters are allowed", key)
+		}
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// writePNGChunk appends a chunk of the given type and data to buf
+func writePNGChunk(buf *bytes.Buffer, chunkType string, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	buf.WriteString(chunkType)
+	buf.Write(data)
+	_ = binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+// pngTextWriter passes an encoded PNG through to w, inserting the text chunks right
+// after the image header
+type pngTextWriter struct {
+	w       io.Writer
+	chunks  []byte
+	written int // Bytes of the encoded image passed through so far
+}
+
+func (tw *pngTextWriter) Write(p []byte) (int, error) {
+	n := 0
+	if tw.written < pngHeaderSize {
+		head := min(len(p), pngHeaderSize-tw.written)
+		m, err := tw.w.Write(p[:head])
+		n += m
+		tw.written += m
+		if err != nil {
+			return n, err
+		}
+		if tw.written < pngHeaderSize {
+			return n, nil
+		}
+		if _, err := tw.w.Write(tw.chunks); err != nil {
+			return n, err
+		}
+		p = p[head:]
+	}
+	m, err := tw.w.Write(p)
+	tw.written += m
+	return n + m, err
+}
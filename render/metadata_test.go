@@ -0,0 +1,61 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngChunks returns the types of the chunks of an encoded PNG image, and the data of
+// its text chunks
+func pngChunks(t *testing.T, data []byte) (types []string, text []string) {
+	t.Helper()
+	require.Greater(t, len(data), 8)
+	data = data[8:]
+	for len(data) >= 12 {
+		length := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+		chunkType := string(data[4:8])
+		types = append(types, chunkType)
+		if chunkType == "tEXt" || chunkType == "iTXt" {
+			text = append(text, string(data[8:8+length]))
+		}
+		data = data[12+length:]
+	}
+	return types, text
+}
+
+func TestWithMetadata(t *testing.T) {
+	c := NewCanvas().WithContent(solidContent{width: 8, height: 8, color: color.RGBA{G: 255, A: 255}})
+
+	var plain bytes.Buffer
+	require.NoError(t, c.WritePNG(&plain))
+	types, text := pngChunks(t, plain.Bytes())
+	assert.Empty(t, text, "No metadata is written by default")
+	assert.Equal(t, "IHDR", types[0])
+
+	c.WithMetadata(map[string]string{"Title": "main.go", "Theme": "dracula", "Comment": "größer"})
+	var buf bytes.Buffer
+	require.NoError(t, c.WritePNG(&buf))
+	types, text = pngChunks(t, buf.Bytes())
+	assert.Equal(t, []string{"IHDR", "iTXt", "tEXt", "tEXt", "tEXt"}, types[:5], "The text follows the header, sorted by keyword")
+	assert.Equal(t, []string{
+		"Comment\x00\x00\x00\x00\x00größer",
+		"Software\x00goshot",
+		"Theme\x00dracula",
+		"Title\x00main.go",
+	}, text)
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err, "The image is still a valid PNG")
+	assert.Equal(t, image.Pt(8, 8), img.Bounds().Size())
+
+	for _, key := range []string{"", " Title", "Tab\tbed", string(make([]byte, 80))} {
+		c.WithMetadata(map[string]string{key: "x"})
+		assert.Error(t, c.WritePNG(&bytes.Buffer{}), "%q isn't a valid keyword", key)
+	}
+}
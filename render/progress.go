@@ -0,0 +1,37 @@
+package render
+
+import (
+	"image"
+
+	"github.com/watzon/goshot/content"
+)
+
+// contentProgress is how far along a render of the canvas is once its content is drawn,
+// leaving the rest to the chrome, background and overlays
+const contentProgress = 0.7
+
+// WithProgress has progress called at each stage of a render of the canvas, with how
+// far along it is from 0 to 1, for showing the progress of long renders. Content that
+// reports its own stages, as code does with content.StageHighlighting,
+// content.StageMeasuring and content.StageDrawing, takes up the first part of the
+// render; the rest is content.StageCompositing, the chrome, background and overlays
+// being put together around the content, and the render ends on a report of 1. When
+// saving a GIF, compositing is reported frame by frame instead. Progress is called on
+// the goroutine doing the render, so in a RenderBatch each canvas reports its own.
+func (c *Canvas) WithProgress(progress content.ProgressFunc) *Canvas {
+	c.progress = progress
+	return c
+}
+
+// renderContent renders the canvas' content, its progress making up the first part of
+// the canvas' when it reports any
+func (c *Canvas) renderContent() (image.Image, error) {
+	reporter, ok := c.content.(content.ProgressReporter)
+	if c.progress == nil || !ok {
+		c.progress.Report(content.StageDrawing, 0)
+		return c.content.Render()
+	}
+	return reporter.RenderWithProgress(func(stage string, fraction float64) {
+		c.progress.Report(stage, fraction*contentProgress)
+	})
+}
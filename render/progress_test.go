@@ -0,0 +1,65 @@
+package render
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/content/code"
+)
+
+func TestWithProgress(t *testing.T) {
+	type report struct {
+		stage    string
+		fraction float64
+	}
+	render := func(c *Canvas) []report {
+		var reports []report
+		c.WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia)).
+			WithBackground(background.NewColorBackground().WithPadding(20)).
+			WithProgress(func(stage string, fraction float64) {
+				reports = append(reports, report{stage, fraction})
+			})
+		_, err := c.RenderToImage()
+		require.NoError(t, err)
+		return reports
+	}
+
+	t.Run("code", func(t *testing.T) {
+		src := strings.Repeat("fmt.Println(\"hello\")\n", 300)
+		reports := render(NewCanvas().WithContent(code.DefaultRenderer(src)))
+
+		var stages []string
+		for i, r := range reports {
+			if i > 0 {
+				assert.GreaterOrEqual(t, r.fraction, reports[i-1].fraction, "Never going backwards")
+			}
+			if len(stages) == 0 || stages[len(stages)-1] != r.stage {
+				stages = append(stages, r.stage)
+			}
+		}
+		assert.Equal(t, []string{content.StageHighlighting, content.StageMeasuring, content.StageDrawing, content.StageCompositing}, stages)
+		assert.Equal(t, report{content.StageHighlighting, 0}, reports[0])
+		assert.Equal(t, report{content.StageCompositing, 1}, reports[len(reports)-1])
+		assert.Greater(t, len(reports), 50, "Drawing is reported as it goes")
+	})
+
+	t.Run("other content", func(t *testing.T) {
+		reports := render(NewCanvas().WithContent(solidContent{width: 10, height: 10, color: color.Black}))
+		assert.Equal(t, []report{
+			{content.StageDrawing, 0},
+			{content.StageCompositing, contentProgress},
+			{content.StageCompositing, 1},
+		}, reports)
+	})
+
+	t.Run("without content", func(t *testing.T) {
+		reports := render(NewCanvas())
+		assert.Equal(t, []report{{content.StageCompositing, 0}, {content.StageCompositing, 1}}, reports)
+	})
+}
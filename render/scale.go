@@ -0,0 +1,77 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content"
+	"golang.org/x/image/draw"
+)
+
+// WithScale draws everything factor times as large, for high density displays: a
+// factor of 2 gives an image twice as wide and tall, with the text drawn at twice the
+// font size rather than stretched. The font size, padding and every other size set on
+// the canvas and its parts stay in units of the unscaled image, and so do pixel
+// positions of annotations. Content, chromes and backgrounds are drawn at the scale
+// when they implement content.Scaler, chrome.Scaler and background.Scaler, which all
+// of the ones in goshot do; other content is rendered as usual and enlarged.
+func (c *Canvas) WithScale(factor float64) *Canvas {
+	c.scale = factor
+	return c
+}
+
+// scaleFactor returns the scale the canvas is drawn at
+func (c *Canvas) scaleFactor() float64 {
+	if c.scale <= 0 {
+		return 1
+	}
+	return c.scale
+}
+
+// scaled returns a copy of the canvas whose content, chrome and background are drawn
+// at its scale, or the canvas itself if there's nothing to scale
+func (c *Canvas) scaled() *Canvas {
+	factor := c.scaleFactor()
+	if factor == 1 || c.partsScaled {
+		return c
+	}
+
+	s := *c
+	s.partsScaled = true
+	if c.content != nil {
+		if sc, ok := c.content.(content.Scaler); ok {
+			s.content = sc.Scaled(factor)
+		} else {
+			s.content = enlargedContent{content: c.content, factor: factor}
+		}
+	}
+	if sc, ok := c.chrome.(chrome.Scaler); ok {
+		s.chrome = sc.Scaled(factor)
+	}
+	if sb, ok := c.background.(background.Scaler); ok {
+		s.background = sb.Scaled(factor)
+	}
+	return &s
+}
+
+// enlargedContent is content that can't be drawn at a scale, rendered as usual and
+// then enlarged
+type enlargedContent struct {
+	content content.Content
+	factor  float64
+}
+
+func (e enlargedContent) Render() (image.Image, error) {
+	img, err := e.content.Render()
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	width := int(math.Round(float64(bounds.Dx()) * e.factor))
+	height := int(math.Round(float64(bounds.Dy()) * e.factor))
+	enlarged := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(enlarged, enlarged.Bounds(), img, bounds, draw.Src, nil)
+	return enlarged, nil
+}
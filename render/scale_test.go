@@ -0,0 +1,77 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content/code"
+)
+
+func TestWithScale(t *testing.T) {
+	canvas := func(scale float64) *Canvas {
+		return NewCanvas().
+			WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia).WithTitle("main.go")).
+			WithBackground(background.NewColorBackground().WithPadding(30).WithShadow(background.NewShadow())).
+			WithContent(code.DefaultRenderer("package main\n\nfunc main() {}\n")).
+			WithScale(scale)
+	}
+
+	img, err := canvas(1).RenderToImage()
+	require.NoError(t, err)
+	rect, err := canvas(1).ContentRect()
+	require.NoError(t, err)
+
+	for _, scale := range []int{2, 3} {
+		c := canvas(float64(scale))
+		scaled, err := c.RenderToImage()
+		require.NoError(t, err)
+		// Glyph advances are rounded to whole pixels, so text may come out a pixel wider
+		// or narrower than exactly to scale
+		assert.InDelta(t, img.Bounds().Dx()*scale, scaled.Bounds().Dx(), float64(scale), "The image grows by the scale at %dx", scale)
+		assert.Equal(t, img.Bounds().Dy()*scale, scaled.Bounds().Dy(), "The image grows by the scale at %dx", scale)
+
+		width, height, err := c.Measure()
+		require.NoError(t, err)
+		assert.Equal(t, scaled.Bounds().Size(), image.Pt(width, height))
+
+		scaledRect, err := c.ContentRect()
+		require.NoError(t, err)
+		assert.Equal(t, rect.Min.Mul(scale), scaledRect.Min, "The content moves with the scale at %dx", scale)
+		assert.InDelta(t, rect.Dx()*scale, scaledRect.Dx(), float64(scale), "The content is drawn, not stretched, at %dx", scale)
+		assert.Equal(t, rect.Dy()*scale, scaledRect.Dy(), "The content is drawn, not stretched, at %dx", scale)
+	}
+}
+
+func TestWithScaleEnlargesOtherContent(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	img, err := NewCanvas().
+		WithContent(solidContent{width: 40, height: 10, color: red}).
+		WithScale(2).
+		RenderToImage()
+	require.NoError(t, err)
+	assert.Equal(t, 80, img.Bounds().Dx())
+	assert.Equal(t, 20, img.Bounds().Dy())
+	assert.Equal(t, red, color.RGBAModel.Convert(img.At(40, 10)))
+}
+
+func TestWithScaleSVG(t *testing.T) {
+	c := NewCanvas().
+		WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia)).
+		WithBackground(background.NewColorBackground()).
+		WithContent(code.DefaultRenderer("x := 1")).
+		WithScale(2)
+	width, height, err := c.Measure()
+	require.NoError(t, err)
+
+	doc, err := c.RenderToSVG()
+	require.NoError(t, err)
+	assert.Contains(t, string(doc), `transform="scale(2)"`)
+	assert.Contains(t, string(doc), `width="`+strconv.Itoa(width)+`"`)
+	assert.Contains(t, string(doc), `height="`+strconv.Itoa(height)+`"`)
+}
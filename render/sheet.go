@@ -0,0 +1,109 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/fonts"
+)
+
+const (
+	sheetCaptionHeight   = 32
+	sheetCaptionFontSize = 13
+)
+
+var (
+	sheetBackground   = color.RGBA{R: 245, G: 245, B: 245, A: 255}
+	sheetCaptionColor = color.RGBA{R: 60, G: 60, B: 60, A: 255}
+)
+
+// SheetItem is an image on a contact sheet, with the caption written below it
+type SheetItem struct {
+	Image   image.Image
+	Caption string
+}
+
+// ContactSheet lays the images out in a grid of cols columns, row by row, for looking
+// over many renders at once. Every cell is as large as the largest image, which is
+// centered at the top of it with its caption below, cut short with an ellipsis if it's
+// wider than the cell. Cells are gap pixels apart and from the edges of the sheet,
+// which is light gray behind them. With cols of zero or less the grid is as close to
+// square as it can be.
+func ContactSheet(items []SheetItem, cols int, gap int) (image.Image, error) {
+	if len(items) == 0 {
+		return nil, errors.New("no items for the contact sheet")
+	}
+	if cols <= 0 {
+		cols = int(math.Ceil(math.Sqrt(float64(len(items)))))
+	}
+	cols = min(cols, len(items))
+	rows := (len(items) + cols - 1) / cols
+	gap = max(gap, 0)
+
+	cellWidth, imageHeight, captioned := 0, 0, false
+	for i, item := range items {
+		if item.Image == nil {
+			return nil, fmt.Errorf("contact sheet item %d has no image", i)
+		}
+		cellWidth = max(cellWidth, item.Image.Bounds().Dx())
+		imageHeight = max(imageHeight, item.Image.Bounds().Dy())
+		captioned = captioned || item.Caption != ""
+	}
+	cellHeight := imageHeight
+	if captioned {
+		cellHeight += sheetCaptionHeight
+	}
+
+	dc := gg.NewContext(gap+cols*(cellWidth+gap), gap+rows*(cellHeight+gap))
+	dc.SetColor(sheetBackground)
+	dc.Clear()
+
+	if captioned {
+		font, err := fonts.GetFallback(fonts.FallbackSans)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fallback font: %v", err)
+		}
+		face, err := font.GetFace(sheetCaptionFontSize, &fonts.FontStyle{
+			Weight:  fonts.WeightRegular,
+			Stretch: fonts.StretchNormal,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create font face: %v", err)
+		}
+		defer face.Close()
+		dc.SetFontFace(face.Face)
+		dc.SetColor(sheetCaptionColor)
+	}
+
+	for i, item := range items {
+		x := gap + (i%cols)*(cellWidth+gap)
+		y := gap + (i/cols)*(cellHeight+gap)
+		bounds := item.Image.Bounds()
+		dc.DrawImage(item.Image, x+(cellWidth-bounds.Dx())/2-bounds.Min.X, y-bounds.Min.Y)
+		if item.Caption != "" {
+			caption := fitCaption(dc, item.Caption, float64(cellWidth))
+			dc.DrawStringAnchored(caption, float64(x)+float64(cellWidth)/2, float64(y+imageHeight)+sheetCaptionHeight/2, 0.5, 0.35)
+		}
+	}
+	return dc.Image(), nil
+}
+
+// fitCaption returns the caption, cut short with an ellipsis if it's wider than width
+// in the context's font
+func fitCaption(dc *gg.Context, caption string, width float64) string {
+	if w, _ := dc.MeasureString(caption); w <= width {
+		return caption
+	}
+	runes := []rune(caption)
+	for n := len(runes) - 1; n > 0; n-- {
+		cut := string(runes[:n]) + "…"
+		if w, _ := dc.MeasureString(cut); w <= width {
+			return cut
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactSheet(t *testing.T) {
+	solid := func(width, height int, col color.Color) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+		return img
+	}
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	items := []SheetItem{
+		{Image: solid(100, 50, red), Caption: "monokai"},
+		{Image: solid(60, 40, blue), Caption: strings.Repeat("a very long caption ", 10)},
+		{Image: solid(100, 50, red)},
+	}
+
+	t.Run("grid", func(t *testing.T) {
+		img, err := ContactSheet(items, 2, 10)
+		require.NoError(t, err)
+		cellHeight := 50 + sheetCaptionHeight
+		assert.Equal(t, image.Rect(0, 0, 10+2*110, 10+2*(cellHeight+10)), img.Bounds())
+
+		assert.Equal(t, sheetBackground, img.At(5, 5), "Gap around the edges")
+		assert.Equal(t, red, img.At(10, 10))
+		assert.Equal(t, blue, img.At(120+20, 10), "Centered in its cell")
+		assert.Equal(t, sheetBackground, img.At(120+10, 10))
+		assert.Equal(t, red, img.At(10, 10+cellHeight+10), "Wrapped onto the next row")
+	})
+
+	t.Run("without captions", func(t *testing.T) {
+		img, err := ContactSheet(items[2:], 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 100, 50), img.Bounds())
+	})
+
+	t.Run("square", func(t *testing.T) {
+		many := make([]SheetItem, 5)
+		for i := range many {
+			many[i] = SheetItem{Image: solid(10, 10, red)}
+		}
+		img, err := ContactSheet(many, 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 30, 20), img.Bounds())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := ContactSheet(nil, 2, 10)
+		assert.Error(t, err)
+		_, err = ContactSheet([]SheetItem{{Caption: "missing"}}, 2, 10)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,227 @@
+package render
+
+import (
+	"image"
+
+	"github.com/watzon/goshot/background"
+	"golang.org/x/image/draw"
+)
+
+// FitMode is how WithAspectRatio brings the image to its aspect ratio
+type FitMode int
+
+const (
+	// FitGrow only grows the background's padding until the image has the aspect
+	// ratio, never cropping or scaling it
+	FitGrow FitMode = iota
+	// FitScale grows the padding like FitGrow, then scales the image to exactly the
+	// width and height given
+	FitScale
+)
+
+// imageSize is the size or shape of the image set with WithAspectRatio or
+// WithOutputSize
+type imageSize struct {
+	width, height int
+	fit           FitMode
+	exact         bool // Set with WithOutputSize, for an image of exactly width by height
+	unfitted      bool // Whether the image is left as it is before it's brought to the size
+}
+
+// WithAspectRatio makes the image width by height in proportion, like 1200 by 630 for
+// Open Graph cards, by growing the background's padding around the window and keeping
+// it centered. With FitGrow the image is only ever made larger, to the nearest pixel of
+// the ratio; with FitScale it's then scaled to exactly width by height pixels, whatever
+// the canvas' scale. The padding is only grown on backgrounds that can be measured and
+// padded, as all of the ones in goshot can (see background.Padder), and a canvas
+// without a background is left as it is. It replaces any size set with
+// WithOutputSize, and a width or height of zero turns it off.
+func (c *Canvas) WithAspectRatio(width, height int, fit FitMode) *Canvas {
+	c.size = nil
+	if width > 0 && height > 0 {
+		c.size = &imageSize{width: width, height: height, fit: fit}
+	}
+	return c
+}
+
+// WithOutputSize makes the image exactly width by height pixels, whatever the canvas'
+// scale, for embedding it in a slot of a fixed size. A window that fits, with the
+// background's padding and shadow around it, is centered with the padding grown to
+// fill the rest. A larger window is scaled down until it fits, with high quality
+// resampling, while the padding and shadow keep their size. Backgrounds that can't be
+// padded, and canvases without a background, are scaled down as a whole if need be
+// and centered in a transparent image. It replaces any aspect ratio set with
+// WithAspectRatio, and a width or height of zero turns it off.
+func (c *Canvas) WithOutputSize(width, height int) *Canvas {
+	c.size = nil
+	if width > 0 && height > 0 {
+		c.size = &imageSize{width: width, height: height, exact: true}
+	}
+	return c
+}
+
+// windowScale returns how much a window of the given size is scaled down to fit in
+// the output size with the background around it, which is 1 when it fits or no output
+// size is set
+func (c *Canvas) windowScale(width, height int) float64 {
+	if c.size == nil || !c.size.exact || width <= 0 || height <= 0 {
+		return 1
+	}
+	fullWidth, fullHeight := width, height
+	if m, ok := c.background.(background.Measurer); ok {
+		fullWidth, fullHeight = m.Measure(width, height)
+	}
+
+	// The padding and shadow keep their size, leaving the rest to the window
+	scale := min(1,
+		float64(c.size.width-(fullWidth-width))/float64(width),
+		float64(c.size.height-(fullHeight-height))/float64(height))
+	if scale <= 0 {
+		// Padding larger than the output size leaves the image to be scaled as a whole
+		return 1
+	}
+	return scale
+}
+
+// scaledSize returns the size of a window of the given size scaled by windowScale
+func scaledSize(width, height int, scale float64) (int, int) {
+	if scale == 1 {
+		return width, height
+	}
+	return max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+}
+
+// scaledWindow returns the window scaled down by windowScale
+func (c *Canvas) scaledWindow(img image.Image) image.Image {
+	bounds := img.Bounds()
+	scale := c.windowScale(bounds.Dx(), bounds.Dy())
+	if scale == 1 {
+		return img
+	}
+	width, height := scaledSize(bounds.Dx(), bounds.Dy(), scale)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// padded returns a copy of the canvas whose background is padded to bring a window of
+// the given size to the image's size or aspect ratio, or the canvas itself if there's
+// nothing to pad
+func (c *Canvas) padded(width, height int) *Canvas {
+	if c.size == nil {
+		return c
+	}
+	m, measures := c.background.(background.Measurer)
+	p, places := c.background.(background.Placer)
+	pd, pads := c.background.(background.Padder)
+	if !measures || !places || !pads {
+		return c
+	}
+
+	fullWidth, fullHeight := m.Measure(width, height)
+	targetWidth, targetHeight := fullWidth, fullHeight
+	switch {
+	case c.size.exact:
+		targetWidth, targetHeight = max(fullWidth, c.size.width), max(fullHeight, c.size.height)
+	case fullWidth*c.size.height < fullHeight*c.size.width:
+		targetWidth = ceilDiv(fullHeight*c.size.width, c.size.height)
+	default:
+		targetHeight = ceilDiv(fullWidth*c.size.height, c.size.width)
+	}
+
+	extraX, extraY := targetWidth-fullWidth, targetHeight-fullHeight
+	if extraX == 0 && extraY == 0 {
+		return c
+	}
+
+	// The extra space goes to whichever side centers the window, which evens out any
+	// difference between the padding on either side
+	window := p.Place(width, height)
+	left := min(max((extraX+fullWidth-window.Max.X-window.Min.X)/2, 0), extraX)
+	top := min(max((extraY+fullHeight-window.Max.Y-window.Min.Y)/2, 0), extraY)
+
+	padded := *c
+	padded.background = pd.Padded(top, extraX-left, extraY-top, left)
+	return &padded
+}
+
+// fittedBounds returns the size of the image brought to the size set with
+// WithAspectRatio or WithOutputSize from an image of the given size, and where in it
+// that image is drawn. It returns false when the image is left as it is.
+func (c *Canvas) fittedBounds(width, height int) (size image.Point, dst image.Rectangle, ok bool) {
+	if c.size == nil || c.size.unfitted || width <= 0 || height <= 0 {
+		return image.Point{}, image.Rectangle{}, false
+	}
+	size = image.Pt(c.size.width, c.size.height)
+	if width == size.X && height == size.Y {
+		return image.Point{}, image.Rectangle{}, false
+	}
+
+	switch {
+	case c.size.exact:
+		// Scaled down to fit, if need be, and centered
+		scale := min(1, float64(size.X)/float64(width), float64(size.Y)/float64(height))
+		w, h := scaledSize(width, height, scale)
+		origin := image.Pt((size.X-w)/2, (size.Y-h)/2)
+		return size, image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}, true
+	case c.size.fit == FitScale:
+		return size, image.Rectangle{Max: size}, true
+	}
+	return image.Point{}, image.Rectangle{}, false
+}
+
+// fitted returns the image brought to the size set with WithAspectRatio or
+// WithOutputSize, when it's scaled or centered to get there
+func (c *Canvas) fitted(img image.Image) image.Image {
+	if img == nil {
+		return nil
+	}
+	bounds := img.Bounds()
+	size, dst, ok := c.fittedBounds(bounds.Dx(), bounds.Dy())
+	if !ok {
+		return img
+	}
+	out := image.NewRGBA(image.Rectangle{Max: size})
+	draw.CatmullRom.Scale(out, dst, img, bounds, draw.Src, nil)
+	return out
+}
+
+// unfitted returns a copy of the canvas whose image is left as it is before it's
+// scaled or centered by fitted, or the canvas itself if there's no size to fit
+func (c *Canvas) unfitted() *Canvas {
+	if c.size == nil {
+		return c
+	}
+	u := *c
+	size := *c.size
+	size.unfitted = true
+	u.size = &size
+	return &u
+}
+
+// fittedRect returns where r, a rectangle in an image of the given size, ends up after
+// fitted
+func (c *Canvas) fittedRect(r image.Rectangle, width, height int) image.Rectangle {
+	_, dst, ok := c.fittedBounds(width, height)
+	if !ok {
+		return r
+	}
+	return mapRect(r, width, height, dst)
+}
+
+// mapRect returns where r, a rectangle in an image of the given size, ends up when the
+// image is scaled into dst
+func mapRect(r image.Rectangle, width, height int, dst image.Rectangle) image.Rectangle {
+	scale := func(v, from, to int) int {
+		return (v*to + from/2) / from
+	}
+	return image.Rect(
+		scale(r.Min.X, width, dst.Dx()), scale(r.Min.Y, height, dst.Dy()),
+		scale(r.Max.X, width, dst.Dx()), scale(r.Max.Y, height, dst.Dy()),
+	).Add(dst.Min)
+}
+
+// ceilDiv returns a divided by b, rounded up
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,134 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+)
+
+func TestWithAspectRatio(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	content := solidContent{width: 200, height: 50, color: red}
+	canvas := func(fit FitMode) *Canvas {
+		return NewCanvas().WithContent(content).
+			WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia)).
+			WithBackground(background.NewColorBackground().WithPaddingDetailed(10, 20, 30, 40)).
+			WithAspectRatio(1200, 630, fit)
+	}
+
+	t.Run("grow", func(t *testing.T) {
+		c := canvas(FitGrow)
+		img, err := c.RenderToImage()
+		require.NoError(t, err)
+
+		// Only the height grows, as the window is much wider than the ratio
+		bounds := img.Bounds()
+		assert.InDelta(t, 1200.0/630, float64(bounds.Dx())/float64(bounds.Dy()), 0.01)
+		plainWidth, plainHeight, err := canvas(FitGrow).WithAspectRatio(0, 0, FitGrow).Measure()
+		require.NoError(t, err)
+		assert.Equal(t, plainWidth, bounds.Dx())
+		assert.Greater(t, bounds.Dy(), plainHeight)
+
+		width, height, err := c.Measure()
+		require.NoError(t, err)
+		assert.Equal(t, bounds.Size(), image.Pt(width, height))
+
+		// The window is centered however the padding was set
+		rect, err := c.ContentRect()
+		require.NoError(t, err)
+		assert.Equal(t, red, img.At(rect.Min.X, rect.Min.Y+10))
+		assert.InDelta(t, rect.Min.Y, bounds.Dy()-rect.Max.Y, 40, "Space above and below the content, including the title bar")
+	})
+
+	t.Run("scale", func(t *testing.T) {
+		c := canvas(FitScale)
+		img, err := c.RenderToImage()
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 1200, 630), img.Bounds())
+
+		width, height, err := c.Measure()
+		require.NoError(t, err)
+		assert.Equal(t, 1200, width)
+		assert.Equal(t, 630, height)
+
+		rect, err := c.ContentRect()
+		require.NoError(t, err)
+		assert.True(t, rect.In(img.Bounds()))
+		assert.Greater(t, rect.Dx(), 200, "The content is scaled up along with everything else")
+		r, _, _, _ := img.At((rect.Min.X+rect.Max.X)/2, (rect.Min.Y+rect.Max.Y)/2).RGBA()
+		assert.Equal(t, uint32(0xffff), r)
+	})
+
+	t.Run("taller than the ratio", func(t *testing.T) {
+		c := NewCanvas().WithContent(solidContent{width: 50, height: 200, color: red}).
+			WithBackground(background.NewColorBackground().WithPadding(10)).
+			WithAspectRatio(2, 1, FitGrow)
+		img, err := c.RenderToImage()
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 440, 220), img.Bounds())
+
+		rect, err := c.ContentRect()
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(195, 10, 245, 210), rect)
+	})
+
+	t.Run("without a background", func(t *testing.T) {
+		img, err := NewCanvas().WithContent(content).WithAspectRatio(1, 1, FitGrow).RenderToImage()
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 200, 50), img.Bounds())
+	})
+}
+
+func TestWithOutputSize(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	padded := background.NewColorBackground().WithPadding(20)
+
+	tests := []struct {
+		name     string
+		canvas   *Canvas
+		size     image.Point
+		wantRect image.Rectangle
+	}{
+		{
+			name:     "smaller content is centered",
+			canvas:   NewCanvas().WithContent(solidContent{width: 200, height: 50, color: red}).WithBackground(padded),
+			size:     image.Pt(600, 400),
+			wantRect: image.Rect(200, 175, 400, 225),
+		},
+		{
+			name:     "larger content is scaled down inside the padding",
+			canvas:   NewCanvas().WithContent(solidContent{width: 1000, height: 500, color: red}).WithBackground(padded),
+			size:     image.Pt(400, 300),
+			wantRect: image.Rect(20, 60, 380, 240),
+		},
+		{
+			name:     "without a background",
+			canvas:   NewCanvas().WithContent(solidContent{width: 1000, height: 500, color: red}),
+			size:     image.Pt(400, 400),
+			wantRect: image.Rect(0, 100, 400, 300),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.canvas.WithOutputSize(tt.size.X, tt.size.Y)
+			img, err := c.RenderToImage()
+			require.NoError(t, err)
+			assert.Equal(t, image.Rectangle{Max: tt.size}, img.Bounds())
+
+			width, height, err := c.Measure()
+			require.NoError(t, err)
+			assert.Equal(t, tt.size, image.Pt(width, height))
+
+			rect, err := c.ContentRect()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRect, rect)
+			assert.Equal(t, red, img.At(rect.Min.X+2, rect.Min.Y+2))
+			assert.NotEqual(t, red, img.At(rect.Min.X-1, rect.Min.Y-1))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/fonts"
+	"github.com/watzon/goshot/utils"
+)
+
+// Corner identifies a corner of the image
+type Corner int
+
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+const (
+	stickerFontSize = 14
+	stickerPadding  = 10 // Space between the text and the ends of the sticker
+	stickerMargin   = 16 // Space between an unrotated sticker and the edges of the image
+	stickerRadius   = 6
+)
+
+// sticker is a text label drawn over a corner of the final image
+type sticker struct {
+	text     string
+	corner   Corner
+	color    color.Color
+	rotation float64 // Degrees
+}
+
+// WithSticker draws a label over a corner of the final image, such as "DRAFT" or
+// "DEPRECATED". With a rotation (e.g. 45 degrees) the sticker becomes a ribbon
+// running across the corner; without one it's a rounded label inset from the edges.
+// The color may be translucent, and the text is drawn in black or white, whichever
+// contrasts best with it.
+func (c *Canvas) WithSticker(text string, corner Corner, col color.Color, rotation float64) *Canvas {
+	c.sticker = &sticker{
+		text:     text,
+		corner:   corner,
+		color:    col,
+		rotation: rotation,
+	}
+	return c
+}
+
+// stickerGeometry is the placement of a sticker, centered on (cx, cy) and rotated
+// by angle degrees
+type stickerGeometry struct {
+	cx, cy, angle float64
+	width, height float64
+}
+
+// layout places the sticker on an image of the given size, given the width of its text
+// and the scale the canvas is drawn at
+func (s *sticker) layout(textWidth, width, height, scale float64) stickerGeometry {
+	g := stickerGeometry{
+		width:  textWidth + stickerPadding*2*scale,
+		height: stickerFontSize * 2.0 * scale,
+	}
+
+	// The sticker's center and the direction it is rotated in, so that ribbons
+	// always run across their corner
+	var sign float64
+	if s.rotation == 0 {
+		g.cx, g.cy = stickerMargin*scale+g.width/2, stickerMargin*scale+g.height/2
+	} else {
+		// Far enough from the corner for the text to fit on the ribbon's outer edge
+		offset := (g.width + g.height) / (2 * math.Sqrt2)
+		g.cx, g.cy = offset, offset
+		// The ribbon spans the whole image and is clipped by its edges
+		g.width = math.Hypot(width, height) * 2
+	}
+	switch s.corner {
+	case TopLeft:
+		sign = -1
+	case TopRight:
+		g.cx, sign = width-g.cx, 1
+	case BottomLeft:
+		g.cy, sign = height-g.cy, 1
+	case BottomRight:
+		g.cx, g.cy, sign = width-g.cx, height-g.cy, -1
+	}
+	g.angle = s.rotation * sign
+	return g
+}
+
+// loadStickerFace loads the face sticker text is written in, at the given scale
+func loadStickerFace(scale float64) (*fonts.Font, *fonts.Face, error) {
+	font, err := fonts.GetFallback(fonts.FallbackSans)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load fallback font: %v", err)
+	}
+	face, err := font.GetFace(stickerFontSize*scale, &fonts.FontStyle{
+		Weight:  fonts.WeightBold,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create font face: %v", err)
+	}
+	return font, face, nil
+}
+
+// drawSticker draws the sticker over img, scaled by scale
+func drawSticker(img image.Image, s *sticker, scale float64) (image.Image, error) {
+	_, face, err := loadStickerFace(scale)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	bounds := img.Bounds()
+	dc := gg.NewContext(bounds.Dx(), bounds.Dy())
+	dc.DrawImage(img, 0, 0)
+	dc.SetFontFace(face.Face)
+
+	textWidth, _ := dc.MeasureString(s.text)
+	g := s.layout(textWidth, float64(bounds.Dx()), float64(bounds.Dy()), scale)
+
+	dc.Push()
+	dc.Translate(g.cx, g.cy)
+	dc.Rotate(gg.Radians(g.angle))
+	dc.SetColor(s.color)
+	if s.rotation == 0 {
+		dc.DrawRoundedRectangle(-g.width/2, -g.height/2, g.width, g.height, stickerRadius*scale)
+	} else {
+		dc.DrawRectangle(-g.width/2, -g.height/2, g.width, g.height)
+	}
+	dc.Fill()
+
+	dc.SetColor(contrastingTextColor(s.color))
+	dc.DrawStringAnchored(s.text, 0, 0, 0.5, 0.35)
+	dc.Pop()
+
+	return dc.Image(), nil
+}
+
+// contrastingTextColor returns black or white, whichever is more legible on bg
+func contrastingTextColor(bg color.Color) color.Color {
+	if utils.Luminance(bg) > 150 {
+		return color.Black
+	}
+	return color.White
+}
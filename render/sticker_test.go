@@ -0,0 +1,88 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStickerPlacement(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	const width, height = 300, 200
+
+	tests := []struct {
+		name     string
+		corner   Corner
+		rotation float64
+		// Whether the sticker is against the left and the top edges
+		left, top bool
+		angle     float64
+	}{
+		{name: "top left", corner: TopLeft, left: true, top: true},
+		{name: "top right", corner: TopRight, top: true},
+		{name: "bottom left", corner: BottomLeft, left: true},
+		{name: "bottom right", corner: BottomRight},
+		{name: "top left ribbon", corner: TopLeft, rotation: 45, left: true, top: true, angle: -45},
+		{name: "top right ribbon", corner: TopRight, rotation: 45, top: true, angle: 45},
+		{name: "bottom left ribbon", corner: BottomLeft, rotation: 45, left: true, angle: 45},
+		{name: "bottom right ribbon", corner: BottomRight, rotation: 45, angle: -45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sticker{text: "DRAFT", corner: tt.corner, color: red, rotation: tt.rotation}
+			_, face, err := loadStickerFace(1)
+			require.NoError(t, err)
+			defer face.Close()
+			dc := gg.NewContext(1, 1)
+			dc.SetFontFace(face.Face)
+			textWidth, _ := dc.MeasureString("DRAFT")
+			g := s.layout(textWidth, width, height, 1)
+			assert.Equal(t, tt.angle, g.angle)
+
+			// The sticker sits in its corner
+			dx, dy := g.cx, g.cy
+			if !tt.left {
+				dx = width - g.cx
+			}
+			if !tt.top {
+				dy = height - g.cy
+			}
+			if tt.rotation == 0 {
+				assert.InDelta(t, stickerMargin+g.width/2, dx, 0.01)
+				assert.InDelta(t, stickerMargin+g.height/2, dy, 0.01)
+			} else {
+				assert.InDelta(t, dx, dy, 0.01)
+				assert.Less(t, dx, float64(width)/2)
+			}
+
+			bg := image.NewRGBA(image.Rect(0, 0, width, height))
+			draw.Draw(bg, bg.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+			img, err := drawSticker(bg, s, 1)
+			require.NoError(t, err)
+
+			// Either side of the text, the sticker is drawn in its color
+			sin, cos := math.Sincos(g.angle * math.Pi / 180)
+			for _, u := range []float64{-textWidth/2 - stickerPadding/2, textWidth/2 + stickerPadding/2} {
+				x, y := g.cx+u*cos, g.cy+u*sin
+				assert.Equal(t, red, img.At(int(x), int(y)), "%v,%v", x, y)
+			}
+
+			// The opposite corner is left as it was
+			x, y := 0, 0
+			if tt.left {
+				x = width - 1
+			}
+			if tt.top {
+				y = height - 1
+			}
+			assert.Equal(t, white, img.At(x, y))
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content"
+	"github.com/watzon/goshot/fonts"
+	"github.com/watzon/goshot/svg"
+)
+
+// RenderToSVG renders the canvas as an SVG document, with the same layout as
+// RenderToImage. Code is written as real, selectable text, and the chrome, solid
+// colors and linear or radial gradients are drawn as shapes. Anything without a
+// vector form, such as image backgrounds or terminal output, is embedded as an
+// image. If the chrome or background can't be drawn as SVG at all, or the image is
+// scaled to its aspect ratio with FitScale or given an output size, the whole rendered
+// image is embedded instead. A scaled canvas is drawn at its own size and scaled as a
+// whole. The fonts of the text are embedded with WithEmbeddedFont.
+func (c *Canvas) RenderToSVG() ([]byte, error) {
+	// Validate that at least one renderer is set
+	if c.chrome == nil && c.background == nil && c.content == nil {
+		return nil, fmt.Errorf("at least one renderer must be set")
+	}
+
+	// Without a vector form, the image is embedded at the canvas' scale
+	if _, ok := c.chrome.(chrome.SVGChrome); c.chrome != nil && !ok {
+		return c.rasterSVG()
+	}
+	if _, ok := c.fittedBackground().(background.SVGBackground); c.background != nil && !ok {
+		return c.rasterSVG()
+	}
+	if c.size != nil && (c.size.exact || c.size.fit == FitScale) {
+		return c.rasterSVG()
+	}
+
+	// Vector content is written out whole, so there's only its start and end to report
+	c.progress.Report(content.StageDrawing, 0)
+	unscaled := *c
+	unscaled.scale = 0
+	f, err := unscaled.svgFragment()
+	if err != nil {
+		return nil, err
+	}
+	if c.embedFont {
+		faces, err := embeddedFontFaces(f.Body)
+		if err != nil {
+			return nil, err
+		}
+		f.Body = faces + f.Body
+	}
+	c.progress.Report(content.StageCompositing, 1)
+	if scale := c.scaleFactor(); scale != 1 {
+		f = &svg.Fragment{
+			Width:  int(math.Round(float64(f.Width) * scale)),
+			Height: int(math.Round(float64(f.Height) * scale)),
+			Body:   fmt.Sprintf("<g transform=\"scale(%s)\">\n%s</g>\n", svg.Number(scale), f.Body),
+		}
+	}
+	return svg.Document(f), nil
+}
+
+// svgFragment renders the canvas, whose chrome and background can both be drawn as
+// SVG, at its own size
+func (c *Canvas) svgFragment() (*svg.Fragment, error) {
+	var f *svg.Fragment
+	var err error
+
+	// First, render the content
+	if c.content != nil {
+		if sc, ok := c.content.(content.SVGContent); ok {
+			f, err = sc.RenderSVG()
+		} else {
+			f, err = renderImageFragment(c.content.Render)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Then, apply the chrome, in the variant suiting the background
+	if ch := c.variantChrome(); ch != nil {
+		f, err = ch.(chrome.SVGChrome).RenderSVG(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Then apply the background, padded to the aspect ratio
+	if f != nil {
+		c = c.padded(f.Width, f.Height)
+	}
+	if c.background != nil {
+		f, err = c.fittedBackground().(background.SVGBackground).RenderSVG(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Finally draw any overlays on top of everything
+	if c.watermark != nil {
+		body, err := c.watermarkSVG(f.Width, f.Height)
+		if err != nil {
+			return nil, err
+		}
+		f.Body += body
+	}
+	if c.sticker != nil {
+		body, err := stickerSVG(c.sticker, f.Width, f.Height)
+		if err != nil {
+			return nil, err
+		}
+		f.Body += body
+	}
+	if len(c.annotations) > 0 {
+		body, err := c.annotationsSVG(f.Width, f.Height)
+		if err != nil {
+			return nil, err
+		}
+		f.Body += body
+	}
+
+	return f, nil
+}
+
+// rasterSVG renders the canvas as an image embedded in an SVG document
+func (c *Canvas) rasterSVG() ([]byte, error) {
+	f, err := renderImageFragment(c.RenderToImage)
+	if err != nil {
+		return nil, err
+	}
+	return svg.Document(f), nil
+}
+
+// renderImageFragment embeds the image returned by render in a fragment
+func renderImageFragment(render func() (image.Image, error)) (*svg.Fragment, error) {
+	img, err := render()
+	if err != nil {
+		return nil, err
+	}
+	return svg.Image(img)
+}
+
+// svgFontFamily returns the font-family attribute value for a font, falling back
+// to a generic family
+func svgFontFamily(font *fonts.Font, generic string) string {
+	if font == nil || font.Name == "" {
+		return generic
+	}
+	return fmt.Sprintf("'%s', %s", svg.Escape(font.Name), generic)
+}
+
+// watermarkSVG draws the tiled watermark over an image of the given size. Its color
+// depends on the image underneath, so the canvas is rendered as an image to pick it.
+func (c *Canvas) watermarkSVG(width, height int) (string, error) {
+	w := c.watermark
+	if w.text == "" || w.opacity <= 0 {
+		return "", nil
+	}
+
+	img, err := c.withoutOverlays().RenderToImage()
+	if err != nil {
+		return "", err
+	}
+
+	font, err := fonts.GetFallback(fonts.FallbackSans)
+	if err != nil {
+		return "", fmt.Errorf("failed to load fallback font: %v", err)
+	}
+	face, err := font.GetFace(watermarkFontSize, &fonts.FontStyle{
+		Weight:  fonts.WeightBold,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create font face: %v", err)
+	}
+	defer face.Close()
+
+	dc := gg.NewContext(1, 1)
+	dc.SetFontFace(face.Face)
+	textWidth, textHeight := dc.MeasureString(w.text)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<g transform=\"translate(%s %s) rotate(%s)\" font-family=\"%s\" font-size=\"%d\" font-weight=\"bold\"%s>\n",
+		svg.Number(float64(width)/2), svg.Number(float64(height)/2), svg.Number(w.angle),
+		svgFontFamily(font, "sans-serif"), watermarkFontSize, svg.Paint("fill", w.textColor(img)))
+	for _, p := range w.positions(textWidth, float64(width), float64(height), 1) {
+		fmt.Fprintf(&b, "<text x=\"%s\" y=\"%s\">%s</text>\n", svg.Number(p.X), svg.Number(p.Y+textHeight*0.35), svg.Escape(w.text))
+	}
+	b.WriteString("</g>\n")
+	return b.String(), nil
+}
+
+// annotationsSVG draws the annotations over an image of the given size. They're
+// rasterized and embedded as a transparent image, which keeps arrow heads and
+// callouts identical to RenderToImage.
+func (c *Canvas) annotationsSVG(width, height int) (string, error) {
+	dc := gg.NewContext(width, height)
+	if err := c.drawAnnotations(dc); err != nil {
+		return "", err
+	}
+	f, err := svg.Image(dc.Image())
+	if err != nil {
+		return "", err
+	}
+	return f.Body, nil
+}
+
+// withoutOverlays returns a copy of the canvas without its watermark, sticker and
+// annotations
+func (c *Canvas) withoutOverlays() *Canvas {
+	plain := *c
+	plain.watermark = nil
+	plain.sticker = nil
+	plain.annotations = nil
+	return &plain
+}
+
+// stickerSVG draws the sticker over an image of the given size
+func stickerSVG(s *sticker, width, height int) (string, error) {
+	font, face, err := loadStickerFace(1)
+	if err != nil {
+		return "", err
+	}
+	defer face.Close()
+
+	dc := gg.NewContext(1, 1)
+	dc.SetFontFace(face.Face)
+	textWidth, textHeight := dc.MeasureString(s.text)
+	g := s.layout(textWidth, float64(width), float64(height), 1)
+
+	radius := 0.0
+	if s.rotation == 0 {
+		radius = stickerRadius
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<g transform=\"translate(%s %s) rotate(%s)\">\n", svg.Number(g.cx), svg.Number(g.cy), svg.Number(g.angle))
+	b.WriteString(svg.RoundedRect(-g.width/2, -g.height/2, g.width, g.height, radius, svg.Paint("fill", s.color)))
+	fmt.Fprintf(&b, "<text y=\"%s\" text-anchor=\"middle\" font-family=\"%s\" font-size=\"%d\" font-weight=\"bold\"%s>%s</text>\n",
+		svg.Number(textHeight*0.35), svgFontFamily(font, "sans-serif"), stickerFontSize,
+		svg.Paint("fill", contrastingTextColor(s.color)), svg.Escape(s.text))
+	b.WriteString("</g>\n")
+	return b.String(), nil
+}
@@ -0,0 +1,189 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/watzon/goshot/fonts"
+	"github.com/watzon/goshot/svg"
+)
+
+// WithEmbeddedFont embeds the fonts of the text in SVG documents rendered from the
+// canvas, as @font-face rules, so that they look the same in any viewer rather than
+// falling back to the fonts installed there. Each font is cut down to the glyphs of
+// the characters drawn in it (see fonts.Font.Subset). Fonts are found by the family
+// names in the document, like GetFont finds them, so a font loaded with
+// fonts.LoadFont is only embedded once it's registered with fonts.RegisterFont.
+// Images are unaffected, as are SVG documents that embed the whole rendered image.
+func (c *Canvas) WithEmbeddedFont(enabled bool) *Canvas {
+	c.embedFont = enabled
+	return c
+}
+
+// genericFamilies are the generic CSS font families, left to the viewer
+var genericFamilies = map[string]bool{
+	"serif": true, "sans-serif": true, "monospace": true, "cursive": true,
+	"fantasy": true, "system-ui": true, "emoji": true, "math": true,
+}
+
+// svgTextStyle is the font of a run of text in an SVG document
+type svgTextStyle struct {
+	family string // Value of the font-family attribute
+	bold   bool
+	italic bool
+}
+
+// svgFontFace is a font embedded in an SVG document, along with the characters drawn
+// in it
+type svgFontFace struct {
+	font   *fonts.Font
+	family string
+	bold   bool
+	italic bool
+	text   strings.Builder
+}
+
+// embeddedFontFaces returns a <style> element with the @font-face rules embedding the
+// fonts of the text in an SVG body, or nothing if no font is found
+func embeddedFontFaces(body string) (string, error) {
+	styles, texts, err := svgTextStyles(body)
+	if err != nil {
+		return "", err
+	}
+
+	// Each character goes to the first font of the family list that has it, like the
+	// viewer picks them
+	var faces []*svgFontFace
+	found := make(map[svgTextStyle]*svgFontFace)
+	for _, style := range styles {
+		remaining := texts[style]
+		for _, family := range strings.Split(style.family, ",") {
+			family = strings.Trim(strings.TrimSpace(family), `'"`)
+			if len(remaining) == 0 || family == "" || genericFamilies[strings.ToLower(family)] {
+				continue
+			}
+			weight := fonts.WeightRegular
+			if style.bold {
+				weight = fonts.WeightBold
+			}
+			font, err := fonts.GetFont(family, &fonts.FontStyle{Weight: weight, Stretch: fonts.StretchNormal, Italic: style.italic})
+			if err != nil {
+				continue
+			}
+
+			key := svgTextStyle{family: family, bold: style.bold, italic: style.italic}
+			face := found[key]
+			if face == nil {
+				face = &svgFontFace{font: font, family: family, bold: style.bold, italic: style.italic}
+				found[key] = face
+				faces = append(faces, face)
+			}
+			var missing []rune
+			for _, r := range remaining {
+				if index, err := font.Font.GlyphIndex(nil, r); err == nil && index != 0 {
+					face.text.WriteRune(r)
+				} else {
+					missing = append(missing, r)
+				}
+			}
+			remaining = missing
+		}
+	}
+
+	var b strings.Builder
+	for _, face := range faces {
+		if face.text.Len() == 0 {
+			continue
+		}
+		data, err := face.font.Subset(face.text.String())
+		if err != nil {
+			return "", fmt.Errorf("failed to subset font %s: %v", face.family, err)
+		}
+		mime, format := "font/ttf", "truetype"
+		if bytes.HasPrefix(data, []byte("OTTO")) {
+			mime, format = "font/otf", "opentype"
+		}
+		weight, style := "normal", "normal"
+		if face.bold {
+			weight = "bold"
+		}
+		if face.italic {
+			style = "italic"
+		}
+		fmt.Fprintf(&b, "@font-face{font-family:'%s';font-weight:%s;font-style:%s;src:url(data:%s;base64,%s) format('%s')}\n",
+			svg.Escape(face.family), weight, style, mime, base64.StdEncoding.EncodeToString(data), format)
+	}
+	if b.Len() == 0 {
+		return "", nil
+	}
+	return "<defs><style>\n" + b.String() + "</style></defs>\n", nil
+}
+
+// svgTextStyles returns the fonts the text of an SVG body is drawn in, in the order
+// they're first used, and the characters drawn in each, sorted
+func svgTextStyles(body string) ([]svgTextStyle, map[svgTextStyle][]rune, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<svg>" + body + "</svg>"))
+	stack := []svgTextStyle{{}}
+	inText := 0
+	var styles []svgTextStyle
+	chars := make(map[svgTextStyle]map[rune]bool)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read SVG text: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			style := stack[len(stack)-1]
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "font-family":
+					style.family = attr.Value
+				case "font-weight":
+					weight, err := strconv.Atoi(attr.Value)
+					style.bold = attr.Value == "bold" || attr.Value == "bolder" || (err == nil && weight >= 600)
+				case "font-style":
+					style.italic = attr.Value == "italic" || attr.Value == "oblique"
+				}
+			}
+			stack = append(stack, style)
+			if t.Name.Local == "text" {
+				inText++
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			if t.Name.Local == "text" {
+				inText--
+			}
+		case xml.CharData:
+			style := stack[len(stack)-1]
+			if inText == 0 || style.family == "" {
+				continue
+			}
+			if chars[style] == nil {
+				chars[style] = make(map[rune]bool)
+				styles = append(styles, style)
+			}
+			for _, r := range string(t) {
+				chars[style][r] = true
+			}
+		}
+	}
+
+	texts := make(map[svgTextStyle][]rune, len(chars))
+	for style, set := range chars {
+		texts[style] = slices.Sorted(maps.Keys(set))
+	}
+	return styles, texts, nil
+}
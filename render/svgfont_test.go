@@ -0,0 +1,60 @@
+package render
+
+import (
+	"encoding/base64"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/content/code"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestWithEmbeddedFont(t *testing.T) {
+	canvas := func() *Canvas {
+		return NewCanvas().
+			WithChrome(chrome.NewMacChrome(chrome.MacStyleSequoia).WithTitle("main.go")).
+			WithContent(code.DefaultRenderer("x := 1").WithLanguage("go"))
+	}
+
+	plain, err := canvas().RenderToSVG()
+	require.NoError(t, err)
+	assert.NotContains(t, string(plain), "@font-face")
+
+	doc, err := canvas().WithEmbeddedFont(true).RenderToSVG()
+	require.NoError(t, err)
+	faces := regexp.MustCompile(`@font-face\{font-family:'([^']+)';font-weight:(\w+);font-style:\w+;src:url\(data:font/ttf;base64,([^)]+)\)`).
+		FindAllStringSubmatch(string(doc), -1)
+	require.Len(t, faces, 2, "The code's font and the title's bold one")
+
+	for _, face := range faces {
+		if face[1] != "JetBrainsMonoNerdFont" {
+			continue
+		}
+		assert.Equal(t, "normal", face[2])
+		data, err := base64.StdEncoding.DecodeString(face[3])
+		require.NoError(t, err)
+		font, err := sfnt.Parse(data)
+		require.NoError(t, err)
+
+		var buf sfnt.Buffer
+		outline := func(r rune) int {
+			index, err := font.GlyphIndex(&buf, r)
+			require.NoError(t, err)
+			segments, err := font.LoadGlyph(&buf, index, fixed.I(12), nil)
+			require.NoError(t, err)
+			return len(segments)
+		}
+		assert.NotZero(t, outline('x'), "Drawn in the code")
+		assert.Zero(t, outline('q'), "Not used anywhere")
+	}
+}
+
+func TestWithEmbeddedFontControlCharacters(t *testing.T) {
+	doc, err := NewCanvas().WithContent(code.DefaultRenderer("a\fb\x1bc\x00d").WithLanguage("go")).WithEmbeddedFont(true).RenderToSVG()
+	require.NoError(t, err)
+	assert.Contains(t, string(doc), "@font-face")
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+	"github.com/watzon/goshot/utils"
+)
+
+// WithAutoChromeVariant picks the light or dark variant of the chrome's theme to suit
+// the background: light over a light background and dark over a dark one, judging by
+// the background's average color. It's decided whenever the canvas is rendered, so a
+// canvas can be reused over backgrounds of different colors. Without a background, or
+// over a transparent one, the chrome is left as it is, as it is when its theme has no
+// such variant.
+func (c *Canvas) WithAutoChromeVariant() *Canvas {
+	c.autoVariant = true
+	return c
+}
+
+// variantChrome returns the chrome in the variant suiting the background, when
+// WithAutoChromeVariant is set, and otherwise the chrome itself. The chromes change
+// their variant in place, so the variant is picked on a copy: the chrome given to
+// WithChrome is left as it is, and may be shared with other canvases rendered at the
+// same time.
+func (c *Canvas) variantChrome() chrome.Chrome {
+	if !c.autoVariant || c.chrome == nil || c.background == nil {
+		return c.chrome
+	}
+	col, ok := backgroundColor(c.background)
+	if !ok {
+		return c.chrome
+	}
+
+	variant := chrome.ThemeVariantDark
+	if utils.IsLight(col) {
+		variant = chrome.ThemeVariantLight
+	}
+	if c.chrome.GetCurrentVariant() == variant {
+		return c.chrome
+	}
+	return copyChrome(c.chrome).WithVariant(variant)
+}
+
+// copyChrome returns a shallow copy of a chrome that's a pointer to a struct, as all
+// of goshot's are, and any other chrome as it is
+func copyChrome(ch chrome.Chrome) chrome.Chrome {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ch
+	}
+	copied := reflect.New(v.Elem().Type())
+	copied.Elem().Set(v.Elem())
+	return copied.Interface().(chrome.Chrome)
+}
+
+// backgroundColor returns the average color of the background drawn around a single
+// transparent pixel, or false if the background is transparent there
+func backgroundColor(bg background.Background) (color.Color, bool) {
+	img, err := bg.Render(image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	if err != nil || img.Bounds().Empty() {
+		return nil, false
+	}
+	bounds := img.Bounds()
+	if _, _, _, a := img.At(bounds.Min.X, bounds.Min.Y).RGBA(); a == 0 {
+		return nil, false
+	}
+	return averageColor(img), true
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/watzon/goshot/background"
+	"github.com/watzon/goshot/chrome"
+)
+
+func TestWithAutoChromeVariant(t *testing.T) {
+	content := solidContent{width: 100, height: 50, color: color.RGBA{R: 255, A: 255}}
+	variant := func(bg background.Background, auto bool) chrome.ThemeVariant {
+		window := chrome.NewMacChrome(chrome.MacStyleSequoia).WithVariant(chrome.ThemeVariantDark)
+		c := NewCanvas().WithContent(content).WithChrome(window)
+		if bg != nil {
+			c.WithBackground(bg)
+		}
+		if auto {
+			c.WithAutoChromeVariant()
+		}
+		_, err := c.RenderToImage()
+		require.NoError(t, err)
+		assert.Equal(t, chrome.ThemeVariantDark, window.GetCurrentVariant(), "The chrome given is left as it is")
+		return c.variantChrome().GetCurrentVariant()
+	}
+
+	white := background.NewColorBackground().WithColor(color.White)
+	black := background.NewColorBackground().WithColor(color.Black)
+	clear := background.NewColorBackground().WithColor(color.Transparent)
+
+	assert.Equal(t, chrome.ThemeVariantLight, variant(white, true))
+	assert.Equal(t, chrome.ThemeVariantDark, variant(black, true))
+	assert.Equal(t, chrome.ThemeVariantDark, variant(white, false), "Left as it is without the option")
+	assert.Equal(t, chrome.ThemeVariantDark, variant(clear, true), "Left as it is over a transparent background")
+	assert.Equal(t, chrome.ThemeVariantDark, variant(nil, true), "Left as it is without a background")
+
+	gradient := background.NewGradientBackground(background.LinearGradient,
+		background.GradientStop{Color: color.White, Position: 0},
+		background.GradientStop{Color: color.RGBA{R: 240, G: 240, B: 255, A: 255}, Position: 1})
+	assert.Equal(t, chrome.ThemeVariantLight, variant(gradient, true))
+
+	t.Run("drawn in the variant", func(t *testing.T) {
+		window := chrome.NewMacChrome(chrome.MacStyleSequoia).WithVariant(chrome.ThemeVariantDark)
+		light := chrome.NewMacChrome(chrome.MacStyleSequoia).WithVariant(chrome.ThemeVariantLight)
+		render := func(ch chrome.Chrome, auto bool) image.Image {
+			c := NewCanvas().WithContent(content).WithChrome(ch).WithBackground(white)
+			if auto {
+				c.WithAutoChromeVariant()
+			}
+			img, err := c.RenderToImage()
+			require.NoError(t, err)
+			return img
+		}
+		assert.Equal(t, render(light, false), render(window, true))
+	})
+}
+
+func TestRenderBatchSharedAutoChromeVariant(t *testing.T) {
+	content := solidContent{width: 100, height: 50, color: color.RGBA{R: 255, A: 255}}
+	window := chrome.NewMacChrome(chrome.MacStyleSequoia).WithVariant(chrome.ThemeVariantDark)
+	backgrounds := []background.Background{
+		background.NewColorBackground().WithColor(color.White),
+		background.NewColorBackground().WithColor(color.Black),
+	}
+
+	var canvases []*Canvas
+	for i := 0; i < 8; i++ {
+		canvases = append(canvases, NewCanvas().WithContent(content).WithChrome(window).
+			WithBackground(backgrounds[i%2]).WithAutoChromeVariant())
+	}
+	images, errs := RenderBatch(canvases, 8)
+	for i := range canvases {
+		require.NoError(t, errs[i])
+		assert.Equal(t, images[i%2], images[i], "Canvases over the same background look the same")
+	}
+	assert.NotEqual(t, images[0], images[1], "Light and dark chrome")
+	assert.Equal(t, chrome.ThemeVariantDark, window.GetCurrentVariant())
+}
@@ -0,0 +1,121 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/watzon/goshot/fonts"
+)
+
+const (
+	watermarkFontSize   = 16
+	watermarkColumnGap  = 48 // Horizontal space between repetitions of the text
+	watermarkRowSpacing = 72 // Vertical distance between rows of text
+)
+
+// tiledWatermark is text repeated across the whole final image
+type tiledWatermark struct {
+	text    string
+	opacity float64 // 0 to 1
+	angle   float64 // Degrees
+}
+
+// WithTiledWatermark repeats faint text across the whole final image, with the rows
+// rotated by angle degrees (e.g. -30). Unlike a sticker it can't be cropped away
+// without losing most of the image. The opacity ranges from 0 to 1, and the text is
+// drawn in black or white, whichever shows against the image.
+func (c *Canvas) WithTiledWatermark(text string, opacity float64, angle float64) *Canvas {
+	c.watermark = &tiledWatermark{
+		text:    text,
+		opacity: opacity,
+		angle:   angle,
+	}
+	return c
+}
+
+// drawTiledWatermark draws the watermark over img, scaled by scale
+func drawTiledWatermark(img image.Image, w *tiledWatermark, scale float64) (image.Image, error) {
+	if w.text == "" || w.opacity <= 0 {
+		return img, nil
+	}
+
+	font, err := fonts.GetFallback(fonts.FallbackSans)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fallback font: %v", err)
+	}
+	face, err := font.GetFace(watermarkFontSize*scale, &fonts.FontStyle{
+		Weight:  fonts.WeightBold,
+		Stretch: fonts.StretchNormal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %v", err)
+	}
+	defer face.Close()
+
+	bounds := img.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+
+	dc := gg.NewContext(bounds.Dx(), bounds.Dy())
+	dc.DrawImage(img, 0, 0)
+	dc.SetFontFace(face.Face)
+
+	dc.SetColor(w.textColor(img))
+
+	textWidth, _ := dc.MeasureString(w.text)
+	dc.Translate(width/2, height/2)
+	dc.Rotate(gg.Radians(w.angle))
+	for _, p := range w.positions(textWidth, width, height, scale) {
+		dc.DrawStringAnchored(w.text, p.X, p.Y, 0, 0.35)
+	}
+
+	return dc.Image(), nil
+}
+
+// positions returns where each repetition of the text starts, relative to the center
+// of an image of the given size and before rotating by the watermark's angle
+func (w *tiledWatermark) positions(textWidth, width, height, scale float64) []gg.Point {
+	columnWidth := textWidth + watermarkColumnGap*scale
+	rowSpacing := watermarkRowSpacing * scale
+
+	// Cover a square large enough to fill the image at any angle
+	var points []gg.Point
+	extent := math.Hypot(width, height) / 2
+	for row, y := 0, -extent; y <= extent+rowSpacing; row, y = row+1, y+rowSpacing {
+		// Every other row is shifted by half a column so the text is staggered
+		x := -extent
+		if row%2 == 1 {
+			x -= columnWidth / 2
+		}
+		for ; x <= extent; x += columnWidth {
+			points = append(points, gg.Point{X: x, Y: y})
+		}
+	}
+	return points
+}
+
+// textColor returns the color of the watermark text over img
+func (w *tiledWatermark) textColor(img image.Image) color.NRGBA {
+	n := color.NRGBAModel.Convert(contrastingTextColor(averageColor(img))).(color.NRGBA)
+	n.A = uint8(math.Round(min(w.opacity, 1) * 255))
+	return n
+}
+
+// averageColor estimates the average color of img from a grid of samples
+func averageColor(img image.Image) color.Color {
+	const samples = 16
+	bounds := img.Bounds()
+	var r, g, b, count uint64
+	for i := range samples {
+		for j := range samples {
+			x := bounds.Min.X + (2*i+1)*bounds.Dx()/(2*samples)
+			y := bounds.Min.Y + (2*j+1)*bounds.Dy()/(2*samples)
+			n := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r, g, b = r+uint64(n.R), g+uint64(n.G), b+uint64(n.B)
+			count++
+		}
+	}
+	return color.NRGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(b / count), A: 255}
+}
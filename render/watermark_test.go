@@ -0,0 +1,88 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTiledWatermark(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	newImage := func(width, height int, col color.Color) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+		return img
+	}
+
+	tests := []struct {
+		name          string
+		width, height int
+		angle         float64
+	}{
+		{name: "level", width: 400, height: 300, angle: 0},
+		{name: "tilted", width: 400, height: 300, angle: -30},
+		{name: "diagonal", width: 300, height: 600, angle: 45},
+		{name: "upright", width: 600, height: 200, angle: 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &tiledWatermark{text: "CONFIDENTIAL", opacity: 0.5, angle: tt.angle}
+			img, err := drawTiledWatermark(newImage(tt.width, tt.height, white), w, 1)
+			require.NoError(t, err)
+			require.Equal(t, image.Rect(0, 0, tt.width, tt.height), img.Bounds())
+
+			// Every corner and the middle of the image get some of the text, in a
+			// translucent black that shows on white
+			quarter := image.Pt(tt.width/4, tt.height/4)
+			for _, origin := range []image.Point{
+				{0, 0}, {tt.width - quarter.X, 0},
+				{0, tt.height - quarter.Y}, {tt.width - quarter.X, tt.height - quarter.Y},
+				{tt.width/2 - quarter.X/2, tt.height/2 - quarter.Y/2},
+			} {
+				marked := false
+				for y := origin.Y; y < origin.Y+quarter.Y && !marked; y++ {
+					for x := origin.X; x < origin.X+quarter.X && !marked; x++ {
+						r, g, b, _ := img.At(x, y).RGBA()
+						marked = r == g && g == b && r < 0xffff
+					}
+				}
+				assert.True(t, marked, "no text around %v", origin)
+			}
+
+			// The tiles cover the image at any angle
+			points := w.positions(100, float64(tt.width), float64(tt.height), 1)
+			minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+			for _, p := range points {
+				minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+				maxX, maxY = math.Max(maxX, p.X+100), math.Max(maxY, p.Y)
+			}
+			sin, cos := math.Sincos(-tt.angle * math.Pi / 180)
+			for _, corner := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				x, y := (corner[0]-0.5)*float64(tt.width), (corner[1]-0.5)*float64(tt.height)
+				rx, ry := x*cos-y*sin, x*sin+y*cos
+				assert.True(t, rx >= minX && rx <= maxX && ry >= minY && ry <= maxY, "corner %v", corner)
+			}
+		})
+	}
+
+	t.Run("white on dark images", func(t *testing.T) {
+		w := &tiledWatermark{text: "CONFIDENTIAL", opacity: 1}
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, w.textColor(newImage(10, 10, color.Black)))
+		w.opacity = 0.25
+		assert.Equal(t, color.NRGBA{A: 64}, w.textColor(newImage(10, 10, white)))
+	})
+
+	t.Run("nothing to draw", func(t *testing.T) {
+		bg := newImage(50, 50, white)
+		for _, w := range []*tiledWatermark{{text: "", opacity: 1}, {text: "DRAFT", opacity: 0}} {
+			img, err := drawTiledWatermark(bg, w, 1)
+			require.NoError(t, err)
+			assert.Same(t, bg, img)
+		}
+	})
+}
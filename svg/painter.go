@@ -0,0 +1,117 @@
+package svg
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Painter records paths as SVG elements. It has the same path methods as gg.Context,
+// so that drawing code can target either one.
+type Painter struct {
+	body      strings.Builder
+	path      strings.Builder
+	color     color.Color
+	lineWidth float64
+}
+
+// NewPainter creates a painter drawing in black with a line width of 1, like gg
+func NewPainter() *Painter {
+	return &Painter{color: color.Black, lineWidth: 1}
+}
+
+// SetColor sets the color of the following fills and strokes
+func (p *Painter) SetColor(c color.Color) {
+	p.color = c
+}
+
+// SetLineWidth sets the width of the following strokes
+func (p *Painter) SetLineWidth(width float64) {
+	p.lineWidth = width
+}
+
+// MoveTo starts a new subpath at the given point
+func (p *Painter) MoveTo(x, y float64) {
+	fmt.Fprintf(&p.path, "M%s %s", Number(x), Number(y))
+}
+
+// LineTo adds a line to the given point, starting a new subpath if there is none
+func (p *Painter) LineTo(x, y float64) {
+	if p.path.Len() == 0 {
+		p.MoveTo(x, y)
+		return
+	}
+	fmt.Fprintf(&p.path, "L%s %s", Number(x), Number(y))
+}
+
+// DrawLine adds a line between the given points
+func (p *Painter) DrawLine(x1, y1, x2, y2 float64) {
+	p.MoveTo(x1, y1)
+	p.LineTo(x2, y2)
+}
+
+// DrawRectangle adds a rectangle
+func (p *Painter) DrawRectangle(x, y, width, height float64) {
+	p.MoveTo(x, y)
+	fmt.Fprintf(&p.path, "h%sv%sh%sZ", Number(width), Number(height), Number(-width))
+}
+
+// DrawCircle adds a circle
+func (p *Painter) DrawCircle(x, y, r float64) {
+	p.MoveTo(x-r, y)
+	fmt.Fprintf(&p.path, "a%s %s 0 1 0 %s 0a%s %s 0 1 0 %s 0Z",
+		Number(r), Number(r), Number(2*r), Number(r), Number(r), Number(-2*r))
+}
+
+// DrawRoundedRectangle adds a rectangle with rounded corners
+func (p *Painter) DrawRoundedRectangle(x, y, width, height, r float64) {
+	p.MoveTo(x+r, y)
+	corner := func(dx, dy float64) string {
+		return fmt.Sprintf("a%s %s 0 0 1 %s %s", Number(r), Number(r), Number(dx), Number(dy))
+	}
+	fmt.Fprintf(&p.path, "h%s%sv%s%sh%s%sv%s%sZ",
+		Number(width-2*r), corner(r, r), Number(height-2*r), corner(-r, r),
+		Number(2*r-width), corner(-r, -r), Number(2*r-height), corner(r, -r))
+}
+
+// DrawArc adds a circular arc from angle1 to angle2, in radians. Like gg, it's joined
+// to the current subpath by a line if there is one.
+func (p *Painter) DrawArc(x, y, r, angle1, angle2 float64) {
+	// A single arc command can't draw a whole circle, so long arcs are split in two
+	if math.Abs(angle2-angle1) >= math.Pi {
+		mid := (angle1 + angle2) / 2
+		p.DrawArc(x, y, r, angle1, mid)
+		p.DrawArc(x, y, r, mid, angle2)
+		return
+	}
+	p.LineTo(x+r*math.Cos(angle1), y+r*math.Sin(angle1))
+	sweep := 0
+	if angle2 > angle1 {
+		sweep = 1
+	}
+	fmt.Fprintf(&p.path, "A%s %s 0 0 %d %s %s", Number(r), Number(r), sweep,
+		Number(x+r*math.Cos(angle2)), Number(y+r*math.Sin(angle2)))
+}
+
+// Fill fills the current path and clears it
+func (p *Painter) Fill() {
+	if p.path.Len() > 0 {
+		fmt.Fprintf(&p.body, "<path d=\"%s\"%s/>\n", p.path.String(), Paint("fill", p.color))
+	}
+	p.path.Reset()
+}
+
+// Stroke strokes the current path with round caps and joins, like gg, and clears it
+func (p *Painter) Stroke() {
+	if p.path.Len() > 0 {
+		fmt.Fprintf(&p.body, "<path d=\"%s\" fill=\"none\"%s stroke-width=\"%s\" stroke-linecap=\"round\" stroke-linejoin=\"round\"/>\n",
+			p.path.String(), Paint("stroke", p.color), Number(p.lineWidth))
+	}
+	p.path.Reset()
+}
+
+// String returns the elements drawn so far
+func (p *Painter) String() string {
+	return p.body.String()
+}
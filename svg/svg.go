@@ -0,0 +1,130 @@
+// Package svg contains the building blocks used to render images as SVG documents
+// made of real text and shapes
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Fragment is a part of an SVG document, such as the rendered content or the content
+// wrapped in its window chrome
+type Fragment struct {
+	Width  int
+	Height int
+	Body   string // SVG elements, drawn from (0, 0) in the fragment's own coordinates
+}
+
+// Document wraps a fragment in the root <svg> element of a standalone document
+func Document(f *Fragment) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		f.Width, f.Height, f.Width, f.Height)
+	b.WriteString("\n")
+	b.WriteString(f.Body)
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// Translate returns the body moved by x and y
+func Translate(body string, x, y float64) string {
+	if x == 0 && y == 0 {
+		return body
+	}
+	return fmt.Sprintf("<g transform=\"translate(%s %s)\">\n%s</g>\n", Number(x), Number(y), body)
+}
+
+// Image returns a fragment showing img, embedded as a PNG. It is the fallback for
+// anything that can't be drawn as vectors.
+func Image(img image.Image) (*Fragment, error) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %v", err)
+	}
+
+	bounds := img.Bounds()
+	return &Fragment{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Body: fmt.Sprintf("<image width=\"%d\" height=\"%d\" xlink:href=\"data:image/png;base64,%s\"/>\n",
+			bounds.Dx(), bounds.Dy(), base64.StdEncoding.EncodeToString(b.Bytes())),
+	}, nil
+}
+
+// Paint returns the attributes painting a fill or stroke (as given by attr) in c,
+// such as ` fill="#1e1e1e"`, with an opacity for translucent colors
+func Paint(attr string, c color.Color) string {
+	if c == nil {
+		return " " + attr + `="none"`
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0 {
+		return " " + attr + `="none"`
+	}
+	paint := fmt.Sprintf(` %s="#%02x%02x%02x"`, attr, n.R, n.G, n.B)
+	if n.A < 255 {
+		paint += fmt.Sprintf(` %s-opacity="%s"`, attr, Number(float64(n.A)/255))
+	}
+	return paint
+}
+
+// StopColor returns the attributes of a gradient stop of color c
+func StopColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	stop := fmt.Sprintf(` stop-color="#%02x%02x%02x"`, n.R, n.G, n.B)
+	if n.A < 255 {
+		stop += fmt.Sprintf(` stop-opacity="%s"`, Number(float64(n.A)/255))
+	}
+	return stop
+}
+
+// Number formats a coordinate or length, with at most two decimals
+func Number(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+var escaper = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;", `'`, "&#39;")
+
+// Escape escapes text for use in SVG text and attribute values. Characters XML doesn't
+// allow, like most control characters, are replaced with U+FFFD, so that each character
+// of s still has one in the result. So are the bytes of invalid UTF-8.
+func Escape(s string) string {
+	if !utf8.ValidString(s) || strings.IndexFunc(s, invalidXMLChar) >= 0 {
+		s = strings.Map(func(r rune) rune {
+			if invalidXMLChar(r) {
+				return utf8.RuneError
+			}
+			return r
+		}, s)
+	}
+	return escaper.Replace(s)
+}
+
+// invalidXMLChar reports whether r can't appear in an XML 1.0 document
+func invalidXMLChar(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return false
+	case r < 0x20, r >= 0xD800 && r <= 0xDFFF, r == 0xFFFE || r == 0xFFFF, r > utf8.MaxRune:
+		return true
+	}
+	return false
+}
+
+// RoundedRect returns a rectangle element with rounded corners and the given
+// extra attributes
+func RoundedRect(x, y, width, height, radius float64, attrs string) string {
+	rect := fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s"`, Number(x), Number(y), Number(width), Number(height))
+	if radius > 0 {
+		rect += fmt.Sprintf(` rx="%s"`, Number(radius))
+	}
+	return rect + attrs + "/>\n"
+}
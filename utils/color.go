@@ -0,0 +1,15 @@
+package utils
+
+import "image/color"
+
+// Luminance returns the perceived brightness of a color, from 0 for black to 255 for
+// white, weighing each channel by how bright it looks (ITU-R BT.601)
+func Luminance(c color.Color) float64 {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return 0.299*float64(n.R) + 0.587*float64(n.G) + 0.114*float64(n.B)
+}
+
+// IsLight reports whether a color looks light, being brighter than half of white
+func IsLight(c color.Color) bool {
+	return Luminance(c) > 128
+}